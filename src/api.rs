@@ -6,38 +6,121 @@
 //! headers, and returns `serde_json::Value`.
 
 use crate::config::Config;
-use anyhow::{bail, Result};
+use anyhow::Result;
+
+/// A non-2xx response from one of these helpers. Displays as
+/// `API error (HTTP 429 Too Many Requests): <body>`.
+#[derive(Debug)]
+pub struct ApiError {
+    pub status: u16,
+    pub reason: String,
+    pub body: String,
+    /// Time until the rate-limit window resets, from `X-RateLimit-Reset` or
+    /// `Retry-After` (both in seconds).
+    pub reset: Option<std::time::Duration>,
+}
+
+impl std::fmt::Display for ApiError {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(
+            f,
+            "API error (HTTP {} {}): {}",
+            self.status, self.reason, self.body
+        )
+    }
+}
+
+impl std::error::Error for ApiError {}
+
+/// A failed request described the way commands report it,
+/// `"{what}: {err:?}"`, keeping the `ApiError` behind it.
+#[derive(Debug)]
+struct Failed {
+    message: String,
+    api: Option<ApiError>,
+}
+
+impl std::fmt::Display for Failed {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.write_str(&self.message)
+    }
+}
+
+impl std::error::Error for Failed {}
+
+/// `err` prefixed with what failed, e.g. `failed to get monitor: API error
+/// (HTTP 404 Not Found): ...`. Unlike `anyhow!("{what}: {err:?}")`, the
+/// `ApiError` survives for `status_of` and `rate_limited`.
+pub fn failed(what: impl std::fmt::Display, err: impl Into<anyhow::Error>) -> anyhow::Error {
+    let err = err.into();
+    let message = format!("{what}: {err:?}");
+    anyhow::Error::new(Failed {
+        message,
+        api: err.downcast::<ApiError>().ok(),
+    })
+}
+
+fn api_error(err: &(dyn std::error::Error + 'static)) -> Option<&ApiError> {
+    err.downcast_ref::<ApiError>()
+        .or_else(|| err.downcast_ref::<Failed>()?.api.as_ref())
+}
+
+/// The HTTP status of a failed request, if `err` is one from this module.
+pub fn status_of(err: &(dyn std::error::Error + 'static)) -> Option<u16> {
+    api_error(err).map(|e| e.status)
+}
+
+/// The rate-limited (429) response behind `err`, if that is what failed.
+pub fn rate_limited(err: &anyhow::Error) -> Option<&ApiError> {
+    err.chain().find_map(api_error).filter(|e| e.status == 429)
+}
+
+/// Seconds from `X-RateLimit-Reset`, else `Retry-After`.
+fn reset_header(headers: &reqwest::header::HeaderMap) -> Option<std::time::Duration> {
+    ["x-ratelimit-reset", "retry-after"]
+        .iter()
+        .find_map(|name| {
+            headers
+                .get(*name)?
+                .to_str()
+                .ok()?
+                .trim()
+                .parse::<u64>()
+                .ok()
+                .map(std::time::Duration::from_secs)
+        })
+}
 
 /// Perform a GET request to a Datadog API endpoint.
 pub async fn get(cfg: &Config, path: &str, query: &[(&str, String)]) -> Result<serde_json::Value> {
     let url = format!("{}{}", cfg.api_base_url(), path);
-    let client = reqwest::Client::new();
+    let client = http_client();
     let mut req = client.get(&url);
-    req = apply_auth(req, cfg)?;
+    req = apply_auth(req, cfg, "GET", path)?;
     if !query.is_empty() {
         req = req.query(query);
     }
-    send(req).await
+    send(cfg, "GET", path, req).await
 }
 
 /// Perform a POST request with a JSON body.
 pub async fn post(cfg: &Config, path: &str, body: &serde_json::Value) -> Result<serde_json::Value> {
     let url = format!("{}{}", cfg.api_base_url(), path);
-    let client = reqwest::Client::new();
+    let client = http_client();
     let mut req = client.post(&url);
-    req = apply_auth(req, cfg)?;
+    req = apply_auth(req, cfg, "POST", path)?;
     req = req.json(body);
-    send(req).await
+    send(cfg, "POST", path, req).await
 }
 
 /// Perform a PUT request with a JSON body.
 pub async fn put(cfg: &Config, path: &str, body: &serde_json::Value) -> Result<serde_json::Value> {
     let url = format!("{}{}", cfg.api_base_url(), path);
-    let client = reqwest::Client::new();
+    let client = http_client();
     let mut req = client.put(&url);
-    req = apply_auth(req, cfg)?;
+    req = apply_auth(req, cfg, "PUT", path)?;
     req = req.json(body);
-    send(req).await
+    send(cfg, "PUT", path, req).await
 }
 
 /// Perform a PATCH request with a JSON body.
@@ -47,49 +130,148 @@ pub async fn patch(
     body: &serde_json::Value,
 ) -> Result<serde_json::Value> {
     let url = format!("{}{}", cfg.api_base_url(), path);
-    let client = reqwest::Client::new();
+    let client = http_client();
     let mut req = client.patch(&url);
-    req = apply_auth(req, cfg)?;
+    req = apply_auth(req, cfg, "PATCH", path)?;
     req = req.json(body);
-    send(req).await
+    send(cfg, "PATCH", path, req).await
 }
 
 /// Perform a DELETE request.
 pub async fn delete(cfg: &Config, path: &str) -> Result<serde_json::Value> {
     let url = format!("{}{}", cfg.api_base_url(), path);
-    let client = reqwest::Client::new();
+    let client = http_client();
     let mut req = client.delete(&url);
-    req = apply_auth(req, cfg)?;
-    send(req).await
+    req = apply_auth(req, cfg, "DELETE", path)?;
+    send(cfg, "DELETE", path, req).await
 }
 
-fn apply_auth(req: reqwest::RequestBuilder, cfg: &Config) -> Result<reqwest::RequestBuilder> {
-    if let Some(token) = &cfg.access_token {
-        Ok(req.header("Authorization", format!("Bearer {token}")))
-    } else if let (Some(api_key), Some(app_key)) = (&cfg.api_key, &cfg.app_key) {
-        Ok(req
+/// Perform a DELETE request with a JSON body (e.g. removing a relationship).
+pub async fn delete_with_body(
+    cfg: &Config,
+    path: &str,
+    body: &serde_json::Value,
+) -> Result<serde_json::Value> {
+    let url = format!("{}{}", cfg.api_base_url(), path);
+    let client = http_client();
+    let mut req = client.delete(&url);
+    req = apply_auth(req, cfg, "DELETE", path)?;
+    req = req.json(body);
+    send(cfg, "DELETE", path, req).await
+}
+
+/// GET an endpoint and return the HTTP status and `Date` header instead of
+/// the body. HTTP errors are not failures here; only a transport error is.
+/// Credentials are attached when configured, so the status reflects them.
+pub async fn probe(cfg: &Config, path: &str) -> Result<(u16, Option<String>)> {
+    let url = format!("{}{}", cfg.api_base_url(), path);
+    let mut req = http_client().get(&url);
+    if cfg.has_bearer_token() || cfg.has_api_keys() {
+        req = apply_auth(req, cfg, "GET", path)?;
+    }
+    #[cfg(not(any(feature = "browser", target_arch = "wasm32")))]
+    let resp = crate::http_debug::send(req).await;
+    #[cfg(any(feature = "browser", target_arch = "wasm32"))]
+    let resp = req.send().await;
+    let resp = resp.map_err(|e| anyhow::anyhow!("HTTP request failed: {e}"))?;
+    let date = resp
+        .headers()
+        .get("date")
+        .and_then(|v| v.to_str().ok())
+        .map(String::from);
+    Ok((resp.status().as_u16(), date))
+}
+
+/// Native builds share the client with `--timeout` applied; browser and WASI
+/// builds use reqwest's fetch-based client, which has no timeouts. Carries no
+/// credentials, so it also serves non-Datadog URLs (webhooks, presigned uploads).
+pub fn http_client() -> reqwest::Client {
+    #[cfg(not(any(feature = "browser", target_arch = "wasm32")))]
+    let client = crate::client::http_client();
+    #[cfg(any(feature = "browser", target_arch = "wasm32"))]
+    let client = reqwest::Client::new();
+    client
+}
+
+/// Whether `method path` only accepts API keys, so a configured OAuth token
+/// must not be sent.
+#[cfg(not(feature = "browser"))]
+fn keys_only(method: &str, path: &str) -> bool {
+    crate::client::requires_api_key_fallback(method, path)
+}
+
+#[cfg(feature = "browser")]
+fn keys_only(_method: &str, _path: &str) -> bool {
+    false
+}
+
+/// Attach the bearer token when there is one, else the API and application
+/// keys. Endpoints that don't accept OAuth get the keys even when a token is
+/// configured.
+fn apply_auth(
+    req: reqwest::RequestBuilder,
+    cfg: &Config,
+    method: &str,
+    path: &str,
+) -> Result<reqwest::RequestBuilder> {
+    let keys_only = keys_only(method, path);
+    match (&cfg.access_token, &cfg.api_key, &cfg.app_key) {
+        (Some(token), _, _) if !keys_only => {
+            Ok(req.header("Authorization", format!("Bearer {token}")))
+        }
+        (_, Some(api_key), Some(app_key)) => Ok(req
             .header("DD-API-KEY", api_key.as_str())
-            .header("DD-APPLICATION-KEY", app_key.as_str()))
-    } else {
-        bail!(
+            .header("DD-APPLICATION-KEY", app_key.as_str())),
+        (Some(_), _, _) => Err(crate::exit_code::Failure::auth(format!(
+            "{method} {path} does not accept OAuth tokens — set DD_API_KEY and DD_APP_KEY \
+             to use it"
+        ))),
+        _ => Err(crate::exit_code::Failure::auth(
             "authentication required: set DD_ACCESS_TOKEN for bearer auth, \
-             or set DD_API_KEY and DD_APP_KEY for API+APP key auth"
-        )
+             or set DD_API_KEY and DD_APP_KEY for API+APP key auth",
+        )),
     }
 }
 
-async fn send(req: reqwest::RequestBuilder) -> Result<serde_json::Value> {
-    let resp = req
-        .send()
-        .await
-        .map_err(|e| anyhow::anyhow!("HTTP request failed: {e}"))?;
+async fn send(
+    cfg: &Config,
+    method: &str,
+    path: &str,
+    req: reqwest::RequestBuilder,
+) -> Result<serde_json::Value> {
+    #[cfg(not(any(feature = "browser", target_arch = "wasm32")))]
+    let resp = crate::http_debug::send(req).await;
+    #[cfg(any(feature = "browser", target_arch = "wasm32"))]
+    let resp = req.send().await;
+    let resp = resp.map_err(|e| anyhow::anyhow!("HTTP request failed: {e}"))?;
     let status = resp.status();
+    let reset = reset_header(resp.headers());
     let body = resp
         .text()
         .await
         .map_err(|e| anyhow::anyhow!("failed to read response body: {e}"))?;
+    // apply_auth prefers the bearer token, so a 403 here is an OAuth 403
+    // unless the endpoint only takes keys.
+    #[cfg(not(feature = "browser"))]
+    if status == reqwest::StatusCode::FORBIDDEN
+        && cfg.has_bearer_token()
+        && !keys_only(method, path)
+    {
+        crate::auth::scopes::record_forbidden(method, path);
+    }
+    #[cfg(feature = "browser")]
+    let _ = (cfg, method, path);
     if !status.is_success() {
-        bail!("API error (HTTP {status}): {body}");
+        return Err(ApiError {
+            status: status.as_u16(),
+            reason: status
+                .canonical_reason()
+                .unwrap_or("<unknown status code>")
+                .to_string(),
+            body,
+            reset,
+        }
+        .into());
     }
     if body.is_empty() {
         return Ok(serde_json::json!({}));
@@ -121,18 +121,13 @@ async fn accept_loop(
         }
 
         let query_string = parts[1].split('?').nth(1).unwrap_or("");
-        let params: std::collections::HashMap<String, String> =
-            url::form_urlencoded::parse(query_string.as_bytes())
-                .map(|(k, v)| (k.to_string(), v.to_string()))
-                .collect();
-
-        let code = params.get("code").cloned().unwrap_or_default();
-        let state = params.get("state").cloned().unwrap_or_default();
-        let error = params.get("error").cloned();
-        let error_description = params.get("error_description").cloned();
-
-        let (status, body) = if error.is_some() {
-            ("400 Bad Request", error_page(&error, &error_description))
+        let result = parse_query(query_string);
+
+        let (status, body) = if result.error.is_some() {
+            (
+                "400 Bad Request",
+                error_page(&result.error, &result.error_description),
+            )
         } else {
             ("200 OK", success_page())
         };
@@ -142,12 +137,6 @@ async fn accept_loop(
         );
         let _ = stream.write_all(response.as_bytes()).await;
 
-        let result = CallbackResult {
-            code,
-            state,
-            error,
-            error_description,
-        };
         if let Some(tx) = result_tx.lock().unwrap().take() {
             let _ = tx.send(result);
         }
@@ -155,6 +144,37 @@ async fn accept_loop(
     }
 }
 
+/// Read the OAuth redirect parameters from a callback query string.
+#[cfg(not(target_arch = "wasm32"))]
+fn parse_query(query: &str) -> CallbackResult {
+    let params: std::collections::HashMap<String, String> =
+        url::form_urlencoded::parse(query.as_bytes())
+            .map(|(k, v)| (k.to_string(), v.to_string()))
+            .collect();
+    CallbackResult {
+        code: params.get("code").cloned().unwrap_or_default(),
+        state: params.get("state").cloned().unwrap_or_default(),
+        error: params.get("error").cloned(),
+        error_description: params.get("error_description").cloned(),
+    }
+}
+
+/// Parse the redirect URL a user copied from their browser's address bar
+/// after authorizing on another machine (`pup auth login --no-browser`).
+/// The page itself fails to load there, since nothing listens on that host's
+/// localhost, but the URL still carries the code. A bare query string works too.
+#[cfg(not(target_arch = "wasm32"))]
+pub fn parse_pasted_callback(input: &str) -> Result<CallbackResult> {
+    let input = input.trim();
+    let query = input.split_once('?').map_or(input, |(_, q)| q);
+    let query = query.split('#').next().unwrap_or_default();
+    let result = parse_query(query);
+    if result.error.is_none() && result.code.is_empty() {
+        bail!("no authorization code found; paste the full URL from the browser's address bar");
+    }
+    Ok(result)
+}
+
 #[cfg(not(target_arch = "wasm32"))]
 fn success_page() -> String {
     r#"<!DOCTYPE html>
@@ -181,3 +201,27 @@ h1{{color:#c00}}p{{color:#555}}</style></head>
 <p>Please close this window and try again.</p></div></body></html>"#
     )
 }
+
+#[cfg(all(test, not(target_arch = "wasm32")))]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_pasted_callback() {
+        let r = parse_pasted_callback(
+            "  http://127.0.0.1:8000/oauth/callback?code=abc%2F1&state=xyz#frag\n",
+        )
+        .unwrap();
+        assert_eq!(r.code, "abc/1");
+        assert_eq!(r.state, "xyz");
+        assert!(r.error.is_none());
+
+        let r = parse_pasted_callback("code=abc&state=xyz").unwrap();
+        assert_eq!(r.code, "abc");
+
+        let r = parse_pasted_callback("/oauth/callback?error=invalid_scope&state=xyz").unwrap();
+        assert_eq!(r.error.as_deref(), Some("invalid_scope"));
+
+        assert!(parse_pasted_callback("http://127.0.0.1:8000/oauth/callback").is_err());
+    }
+}
@@ -14,7 +14,6 @@ pub const DCR_CLIENT_NAME: &str = "datadog-api-claude-plugin";
 pub const DCR_REDIRECT_PORTS: &[u16] = &[8000, 8080, 8888, 9000];
 
 #[cfg(not(target_arch = "wasm32"))]
-#[allow(dead_code)]
 pub fn get_redirect_uris() -> Vec<String> {
     DCR_REDIRECT_PORTS
         .iter()
@@ -75,6 +74,7 @@ impl DcrClient {
         redirect_uri: &str,
         _scopes: &[&str],
     ) -> Result<ClientCredentials> {
+        super::sites::SiteAuth::new(&self.site).require_oauth_login()?;
         let url = format!("https://api.{}/api/v2/oauth2/register", self.site);
 
         let body = RegistrationRequest {
@@ -1,5 +1,7 @@
 pub mod callback;
 pub mod dcr;
 pub mod pkce;
+pub mod scopes;
+pub mod sites;
 pub mod storage;
 pub mod types;
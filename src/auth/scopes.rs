@@ -0,0 +1,295 @@
+//! Endpoint → OAuth scope map, used to turn a bare HTTP 403 on an OAuth
+//! session into the exact scope the token is missing.
+//!
+//! The table is maintained by hand. Entries are checked in order and the first
+//! match wins, so specific paths (and read-only POST searches) come before the
+//! broader write entries for the same prefix.
+
+use std::sync::Mutex;
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Methods {
+    /// GET and HEAD.
+    Read,
+    /// Anything but GET and HEAD.
+    Write,
+    Only(&'static str),
+}
+
+struct EndpointScope {
+    methods: Methods,
+    /// Matches the path itself and anything below it.
+    path: &'static str,
+    scope: &'static str,
+}
+
+const fn read(path: &'static str, scope: &'static str) -> EndpointScope {
+    EndpointScope {
+        methods: Methods::Read,
+        path,
+        scope,
+    }
+}
+
+const fn write(path: &'static str, scope: &'static str) -> EndpointScope {
+    EndpointScope {
+        methods: Methods::Write,
+        path,
+        scope,
+    }
+}
+
+const fn post(path: &'static str, scope: &'static str) -> EndpointScope {
+    EndpointScope {
+        methods: Methods::Only("POST"),
+        path,
+        scope,
+    }
+}
+
+static ENDPOINT_SCOPES: &[EndpointScope] = &[
+    // Monitors and downtimes
+    read("/api/v1/monitor", "monitors_read"),
+    write("/api/v1/monitor", "monitors_write"),
+    read("/api/v1/downtime", "monitors_read"),
+    write("/api/v1/downtime", "monitors_downtime"),
+    read("/api/v2/downtime", "monitors_read"),
+    write("/api/v2/downtime", "monitors_downtime"),
+    // Dashboards
+    read("/api/v1/dashboard", "dashboards_read"),
+    write("/api/v1/dashboard", "dashboards_write"),
+    // SLOs
+    read("/api/v1/slo/correction", "slos_read"),
+    write("/api/v1/slo/correction", "slos_corrections"),
+    read("/api/v1/slo", "slos_read"),
+    write("/api/v1/slo", "slos_write"),
+    // Incidents
+    read("/api/v2/incidents/config", "incident_settings_read"),
+    write("/api/v2/incidents/config", "incident_settings_write"),
+    read("/api/v2/incidents", "incident_read"),
+    write("/api/v2/incidents", "incident_write"),
+    // Synthetics
+    read(
+        "/api/v1/synthetics/variables",
+        "synthetics_global_variable_read",
+    ),
+    write(
+        "/api/v1/synthetics/variables",
+        "synthetics_global_variable_write",
+    ),
+    read(
+        "/api/v1/synthetics/private-locations",
+        "synthetics_private_location_read",
+    ),
+    write(
+        "/api/v1/synthetics/private-locations",
+        "synthetics_private_location_write",
+    ),
+    read("/api/v1/synthetics", "synthetics_read"),
+    write("/api/v1/synthetics", "synthetics_write"),
+    // Security
+    post(
+        "/api/v2/security_monitoring/signals/search",
+        "security_monitoring_signals_read",
+    ),
+    read(
+        "/api/v2/security_monitoring/signals",
+        "security_monitoring_signals_read",
+    ),
+    write(
+        "/api/v2/security_monitoring/signals",
+        "security_monitoring_signals_write",
+    ),
+    read(
+        "/api/v2/security_monitoring/rules",
+        "security_monitoring_rules_read",
+    ),
+    write(
+        "/api/v2/security_monitoring/rules",
+        "security_monitoring_rules_write",
+    ),
+    read(
+        "/api/v2/security_monitoring/configuration/suppressions",
+        "security_monitoring_suppressions_read",
+    ),
+    write(
+        "/api/v2/security_monitoring/configuration/suppressions",
+        "security_monitoring_suppressions_write",
+    ),
+    read(
+        "/api/v2/security_monitoring/configuration/security_filters",
+        "security_monitoring_filters_read",
+    ),
+    write(
+        "/api/v2/security_monitoring/configuration/security_filters",
+        "security_monitoring_filters_write",
+    ),
+    read(
+        "/api/v2/posture_management/findings",
+        "security_monitoring_findings_read",
+    ),
+    write(
+        "/api/v2/posture_management/findings",
+        "security_monitoring_findings_write",
+    ),
+    // RUM
+    read("/api/v2/rum/applications", "rum_apps_read"),
+    write("/api/v2/rum/applications", "rum_apps_write"),
+    // Logs
+    post("/api/v2/logs/events/search", "logs_read_data"),
+    post("/api/v2/logs/analytics", "logs_read_data"),
+    read("/api/v2/logs/events", "logs_read_data"),
+    read("/api/v2/logs/config/archives", "logs_read_archives"),
+    write("/api/v2/logs/config/archives", "logs_write_archives"),
+    read("/api/v2/logs/config/metrics", "logs_read_config"),
+    write("/api/v2/logs/config/metrics", "logs_generate_metrics"),
+    read("/api/v1/logs/config/pipelines", "logs_read_config"),
+    write("/api/v1/logs/config/pipelines", "logs_write_pipelines"),
+    read("/api/v1/logs/config/pipeline-order", "logs_read_config"),
+    write("/api/v1/logs/config/pipeline-order", "logs_write_pipelines"),
+    read("/api/v1/logs/config/indexes", "logs_read_config"),
+    write("/api/v1/logs/config/indexes", "logs_modify_indexes"),
+    // Metrics
+    read("/api/v1/query", "timeseries_query"),
+    post("/api/v2/query", "timeseries_query"),
+    read("/api/v1/metrics", "metrics_read"),
+    read("/api/v2/metrics", "metrics_read"),
+    // APM
+    post("/api/v2/spans/events/search", "apm_read"),
+    post("/api/v2/spans/analytics", "apm_read"),
+    read("/api/v2/spans/events", "apm_read"),
+    // Error tracking
+    post(
+        "/api/v2/error-tracking/issues/search",
+        "error_tracking_read",
+    ),
+    read("/api/v2/error-tracking/issues", "error_tracking_read"),
+    // Audit, events, hosts
+    post("/api/v2/audit/events/search", "audit_logs_read"),
+    read("/api/v2/audit/events", "audit_logs_read"),
+    read("/api/v1/events", "events_read"),
+    read("/api/v2/events", "events_read"),
+    read("/api/v1/hosts", "hosts_read"),
+    // Users and access
+    read("/api/v2/current_user", "user_self_profile_read"),
+    read("/api/v2/users", "user_access_read"),
+    write("/api/v2/users", "user_access_manage"),
+    read("/api/v2/roles", "user_access_read"),
+    write("/api/v2/roles", "user_access_manage"),
+    // Cases
+    post("/api/v2/cases/search", "cases_read"),
+    read("/api/v2/cases", "cases_read"),
+    write("/api/v2/cases", "cases_write"),
+    // Usage
+    read("/api/v1/usage", "usage_read"),
+    read("/api/v2/usage", "usage_read"),
+    // OCI integration
+    read("/api/v2/integration/oci", "oci_configuration_read"),
+    write("/api/v2/integration/oci", "oci_configuration_edit"),
+];
+
+fn method_matches(methods: Methods, method: &str) -> bool {
+    let is_read = method.eq_ignore_ascii_case("GET") || method.eq_ignore_ascii_case("HEAD");
+    match methods {
+        Methods::Read => is_read,
+        Methods::Write => !is_read,
+        Methods::Only(m) => m.eq_ignore_ascii_case(method),
+    }
+}
+
+fn path_matches(prefix: &str, path: &str) -> bool {
+    path.strip_prefix(prefix)
+        .is_some_and(|rest| rest.is_empty() || rest.starts_with('/'))
+}
+
+/// The OAuth scope an endpoint requires, if it is in the table.
+pub fn required_scope(method: &str, path: &str) -> Option<&'static str> {
+    let path = path.split('?').next().unwrap_or(path);
+    ENDPOINT_SCOPES
+        .iter()
+        .find(|e| method_matches(e.methods, method) && path_matches(e.path, path))
+        .map(|e| e.scope)
+}
+
+/// Remediation for a 403 on an OAuth session, naming the scope to re-register
+/// with. None when the endpoint is not in the table.
+pub fn hint(method: &str, path: &str) -> Option<String> {
+    let scope = required_scope(method, path)?;
+    let method = method.to_ascii_uppercase();
+    if crate::auth::types::default_scopes().contains(&scope) {
+        Some(format!(
+            "{method} {path} requires the OAuth scope {scope}. If 'pup auth scopes' shows it \
+             missing, run 'pup auth logout' then 'pup auth login' to re-register with it."
+        ))
+    } else {
+        Some(format!(
+            "{method} {path} requires the OAuth scope {scope}, which 'pup auth login' does not \
+             request. Use DD_API_KEY and DD_APP_KEY for this command."
+        ))
+    }
+}
+
+/// First 403 an OAuth request got in this invocation, as (method, path).
+static FORBIDDEN: Mutex<Option<(String, String)>> = Mutex::new(None);
+
+/// Note a 403 on a bearer-token request. Only the first one is kept: later
+/// failures are usually knock-on effects of it.
+pub fn record_forbidden(method: &str, path: &str) {
+    if let Ok(mut forbidden) = FORBIDDEN.lock() {
+        if forbidden.is_none() {
+            *forbidden = Some((method.to_string(), path.to_string()));
+        }
+    }
+}
+
+/// Hint for the recorded 403, printed after the error when a command fails.
+pub fn forbidden_hint() -> Option<String> {
+    let forbidden = FORBIDDEN.lock().ok()?.clone()?;
+    hint(&forbidden.0, &forbidden.1)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_required_scope_by_method() {
+        assert_eq!(
+            required_scope("GET", "/api/v1/monitor/123"),
+            Some("monitors_read")
+        );
+        assert_eq!(
+            required_scope("DELETE", "/api/v1/monitor/123"),
+            Some("monitors_write")
+        );
+        assert_eq!(
+            required_scope("post", "/api/v2/logs/events/search"),
+            Some("logs_read_data")
+        );
+        assert_eq!(
+            required_scope("PUT", "/api/v1/slo/correction/abc"),
+            Some("slos_corrections")
+        );
+    }
+
+    #[test]
+    fn test_required_scope_matches_whole_segments() {
+        assert_eq!(
+            required_scope("GET", "/api/v1/monitor?group_states=all"),
+            Some("monitors_read")
+        );
+        assert_eq!(required_scope("GET", "/api/v1/monitors_other"), None);
+        assert_eq!(required_scope("GET", "/api/v2/unknown"), None);
+    }
+
+    #[test]
+    fn test_hint_names_scope_and_remedy() {
+        let h = hint("POST", "/api/v1/monitor").unwrap();
+        assert!(h.contains("monitors_write"));
+        assert!(h.contains("pup auth login"));
+        let h = hint("POST", "/api/v2/security_monitoring/rules").unwrap();
+        assert!(h.contains("security_monitoring_rules_write"));
+        assert!(h.contains("DD_API_KEY"));
+        assert!(hint("GET", "/api/v2/unknown").is_none());
+    }
+}
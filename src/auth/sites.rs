@@ -0,0 +1,113 @@
+//! What each Datadog site supports for authentication. The US1-FED
+//! government site (ddog-gov.com) has no dynamic client registration, so
+//! `pup auth login` is unavailable there and API keys are the way in.
+
+use anyhow::{bail, Result};
+
+/// The US1-FED (FedRAMP) government site.
+pub const GOV_SITE: &str = "ddog-gov.com";
+
+/// Whether `site` is the government cloud, where OAuth login is unavailable.
+pub fn is_gov(site: &str) -> bool {
+    site == GOV_SITE || site.ends_with(".ddog-gov.com")
+}
+
+/// Auth capabilities of one site.
+#[derive(Debug, Clone, PartialEq)]
+pub struct SiteAuth {
+    pub site: String,
+    pub gov: bool,
+    /// `pup auth login`: OAuth2 with dynamic client registration.
+    pub oauth_login: bool,
+}
+
+impl SiteAuth {
+    pub fn new(site: &str) -> Self {
+        let gov = is_gov(site);
+        SiteAuth {
+            site: site.to_string(),
+            gov,
+            oauth_login: !gov,
+        }
+    }
+
+    /// Fail with a pointer to key auth where `pup auth login` can't work.
+    pub fn require_oauth_login(&self) -> Result<()> {
+        if !self.oauth_login {
+            bail!(
+                "OAuth login is not available on {}: the site does not support dynamic client registration.\n\
+                 Use API keys instead: set DD_API_KEY and DD_APP_KEY, or store them in a profile with\n\
+                 'pup config profiles set gov --site {} --api-key-file <file> --app-key-file <file>'.\n\
+                 Run 'pup auth capabilities' to see what this site supports.",
+                self.site,
+                self.site
+            );
+        }
+        Ok(())
+    }
+
+    /// One row per auth method for `pup auth capabilities`. `in_use` names
+    /// the method the current config authenticates with, if any.
+    pub fn method_rows(&self, in_use: Option<&str>) -> Vec<serde_json::Value> {
+        let row = |method: &str, setup: &str, supported: bool, note: &str| {
+            serde_json::json!({
+                "method": method,
+                "setup": setup,
+                "supported": supported,
+                "in_use": in_use == Some(method),
+                "note": note,
+            })
+        };
+        let oauth_note = if self.oauth_login {
+            "browser login; tokens refresh automatically"
+        } else {
+            "no dynamic client registration on this site"
+        };
+        vec![
+            row("oauth2", "pup auth login", self.oauth_login, oauth_note),
+            row(
+                "bearer_token",
+                "DD_ACCESS_TOKEN",
+                true,
+                "pre-obtained token; not refreshed by pup",
+            ),
+            row(
+                "api_keys",
+                "DD_API_KEY + DD_APP_KEY",
+                true,
+                if self.gov {
+                    "the supported method on this site"
+                } else {
+                    "required by a few endpoints that reject OAuth tokens"
+                },
+            ),
+        ]
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_gov_site_disables_dcr() {
+        let gov = SiteAuth::new("ddog-gov.com");
+        assert!(gov.gov);
+        assert!(!gov.oauth_login);
+        let err = gov.require_oauth_login().unwrap_err().to_string();
+        assert!(err.contains("DD_API_KEY"), "{err}");
+
+        let com = SiteAuth::new("datadoghq.com");
+        assert!(com.oauth_login);
+        assert!(com.require_oauth_login().is_ok());
+    }
+
+    #[test]
+    fn test_method_rows() {
+        let rows = SiteAuth::new("ddog-gov.com").method_rows(Some("api_keys"));
+        assert_eq!(rows[0]["method"], "oauth2");
+        assert_eq!(rows[0]["supported"], false);
+        assert_eq!(rows[2]["in_use"], true);
+        assert_eq!(rows[1]["in_use"], false);
+    }
+}
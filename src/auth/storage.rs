@@ -436,45 +436,177 @@ use std::sync::Mutex;
 
 static STORAGE: Mutex<Option<Box<dyn Storage>>> = Mutex::new(None);
 
+/// Backend requested with `--credential-store`; wins over env and config file.
+static PREFERRED: Mutex<Option<BackendType>> = Mutex::new(None);
+
 pub fn get_storage() -> Result<&'static Mutex<Option<Box<dyn Storage>>>> {
     let mut guard = STORAGE.lock().unwrap();
     if guard.is_none() {
-        let backend = detect_backend();
+        let backend = detect_backend()?;
         *guard = Some(backend);
     }
     drop(guard);
     Ok(&STORAGE)
 }
 
-#[cfg(not(target_arch = "wasm32"))]
-fn detect_backend() -> Box<dyn Storage> {
-    // Check DD_TOKEN_STORAGE env var
-    if let Ok(val) = std::env::var("DD_TOKEN_STORAGE") {
-        match val.as_str() {
-            "file" => return Box::new(FileStorage::new().expect("failed to create file storage")),
-            "keychain" => return Box::new(KeychainStorage::new().expect("keychain not available")),
-            _ => eprintln!("Warning: unknown DD_TOKEN_STORAGE={val:?}, auto-detecting"),
-        }
+/// Parse a credential store name. `keyring` and `keychain` are synonyms.
+pub fn parse_backend(name: &str) -> Result<BackendType> {
+    match name.trim().to_lowercase().as_str() {
+        "keyring" | "keychain" => Ok(BackendType::Keychain),
+        "file" => Ok(BackendType::File),
+        other => anyhow::bail!("unknown credential store {other:?} (use keyring or file)"),
     }
+}
 
-    // Try keychain first
-    match KeychainStorage::new() {
-        Ok(ks) => Box::new(ks),
+/// Select the storage backend explicitly. Must be called before the first
+/// `get_storage()` to take effect.
+pub fn set_preferred_backend(backend: BackendType) {
+    *PREFERRED.lock().unwrap() = Some(backend);
+}
+
+/// Backend requested by flag > DD_TOKEN_STORAGE > config `credential_store`,
+/// or None to auto-detect.
+#[cfg(not(target_arch = "wasm32"))]
+fn requested_backend() -> Option<BackendType> {
+    if let Some(backend) = *PREFERRED.lock().unwrap() {
+        return Some(backend);
+    }
+    let (source, name) = match std::env::var("DD_TOKEN_STORAGE") {
+        Ok(val) if !val.is_empty() => ("DD_TOKEN_STORAGE", val),
+        _ => ("credential_store", crate::config::file_credential_store()?),
+    };
+    match parse_backend(&name) {
+        Ok(backend) => Some(backend),
         Err(_) => {
-            eprintln!("Warning: OS keychain not available, using file storage (~/.config/pup/)");
-            Box::new(FileStorage::new().expect("failed to create file storage"))
+            eprintln!("Warning: unknown {source}={name:?}, auto-detecting");
+            None
         }
     }
 }
 
+#[cfg(not(target_arch = "wasm32"))]
+fn detect_backend() -> Result<Box<dyn Storage>> {
+    match requested_backend() {
+        Some(BackendType::File) => Ok(Box::new(FileStorage::new()?)),
+        Some(_) => Ok(Box::new(KeychainStorage::new().context(
+            "keyring credential store requested but the OS keychain is unavailable",
+        )?)),
+        None => match KeychainStorage::new() {
+            Ok(ks) => {
+                if let Some(dir) = crate::config::config_dir() {
+                    if !credential_files(&dir).is_empty() {
+                        eprintln!(
+                            "Note: credential files in {} are not used while the OS keychain \
+                             is available; run 'pup auth migrate' to move them",
+                            dir.display()
+                        );
+                    }
+                }
+                Ok(Box::new(ks))
+            }
+            Err(_) => {
+                eprintln!(
+                    "Warning: OS keychain not available, using file storage (~/.config/pup/)"
+                );
+                Ok(Box::new(FileStorage::new()?))
+            }
+        },
+    }
+}
+
 #[cfg(all(target_arch = "wasm32", not(feature = "browser")))]
-fn detect_backend() -> Box<dyn Storage> {
-    Box::new(InMemoryStorage)
+fn detect_backend() -> Result<Box<dyn Storage>> {
+    Ok(Box::new(InMemoryStorage))
 }
 
 #[cfg(feature = "browser")]
-fn detect_backend() -> Box<dyn Storage> {
-    Box::new(LocalStorageBackend)
+fn detect_backend() -> Result<Box<dyn Storage>> {
+    Ok(Box::new(LocalStorageBackend))
+}
+
+// ---------------------------------------------------------------------------
+// Migration — plaintext files written by the file backend into the keychain
+// ---------------------------------------------------------------------------
+
+/// Credential files in `dir` written by `FileStorage`, as (entry key, path).
+/// File and keychain entries share keys: `tokens_<site>` / `client_<site>`.
+#[cfg(not(target_arch = "wasm32"))]
+fn credential_files(dir: &std::path::Path) -> Vec<(String, PathBuf)> {
+    let Ok(entries) = std::fs::read_dir(dir) else {
+        return vec![];
+    };
+    let mut files: Vec<(String, PathBuf)> = entries
+        .filter_map(|e| e.ok())
+        .filter_map(|e| {
+            let name = e.file_name().to_str()?.to_string();
+            let key = name.strip_suffix(".json")?;
+            (key.starts_with("tokens_") || key.starts_with("client_"))
+                .then(|| (key.to_string(), e.path()))
+        })
+        .collect();
+    files.sort();
+    files
+}
+
+/// Merge a migrated token file into an existing keychain entry. Orgs already
+/// in the keychain are newer and win over the file copy.
+#[cfg(not(target_arch = "wasm32"))]
+fn merge_token_maps(existing: Option<&str>, incoming: &str) -> Result<String> {
+    let mut map = parse_token_map(incoming)?;
+    if let Some(existing) = existing {
+        map.extend(parse_token_map(existing)?);
+    }
+    Ok(serde_json::to_string(&map)?)
+}
+
+/// Move credential files written by the file backend into the OS keychain.
+/// With `dry_run`, only lists the files that would move. Returns their paths.
+#[cfg(not(target_arch = "wasm32"))]
+pub fn migrate_files(dry_run: bool) -> Result<Vec<PathBuf>> {
+    let dir = crate::config::config_dir().context("could not determine config directory")?;
+    let files: Vec<PathBuf> = credential_files(&dir).into_iter().map(|(_, p)| p).collect();
+    if dry_run || files.is_empty() {
+        return Ok(files);
+    }
+    let keychain = KeychainStorage::new()
+        .context("the OS keychain is unavailable; credentials stay in file storage")?;
+    keychain.import_files(&dir)?;
+    Ok(files)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+impl KeychainStorage {
+    /// Copy every file-backend credential in `dir` into the keychain, deleting
+    /// each file once its entry is stored. Returns the number of files migrated.
+    fn import_files(&self, dir: &std::path::Path) -> Result<usize> {
+        let mut migrated = 0;
+        for (key, path) in credential_files(dir) {
+            let contents = std::fs::read_to_string(&path)
+                .with_context(|| format!("failed to read {}", path.display()))?;
+            let entry = keyring::Entry::new(SERVICE_NAME, &key)?;
+            let existing = match entry.get_password() {
+                Ok(json) => Some(json),
+                Err(keyring::Error::NoEntry) => None,
+                Err(e) => return Err(e.into()),
+            };
+            let value = if key.starts_with("tokens_") {
+                Some(merge_token_maps(existing.as_deref(), &contents)?)
+            } else if existing.is_none() {
+                serde_json::from_str::<ClientCredentials>(&contents)
+                    .with_context(|| format!("unrecognised format in {}", path.display()))?;
+                Some(contents)
+            } else {
+                // Client registrations are per-site; keep the keychain copy.
+                None
+            };
+            if let Some(value) = value {
+                entry.set_password(&value)?;
+            }
+            std::fs::remove_file(&path)?;
+            migrated += 1;
+        }
+        Ok(migrated)
+    }
 }
 
 // ---------------------------------------------------------------------------
@@ -955,4 +1087,60 @@ mod tests {
         std::env::remove_var("PUP_CONFIG_DIR");
         assert!(result.is_ok());
     }
+
+    // --- Backend selection and migration ------------------------------------
+
+    #[test]
+    fn test_parse_backend() {
+        assert_eq!(parse_backend("keyring").unwrap(), BackendType::Keychain);
+        assert_eq!(parse_backend("Keychain").unwrap(), BackendType::Keychain);
+        assert_eq!(parse_backend("file").unwrap(), BackendType::File);
+        assert!(parse_backend("vault").is_err());
+    }
+
+    #[test]
+    fn test_credential_files() {
+        let tmp = TempDir::new("cred_files");
+        let store = FileStorage {
+            base_dir: tmp.path().clone(),
+        };
+        store
+            .save_tokens("datadoghq.com", None, &make_token("tok"))
+            .unwrap();
+        std::fs::write(tmp.path().join("client_datadoghq_com.json"), "{}").unwrap();
+        std::fs::write(tmp.path().join("sessions.json"), "[]").unwrap();
+        std::fs::write(tmp.path().join("config.yaml"), "").unwrap();
+
+        let keys: Vec<String> = credential_files(tmp.path())
+            .into_iter()
+            .map(|(k, _)| k)
+            .collect();
+        assert_eq!(keys, vec!["client_datadoghq_com", "tokens_datadoghq_com"]);
+    }
+
+    #[test]
+    fn test_merge_token_maps_keychain_wins() {
+        let file: OrgTokenMap = [
+            (DEFAULT_ORG_KEY.to_string(), make_token("file_default")),
+            ("prod".to_string(), make_token("file_prod")),
+        ]
+        .into_iter()
+        .collect();
+        let keychain: OrgTokenMap = [("prod".to_string(), make_token("keychain_prod"))]
+            .into_iter()
+            .collect();
+        let merged = merge_token_maps(
+            Some(&serde_json::to_string(&keychain).unwrap()),
+            &serde_json::to_string(&file).unwrap(),
+        )
+        .unwrap();
+        let merged = parse_token_map(&merged).unwrap();
+        assert_eq!(merged[DEFAULT_ORG_KEY].access_token, "file_default");
+        assert_eq!(merged["prod"].access_token, "keychain_prod");
+
+        // Legacy single-TokenSet files migrate into the map format.
+        let legacy = serde_json::to_string(&make_token("legacy")).unwrap();
+        let merged = parse_token_map(&merge_token_maps(None, &legacy).unwrap()).unwrap();
+        assert_eq!(merged[DEFAULT_ORG_KEY].access_token, "legacy");
+    }
 }
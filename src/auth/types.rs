@@ -94,6 +94,52 @@ pub fn default_scopes() -> Vec<&'static str> {
     ]
 }
 
+/// Scope groups in `default_scopes` that some orgs cannot grant. An authorize
+/// request containing one fails outright with `invalid_scope`, so login drops
+/// each group on its own, last group first, and then combinations of groups,
+/// until a request succeeds.
+pub const OPTIONAL_SCOPES: &[(&str, &[&str])] = &[
+    (
+        "OCI integration",
+        &[
+            "oci_configuration_edit",
+            "oci_configuration_read",
+            "oci_configurations_manage",
+        ],
+    ),
+    ("Error Tracking", &["error_tracking_read"]),
+];
+
+/// Scope sets to request, in order: the full default set, then the set
+/// without each optional group alone, then without two groups, and so on, so
+/// an org missing one group keeps every other optional scope.
+pub fn scope_candidates() -> Vec<Vec<&'static str>> {
+    let groups: Vec<&[&str]> = OPTIONAL_SCOPES.iter().rev().map(|(_, g)| *g).collect();
+    // Bit i of a mask drops groups[i]; masks with fewer bits come first.
+    let mut masks: Vec<u32> = (1..1u32 << groups.len()).collect();
+    masks.sort_by_key(|m| m.count_ones());
+
+    let mut candidates = vec![default_scopes()];
+    for mask in masks {
+        let mut next = default_scopes();
+        for (i, group) in groups.iter().enumerate() {
+            if mask & (1 << i) != 0 {
+                next.retain(|s| !group.contains(s));
+            }
+        }
+        candidates.push(next);
+    }
+    candidates
+}
+
+/// Default scopes missing from a reduced scope set.
+pub fn dropped_scopes(scopes: &[&str]) -> Vec<&'static str> {
+    default_scopes()
+        .into_iter()
+        .filter(|s| !scopes.contains(s))
+        .collect()
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -110,6 +156,24 @@ mod tests {
         }
     }
 
+    #[test]
+    fn test_scope_candidates_drop_each_group_alone_first() {
+        let candidates = scope_candidates();
+        assert_eq!(candidates.len(), 1 << OPTIONAL_SCOPES.len());
+        assert_eq!(candidates[0], default_scopes());
+        assert!(candidates[0].contains(&"error_tracking_read"));
+        // Error Tracking is listed last, so it is the first group dropped.
+        assert_eq!(dropped_scopes(&candidates[1]), vec!["error_tracking_read"]);
+        // Then OCI alone, keeping Error Tracking.
+        assert!(candidates[2].contains(&"error_tracking_read"));
+        assert!(!candidates[2].contains(&"oci_configuration_read"));
+        // Both groups go only once each alone has failed.
+        let last = candidates.last().unwrap();
+        assert!(!last.contains(&"oci_configuration_read"));
+        assert!(!last.contains(&"error_tracking_read"));
+        assert!(last.contains(&"monitors_read"));
+    }
+
     #[test]
     fn test_token_not_expired() {
         let token = make_token(0, 3600); // issued now, expires in 1h
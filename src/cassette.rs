@@ -0,0 +1,509 @@
+//! Record and replay API traffic (`--record` / `--replay`).
+//!
+//! Both modes run a small HTTP server on 127.0.0.1 and point `PUP_MOCK_SERVER`
+//! at it, so every request path (typed client, raw requests, WASI-style
+//! `api` calls) goes through it without knowing about cassettes:
+//!
+//! - Recording forwards each request to the real API and appends the
+//!   request/response pair to a JSON Lines cassette. Auth headers are never
+//!   written, and bodies and query strings are redacted the same way as
+//!   `--debug-http-bodies` output: secret-named JSON fields (`api_key`, `key`,
+//!   `token`, ...) are masked and the configured keys and tokens, plus any
+//!   `--secret-from-*` value, are scrubbed wherever they appear.
+//! - Replaying answers from the cassette and never opens an outbound
+//!   connection. Requests are matched on method and path+query first, then on
+//!   method and path alone (time-window queries change between runs), taking
+//!   recorded entries in order. A request with no match gets a 599 naming it.
+
+use std::path::Path;
+use std::sync::{Arc, Mutex};
+
+use anyhow::{bail, Result};
+use serde::{Deserialize, Serialize};
+use tokio::io::{AsyncReadExt, AsyncWriteExt};
+use tokio::net::{TcpListener, TcpStream};
+
+/// Status for a replayed request that is not in the cassette. Outside the
+/// range the API uses, so it cannot be mistaken for a recorded error.
+const NO_MATCH_STATUS: u16 = 599;
+
+/// Largest request head accepted by the local server.
+const MAX_HEAD_BYTES: usize = 64 * 1024;
+
+/// Request headers that describe the hop to the local server, not the request.
+const HOP_HEADERS: &[&str] = &[
+    "host",
+    "connection",
+    "content-length",
+    "accept-encoding",
+    "transfer-encoding",
+];
+
+/// One recorded request/response pair, one per cassette line.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct Interaction {
+    pub method: String,
+    /// Path and query string, e.g. `/api/v1/monitor?page=0`.
+    pub path: String,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub request_body: Option<serde_json::Value>,
+    pub status: u16,
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub content_type: Option<String>,
+    /// Parsed JSON when the body is JSON, otherwise the body as a string.
+    #[serde(default)]
+    pub response_body: serde_json::Value,
+}
+
+fn without_query(path: &str) -> &str {
+    path.split('?').next().unwrap_or(path)
+}
+
+/// Recorded interactions plus which ones replay has already served.
+pub struct Cassette {
+    entries: Vec<Interaction>,
+    used: Vec<bool>,
+}
+
+impl Cassette {
+    pub fn new(entries: Vec<Interaction>) -> Self {
+        let used = vec![false; entries.len()];
+        Cassette { entries, used }
+    }
+
+    pub fn load(path: &Path) -> Result<Self> {
+        let contents = std::fs::read_to_string(path)
+            .map_err(|e| anyhow::anyhow!("failed to read cassette {}: {e}", path.display()))?;
+        let mut entries = Vec::new();
+        for (i, line) in contents.lines().enumerate() {
+            if line.trim().is_empty() {
+                continue;
+            }
+            let entry: Interaction = serde_json::from_str(line).map_err(|e| {
+                anyhow::anyhow!("invalid cassette line {} in {}: {e}", i + 1, path.display())
+            })?;
+            entries.push(entry);
+        }
+        if entries.is_empty() {
+            bail!("cassette {} has no recorded requests", path.display());
+        }
+        Ok(Self::new(entries))
+    }
+
+    /// The response to serve for a request. Unused entries are taken in
+    /// recording order; once all matching entries are used, the last one
+    /// repeats, so polling loops keep working.
+    pub fn find(&mut self, method: &str, path: &str) -> Option<&Interaction> {
+        let exact = |e: &Interaction| e.method.eq_ignore_ascii_case(method) && e.path == path;
+        let loose = |e: &Interaction| {
+            e.method.eq_ignore_ascii_case(method) && without_query(&e.path) == without_query(path)
+        };
+        let unused = |pred: &dyn Fn(&Interaction) -> bool| {
+            (0..self.entries.len()).find(|&i| !self.used[i] && pred(&self.entries[i]))
+        };
+        let repeat = |pred: &dyn Fn(&Interaction) -> bool| {
+            (0..self.entries.len())
+                .rev()
+                .find(|&i| pred(&self.entries[i]))
+        };
+        let i = unused(&exact)
+            .or_else(|| unused(&loose))
+            .or_else(|| repeat(&exact))
+            .or_else(|| repeat(&loose))?;
+        self.used[i] = true;
+        Some(&self.entries[i])
+    }
+}
+
+/// A request read off the local socket.
+#[derive(Debug, Default, PartialEq)]
+struct RawRequest {
+    method: String,
+    target: String,
+    headers: Vec<(String, String)>,
+    body: Vec<u8>,
+}
+
+/// Method, request target, and headers from an HTTP/1.1 request head.
+fn parse_head(head: &str) -> Option<RawRequest> {
+    let mut lines = head.split("\r\n");
+    let mut start = lines.next()?.split_whitespace();
+    let method = start.next()?.to_string();
+    let target = start.next()?.to_string();
+    let headers = lines
+        .filter(|l| !l.is_empty())
+        .filter_map(|l| {
+            let (name, value) = l.split_once(':')?;
+            Some((name.trim().to_ascii_lowercase(), value.trim().to_string()))
+        })
+        .collect();
+    Some(RawRequest {
+        method,
+        target,
+        headers,
+        body: Vec::new(),
+    })
+}
+
+async fn read_request(stream: &mut TcpStream) -> Result<RawRequest> {
+    let mut buf = Vec::new();
+    let mut chunk = [0u8; 8192];
+    let head_end = loop {
+        if let Some(i) = buf.windows(4).position(|w| w == b"\r\n\r\n") {
+            break i;
+        }
+        if buf.len() > MAX_HEAD_BYTES {
+            bail!("request head too large");
+        }
+        let n = stream.read(&mut chunk).await?;
+        if n == 0 {
+            bail!("connection closed before request head");
+        }
+        buf.extend_from_slice(&chunk[..n]);
+    };
+    let head = String::from_utf8_lossy(&buf[..head_end]).to_string();
+    let Some(mut req) = parse_head(&head) else {
+        bail!("malformed request head");
+    };
+    let length: usize = req
+        .headers
+        .iter()
+        .find(|(k, _)| k == "content-length")
+        .and_then(|(_, v)| v.parse().ok())
+        .unwrap_or(0);
+    let mut body = buf[head_end + 4..].to_vec();
+    while body.len() < length {
+        let n = stream.read(&mut chunk).await?;
+        if n == 0 {
+            break;
+        }
+        body.extend_from_slice(&chunk[..n]);
+    }
+    body.truncate(length);
+    req.body = body;
+    Ok(req)
+}
+
+async fn write_response(
+    stream: &mut TcpStream,
+    status: u16,
+    content_type: Option<&str>,
+    body: &[u8],
+) -> Result<()> {
+    let mut head = format!(
+        "HTTP/1.1 {status} {}\r\nContent-Length: {}\r\nConnection: close\r\n",
+        reason(status),
+        body.len()
+    );
+    if let Some(ct) = content_type {
+        head.push_str(&format!("Content-Type: {ct}\r\n"));
+    }
+    head.push_str("\r\n");
+    stream.write_all(head.as_bytes()).await?;
+    stream.write_all(body).await?;
+    stream.flush().await?;
+    Ok(())
+}
+
+fn reason(status: u16) -> &'static str {
+    reqwest::StatusCode::from_u16(status)
+        .ok()
+        .and_then(|s| s.canonical_reason())
+        .unwrap_or("Unknown")
+}
+
+/// A recorded body with secrets removed, as stored in the cassette.
+fn redacted_value(bytes: &[u8], secrets: &[String]) -> serde_json::Value {
+    if bytes.is_empty() {
+        return serde_json::Value::Null;
+    }
+    body_value(crate::http_debug::redact(bytes, secrets).as_bytes())
+}
+
+/// A body as stored in the cassette: JSON when it parses, else a string.
+fn body_value(bytes: &[u8]) -> serde_json::Value {
+    if bytes.is_empty() {
+        return serde_json::Value::Null;
+    }
+    serde_json::from_slice(bytes)
+        .unwrap_or_else(|_| serde_json::Value::String(String::from_utf8_lossy(bytes).into()))
+}
+
+/// Bytes to send for a stored body.
+fn body_bytes(value: &serde_json::Value) -> Vec<u8> {
+    match value {
+        serde_json::Value::Null => Vec::new(),
+        serde_json::Value::String(s) => s.clone().into_bytes(),
+        other => other.to_string().into_bytes(),
+    }
+}
+
+async fn bind() -> Result<(TcpListener, String)> {
+    let listener = TcpListener::bind(("127.0.0.1", 0))
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to start local cassette server: {e}"))?;
+    let addr = listener.local_addr()?;
+    Ok((listener, format!("http://{addr}")))
+}
+
+/// Serve `path` and return the local base URL to use as `PUP_MOCK_SERVER`.
+pub async fn start_replay(path: &Path) -> Result<String> {
+    let cassette = Arc::new(Mutex::new(Cassette::load(path)?));
+    let (listener, base) = bind().await?;
+    tokio::spawn(async move {
+        while let Ok((mut stream, _)) = listener.accept().await {
+            let cassette = cassette.clone();
+            tokio::spawn(async move {
+                let Ok(req) = read_request(&mut stream).await else {
+                    return;
+                };
+                let found = cassette
+                    .lock()
+                    .ok()
+                    .and_then(|mut c| c.find(&req.method, &req.target).cloned());
+                let _ = match found {
+                    Some(entry) => {
+                        write_response(
+                            &mut stream,
+                            entry.status,
+                            entry.content_type.as_deref(),
+                            &body_bytes(&entry.response_body),
+                        )
+                        .await
+                    }
+                    None => {
+                        let body = serde_json::json!({
+                            "errors": [format!(
+                                "pup replay: no recorded response for {} {}",
+                                req.method, req.target
+                            )]
+                        });
+                        write_response(
+                            &mut stream,
+                            NO_MATCH_STATUS,
+                            Some("application/json"),
+                            body.to_string().as_bytes(),
+                        )
+                        .await
+                    }
+                };
+            });
+        }
+    });
+    Ok(base)
+}
+
+async fn forward(
+    client: &reqwest::Client,
+    upstream: &str,
+    req: &RawRequest,
+) -> Result<(u16, Option<String>, Vec<u8>)> {
+    let method = reqwest::Method::from_bytes(req.method.as_bytes())
+        .map_err(|_| anyhow::anyhow!("unsupported method {}", req.method))?;
+    let mut out = client.request(method, format!("{upstream}{}", req.target));
+    for (name, value) in &req.headers {
+        if !HOP_HEADERS.contains(&name.as_str()) {
+            out = out.header(name.as_str(), value.as_str());
+        }
+    }
+    let resp = out.body(req.body.clone()).send().await?;
+    let status = resp.status().as_u16();
+    let content_type = resp
+        .headers()
+        .get("content-type")
+        .and_then(|v| v.to_str().ok())
+        .map(String::from);
+    let body = resp.bytes().await?.to_vec();
+    Ok((status, content_type, body))
+}
+
+/// Proxy to `upstream`, appending every exchange to `path` (created or
+/// truncated) with `secrets` and secret fields redacted. Returns the local
+/// base URL to use as `PUP_MOCK_SERVER`.
+pub async fn start_record(path: &Path, upstream: String, secrets: Vec<String>) -> Result<String> {
+    let file = std::fs::File::create(path)
+        .map_err(|e| anyhow::anyhow!("failed to create cassette {}: {e}", path.display()))?;
+    let file = Arc::new(Mutex::new(file));
+    let client = reqwest::Client::new();
+    let (listener, base) = bind().await?;
+    tokio::spawn(async move {
+        while let Ok((mut stream, _)) = listener.accept().await {
+            let (file, client, upstream) = (file.clone(), client.clone(), upstream.clone());
+            let mut secrets = secrets.clone();
+            tokio::spawn(async move {
+                let Ok(req) = read_request(&mut stream).await else {
+                    return;
+                };
+                let (status, content_type, body) = match forward(&client, &upstream, &req).await {
+                    Ok(resp) => resp,
+                    Err(e) => {
+                        let body = serde_json::json!({ "errors": [format!("pup record: {e}")] });
+                        let _ = write_response(
+                            &mut stream,
+                            502,
+                            Some("application/json"),
+                            body.to_string().as_bytes(),
+                        )
+                        .await;
+                        return;
+                    }
+                };
+                // Secrets read after recording started (--secret-from-*) too.
+                secrets.extend(crate::http_debug::secrets());
+                let entry = Interaction {
+                    method: req.method.clone(),
+                    path: crate::http_debug::scrub(
+                        &crate::http_debug::redact_url(&req.target),
+                        &secrets,
+                    ),
+                    request_body: Some(redacted_value(&req.body, &secrets))
+                        .filter(|b| !b.is_null()),
+                    status,
+                    content_type: content_type.clone(),
+                    response_body: redacted_value(&body, &secrets),
+                };
+                if let (Ok(mut f), Ok(line)) = (file.lock(), serde_json::to_string(&entry)) {
+                    use std::io::Write;
+                    let _ = writeln!(f, "{line}").and_then(|_| f.flush());
+                }
+                let _ = write_response(&mut stream, status, content_type.as_deref(), &body).await;
+            });
+        }
+    });
+    Ok(base)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn entry(method: &str, path: &str, body: serde_json::Value) -> Interaction {
+        Interaction {
+            method: method.into(),
+            path: path.into(),
+            request_body: None,
+            status: 200,
+            content_type: Some("application/json".into()),
+            response_body: body,
+        }
+    }
+
+    #[test]
+    fn test_find_prefers_exact_then_path_then_repeats() {
+        let mut c = Cassette::new(vec![
+            entry("GET", "/api/v1/monitor?page=0", serde_json::json!([1])),
+            entry("GET", "/api/v1/monitor?page=1", serde_json::json!([2])),
+            entry(
+                "POST",
+                "/api/v2/logs/events/search",
+                serde_json::json!({"n": 1}),
+            ),
+        ]);
+        let body =
+            |c: &mut Cassette, m: &str, p: &str| c.find(m, p).map(|e| e.response_body.clone());
+        assert_eq!(
+            body(&mut c, "GET", "/api/v1/monitor?page=1"),
+            Some(serde_json::json!([2]))
+        );
+        // Different query: first unused entry for the path.
+        assert_eq!(
+            body(&mut c, "get", "/api/v1/monitor?page=7"),
+            Some(serde_json::json!([1]))
+        );
+        // All used: the last recorded match repeats.
+        assert_eq!(
+            body(&mut c, "GET", "/api/v1/monitor?page=1"),
+            Some(serde_json::json!([2]))
+        );
+        assert!(c.find("DELETE", "/api/v1/monitor/1").is_none());
+        assert!(c.find("GET", "/api/v2/logs/events/search").is_none());
+    }
+
+    #[test]
+    fn test_parse_head() {
+        let req = parse_head(
+            "POST /api/v2/logs/events/search?x=1 HTTP/1.1\r\nHost: 127.0.0.1\r\nContent-Length: 2",
+        )
+        .unwrap();
+        assert_eq!(req.method, "POST");
+        assert_eq!(req.target, "/api/v2/logs/events/search?x=1");
+        assert_eq!(
+            req.headers,
+            vec![
+                ("host".to_string(), "127.0.0.1".to_string()),
+                ("content-length".to_string(), "2".to_string())
+            ]
+        );
+        assert!(parse_head("").is_none());
+    }
+
+    #[test]
+    fn test_redacted_value() {
+        let secrets = vec!["sekrit-api-key".to_string()];
+        let body =
+            br#"{"data":{"attributes":{"name":"ci","key":"abc123","note":"sekrit-api-key"}}}"#;
+        assert_eq!(
+            redacted_value(body, &secrets),
+            serde_json::json!({"data": {"attributes": {
+                "name": "ci", "key": "[REDACTED]", "note": "[REDACTED]"
+            }}})
+        );
+        assert_eq!(
+            redacted_value(b"token sekrit-api-key", &secrets),
+            serde_json::json!("token [REDACTED]")
+        );
+        assert_eq!(redacted_value(b"", &secrets), serde_json::Value::Null);
+    }
+
+    #[test]
+    fn test_body_round_trip() {
+        assert_eq!(body_value(b""), serde_json::Value::Null);
+        assert_eq!(body_value(br#"{"a":1}"#), serde_json::json!({"a": 1}));
+        assert_eq!(body_value(b"plain"), serde_json::json!("plain"));
+        assert_eq!(body_bytes(&serde_json::json!("plain")), b"plain");
+        assert_eq!(body_bytes(&serde_json::json!({"a": 1})), br#"{"a":1}"#);
+        assert!(body_bytes(&serde_json::Value::Null).is_empty());
+    }
+
+    #[test]
+    fn test_cassette_lines_round_trip() {
+        let e = entry(
+            "GET",
+            "/api/v1/validate",
+            serde_json::json!({"valid": true}),
+        );
+        let line = serde_json::to_string(&e).unwrap();
+        assert!(!line.contains("request_body"));
+        assert_eq!(serde_json::from_str::<Interaction>(&line).unwrap(), e);
+    }
+
+    #[tokio::test]
+    async fn test_replay_serves_recorded_response() {
+        let path = std::env::temp_dir().join(format!("pup_cassette_{}.jsonl", std::process::id()));
+        let e = entry(
+            "GET",
+            "/api/v1/validate",
+            serde_json::json!({"valid": true}),
+        );
+        std::fs::write(&path, serde_json::to_string(&e).unwrap() + "\n").unwrap();
+        let base = start_replay(&path).await.unwrap();
+        let client = reqwest::Client::new();
+
+        let resp = client
+            .get(format!("{base}/api/v1/validate"))
+            .send()
+            .await
+            .unwrap();
+        assert_eq!(resp.status().as_u16(), 200);
+        let body: serde_json::Value = resp.json().await.unwrap();
+        assert_eq!(body, serde_json::json!({"valid": true}));
+
+        let resp = client
+            .get(format!("{base}/api/v1/monitor"))
+            .send()
+            .await
+            .unwrap();
+        assert_eq!(resp.status().as_u16(), NO_MATCH_STATUS);
+        let _ = std::fs::remove_file(&path);
+    }
+}
@@ -0,0 +1,261 @@
+//! Checkpoint files for bulk destructive operations.
+//!
+//! A bulk run records its full target list once, then appends each ID as it
+//! completes, rewriting the file atomically after every item. An interrupted
+//! run (Ctrl-C, rate limiting, an expired token) picks up with `--resume <file>`
+//! without scanning or confirming again; the file is removed once every
+//! target is done.
+
+use std::path::{Path, PathBuf};
+
+use anyhow::{bail, Result};
+use serde::{Deserialize, Serialize};
+
+use crate::exit_code::status_code;
+
+/// Attempts per item before a rate-limited or transient failure stops the run.
+const MAX_ATTEMPTS: u32 = 5;
+/// Delay before the first retry; doubled for each further attempt.
+const RETRY_BASE_DELAY_SECS: u64 = 2;
+
+#[derive(Debug, Serialize, Deserialize)]
+pub struct Checkpoint {
+    pub operation: String,
+    pub created_at: String,
+    pub targets: Vec<String>,
+    pub completed: Vec<String>,
+    #[serde(skip)]
+    path: PathBuf,
+}
+
+impl Checkpoint {
+    /// Start a checkpoint for `targets`; an existing file is never overwritten.
+    pub fn create(path: &Path, operation: &str, targets: Vec<String>) -> Result<Self> {
+        if path.exists() {
+            bail!(
+                "checkpoint {} already exists; pass --resume {} to continue that run or remove it",
+                path.display(),
+                path.display()
+            );
+        }
+        let cp = Checkpoint {
+            operation: operation.to_string(),
+            created_at: chrono::Utc::now().to_rfc3339(),
+            targets,
+            completed: Vec::new(),
+            path: path.to_path_buf(),
+        };
+        cp.save()?;
+        Ok(cp)
+    }
+
+    /// Load a checkpoint written by the same operation.
+    pub fn load(path: &Path, operation: &str) -> Result<Self> {
+        let contents = std::fs::read_to_string(path)
+            .map_err(|e| anyhow::anyhow!("failed to read checkpoint {}: {e}", path.display()))?;
+        let mut cp: Checkpoint = serde_json::from_str(&contents)
+            .map_err(|e| anyhow::anyhow!("invalid checkpoint {}: {e}", path.display()))?;
+        if cp.operation != operation {
+            bail!(
+                "checkpoint {} belongs to {:?}, not {operation:?}",
+                path.display(),
+                cp.operation
+            );
+        }
+        cp.path = path.to_path_buf();
+        Ok(cp)
+    }
+
+    /// Targets not yet completed, in their original order.
+    pub fn remaining(&self) -> Vec<String> {
+        let done: std::collections::HashSet<&str> =
+            self.completed.iter().map(String::as_str).collect();
+        self.targets
+            .iter()
+            .filter(|t| !done.contains(t.as_str()))
+            .cloned()
+            .collect()
+    }
+
+    pub fn mark_done(&mut self, id: &str) -> Result<()> {
+        self.completed.push(id.to_string());
+        self.save()
+    }
+
+    /// Remove the file once the run has finished.
+    pub fn finish(self) -> Result<()> {
+        std::fs::remove_file(&self.path).map_err(|e| {
+            anyhow::anyhow!("failed to remove checkpoint {}: {e}", self.path.display())
+        })
+    }
+
+    /// Write to a sibling temp file and rename it over the checkpoint, so an
+    /// interruption mid-write never leaves a truncated file behind.
+    fn save(&self) -> Result<()> {
+        let mut tmp = self.path.clone().into_os_string();
+        tmp.push(".tmp");
+        let tmp = PathBuf::from(tmp);
+        let json = serde_json::to_string_pretty(self)?;
+        std::fs::write(&tmp, json)
+            .and_then(|_| std::fs::rename(&tmp, &self.path))
+            .map_err(|e| anyhow::anyhow!("failed to write checkpoint {}: {e}", self.path.display()))
+    }
+}
+
+/// How one target ended up.
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum ItemOutcome {
+    Done,
+    /// The target was already gone (404), which counts as done.
+    AlreadyGone,
+}
+
+fn is_retryable(err: &str) -> bool {
+    let lower = err.to_lowercase();
+    [
+        "timed out",
+        "timeout",
+        "connection reset",
+        "connection closed",
+    ]
+    .iter()
+    .any(|p| lower.contains(p))
+        || matches!(status_code(err), Some(408 | 429 | 500..=599))
+}
+
+/// Run `op` for every remaining target, checkpointing after each one.
+/// Rate-limited and transient failures are retried with backoff; anything
+/// else (or running out of attempts) stops the run with a resume hint.
+pub async fn run<F, Fut>(cp: &mut Checkpoint, mut op: F) -> Result<Vec<(String, ItemOutcome)>>
+where
+    F: FnMut(String) -> Fut,
+    Fut: std::future::Future<Output = Result<()>>,
+{
+    let remaining = cp.remaining();
+    let total = cp.targets.len();
+    let mut results = Vec::with_capacity(remaining.len());
+    for id in remaining {
+        let mut attempt = 1;
+        let outcome = loop {
+            let err = match op(id.clone()).await {
+                Ok(()) => break ItemOutcome::Done,
+                Err(e) => format!("{e:?}"),
+            };
+            if status_code(&err) == Some(404) {
+                break ItemOutcome::AlreadyGone;
+            }
+            if attempt >= MAX_ATTEMPTS || !is_retryable(&err) || !can_wait() {
+                bail!(
+                    "stopped after {} of {total} ({id}): {err}\nresume with --resume {}",
+                    cp.completed.len(),
+                    cp.path.display()
+                );
+            }
+            let delay = RETRY_BASE_DELAY_SECS * 2u64.pow(attempt - 1);
+            eprintln!("warning: {id} failed ({attempt}/{MAX_ATTEMPTS}); retrying in {delay}s");
+            wait_secs(delay).await;
+            attempt += 1;
+        };
+        cp.mark_done(&id)?;
+        eprintln!("[{}/{total}] {id}", cp.completed.len());
+        results.push((id, outcome));
+    }
+    Ok(results)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+fn can_wait() -> bool {
+    true
+}
+
+#[cfg(target_arch = "wasm32")]
+fn can_wait() -> bool {
+    false
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn wait_secs(secs: u64) {
+    tokio::time::sleep(std::time::Duration::from_secs(secs)).await;
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn wait_secs(_secs: u64) {}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn temp_path(name: &str) -> PathBuf {
+        let p = std::env::temp_dir().join(format!("pup_checkpoint_{name}_{}", std::process::id()));
+        let _ = std::fs::remove_file(&p);
+        p
+    }
+
+    #[test]
+    fn test_checkpoint_round_trip() {
+        let path = temp_path("round_trip");
+        let ids = vec!["1".to_string(), "2".to_string(), "3".to_string()];
+        let mut cp = Checkpoint::create(&path, "monitors bulk-delete", ids.clone()).unwrap();
+        cp.mark_done("2").unwrap();
+        assert!(Checkpoint::create(&path, "monitors bulk-delete", ids).is_err());
+        assert!(Checkpoint::load(&path, "dashboards bulk-delete").is_err());
+
+        let cp = Checkpoint::load(&path, "monitors bulk-delete").unwrap();
+        assert_eq!(cp.remaining(), vec!["1", "3"]);
+        cp.finish().unwrap();
+        assert!(!path.exists());
+    }
+
+    #[test]
+    fn test_status_code_and_retryable() {
+        assert_eq!(status_code("API error (HTTP 404 Not Found): {}"), Some(404));
+        assert_eq!(
+            status_code("ResponseContent { status: 429, .. }"),
+            Some(429)
+        );
+        assert!(is_retryable(
+            "API error (HTTP 429 Too Many Requests): slow down"
+        ));
+        assert!(is_retryable("operation timed out"));
+        assert!(!is_retryable(
+            "API error (HTTP 403 Forbidden): token expired"
+        ));
+    }
+
+    #[tokio::test]
+    async fn test_run_stops_with_resume_hint_and_resumes() {
+        let path = temp_path("run");
+        let ids: Vec<String> = ["1", "2", "3"].iter().map(|s| s.to_string()).collect();
+        let mut cp = Checkpoint::create(&path, "op", ids).unwrap();
+        let err = run(&mut cp, |id| async move {
+            match id.as_str() {
+                "2" => bail!("API error (HTTP 403 Forbidden): expired"),
+                _ => Ok(()),
+            }
+        })
+        .await
+        .unwrap_err()
+        .to_string();
+        assert!(err.contains("stopped after 1 of 3 (2)"), "{err}");
+        assert!(err.contains("--resume"));
+
+        let mut cp = Checkpoint::load(&path, "op").unwrap();
+        let results = run(&mut cp, |id| async move {
+            match id.as_str() {
+                "3" => bail!("API error (HTTP 404 Not Found): gone"),
+                _ => Ok(()),
+            }
+        })
+        .await
+        .unwrap();
+        assert_eq!(
+            results,
+            vec![
+                ("2".to_string(), ItemOutcome::Done),
+                ("3".to_string(), ItemOutcome::AlreadyGone)
+            ]
+        );
+        assert!(cp.remaining().is_empty());
+        cp.finish().unwrap();
+    }
+}
@@ -3,6 +3,8 @@ use async_trait::async_trait;
 #[cfg(not(target_arch = "wasm32"))]
 use reqwest_middleware::{ClientBuilder, ClientWithMiddleware, Middleware, Next};
 #[cfg(not(target_arch = "wasm32"))]
+use std::time::Duration;
+#[cfg(not(target_arch = "wasm32"))]
 use task_local_extensions::Extensions;
 
 use crate::config::Config;
@@ -29,20 +31,126 @@ impl Middleware for BearerAuthMiddleware {
             reqwest::header::AUTHORIZATION,
             format!("Bearer {}", self.token).parse().unwrap(),
         );
-        next.run(req, extensions).await
+        let method = req.method().to_string();
+        let path = req.url().path().to_string();
+        let resp = next.run(req, extensions).await?;
+        if resp.status() == reqwest::StatusCode::FORBIDDEN {
+            crate::auth::scopes::record_forbidden(&method, &path);
+        }
+        Ok(resp)
     }
 }
 
+// ---------------------------------------------------------------------------
+// Request stats middleware (native only)
+// ---------------------------------------------------------------------------
+
+/// Records each request for the `--stats` footer.
+#[cfg(not(target_arch = "wasm32"))]
+struct StatsMiddleware;
+
+#[cfg(not(target_arch = "wasm32"))]
+#[async_trait]
+impl Middleware for StatsMiddleware {
+    async fn handle(
+        &self,
+        req: reqwest::Request,
+        extensions: &mut Extensions,
+        next: Next<'_>,
+    ) -> reqwest_middleware::Result<reqwest::Response> {
+        let sent = req
+            .body()
+            .and_then(|b| b.as_bytes())
+            .map_or(0, |b| b.len() as u64);
+        let resp = next.run(req, extensions).await?;
+        crate::stats::record_response(sent, &resp);
+        Ok(resp)
+    }
+}
+
+// ---------------------------------------------------------------------------
+// HTTP debug middleware (native only)
+// ---------------------------------------------------------------------------
+
+/// Logs each request for `--debug-http`, with credentials redacted.
+#[cfg(not(target_arch = "wasm32"))]
+struct DebugHttpMiddleware;
+
+#[cfg(not(target_arch = "wasm32"))]
+#[async_trait]
+impl Middleware for DebugHttpMiddleware {
+    async fn handle(
+        &self,
+        req: reqwest::Request,
+        extensions: &mut Extensions,
+        next: Next<'_>,
+    ) -> reqwest_middleware::Result<reqwest::Response> {
+        let exchange = crate::http_debug::Exchange::start(
+            req.method().as_str(),
+            req.url().as_str(),
+            req.body().and_then(|b| b.as_bytes()),
+        );
+        match next.run(req, extensions).await {
+            Ok(resp) => Ok(exchange.finish_response(resp).await?),
+            Err(e) => {
+                exchange.fail(&e);
+                Err(e)
+            }
+        }
+    }
+}
+
+// ---------------------------------------------------------------------------
+// Request timeouts (native only)
+// ---------------------------------------------------------------------------
+
+/// (request, connect) timeouts for every client pup builds; see `set_timeouts`.
+#[cfg(not(target_arch = "wasm32"))]
+static TIMEOUTS: std::sync::OnceLock<(Option<Duration>, Option<Duration>)> =
+    std::sync::OnceLock::new();
+
+/// Set the per-request and connect timeouts from `--timeout` /
+/// `--connect-timeout` (or their env/config defaults). Call before the first
+/// request; None disables a timeout.
+#[cfg(not(target_arch = "wasm32"))]
+pub fn set_timeouts(request: Option<Duration>, connect: Option<Duration>) {
+    let _ = TIMEOUTS.set((request, connect));
+}
+
+/// The (request, connect) timeouts in effect.
+#[cfg(not(target_arch = "wasm32"))]
+pub fn timeouts() -> (Option<Duration>, Option<Duration>) {
+    TIMEOUTS
+        .get()
+        .copied()
+        .unwrap_or((None, Some(crate::config::DEFAULT_CONNECT_TIMEOUT)))
+}
+
+/// A reqwest client with the configured timeouts, for raw API calls.
+#[cfg(not(target_arch = "wasm32"))]
+pub fn http_client() -> reqwest::Client {
+    let (request, connect) = timeouts();
+    let mut builder = reqwest::Client::builder();
+    if let Some(t) = request {
+        builder = builder.timeout(t);
+    }
+    if let Some(t) = connect {
+        builder = builder.connect_timeout(t);
+    }
+    builder.build().expect("failed to build reqwest client")
+}
+
 // ---------------------------------------------------------------------------
 // DD Configuration builder (native only)
 // ---------------------------------------------------------------------------
 
 /// Creates a DD API Configuration with all unstable ops enabled.
-/// `Configuration::new()` reads DD_API_KEY, DD_APP_KEY, DD_SITE from env.
+/// `Configuration::new()` only reads DD_API_KEY, DD_APP_KEY, DD_SITE from env,
+/// so the resolved site and keys (flags, config file) are applied on top.
 ///
 /// If PUP_MOCK_SERVER is set, redirects all API calls to the mock server.
 #[cfg(not(target_arch = "wasm32"))]
-pub fn make_dd_config(_cfg: &Config) -> datadog_api_client::datadog::Configuration {
+pub fn make_dd_config(cfg: &Config) -> datadog_api_client::datadog::Configuration {
     let mut dd_cfg = datadog_api_client::datadog::Configuration::new();
 
     // Enable all 63 unstable operations (snake_case in Rust client)
@@ -80,29 +188,57 @@ pub fn make_dd_config(_cfg: &Config) -> datadog_api_client::datadog::Configurati
             "datadoghq.eu",
             "ddog-gov.com",
         ];
-        let site = std::env::var("DD_SITE").unwrap_or_default();
-        if !site.is_empty() && !STANDARD_SITES.contains(&site.as_str()) {
+        if !STANDARD_SITES.contains(&cfg.site.as_str()) {
             dd_cfg.server_index = 2;
         }
+        dd_cfg
+            .server_variables
+            .insert("site".into(), cfg.site.clone());
+    }
+
+    for (name, key) in [("apiKeyAuth", &cfg.api_key), ("appKeyAuth", &cfg.app_key)] {
+        if let Some(key) = key {
+            dd_cfg.set_auth_key(
+                name,
+                datadog_api_client::datadog::APIKey {
+                    key: key.clone(),
+                    prefix: String::new(),
+                },
+            );
+        }
     }
 
     dd_cfg
 }
 
-/// Creates a reqwest middleware client with bearer token injection.
-/// Returns None if no bearer token is configured.
+/// Creates a reqwest middleware client with bearer token injection and, when
+/// `--stats` or `--debug-http` is on, request accounting or logging. It also
+/// carries a `--timeout` the DD client's own HTTP client would not apply.
+/// Returns None if none is needed, so the DD client uses its own HTTP client.
 #[cfg(not(target_arch = "wasm32"))]
 pub fn make_bearer_client(cfg: &Config) -> Option<ClientWithMiddleware> {
-    let token = cfg.access_token.as_ref()?;
-    let reqwest_client = reqwest::Client::builder()
-        .build()
-        .expect("failed to build reqwest client");
-    let client = ClientBuilder::new(reqwest_client)
-        .with(BearerAuthMiddleware {
+    if cfg.access_token.is_none()
+        && !crate::stats::is_enabled()
+        && !crate::http_debug::is_enabled()
+        && timeouts().0.is_none()
+    {
+        return None;
+    }
+    let reqwest_client = http_client();
+    let mut builder = ClientBuilder::new(reqwest_client);
+    if let Some(token) = &cfg.access_token {
+        builder = builder.with(BearerAuthMiddleware {
             token: token.clone(),
-        })
-        .build();
-    Some(client)
+        });
+    }
+    if crate::stats::is_enabled() {
+        builder = builder.with(StatsMiddleware);
+    }
+    // Innermost, so the logged latency is the request's own.
+    if crate::http_debug::is_enabled() {
+        builder = builder.with(DebugHttpMiddleware);
+    }
+    Some(builder.build())
 }
 
 // ---------------------------------------------------------------------------
@@ -225,23 +361,20 @@ pub fn get_auth_type(cfg: &Config) -> AuthType {
 }
 
 // ---------------------------------------------------------------------------
-// OAuth-excluded endpoint validation (native only)
+// OAuth-excluded endpoint validation
 // ---------------------------------------------------------------------------
 
-#[cfg(not(target_arch = "wasm32"))]
 struct EndpointRequirement {
     path: &'static str,
     method: &'static str,
 }
 
 /// Returns true if the endpoint doesn't support OAuth and requires API key fallback.
-#[cfg(not(target_arch = "wasm32"))]
-#[allow(dead_code)]
+/// `api::apply_auth` sends API keys instead of the bearer token for these.
 pub fn requires_api_key_fallback(method: &str, path: &str) -> bool {
     find_endpoint_requirement(method, path).is_some()
 }
 
-#[cfg(not(target_arch = "wasm32"))]
 fn find_endpoint_requirement(method: &str, path: &str) -> Option<&'static EndpointRequirement> {
     OAUTH_EXCLUDED_ENDPOINTS.iter().find(|req| {
         if req.method != method {
@@ -257,12 +390,11 @@ fn find_endpoint_requirement(method: &str, path: &str) -> Option<&'static Endpoi
 }
 
 // ---------------------------------------------------------------------------
-// Static tables (native only)
+// Static tables
 // ---------------------------------------------------------------------------
 
 /// Endpoints that don't support OAuth.
 /// Trailing "/" means prefix match for ID-parameterized paths.
-#[cfg(not(target_arch = "wasm32"))]
 static OAUTH_EXCLUDED_ENDPOINTS: &[EndpointRequirement] = &[
     // RUM API (10)
     EndpointRequirement {
@@ -439,6 +571,9 @@ static OAUTH_EXCLUDED_ENDPOINTS: &[EndpointRequirement] = &[
 /// Used for endpoints not covered by the typed DD API client.
 pub async fn raw_get(cfg: &Config, path: &str) -> anyhow::Result<serde_json::Value> {
     let url = format!("{}{}", cfg.api_base_url(), path);
+    #[cfg(not(target_arch = "wasm32"))]
+    let client = http_client();
+    #[cfg(target_arch = "wasm32")]
     let client = reqwest::Client::new();
     let mut req = client.get(&url);
 
@@ -452,11 +587,25 @@ pub async fn raw_get(cfg: &Config, path: &str) -> anyhow::Result<serde_json::Val
         anyhow::bail!("no authentication configured");
     }
 
-    let resp = req.header("Accept", "application/json").send().await?;
+    let req = req.header("Accept", "application/json");
+    #[cfg(not(target_arch = "wasm32"))]
+    let resp = crate::http_debug::send(req).await?;
+    #[cfg(target_arch = "wasm32")]
+    let resp = req.send().await?;
+    crate::stats::record_response(0, &resp);
+    if resp.status() == reqwest::StatusCode::FORBIDDEN && cfg.access_token.is_some() {
+        crate::auth::scopes::record_forbidden("GET", path);
+    }
     if !resp.status().is_success() {
         let status = resp.status();
         let body = resp.text().await.unwrap_or_default();
-        anyhow::bail!("API error (HTTP {status}): {body}");
+        return Err(crate::api::ApiError {
+            status: status.as_u16(),
+            reason: status.canonical_reason().unwrap_or_default().to_string(),
+            body,
+            reset: None,
+        }
+        .into());
     }
     Ok(resp.json().await?)
 }
@@ -469,6 +618,9 @@ pub async fn raw_post(
     body: serde_json::Value,
 ) -> anyhow::Result<serde_json::Value> {
     let url = format!("{}{}", cfg.api_base_url(), path);
+    #[cfg(not(target_arch = "wasm32"))]
+    let client = http_client();
+    #[cfg(target_arch = "wasm32")]
     let client = reqwest::Client::new();
     let mut req = client.post(&url);
 
@@ -482,20 +634,148 @@ pub async fn raw_post(
         anyhow::bail!("no authentication configured");
     }
 
-    let resp = req
+    let sent = serde_json::to_vec(&body).map_or(0, |b| b.len() as u64);
+    let req = req
         .header("Content-Type", "application/json")
         .header("Accept", "application/json")
-        .json(&body)
-        .send()
-        .await?;
+        .json(&body);
+    #[cfg(not(target_arch = "wasm32"))]
+    let resp = crate::http_debug::send(req).await?;
+    #[cfg(target_arch = "wasm32")]
+    let resp = req.send().await?;
+    crate::stats::record_response(sent, &resp);
+    if resp.status() == reqwest::StatusCode::FORBIDDEN && cfg.access_token.is_some() {
+        crate::auth::scopes::record_forbidden("POST", path);
+    }
     if !resp.status().is_success() {
         let status = resp.status();
         let body = resp.text().await.unwrap_or_default();
-        anyhow::bail!("API error (HTTP {status}): {body}");
+        return Err(crate::api::ApiError {
+            status: status.as_u16(),
+            reason: status.canonical_reason().unwrap_or_default().to_string(),
+            body,
+            reset: None,
+        }
+        .into());
     }
     Ok(resp.json().await?)
 }
 
+// ---------------------------------------------------------------------------
+// Safe retries for create calls (native only)
+// ---------------------------------------------------------------------------
+
+/// Attempts made for a create call before giving up.
+#[cfg(not(target_arch = "wasm32"))]
+pub const CREATE_MAX_ATTEMPTS: u32 = 3;
+
+/// Delay before the first retry; doubled for each further attempt.
+#[cfg(not(target_arch = "wasm32"))]
+const CREATE_RETRY_BASE_DELAY_MS: u64 = 500;
+
+/// Clock skew tolerated when deciding whether a resource was created by this run.
+#[cfg(not(target_arch = "wasm32"))]
+const CREATED_SINCE_LEEWAY_SECS: i64 = 60;
+
+/// Whether an API error is worth retrying: timeouts, dropped connections,
+/// rate limiting (429), request timeouts (408), and 5xx responses.
+///
+/// Works on the `{:?}` text of both typed client errors (`status: 503`) and
+/// raw helper errors (`HTTP 503 Service Unavailable`).
+#[cfg(not(target_arch = "wasm32"))]
+pub fn is_transient_error(err: &str) -> bool {
+    let lower = err.to_lowercase();
+    let network = [
+        "timed out",
+        "timeout",
+        "connection reset",
+        "connection closed",
+        "connection refused",
+        "error sending request",
+        "broken pipe",
+    ];
+    if network.iter().any(|p| lower.contains(p)) {
+        return true;
+    }
+    ["HTTP ", "status: "].iter().any(|marker| {
+        err.match_indices(marker).any(|(i, _)| {
+            let code: String = err[i + marker.len()..]
+                .chars()
+                .take_while(|c| c.is_ascii_digit())
+                .collect();
+            matches!(code.parse::<u16>(), Ok(408 | 429 | 500..=599))
+        })
+    })
+}
+
+/// Whether `resource[field]` is an RFC3339 timestamp at or after `since`
+/// (minus a small leeway for clock skew). Used to tell resources created by an
+/// earlier attempt of this run apart from pre-existing ones with the same name.
+#[cfg(not(target_arch = "wasm32"))]
+pub fn created_since(
+    resource: &serde_json::Value,
+    field: &str,
+    since: chrono::DateTime<chrono::Utc>,
+) -> bool {
+    resource
+        .get(field)
+        .and_then(|v| v.as_str())
+        .and_then(|s| chrono::DateTime::parse_from_rfc3339(s).ok())
+        .is_some_and(|t| t >= since - chrono::Duration::seconds(CREATED_SINCE_LEEWAY_SECS))
+}
+
+/// Run a non-idempotent create call, retrying transient failures without
+/// creating duplicates.
+///
+/// Datadog's create endpoints don't accept idempotency keys, so before each
+/// retry `find_existing` is asked whether the previous attempt actually landed
+/// (e.g. the server committed it but the response was lost). If it did, that
+/// resource is returned instead of creating another one. If the lookup itself
+/// fails, the original error is returned rather than risking a duplicate.
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn create_with_retry<T, C, CFut, F, FFut>(
+    what: &str,
+    mut create: C,
+    mut find_existing: F,
+) -> anyhow::Result<T>
+where
+    C: FnMut() -> CFut,
+    CFut: std::future::Future<Output = anyhow::Result<T>>,
+    F: FnMut() -> FFut,
+    FFut: std::future::Future<Output = anyhow::Result<Option<T>>>,
+{
+    let mut attempt = 1;
+    loop {
+        let err = match create().await {
+            Ok(v) => return Ok(v),
+            Err(e) => e,
+        };
+        if attempt >= CREATE_MAX_ATTEMPTS || !is_transient_error(&format!("{err:?}")) {
+            return Err(err);
+        }
+        let delay = CREATE_RETRY_BASE_DELAY_MS * 2u64.pow(attempt - 1);
+        eprintln!(
+            "warning: {what} create attempt {attempt}/{CREATE_MAX_ATTEMPTS} failed; retrying in {delay}ms"
+        );
+        tokio::time::sleep(std::time::Duration::from_millis(delay)).await;
+        match find_existing().await {
+            Ok(Some(existing)) => {
+                eprintln!(
+                    "Found the {what} created by the earlier attempt; not creating it again."
+                );
+                return Ok(existing);
+            }
+            Ok(None) => {}
+            Err(lookup) => {
+                return Err(err.context(format!(
+                    "could not check whether the {what} was already created ({lookup}); not retrying"
+                )));
+            }
+        }
+        attempt += 1;
+    }
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -506,12 +786,7 @@ mod tests {
         Config {
             api_key: Some("test".into()),
             app_key: Some("test".into()),
-            access_token: None,
-            site: "datadoghq.com".into(),
-            org: None,
-            output_format: crate::config::OutputFormat::Json,
-            auto_approve: false,
-            agent_mode: false,
+            ..crate::test_utils::config()
         }
     }
 
@@ -632,10 +907,27 @@ mod tests {
         let dd_cfg = make_dd_config(&cfg);
         // Verify unstable ops are enabled (server_index should be default 0)
         assert_eq!(dd_cfg.server_index, 0);
+        assert_eq!(
+            dd_cfg.server_variables.get("site").unwrap(),
+            "datadoghq.com"
+        );
         std::env::remove_var("DD_API_KEY");
         std::env::remove_var("DD_APP_KEY");
     }
 
+    #[test]
+    fn test_make_dd_config_uses_config_site() {
+        let _guard = ENV_LOCK.lock().unwrap_or_else(|p| p.into_inner());
+        std::env::remove_var("PUP_MOCK_SERVER");
+        std::env::set_var("DD_SITE", "datadoghq.com");
+        let mut cfg = test_cfg();
+        cfg.site = "datad0g.com".into();
+        let dd_cfg = make_dd_config(&cfg);
+        assert_eq!(dd_cfg.server_index, 2);
+        assert_eq!(dd_cfg.server_variables.get("site").unwrap(), "datad0g.com");
+        std::env::remove_var("DD_SITE");
+    }
+
     #[test]
     fn test_make_dd_config_with_mock_server() {
         let _guard = ENV_LOCK.lock().unwrap_or_else(|p| p.into_inner());
@@ -706,4 +998,67 @@ mod tests {
             "/api/v2/error_tracking/issues/search"
         ));
     }
+
+    #[test]
+    fn test_is_transient_error() {
+        assert!(is_transient_error(
+            "API error (HTTP 503 Service Unavailable): oops"
+        ));
+        assert!(is_transient_error(
+            "ResponseError(ResponseContent { status: 429, content: \"\" })"
+        ));
+        assert!(is_transient_error(
+            "Reqwest(error sending request for url (...): operation timed out)"
+        ));
+        assert!(!is_transient_error("API error (HTTP 400 Bad Request): bad"));
+        assert!(!is_transient_error(
+            "ResponseError(ResponseContent { status: 403, content: \"\" })"
+        ));
+    }
+
+    #[test]
+    fn test_created_since() {
+        let since = chrono::DateTime::parse_from_rfc3339("2024-01-02T03:00:00Z")
+            .unwrap()
+            .with_timezone(&chrono::Utc);
+        let fresh = serde_json::json!({"created": "2024-01-02T03:00:30Z"});
+        let skewed = serde_json::json!({"created": "2024-01-02T02:59:30Z"});
+        let old = serde_json::json!({"created": "2023-12-01T00:00:00Z"});
+        assert!(created_since(&fresh, "created", since));
+        assert!(created_since(&skewed, "created", since));
+        assert!(!created_since(&old, "created", since));
+        assert!(!created_since(&fresh, "created_at", since));
+    }
+
+    #[tokio::test]
+    async fn test_create_with_retry_finds_existing() {
+        let attempts = std::cell::Cell::new(0);
+        let result = create_with_retry(
+            "monitor",
+            || {
+                attempts.set(attempts.get() + 1);
+                async { Err::<i64, _>(anyhow::anyhow!("API error (HTTP 502 Bad Gateway)")) }
+            },
+            || async { Ok(Some(42)) },
+        )
+        .await;
+        assert_eq!(result.unwrap(), 42);
+        assert_eq!(attempts.get(), 1);
+    }
+
+    #[tokio::test]
+    async fn test_create_with_retry_gives_up_on_client_errors() {
+        let attempts = std::cell::Cell::new(0);
+        let result = create_with_retry(
+            "monitor",
+            || {
+                attempts.set(attempts.get() + 1);
+                async { Err::<i64, _>(anyhow::anyhow!("API error (HTTP 400 Bad Request)")) }
+            },
+            || async { Ok(None) },
+        )
+        .await;
+        assert!(result.is_err());
+        assert_eq!(attempts.get(), 1);
+    }
 }
@@ -8,6 +8,7 @@ use datadog_api_client::datadogV2::api_key_management::{
 use crate::client;
 use crate::config::Config;
 use crate::formatter;
+use crate::util;
 
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn list(cfg: &Config) -> Result<()> {
@@ -29,6 +30,24 @@ pub async fn list(cfg: &Config) -> Result<()> {
     crate::formatter::output(cfg, &data)
 }
 
+/// Largest `page[size]` the key management endpoints accept.
+const KEYS_PAGE_MAX: usize = 100;
+
+/// List every API key, following page numbers (`--all`).
+pub async fn list_all_pages(cfg: &Config, max_items: usize) -> Result<()> {
+    let size = util::page_size(KEYS_PAGE_MAX, max_items);
+    let collected =
+        util::collect_pages(util::Paging::Number { size }, "/data", max_items, |page| {
+            let query = vec![
+                ("page[size]", size.to_string()),
+                ("page[number]", page.number.to_string()),
+            ];
+            async move { crate::api::get(cfg, "/api/v2/api_keys", &query).await }
+        })
+        .await?;
+    util::print_collected(cfg, "api-keys list", collected)
+}
+
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn get(cfg: &Config, key_id: &str) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
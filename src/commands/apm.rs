@@ -1,7 +1,8 @@
 use anyhow::Result;
+use serde::Serialize;
 
 use crate::client;
-use crate::config::Config;
+use crate::config::{Config, OutputFormat};
 use crate::formatter;
 use crate::util;
 
@@ -50,21 +51,47 @@ pub async fn services_stats(cfg: &Config, env: String, from: String, to: String)
 }
 
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn entities_list(cfg: &Config, from: String, to: String) -> Result<()> {
+pub async fn entities_list(
+    cfg: &Config,
+    from: String,
+    to: String,
+    env: Option<String>,
+    types: Option<String>,
+    raw: bool,
+) -> Result<()> {
     let from_ts = util::parse_time_to_unix(&from)?;
     let to_ts = util::parse_time_to_unix(&to)?;
-    let path = format!("/api/unstable/apm/entities?start={from_ts}&end={to_ts}");
+    let mut path = format!("/api/unstable/apm/entities?start={from_ts}&end={to_ts}");
+    if let Some(env) = env {
+        path.push_str(&format!("&filter[env]={env}"));
+    }
+    if let Some(types) = types {
+        path.push_str(&format!("&filter[types]={types}"));
+    }
     let data = client::raw_get(cfg, &path).await?;
-    formatter::output(cfg, &data)
+    print_entities(cfg, data, raw)
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn entities_list(cfg: &Config, from: String, to: String) -> Result<()> {
+pub async fn entities_list(
+    cfg: &Config,
+    from: String,
+    to: String,
+    env: Option<String>,
+    types: Option<String>,
+    raw: bool,
+) -> Result<()> {
     let from_ts = util::parse_time_to_unix(&from)?;
     let to_ts = util::parse_time_to_unix(&to)?;
-    let query = vec![("start", from_ts.to_string()), ("end", to_ts.to_string())];
+    let mut query = vec![("start", from_ts.to_string()), ("end", to_ts.to_string())];
+    if let Some(env) = env {
+        query.push(("filter[env]", env));
+    }
+    if let Some(types) = types {
+        query.push(("filter[types]", types));
+    }
     let data = crate::api::get(cfg, "/api/unstable/apm/entities", &query).await?;
-    crate::formatter::output(cfg, &data)
+    print_entities(cfg, data, raw)
 }
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -93,36 +120,37 @@ pub async fn dependencies_list(cfg: &Config, env: String, from: String, to: Stri
 pub async fn services_operations(
     cfg: &Config,
     service: String,
-    env: String,
+    env: Option<String>,
     from: String,
     to: String,
 ) -> Result<()> {
     let from_ts = util::parse_time_to_unix(&from)?;
     let to_ts = util::parse_time_to_unix(&to)?;
-    let path =
-        format!("/api/v1/trace/operation_names/{service}?env={env}&start={from_ts}&end={to_ts}");
+    let mut path = format!("/api/v1/trace/operation_names/{service}?start={from_ts}&end={to_ts}");
+    if let Some(env) = env {
+        path.push_str(&format!("&env={env}"));
+    }
     let data = client::raw_get(cfg, &path).await?;
-    formatter::output(cfg, &data)
+    print_names(cfg, data)
 }
 
 #[cfg(target_arch = "wasm32")]
 pub async fn services_operations(
     cfg: &Config,
     service: String,
-    env: String,
+    env: Option<String>,
     from: String,
     to: String,
 ) -> Result<()> {
     let from_ts = util::parse_time_to_unix(&from)?;
     let to_ts = util::parse_time_to_unix(&to)?;
     let path = format!("/api/v1/trace/operation_names/{service}");
-    let query = vec![
-        ("env", env),
-        ("start", from_ts.to_string()),
-        ("end", to_ts.to_string()),
-    ];
+    let mut query = vec![("start", from_ts.to_string()), ("end", to_ts.to_string())];
+    if let Some(env) = env {
+        query.push(("env", env));
+    }
     let data = crate::api::get(cfg, &path, &query).await?;
-    crate::formatter::output(cfg, &data)
+    print_names(cfg, data)
 }
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -130,17 +158,20 @@ pub async fn services_resources(
     cfg: &Config,
     service: String,
     operation: String,
-    env: String,
+    env: Option<String>,
     from: String,
     to: String,
 ) -> Result<()> {
     let from_ts = util::parse_time_to_unix(&from)?;
     let to_ts = util::parse_time_to_unix(&to)?;
-    let path = format!(
-        "/api/ui/apm/resources?service={service}&operation={operation}&env={env}&start={from_ts}&end={to_ts}"
+    let mut path = format!(
+        "/api/ui/apm/resources?service={service}&operation={operation}&start={from_ts}&end={to_ts}"
     );
+    if let Some(env) = env {
+        path.push_str(&format!("&env={env}"));
+    }
     let data = client::raw_get(cfg, &path).await?;
-    formatter::output(cfg, &data)
+    print_names(cfg, data)
 }
 
 #[cfg(target_arch = "wasm32")]
@@ -148,21 +179,23 @@ pub async fn services_resources(
     cfg: &Config,
     service: String,
     operation: String,
-    env: String,
+    env: Option<String>,
     from: String,
     to: String,
 ) -> Result<()> {
     let from_ts = util::parse_time_to_unix(&from)?;
     let to_ts = util::parse_time_to_unix(&to)?;
-    let query = vec![
+    let mut query = vec![
         ("service", service),
         ("operation", operation),
-        ("env", env),
         ("start", from_ts.to_string()),
         ("end", to_ts.to_string()),
     ];
+    if let Some(env) = env {
+        query.push(("env", env));
+    }
     let data = crate::api::get(cfg, "/api/ui/apm/resources", &query).await?;
-    crate::formatter::output(cfg, &data)
+    print_names(cfg, data)
 }
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -200,3 +233,570 @@ pub async fn flow_map(
     let data = crate::api::get(cfg, "/api/ui/apm/flow-map", &q).await?;
     crate::formatter::output(cfg, &data)
 }
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn flow_map_get(
+    cfg: &Config,
+    service: String,
+    env: String,
+    limit: i64,
+    from: String,
+    to: String,
+    raw: bool,
+) -> Result<()> {
+    let from_ts = util::parse_time_to_unix(&from)?;
+    let to_ts = util::parse_time_to_unix(&to)?;
+    let query = format!("service:{service} env:{env}");
+    let path =
+        format!("/api/ui/apm/flow-map?query={query}&limit={limit}&start={from_ts}&end={to_ts}");
+    let data = client::raw_get(cfg, &path).await?;
+    print_flow_map(cfg, data, service, env, raw)
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn flow_map_get(
+    cfg: &Config,
+    service: String,
+    env: String,
+    limit: i64,
+    from: String,
+    to: String,
+    raw: bool,
+) -> Result<()> {
+    let from_ts = util::parse_time_to_unix(&from)?;
+    let to_ts = util::parse_time_to_unix(&to)?;
+    let q = vec![
+        ("query", format!("service:{service} env:{env}")),
+        ("limit", limit.to_string()),
+        ("start", from_ts.to_string()),
+        ("end", to_ts.to_string()),
+    ];
+    let data = crate::api::get(cfg, "/api/ui/apm/flow-map", &q).await?;
+    print_flow_map(cfg, data, service, env, raw)
+}
+
+/// Operation and resource endpoints return bare lists of names, which the
+/// table formatter cannot render. Wrap them as `{"name": ...}` rows for tables.
+fn names_as_rows(data: &serde_json::Value) -> Option<Vec<serde_json::Value>> {
+    let list = match data {
+        serde_json::Value::Array(arr) => arr,
+        serde_json::Value::Object(obj) => obj.get("data")?.as_array()?,
+        _ => return None,
+    };
+    list.iter()
+        .map(|v| v.as_str().map(|name| serde_json::json!({ "name": name })))
+        .collect()
+}
+
+fn print_names(cfg: &Config, data: serde_json::Value) -> Result<()> {
+    if cfg.output_format == OutputFormat::Table && !cfg.agent_mode {
+        if let Some(rows) = names_as_rows(&data) {
+            return formatter::output(cfg, &rows);
+        }
+    }
+    formatter::output(cfg, &data)
+}
+
+// ---------------------------------------------------------------------------
+// Stable output shapes for unstable APM endpoints
+// ---------------------------------------------------------------------------
+
+/// Version of the normalized shapes emitted by `apm entities list` and
+/// `apm flow-map get`. Bump when a field is removed or changes meaning;
+/// adding optional fields does not require a bump.
+pub const APM_OUTPUT_SCHEMA_VERSION: &str = "v1";
+
+/// An APM entity (service, datastore, queue, inferred service, ...).
+#[derive(Serialize, Debug, PartialEq)]
+pub struct ApmEntity {
+    pub id: String,
+    #[serde(rename = "type")]
+    pub kind: String,
+    pub name: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub env: Option<String>,
+}
+
+/// Output of `apm entities list`.
+#[derive(Serialize)]
+pub struct ApmEntitiesOutput {
+    pub schema_version: &'static str,
+    pub entities: Vec<ApmEntity>,
+}
+
+/// A node in a service flow map.
+#[derive(Serialize, Debug, PartialEq)]
+pub struct FlowMapNode {
+    pub id: String,
+    pub name: String,
+    #[serde(rename = "type", skip_serializing_if = "Option::is_none")]
+    pub kind: Option<String>,
+}
+
+/// A directed call edge between two flow map nodes.
+#[derive(Serialize, Debug, PartialEq)]
+pub struct FlowMapEdge {
+    pub source: String,
+    pub target: String,
+}
+
+/// Output of `apm flow-map get`.
+#[derive(Serialize)]
+pub struct FlowMapOutput {
+    pub schema_version: &'static str,
+    pub service: String,
+    pub env: String,
+    pub nodes: Vec<FlowMapNode>,
+    pub edges: Vec<FlowMapEdge>,
+}
+
+fn str_field(v: &serde_json::Value, keys: &[&str]) -> Option<String> {
+    keys.iter().find_map(|k| match v.get(*k) {
+        Some(serde_json::Value::String(s)) if !s.is_empty() => Some(s.clone()),
+        Some(serde_json::Value::Number(n)) => Some(n.to_string()),
+        _ => None,
+    })
+}
+
+/// Normalize a raw `/api/unstable/apm/entities` response.
+/// Returns None when the response no longer has the expected JSON:API shape.
+fn normalize_entities(data: &serde_json::Value) -> Option<Vec<ApmEntity>> {
+    let items = data.get("data")?.as_array()?;
+    items
+        .iter()
+        .map(|item| {
+            let id = str_field(item, &["id"])?;
+            let attrs = item.get("attributes").unwrap_or(&serde_json::Value::Null);
+            Some(ApmEntity {
+                kind: str_field(item, &["type"]).unwrap_or_else(|| "unknown".into()),
+                name: str_field(attrs, &["name", "service"]).unwrap_or_else(|| id.clone()),
+                env: str_field(attrs, &["env"]),
+                id,
+            })
+        })
+        .collect()
+}
+
+/// Normalize a raw `/api/ui/apm/flow-map` response into nodes and edges.
+/// Returns None when neither a top-level nor a `data`-wrapped node list is present.
+fn normalize_flow_map(data: &serde_json::Value) -> Option<(Vec<FlowMapNode>, Vec<FlowMapEdge>)> {
+    let root = match data.get("data") {
+        Some(inner) if inner.is_object() => inner,
+        _ => data,
+    };
+    let nodes = root
+        .get("nodes")?
+        .as_array()?
+        .iter()
+        .map(|n| {
+            let id = str_field(n, &["id", "name"])?;
+            Some(FlowMapNode {
+                name: str_field(n, &["name", "service"]).unwrap_or_else(|| id.clone()),
+                kind: str_field(n, &["type", "kind"]),
+                id,
+            })
+        })
+        .collect::<Option<Vec<_>>>()?;
+    let edges = match root.get("edges").or_else(|| root.get("links")) {
+        Some(serde_json::Value::Array(arr)) => arr
+            .iter()
+            .map(|e| {
+                Some(FlowMapEdge {
+                    source: str_field(e, &["source", "from"])?,
+                    target: str_field(e, &["target", "to"])?,
+                })
+            })
+            .collect::<Option<Vec<_>>>()?,
+        Some(_) => return None,
+        None => Vec::new(),
+    };
+    Some((nodes, edges))
+}
+
+fn warn_unstable_shape(endpoint: &str) {
+    eprintln!(
+        "Warning: {endpoint} returned an unexpected response shape (the API is unstable); \
+         showing the raw response instead"
+    );
+}
+
+fn print_entities(cfg: &Config, data: serde_json::Value, raw: bool) -> Result<()> {
+    if raw {
+        return formatter::output(cfg, &data);
+    }
+    let Some(entities) = normalize_entities(&data) else {
+        warn_unstable_shape("/api/unstable/apm/entities");
+        return formatter::output(cfg, &data);
+    };
+    if cfg.output_format == OutputFormat::Table && !cfg.agent_mode {
+        return formatter::output(cfg, &entities);
+    }
+    let out = ApmEntitiesOutput {
+        schema_version: APM_OUTPUT_SCHEMA_VERSION,
+        entities,
+    };
+    formatter::output(cfg, &out)
+}
+
+fn print_flow_map(
+    cfg: &Config,
+    data: serde_json::Value,
+    service: String,
+    env: String,
+    raw: bool,
+) -> Result<()> {
+    if raw {
+        return formatter::output(cfg, &data);
+    }
+    let Some((nodes, edges)) = normalize_flow_map(&data) else {
+        warn_unstable_shape("/api/ui/apm/flow-map");
+        return formatter::output(cfg, &data);
+    };
+    if cfg.output_format == OutputFormat::Table && !cfg.agent_mode {
+        return formatter::output(cfg, &edges);
+    }
+    let out = FlowMapOutput {
+        schema_version: APM_OUTPUT_SCHEMA_VERSION,
+        service,
+        env,
+        nodes,
+        edges,
+    };
+    formatter::output(cfg, &out)
+}
+
+// ---------------------------------------------------------------------------
+// Service summary
+// ---------------------------------------------------------------------------
+
+/// Resources shown by `apm services summary`, busiest first.
+const SUMMARY_RESOURCE_LIMIT: usize = 25;
+
+/// Latency percentiles computed per resource, in response order after the count.
+const SUMMARY_PERCENTILES: [&str; 3] = ["pc50", "pc95", "pc99"];
+
+/// Request rate, latency, and errors for a resource (or the whole service).
+#[derive(Serialize, Debug, PartialEq)]
+pub struct ResourceSummary {
+    pub resource: String,
+    pub requests: u64,
+    pub requests_per_sec: f64,
+    pub p50_ms: Option<f64>,
+    pub p95_ms: Option<f64>,
+    pub p99_ms: Option<f64>,
+    pub errors: u64,
+    /// Percentage of requests that errored.
+    pub error_rate: f64,
+}
+
+#[derive(Serialize, Debug)]
+pub struct ServiceSummary {
+    pub service: String,
+    pub env: String,
+    pub from: String,
+    pub to: String,
+    pub window_seconds: i64,
+    pub total: ResourceSummary,
+    pub resources: Vec<ResourceSummary>,
+}
+
+fn round2(v: f64) -> f64 {
+    (v * 100.0).round() / 100.0
+}
+
+impl ResourceSummary {
+    fn new(resource: String, stats: &[Option<f64>], errors: u64, window_seconds: i64) -> Self {
+        let requests = stats.first().copied().flatten().unwrap_or(0.0) as u64;
+        // Span durations are in nanoseconds.
+        let ms = |i: usize| stats.get(i).copied().flatten().map(|ns| round2(ns / 1e6));
+        ResourceSummary {
+            resource,
+            requests,
+            requests_per_sec: round2(requests as f64 / window_seconds.max(1) as f64),
+            p50_ms: ms(1),
+            p95_ms: ms(2),
+            p99_ms: ms(3),
+            errors,
+            error_rate: if requests == 0 {
+                0.0
+            } else {
+                round2(errors as f64 * 100.0 / requests as f64)
+            },
+        }
+    }
+}
+
+/// Buckets of a `/api/v2/spans/analytics/aggregate` response as
+/// (`resource_name` group, computed values in request order). Computes come
+/// back keyed `c0`, `c1`, ... in the order they were requested.
+pub fn aggregate_buckets(
+    resp: &serde_json::Value,
+    computes: usize,
+) -> Vec<(Option<String>, Vec<Option<f64>>)> {
+    resp["data"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .map(|bucket| {
+            let attrs = &bucket["attributes"];
+            let group = attrs["by"]["resource_name"].as_str().map(String::from);
+            let values = (0..computes)
+                .map(|i| attrs["compute"][format!("c{i}")].as_f64())
+                .collect();
+            (group, values)
+        })
+        .collect()
+}
+
+/// Build the summary from the four aggregations: per-resource stats and
+/// error counts, and the same two for the whole service.
+pub fn build_service_summary(
+    stats: &serde_json::Value,
+    errors: &serde_json::Value,
+    total_stats: &serde_json::Value,
+    total_errors: &serde_json::Value,
+    window_seconds: i64,
+) -> (ResourceSummary, Vec<ResourceSummary>) {
+    let computes = SUMMARY_PERCENTILES.len() + 1;
+    let error_counts: std::collections::HashMap<String, u64> = aggregate_buckets(errors, 1)
+        .into_iter()
+        .filter_map(|(group, values)| Some((group?, values[0].unwrap_or(0.0) as u64)))
+        .collect();
+    let mut resources: Vec<ResourceSummary> = aggregate_buckets(stats, computes)
+        .into_iter()
+        .filter_map(|(group, values)| {
+            let group = group?;
+            let errors = error_counts.get(&group).copied().unwrap_or(0);
+            Some(ResourceSummary::new(group, &values, errors, window_seconds))
+        })
+        .collect();
+    resources.sort_by(|a, b| b.requests.cmp(&a.requests));
+
+    let total_values = aggregate_buckets(total_stats, computes)
+        .into_iter()
+        .next()
+        .map(|(_, v)| v)
+        .unwrap_or_default();
+    let total_error_count = aggregate_buckets(total_errors, 1)
+        .first()
+        .and_then(|(_, v)| v[0])
+        .unwrap_or(0.0) as u64;
+    let total = ResourceSummary::new(
+        "(all resources)".into(),
+        &total_values,
+        total_error_count,
+        window_seconds,
+    );
+    (total, resources)
+}
+
+async fn spans_aggregate(
+    cfg: &Config,
+    query: &str,
+    from_ms: i64,
+    to_ms: i64,
+    with_latency: bool,
+    by_resource: bool,
+) -> Result<serde_json::Value> {
+    let mut compute = vec![serde_json::json!({ "aggregation": "count", "type": "total" })];
+    if with_latency {
+        for pc in SUMMARY_PERCENTILES {
+            compute.push(serde_json::json!({
+                "aggregation": pc,
+                "metric": "@duration",
+                "type": "total"
+            }));
+        }
+    }
+    let mut body = serde_json::json!({
+        "data": {
+            "type": "aggregate_request",
+            "attributes": {
+                "filter": {
+                    "query": query,
+                    "from": from_ms.to_string(),
+                    "to": to_ms.to_string()
+                },
+                "compute": compute
+            }
+        }
+    });
+    if by_resource {
+        body["data"]["attributes"]["group_by"] = serde_json::json!([{
+            "facet": "resource_name",
+            "limit": SUMMARY_RESOURCE_LIMIT,
+            "sort": { "aggregation": "count", "order": "desc", "type": "measure" }
+        }]);
+    }
+    crate::api::post(cfg, "/api/v2/spans/analytics/aggregate", &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to aggregate spans", e))
+}
+
+/// Requests/sec, p50/p95/p99 latency, and error rate for a service's entry
+/// spans, per resource and overall, computed by the spans aggregate API.
+pub async fn services_summary(
+    cfg: &Config,
+    service: String,
+    env: String,
+    from: String,
+    to: String,
+) -> Result<()> {
+    let from_ms = util::parse_time_to_unix_millis(&from)?;
+    let to_ms = util::parse_time_to_unix_millis(&to)?;
+    if to_ms <= from_ms {
+        anyhow::bail!("--from must be before --to");
+    }
+    let window_seconds = (to_ms - from_ms) / 1000;
+
+    // Entry spans only, so each request is counted once.
+    let query = format!("service:{service} env:{env} @_top_level:1");
+    let error_query = format!("{query} status:error");
+    let stats = spans_aggregate(cfg, &query, from_ms, to_ms, true, true).await?;
+    let errors = spans_aggregate(cfg, &error_query, from_ms, to_ms, false, true).await?;
+    let total_stats = spans_aggregate(cfg, &query, from_ms, to_ms, true, false).await?;
+    let total_errors = spans_aggregate(cfg, &error_query, from_ms, to_ms, false, false).await?;
+    let (total, resources) =
+        build_service_summary(&stats, &errors, &total_stats, &total_errors, window_seconds);
+    if total.requests == 0 {
+        eprintln!("No requests found for service {service} in env {env}.");
+    }
+
+    if cfg.output_format == OutputFormat::Table && !cfg.agent_mode {
+        let mut rows = vec![total];
+        rows.extend(resources);
+        return formatter::output(cfg, &rows);
+    }
+    let out = ServiceSummary {
+        service,
+        env,
+        from,
+        to,
+        window_seconds,
+        total,
+        resources,
+    };
+    formatter::output(cfg, &out)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_names_as_rows() {
+        let rows = names_as_rows(&serde_json::json!(["web.request", "db.query"])).unwrap();
+        assert_eq!(rows[0]["name"], "web.request");
+        let rows = names_as_rows(&serde_json::json!({"data": ["GET /"]})).unwrap();
+        assert_eq!(rows.len(), 1);
+        assert!(names_as_rows(&serde_json::json!([{"name": "x"}])).is_none());
+    }
+
+    #[test]
+    fn test_normalize_entities() {
+        let data = serde_json::json!({
+            "data": [
+                {"id": "svc-1", "type": "service", "attributes": {"name": "web", "env": "prod"}},
+                {"id": "db-1", "type": "datastore", "attributes": {}}
+            ]
+        });
+        let entities = normalize_entities(&data).unwrap();
+        assert_eq!(entities.len(), 2);
+        assert_eq!(entities[0].name, "web");
+        assert_eq!(entities[0].env.as_deref(), Some("prod"));
+        assert_eq!(entities[1].name, "db-1");
+        assert_eq!(entities[1].kind, "datastore");
+    }
+
+    #[test]
+    fn test_normalize_entities_unexpected_shape() {
+        assert!(normalize_entities(&serde_json::json!({"entities": []})).is_none());
+        assert!(normalize_entities(&serde_json::json!({"data": [{"type": "x"}]})).is_none());
+    }
+
+    #[test]
+    fn test_normalize_flow_map() {
+        let data = serde_json::json!({
+            "nodes": [{"id": "web"}, {"id": "db", "type": "datastore"}],
+            "edges": [{"source": "web", "target": "db"}]
+        });
+        let (nodes, edges) = normalize_flow_map(&data).unwrap();
+        assert_eq!(nodes.len(), 2);
+        assert_eq!(nodes[1].kind.as_deref(), Some("datastore"));
+        assert_eq!(
+            edges,
+            vec![FlowMapEdge {
+                source: "web".into(),
+                target: "db".into()
+            }]
+        );
+    }
+
+    #[test]
+    fn test_normalize_flow_map_data_wrapped_links() {
+        let data = serde_json::json!({
+            "data": {"nodes": [{"name": "web"}], "links": [{"from": "web", "to": "cache"}]}
+        });
+        let (nodes, edges) = normalize_flow_map(&data).unwrap();
+        assert_eq!(nodes[0].id, "web");
+        assert_eq!(edges[0].target, "cache");
+    }
+
+    #[test]
+    fn test_normalize_flow_map_unexpected_shape() {
+        assert!(normalize_flow_map(&serde_json::json!({"graph": {}})).is_none());
+        assert!(normalize_flow_map(&serde_json::json!({"nodes": [], "edges": {}})).is_none());
+    }
+
+    #[test]
+    fn test_build_service_summary() {
+        let stats = serde_json::json!({"data": [
+            {"attributes": {"by": {"resource_name": "GET /slow"},
+                "compute": {"c0": 100, "c1": 2.0e8, "c2": 9.0e8, "c3": 1.5e9}}},
+            {"attributes": {"by": {"resource_name": "GET /health"},
+                "compute": {"c0": 3600, "c1": 1.0e6, "c2": 2.5e6, "c3": 4.0e6}}}
+        ]});
+        let errors = serde_json::json!({"data": [
+            {"attributes": {"by": {"resource_name": "GET /slow"}, "compute": {"c0": 5}}}
+        ]});
+        let total_stats = serde_json::json!({"data": [
+            {"attributes": {"by": {}, "compute": {"c0": 3700, "c1": 1.1e6, "c2": 3.0e8, "c3": 9.5e8}}}
+        ]});
+        let total_errors = serde_json::json!({"data": [
+            {"attributes": {"by": {}, "compute": {"c0": 5}}}
+        ]});
+        let (total, resources) =
+            build_service_summary(&stats, &errors, &total_stats, &total_errors, 3600);
+
+        assert_eq!(resources[0].resource, "GET /health");
+        assert_eq!(resources[0].requests_per_sec, 1.0);
+        assert_eq!(resources[0].p95_ms, Some(2.5));
+        assert_eq!(resources[0].error_rate, 0.0);
+        assert_eq!(
+            resources[1],
+            ResourceSummary {
+                resource: "GET /slow".into(),
+                requests: 100,
+                requests_per_sec: 0.03,
+                p50_ms: Some(200.0),
+                p95_ms: Some(900.0),
+                p99_ms: Some(1500.0),
+                errors: 5,
+                error_rate: 5.0,
+            }
+        );
+        assert_eq!(total.requests, 3700);
+        assert_eq!(total.error_rate, 0.14);
+        assert_eq!(total.p99_ms, Some(950.0));
+    }
+
+    #[test]
+    fn test_build_service_summary_without_traffic() {
+        let empty = serde_json::json!({"data": []});
+        let (total, resources) = build_service_summary(&empty, &empty, &empty, &empty, 60);
+        assert!(resources.is_empty());
+        assert_eq!(total.requests, 0);
+        assert_eq!(total.p50_ms, None);
+        assert_eq!(total.error_rate, 0.0);
+    }
+}
@@ -11,6 +11,7 @@ use datadog_api_client::datadogV2::model::ApplicationKeysSort;
 use crate::client;
 use crate::config::Config;
 use crate::formatter;
+use crate::util;
 
 #[cfg(not(target_arch = "wasm32"))]
 fn parse_sort(s: &str) -> Result<ApplicationKeysSort> {
@@ -155,6 +156,46 @@ pub async fn list_all(
     crate::formatter::output(cfg, &data)
 }
 
+// ---------------------------------------------------------------------------
+// List every page of application keys (--all-pages)
+// ---------------------------------------------------------------------------
+
+/// Largest `page[size]` the key management endpoints accept.
+const KEYS_PAGE_MAX: usize = 100;
+
+/// Follow page numbers through the current user's keys, or the whole org's
+/// keys when `org_wide` is set (same split as `list` / `list_all`).
+pub async fn list_all_pages(
+    cfg: &Config,
+    org_wide: bool,
+    filter: &str,
+    sort: &str,
+    max_items: usize,
+) -> Result<()> {
+    let path = if org_wide {
+        "/api/v2/application_keys"
+    } else {
+        "/api/v2/current_user/application_keys"
+    };
+    let size = util::page_size(KEYS_PAGE_MAX, max_items);
+    let collected =
+        util::collect_pages(util::Paging::Number { size }, "/data", max_items, |page| {
+            let mut query = vec![
+                ("page[size]", size.to_string()),
+                ("page[number]", page.number.to_string()),
+            ];
+            if !filter.is_empty() {
+                query.push(("filter", filter.to_string()));
+            }
+            if !sort.is_empty() {
+                query.push(("sort", sort.to_string()));
+            }
+            async move { crate::api::get(cfg, path, &query).await }
+        })
+        .await?;
+    util::print_collected(cfg, "app-keys list", collected)
+}
+
 // ---------------------------------------------------------------------------
 // Get application key details (current user)
 // ---------------------------------------------------------------------------
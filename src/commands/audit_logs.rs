@@ -126,3 +126,190 @@ pub async fn search(
     let data = crate::api::post(cfg, "/api/v2/audit/events/search", &body).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Export (paginate a window to newline-delimited JSON, optionally follow)
+// ---------------------------------------------------------------------------
+
+/// Largest page the audit search endpoint returns.
+const EXPORT_PAGE_SIZE: usize = 1000;
+
+/// `ms` as an RFC 3339 time; an error when it is outside the representable
+/// range (e.g. a mistyped Unix timestamp).
+fn rfc3339(ms: i64) -> Result<String> {
+    chrono::DateTime::from_timestamp_millis(ms)
+        .map(|t| t.to_rfc3339())
+        .ok_or_else(|| {
+            crate::exit_code::Failure::validation(format!(
+                "time out of range: {ms} ms since the Unix epoch"
+            ))
+        })
+}
+
+fn export_filter(query: &str, from_ms: i64, to: &str) -> Result<serde_json::Value> {
+    let from = rfc3339(from_ms)?;
+    Ok(serde_json::json!({ "query": query, "from": from, "to": to }))
+}
+
+fn write_events(
+    out: &mut impl std::io::Write,
+    path: &str,
+    events: &[crate::commands::logs::TailEvent],
+) -> Result<()> {
+    let write = |out: &mut dyn std::io::Write| -> std::io::Result<()> {
+        for event in events {
+            serde_json::to_writer(&mut *out, &event.raw)?;
+            out.write_all(b"\n")?;
+        }
+        out.flush()
+    };
+    write(out).map_err(|e| anyhow::anyhow!("failed to write {path}: {e}"))
+}
+
+/// Write every event in the window to `out` oldest first, page by page, and
+/// return how many were new to `cursor`.
+async fn export_window(
+    cfg: &Config,
+    query: &str,
+    from_ms: i64,
+    to: &str,
+    cursor: &mut crate::commands::logs::TailCursor,
+    out: &mut impl std::io::Write,
+    path: &str,
+) -> Result<usize> {
+    let filter = export_filter(query, from_ms, to)?;
+    let mut page_cursor: Option<String> = None;
+    let mut written = 0;
+    loop {
+        let mut page = serde_json::json!({ "limit": EXPORT_PAGE_SIZE });
+        if let Some(c) = &page_cursor {
+            page["cursor"] = serde_json::Value::String(c.clone());
+        }
+        let body = serde_json::json!({ "filter": filter, "page": page, "sort": "timestamp" });
+        let resp = crate::api::post(cfg, "/api/v2/audit/events/search", &body)
+            .await
+            .map_err(|e| crate::api::failed("failed to export audit logs", e))?;
+        let items = resp["data"].as_array().cloned().unwrap_or_default();
+        let events = items
+            .iter()
+            .filter_map(crate::commands::logs::tail_event)
+            .collect();
+        let fresh = cursor.fresh(events);
+        write_events(out, path, &fresh)?;
+        written += fresh.len();
+        page_cursor = resp
+            .pointer("/meta/page/after")
+            .and_then(|v| v.as_str())
+            .map(String::from);
+        if items.is_empty() || page_cursor.is_none() {
+            return Ok(written);
+        }
+    }
+}
+
+/// Write every audit event in `[from, to]` to `output_file` as JSON lines.
+/// With `follow`, keep polling every `interval_secs` and append new events
+/// until interrupted.
+pub async fn export(
+    cfg: &Config,
+    query: String,
+    from: String,
+    to: String,
+    output_file: String,
+    follow: bool,
+    interval_secs: u64,
+) -> Result<()> {
+    if follow && to != "now" {
+        anyhow::bail!("invalid --to {to:?}: --follow exports up to now");
+    }
+    let from_ms = util::parse_time_to_unix_millis(&from)?;
+    let to_bound = if to == "now" {
+        "now".to_string()
+    } else {
+        rfc3339(util::parse_time_to_unix_millis(&to)?)?
+    };
+    // Fail on a bad --from before creating the output file.
+    rfc3339(from_ms)?;
+    let mut file = std::fs::File::create(&output_file)
+        .map(std::io::BufWriter::new)
+        .map_err(|e| anyhow::anyhow!("failed to create {output_file}: {e}"))?;
+    let mut cursor = crate::commands::logs::TailCursor::new(from_ms);
+    let count = export_window(
+        cfg,
+        &query,
+        from_ms,
+        &to_bound,
+        &mut cursor,
+        &mut file,
+        &output_file,
+    )
+    .await?;
+    eprintln!("Exported {count} audit events to {output_file}.");
+    if !follow {
+        return Ok(());
+    }
+    follow_export(cfg, &query, cursor, &mut file, &output_file, interval_secs).await
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn follow_export(
+    cfg: &Config,
+    query: &str,
+    mut cursor: crate::commands::logs::TailCursor,
+    out: &mut impl std::io::Write,
+    path: &str,
+    interval_secs: u64,
+) -> Result<()> {
+    eprintln!("Following audit events into {path} (Ctrl-C to stop)...");
+    let interval = std::time::Duration::from_secs(interval_secs.max(1));
+    loop {
+        tokio::time::sleep(interval).await;
+        let from_ms = cursor.next_from_ms();
+        // Keep following through transient failures; the cursor re-reads the gap.
+        match export_window(cfg, query, from_ms, "now", &mut cursor, out, path).await {
+            Ok(0) => {}
+            Ok(n) => eprintln!("Appended {n} audit events."),
+            Err(e) => eprintln!("warning: {e}"),
+        }
+    }
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn follow_export(
+    _cfg: &Config,
+    _query: &str,
+    _cursor: crate::commands::logs::TailCursor,
+    _out: &mut impl std::io::Write,
+    _path: &str,
+    _interval_secs: u64,
+) -> Result<()> {
+    anyhow::bail!("--follow is not supported in WASM builds")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_export_filter() {
+        let filter = export_filter("@evt.name:dashboard", 1_700_000_000_000, "now").unwrap();
+        assert_eq!(
+            filter,
+            serde_json::json!({
+                "query": "@evt.name:dashboard",
+                "from": "2023-11-14T22:13:20+00:00",
+                "to": "now"
+            })
+        );
+    }
+
+    #[test]
+    fn test_export_filter_rejects_out_of_range_times() {
+        let err = export_filter("*", i64::MAX, "now").unwrap_err();
+        assert!(err.to_string().contains("out of range"), "{err}");
+        assert_eq!(
+            crate::exit_code::classify(&err),
+            crate::exit_code::VALIDATION
+        );
+    }
+}
@@ -4,7 +4,7 @@ use crate::auth::storage;
 use crate::config::Config;
 
 /// Helper to run a closure with the storage lock held (non-async to avoid holding lock across await).
-fn with_storage<F, R>(f: F) -> Result<R>
+pub(crate) fn with_storage<F, R>(f: F) -> Result<R>
 where
     F: FnOnce(&mut dyn storage::Storage) -> Result<R>,
 {
@@ -15,23 +15,36 @@ where
 }
 
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn login(cfg: &Config) -> Result<()> {
-    use crate::auth::{dcr, pkce, types};
+pub async fn login(cfg: &Config, no_browser: bool) -> Result<()> {
+    use crate::auth::{callback, dcr, pkce, types};
 
     let site = &cfg.site;
+    crate::auth::sites::SiteAuth::new(site).require_oauth_login()?;
     let org = cfg.org.as_deref();
-
-    // 1. Start callback server
-    let mut server = crate::auth::callback::CallbackServer::new().await?;
-    let redirect_uri = server.redirect_uri();
     let org_label = org.map(|o| format!(" (org: {o})")).unwrap_or_default();
     eprintln!("\n🔐 Starting OAuth2 login for site: {site}{org_label}\n");
-    eprintln!("📡 Callback server started on: {redirect_uri}");
 
-    // 2. Load existing client credentials (lock released before any await)
+    // 1. Load existing client credentials (lock released before any await)
     // Client credentials are site-scoped (DCR is per-site, shared across orgs)
     let existing_creds = with_storage(|store| store.load_client_credentials(site))?;
 
+    // 2. Start callback server. With --no-browser nothing listens: the user
+    // authorizes on another machine and pastes the redirect URL back, so the
+    // redirect only has to match the client registration.
+    let mut server = if no_browser {
+        None
+    } else {
+        Some(callback::CallbackServer::new().await?)
+    };
+    let redirect_uri = match (&server, &existing_creds) {
+        (Some(server), _) => server.redirect_uri(),
+        (None, Some(creds)) if !creds.redirect_uris.is_empty() => creds.redirect_uris[0].clone(),
+        (None, _) => dcr::get_redirect_uris().remove(0),
+    };
+    if server.is_some() {
+        eprintln!("📡 Callback server started on: {redirect_uri}");
+    }
+
     let creds = match existing_creds {
         Some(creds) => {
             eprintln!("✓ Using existing client registration");
@@ -48,36 +61,64 @@ pub async fn login(cfg: &Config) -> Result<()> {
         }
     };
 
-    // 3. Generate PKCE challenge + state
-    let challenge = pkce::generate_pkce_challenge()?;
-    let state = pkce::generate_state()?;
-
-    // 4. Build authorization URL
+    // 3-6. Authorize in the browser. Orgs that cannot grant an optional scope
+    // reject the whole request with `invalid_scope`, so retry with smaller sets.
     let dcr_client = dcr::DcrClient::new(site);
-    let scopes = types::default_scopes();
-    let auth_url = dcr_client.build_authorization_url(
-        &creds.client_id,
-        &redirect_uri,
-        &state,
-        &challenge,
-        &scopes,
-    );
-
-    // 5. Open browser
-    eprintln!("\n🌐 Opening browser for authentication...");
-    eprintln!("If the browser doesn't open, visit: {auth_url}");
-    let _ = open::that(&auth_url);
-
-    // 6. Wait for callback
-    eprintln!("\n⏳ Waiting for authorization...");
-    let result = server
-        .wait_for_callback(std::time::Duration::from_secs(300))
-        .await?;
-
-    if let Some(err) = &result.error {
-        let desc = result.error_description.as_deref().unwrap_or("");
-        bail!("OAuth error: {err}: {desc}");
-    }
+    let candidates = types::scope_candidates();
+    let mut attempt = 0;
+    let (result, challenge, state, scopes) = loop {
+        let scopes = &candidates[attempt];
+        let challenge = pkce::generate_pkce_challenge()?;
+        let state = pkce::generate_state()?;
+        let auth_url = dcr_client.build_authorization_url(
+            &creds.client_id,
+            &redirect_uri,
+            &state,
+            &challenge,
+            scopes,
+        );
+
+        let result = match server.as_mut() {
+            Some(server) => {
+                eprintln!("\n🌐 Opening browser for authentication...");
+                eprintln!("If the browser doesn't open, visit: {auth_url}");
+                let _ = open::that(&auth_url);
+
+                eprintln!("\n⏳ Waiting for authorization...");
+                server
+                    .wait_for_callback(std::time::Duration::from_secs(300))
+                    .await?
+            }
+            None => {
+                eprintln!("\n🌐 Open this URL in a browser on any machine and approve access:");
+                eprintln!("\n   {auth_url}\n");
+                eprintln!(
+                    "The browser then fails to load a {redirect_uri} page; that is expected."
+                );
+                eprint!("Paste the full URL from its address bar here: ");
+                let _ = std::io::Write::flush(&mut std::io::stderr());
+                let mut input = String::new();
+                std::io::stdin().read_line(&mut input)?;
+                callback::parse_pasted_callback(&input)?
+            }
+        };
+
+        match result.error.as_deref() {
+            Some("invalid_scope") if attempt + 1 < candidates.len() => {
+                attempt += 1;
+                let dropped = types::dropped_scopes(&candidates[attempt]);
+                eprintln!(
+                    "⚠️  Scope request rejected (invalid_scope); retrying without: {}",
+                    dropped.join(", ")
+                );
+            }
+            Some(err) => {
+                let desc = result.error_description.as_deref().unwrap_or("");
+                bail!("OAuth error: {err}: {desc}");
+            }
+            None => break (result, challenge, state, scopes),
+        }
+    };
 
     if result.state != state {
         bail!("OAuth state mismatch (possible CSRF attack)");
@@ -85,9 +126,14 @@ pub async fn login(cfg: &Config) -> Result<()> {
 
     // 7. Exchange code for tokens
     eprintln!("🔄 Exchanging authorization code for tokens...");
-    let tokens = dcr_client
+    let mut tokens = dcr_client
         .exchange_code(&result.code, &redirect_uri, &challenge.verifier, &creds)
         .await?;
+    // Record what was granted; fall back to what was requested when the
+    // token response omits `scope`.
+    if tokens.scope.is_empty() {
+        tokens.scope = scopes.join(" ");
+    }
 
     let location = with_storage(|store| {
         store.save_tokens(site, org, &tokens)?;
@@ -104,12 +150,18 @@ pub async fn login(cfg: &Config) -> Result<()> {
     eprintln!("\n✅ Login successful{org_label}!");
     eprintln!("   Access token expires: {expires_at}");
     eprintln!("   Token stored in: {location}");
+    if attempt > 0 {
+        eprintln!(
+            "   Scopes not granted by this org: {}",
+            types::dropped_scopes(scopes).join(", ")
+        );
+    }
 
     Ok(())
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn login(_cfg: &Config) -> Result<()> {
+pub async fn login(_cfg: &Config, _no_browser: bool) -> Result<()> {
     bail!(
         "OAuth login is not available in WASM builds.\n\
          Use DD_ACCESS_TOKEN env var for bearer token auth,\n\
@@ -196,7 +248,7 @@ pub fn status(cfg: &Config) -> Result<()> {
                     "status": status,
                     "token_type": tokens.token_type,
                 });
-                println!("{}", serde_json::to_string_pretty(&json).unwrap());
+                crate::formatter::output(cfg, &json)?;
             }
             None => {
                 let org_label = org.map(|o| format!(" (org: {o})")).unwrap_or_default();
@@ -207,7 +259,7 @@ pub fn status(cfg: &Config) -> Result<()> {
                     "site": site,
                     "status": "no token",
                 });
-                println!("{}", serde_json::to_string_pretty(&json).unwrap());
+                crate::formatter::output(cfg, &json)?;
             }
         }
         Ok(())
@@ -230,12 +282,16 @@ pub fn token(cfg: &Config) -> Result<()> {
         with_storage(|store| match store.load_tokens(site, org)? {
             Some(tokens) => {
                 if tokens.is_expired() {
-                    bail!("token is expired — run 'pup auth login' to refresh");
+                    return Err(crate::exit_code::Failure::auth(
+                        "token is expired — run 'pup auth login' to refresh",
+                    ));
                 }
                 println!("{}", tokens.access_token);
                 Ok(())
             }
-            None => bail!("no token available — run 'pup auth login' or set DD_ACCESS_TOKEN"),
+            None => Err(crate::exit_code::Failure::auth(
+                "no token available — run 'pup auth login' or set DD_ACCESS_TOKEN",
+            )),
         })
     }
 }
@@ -248,15 +304,21 @@ pub async fn refresh(cfg: &Config) -> Result<()> {
     let org = cfg.org.as_deref();
 
     let tokens = with_storage(|store| store.load_tokens(site, org))?.ok_or_else(|| {
-        anyhow::anyhow!("no tokens found for site {site} — run 'pup auth login' first")
+        crate::exit_code::Failure::auth(format!(
+            "no tokens found for site {site} — run 'pup auth login' first"
+        ))
     })?;
 
     if tokens.refresh_token.is_empty() {
-        bail!("no refresh token available — run 'pup auth login' to re-authenticate");
+        return Err(crate::exit_code::Failure::auth(
+            "no refresh token available — run 'pup auth login' to re-authenticate",
+        ));
     }
 
     let creds = with_storage(|store| store.load_client_credentials(site))?.ok_or_else(|| {
-        anyhow::anyhow!("no client credentials found for site {site} — run 'pup auth login' first")
+        crate::exit_code::Failure::auth(format!(
+            "no client credentials found for site {site} — run 'pup auth login' first"
+        ))
     })?;
 
     let org_label = org.map(|o| format!(" (org: {o})")).unwrap_or_default();
@@ -299,6 +361,122 @@ pub fn list(cfg: &Config) -> Result<()> {
     crate::formatter::output(cfg, &sessions)
 }
 
+/// Move credential files left by the file backend into the OS keychain.
+#[cfg(not(target_arch = "wasm32"))]
+pub fn migrate(dry_run: bool) -> Result<()> {
+    let files = storage::migrate_files(dry_run)?;
+    if files.is_empty() {
+        eprintln!("No credential files to migrate.");
+        return Ok(());
+    }
+    for path in &files {
+        println!("{}", path.display());
+    }
+    if dry_run {
+        eprintln!(
+            "{} file(s) would be moved into the OS keychain.",
+            files.len()
+        );
+    } else {
+        eprintln!("Moved {} file(s) into the OS keychain.", files.len());
+    }
+    Ok(())
+}
+
+#[cfg(target_arch = "wasm32")]
+pub fn migrate(_dry_run: bool) -> Result<()> {
+    bail!(
+        "pup auth migrate is not available in WASM builds.\n\
+         Token storage is not available — credentials are read from environment variables."
+    )
+}
+
+/// Granted scopes against the scopes `pup auth login` requests. `granted` is
+/// None when there is no stored session to inspect.
+pub fn scope_report(granted: Option<&str>) -> serde_json::Value {
+    let default = crate::auth::types::default_scopes();
+    let Some(granted) = granted else {
+        return serde_json::json!({
+            "granted": null,
+            "default": default,
+        });
+    };
+    let mut granted: Vec<&str> = granted.split_whitespace().collect();
+    granted.sort_unstable();
+    granted.dedup();
+    let missing: Vec<&str> = default
+        .iter()
+        .copied()
+        .filter(|s| !granted.contains(s))
+        .collect();
+    let additional: Vec<&str> = granted
+        .iter()
+        .copied()
+        .filter(|s| !default.contains(s))
+        .collect();
+    serde_json::json!({
+        "granted": granted,
+        "default": default,
+        "missing": missing,
+        "additional": additional,
+    })
+}
+
+pub fn scopes(cfg: &Config) -> Result<()> {
+    let site = &cfg.site;
+    let org = cfg.org.as_deref();
+
+    #[cfg(not(target_arch = "wasm32"))]
+    let tokens = with_storage(|store| store.load_tokens(site, org))?;
+    #[cfg(target_arch = "wasm32")]
+    let tokens: Option<crate::auth::types::TokenSet> = None;
+
+    let org_label = org.map(|o| format!(" (org: {o})")).unwrap_or_default();
+    let mut report = scope_report(tokens.as_ref().map(|t| t.scope.as_str()));
+    match &tokens {
+        None => eprintln!(
+            "No stored session for site: {site}{org_label}; showing the scopes login requests."
+        ),
+        Some(_) if report["missing"].as_array().is_some_and(|m| !m.is_empty()) => eprintln!(
+            "⚠️  Session is missing default scopes. Run 'pup auth logout' then 'pup auth login' \
+             to re-register with them."
+        ),
+        Some(_) => {}
+    }
+    report["site"] = site.as_str().into();
+    report["org"] = org.into();
+    crate::formatter::output(cfg, &report)
+}
+
+/// The auth method the current config authenticates with: a token from
+/// DD_ACCESS_TOKEN, a stored `pup auth login` session, or API keys.
+pub fn method_in_use(cfg: &Config, token_from_env: bool) -> Option<&'static str> {
+    if cfg.has_bearer_token() {
+        Some(if token_from_env {
+            "bearer_token"
+        } else {
+            "oauth2"
+        })
+    } else if cfg.has_api_keys() {
+        Some("api_keys")
+    } else {
+        None
+    }
+}
+
+/// Which auth methods the configured site supports, and which is in use.
+pub fn capabilities(cfg: &Config) -> Result<()> {
+    let site = crate::auth::sites::SiteAuth::new(&cfg.site);
+    let token_from_env = std::env::var("DD_ACCESS_TOKEN").is_ok_and(|t| !t.is_empty());
+    let in_use = method_in_use(cfg, token_from_env);
+    let gov_label = if site.gov { " (US1-FED)" } else { "" };
+    eprintln!("Site: {}{gov_label}", site.site);
+    if in_use.is_none() {
+        eprintln!("No credentials configured.");
+    }
+    crate::formatter::output(cfg, &site.method_rows(in_use))
+}
+
 #[cfg(target_arch = "wasm32")]
 pub fn list(_cfg: &Config) -> Result<()> {
     bail!(
@@ -306,3 +484,187 @@ pub fn list(_cfg: &Config) -> Result<()> {
          Session storage is not available — credentials are read from environment variables."
     )
 }
+
+/// Credential variables removed from the inherited environment before `auth exec`
+/// injects its own, so the child never sees stale or long-lived values by accident.
+#[cfg(not(target_arch = "wasm32"))]
+const CREDENTIAL_ENV_VARS: &[&str] = &[
+    "DD_ACCESS_TOKEN",
+    "DD_API_KEY",
+    "DD_APP_KEY",
+    "DD_APPLICATION_KEY",
+    "DATADOG_API_KEY",
+    "DATADOG_APP_KEY",
+    "DD_API_KEY_FILE",
+    "DD_APP_KEY_FILE",
+    "PUP_CREDENTIAL_PROCESS",
+];
+
+/// Pick the credentials to inject: the OAuth access token unless `use_keys` is set,
+/// falling back to API + APP keys.
+#[cfg(not(target_arch = "wasm32"))]
+fn exec_credentials(cfg: &Config, use_keys: bool) -> Result<Vec<(&'static str, String)>> {
+    if !use_keys {
+        if let Some(token) = &cfg.access_token {
+            return Ok(vec![("DD_ACCESS_TOKEN", token.clone())]);
+        }
+    }
+    match (&cfg.api_key, &cfg.app_key) {
+        (Some(api), Some(app)) => {
+            if !use_keys {
+                eprintln!(
+                    "⚠️  No OAuth token available; injecting long-lived API + APP keys. \
+                     Run 'pup auth login' to use short-lived tokens instead."
+                );
+            }
+            Ok(vec![
+                ("DD_API_KEY", api.clone()),
+                ("DD_APP_KEY", app.clone()),
+            ])
+        }
+        _ if use_keys => Err(crate::exit_code::Failure::auth(
+            "--keys requires DD_API_KEY and DD_APP_KEY to be configured",
+        )),
+        _ => Err(crate::exit_code::Failure::auth(
+            "no credentials available — run 'pup auth login' or set DD_API_KEY and DD_APP_KEY",
+        )),
+    }
+}
+
+/// Run a command with credentials present only in the child's environment.
+/// Returns the child's exit code. Nothing is written to disk or to the parent shell;
+/// the injected values go away with the child process.
+#[cfg(not(target_arch = "wasm32"))]
+pub fn exec(cfg: &mut Config, use_keys: bool, command: &[String]) -> Result<i32> {
+    let Some((program, args)) = command.split_first() else {
+        bail!("no command given — usage: pup auth exec -- <command> [args...]");
+    };
+    // Keys are injected with --keys or when there is no token, and may come
+    // only from credential_process.
+    if use_keys || cfg.access_token.is_none() {
+        cfg.resolve_credential_process()?;
+    }
+
+    // A token loaded from storage (not DD_ACCESS_TOKEN) may have expired since login.
+    let from_storage = std::env::var("DD_ACCESS_TOKEN")
+        .ok()
+        .filter(|s| !s.is_empty())
+        .is_none();
+    if !use_keys && from_storage && cfg.access_token.is_some() {
+        let expired = with_storage(|store| store.load_tokens(&cfg.site, cfg.org.as_deref()))?
+            .map(|t| t.is_expired())
+            .unwrap_or(false);
+        if expired {
+            bail!("token is expired — run 'pup auth refresh' or 'pup auth login' first");
+        }
+    }
+
+    let creds = exec_credentials(cfg, use_keys)?;
+    let mut cmd = std::process::Command::new(program);
+    cmd.args(args);
+    for var in CREDENTIAL_ENV_VARS {
+        cmd.env_remove(var);
+    }
+    cmd.envs(creds.iter().map(|(k, v)| (*k, v.as_str())));
+    cmd.env("DD_SITE", &cfg.site);
+    let status = cmd
+        .status()
+        .map_err(|e| anyhow::anyhow!("failed to run {program:?}: {e}"))?;
+    // Killed by a signal: mirror the shell convention of 128 + signal.
+    #[cfg(unix)]
+    if let Some(sig) = std::os::unix::process::ExitStatusExt::signal(&status) {
+        return Ok(128 + sig);
+    }
+    Ok(status.code().unwrap_or(1))
+}
+
+#[cfg(target_arch = "wasm32")]
+pub fn exec(_cfg: &mut Config, _use_keys: bool, _command: &[String]) -> Result<i32> {
+    bail!("pup auth exec is not available in WASM builds — subprocesses are not supported.")
+}
+
+#[cfg(all(test, not(target_arch = "wasm32")))]
+mod tests {
+    use super::*;
+
+    fn cfg(token: Option<&str>, keys: bool) -> Config {
+        Config {
+            api_key: keys.then(|| "api".to_string()),
+            app_key: keys.then(|| "app".to_string()),
+            access_token: token.map(String::from),
+            ..crate::test_utils::config()
+        }
+    }
+
+    #[test]
+    fn test_exec_credentials_prefers_token() {
+        let creds = exec_credentials(&cfg(Some("tok"), true), false).unwrap();
+        assert_eq!(creds, vec![("DD_ACCESS_TOKEN", "tok".to_string())]);
+    }
+
+    #[test]
+    fn test_exec_credentials_keys() {
+        let creds = exec_credentials(&cfg(Some("tok"), true), true).unwrap();
+        assert_eq!(
+            creds,
+            vec![
+                ("DD_API_KEY", "api".to_string()),
+                ("DD_APP_KEY", "app".to_string())
+            ]
+        );
+        let creds = exec_credentials(&cfg(None, true), false).unwrap();
+        assert_eq!(creds.len(), 2);
+    }
+
+    #[test]
+    fn test_exec_credentials_none() {
+        assert!(exec_credentials(&cfg(None, false), false).is_err());
+        assert!(exec_credentials(&cfg(Some("tok"), false), true).is_err());
+    }
+
+    #[test]
+    fn test_exec_requires_command() {
+        assert!(exec(&mut cfg(Some("tok"), false), false, &[]).is_err());
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn test_exec_without_token_uses_credential_process_keys() {
+        let mut cfg = Config {
+            credential_process: Some(r#"printf '{"api_key":"cp-api","app_key":"cp-app"}'"#.into()),
+            ..cfg(None, false)
+        };
+        let check = r#"test "$DD_API_KEY" = cp-api && test "$DD_APP_KEY" = cp-app"#;
+        let command = ["sh".to_string(), "-c".to_string(), check.to_string()];
+        assert_eq!(exec(&mut cfg, false, &command).unwrap(), 0);
+        assert!(cfg.credential_process.is_none());
+    }
+
+    #[test]
+    fn test_method_in_use() {
+        assert_eq!(
+            method_in_use(&cfg(Some("tok"), true), true),
+            Some("bearer_token")
+        );
+        assert_eq!(
+            method_in_use(&cfg(Some("tok"), false), false),
+            Some("oauth2")
+        );
+        assert_eq!(method_in_use(&cfg(None, true), false), Some("api_keys"));
+        assert_eq!(method_in_use(&cfg(None, false), false), None);
+    }
+
+    #[test]
+    fn test_scope_report() {
+        let report = scope_report(Some("monitors_read custom_scope monitors_read"));
+        assert_eq!(
+            report["granted"],
+            serde_json::json!(["custom_scope", "monitors_read"])
+        );
+        assert_eq!(report["additional"], serde_json::json!(["custom_scope"]));
+        let missing = report["missing"].as_array().unwrap();
+        assert!(missing.contains(&"monitors_write".into()));
+        assert!(!missing.contains(&"monitors_read".into()));
+        assert!(scope_report(None)["granted"].is_null());
+    }
+}
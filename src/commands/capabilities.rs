@@ -0,0 +1,812 @@
+use anyhow::{bail, Result};
+use serde::Serialize;
+
+use crate::config::Config;
+use crate::formatter;
+
+// ---------------------------------------------------------------------------
+// Capability registry
+//
+// One entry per top-level command: the API path prefixes it calls and the
+// OAuth scopes it needs for reads and for writes. Everything else in the
+// manifest (leaf commands, read/write classification, OAuth support) is
+// derived from the clap tree and the OAuth exclusion table in client.rs, so
+// this table is the only place that needs updating when a domain is added.
+// ---------------------------------------------------------------------------
+
+/// Registry entry for one top-level command.
+pub struct DomainCapability {
+    pub command: &'static str,
+    /// API path prefixes called by the domain. Empty means the command is local.
+    pub endpoints: &'static [&'static str],
+    pub read_scopes: &'static [&'static str],
+    /// Additional scopes needed by write and destructive subcommands.
+    pub write_scopes: &'static [&'static str],
+}
+
+const fn domain(
+    command: &'static str,
+    endpoints: &'static [&'static str],
+    read_scopes: &'static [&'static str],
+    write_scopes: &'static [&'static str],
+) -> DomainCapability {
+    DomainCapability {
+        command,
+        endpoints,
+        read_scopes,
+        write_scopes,
+    }
+}
+
+pub static DOMAINS: &[DomainCapability] = &[
+    domain("agent", &[], &[], &[]),
+    domain("alias", &[], &[], &[]),
+    domain(
+        "api-keys",
+        &["/api/v2/api_keys"],
+        &["api_keys_read"],
+        &["api_keys_write"],
+    ),
+    domain(
+        "apm",
+        &[
+            "/api/v1/service_dependencies",
+            "/api/v1/trace/operation_names",
+            "/api/v2/apm/services",
+            "/api/v2/spans/analytics/aggregate",
+        ],
+        &["apm_read"],
+        &[],
+    ),
+    domain(
+        "app-keys",
+        &[
+            "/api/v2/application_keys",
+            "/api/v2/current_user/application_keys",
+        ],
+        &[],
+        &[],
+    ),
+    domain(
+        "audit-logs",
+        &["/api/v2/audit/events"],
+        &["audit_logs_read"],
+        &[],
+    ),
+    domain("auth", &[], &[], &[]),
+    domain("capabilities", &[], &[], &[]),
+    domain(
+        "cases",
+        &["/api/v2/cases", "/api/v2/case-management/projects"],
+        &["cases_read"],
+        &["cases_write"],
+    ),
+    domain(
+        "cicd",
+        &[
+            "/api/v2/ci/pipelines",
+            "/api/v2/ci/tests",
+            "/api/v2/dora/deployments",
+        ],
+        &[],
+        &[],
+    ),
+    domain(
+        "cloud",
+        &[
+            "/api/v1/integration/aws",
+            "/api/v1/integration/azure",
+            "/api/v1/integration/gcp",
+            "/api/v2/integration/gcp",
+            "/api/v2/integration/oci",
+        ],
+        &["oci_configuration_read"],
+        &["oci_configuration_edit", "oci_configurations_manage"],
+    ),
+    domain(
+        "cloud-cost",
+        &[
+            "/api/v2/cost/aws_cur_config",
+            "/api/v2/cost/azure_uc_config",
+            "/api/v2/cost/gcp_uc_config",
+        ],
+        &["cloud_cost_management_read"],
+        &["cloud_cost_management_write"],
+    ),
+    domain("code-coverage", &["/api/v2/ci/code-coverage"], &[], &[]),
+    domain(
+        "codegen",
+        &["/api/v1/dashboard", "/api/v1/monitor", "/api/v1/slo"],
+        &["dashboards_read", "monitors_read", "slos_read"],
+        &[],
+    ),
+    domain("completions", &[], &[], &[]),
+    domain("config", &[], &[], &[]),
+    domain(
+        "cost",
+        &[
+            "/api/v2/cost_by_tag",
+            "/api/v2/usage/cost_by_org",
+            "/api/v2/usage/projected_cost",
+        ],
+        &["usage_read"],
+        &[],
+    ),
+    domain(
+        "dashboards",
+        &["/api/v1/dashboard"],
+        &["dashboards_read"],
+        &["dashboards_write"],
+    ),
+    domain(
+        "data-governance",
+        &["/api/v2/sensitive-data-scanner/config"],
+        &["data_scanner_read"],
+        &["data_scanner_write"],
+    ),
+    domain("doctor", &["/api/v1/validate"], &[], &[]),
+    domain(
+        "downtime",
+        &["/api/v2/downtime"],
+        &["monitors_read"],
+        &["monitors_downtime"],
+    ),
+    domain(
+        "error-tracking",
+        &["/api/v2/error-tracking/issues"],
+        &["error_tracking_read"],
+        &[],
+    ),
+    domain(
+        "events",
+        &["/api/v1/events", "/api/v2/events", "/api/v2/events/search"],
+        &["events_read"],
+        &[],
+    ),
+    domain("examples", &[], &[], &[]),
+    domain("fanout", &[], &[], &[]),
+    domain(
+        "find",
+        &[
+            "/api/v1/monitor",
+            "/api/v1/dashboard",
+            "/api/v1/slo",
+            "/api/v1/notebooks",
+            "/api/v1/synthetics/tests",
+        ],
+        &[
+            "monitors_read",
+            "dashboards_read",
+            "slos_read",
+            "notebooks_read",
+            "synthetics_read",
+        ],
+        &[],
+    ),
+    domain(
+        "fleet",
+        &[
+            "/api/v2/fleet/agents",
+            "/api/v2/fleet/deployments",
+            "/api/v2/fleet/schedules",
+        ],
+        &[],
+        &[],
+    ),
+    domain("hamr", &["/api/v2/hamr/connections"], &[], &[]),
+    domain(
+        "incidents",
+        &[
+            "/api/v2/incidents",
+            "/api/v1/notebooks",
+            "/api/v2/events",
+            "/api/v2/users",
+        ],
+        &["incident_read", "incident_settings_read"],
+        &["incident_write", "incident_settings_write"],
+    ),
+    domain("infrastructure", &["/api/v1/hosts"], &["hosts_read"], &[]),
+    domain(
+        "integrations",
+        &[
+            "/api/v1/integration/pagerduty",
+            "/api/v1/integration/slack",
+            "/api/v1/integration/webhooks",
+            "/api/v2/integration/aws",
+            "/api/v2/integration/jira",
+            "/api/v2/integration/opsgenie",
+            "/api/v2/integration/servicenow",
+        ],
+        &[],
+        &[],
+    ),
+    domain(
+        "investigations",
+        &["/api/v2/bits-ai/investigations"],
+        &[],
+        &[],
+    ),
+    domain(
+        "ip-allowlist",
+        &["/api/v2/ip_allowlist"],
+        &["org_management"],
+        &["org_management"],
+    ),
+    domain(
+        "logs",
+        &[
+            "/api/v2/logs/events/search",
+            "/api/v2/logs/analytics/aggregate",
+            "/api/v2/logs/config",
+            "/api/v1/logs/config",
+        ],
+        &[
+            "logs_read_data",
+            "logs_read_index_data",
+            "logs_read_config",
+            "logs_read_archives",
+        ],
+        &[
+            "logs_write_archives",
+            "logs_write_pipelines",
+            "logs_modify_indexes",
+            "logs_generate_metrics",
+            "logs_write_forwarding_rules",
+        ],
+    ),
+    domain(
+        "metrics",
+        &[
+            "/api/v1/metrics",
+            "/api/v1/query",
+            "/api/v1/search",
+            "/api/v2/metrics",
+            "/api/v2/query/timeseries",
+            "/api/v2/series",
+        ],
+        &["metrics_read", "timeseries_query"],
+        &[],
+    ),
+    domain("misc", &["/api/v1/ip_ranges", "/api/v1/validate"], &[], &[]),
+    domain(
+        "monitors",
+        &["/api/v1/monitor", "/api/v1/query"],
+        &["monitors_read", "timeseries_query"],
+        &["monitors_write"],
+    ),
+    domain("network", &[], &[], &[]),
+    domain("notebooks", &["/api/v1/notebooks"], &[], &[]),
+    domain("obs-pipelines", &[], &[], &[]),
+    domain(
+        "on-call",
+        &["/api/v2/on-call", "/api/v2/teams"],
+        &["on_call_read"],
+        &["on_call_write"],
+    ),
+    domain(
+        "organizations",
+        &["/api/v1/org"],
+        &["user_self_profile_read"],
+        &[],
+    ),
+    domain(
+        "product-analytics",
+        &["/api/v2/product-analytics/events"],
+        &[],
+        &[],
+    ),
+    domain(
+        "reference-tables",
+        &["/api/v2/reference-tables"],
+        &["reference_tables_read"],
+        &["reference_tables_write"],
+    ),
+    domain(
+        "restriction-policies",
+        &["/api/v2/restriction_policy"],
+        &[],
+        &[],
+    ),
+    domain(
+        "rum",
+        &[
+            "/api/v2/rum/applications",
+            "/api/v2/rum/events/search",
+            "/api/v2/rum/metrics",
+            "/api/v2/rum/retention_filters",
+            "/api/v2/rum/replay",
+        ],
+        &["rum_apps_read", "rum_retention_filters_read"],
+        &["rum_apps_write", "rum_retention_filters_write"],
+    ),
+    domain("scorecards", &[], &[], &[]),
+    domain(
+        "security",
+        &[
+            "/api/v2/security_monitoring/rules",
+            "/api/v2/security_monitoring/signals",
+            "/api/v2/security_monitoring/content_packs",
+            "/api/v2/posture_management/findings",
+            "/api/v2/entity_risk_scores",
+        ],
+        &[
+            "security_monitoring_signals_read",
+            "security_monitoring_rules_read",
+            "security_monitoring_findings_read",
+        ],
+        &["security_monitoring_rules_write"],
+    ),
+    domain(
+        "service-catalog",
+        &["/api/v2/services/definitions"],
+        &[],
+        &[],
+    ),
+    domain(
+        "slos",
+        &["/api/v1/slo", "/api/v2/slo"],
+        &["slos_read"],
+        &["slos_write"],
+    ),
+    domain("static-analysis", &[], &[], &[]),
+    domain("status-pages", &["/api/v2/status_pages"], &[], &[]),
+    domain(
+        "synthetics",
+        &["/api/v1/synthetics", "/api/v2/synthetics/suites"],
+        &["synthetics_read", "synthetics_private_location_read"],
+        &["synthetics_write"],
+    ),
+    domain(
+        "tags",
+        &["/api/v1/tags/hosts", "/api/v1/hosts"],
+        &["hosts_read"],
+        &[],
+    ),
+    domain("test", &[], &[], &[]),
+    domain(
+        "traces",
+        &[
+            "/api/v2/spans/events/search",
+            "/api/v2/spans/analytics",
+            "/api/v2/logs/events/search",
+        ],
+        &["apm_read", "logs_read_data"],
+        &[],
+    ),
+    domain(
+        "ui",
+        &[
+            "/api/v1/monitor/search",
+            "/api/v2/incidents",
+            "/api/v2/logs/events/search",
+        ],
+        &["monitors_read", "incident_read", "logs_read_data"],
+        &[],
+    ),
+    domain(
+        "usage",
+        &[
+            "/api/v1/usage/summary",
+            "/api/v1/usage/hourly-attribution",
+            "/api/v2/usage/hourly_usage",
+        ],
+        &["usage_read"],
+        &[],
+    ),
+    domain(
+        "users",
+        &["/api/v2/users", "/api/v2/roles", "/api/v2/user_invitations"],
+        &["user_access_read"],
+        &["user_access_invite", "user_access_manage"],
+    ),
+    domain("version", &[], &[], &[]),
+    domain(
+        "watchdog",
+        &["/api/v2/events/search", "/api/v2/events"],
+        &["events_read"],
+        &[],
+    ),
+    domain(
+        "workflows",
+        &["/api/v2/workflows"],
+        &["workflows_read"],
+        &["workflows_run"],
+    ),
+];
+
+pub fn lookup(command: &str) -> Option<&'static DomainCapability> {
+    DOMAINS.iter().find(|d| d.command == command)
+}
+
+// ---------------------------------------------------------------------------
+// Classification
+// ---------------------------------------------------------------------------
+
+#[derive(Serialize, Debug, Clone, Copy, PartialEq, Eq)]
+#[serde(rename_all = "snake_case")]
+pub enum Access {
+    Read,
+    Write,
+    Destructive,
+}
+
+/// Leaf names that only read.
+const READ_VERBS: &[&str] = &[
+    "aggregate",
+    "attribution",
+    "branch-summary",
+    "bulk-export",
+    "by-org",
+    "capabilities",
+    "codegen",
+    "commit-summary",
+    "completions",
+    "composite-tree",
+    "detect",
+    "diff",
+    "doctor",
+    "drift",
+    "events",
+    "export",
+    "find",
+    "flow-map",
+    "get",
+    "guide",
+    "history",
+    "hourly",
+    "ip-ranges",
+    "lint",
+    "list",
+    "logs",
+    "operations",
+    "pattern",
+    "projected",
+    "query",
+    "report",
+    "resources",
+    "schema",
+    "scopes",
+    "search",
+    "show",
+    "stats",
+    "status",
+    "suggest",
+    "summary",
+    "tag-compliance",
+    "tail",
+    "timeline",
+    "token",
+    "tune",
+    "ui",
+    "validate",
+    "version",
+    "versions",
+    "watch",
+    "who",
+];
+
+/// Leaf names that create or change something.
+const WRITE_VERBS: &[&str] = &[
+    "activate",
+    "add",
+    "add-items",
+    "append",
+    "apply",
+    "assign",
+    "bulk-add",
+    "clone",
+    "comment",
+    "configure",
+    "create",
+    "enable",
+    "generate-external-id",
+    "import",
+    "invite",
+    "link",
+    "login",
+    "migrate",
+    "move",
+    "pause",
+    "priority",
+    "refresh",
+    "register",
+    "reorder",
+    "resume",
+    "rewrite",
+    "send",
+    "set",
+    "submit",
+    "test",
+    "trigger",
+    "unarchive",
+    "update",
+    "upgrade",
+];
+
+/// Leaf names that remove, disable, or stop something.
+const DESTRUCTIVE_VERBS: &[&str] = &[
+    "archive",
+    "bulk-remove",
+    "cancel",
+    "deactivate",
+    "disable",
+    "logout",
+    "remove",
+    "remove-items",
+    "revoke",
+    "unlink",
+    "unregister",
+];
+
+/// Leaves whose name says the wrong thing about what they do, by full path.
+/// Checked before the verb lists.
+const LEAF_ACCESS: &[(&str, Access)] = &[
+    // Sets the case status, unlike the read-only `status` commands.
+    ("cases status", Access::Write),
+];
+
+/// Access implied by a leaf name alone, or None when the lists don't cover it.
+pub fn classify(name: &str) -> Option<Access> {
+    if DESTRUCTIVE_VERBS.contains(&name) || name.contains("delete") {
+        Some(Access::Destructive)
+    } else if WRITE_VERBS.contains(&name)
+        || name.starts_with("update-")
+        || name.starts_with("create-")
+        || name.contains("patch")
+    {
+        Some(Access::Write)
+    } else if READ_VERBS.contains(&name) {
+        Some(Access::Read)
+    } else {
+        None
+    }
+}
+
+/// Declared access of the leaf command at `path`, e.g. `["cases", "status"]`,
+/// or None when neither `LEAF_ACCESS` nor the verb lists cover it.
+pub fn declared_access(path: &[&str]) -> Option<Access> {
+    let key = path.join(" ");
+    LEAF_ACCESS
+        .iter()
+        .find(|(p, _)| *p == key)
+        .map(|(_, a)| *a)
+        .or_else(|| classify(path.last().copied().unwrap_or_default()))
+}
+
+/// Access of the leaf command at `path`. Undeclared leaves count as writes,
+/// so a new command is never reported read-only by mistake.
+pub fn access(path: &[&str]) -> Access {
+    declared_access(path).unwrap_or(Access::Write)
+}
+
+/// How a domain's endpoints relate to OAuth support.
+#[derive(Serialize, Debug, Clone, Copy, PartialEq, Eq)]
+#[serde(rename_all = "snake_case")]
+pub enum OAuthSupport {
+    /// Local command; no credentials needed.
+    NotRequired,
+    Supported,
+    /// Some endpoints in the domain require API keys.
+    Partial,
+    /// Every endpoint in the domain requires API keys.
+    Unsupported,
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+fn oauth_excluded(endpoint: &str) -> bool {
+    ["GET", "POST", "PATCH", "PUT", "DELETE"]
+        .iter()
+        .any(|m| crate::client::requires_api_key_fallback(m, endpoint))
+}
+
+#[cfg(target_arch = "wasm32")]
+fn oauth_excluded(_endpoint: &str) -> bool {
+    false
+}
+
+pub fn oauth_support(endpoints: &[&str]) -> OAuthSupport {
+    if endpoints.is_empty() {
+        return OAuthSupport::NotRequired;
+    }
+    let excluded = endpoints.iter().filter(|e| oauth_excluded(e)).count();
+    if excluded == 0 {
+        OAuthSupport::Supported
+    } else if excluded == endpoints.len() {
+        OAuthSupport::Unsupported
+    } else {
+        OAuthSupport::Partial
+    }
+}
+
+// ---------------------------------------------------------------------------
+// Manifest
+// ---------------------------------------------------------------------------
+
+#[derive(Serialize, Debug)]
+pub struct CommandAuth {
+    pub oauth: OAuthSupport,
+    pub api_keys: bool,
+    /// Scopes needed when authenticating with OAuth; omitted when unknown.
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub oauth_scopes: Vec<&'static str>,
+}
+
+#[derive(Serialize, Debug)]
+pub struct CommandCapability {
+    pub command: String,
+    pub domain: String,
+    pub access: Access,
+    pub read_only: bool,
+    pub auth: CommandAuth,
+    pub endpoints: Vec<&'static str>,
+}
+
+fn leaf_capability(path: &[&str]) -> CommandCapability {
+    let domain_name = path.first().copied().unwrap_or_default();
+    let entry = lookup(domain_name);
+    let endpoints: Vec<&'static str> = entry.map(|d| d.endpoints.to_vec()).unwrap_or_default();
+    let access = if endpoints.is_empty() {
+        Access::Read
+    } else {
+        access(path)
+    };
+    let mut scopes: Vec<&'static str> = entry.map(|d| d.read_scopes.to_vec()).unwrap_or_default();
+    if access != Access::Read {
+        scopes.extend(entry.map(|d| d.write_scopes).unwrap_or_default());
+    }
+    CommandCapability {
+        command: format!("pup {}", path.join(" ")),
+        domain: domain_name.to_string(),
+        access,
+        read_only: access == Access::Read,
+        auth: CommandAuth {
+            oauth: oauth_support(&endpoints),
+            api_keys: !endpoints.is_empty(),
+            oauth_scopes: scopes,
+        },
+        endpoints,
+    }
+}
+
+fn collect_leaves<'a>(
+    cmd: &'a clap::Command,
+    path: &mut Vec<&'a str>,
+    out: &mut Vec<CommandCapability>,
+) {
+    let subs: Vec<&clap::Command> = cmd
+        .get_subcommands()
+        .filter(|s| s.get_name() != "help")
+        .collect();
+    if subs.is_empty() {
+        out.push(leaf_capability(path));
+        return;
+    }
+    for sub in subs {
+        path.push(sub.get_name());
+        collect_leaves(sub, path, out);
+        path.pop();
+    }
+}
+
+/// Build the manifest for every leaf command under `root`, optionally limited
+/// to one top-level domain.
+pub fn build_manifest(root: &clap::Command, domain: Option<&str>) -> Vec<CommandCapability> {
+    let mut out = Vec::new();
+    for top in root.get_subcommands().filter(|s| s.get_name() != "help") {
+        if domain.is_some_and(|d| d != top.get_name()) {
+            continue;
+        }
+        let mut path = vec![top.get_name()];
+        collect_leaves(top, &mut path, &mut out);
+    }
+    out.sort_by(|a, b| a.command.cmp(&b.command));
+    out
+}
+
+/// Top-level commands present in the CLI but missing from the registry.
+pub fn unregistered(root: &clap::Command) -> Vec<String> {
+    root.get_subcommands()
+        .map(|s| s.get_name())
+        .filter(|n| *n != "help" && lookup(n).is_none())
+        .map(String::from)
+        .collect()
+}
+
+pub fn run(cfg: &Config, root: &clap::Command, domain: Option<String>) -> Result<()> {
+    if let Some(d) = &domain {
+        if lookup(d).is_none() {
+            bail!("unknown domain {d:?} (see 'pup capabilities' for the full list)");
+        }
+    }
+    let commands = build_manifest(root, domain.as_deref());
+    let manifest = serde_json::json!({
+        "version": crate::version::VERSION,
+        "commands": commands,
+    });
+    formatter::output(cfg, &manifest)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn sample_cli() -> clap::Command {
+        clap::Command::new("pup")
+            .subcommand(
+                clap::Command::new("monitors")
+                    .subcommand(clap::Command::new("list"))
+                    .subcommand(clap::Command::new("delete")),
+            )
+            .subcommand(clap::Command::new("notebooks").subcommand(clap::Command::new("clone")))
+            .subcommand(clap::Command::new("version"))
+            .subcommand(clap::Command::new("mystery"))
+    }
+
+    #[test]
+    fn test_classify() {
+        assert_eq!(classify("list"), Some(Access::Read));
+        assert_eq!(classify("clone"), Some(Access::Write));
+        assert_eq!(classify("update-settings"), Some(Access::Write));
+        assert_eq!(classify("delete"), Some(Access::Destructive));
+        assert_eq!(classify("bulk-delete"), Some(Access::Destructive));
+        assert_eq!(classify("cancel"), Some(Access::Destructive));
+        assert_eq!(classify("frobnicate"), None);
+    }
+
+    #[test]
+    fn test_access_by_path() {
+        assert_eq!(access(&["auth", "status"]), Access::Read);
+        assert_eq!(access(&["cases", "status"]), Access::Write);
+        assert_eq!(access(&["tags", "bulk-remove"]), Access::Destructive);
+        assert_eq!(access(&["monitors", "frobnicate"]), Access::Write);
+        assert_eq!(declared_access(&["monitors", "frobnicate"]), None);
+    }
+
+    #[test]
+    fn test_build_manifest() {
+        let manifest = build_manifest(&sample_cli(), None);
+        let names: Vec<&str> = manifest.iter().map(|c| c.command.as_str()).collect();
+        assert_eq!(
+            names,
+            vec![
+                "pup monitors delete",
+                "pup monitors list",
+                "pup mystery",
+                "pup notebooks clone",
+                "pup version"
+            ]
+        );
+        let delete = &manifest[0];
+        assert_eq!(delete.access, Access::Destructive);
+        assert_eq!(
+            delete.auth.oauth_scopes,
+            vec!["monitors_read", "monitors_write"]
+        );
+        assert_eq!(manifest[1].auth.oauth_scopes, vec!["monitors_read"]);
+        assert!(manifest[1].read_only);
+        assert_eq!(manifest[4].auth.oauth, OAuthSupport::NotRequired);
+
+        let only = build_manifest(&sample_cli(), Some("notebooks"));
+        assert_eq!(only.len(), 1);
+        assert_eq!(only[0].access, Access::Write);
+    }
+
+    #[test]
+    #[cfg(not(target_arch = "wasm32"))]
+    fn test_oauth_support_from_exclusion_table() {
+        assert_eq!(
+            oauth_support(&["/api/v1/notebooks"]),
+            OAuthSupport::Unsupported
+        );
+        assert_eq!(oauth_support(&["/api/v1/monitor"]), OAuthSupport::Supported);
+        assert_eq!(
+            oauth_support(&["/api/v1/monitor", "/api/v1/notebooks"]),
+            OAuthSupport::Partial
+        );
+    }
+
+    #[test]
+    fn test_unregistered() {
+        assert_eq!(unregistered(&sample_cli()), vec!["mystery"]);
+    }
+}
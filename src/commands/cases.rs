@@ -38,9 +38,11 @@ fn make_api(cfg: &Config) -> CaseManagementAPI {
 // ---------------------------------------------------------------------------
 
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn search(cfg: &Config, _query: Option<String>, page_size: i64) -> Result<()> {
+pub async fn search(cfg: &Config, _query: Option<String>, limit: i64, page: i64) -> Result<()> {
     let api = make_api(cfg);
-    let params = SearchCasesOptionalParams::default().page_size(page_size);
+    let params = SearchCasesOptionalParams::default()
+        .page_size(limit)
+        .page_number(page);
     let resp = api
         .search_cases(params)
         .await
@@ -49,8 +51,11 @@ pub async fn search(cfg: &Config, _query: Option<String>, page_size: i64) -> Res
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn search(cfg: &Config, _query: Option<String>, page_size: i64) -> Result<()> {
-    let q = vec![("page[size]", page_size.to_string())];
+pub async fn search(cfg: &Config, _query: Option<String>, limit: i64, page: i64) -> Result<()> {
+    let q = vec![
+        ("page[size]", limit.to_string()),
+        ("page[number]", page.to_string()),
+    ];
     let data = crate::api::get(cfg, "/api/v2/cases", &q).await?;
     crate::formatter::output(cfg, &data)
 }
@@ -368,27 +373,51 @@ pub async fn update_status(cfg: &Config, case_id: &str, status: &str) -> Result<
     crate::formatter::output(cfg, &data)
 }
 
+// ---------------------------------------------------------------------------
+// Comments
+// ---------------------------------------------------------------------------
+
+pub async fn comment(cfg: &Config, case_id: &str, message: &str) -> Result<()> {
+    if message.trim().is_empty() {
+        anyhow::bail!("comment message is empty");
+    }
+    let body = serde_json::json!({
+        "data": {
+            "attributes": {
+                "comment": message
+            },
+            "type": "case"
+        }
+    });
+    let data = crate::api::post(cfg, &format!("/api/v2/cases/{case_id}/comment"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to comment on case", e))?;
+    formatter::output(cfg, &data)
+}
+
 // ---------------------------------------------------------------------------
 // Jira integration
 // ---------------------------------------------------------------------------
 
+/// Create a Jira issue for a case from `body` (see `request_body`) and print
+/// its URL once Datadog has created it.
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn jira_create_issue(cfg: &Config, case_id: &str, file: &str) -> Result<()> {
+pub async fn jira_create_issue(cfg: &Config, case_id: &str, body: serde_json::Value) -> Result<()> {
+    let body: JiraIssueCreateRequest = serde_json::from_value(body)
+        .map_err(|e| anyhow::anyhow!("invalid Jira issue request: {e}"))?;
+    let before = ticket_before(cfg, case_id, Ticket::Jira).await?;
     let api = make_api(cfg);
-    let body: JiraIssueCreateRequest = crate::util::read_json_file(file)?;
     api.create_case_jira_issue(case_id.to_string(), body)
         .await
         .map_err(|e| anyhow::anyhow!("failed to create Jira issue for case: {e:?}"))?;
-    println!("Jira issue created for case '{case_id}'.");
-    Ok(())
+    report_ticket(cfg, case_id, Ticket::Jira, &before).await
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn jira_create_issue(cfg: &Config, case_id: &str, file: &str) -> Result<()> {
-    let body: serde_json::Value = crate::util::read_json_file(file)?;
+pub async fn jira_create_issue(cfg: &Config, case_id: &str, body: serde_json::Value) -> Result<()> {
+    let before = ticket_before(cfg, case_id, Ticket::Jira).await?;
     crate::api::post(cfg, &format!("/api/v2/cases/{case_id}/jira_issue"), &body).await?;
-    println!("Jira issue created for case '{case_id}'.");
-    Ok(())
+    report_ticket(cfg, case_id, Ticket::Jira, &before).await
 }
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -436,28 +465,220 @@ pub async fn jira_unlink(cfg: &Config, case_id: &str) -> Result<()> {
 // ServiceNow integration
 // ---------------------------------------------------------------------------
 
+/// Create a ServiceNow ticket for a case from `body` (see `request_body`) and
+/// print its URL once Datadog has created it.
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn servicenow_create_ticket(cfg: &Config, case_id: &str, file: &str) -> Result<()> {
+pub async fn servicenow_create_ticket(
+    cfg: &Config,
+    case_id: &str,
+    body: serde_json::Value,
+) -> Result<()> {
+    let body: ServiceNowTicketCreateRequest = serde_json::from_value(body)
+        .map_err(|e| anyhow::anyhow!("invalid ServiceNow ticket request: {e}"))?;
+    let before = ticket_before(cfg, case_id, Ticket::ServiceNow).await?;
     let api = make_api(cfg);
-    let body: ServiceNowTicketCreateRequest = crate::util::read_json_file(file)?;
     api.create_case_service_now_ticket(case_id.to_string(), body)
         .await
         .map_err(|e| anyhow::anyhow!("failed to create ServiceNow ticket for case: {e:?}"))?;
-    println!("ServiceNow ticket created for case '{case_id}'.");
-    Ok(())
+    report_ticket(cfg, case_id, Ticket::ServiceNow, &before).await
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn servicenow_create_ticket(cfg: &Config, case_id: &str, file: &str) -> Result<()> {
-    let body: serde_json::Value = crate::util::read_json_file(file)?;
+pub async fn servicenow_create_ticket(
+    cfg: &Config,
+    case_id: &str,
+    body: serde_json::Value,
+) -> Result<()> {
+    let before = ticket_before(cfg, case_id, Ticket::ServiceNow).await?;
     crate::api::post(
         cfg,
         &format!("/api/v2/cases/{case_id}/servicenow_ticket"),
         &body,
     )
     .await?;
-    println!("ServiceNow ticket created for case '{case_id}'.");
-    Ok(())
+    report_ticket(cfg, case_id, Ticket::ServiceNow, &before).await
+}
+
+// ---------------------------------------------------------------------------
+// Ticket creation with the resulting URL
+// ---------------------------------------------------------------------------
+
+/// How often and how many times to re-read a ticket that is still being
+/// created on the Jira/ServiceNow side.
+const TICKET_POLL_SECS: u64 = 2;
+const TICKET_POLL_ATTEMPTS: usize = 15;
+
+/// Where an asynchronously created Jira issue or ServiceNow ticket stands.
+#[derive(Debug, Clone, PartialEq)]
+pub enum TicketState {
+    Pending,
+    Ready(String),
+    Failed(String),
+}
+
+/// The ticketing system a case ticket lives in.
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum Ticket {
+    Jira,
+    ServiceNow,
+}
+
+impl Ticket {
+    fn name(self) -> &'static str {
+        match self {
+            Ticket::Jira => "Jira",
+            Ticket::ServiceNow => "ServiceNow",
+        }
+    }
+
+    /// Case attribute holding the ticket, and the URL field in its `result`.
+    fn fields(self) -> (&'static str, &'static str) {
+        match self {
+            Ticket::Jira => ("jira_issue", "issue_url"),
+            Ticket::ServiceNow => ("service_now_ticket", "sys_target_link"),
+        }
+    }
+}
+
+/// The case's ticket attribute, as recorded before a create request.
+pub fn ticket_attr(case: &serde_json::Value, ticket: Ticket) -> serde_json::Value {
+    case["data"]["attributes"][ticket.fields().0].clone()
+}
+
+/// The ticket state a case reports. A case keeps the ticket it already had
+/// until Datadog has processed a create request, so anything unchanged from
+/// `before` is still pending.
+pub fn case_ticket_state(
+    case: &serde_json::Value,
+    ticket: Ticket,
+    before: &serde_json::Value,
+) -> TicketState {
+    let (field, url_field) = ticket.fields();
+    let current = &case["data"]["attributes"][field];
+    if current == before {
+        return TicketState::Pending;
+    }
+    if let Some(url) = current["result"][url_field]
+        .as_str()
+        .filter(|u| !u.is_empty())
+    {
+        return TicketState::Ready(url.to_string());
+    }
+    match current["status"].as_str() {
+        Some("FAILED") => TicketState::Failed(format!("{field} status is FAILED")),
+        _ => TicketState::Pending,
+    }
+}
+
+/// Re-run `fetch` until the ticket has a URL. `Ok(None)` means it was still
+/// pending when polling gave up.
+pub async fn wait_for_ticket<F, Fut>(mut fetch: F) -> Result<Option<String>>
+where
+    F: FnMut() -> Fut,
+    Fut: std::future::Future<Output = Result<TicketState>>,
+{
+    for attempt in 0..TICKET_POLL_ATTEMPTS {
+        match fetch().await? {
+            TicketState::Ready(url) => return Ok(Some(url)),
+            TicketState::Failed(reason) => anyhow::bail!("ticket creation failed: {reason}"),
+            TicketState::Pending => {}
+        }
+        if attempt + 1 < TICKET_POLL_ATTEMPTS {
+            #[cfg(not(target_arch = "wasm32"))]
+            tokio::time::sleep(std::time::Duration::from_secs(TICKET_POLL_SECS)).await;
+            #[cfg(target_arch = "wasm32")]
+            return Ok(None);
+        }
+    }
+    Ok(None)
+}
+
+/// The request body from `--file`, or built from flags when there is no file.
+pub fn request_body(
+    file: Option<&str>,
+    from_flags: impl FnOnce() -> serde_json::Value,
+) -> Result<serde_json::Value> {
+    match file {
+        Some(file) => crate::util::read_json_file(file),
+        None => Ok(from_flags()),
+    }
+}
+
+/// Request body for a Jira issue created from a case.
+pub fn jira_issue_body(
+    account_id: &str,
+    project_id: &str,
+    issue_type_id: Option<&str>,
+) -> serde_json::Value {
+    let mut attributes = serde_json::json!({
+        "jira_account_id": account_id,
+        "project_id": project_id,
+    });
+    if let Some(issue_type_id) = issue_type_id {
+        attributes["issue_type_id"] = issue_type_id.into();
+    }
+    serde_json::json!({ "data": { "type": "issues", "attributes": attributes } })
+}
+
+/// Request body for a ServiceNow ticket created from a case.
+pub fn servicenow_ticket_body(instance: &str, assignment_group: Option<&str>) -> serde_json::Value {
+    let mut attributes = serde_json::json!({ "instance_name": instance });
+    if let Some(group) = assignment_group {
+        attributes["assignment_group"] = group.into();
+    }
+    serde_json::json!({ "data": { "type": "tickets", "attributes": attributes } })
+}
+
+/// Print the ticket's URL, or say where to find it once it exists.
+pub fn ticket_output(
+    cfg: &Config,
+    id_field: &str,
+    id: &str,
+    system: &str,
+    url: Option<String>,
+    get_command: &str,
+) -> Result<()> {
+    if url.is_none() {
+        eprintln!(
+            "The {system} ticket is still being created; run '{get_command}' to see its URL."
+        );
+    }
+    formatter::output(
+        cfg,
+        &serde_json::json!({ id_field: id, "system": system, "url": url }),
+    )
+}
+
+async fn fetch_case(cfg: &Config, case_id: &str) -> Result<serde_json::Value> {
+    crate::api::get(cfg, &format!("/api/v2/cases/{case_id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get case", e))
+}
+
+async fn ticket_before(cfg: &Config, case_id: &str, ticket: Ticket) -> Result<serde_json::Value> {
+    Ok(ticket_attr(&fetch_case(cfg, case_id).await?, ticket))
+}
+
+/// Poll the case until the ticket requested after `before` has a URL, then print it.
+async fn report_ticket(
+    cfg: &Config,
+    case_id: &str,
+    ticket: Ticket,
+    before: &serde_json::Value,
+) -> Result<()> {
+    let url = wait_for_ticket(|| async {
+        let case = fetch_case(cfg, case_id).await?;
+        Ok(case_ticket_state(&case, ticket, before))
+    })
+    .await?;
+    ticket_output(
+        cfg,
+        "case_id",
+        case_id,
+        ticket.name(),
+        url,
+        &format!("pup cases get {case_id}"),
+    )
 }
 
 // ---------------------------------------------------------------------------
@@ -664,3 +885,54 @@ pub async fn projects_update(cfg: &Config, project_id: &str, file: &str) -> Resu
     .await?;
     crate::formatter::output(cfg, &data)
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_case_ticket_state() {
+        let case = |attrs: serde_json::Value| serde_json::json!({"data": {"attributes": attrs}});
+        let none = serde_json::Value::Null;
+        let ready = case(serde_json::json!({"jira_issue": {
+            "status": "COMPLETED",
+            "result": {"issue_key": "OPS-12", "issue_url": "https://acme.atlassian.net/browse/OPS-12"}
+        }}));
+        assert_eq!(
+            case_ticket_state(&ready, Ticket::Jira, &none),
+            TicketState::Ready("https://acme.atlassian.net/browse/OPS-12".into())
+        );
+        assert_eq!(
+            case_ticket_state(&ready, Ticket::ServiceNow, &none),
+            TicketState::Pending
+        );
+        // The issue the case already had is not the one just requested.
+        let before = ticket_attr(&ready, Ticket::Jira);
+        assert_eq!(
+            case_ticket_state(&ready, Ticket::Jira, &before),
+            TicketState::Pending
+        );
+        let failed = case(serde_json::json!({"service_now_ticket": {"status": "FAILED"}}));
+        assert!(matches!(
+            case_ticket_state(&failed, Ticket::ServiceNow, &none),
+            TicketState::Failed(_)
+        ));
+        let pending =
+            case(serde_json::json!({"jira_issue": {"status": "IN_PROGRESS", "result": {}}}));
+        assert_eq!(
+            case_ticket_state(&pending, Ticket::Jira, &before),
+            TicketState::Pending
+        );
+    }
+
+    #[test]
+    fn test_ticket_bodies() {
+        let body = jira_issue_body("acct-1", "10001", None);
+        assert_eq!(body["data"]["type"], "issues");
+        assert_eq!(body["data"]["attributes"]["project_id"], "10001");
+        assert!(body["data"]["attributes"].get("issue_type_id").is_none());
+        let body = servicenow_ticket_body("acme", Some("SRE"));
+        assert_eq!(body["data"]["type"], "tickets");
+        assert_eq!(body["data"]["attributes"]["assignment_group"], "SRE");
+    }
+}
@@ -159,6 +159,51 @@ pub async fn tests_list(
     crate::formatter::output(cfg, &data)
 }
 
+/// Largest `page[limit]` the CI test events endpoint accepts.
+const TEST_EVENTS_PAGE_MAX: usize = 1000;
+
+/// List CI test events across every page, following `meta.page.after` (`--all`).
+pub async fn tests_list_all_pages(
+    cfg: &Config,
+    query: Option<String>,
+    from: String,
+    to: String,
+    max_items: usize,
+) -> Result<()> {
+    let from_ms = crate::util::parse_time_to_unix_millis(&from)?;
+    let to_ms = crate::util::parse_time_to_unix_millis(&to)?;
+    let from_str = chrono::DateTime::from_timestamp_millis(from_ms)
+        .unwrap()
+        .to_rfc3339();
+    let to_str = chrono::DateTime::from_timestamp_millis(to_ms)
+        .unwrap()
+        .to_rfc3339();
+    let limit = crate::util::page_size(TEST_EVENTS_PAGE_MAX, max_items);
+    let collected = crate::util::collect_pages(
+        crate::util::Paging::Cursor {
+            next: "/meta/page/after",
+        },
+        "/data",
+        max_items,
+        |page| {
+            let mut q: Vec<(&str, String)> = vec![
+                ("filter[from]", from_str.clone()),
+                ("filter[to]", to_str.clone()),
+                ("page[limit]", limit.to_string()),
+            ];
+            if let Some(qv) = &query {
+                q.push(("filter[query]", qv.clone()));
+            }
+            if let Some(cursor) = page.cursor {
+                q.push(("page[cursor]", cursor));
+            }
+            async move { crate::api::get(cfg, "/api/v2/ci/tests/events", &q).await }
+        },
+    )
+    .await?;
+    crate::util::print_collected(cfg, "cicd tests list", collected)
+}
+
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn events_search(
     cfg: &Config,
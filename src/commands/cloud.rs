@@ -1,17 +1,18 @@
-use anyhow::Result;
-#[cfg(not(target_arch = "wasm32"))]
-use datadog_api_client::datadogV1::api_aws_integration::{
-    AWSIntegrationAPI, ListAWSAccountsOptionalParams,
-};
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV1::api_azure_integration::AzureIntegrationAPI;
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV1::api_gcp_integration::GCPIntegrationAPI;
 #[cfg(not(target_arch = "wasm32"))]
+use datadog_api_client::datadogV2::api_aws_integration::{
+    AWSIntegrationAPI, ListAWSAccountsOptionalParams,
+};
+#[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV2::api_oci_integration::OCIIntegrationAPI;
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV2::model::{
-    CreateTenancyConfigRequest, UpdateTenancyConfigRequest,
+    AWSAccountCreateRequest, AWSAccountUpdateRequest, CreateTenancyConfigRequest,
+    UpdateTenancyConfigRequest,
 };
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -19,26 +20,6 @@ use crate::client;
 use crate::config::Config;
 use crate::formatter;
 
-#[cfg(not(target_arch = "wasm32"))]
-pub async fn aws_list(cfg: &Config) -> Result<()> {
-    let dd_cfg = client::make_dd_config(cfg);
-    let api = match client::make_bearer_client(cfg) {
-        Some(c) => AWSIntegrationAPI::with_client_and_config(dd_cfg, c),
-        None => AWSIntegrationAPI::with_config(dd_cfg),
-    };
-    let resp = api
-        .list_aws_accounts(ListAWSAccountsOptionalParams::default())
-        .await
-        .map_err(|e| anyhow::anyhow!("failed to list AWS accounts: {e:?}"))?;
-    formatter::output(cfg, &resp)
-}
-
-#[cfg(target_arch = "wasm32")]
-pub async fn aws_list(cfg: &Config) -> Result<()> {
-    let data = crate::api::get(cfg, "/api/v1/integration/aws", &[]).await?;
-    crate::formatter::output(cfg, &data)
-}
-
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn gcp_list(cfg: &Config) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
@@ -79,6 +60,306 @@ pub async fn azure_list(cfg: &Config) -> Result<()> {
     crate::formatter::output(cfg, &data)
 }
 
+// ---------------------------------------------------------------------------
+// GCP STS accounts and Azure app registrations
+// ---------------------------------------------------------------------------
+
+const GCP_ACCOUNTS_PATH: &str = "/api/v2/integration/gcp/accounts";
+const AZURE_PATH: &str = "/api/v1/integration/azure";
+
+/// Check a GCP STS account request body before sending it. STS accounts are
+/// keyless: Datadog impersonates `client_email`, so a service account key file
+/// is refused rather than uploaded. `creating` requires `client_email`.
+pub fn validate_gcp_sts_body(body: &serde_json::Value, creating: bool) -> Result<()> {
+    if body.get("private_key").is_some() || body["type"] == "service_account" {
+        bail!(
+            "this looks like a service account key file; STS accounts don't use keys. \
+             Send {{\"data\": {{\"type\": \"gcp_service_account\", \"attributes\": {{\"client_email\": ...}}}}}} \
+             and grant Datadog's principal the Service Account Token Creator role instead"
+        );
+    }
+    let data = &body["data"];
+    if !data.is_object() {
+        bail!("invalid GCP account body: expected a top-level \"data\" object");
+    }
+    if let Some(t) = data["type"].as_str() {
+        if t != "gcp_service_account" {
+            bail!("invalid GCP account body: data.type must be \"gcp_service_account\", got {t:?}");
+        }
+    }
+    let attrs = &data["attributes"];
+    if attrs.get("private_key").is_some() {
+        bail!("invalid GCP account body: STS accounts don't take a private_key");
+    }
+    match attrs["client_email"].as_str() {
+        Some(email) if !email.ends_with(".iam.gserviceaccount.com") => bail!(
+            "invalid client_email {email:?}: expected a service account address ending in .iam.gserviceaccount.com"
+        ),
+        None if creating => bail!("invalid GCP account body: data.attributes.client_email is required"),
+        _ => Ok(()),
+    }
+}
+
+/// Check an Azure app registration body before sending it: tenant and client
+/// IDs must be GUIDs, and `creating` requires a non-empty `client_secret`.
+pub fn validate_azure_body(body: &serde_json::Value, creating: bool) -> Result<()> {
+    for field in ["tenant_name", "client_id"] {
+        match body[field].as_str() {
+            Some(v) => {
+                crate::util::parse_uuid(v, field)?;
+            }
+            None => bail!("invalid Azure body: {field} is required"),
+        }
+    }
+    for field in ["new_tenant_name", "new_client_id"] {
+        if let Some(v) = body[field].as_str() {
+            crate::util::parse_uuid(v, field)?;
+        }
+    }
+    let has_secret = body["client_secret"]
+        .as_str()
+        .is_some_and(|s| !s.trim().is_empty());
+    if creating && !has_secret {
+        bail!("invalid Azure body: client_secret is required when adding an app registration");
+    }
+    Ok(())
+}
+
+pub async fn gcp_sts_list(cfg: &Config) -> Result<()> {
+    let resp = crate::api::get(cfg, GCP_ACCOUNTS_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list GCP STS accounts", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn gcp_create(cfg: &Config, file: &str) -> Result<()> {
+    let body: serde_json::Value = crate::util::read_json_file(file)?;
+    validate_gcp_sts_body(&body, true)?;
+    let resp = crate::api::post(cfg, GCP_ACCOUNTS_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create GCP STS account", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn gcp_update(cfg: &Config, account_id: &str, file: &str) -> Result<()> {
+    let body: serde_json::Value = crate::util::read_json_file(file)?;
+    validate_gcp_sts_body(&body, false)?;
+    let resp = crate::api::patch(cfg, &format!("{GCP_ACCOUNTS_PATH}/{account_id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update GCP STS account", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn gcp_delete(cfg: &Config, account_id: &str) -> Result<()> {
+    crate::api::delete(cfg, &format!("{GCP_ACCOUNTS_PATH}/{account_id}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to delete GCP STS account", e))?;
+    println!("GCP STS account '{account_id}' deleted.");
+    Ok(())
+}
+
+pub async fn azure_create(cfg: &Config, file: &str) -> Result<()> {
+    let body: serde_json::Value = crate::util::read_json_file(file)?;
+    validate_azure_body(&body, true)?;
+    let resp = crate::api::post(cfg, AZURE_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create Azure integration", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn azure_update(cfg: &Config, file: &str) -> Result<()> {
+    let body: serde_json::Value = crate::util::read_json_file(file)?;
+    validate_azure_body(&body, false)?;
+    let resp = crate::api::put(cfg, AZURE_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update Azure integration", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn azure_delete(cfg: &Config, tenant_id: &str, client_id: &str) -> Result<()> {
+    let body = serde_json::json!({ "tenant_name": tenant_id, "client_id": client_id });
+    validate_azure_body(&body, false)?;
+    crate::api::delete_with_body(cfg, AZURE_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to delete Azure integration", e))?;
+    println!("Azure app registration '{client_id}' in tenant '{tenant_id}' deleted.");
+    Ok(())
+}
+
+// ---------------------------------------------------------------------------
+// AWS accounts
+// ---------------------------------------------------------------------------
+
+#[cfg(target_arch = "wasm32")]
+const AWS_ACCOUNTS_PATH: &str = "/api/v2/integration/aws/accounts";
+
+/// Whether `id` is a 12-digit AWS account ID rather than a Datadog account
+/// config ID.
+pub fn is_aws_account_id(id: &str) -> bool {
+    id.len() == 12 && id.bytes().all(|b| b.is_ascii_digit())
+}
+
+/// The config ID of the account in a list response whose AWS account ID is
+/// `aws_account_id`.
+pub fn aws_config_id(resp: &serde_json::Value, aws_account_id: &str) -> Option<String> {
+    resp["data"]
+        .as_array()?
+        .iter()
+        .find(|a| a["attributes"]["aws_account_id"].as_str() == Some(aws_account_id))
+        .and_then(|a| a["id"].as_str())
+        .map(str::to_string)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+fn make_aws_api(cfg: &Config) -> AWSIntegrationAPI {
+    let dd_cfg = client::make_dd_config(cfg);
+    match client::make_bearer_client(cfg) {
+        Some(c) => AWSIntegrationAPI::with_client_and_config(dd_cfg, c),
+        None => AWSIntegrationAPI::with_config(dd_cfg),
+    }
+}
+
+/// Integrated AWS accounts, optionally only the one with `aws_account_id`.
+#[cfg(not(target_arch = "wasm32"))]
+async fn list_aws_accounts(
+    cfg: &Config,
+    aws_account_id: Option<&str>,
+) -> Result<serde_json::Value> {
+    let mut params = ListAWSAccountsOptionalParams::default();
+    if let Some(id) = aws_account_id {
+        params = params.aws_account_id(id.to_string());
+    }
+    let resp = make_aws_api(cfg)
+        .list_aws_accounts(params)
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to list AWS accounts: {e:?}"))?;
+    Ok(serde_json::to_value(resp)?)
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn list_aws_accounts(
+    cfg: &Config,
+    aws_account_id: Option<&str>,
+) -> Result<serde_json::Value> {
+    let mut query = Vec::new();
+    if let Some(id) = aws_account_id {
+        query.push(("aws_account_id", id.to_string()));
+    }
+    crate::api::get(cfg, AWS_ACCOUNTS_PATH, &query)
+        .await
+        .map_err(|e| crate::api::failed("failed to list AWS accounts", e))
+}
+
+/// Accept either the config ID Datadog assigns or the AWS account ID, which
+/// is what people usually have to hand.
+async fn resolve_aws_account(cfg: &Config, id: &str) -> Result<String> {
+    if !is_aws_account_id(id) {
+        return Ok(id.to_string());
+    }
+    let resp = list_aws_accounts(cfg, Some(id)).await?;
+    aws_config_id(&resp, id)
+        .ok_or_else(|| anyhow::anyhow!("AWS account {id} is not integrated with Datadog"))
+}
+
+pub async fn aws_list(cfg: &Config, aws_account_id: Option<&str>) -> Result<()> {
+    let resp = list_aws_accounts(cfg, aws_account_id).await?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn aws_get(cfg: &Config, id: &str) -> Result<()> {
+    let config_id = resolve_aws_account(cfg, id).await?;
+    let resp = make_aws_api(cfg)
+        .get_aws_account(config_id)
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to get AWS account: {e:?}"))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn aws_get(cfg: &Config, id: &str) -> Result<()> {
+    let config_id = resolve_aws_account(cfg, id).await?;
+    let data = crate::api::get(cfg, &format!("{AWS_ACCOUNTS_PATH}/{config_id}"), &[]).await?;
+    crate::formatter::output(cfg, &data)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn aws_create(cfg: &Config, file: &str) -> Result<()> {
+    let body: AWSAccountCreateRequest = crate::util::read_json_file(file)?;
+    let resp = make_aws_api(cfg)
+        .create_aws_account(body)
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to create AWS account: {e:?}"))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn aws_create(cfg: &Config, file: &str) -> Result<()> {
+    let body: serde_json::Value = crate::util::read_json_file(file)?;
+    let data = crate::api::post(cfg, AWS_ACCOUNTS_PATH, &body).await?;
+    crate::formatter::output(cfg, &data)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn aws_update(cfg: &Config, id: &str, file: &str) -> Result<()> {
+    let config_id = resolve_aws_account(cfg, id).await?;
+    let body: AWSAccountUpdateRequest = crate::util::read_json_file(file)?;
+    let resp = make_aws_api(cfg)
+        .update_aws_account(config_id, body)
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to update AWS account: {e:?}"))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn aws_update(cfg: &Config, id: &str, file: &str) -> Result<()> {
+    let config_id = resolve_aws_account(cfg, id).await?;
+    let body: serde_json::Value = crate::util::read_json_file(file)?;
+    let data = crate::api::patch(cfg, &format!("{AWS_ACCOUNTS_PATH}/{config_id}"), &body).await?;
+    crate::formatter::output(cfg, &data)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn aws_delete(cfg: &Config, id: &str) -> Result<()> {
+    let config_id = resolve_aws_account(cfg, id).await?;
+    make_aws_api(cfg)
+        .delete_aws_account(config_id)
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to delete AWS account: {e:?}"))?;
+    println!("AWS account {id} deleted.");
+    Ok(())
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn aws_delete(cfg: &Config, id: &str) -> Result<()> {
+    let config_id = resolve_aws_account(cfg, id).await?;
+    crate::api::delete(cfg, &format!("{AWS_ACCOUNTS_PATH}/{config_id}")).await?;
+    println!("AWS account {id} deleted.");
+    Ok(())
+}
+
+/// Generate the external ID to put in the trust policy of the IAM role
+/// Datadog assumes, before creating the account.
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn aws_generate_external_id(cfg: &Config) -> Result<()> {
+    let resp = make_aws_api(cfg)
+        .create_new_aws_external_id()
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to generate AWS external ID: {e:?}"))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn aws_generate_external_id(cfg: &Config) -> Result<()> {
+    let data = crate::api::post(
+        cfg,
+        "/api/v2/integration/aws/generate_new_external_id",
+        &serde_json::json!({}),
+    )
+    .await?;
+    crate::formatter::output(cfg, &data)
+}
+
 // ---------------------------------------------------------------------------
 // OCI tenancy management
 // ---------------------------------------------------------------------------
@@ -207,3 +488,64 @@ pub async fn oci_products_list(cfg: &Config, product_keys: &str) -> Result<()> {
     let data = crate::api::get(cfg, "/api/v2/integration/oci/tenancy_products", &query).await?;
     crate::formatter::output(cfg, &data)
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_aws_config_id() {
+        assert!(is_aws_account_id("123456789012"));
+        assert!(!is_aws_account_id("1234-5678-9012"));
+        assert!(!is_aws_account_id("00000000-0000-0000-0000-000000000001"));
+        let resp = serde_json::json!({"data": [
+            {"id": "cfg-a", "attributes": {"aws_account_id": "111111111111"}},
+            {"id": "cfg-b", "attributes": {"aws_account_id": "123456789012"}}
+        ]});
+        assert_eq!(
+            aws_config_id(&resp, "123456789012").as_deref(),
+            Some("cfg-b")
+        );
+        assert_eq!(aws_config_id(&resp, "999999999999"), None);
+    }
+
+    #[test]
+    fn test_validate_gcp_sts_body() {
+        let ok = serde_json::json!({"data": {"type": "gcp_service_account", "attributes": {
+            "client_email": "datadog@my-project.iam.gserviceaccount.com"
+        }}});
+        assert!(validate_gcp_sts_body(&ok, true).is_ok());
+
+        let key_file = serde_json::json!({"type": "service_account", "private_key": "-----BEGIN"});
+        let err = validate_gcp_sts_body(&key_file, true).unwrap_err();
+        assert!(err.to_string().contains("key file"), "{err}");
+
+        let bad_email =
+            serde_json::json!({"data": {"attributes": {"client_email": "me@gmail.com"}}});
+        assert!(validate_gcp_sts_body(&bad_email, true).is_err());
+
+        let partial = serde_json::json!({"data": {"attributes": {"automute": true}}});
+        assert!(validate_gcp_sts_body(&partial, true).is_err());
+        assert!(validate_gcp_sts_body(&partial, false).is_ok());
+    }
+
+    #[test]
+    fn test_validate_azure_body() {
+        let tenant = "00000000-0000-0000-0000-000000000001";
+        let client = "00000000-0000-0000-0000-000000000002";
+        let ok =
+            serde_json::json!({"tenant_name": tenant, "client_id": client, "client_secret": "s"});
+        assert!(validate_azure_body(&ok, true).is_ok());
+
+        let no_secret = serde_json::json!({"tenant_name": tenant, "client_id": client});
+        assert!(validate_azure_body(&no_secret, true).is_err());
+        assert!(validate_azure_body(&no_secret, false).is_ok());
+
+        let bad_tenant = serde_json::json!({"tenant_name": "contoso", "client_id": client});
+        assert!(validate_azure_body(&bad_tenant, false).is_err());
+
+        let bad_new =
+            serde_json::json!({"tenant_name": tenant, "client_id": client, "new_client_id": "x"});
+        assert!(validate_azure_body(&bad_new, false).is_err());
+    }
+}
@@ -0,0 +1,245 @@
+//! Cloud Cost Management account configs: the AWS CUR, Azure, and GCP
+//! billing exports Datadog ingests. Each provider has its own endpoint and
+//! request types, but the commands on top of them are the same.
+
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+use crate::formatter;
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum Provider {
+    Aws,
+    Azure,
+    Gcp,
+}
+
+impl Provider {
+    pub const ALL: [Provider; 3] = [Provider::Aws, Provider::Azure, Provider::Gcp];
+
+    pub fn name(self) -> &'static str {
+        match self {
+            Provider::Aws => "aws",
+            Provider::Azure => "azure",
+            Provider::Gcp => "gcp",
+        }
+    }
+
+    fn path(self) -> &'static str {
+        match self {
+            Provider::Aws => "/api/v2/cost/aws_cur_config",
+            Provider::Azure => "/api/v2/cost/azure_uc_config",
+            Provider::Gcp => "/api/v2/cost/gcp_uc_config",
+        }
+    }
+
+    fn type_prefix(self) -> &'static str {
+        match self {
+            Provider::Aws => "aws_cur_config",
+            Provider::Azure => "azure_uc_config",
+            Provider::Gcp => "gcp_uc_config",
+        }
+    }
+
+    fn label(self) -> &'static str {
+        match self {
+            Provider::Aws => "AWS CUR config",
+            Provider::Azure => "Azure cost config",
+            Provider::Gcp => "GCP cost config",
+        }
+    }
+}
+
+impl std::str::FromStr for Provider {
+    type Err = anyhow::Error;
+
+    fn from_str(s: &str) -> Result<Self> {
+        match s.to_ascii_lowercase().as_str() {
+            "aws" => Ok(Provider::Aws),
+            "azure" => Ok(Provider::Azure),
+            "gcp" => Ok(Provider::Gcp),
+            _ => bail!("invalid provider {s:?}: expected aws, azure, or gcp"),
+        }
+    }
+}
+
+/// Wrap a config file in the request envelope. Files may hold the full
+/// `{"data": ...}` request or just the attributes object.
+pub fn request_body(
+    provider: Provider,
+    kind: &str,
+    file: serde_json::Value,
+) -> Result<serde_json::Value> {
+    if file.get("data").is_some() {
+        return Ok(file);
+    }
+    if !file.is_object() {
+        bail!("invalid config file: expected a JSON object of attributes or a full request");
+    }
+    Ok(serde_json::json!({
+        "data": {
+            "type": format!("{}_{kind}_request", provider.type_prefix()),
+            "attributes": file,
+        }
+    }))
+}
+
+/// One row per config for `status`: which account, whether ingestion is
+/// healthy, and any errors the export reported.
+pub fn status_row(provider: Provider, config: &serde_json::Value) -> serde_json::Value {
+    let attrs = &config["attributes"];
+    let account = attrs["account_id"]
+        .as_str()
+        .or(attrs["billing_account_id"].as_str())
+        .or(attrs["scope"].as_str());
+    let errors: Vec<&str> = attrs["error_messages"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|e| e.as_str())
+        .collect();
+    serde_json::json!({
+        "provider": provider.name(),
+        "id": config["id"],
+        "account": account,
+        "status": attrs["status"],
+        "enabled": attrs["is_enabled"],
+        "status_updated_at": attrs["status_updated_at"],
+        "errors": errors.join("; "),
+    })
+}
+
+async fn list_configs(cfg: &Config, provider: Provider) -> Result<Vec<serde_json::Value>> {
+    let resp = crate::api::get(cfg, provider.path(), &[])
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to list {}s", provider.label()), e))?;
+    Ok(resp["data"].as_array().cloned().unwrap_or_default())
+}
+
+pub async fn list(cfg: &Config, provider: Provider) -> Result<()> {
+    let configs = list_configs(cfg, provider).await?;
+    formatter::output(cfg, &serde_json::json!({ "data": configs }))
+}
+
+pub async fn create(cfg: &Config, provider: Provider, file: &str) -> Result<()> {
+    let body = request_body(provider, "post", crate::util::read_json_file(file)?)?;
+    let resp = crate::api::post(cfg, provider.path(), &body)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to create {}", provider.label()), e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn update(
+    cfg: &Config,
+    provider: Provider,
+    config_id: &str,
+    file: Option<&str>,
+    enabled: Option<bool>,
+) -> Result<()> {
+    if file.is_none() && enabled.is_none() {
+        bail!("nothing to update: pass --file and/or --enabled");
+    }
+    let mut attrs = match file {
+        Some(f) => crate::util::read_json_file(f)?,
+        None => serde_json::json!({}),
+    };
+    if let Some(enabled) = enabled {
+        let target = if attrs.get("data").is_some() {
+            &mut attrs["data"]["attributes"]
+        } else {
+            &mut attrs
+        };
+        let Some(obj) = target.as_object_mut() else {
+            bail!("invalid config file: expected a JSON object");
+        };
+        obj.insert("is_enabled".into(), serde_json::json!(enabled));
+    }
+    let body = request_body(provider, "patch", attrs)?;
+    let resp = crate::api::patch(cfg, &format!("{}/{config_id}", provider.path()), &body)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to update {}", provider.label()), e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn delete(cfg: &Config, provider: Provider, config_id: &str) -> Result<()> {
+    crate::api::delete(cfg, &format!("{}/{config_id}", provider.path()))
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to delete {}", provider.label()), e))?;
+    println!("{} {config_id} deleted.", provider.label());
+    Ok(())
+}
+
+/// Ingestion status across providers. A provider that fails to list is
+/// reported as a warning so the others still show.
+pub async fn status(cfg: &Config, provider: Option<Provider>) -> Result<()> {
+    let providers = match provider {
+        Some(p) => vec![p],
+        None => Provider::ALL.to_vec(),
+    };
+    let mut rows = Vec::new();
+    let mut errors = Vec::new();
+    for p in &providers {
+        match list_configs(cfg, *p).await {
+            Ok(configs) => rows.extend(configs.iter().map(|c| status_row(*p, c))),
+            Err(e) => errors.push(e),
+        }
+    }
+    if errors.len() == providers.len() {
+        return Err(errors.remove(0));
+    }
+    for e in &errors {
+        eprintln!("warning: {e:#}");
+    }
+    formatter::output(cfg, &rows)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_request_body_wraps_attributes() {
+        let body = request_body(
+            Provider::Aws,
+            "post",
+            serde_json::json!({"account_id": "123", "bucket_name": "cur"}),
+        )
+        .unwrap();
+        assert_eq!(body["data"]["type"], "aws_cur_config_post_request");
+        assert_eq!(body["data"]["attributes"]["bucket_name"], "cur");
+
+        let full = serde_json::json!({"data": {"type": "gcp_uc_config_patch_request"}});
+        assert_eq!(
+            request_body(Provider::Gcp, "patch", full.clone()).unwrap(),
+            full
+        );
+        assert!(request_body(Provider::Azure, "post", serde_json::json!([1])).is_err());
+    }
+
+    #[test]
+    fn test_status_row() {
+        let config = serde_json::json!({
+            "id": "42",
+            "attributes": {
+                "account_id": "123456789012",
+                "status": "error",
+                "status_updated_at": "2026-01-02T03:04:05Z",
+                "error_messages": ["access denied", "bucket missing"]
+            }
+        });
+        let row = status_row(Provider::Aws, &config);
+        assert_eq!(row["provider"], "aws");
+        assert_eq!(row["account"], "123456789012");
+        assert_eq!(row["status"], "error");
+        assert_eq!(row["errors"], "access denied; bucket missing");
+
+        let gcp = serde_json::json!({"id": "7", "attributes": {"billing_account_id": "B-1", "status": "active"}});
+        assert_eq!(status_row(Provider::Gcp, &gcp)["account"], "B-1");
+    }
+
+    #[test]
+    fn test_provider_from_str() {
+        assert_eq!("AWS".parse::<Provider>().unwrap(), Provider::Aws);
+        assert!("oci".parse::<Provider>().is_err());
+    }
+}
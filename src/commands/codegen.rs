@@ -0,0 +1,474 @@
+//! `pup codegen`: turn a live resource into an API-client snippet or a
+//! Terraform block that recreates it.
+
+use anyhow::{bail, Result};
+use serde_json::Value;
+
+use crate::config::Config;
+use crate::formatter;
+use crate::util;
+
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum Lang {
+    Go,
+    Python,
+    Terraform,
+}
+
+impl std::str::FromStr for Lang {
+    type Err = anyhow::Error;
+    fn from_str(s: &str) -> Result<Self> {
+        match s.to_lowercase().as_str() {
+            "go" => Ok(Lang::Go),
+            "python" | "py" => Ok(Lang::Python),
+            "terraform" | "tf" | "hcl" => Ok(Lang::Terraform),
+            _ => bail!("unsupported --lang {s:?} (expected go, python, or terraform)"),
+        }
+    }
+}
+
+/// How a resource type is fetched and created in each client.
+struct Resource {
+    kind: &'static str,
+    path: &'static str,
+    name_field: &'static str,
+    go_model: &'static str,
+    go_api: &'static str,
+    go_create: &'static str,
+    py_module: &'static str,
+    py_api: &'static str,
+    py_create: &'static str,
+}
+
+const RESOURCES: &[Resource] = &[
+    Resource {
+        kind: "monitor",
+        path: "/api/v1/monitor",
+        name_field: "name",
+        go_model: "Monitor",
+        go_api: "NewMonitorsApi",
+        go_create: "CreateMonitor",
+        py_module: "monitors_api",
+        py_api: "MonitorsApi",
+        py_create: "create_monitor",
+    },
+    Resource {
+        kind: "dashboard",
+        path: "/api/v1/dashboard",
+        name_field: "title",
+        go_model: "Dashboard",
+        go_api: "NewDashboardsApi",
+        go_create: "CreateDashboard",
+        py_module: "dashboards_api",
+        py_api: "DashboardsApi",
+        py_create: "create_dashboard",
+    },
+    Resource {
+        kind: "slo",
+        path: "/api/v1/slo",
+        name_field: "name",
+        go_model: "ServiceLevelObjectiveRequest",
+        go_api: "NewServiceLevelObjectivesApi",
+        go_create: "CreateSLO",
+        py_module: "service_level_objectives_api",
+        py_api: "ServiceLevelObjectivesApi",
+        py_create: "create_slo",
+    },
+];
+
+fn resource(kind: &str) -> Result<&'static Resource> {
+    let kind = kind.to_lowercase();
+    let kind = kind.trim_end_matches('s');
+    match RESOURCES.iter().find(|r| r.kind == kind) {
+        Some(r) => Ok(r),
+        None => bail!("unsupported --resource {kind:?} (expected monitor, dashboard, or slo)"),
+    }
+}
+
+/// Server-managed SLO fields that a create request rejects or ignores.
+const SLO_READ_ONLY_FIELDS: &[&str] = &[
+    "id",
+    "created_at",
+    "modified_at",
+    "creator",
+    "configured_alert_ids",
+    "monitor_tags",
+];
+
+/// Reduce a fetched resource to the body that would create it.
+fn create_body(res: &Resource, fetched: Value) -> Value {
+    match res.kind {
+        "monitor" => super::monitors::import_body(fetched),
+        "dashboard" => super::dashboards::import_body(fetched),
+        _ => {
+            // GET /api/v1/slo/{id} wraps the SLO in `data`.
+            let mut slo = match fetched.get("data") {
+                Some(data) if data.is_object() => data.clone(),
+                _ => fetched,
+            };
+            if let Some(obj) = slo.as_object_mut() {
+                for field in SLO_READ_ONLY_FIELDS {
+                    obj.remove(*field);
+                }
+                obj.retain(|_, v| !v.is_null());
+            }
+            formatter::sort_json_value(slo)
+        }
+    }
+}
+
+// ---------------------------------------------------------------------------
+// Go
+// ---------------------------------------------------------------------------
+
+fn render_go(res: &Resource, body: &Value) -> Result<String> {
+    // Raw string literals can't contain backticks; splice them in as "`".
+    let json = serde_json::to_string_pretty(body)?.replace('`', "` + \"`\" + `");
+    Ok(format!(
+        r#"package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+const {kind}JSON = `{json}`
+
+func main() {{
+	var body datadogV1.{model}
+	if err := json.Unmarshal([]byte({kind}JSON), &body); err != nil {{
+		fmt.Fprintf(os.Stderr, "invalid {kind} body: %v\n", err)
+		os.Exit(1)
+	}}
+
+	ctx := datadog.NewDefaultContext(context.Background())
+	api := datadogV1.{api}(datadog.NewAPIClient(datadog.NewConfiguration()))
+	resp, r, err := api.{create}(ctx, body)
+	if err != nil {{
+		fmt.Fprintf(os.Stderr, "Error when calling {create}: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Full HTTP response: %v\n", r)
+		os.Exit(1)
+	}}
+
+	out, _ := json.MarshalIndent(resp, "", "  ")
+	fmt.Println(string(out))
+}}
+"#,
+        kind = res.kind,
+        model = res.go_model,
+        api = res.go_api,
+        create = res.go_create,
+    ))
+}
+
+// ---------------------------------------------------------------------------
+// Python
+// ---------------------------------------------------------------------------
+
+/// Render JSON as a Python literal (`True`/`False`/`None`).
+fn python_value(v: &Value, indent: usize) -> String {
+    let pad = "    ".repeat(indent + 1);
+    let close = "    ".repeat(indent);
+    match v {
+        Value::Null => "None".into(),
+        Value::Bool(true) => "True".into(),
+        Value::Bool(false) => "False".into(),
+        // JSON string escapes are valid Python string escapes.
+        Value::Number(_) | Value::String(_) => v.to_string(),
+        Value::Array(items) if items.is_empty() => "[]".into(),
+        Value::Array(items) => {
+            let inner: Vec<String> = items
+                .iter()
+                .map(|i| format!("{pad}{},\n", python_value(i, indent + 1)))
+                .collect();
+            format!("[\n{}{close}]", inner.concat())
+        }
+        Value::Object(map) if map.is_empty() => "{}".into(),
+        Value::Object(map) => {
+            let inner: Vec<String> = map
+                .iter()
+                .map(|(k, v)| {
+                    format!(
+                        "{pad}{}: {},\n",
+                        Value::String(k.clone()),
+                        python_value(v, indent + 1)
+                    )
+                })
+                .collect();
+            format!("{{\n{}{close}}}", inner.concat())
+        }
+    }
+}
+
+fn render_python(res: &Resource, body: &Value) -> String {
+    format!(
+        r#"from datadog_api_client import ApiClient, Configuration
+from datadog_api_client.v1.api.{module} import {api}
+
+body = {body}
+
+configuration = Configuration()
+with ApiClient(configuration) as api_client:
+    api_instance = {api}(api_client)
+    response = api_instance.{create}(body=body)
+    print(response)
+"#,
+        module = res.py_module,
+        api = res.py_api,
+        create = res.py_create,
+        body = python_value(body, 0),
+    )
+}
+
+// ---------------------------------------------------------------------------
+// Terraform
+// ---------------------------------------------------------------------------
+
+/// A Terraform resource name from a display name: snake_case, never starting with a digit.
+fn terraform_name(name: &str, fallback: &str) -> String {
+    let name = util::slug(name, fallback).replace('-', "_");
+    if name.starts_with(|c: char| c.is_ascii_digit()) {
+        format!("{fallback}_{name}")
+    } else {
+        name
+    }
+}
+
+fn hcl_string(s: &str) -> String {
+    let quoted = Value::String(s.to_string()).to_string();
+    // Escape template sequences so Terraform keeps them literal.
+    quoted.replace("${", "$${").replace("%{", "%%{")
+}
+
+fn hcl_key(k: &str) -> String {
+    let ident = k.starts_with(|c: char| c.is_ascii_alphabetic() || c == '_')
+        && k.chars()
+            .all(|c| c.is_ascii_alphanumeric() || c == '_' || c == '-');
+    if ident {
+        k.to_string()
+    } else {
+        hcl_string(k)
+    }
+}
+
+/// Render JSON as an HCL expression (for `jsonencode(...)` and attributes).
+fn hcl_value(v: &Value, indent: usize) -> String {
+    let pad = "  ".repeat(indent + 1);
+    let close = "  ".repeat(indent);
+    match v {
+        Value::Null => "null".into(),
+        Value::Bool(_) | Value::Number(_) => v.to_string(),
+        Value::String(s) => hcl_string(s),
+        Value::Array(items) if items.is_empty() => "[]".into(),
+        Value::Array(items) => {
+            let inner: Vec<String> = items
+                .iter()
+                .map(|i| format!("{pad}{},\n", hcl_value(i, indent + 1)))
+                .collect();
+            format!("[\n{}{close}]", inner.concat())
+        }
+        Value::Object(map) if map.is_empty() => "{}".into(),
+        Value::Object(map) => {
+            let inner: Vec<String> = map
+                .iter()
+                .map(|(k, v)| format!("{pad}{} = {}\n", hcl_key(k), hcl_value(v, indent + 1)))
+                .collect();
+            format!("{{\n{}{close}}}", inner.concat())
+        }
+    }
+}
+
+/// `datadog_service_level_objective` for metric and monitor SLOs.
+fn render_terraform_slo(name: &str, slo: &Value) -> Result<String> {
+    let kind = slo["type"].as_str().unwrap_or("");
+    if kind != "metric" && kind != "monitor" {
+        bail!(
+            "terraform output supports metric and monitor SLOs, not {kind:?} \
+             (use --lang go or --lang python)"
+        );
+    }
+    let mut out = format!("resource \"datadog_service_level_objective\" \"{name}\" {{\n");
+    for field in ["name", "type", "description"] {
+        if let Some(v) = slo.get(field).filter(|v| !v.is_null()) {
+            out.push_str(&format!("  {field} = {}\n", hcl_value(v, 1)));
+        }
+    }
+    if kind == "metric" {
+        out.push_str("\n  query {\n");
+        for field in ["numerator", "denominator"] {
+            let q = slo
+                .pointer(&format!("/query/{field}"))
+                .unwrap_or(&Value::Null);
+            out.push_str(&format!("    {field} = {}\n", hcl_value(q, 2)));
+        }
+        out.push_str("  }\n");
+    } else if let Some(ids) = slo.get("monitor_ids") {
+        out.push_str(&format!("  monitor_ids = {}\n", hcl_value(ids, 1)));
+    }
+    for threshold in slo["thresholds"].as_array().into_iter().flatten() {
+        out.push_str("\n  thresholds {\n");
+        for field in ["timeframe", "target", "warning"] {
+            if let Some(v) = threshold.get(field).filter(|v| !v.is_null()) {
+                out.push_str(&format!("    {field} = {}\n", hcl_value(v, 2)));
+            }
+        }
+        out.push_str("  }\n");
+    }
+    if let Some(tags) = slo
+        .get("tags")
+        .filter(|t| t.as_array().is_some_and(|a| !a.is_empty()))
+    {
+        out.push_str(&format!("\n  tags = {}\n", hcl_value(tags, 1)));
+    }
+    out.push_str("}\n");
+    Ok(out)
+}
+
+fn render_terraform(res: &Resource, body: &Value) -> Result<String> {
+    let display = body[res.name_field].as_str().unwrap_or("");
+    let name = terraform_name(display, res.kind);
+    if res.kind == "slo" {
+        return render_terraform_slo(&name, body);
+    }
+    // The *_json resources take the API body verbatim, so nothing is lost in translation.
+    Ok(format!(
+        "resource \"datadog_{kind}_json\" \"{name}\" {{\n  {kind} = jsonencode({})\n}}\n",
+        hcl_value(body, 1),
+        kind = res.kind,
+    ))
+}
+
+/// Render the create body for `kind` in `lang`.
+pub fn render(kind: &str, lang: Lang, fetched: Value) -> Result<String> {
+    let res = resource(kind)?;
+    let body = create_body(res, fetched);
+    match lang {
+        Lang::Go => render_go(res, &body),
+        Lang::Python => Ok(render_python(res, &body)),
+        Lang::Terraform => render_terraform(res, &body),
+    }
+}
+
+/// Fetch a resource and print code that recreates it.
+pub async fn run(cfg: &Config, kind: &str, id: &str, lang: &str) -> Result<()> {
+    let res = resource(kind)?;
+    let lang: Lang = lang.parse()?;
+    let fetched = crate::api::get(cfg, &format!("{}/{id}", res.path), &[])
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to get {}", res.kind), e))?;
+    let code = render(res.kind, lang, fetched)?;
+    if cfg.agent_mode {
+        let lang_name = match lang {
+            Lang::Go => "go",
+            Lang::Python => "python",
+            Lang::Terraform => "terraform",
+        };
+        let out = serde_json::json!({
+            "resource": res.kind,
+            "id": id,
+            "lang": lang_name,
+            "code": code,
+        });
+        return formatter::format_and_print(&out, cfg, None);
+    }
+    print!("{code}");
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn monitor() -> Value {
+        json!({
+            "id": 123,
+            "name": "CPU ${host} high",
+            "type": "metric alert",
+            "query": "avg(last_5m):avg:system.cpu.user{*} > 90",
+            "message": "CPU is high @slack-ops",
+            "tags": ["team:sre"],
+            "options": {"notify_no_data": false, "thresholds": {"critical": 90}},
+            "overall_state": "OK",
+            "created": "2024-01-01T00:00:00Z",
+            "creator": {"email": "a@b.c"}
+        })
+    }
+
+    #[test]
+    fn test_resource_lookup() {
+        assert_eq!(resource("monitors").unwrap().kind, "monitor");
+        assert_eq!(resource("SLO").unwrap().kind, "slo");
+        assert!(resource("notebook").is_err());
+        assert!("rust".parse::<Lang>().is_err());
+        assert_eq!("tf".parse::<Lang>().unwrap(), Lang::Terraform);
+    }
+
+    #[test]
+    fn test_render_terraform_monitor() {
+        let out = render("monitor", Lang::Terraform, monitor()).unwrap();
+        assert!(out.starts_with("resource \"datadog_monitor_json\" \"cpu_host_high\" {\n"));
+        assert!(out.contains("  monitor = jsonencode({\n"));
+        assert!(out.contains("    name = \"CPU $${host} high\"\n"));
+        assert!(out.contains("      notify_no_data = false\n"));
+        assert!(!out.contains("overall_state"));
+        assert!(!out.contains("  id = 123"));
+    }
+
+    #[test]
+    fn test_render_python_monitor() {
+        let out = render("monitor", Lang::Python, monitor()).unwrap();
+        assert!(out.contains("from datadog_api_client.v1.api.monitors_api import MonitorsApi"));
+        assert!(out.contains("        \"notify_no_data\": False,\n"));
+        assert!(out.contains("api_instance.create_monitor(body=body)"));
+        assert!(!out.contains("creator"));
+    }
+
+    #[test]
+    fn test_render_go_escapes_backticks() {
+        let mut m = monitor();
+        m["message"] = json!("run `restart`");
+        let out = render("monitor", Lang::Go, m).unwrap();
+        assert!(out.contains("var body datadogV1.Monitor"));
+        assert!(out.contains("api.CreateMonitor(ctx, body)"));
+        assert!(out.contains("run ` + \"`\" + `restart` + \"`\" + `"));
+    }
+
+    #[test]
+    fn test_render_terraform_slo() {
+        let slo = json!({"data": {
+            "id": "abc",
+            "name": "API availability",
+            "type": "metric",
+            "query": {"numerator": "sum:hits{!status:error}.as_count()", "denominator": "sum:hits{*}.as_count()"},
+            "thresholds": [{"timeframe": "30d", "target": 99.9, "warning": null}],
+            "tags": ["service:api"],
+            "created_at": 1700000000
+        }});
+        let out = render("slo", Lang::Terraform, slo).unwrap();
+        assert!(out
+            .starts_with("resource \"datadog_service_level_objective\" \"api_availability\" {\n"));
+        assert!(out.contains("    numerator = \"sum:hits{!status:error}.as_count()\"\n"));
+        assert!(out.contains("    timeframe = \"30d\"\n    target = 99.9\n  }\n"));
+        assert!(!out.contains("warning"));
+
+        let slo = json!({"data": {"name": "p95", "type": "time_slice"}});
+        assert!(render("slo", Lang::Terraform, slo).is_err());
+    }
+
+    #[test]
+    fn test_terraform_name() {
+        assert_eq!(
+            terraform_name("5xx errors", "monitor"),
+            "monitor_5xx_errors"
+        );
+        assert_eq!(terraform_name("", "dashboard"), "dashboard");
+        assert_eq!(hcl_key("a.b"), "\"a.b\"");
+        assert_eq!(hcl_key("query"), "query");
+    }
+}
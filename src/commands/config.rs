@@ -0,0 +1,291 @@
+use anyhow::{bail, Context, Result};
+use std::collections::BTreeMap;
+use std::path::PathBuf;
+
+use crate::config::{self, Config, Profile};
+
+fn config_path() -> Result<PathBuf> {
+    let dir = config::config_dir().context("could not determine config directory")?;
+    Ok(dir.join("config.yaml"))
+}
+
+/// Load config.yaml as a generic document so keys pup doesn't model survive a rewrite.
+fn load_document() -> Result<serde_yaml::Mapping> {
+    let path = config_path()?;
+    match std::fs::read_to_string(&path) {
+        Ok(contents) if contents.trim().is_empty() => Ok(serde_yaml::Mapping::new()),
+        Ok(contents) => serde_yaml::from_str(&contents)
+            .with_context(|| format!("failed to parse {}", path.display())),
+        Err(e) if e.kind() == std::io::ErrorKind::NotFound => Ok(serde_yaml::Mapping::new()),
+        Err(e) => Err(e.into()),
+    }
+}
+
+fn save_document(doc: &serde_yaml::Mapping) -> Result<()> {
+    let path = config_path()?;
+    if let Some(parent) = path.parent() {
+        std::fs::create_dir_all(parent)?;
+    }
+    let yaml = serde_yaml::to_string(doc)?;
+    std::fs::write(&path, yaml)
+        .with_context(|| format!("failed to write config: {}", path.display()))?;
+    // Profiles may hold API/app keys.
+    #[cfg(unix)]
+    {
+        use std::os::unix::fs::PermissionsExt;
+        std::fs::set_permissions(&path, std::fs::Permissions::from_mode(0o600))?;
+    }
+    Ok(())
+}
+
+fn profiles(doc: &serde_yaml::Mapping) -> Result<BTreeMap<String, Profile>> {
+    match doc.get("profiles") {
+        Some(section) => {
+            serde_yaml::from_value(section.clone()).context("invalid 'profiles' section")
+        }
+        None => Ok(BTreeMap::new()),
+    }
+}
+
+/// Merge the set fields of `update` into profile `name`, creating it if needed.
+fn upsert_profile(doc: &mut serde_yaml::Mapping, name: &str, update: Profile) -> Result<Profile> {
+    let mut all = profiles(doc)?;
+    let current = all.remove(name).unwrap_or_default();
+    let merged = Profile {
+        site: update.site.or(current.site),
+        api_key: update.api_key.or(current.api_key),
+        app_key: update.app_key.or(current.app_key),
+        api_key_file: update.api_key_file.or(current.api_key_file),
+        app_key_file: update.app_key_file.or(current.app_key_file),
+        output: update.output.or(current.output),
+        org: update.org.or(current.org),
+    };
+    all.insert(name.to_string(), merged.clone());
+    doc.insert("profiles".into(), serde_yaml::to_value(&all)?);
+    Ok(merged)
+}
+
+fn remove_profile(doc: &mut serde_yaml::Mapping, name: &str) -> Result<()> {
+    let mut all = profiles(doc)?;
+    if all.remove(name).is_none() {
+        bail!("profile not found: {name}");
+    }
+    if all.is_empty() {
+        doc.remove("profiles");
+    } else {
+        doc.insert("profiles".into(), serde_yaml::to_value(&all)?);
+    }
+    Ok(())
+}
+
+fn profile_summary(name: &str, profile: &Profile, active: Option<&str>) -> serde_json::Value {
+    let key = |inline: &Option<String>, file: &Option<String>| match (inline, file) {
+        (Some(k), _) => Some(super::test::mask_key(k)),
+        (None, Some(f)) => Some(format!("file:{f}")),
+        (None, None) => None,
+    };
+    serde_json::json!({
+        "name": name,
+        "active": active == Some(name),
+        "site": profile.site,
+        "org": profile.org,
+        "output": profile.output,
+        "api_key": key(&profile.api_key, &profile.api_key_file),
+        "app_key": key(&profile.app_key, &profile.app_key_file),
+    })
+}
+
+pub fn profiles_list(cfg: &Config) -> Result<()> {
+    let doc = load_document()?;
+    let active = config::active_profile();
+    let items: Vec<serde_json::Value> = profiles(&doc)?
+        .iter()
+        .map(|(name, p)| profile_summary(name, p, active.as_deref()))
+        .collect();
+    if items.is_empty() {
+        eprintln!("No profiles configured. Create one with 'pup config profiles set <name>'.");
+        return Ok(());
+    }
+    crate::formatter::format_and_print(&items, cfg, None)
+}
+
+pub fn profiles_set(name: &str, update: Profile) -> Result<()> {
+    if name.trim().is_empty() {
+        bail!("profile name must not be empty");
+    }
+    if let Some(output) = &update.output {
+        output.parse::<config::OutputFormat>()?;
+    }
+    let mut doc = load_document()?;
+    let created = !profiles(&doc)?.contains_key(name);
+    upsert_profile(&mut doc, name, update)?;
+    save_document(&doc)?;
+    let verb = if created { "created" } else { "updated" };
+    println!("Profile {name} {verb}. Use it with --profile {name} or PUP_PROFILE={name}.");
+    Ok(())
+}
+
+pub fn profiles_delete(name: &str) -> Result<()> {
+    let mut doc = load_document()?;
+    remove_profile(&mut doc, name)?;
+    save_document(&doc)?;
+    println!("Profile {name} deleted.");
+    Ok(())
+}
+
+/// Lint config.yaml and aliases.yaml. `commands` is the list of top-level
+/// pup commands aliases may target. Fails if any errors are found so CI can
+/// gate on it; warnings alone pass.
+pub fn lint(cfg: &Config, commands: &[String]) -> Result<()> {
+    let dir = config::config_dir().context("could not determine config directory")?;
+    let mut rows = Vec::new();
+    let mut errors = 0;
+    for file in ["config.yaml", "aliases.yaml"] {
+        let contents = match std::fs::read_to_string(dir.join(file)) {
+            Ok(contents) => contents,
+            Err(e) if e.kind() == std::io::ErrorKind::NotFound => continue,
+            Err(e) => bail!("failed to read {}: {e}", dir.join(file).display()),
+        };
+        let issues = if file == "config.yaml" {
+            config::lint_config(&contents)
+        } else {
+            config::lint_aliases(&contents, commands)
+        };
+        for issue in issues {
+            errors += usize::from(issue.level == "error");
+            rows.push(serde_json::json!({
+                "file": file,
+                "level": issue.level,
+                "key": issue.key,
+                "message": issue.message,
+            }));
+        }
+    }
+    if rows.is_empty() {
+        eprintln!("No problems found in {}.", dir.display());
+        return Ok(());
+    }
+    crate::formatter::format_and_print(&rows, cfg, None)?;
+    if errors > 0 {
+        bail!("{errors} error(s) found in {}", dir.display());
+    }
+    Ok(())
+}
+
+/// Print the effective value of every setting, with `sources` also where it
+/// came from. `settings` is the provenance recorded while loading the config,
+/// with main's flag overrides applied. Secrets are masked.
+pub fn show(
+    cfg: &Config,
+    settings: Vec<config::SettingSource>,
+    credential_store: Option<&str>,
+    sources: bool,
+) -> Result<()> {
+    let masked = |key: &Option<String>| key.as_deref().map(super::test::mask_key);
+    // Not run just to display it; see Config::resolve_credential_process.
+    let pending = cfg
+        .credential_process
+        .as_ref()
+        .map(|_| "(from credential_process)".to_string());
+    #[cfg(not(target_arch = "wasm32"))]
+    let (timeout, connect_timeout) = crate::client::timeouts();
+    #[cfg(target_arch = "wasm32")]
+    let (timeout, connect_timeout): (Option<std::time::Duration>, _) = (None, None);
+    let rows: Vec<serde_json::Value> = settings
+        .into_iter()
+        .map(|s| {
+            let value = match s.setting {
+                "profile" => config::active_profile(),
+                "site" => Some(cfg.site.clone()),
+                "org" => cfg.org.clone(),
+                "api_key" => masked(&cfg.api_key).or_else(|| pending.clone()),
+                "app_key" => masked(&cfg.app_key).or_else(|| pending.clone()),
+                "access_token" => masked(&cfg.access_token),
+                "output" => Some(cfg.output_format.to_string()),
+                "auto_approve" => Some(cfg.auto_approve.to_string()),
+                "max_output_bytes" => Some(cfg.max_output_bytes.to_string()),
+                "time_format" => cfg.time_format.as_ref().map(|t| t.to_string()),
+                "timeout" => timeout.map(|t| format!("{t:?}")),
+                "connect_timeout" => connect_timeout.map(|t| format!("{t:?}")),
+                "credential_store" => credential_store
+                    .map(String::from)
+                    .or(s.raw)
+                    .or_else(|| Some("auto".into())),
+                _ => None,
+            };
+            // A source that produced nothing (e.g. an empty token store).
+            let source = if value.is_none() {
+                "not set".to_string()
+            } else {
+                s.source
+            };
+            let mut row = serde_json::json!({ "setting": s.setting, "value": value });
+            if sources {
+                row["source"] = serde_json::json!(source);
+            }
+            row
+        })
+        .collect();
+    crate::formatter::format_and_print(&rows, cfg, None)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn doc(yaml: &str) -> serde_yaml::Mapping {
+        serde_yaml::from_str(yaml).unwrap()
+    }
+
+    #[test]
+    fn test_upsert_profile_merges_and_keeps_other_keys() {
+        let mut d = doc(
+            "site: datadoghq.com\nprofiles:\n  staging:\n    site: datadoghq.eu\n    org: stg\n",
+        );
+        let merged = upsert_profile(
+            &mut d,
+            "staging",
+            Profile {
+                output: Some("table".into()),
+                ..Default::default()
+            },
+        )
+        .unwrap();
+        assert_eq!(merged.site.as_deref(), Some("datadoghq.eu"));
+        assert_eq!(merged.output.as_deref(), Some("table"));
+        assert_eq!(d["site"], serde_yaml::Value::from("datadoghq.com"));
+
+        upsert_profile(
+            &mut d,
+            "prod",
+            Profile {
+                site: Some("us5.datadoghq.com".into()),
+                ..Default::default()
+            },
+        )
+        .unwrap();
+        let names: Vec<String> = profiles(&d).unwrap().into_keys().collect();
+        assert_eq!(names, vec!["prod", "staging"]);
+    }
+
+    #[test]
+    fn test_remove_profile() {
+        let mut d = doc("profiles:\n  staging:\n    site: datadoghq.eu\n");
+        assert!(remove_profile(&mut d, "prod").is_err());
+        remove_profile(&mut d, "staging").unwrap();
+        assert!(d.get("profiles").is_none());
+    }
+
+    #[test]
+    fn test_profile_summary_masks_keys() {
+        let p = Profile {
+            api_key: Some("abcdefghijklmnopqrstuvwxyz".into()),
+            app_key_file: Some("/run/secrets/app".into()),
+            ..Default::default()
+        };
+        let s = profile_summary("staging", &p, Some("staging"));
+        assert_eq!(s["active"], true);
+        assert_eq!(s["api_key"], "abcdefgh...wxyz");
+        assert_eq!(s["app_key"], "file:/run/secrets/app");
+    }
+}
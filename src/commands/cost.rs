@@ -7,7 +7,7 @@ use datadog_api_client::datadogV2::api_usage_metering::{
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
-use crate::config::Config;
+use crate::config::{Config, OutputFormat};
 use crate::formatter;
 use crate::util;
 
@@ -105,3 +105,341 @@ pub async fn attribution(cfg: &Config, start: String, fields: Option<String>) ->
     let data = crate::api::get(cfg, "/api/v2/cost_by_tag/monthly_cost_attribution", &query).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Tag compliance
+// ---------------------------------------------------------------------------
+
+/// Tag values the cost attribution API uses for untagged spend.
+const UNTAGGED_VALUES: &[&str] = &["", "<none>", "<empty>", "untagged"];
+
+/// Resolve a month argument to the RFC3339 start of that month (UTC).
+/// Accepts `YYYY-MM`, `NM`/`Nmo` (N months ago, `0M` = this month), or any
+/// time accepted elsewhere (`30d`, RFC3339, ...), truncated to its month.
+pub fn parse_month(input: &str) -> Result<String> {
+    use chrono::Datelike;
+    let input = input.trim();
+    let (year, month) = if let Some((y, m)) = input.split_once('-').filter(|(y, m)| {
+        y.len() == 4
+            && (1..=2).contains(&m.len())
+            && format!("{y}{m}").chars().all(|c| c.is_ascii_digit())
+    }) {
+        (y.parse::<i32>()?, m.parse::<u32>()?)
+    } else if let Some(n) = input
+        .strip_suffix("mo")
+        .or_else(|| input.strip_suffix('M'))
+        .and_then(|n| n.trim_start_matches('-').parse::<i32>().ok())
+    {
+        let now = chrono::Utc::now();
+        let total = now.year() * 12 + now.month0() as i32 - n;
+        (total.div_euclid(12), total.rem_euclid(12) as u32 + 1)
+    } else {
+        let ms = util::parse_time_to_unix_millis(input)?;
+        let dt = chrono::DateTime::from_timestamp_millis(ms)
+            .ok_or_else(|| anyhow::anyhow!("time out of range: {input:?}"))?;
+        (dt.year(), dt.month())
+    };
+    if !(1..=12).contains(&month) {
+        anyhow::bail!("invalid month in {input:?} (expected YYYY-MM)");
+    }
+    Ok(format!("{year:04}-{month:02}-01T00:00:00+00:00"))
+}
+
+/// Sum a row's cost values per product. `<product>_total_cost` wins when
+/// present; otherwise committed/on-demand (or bare `_cost`) fields are added up,
+/// so each dollar is counted once.
+fn product_costs(
+    values: &serde_json::Map<String, serde_json::Value>,
+) -> std::collections::BTreeMap<String, f64> {
+    let mut totals = std::collections::BTreeMap::new();
+    let mut parts: std::collections::BTreeMap<String, f64> = std::collections::BTreeMap::new();
+    for (key, value) in values {
+        let Some(cost) = value.as_f64() else { continue };
+        if let Some(p) = key.strip_suffix("_total_cost") {
+            *totals.entry(p.to_string()).or_insert(0.0) += cost;
+        } else if let Some(p) = key
+            .strip_suffix("_committed_cost")
+            .or_else(|| key.strip_suffix("_on_demand_cost"))
+            .or_else(|| key.strip_suffix("_cost"))
+        {
+            if p != "total" {
+                *parts.entry(p.to_string()).or_insert(0.0) += cost;
+            }
+        }
+    }
+    for (p, cost) in parts {
+        totals.entry(p).or_insert(cost);
+    }
+    totals
+}
+
+fn is_untagged(tags: &serde_json::Value, key: &str) -> bool {
+    let untagged =
+        |v: &serde_json::Value| v.as_str().map_or(true, |s| UNTAGGED_VALUES.contains(&s));
+    match tags.get(key) {
+        None | Some(serde_json::Value::Null) => true,
+        Some(serde_json::Value::Array(vals)) => vals.iter().all(untagged),
+        Some(v) => untagged(v),
+    }
+}
+
+fn round2(x: f64) -> f64 {
+    (x * 100.0).round() / 100.0
+}
+
+fn pct(part: f64, whole: f64) -> f64 {
+    if whole > 0.0 {
+        round2(part * 100.0 / whole)
+    } else {
+        0.0
+    }
+}
+
+#[derive(Default)]
+struct ProductSpend {
+    total: f64,
+    noncompliant: f64,
+    missing: Vec<f64>,
+}
+
+/// Build the compliance report from monthly cost attribution rows: how much
+/// spend lacks at least one required tag, overall, per tag, and per product.
+pub fn compliance_report(rows: &[serde_json::Value], required: &[String]) -> serde_json::Value {
+    let mut products: std::collections::BTreeMap<String, ProductSpend> =
+        std::collections::BTreeMap::new();
+    for row in rows {
+        let attrs = row.get("attributes").unwrap_or(row);
+        let tags = attrs.get("tags").cloned().unwrap_or_default();
+        let missing: Vec<bool> = required.iter().map(|t| is_untagged(&tags, t)).collect();
+        let Some(values) = attrs.get("values").and_then(|v| v.as_object()) else {
+            continue;
+        };
+        for (product, cost) in product_costs(values) {
+            let spend = products.entry(product).or_insert_with(|| ProductSpend {
+                missing: vec![0.0; required.len()],
+                ..Default::default()
+            });
+            spend.total += cost;
+            if missing.iter().any(|m| *m) {
+                spend.noncompliant += cost;
+            }
+            for (i, m) in missing.iter().enumerate() {
+                if *m {
+                    spend.missing[i] += cost;
+                }
+            }
+        }
+    }
+
+    let total: f64 = products.values().map(|p| p.total).sum();
+    let noncompliant: f64 = products.values().map(|p| p.noncompliant).sum();
+    let by_tag: Vec<serde_json::Value> = required
+        .iter()
+        .enumerate()
+        .map(|(i, tag)| {
+            let missing: f64 = products.values().map(|p| p.missing[i]).sum();
+            serde_json::json!({
+                "tag": tag,
+                "missing_cost": round2(missing),
+                "missing_pct": pct(missing, total),
+            })
+        })
+        .collect();
+    let mut by_product: Vec<(&String, &ProductSpend)> =
+        products.iter().filter(|(_, p)| p.total > 0.0).collect();
+    by_product.sort_by(|a, b| b.1.noncompliant.total_cmp(&a.1.noncompliant));
+    let by_product: Vec<serde_json::Value> = by_product
+        .into_iter()
+        .map(|(product, p)| {
+            let mut row = serde_json::json!({
+                "product": product,
+                "total_cost": round2(p.total),
+                "noncompliant_cost": round2(p.noncompliant),
+                "noncompliant_pct": pct(p.noncompliant, p.total),
+            });
+            for (tag, missing) in required.iter().zip(&p.missing) {
+                row[format!("missing_{tag}")] = round2(*missing).into();
+            }
+            row
+        })
+        .collect();
+
+    serde_json::json!({
+        "required_tags": required,
+        "total_cost": round2(total),
+        "noncompliant_cost": round2(noncompliant),
+        "noncompliant_pct": pct(noncompliant, total),
+        "by_tag": by_tag,
+        "by_product": by_product,
+    })
+}
+
+/// Render `by_product` rows as CSV with a header line.
+pub fn report_csv(report: &serde_json::Value) -> String {
+    let rows = report["by_product"].as_array().cloned().unwrap_or_default();
+    let Some(first) = rows.first().and_then(|r| r.as_object()) else {
+        return String::new();
+    };
+    let headers: Vec<&String> = first.keys().collect();
+    let mut out = headers
+        .iter()
+        .map(|h| h.as_str())
+        .collect::<Vec<_>>()
+        .join(",");
+    out.push('\n');
+    for row in &rows {
+        let line: Vec<String> = headers
+            .iter()
+            .map(|h| formatter::csv_field(&row[h.as_str()]))
+            .collect();
+        out.push_str(&line.join(","));
+        out.push('\n');
+    }
+    out
+}
+
+/// Report the share of spend missing any of the required tags, by product.
+pub async fn tag_compliance(
+    cfg: &Config,
+    required_tags: &str,
+    from: String,
+    to: Option<String>,
+    csv: bool,
+) -> Result<()> {
+    let required: Vec<String> = required_tags
+        .split(',')
+        .map(|t| t.trim().to_string())
+        .filter(|t| !t.is_empty())
+        .collect();
+    if required.is_empty() {
+        anyhow::bail!("--required-tags needs at least one tag key (e.g. team,service,env)");
+    }
+    let start_month = parse_month(&from)?;
+    let end_month = to.as_deref().map(parse_month).transpose()?;
+
+    let collected = util::collect_pages(
+        util::Paging::Cursor {
+            next: "/meta/pagination/next_record_id",
+        },
+        "/data",
+        0,
+        |page| {
+            let mut query = vec![
+                ("start_month", start_month.clone()),
+                ("fields", "*".to_string()),
+                ("tag_breakdown_keys", required.join(",")),
+            ];
+            if let Some(end) = &end_month {
+                query.push(("end_month", end.clone()));
+            }
+            if let Some(id) = page.cursor {
+                query.push(("next_record_id", id));
+            }
+            async move {
+                crate::api::get(cfg, "/api/v2/cost_by_tag/monthly_cost_attribution", &query)
+                    .await
+                    .map_err(|e| crate::api::failed("failed to get cost attribution", e))
+            }
+        },
+    )
+    .await?;
+
+    let mut report = compliance_report(&collected.items, &required);
+    report["start_month"] = start_month.into();
+    if let Some(end) = end_month {
+        report["end_month"] = end.into();
+    }
+
+    if csv {
+        print!("{}", report_csv(&report));
+        return Ok(());
+    }
+    if !cfg.agent_mode && cfg.output_format == OutputFormat::Table {
+        formatter::output(cfg, &report["by_product"])?;
+        eprintln!(
+            "\n{}% of ${} spend is missing at least one of: {}",
+            report["noncompliant_pct"],
+            report["total_cost"],
+            required.join(", ")
+        );
+        for tag in report["by_tag"].as_array().into_iter().flatten() {
+            eprintln!(
+                "  {}: ${} untagged ({}%)",
+                tag["tag"].as_str().unwrap_or_default(),
+                tag["missing_cost"],
+                tag["missing_pct"]
+            );
+        }
+        return Ok(());
+    }
+    formatter::output(cfg, &report)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_month() {
+        assert_eq!(parse_month("2024-03").unwrap(), "2024-03-01T00:00:00+00:00");
+        assert_eq!(
+            parse_month("2024-03-15T10:00:00Z").unwrap(),
+            "2024-03-01T00:00:00+00:00"
+        );
+        let this_month = parse_month("0M").unwrap();
+        let last_month = parse_month("1M").unwrap();
+        assert!(last_month < this_month);
+        assert_eq!(parse_month("1mo").unwrap(), last_month);
+        assert!(parse_month("2024-13").is_err());
+    }
+
+    #[test]
+    fn test_product_costs_counts_each_dollar_once() {
+        let values = serde_json::json!({
+            "infra_host_committed_cost": 60.0,
+            "infra_host_on_demand_cost": 40.0,
+            "infra_host_total_cost": 100.0,
+            "apm_host_committed_cost": 5.0,
+            "apm_host_on_demand_cost": 2.5,
+            "logs_indexed_cost": 7.0,
+            "total_cost": 114.5,
+        });
+        let costs = product_costs(values.as_object().unwrap());
+        assert_eq!(costs["infra_host"], 100.0);
+        assert_eq!(costs["apm_host"], 7.5);
+        assert_eq!(costs["logs_indexed"], 7.0);
+        assert!(!costs.contains_key("total"));
+    }
+
+    #[test]
+    fn test_compliance_report() {
+        let rows = vec![
+            serde_json::json!({"attributes": {
+                "tags": {"team": ["core"], "env": ["prod"]},
+                "values": {"infra_host_total_cost": 75.0}
+            }}),
+            serde_json::json!({"attributes": {
+                "tags": {"team": ["<none>"], "env": ["prod"]},
+                "values": {"infra_host_total_cost": 20.0, "apm_host_total_cost": 5.0}
+            }}),
+        ];
+        let required = vec!["team".to_string(), "env".to_string()];
+        let report = compliance_report(&rows, &required);
+        assert_eq!(report["total_cost"], 100.0);
+        assert_eq!(report["noncompliant_cost"], 25.0);
+        assert_eq!(report["noncompliant_pct"], 25.0);
+        assert_eq!(report["by_tag"][0]["missing_cost"], 25.0);
+        assert_eq!(report["by_tag"][1]["missing_cost"], 0.0);
+        assert_eq!(report["by_product"][0]["product"], "infra_host");
+        assert_eq!(report["by_product"][0]["noncompliant_pct"], 21.05);
+        assert_eq!(report["by_product"][1]["missing_team"], 5.0);
+
+        let csv = report_csv(&report);
+        let mut lines = csv.lines();
+        assert_eq!(
+            lines.next().unwrap(),
+            "product,total_cost,noncompliant_cost,noncompliant_pct,missing_team,missing_env"
+        );
+        assert_eq!(lines.next().unwrap(), "infra_host,95.0,20.0,21.05,20.0,0.0");
+    }
+}
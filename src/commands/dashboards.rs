@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV1::api_dashboards::{DashboardsAPI, ListDashboardsOptionalParams};
 #[cfg(not(target_arch = "wasm32"))]
@@ -58,13 +58,52 @@ pub async fn create(cfg: &Config, file: &str) -> Result<()> {
         Some(c) => DashboardsAPI::with_client_and_config(dd_cfg, c),
         None => DashboardsAPI::with_config(dd_cfg),
     };
-    let resp = api
-        .create_dashboard(body)
-        .await
-        .map_err(|e| anyhow::anyhow!("failed to create dashboard: {e:?}"))?;
+    let started = chrono::Utc::now();
+    let (api, body) = (&api, &body);
+    let resp = client::create_with_retry(
+        "dashboard",
+        move || async move {
+            api.create_dashboard(body.clone())
+                .await
+                .map_err(|e| anyhow::anyhow!("failed to create dashboard: {e:?}"))
+        },
+        move || find_created_dashboard(api, &body.title, started),
+    )
+    .await?;
     formatter::output(cfg, &resp)
 }
 
+/// Look for a dashboard with this exact title created since `since`, i.e. by an
+/// earlier attempt of the current create.
+#[cfg(not(target_arch = "wasm32"))]
+async fn find_created_dashboard(
+    api: &DashboardsAPI,
+    title: &str,
+    since: chrono::DateTime<chrono::Utc>,
+) -> Result<Option<Dashboard>> {
+    let summary = api
+        .list_dashboards(ListDashboardsOptionalParams::default())
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to list dashboards: {e:?}"))?;
+    let summary = serde_json::to_value(summary)?;
+    let id = summary["dashboards"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .find(|d| {
+            d["title"].as_str() == Some(title) && client::created_since(d, "created_at", since)
+        })
+        .and_then(|d| d["id"].as_str());
+    let Some(id) = id else {
+        return Ok(None);
+    };
+    let dashboard = api
+        .get_dashboard(id.to_string())
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to get dashboard: {e:?}"))?;
+    Ok(Some(dashboard))
+}
+
 #[cfg(target_arch = "wasm32")]
 pub async fn create(cfg: &Config, file: &str) -> Result<()> {
     let body: serde_json::Value = util::read_json_file(file)?;
@@ -113,3 +152,1002 @@ pub async fn delete(cfg: &Config, id: &str) -> Result<()> {
     let data = crate::api::delete(cfg, &format!("/api/v1/dashboard/{id}")).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Clone
+// ---------------------------------------------------------------------------
+
+/// Server-assigned dashboard fields that must not be sent on create.
+const DASHBOARD_READ_ONLY_FIELDS: &[&str] = &[
+    "id",
+    "author_handle",
+    "author_name",
+    "created_at",
+    "modified_at",
+    "url",
+];
+
+/// Rewrites applied to a dashboard copy.
+#[derive(Debug, Default)]
+pub struct CloneOptions {
+    /// New title; defaults to "<original> (copy)".
+    pub title: Option<String>,
+    pub add_tags: Vec<String>,
+    pub remove_tags: Vec<String>,
+    /// Template variable defaults as `name=value`.
+    pub template_defaults: Vec<String>,
+    /// Named org session to create the copy in (see `pup auth login --org`).
+    pub to_org: Option<String>,
+}
+
+/// Turn a fetched dashboard into a create body with the requested rewrites.
+pub fn prepare_clone(
+    mut dash: serde_json::Value,
+    opts: &CloneOptions,
+) -> Result<serde_json::Value> {
+    let Some(obj) = dash.as_object_mut() else {
+        bail!("unexpected dashboard response: expected a JSON object");
+    };
+    for field in DASHBOARD_READ_ONLY_FIELDS {
+        obj.remove(*field);
+    }
+    // Role IDs are org-specific; keep them only when cloning within the same org.
+    if opts.to_org.is_some() {
+        obj.remove("restricted_roles");
+    }
+
+    let title = match &opts.title {
+        Some(t) => t.clone(),
+        None => format!(
+            "{} (copy)",
+            obj.get("title")
+                .and_then(|t| t.as_str())
+                .unwrap_or("Dashboard")
+        ),
+    };
+    obj.insert("title".into(), serde_json::json!(title));
+
+    if !opts.add_tags.is_empty() || !opts.remove_tags.is_empty() {
+        let mut tags: Vec<String> = obj
+            .get("tags")
+            .and_then(|t| t.as_array())
+            .map(|a| {
+                a.iter()
+                    .filter_map(|t| t.as_str().map(String::from))
+                    .collect()
+            })
+            .unwrap_or_default();
+        tags.retain(|t| !opts.remove_tags.contains(t));
+        for t in &opts.add_tags {
+            if !tags.contains(t) {
+                tags.push(t.clone());
+            }
+        }
+        obj.insert("tags".into(), serde_json::json!(tags));
+    }
+
+    for pair in &opts.template_defaults {
+        let Some((name, value)) = pair.split_once('=') else {
+            bail!("invalid --template-var {pair:?} (expected name=value)");
+        };
+        let var = obj
+            .get_mut("template_variables")
+            .and_then(|v| v.as_array_mut())
+            .and_then(|vars| {
+                vars.iter_mut()
+                    .find(|v| v.get("name").and_then(|n| n.as_str()) == Some(name))
+            });
+        let Some(var) = var.and_then(|v| v.as_object_mut()) else {
+            bail!("template variable {name:?} not found on dashboard");
+        };
+        // `default` is deprecated in favor of `defaults`; the API rejects both at once.
+        var.remove("default");
+        var.insert("defaults".into(), serde_json::json!([value]));
+    }
+
+    Ok(dash)
+}
+
+/// Copy a dashboard, optionally into another org session, rewriting title/tags/template vars.
+pub async fn clone(cfg: &Config, id: &str, opts: CloneOptions) -> Result<()> {
+    let dest = match &opts.to_org {
+        Some(org) => cfg.for_org(org)?,
+        None => cfg.clone(),
+    };
+    let source = crate::client::raw_get(cfg, &format!("/api/v1/dashboard/{id}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to get dashboard", e))?;
+    let body = prepare_clone(source, &opts)?;
+    let resp = crate::client::raw_post(&dest, "/api/v1/dashboard", body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create dashboard copy", e))?;
+    formatter::output(cfg, &resp)
+}
+
+// ---------------------------------------------------------------------------
+// Export / import
+// ---------------------------------------------------------------------------
+
+/// Normalize a dashboard for the export directory: drop server-managed fields
+/// (keeping `id` so import can update in place) and sort keys so an unchanged
+/// dashboard always produces an identical file.
+pub fn export_body(mut dash: serde_json::Value) -> serde_json::Value {
+    if let Some(obj) = dash.as_object_mut() {
+        for field in DASHBOARD_READ_ONLY_FIELDS.iter().filter(|f| **f != "id") {
+            obj.remove(*field);
+        }
+    }
+    formatter::sort_json_value(dash)
+}
+
+/// The part of a dashboard that import compares and sends: the export body without `id`.
+pub fn import_body(dash: serde_json::Value) -> serde_json::Value {
+    let mut body = export_body(dash);
+    if let Some(obj) = body.as_object_mut() {
+        obj.remove("id");
+    }
+    body
+}
+
+fn export_contents(dash: serde_json::Value) -> Result<String> {
+    Ok(format!(
+        "{}\n",
+        serde_json::to_string_pretty(&export_body(dash))?
+    ))
+}
+
+/// Pull every dashboard into `dir`, one `<slug>.json` file each, removing
+/// files an earlier export wrote for dashboards since renamed or deleted.
+pub async fn export(cfg: &Config, dir: &str) -> Result<()> {
+    let summary = crate::api::get(cfg, "/api/v1/dashboard", &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list dashboards", e))?;
+    let dashboards: Vec<(String, String)> = summary["dashboards"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|d| {
+            let id = d["id"].as_str()?.to_string();
+            Some((id, d["title"].as_str().unwrap_or_default().to_string()))
+        })
+        .collect();
+    std::fs::create_dir_all(dir)
+        .map_err(|e| anyhow::anyhow!("failed to create directory {dir:?}: {e}"))?;
+
+    let mut results = Vec::new();
+    let mut written = Vec::new();
+    for ((id, title), name) in dashboards
+        .iter()
+        .zip(util::export_file_names(&dashboards, "dashboard"))
+    {
+        let dash = crate::api::get(cfg, &format!("/api/v1/dashboard/{id}"), &[])
+            .await
+            .map_err(|e| crate::api::failed(format!("failed to get dashboard {id}"), e))?;
+        let path = std::path::Path::new(dir).join(name);
+        let contents = export_contents(dash)?;
+        let action = match std::fs::read_to_string(&path) {
+            Ok(existing) if existing == contents => "unchanged",
+            Ok(_) => "updated",
+            Err(_) => "created",
+        };
+        if action != "unchanged" {
+            std::fs::write(&path, contents)
+                .map_err(|e| anyhow::anyhow!("failed to write {}: {e}", path.display()))?;
+        }
+        results.push(serde_json::json!({
+            "id": id,
+            "title": title,
+            "file": path.display().to_string(),
+            "action": action,
+        }));
+        written.push(path);
+    }
+    // Files from renamed or deleted dashboards would otherwise be re-imported.
+    for path in util::stale_export_files(dir, &written)? {
+        let stale: serde_json::Value = util::read_json_file(&path.display().to_string())?;
+        std::fs::remove_file(&path)
+            .map_err(|e| anyhow::anyhow!("failed to remove {}: {e}", path.display()))?;
+        results.push(serde_json::json!({
+            "id": stale["id"],
+            "title": stale["title"],
+            "file": path.display().to_string(),
+            "action": "removed",
+        }));
+    }
+    formatter::output(cfg, &results)
+}
+
+/// Push the dashboards in `dir` back: files whose `id` exists are updated when
+/// they differ from the server, the rest are created and get their new `id`
+/// written back so the next import updates them in place. Two files with the
+/// same `id` are refused before anything is sent.
+pub async fn import(cfg: &Config, dir: &str, dry_run: bool) -> Result<()> {
+    let paths = util::json_files(dir)?;
+    if paths.is_empty() {
+        bail!("no dashboard .json files found in {dir:?}");
+    }
+    let mut files = Vec::new();
+    for path in &paths {
+        let file = path.display().to_string();
+        let local: serde_json::Value = util::read_json_file(&file)?;
+        if local.get("title").and_then(|t| t.as_str()).is_none() {
+            bail!("{file}: not a dashboard (missing \"title\")");
+        }
+        files.push((file, local));
+    }
+    util::check_unique_ids(&files)?;
+
+    let mut results = Vec::new();
+    for (path, (file, local)) in paths.iter().zip(files) {
+        let id = local.get("id").and_then(|i| i.as_str()).map(String::from);
+        let body = import_body(local);
+
+        let remote = match &id {
+            Some(id) => match crate::api::get(cfg, &format!("/api/v1/dashboard/{id}"), &[]).await {
+                Ok(remote) => Some(remote),
+                // Deleted upstream or exported from another org: recreate it.
+                Err(e) if e.to_string().contains("HTTP 404") => None,
+                Err(e) => bail!("failed to get dashboard {id}: {e:?}"),
+            },
+            None => None,
+        };
+
+        let action = match (&id, remote) {
+            (Some(_), Some(remote)) if import_body(remote) == body => "unchanged",
+            (Some(id), Some(_)) => {
+                if !dry_run {
+                    crate::api::put(cfg, &format!("/api/v1/dashboard/{id}"), &body)
+                        .await
+                        .map_err(|e| {
+                            crate::api::failed(format!("failed to update dashboard {id}"), e)
+                        })?;
+                }
+                "updated"
+            }
+            _ => {
+                if !dry_run {
+                    let created = crate::api::post(cfg, "/api/v1/dashboard", &body)
+                        .await
+                        .map_err(|e| {
+                            crate::api::failed(format!("failed to create dashboard from {file}"), e)
+                        })?;
+                    std::fs::write(path, export_contents(created)?)
+                        .map_err(|e| anyhow::anyhow!("failed to write {file}: {e}"))?;
+                }
+                "created"
+            }
+        };
+        results.push(serde_json::json!({
+            "file": file,
+            "id": id,
+            "title": body["title"],
+            "action": action,
+            "dry_run": dry_run,
+        }));
+    }
+    formatter::output(cfg, &results)
+}
+
+// ---------------------------------------------------------------------------
+// Widget diff
+// ---------------------------------------------------------------------------
+
+/// A widget with its place on the dashboard, flattened out of groups.
+#[derive(Debug, Clone)]
+struct FlatWidget {
+    id: Option<i64>,
+    kind: String,
+    title: String,
+    group: Option<String>,
+    /// The widget without its id (and, for groups, without their children),
+    /// so a re-created widget still compares equal.
+    body: serde_json::Value,
+}
+
+fn widget_title(widget: &serde_json::Value) -> String {
+    widget["definition"]["title"]
+        .as_str()
+        .unwrap_or_default()
+        .to_string()
+}
+
+fn flatten_widgets(widgets: &serde_json::Value, group: Option<&str>, out: &mut Vec<FlatWidget>) {
+    for widget in widgets.as_array().into_iter().flatten() {
+        let mut body = widget.clone();
+        if let Some(obj) = body.as_object_mut() {
+            obj.remove("id");
+        }
+        let children = body
+            .get_mut("definition")
+            .and_then(|d| d.as_object_mut())
+            .and_then(|d| d.remove("widgets"));
+        let title = widget_title(widget);
+        out.push(FlatWidget {
+            id: widget["id"].as_i64(),
+            kind: widget["definition"]["type"]
+                .as_str()
+                .unwrap_or("unknown")
+                .to_string(),
+            title: title.clone(),
+            group: group.map(String::from),
+            body,
+        });
+        if let Some(children) = children {
+            flatten_widgets(&children, Some(&title), out);
+        }
+    }
+}
+
+/// Paths (`definition.requests[0].q`) where two JSON values differ.
+pub fn diff_paths(a: &serde_json::Value, b: &serde_json::Value) -> Vec<String> {
+    fn walk(a: &serde_json::Value, b: &serde_json::Value, path: &str, out: &mut Vec<String>) {
+        use serde_json::Value;
+        match (a, b) {
+            (Value::Object(x), Value::Object(y)) => {
+                let mut keys: Vec<&String> = x.keys().chain(y.keys()).collect();
+                keys.sort();
+                keys.dedup();
+                for key in keys {
+                    let child = if path.is_empty() {
+                        key.clone()
+                    } else {
+                        format!("{path}.{key}")
+                    };
+                    walk(
+                        x.get(key).unwrap_or(&Value::Null),
+                        y.get(key).unwrap_or(&Value::Null),
+                        &child,
+                        out,
+                    );
+                }
+            }
+            (Value::Array(x), Value::Array(y)) if x.len() == y.len() => {
+                for (i, (l, r)) in x.iter().zip(y).enumerate() {
+                    walk(l, r, &format!("{path}[{i}]"), out);
+                }
+            }
+            _ if a != b => out.push(path.to_string()),
+            _ => {}
+        }
+    }
+    let mut out = Vec::new();
+    walk(a, b, "", &mut out);
+    out
+}
+
+fn diff_row(
+    change: &str,
+    w: &FlatWidget,
+    id: Option<i64>,
+    fields: Vec<String>,
+) -> serde_json::Value {
+    serde_json::json!({
+        "change": change,
+        "widget_id": id,
+        "type": w.kind,
+        "title": w.title,
+        "group": w.group,
+        "fields": fields,
+    })
+}
+
+/// Widget-level differences between the remote dashboard and a local
+/// definition, from the point of view of pushing the local file: `added`
+/// widgets exist only locally, `removed` only remotely, `changed` differ in
+/// content, and `moved` differ only in layout. Widgets are matched by id, then
+/// by type and title. Dashboard-level fields (title, template variables, ...)
+/// are reported as one `changed` row of type `dashboard`.
+pub fn widget_diff(
+    remote: &serde_json::Value,
+    local: &serde_json::Value,
+) -> Vec<serde_json::Value> {
+    let (remote, local) = (import_body(remote.clone()), import_body(local.clone()));
+    let mut rows = Vec::new();
+
+    let strip_widgets = |d: &serde_json::Value| {
+        let mut d = d.clone();
+        if let Some(obj) = d.as_object_mut() {
+            obj.remove("widgets");
+        }
+        d
+    };
+    let fields = diff_paths(&strip_widgets(&remote), &strip_widgets(&local));
+    if !fields.is_empty() {
+        rows.push(serde_json::json!({
+            "change": "changed",
+            "widget_id": null,
+            "type": "dashboard",
+            "title": local["title"],
+            "group": null,
+            "fields": fields,
+        }));
+    }
+
+    let (mut theirs, mut ours) = (Vec::new(), Vec::new());
+    flatten_widgets(&remote["widgets"], None, &mut theirs);
+    flatten_widgets(&local["widgets"], None, &mut ours);
+    let mut unmatched: Vec<Option<FlatWidget>> = theirs.into_iter().map(Some).collect();
+    let mut take = |pred: &dyn Fn(&FlatWidget) -> bool| {
+        unmatched
+            .iter_mut()
+            .find(|w| w.as_ref().is_some_and(pred))
+            .and_then(Option::take)
+    };
+    // Match every id first so a type/title match can't claim a widget whose id
+    // belongs to another local widget.
+    let mut pending = Vec::new();
+    for w in ours {
+        let by_id = w.id.and_then(|id| take(&|r| r.id == Some(id)));
+        pending.push((w, by_id));
+    }
+    for (w, matched) in pending {
+        let matched = matched.or_else(|| take(&|r| r.kind == w.kind && r.title == w.title));
+        match matched {
+            None => rows.push(diff_row("added", &w, w.id, vec![])),
+            Some(r) => {
+                let fields = diff_paths(&r.body, &w.body);
+                if fields.is_empty() && r.group == w.group {
+                    continue;
+                }
+                let only_layout = fields.iter().all(|f| f.starts_with("layout"));
+                let change = if only_layout { "moved" } else { "changed" };
+                rows.push(diff_row(change, &w, r.id.or(w.id), fields));
+            }
+        }
+    }
+    for r in unmatched.into_iter().flatten() {
+        rows.push(diff_row("removed", &r, r.id, vec![]));
+    }
+    rows
+}
+
+/// Compare a dashboard with a local JSON definition, widget by widget.
+pub async fn diff(cfg: &Config, id: &str, file: &str) -> Result<()> {
+    let local: serde_json::Value = util::read_json_file(file)?;
+    if local.get("title").and_then(|t| t.as_str()).is_none() {
+        bail!("{file}: not a dashboard (missing \"title\")");
+    }
+    let remote = crate::api::get(cfg, &format!("/api/v1/dashboard/{id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to get dashboard {id}"), e))?;
+    let rows = widget_diff(&remote, &local);
+    if rows.is_empty() {
+        eprintln!("No differences between dashboard {id} and {file}.");
+    }
+    formatter::output(cfg, &rows)
+}
+
+// ---------------------------------------------------------------------------
+// Dashboard lists
+// ---------------------------------------------------------------------------
+
+const LISTS_PATH: &str = "/api/v1/dashboard/lists/manual";
+
+fn list_items_path(list_id: i64) -> String {
+    format!("/api/v2/dashboard/lists/manual/{list_id}/dashboards")
+}
+
+/// The dashboard-list item type for a dashboard's `layout_type`.
+pub fn list_item_type(layout_type: &str) -> Result<&'static str> {
+    match layout_type {
+        "ordered" => Ok("custom_timeboard"),
+        "free" => Ok("custom_screenboard"),
+        other => bail!("unsupported dashboard layout {other:?} (expected ordered or free)"),
+    }
+}
+
+/// Request body for adding or removing dashboards: `(id, type)` pairs.
+pub fn list_items_body(items: &[(String, &str)]) -> serde_json::Value {
+    let dashboards: Vec<serde_json::Value> = items
+        .iter()
+        .map(|(id, kind)| serde_json::json!({ "id": id, "type": kind }))
+        .collect();
+    serde_json::json!({ "dashboards": dashboards })
+}
+
+/// Look up each dashboard so the list API gets the item type it requires.
+async fn list_items(cfg: &Config, dashboard_ids: &[String]) -> Result<Vec<(String, &'static str)>> {
+    if dashboard_ids.is_empty() {
+        bail!("no dashboards given: pass --dashboard <id> (repeatable)");
+    }
+    let mut items = Vec::new();
+    for id in dashboard_ids {
+        let dashboard = crate::api::get(cfg, &format!("/api/v1/dashboard/{id}"), &[])
+            .await
+            .map_err(|e| crate::api::failed(format!("failed to get dashboard {id}"), e))?;
+        let layout = dashboard["layout_type"].as_str().unwrap_or_default();
+        items.push((id.clone(), list_item_type(layout)?));
+    }
+    Ok(items)
+}
+
+pub async fn lists_list(cfg: &Config) -> Result<()> {
+    let resp = crate::api::get(cfg, LISTS_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list dashboard lists", e))?;
+    formatter::output(cfg, &resp)
+}
+
+/// A dashboard list with the dashboards in it under `dashboards`.
+pub async fn lists_get(cfg: &Config, list_id: i64) -> Result<()> {
+    let mut list = crate::api::get(cfg, &format!("{LISTS_PATH}/{list_id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get dashboard list", e))?;
+    let items = crate::api::get(cfg, &list_items_path(list_id), &[])
+        .await
+        .map_err(|e| {
+            crate::api::failed(format!("failed to list dashboards in list {list_id}"), e)
+        })?;
+    list["dashboards"] = items["dashboards"].clone();
+    formatter::output(cfg, &list)
+}
+
+pub async fn lists_create(cfg: &Config, name: &str) -> Result<()> {
+    let resp = crate::api::post(cfg, LISTS_PATH, &serde_json::json!({ "name": name }))
+        .await
+        .map_err(|e| crate::api::failed("failed to create dashboard list", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn lists_delete(cfg: &Config, list_id: i64) -> Result<()> {
+    crate::api::delete(cfg, &format!("{LISTS_PATH}/{list_id}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to delete dashboard list", e))?;
+    println!("Dashboard list {list_id} deleted.");
+    Ok(())
+}
+
+pub async fn lists_add_items(cfg: &Config, list_id: i64, dashboard_ids: &[String]) -> Result<()> {
+    let body = list_items_body(&list_items(cfg, dashboard_ids).await?);
+    let resp = crate::api::post(cfg, &list_items_path(list_id), &body)
+        .await
+        .map_err(|e| {
+            crate::api::failed(format!("failed to add dashboards to list {list_id}"), e)
+        })?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn lists_remove_items(
+    cfg: &Config,
+    list_id: i64,
+    dashboard_ids: &[String],
+) -> Result<()> {
+    let body = list_items_body(&list_items(cfg, dashboard_ids).await?);
+    let resp = crate::api::delete_with_body(cfg, &list_items_path(list_id), &body)
+        .await
+        .map_err(|e| {
+            crate::api::failed(
+                format!("failed to remove dashboards from list {list_id}"),
+                e,
+            )
+        })?;
+    formatter::output(cfg, &resp)
+}
+
+// ---------------------------------------------------------------------------
+// Shared dashboards
+// ---------------------------------------------------------------------------
+
+const SHARES_PATH: &str = "/api/v1/dashboard/public";
+
+/// Inputs for `pup dashboards shares create`.
+#[derive(Debug, Default)]
+pub struct ShareOptions {
+    /// open (anyone with the link), invite (viewers sign in with an invited
+    /// email), or embed.
+    pub share_type: String,
+    /// Emails allowed to view an invite share.
+    pub invitees: Vec<String>,
+    /// When the link stops working (`30d` from now, or RFC3339).
+    pub expires: Option<String>,
+    /// Default time frame for viewers, e.g. `1h`.
+    pub live_span: Option<String>,
+    /// Let viewers change the time frame.
+    pub selectable_time: bool,
+}
+
+/// Expiration timestamp for `--expires`: a duration from now or an absolute time.
+pub fn share_expiration(input: &str, now: chrono::DateTime<chrono::Utc>) -> Result<String> {
+    let at = match util::parse_duration_secs(input) {
+        Ok(secs) => now + chrono::Duration::seconds(secs),
+        Err(_) => chrono::DateTime::parse_from_rfc3339(input)
+            .map_err(|_| {
+                anyhow::anyhow!(
+                    "invalid --expires {input:?}: expected a duration like 30d or RFC3339"
+                )
+            })?
+            .with_timezone(&chrono::Utc),
+    };
+    if at <= now {
+        bail!("--expires {input:?} is in the past");
+    }
+    Ok(at.to_rfc3339_opts(chrono::SecondsFormat::Secs, true))
+}
+
+/// Request body for a new shared dashboard.
+pub fn share_body(
+    dashboard_id: &str,
+    dashboard_type: &str,
+    opts: &ShareOptions,
+    expiration: Option<&str>,
+) -> Result<serde_json::Value> {
+    let share_type = opts.share_type.to_lowercase();
+    if !matches!(share_type.as_str(), "open" | "invite" | "embed") {
+        bail!(
+            "invalid share type {:?} (expected open, invite, or embed)",
+            opts.share_type
+        );
+    }
+    if share_type == "invite" && opts.invitees.is_empty() {
+        bail!("invite shares need at least one --invite <email>");
+    }
+    if share_type != "invite" && !opts.invitees.is_empty() {
+        bail!("--invite only applies to --type invite");
+    }
+    let mut body = serde_json::json!({
+        "dashboard_id": dashboard_id,
+        "dashboard_type": dashboard_type,
+        "share_type": share_type,
+        "global_time_selectable_enabled": opts.selectable_time,
+    });
+    if let Some(span) = &opts.live_span {
+        body["global_time"] = serde_json::json!({ "live_span": span });
+    }
+    if let Some(expiration) = expiration {
+        body["expiration"] = expiration.into();
+    }
+    if !opts.invitees.is_empty() {
+        body["share_list"] = opts.invitees.clone().into();
+        body["invitees"] = opts
+            .invitees
+            .iter()
+            .map(|email| serde_json::json!({ "email": email, "access_expiration": expiration }))
+            .collect();
+    }
+    Ok(body)
+}
+
+/// The shares in a list response that belong to `dashboard_id`. The list is
+/// a bare array of SharedDashboard objects, the shape `shares create` gets
+/// back for one share.
+pub fn shares_for(resp: &serde_json::Value, dashboard_id: &str) -> Result<Vec<serde_json::Value>> {
+    let Some(items) = resp.as_array() else {
+        bail!("unexpected shared dashboards response: expected an array of shared dashboards");
+    };
+    Ok(items
+        .iter()
+        .filter(|s| s["dashboard_id"].as_str() == Some(dashboard_id))
+        .cloned()
+        .collect())
+}
+
+/// One audit row per share: who can see it, how, and until when.
+pub fn share_row(share: &serde_json::Value) -> serde_json::Value {
+    serde_json::json!({
+        "token": share["token"],
+        "share_type": share["share_type"],
+        "status": share["status"],
+        "public_url": share["public_url"],
+        "expiration": share["expiration"],
+        "share_list": share["share_list"],
+        "author": share["author"]["handle"],
+        "created": share["created"],
+    })
+}
+
+async fn dashboard_shares(cfg: &Config, dashboard_id: &str) -> Result<Vec<serde_json::Value>> {
+    let resp = crate::api::get(cfg, SHARES_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list shared dashboards", e))?;
+    shares_for(&resp, dashboard_id)
+}
+
+pub async fn shares_list(cfg: &Config, dashboard_id: &str) -> Result<()> {
+    let rows: Vec<serde_json::Value> = dashboard_shares(cfg, dashboard_id)
+        .await?
+        .iter()
+        .map(share_row)
+        .collect();
+    if rows.is_empty() {
+        eprintln!("Dashboard {dashboard_id} has no shared links.");
+    }
+    formatter::output(cfg, &rows)
+}
+
+pub async fn shares_create(cfg: &Config, dashboard_id: &str, opts: &ShareOptions) -> Result<()> {
+    let expiration = opts
+        .expires
+        .as_deref()
+        .map(|e| share_expiration(e, chrono::Utc::now()))
+        .transpose()?;
+    let dashboard = crate::api::get(cfg, &format!("/api/v1/dashboard/{dashboard_id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to get dashboard {dashboard_id}"), e))?;
+    let dashboard_type = list_item_type(dashboard["layout_type"].as_str().unwrap_or_default())?;
+    let body = share_body(dashboard_id, dashboard_type, opts, expiration.as_deref())?;
+    let resp = crate::api::post(cfg, SHARES_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to share dashboard", e))?;
+    formatter::output(cfg, &share_row(&resp))
+}
+
+/// Tokens to revoke: `token` if it belongs to the dashboard, else all of its shares.
+pub async fn shares_to_revoke(
+    cfg: &Config,
+    dashboard_id: &str,
+    token: Option<&str>,
+) -> Result<Vec<String>> {
+    let tokens: Vec<String> = dashboard_shares(cfg, dashboard_id)
+        .await?
+        .iter()
+        .filter_map(|s| s["token"].as_str().map(String::from))
+        .collect();
+    match token {
+        Some(t) if tokens.iter().any(|k| k == t) => Ok(vec![t.to_string()]),
+        Some(t) => bail!("share {t} is not a shared link of dashboard {dashboard_id}"),
+        None => Ok(tokens),
+    }
+}
+
+pub async fn shares_revoke(cfg: &Config, tokens: &[String]) -> Result<()> {
+    for token in tokens {
+        crate::api::delete(cfg, &format!("{SHARES_PATH}/{token}"))
+            .await
+            .map_err(|e| {
+                crate::api::failed(format!("failed to revoke shared dashboard {token}"), e)
+            })?;
+        println!("Shared dashboard {token} revoked.");
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_share_body() {
+        let opts = ShareOptions {
+            share_type: "invite".into(),
+            invitees: vec!["a@example.com".into()],
+            live_span: Some("1h".into()),
+            ..Default::default()
+        };
+        let body = share_body(
+            "abc-def-ghi",
+            "custom_timeboard",
+            &opts,
+            Some("2026-02-01T00:00:00Z"),
+        )
+        .unwrap();
+        assert_eq!(body["share_type"], "invite");
+        assert_eq!(body["global_time"]["live_span"], "1h");
+        assert_eq!(body["expiration"], "2026-02-01T00:00:00Z");
+        assert_eq!(body["invitees"][0]["email"], "a@example.com");
+        assert_eq!(
+            body["invitees"][0]["access_expiration"],
+            "2026-02-01T00:00:00Z"
+        );
+
+        let open = ShareOptions {
+            share_type: "open".into(),
+            ..Default::default()
+        };
+        let body = share_body("abc-def-ghi", "custom_timeboard", &open, None).unwrap();
+        assert!(body.get("expiration").is_none());
+        assert!(body.get("share_list").is_none());
+
+        let no_invitees = ShareOptions {
+            share_type: "invite".into(),
+            ..Default::default()
+        };
+        assert!(share_body("x", "custom_timeboard", &no_invitees, None).is_err());
+        let bad = ShareOptions {
+            share_type: "public".into(),
+            ..Default::default()
+        };
+        assert!(share_body("x", "custom_timeboard", &bad, None).is_err());
+    }
+
+    #[test]
+    fn test_share_expiration() {
+        let now = chrono::DateTime::parse_from_rfc3339("2026-01-01T00:00:00Z")
+            .unwrap()
+            .with_timezone(&chrono::Utc);
+        assert_eq!(
+            share_expiration("30d", now).unwrap(),
+            "2026-01-31T00:00:00Z"
+        );
+        assert_eq!(
+            share_expiration("2026-03-01T12:00:00Z", now).unwrap(),
+            "2026-03-01T12:00:00Z"
+        );
+        assert!(share_expiration("2025-01-01T00:00:00Z", now).is_err());
+        assert!(share_expiration("soon", now).is_err());
+    }
+
+    #[test]
+    fn test_shares_for() {
+        let resp = serde_json::json!([
+            {"token": "t1", "dashboard_id": "abc", "share_type": "open"},
+            {"token": "t2", "dashboard_id": "xyz", "share_type": "invite"}
+        ]);
+        let shares = shares_for(&resp, "abc").unwrap();
+        assert_eq!(shares.len(), 1);
+        assert_eq!(share_row(&shares[0])["token"], "t1");
+        let wrapped =
+            serde_json::json!({"data": [{"attributes": {"token": "t3", "dashboard_id": "abc"}}]});
+        assert!(shares_for(&wrapped, "abc").is_err());
+    }
+
+    #[test]
+    fn test_list_items_body() {
+        assert_eq!(list_item_type("ordered").unwrap(), "custom_timeboard");
+        assert_eq!(list_item_type("free").unwrap(), "custom_screenboard");
+        assert!(list_item_type("").is_err());
+        let body = list_items_body(&[("abc-def-ghi".into(), "custom_timeboard")]);
+        assert_eq!(
+            body,
+            serde_json::json!({"dashboards": [{"id": "abc-def-ghi", "type": "custom_timeboard"}]})
+        );
+    }
+
+    fn dashboard() -> serde_json::Value {
+        serde_json::json!({
+            "id": "abc-def-ghi",
+            "title": "Golden Service",
+            "author_handle": "a@example.com",
+            "created_at": "2024-01-01T00:00:00Z",
+            "url": "/dashboard/abc-def-ghi",
+            "layout_type": "ordered",
+            "tags": ["team:platform"],
+            "restricted_roles": ["r1"],
+            "template_variables": [
+                {"name": "service", "prefix": "service", "default": "*"},
+                {"name": "env", "prefix": "env", "defaults": ["staging"]}
+            ],
+            "widgets": []
+        })
+    }
+
+    #[test]
+    fn test_prepare_clone_defaults() {
+        let body = prepare_clone(dashboard(), &CloneOptions::default()).unwrap();
+        assert_eq!(body["title"], "Golden Service (copy)");
+        assert!(body.get("id").is_none());
+        assert!(body.get("author_handle").is_none());
+        assert!(body.get("url").is_none());
+        assert_eq!(body["restricted_roles"], serde_json::json!(["r1"]));
+        assert_eq!(body["tags"], serde_json::json!(["team:platform"]));
+        assert_eq!(body["layout_type"], "ordered");
+    }
+
+    #[test]
+    fn test_prepare_clone_rewrites() {
+        let opts = CloneOptions {
+            title: Some("Payments Service".into()),
+            add_tags: vec!["team:payments".into()],
+            remove_tags: vec!["team:platform".into()],
+            template_defaults: vec!["service=payments-api".into(), "env=prod".into()],
+            to_org: Some("child".into()),
+        };
+        let body = prepare_clone(dashboard(), &opts).unwrap();
+        assert_eq!(body["title"], "Payments Service");
+        assert_eq!(body["tags"], serde_json::json!(["team:payments"]));
+        assert!(body.get("restricted_roles").is_none());
+        let vars = &body["template_variables"];
+        assert_eq!(vars[0]["defaults"], serde_json::json!(["payments-api"]));
+        assert!(vars[0].get("default").is_none());
+        assert_eq!(vars[1]["defaults"], serde_json::json!(["prod"]));
+    }
+
+    #[test]
+    fn test_export_and_import_body() {
+        let exported = export_body(dashboard());
+        assert_eq!(exported["id"], "abc-def-ghi");
+        assert!(exported.get("author_handle").is_none());
+        assert!(exported.get("created_at").is_none());
+        assert!(exported.get("url").is_none());
+        let keys: Vec<&String> = exported.as_object().unwrap().keys().collect();
+        let mut sorted = keys.clone();
+        sorted.sort();
+        assert_eq!(keys, sorted);
+
+        // A re-fetched dashboard with new server metadata compares equal.
+        let mut refetched = dashboard();
+        refetched["modified_at"] = serde_json::json!("2025-06-01T00:00:00Z");
+        let body = import_body(exported);
+        assert!(body.get("id").is_none());
+        assert_eq!(import_body(refetched.clone()), body);
+        refetched["title"] = serde_json::json!("Renamed");
+        assert_ne!(import_body(refetched), body);
+    }
+
+    #[test]
+    fn test_prepare_clone_bad_template_var() {
+        let opts = CloneOptions {
+            template_defaults: vec!["missing=x".into()],
+            ..Default::default()
+        };
+        assert!(prepare_clone(dashboard(), &opts).is_err());
+        let opts = CloneOptions {
+            template_defaults: vec!["service".into()],
+            ..Default::default()
+        };
+        assert!(prepare_clone(dashboard(), &opts).is_err());
+    }
+
+    #[test]
+    fn test_diff_paths() {
+        let a = serde_json::json!({"q": "a", "list": [1, 2], "x": {"y": 1}});
+        let b = serde_json::json!({"q": "b", "list": [1, 3], "x": {"z": 1}});
+        assert_eq!(diff_paths(&a, &b), vec!["list[1]", "q", "x.y", "x.z"]);
+        let c = serde_json::json!({"list": [1]});
+        assert_eq!(
+            diff_paths(&serde_json::json!({"list": [1, 2]}), &c),
+            vec!["list"]
+        );
+        assert!(diff_paths(&a, &a).is_empty());
+    }
+
+    #[test]
+    fn test_widget_diff() {
+        let widget = |id: i64, kind: &str, title: &str, q: &str, x: i64| {
+            serde_json::json!({
+                "id": id,
+                "definition": {"type": kind, "title": title, "requests": [{"q": q}]},
+                "layout": {"x": x, "y": 0, "width": 4, "height": 2}
+            })
+        };
+        let mut remote = dashboard();
+        remote["widgets"] = serde_json::json!([
+            widget(1, "timeseries", "CPU", "avg:cpu{*}", 0),
+            widget(2, "timeseries", "Memory", "avg:mem{*}", 4),
+            widget(3, "query_value", "Errors", "sum:errors{*}", 8),
+            {"id": 4, "definition": {"type": "group", "title": "Disk", "widgets": [
+                widget(5, "timeseries", "IO", "avg:io{*}", 0)
+            ]}}
+        ]);
+        let mut local = remote.clone();
+        local["title"] = "Golden Service v2".into();
+        local["created_at"] = "ignored".into();
+        local["widgets"] = serde_json::json!([
+            widget(1, "timeseries", "CPU", "max:cpu{*}", 0),
+            widget(2, "timeseries", "Memory", "avg:mem{*}", 6),
+            {"definition": {"type": "note", "title": "", "content": "hi"}},
+            {"id": 4, "definition": {"type": "group", "title": "Disk", "widgets": [
+                // Re-created without its id: matched by type and title.
+                {"definition": {"type": "timeseries", "title": "IO", "requests": [{"q": "avg:io{*}"}]},
+                 "layout": {"x": 0, "y": 0, "width": 4, "height": 2}}
+            ]}}
+        ]);
+
+        let rows = widget_diff(&remote, &local);
+        let summary: Vec<(String, String)> = rows
+            .iter()
+            .map(|r| {
+                (
+                    r["change"].as_str().unwrap().to_string(),
+                    r["title"].as_str().unwrap().to_string(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            summary,
+            vec![
+                ("changed".into(), "Golden Service v2".into()),
+                ("changed".into(), "CPU".into()),
+                ("moved".into(), "Memory".into()),
+                ("added".into(), "".into()),
+                ("removed".into(), "Errors".into()),
+            ]
+        );
+        assert_eq!(rows[0]["fields"], serde_json::json!(["title"]));
+        assert_eq!(
+            rows[1]["fields"],
+            serde_json::json!(["definition.requests[0].q"])
+        );
+        assert_eq!(rows[2]["fields"], serde_json::json!(["layout.x"]));
+        assert!(widget_diff(&remote, &remote).is_empty());
+    }
+}
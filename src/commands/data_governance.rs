@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV2::api_sensitive_data_scanner::SensitiveDataScannerAPI;
 
@@ -26,3 +26,313 @@ pub async fn scanner_rules_list(cfg: &Config) -> Result<()> {
     let data = crate::api::get(cfg, "/api/v2/sensitive-data-scanner/config", &[]).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Scanning groups, rules, and standard patterns
+// ---------------------------------------------------------------------------
+
+// Every write to the scanner config must carry the config's current
+// `meta.version`; a stale version is rejected, so each command reads the
+// config first and sends the version it saw.
+
+const CONFIG_PATH: &str = "/api/v2/sensitive-data-scanner/config";
+const CONFIG_TYPE: &str = "sensitive_data_scanner_configuration";
+const GROUP_TYPE: &str = "sensitive_data_scanner_group";
+const RULE_TYPE: &str = "sensitive_data_scanner_rule";
+const STANDARD_PATTERN_TYPE: &str = "sensitive_data_scanner_standard_pattern";
+
+/// The scanner config as of this read: its id, version, and group order.
+#[derive(Debug)]
+pub struct ScannerConfig {
+    pub id: String,
+    pub version: i64,
+    pub group_ids: Vec<String>,
+    pub raw: serde_json::Value,
+}
+
+pub fn parse_scanner_config(resp: serde_json::Value) -> Result<ScannerConfig> {
+    let Some(id) = resp["data"]["id"].as_str().map(String::from) else {
+        bail!("sensitive data scanner config response has no id");
+    };
+    let group_ids = resp["data"]["relationships"]["groups"]["data"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|g| g["id"].as_str().map(String::from))
+        .collect();
+    Ok(ScannerConfig {
+        id,
+        version: resp["meta"]["version"].as_i64().unwrap_or(0),
+        group_ids,
+        raw: resp,
+    })
+}
+
+async fn scanner_config(cfg: &Config) -> Result<ScannerConfig> {
+    let resp = crate::api::get(cfg, CONFIG_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get sensitive data scanner config", e))?;
+    parse_scanner_config(resp)
+}
+
+/// One row per group, in scanning order.
+pub fn group_rows(config: &ScannerConfig) -> Vec<serde_json::Value> {
+    let included = config.raw["included"]
+        .as_array()
+        .map(Vec::as_slice)
+        .unwrap_or(&[]);
+    config
+        .group_ids
+        .iter()
+        .enumerate()
+        .map(|(i, id)| {
+            let group = included
+                .iter()
+                .find(|g| g["type"] == GROUP_TYPE && g["id"] == id.as_str())
+                .cloned()
+                .unwrap_or_default();
+            let attrs = &group["attributes"];
+            serde_json::json!({
+                "position": i + 1,
+                "id": id,
+                "name": attrs["name"],
+                "enabled": attrs["is_enabled"],
+                "filter": attrs["filter"]["query"],
+                "products": attrs["product_list"],
+                "rules": group["relationships"]["rules"]["data"]
+                    .as_array()
+                    .map_or(0, Vec::len),
+            })
+        })
+        .collect()
+}
+
+/// New group order: `requested` first, in that order, then the remaining
+/// groups in their current relative order.
+pub fn reorder_groups(current: &[String], requested: &[String]) -> Result<Vec<String>> {
+    let mut order = Vec::with_capacity(current.len());
+    for id in requested {
+        if !current.contains(id) {
+            bail!("scanning group {id:?} not found");
+        }
+        if order.contains(id) {
+            bail!("scanning group {id:?} listed more than once");
+        }
+        order.push(id.clone());
+    }
+    order.extend(current.iter().filter(|id| !requested.contains(id)).cloned());
+    Ok(order)
+}
+
+/// Attributes from a `--file`: a bare attributes object, or a request whose
+/// `data.attributes` is used.
+pub fn file_attributes(file: serde_json::Value) -> Result<serde_json::Value> {
+    let attrs = match file.pointer("/data/attributes") {
+        Some(a) => a.clone(),
+        None => file,
+    };
+    if !attrs.is_object() {
+        bail!("invalid --file: expected a JSON object of attributes");
+    }
+    Ok(attrs)
+}
+
+pub async fn groups_list(cfg: &Config) -> Result<()> {
+    let config = scanner_config(cfg).await?;
+    formatter::output(cfg, &group_rows(&config))
+}
+
+pub async fn groups_create(cfg: &Config, file: &str) -> Result<()> {
+    let attrs = file_attributes(crate::util::read_json_file(file)?)?;
+    let config = scanner_config(cfg).await?;
+    let body = serde_json::json!({
+        "data": {
+            "type": GROUP_TYPE,
+            "attributes": attrs,
+            "relationships": {
+                "configuration": { "data": { "type": CONFIG_TYPE, "id": config.id } },
+                "rules": { "data": [] }
+            }
+        },
+        "meta": { "version": config.version }
+    });
+    let resp = crate::api::post(cfg, &format!("{CONFIG_PATH}/groups"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create scanning group", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn groups_update(cfg: &Config, group_id: &str, file: &str) -> Result<()> {
+    let attrs = file_attributes(crate::util::read_json_file(file)?)?;
+    let config = scanner_config(cfg).await?;
+    let body = serde_json::json!({
+        "data": { "id": group_id, "type": GROUP_TYPE, "attributes": attrs },
+        "meta": { "version": config.version }
+    });
+    let resp = crate::api::patch(cfg, &format!("{CONFIG_PATH}/groups/{group_id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update scanning group", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn groups_delete(cfg: &Config, group_id: &str) -> Result<()> {
+    let config = scanner_config(cfg).await?;
+    let body = serde_json::json!({ "meta": { "version": config.version } });
+    crate::api::delete_with_body(cfg, &format!("{CONFIG_PATH}/groups/{group_id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to delete scanning group", e))?;
+    println!("Scanning group {group_id} deleted.");
+    Ok(())
+}
+
+pub async fn groups_reorder(cfg: &Config, group_ids: &[String]) -> Result<()> {
+    let config = scanner_config(cfg).await?;
+    let order = reorder_groups(&config.group_ids, group_ids)?;
+    let body = serde_json::json!({
+        "data": {
+            "id": config.id,
+            "type": CONFIG_TYPE,
+            "relationships": {
+                "groups": {
+                    "data": order
+                        .iter()
+                        .map(|id| serde_json::json!({ "type": GROUP_TYPE, "id": id }))
+                        .collect::<Vec<_>>()
+                }
+            }
+        },
+        "meta": { "version": config.version }
+    });
+    crate::api::patch(cfg, CONFIG_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to reorder scanning groups", e))?;
+    groups_list(cfg).await
+}
+
+pub async fn rules_create(
+    cfg: &Config,
+    group_id: &str,
+    standard_pattern: Option<&str>,
+    file: &str,
+) -> Result<()> {
+    let attrs = file_attributes(crate::util::read_json_file(file)?)?;
+    let config = scanner_config(cfg).await?;
+    let mut relationships = serde_json::json!({
+        "group": { "data": { "type": GROUP_TYPE, "id": group_id } }
+    });
+    if let Some(pattern) = standard_pattern {
+        relationships["standard_pattern"] =
+            serde_json::json!({ "data": { "type": STANDARD_PATTERN_TYPE, "id": pattern } });
+    }
+    let body = serde_json::json!({
+        "data": { "type": RULE_TYPE, "attributes": attrs, "relationships": relationships },
+        "meta": { "version": config.version }
+    });
+    let resp = crate::api::post(cfg, &format!("{CONFIG_PATH}/rules"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create scanning rule", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn rules_update(cfg: &Config, rule_id: &str, file: &str) -> Result<()> {
+    let attrs = file_attributes(crate::util::read_json_file(file)?)?;
+    let config = scanner_config(cfg).await?;
+    let body = serde_json::json!({
+        "data": { "id": rule_id, "type": RULE_TYPE, "attributes": attrs },
+        "meta": { "version": config.version }
+    });
+    let resp = crate::api::patch(cfg, &format!("{CONFIG_PATH}/rules/{rule_id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update scanning rule", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn rules_delete(cfg: &Config, rule_id: &str) -> Result<()> {
+    let config = scanner_config(cfg).await?;
+    let body = serde_json::json!({ "meta": { "version": config.version } });
+    crate::api::delete_with_body(cfg, &format!("{CONFIG_PATH}/rules/{rule_id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to delete scanning rule", e))?;
+    println!("Scanning rule {rule_id} deleted.");
+    Ok(())
+}
+
+pub async fn standard_patterns_list(cfg: &Config) -> Result<()> {
+    let resp = crate::api::get(cfg, &format!("{CONFIG_PATH}/standard-patterns"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list standard patterns", e))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn config() -> ScannerConfig {
+        parse_scanner_config(serde_json::json!({
+            "data": {
+                "id": "cfg-1",
+                "type": "sensitive_data_scanner_configuration",
+                "relationships": {"groups": {"data": [
+                    {"id": "g2", "type": "sensitive_data_scanner_group"},
+                    {"id": "g1", "type": "sensitive_data_scanner_group"}
+                ]}}
+            },
+            "meta": {"version": 12},
+            "included": [
+                {"id": "g1", "type": "sensitive_data_scanner_group",
+                 "attributes": {"name": "PII", "is_enabled": true, "filter": {"query": "*"}, "product_list": ["logs"]},
+                 "relationships": {"rules": {"data": [{"id": "r1"}, {"id": "r2"}]}}},
+                {"id": "g2", "type": "sensitive_data_scanner_group",
+                 "attributes": {"name": "Secrets", "is_enabled": false}},
+                {"id": "r1", "type": "sensitive_data_scanner_rule", "attributes": {"name": "email"}}
+            ]
+        }))
+        .unwrap()
+    }
+
+    #[test]
+    fn test_parse_scanner_config() {
+        let c = config();
+        assert_eq!(c.id, "cfg-1");
+        assert_eq!(c.version, 12);
+        assert_eq!(c.group_ids, vec!["g2", "g1"]);
+        assert!(parse_scanner_config(serde_json::json!({"data": {}})).is_err());
+    }
+
+    #[test]
+    fn test_group_rows_follow_scanning_order() {
+        let rows = group_rows(&config());
+        assert_eq!(rows.len(), 2);
+        assert_eq!(rows[0]["id"], "g2");
+        assert_eq!(rows[0]["rules"], 0);
+        assert_eq!(rows[1]["position"], 2);
+        assert_eq!(rows[1]["name"], "PII");
+        assert_eq!(rows[1]["filter"], "*");
+        assert_eq!(rows[1]["rules"], 2);
+    }
+
+    #[test]
+    fn test_reorder_groups() {
+        let ids = |v: &[&str]| v.iter().map(|s| s.to_string()).collect::<Vec<_>>();
+        let current = ids(&["a", "b", "c", "d"]);
+        assert_eq!(
+            reorder_groups(&current, &ids(&["c", "a"])).unwrap(),
+            ids(&["c", "a", "b", "d"])
+        );
+        assert_eq!(reorder_groups(&current, &[]).unwrap(), current);
+        assert!(reorder_groups(&current, &ids(&["x"])).is_err());
+        assert!(reorder_groups(&current, &ids(&["a", "a"])).is_err());
+    }
+
+    #[test]
+    fn test_file_attributes() {
+        let attrs = serde_json::json!({"name": "PII"});
+        assert_eq!(file_attributes(attrs.clone()).unwrap(), attrs);
+        assert_eq!(
+            file_attributes(serde_json::json!({"data": {"attributes": attrs.clone()}})).unwrap(),
+            attrs
+        );
+        assert!(file_attributes(serde_json::json!("PII")).is_err());
+    }
+}
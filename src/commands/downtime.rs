@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV2::api_downtimes::{
     DowntimesAPI, GetDowntimeOptionalParams, ListDowntimesOptionalParams,
@@ -6,8 +6,11 @@ use datadog_api_client::datadogV2::api_downtimes::{
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
+use serde::{Deserialize, Serialize};
+
 use crate::config::Config;
-use crate::formatter;
+use crate::formatter::{self, Metadata};
+use crate::util;
 
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn list(cfg: &Config) -> Result<()> {
@@ -92,3 +95,483 @@ pub async fn cancel(cfg: &Config, id: &str) -> Result<()> {
     println!("Downtime {id} cancelled.");
     Ok(())
 }
+
+// ---------------------------------------------------------------------------
+// Declarative mute windows
+// ---------------------------------------------------------------------------
+
+const DOWNTIME_PAGE_SIZE: usize = 100;
+
+/// Marker appended to the message of every downtime `apply` manages; it's how
+/// a window in the file is matched to its downtime and how removals are found.
+const MANAGED_MARKER_PREFIX: &str = "[pup:mute-window=";
+
+/// A `mute-windows.yaml` file; `pup downtime apply --help` documents the format.
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields)]
+pub struct MuteWindowsFile {
+    #[serde(default)]
+    pub downtimes: Vec<MuteWindow>,
+}
+
+#[derive(Debug, Clone, Deserialize)]
+#[serde(deny_unknown_fields)]
+pub struct MuteWindow {
+    pub name: String,
+    pub scope: String,
+    #[serde(default)]
+    pub monitor_id: Option<i64>,
+    #[serde(default)]
+    pub monitor_tags: Vec<String>,
+    #[serde(default = "default_timezone")]
+    pub timezone: String,
+    pub recurrences: Vec<Recurrence>,
+    #[serde(default)]
+    pub message: Option<String>,
+    #[serde(default)]
+    pub mute_first_recovery_notification: bool,
+}
+
+#[derive(Debug, Clone, Deserialize)]
+#[serde(deny_unknown_fields)]
+pub struct Recurrence {
+    pub rrule: String,
+    /// Local time in the window's timezone, e.g. `2026-01-04T02:00`; defaults to now.
+    #[serde(default)]
+    pub start: Option<String>,
+    pub duration: String,
+}
+
+fn default_timezone() -> String {
+    "UTC".to_string()
+}
+
+pub fn parse_mute_windows(contents: &str) -> Result<Vec<MuteWindow>> {
+    let file: MuteWindowsFile = serde_yaml::from_str(contents)
+        .map_err(|e| anyhow::anyhow!("invalid mute windows file: {e}"))?;
+    let mut seen = std::collections::HashSet::new();
+    for w in &file.downtimes {
+        if w.name.trim().is_empty() || w.name.contains(']') {
+            bail!(
+                "invalid downtime name {:?}: must be non-empty and contain no ']'",
+                w.name
+            );
+        }
+        if !seen.insert(w.name.as_str()) {
+            bail!("duplicate downtime name {:?}", w.name);
+        }
+        if w.scope.trim().is_empty() {
+            bail!("downtime {:?}: scope is required", w.name);
+        }
+        if w.monitor_id.is_some() && !w.monitor_tags.is_empty() {
+            bail!(
+                "downtime {:?}: set monitor_id or monitor_tags, not both",
+                w.name
+            );
+        }
+        if w.recurrences.is_empty() {
+            bail!("downtime {:?}: at least one recurrence is required", w.name);
+        }
+        for r in &w.recurrences {
+            if !r.rrule.trim().to_uppercase().starts_with("FREQ=") {
+                bail!(
+                    "downtime {:?}: rrule {:?} must start with FREQ=",
+                    w.name,
+                    r.rrule
+                );
+            }
+            util::parse_duration_secs(&r.duration)
+                .map_err(|e| anyhow::anyhow!("downtime {:?}: {e}", w.name))?;
+        }
+    }
+    Ok(file.downtimes)
+}
+
+fn marker(name: &str) -> String {
+    format!("{MANAGED_MARKER_PREFIX}{name}]")
+}
+
+/// The mute window name recorded in a downtime's message, if `apply` manages it.
+pub fn managed_name(downtime: &serde_json::Value) -> Option<String> {
+    let message = downtime["attributes"]["message"].as_str()?;
+    let start = message.rfind(MANAGED_MARKER_PREFIX)? + MANAGED_MARKER_PREFIX.len();
+    let end = message[start..].find(']')?;
+    Some(message[start..start + end].to_string())
+}
+
+/// `2026-01-04T02:00:00` and `2026-01-04T02:00` are the same start.
+fn normalize_start(start: &str) -> String {
+    start
+        .strip_suffix(":00")
+        .filter(|s| s.len() == 16)
+        .unwrap_or(start)
+        .to_string()
+}
+
+/// v2 downtime attributes for a window.
+pub fn window_attributes(w: &MuteWindow) -> serde_json::Value {
+    let monitor_identifier = match w.monitor_id {
+        Some(id) => serde_json::json!({ "monitor_id": id }),
+        None if w.monitor_tags.is_empty() => serde_json::json!({ "monitor_tags": ["*"] }),
+        None => serde_json::json!({ "monitor_tags": w.monitor_tags }),
+    };
+    let recurrences: Vec<serde_json::Value> = w
+        .recurrences
+        .iter()
+        .map(|r| {
+            let mut rec =
+                serde_json::json!({ "rrule": r.rrule.trim(), "duration": r.duration.trim() });
+            if let Some(start) = &r.start {
+                rec["start"] = normalize_start(start.trim()).into();
+            }
+            rec
+        })
+        .collect();
+    let message = match w
+        .message
+        .as_deref()
+        .map(str::trim)
+        .filter(|m| !m.is_empty())
+    {
+        Some(m) => format!("{m}\n\n{}", marker(&w.name)),
+        None => marker(&w.name),
+    };
+    serde_json::json!({
+        "scope": w.scope.trim(),
+        "monitor_identifier": monitor_identifier,
+        "schedule": { "timezone": w.timezone, "recurrences": recurrences },
+        "message": message,
+        "mute_first_recovery_notification": w.mute_first_recovery_notification,
+    })
+}
+
+/// The fields `apply` owns, in the shape of `window_attributes`. Recurrence
+/// starts are only compared when the file pins one; otherwise the API picks it.
+fn managed_view(attrs: &serde_json::Value, pinned_starts: &[bool]) -> serde_json::Value {
+    let recurrences: Vec<serde_json::Value> = attrs["schedule"]["recurrences"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .enumerate()
+        .map(|(i, r)| {
+            let mut rec = serde_json::json!({ "rrule": r["rrule"], "duration": r["duration"] });
+            if pinned_starts.get(i).copied().unwrap_or(false) {
+                rec["start"] = r["start"]
+                    .as_str()
+                    .map(normalize_start)
+                    .map(Into::into)
+                    .unwrap_or_default();
+            }
+            rec
+        })
+        .collect();
+    serde_json::json!({
+        "scope": attrs["scope"],
+        "monitor_identifier": attrs["monitor_identifier"],
+        "timezone": attrs["schedule"]["timezone"],
+        "recurrences": recurrences,
+        "message": attrs["message"],
+        "mute_first_recovery_notification": attrs["mute_first_recovery_notification"].as_bool().unwrap_or(false),
+    })
+}
+
+#[derive(Debug, Clone, Serialize)]
+pub struct DowntimeChange {
+    pub name: String,
+    pub id: Option<String>,
+    /// create, update, delete, or unchanged.
+    pub action: &'static str,
+    #[serde(skip)]
+    pub attributes: Option<serde_json::Value>,
+}
+
+/// Downtimes that count as live: cancelled and finished ones are history, not drift.
+fn is_live(downtime: &serde_json::Value) -> bool {
+    !matches!(
+        downtime["attributes"]["status"].as_str(),
+        Some("canceled" | "cancelled" | "ended")
+    )
+}
+
+/// Changes that make the live managed downtimes match `windows`, in file order
+/// followed by removals. Unmanaged downtimes are never touched.
+pub fn plan_apply(windows: &[MuteWindow], remote: &[serde_json::Value]) -> Vec<DowntimeChange> {
+    let mut managed: Vec<(String, &serde_json::Value)> = remote
+        .iter()
+        .filter(|d| is_live(d))
+        .filter_map(|d| Some((managed_name(d)?, d)))
+        .collect();
+    let mut changes = Vec::new();
+    for w in windows {
+        let attributes = window_attributes(w);
+        let existing = managed
+            .iter()
+            .position(|(name, _)| *name == w.name)
+            .map(|i| managed.remove(i).1);
+        let change = match existing {
+            None => DowntimeChange {
+                name: w.name.clone(),
+                id: None,
+                action: "create",
+                attributes: Some(attributes),
+            },
+            Some(d) => {
+                let pinned: Vec<bool> = w.recurrences.iter().map(|r| r.start.is_some()).collect();
+                let drifted =
+                    managed_view(&d["attributes"], &pinned) != managed_view(&attributes, &pinned);
+                DowntimeChange {
+                    name: w.name.clone(),
+                    id: d["id"].as_str().map(String::from),
+                    action: if drifted { "update" } else { "unchanged" },
+                    attributes: drifted.then_some(attributes),
+                }
+            }
+        };
+        changes.push(change);
+    }
+    // Whatever is left was removed from the file (or is a duplicate of a window).
+    for (name, d) in managed {
+        changes.push(DowntimeChange {
+            name,
+            id: d["id"].as_str().map(String::from),
+            action: "delete",
+            attributes: None,
+        });
+    }
+    changes
+}
+
+async fn list_all_downtimes(cfg: &Config) -> Result<Vec<serde_json::Value>> {
+    let collected = util::collect_pages(
+        util::Paging::Number {
+            size: DOWNTIME_PAGE_SIZE,
+        },
+        "/data",
+        util::DEFAULT_MAX_ITEMS,
+        |req| async move {
+            let query = [
+                ("page[limit]", DOWNTIME_PAGE_SIZE.to_string()),
+                (
+                    "page[offset]",
+                    (req.number * DOWNTIME_PAGE_SIZE).to_string(),
+                ),
+            ];
+            crate::api::get(cfg, "/api/v2/downtime", &query)
+                .await
+                .map_err(|e| crate::api::failed("failed to list downtimes", e))
+        },
+    )
+    .await?;
+    Ok(collected.items)
+}
+
+async fn apply_change(cfg: &Config, change: &DowntimeChange) -> Result<Option<String>> {
+    let name = &change.name;
+    match change.action {
+        "create" => {
+            let body = serde_json::json!({
+                "data": { "type": "downtime", "attributes": change.attributes }
+            });
+            let created = crate::api::post(cfg, "/api/v2/downtime", &body)
+                .await
+                .map_err(|e| {
+                    crate::api::failed(format!("failed to create downtime {name:?}"), e)
+                })?;
+            Ok(created["data"]["id"].as_str().map(String::from))
+        }
+        "update" => {
+            let id = change.id.as_deref().unwrap_or_default();
+            let body = serde_json::json!({
+                "data": { "id": id, "type": "downtime", "attributes": change.attributes }
+            });
+            crate::api::patch(cfg, &format!("/api/v2/downtime/{id}"), &body)
+                .await
+                .map_err(|e| {
+                    crate::api::failed(format!("failed to update downtime {name:?} ({id})"), e)
+                })?;
+            Ok(change.id.clone())
+        }
+        "delete" => {
+            let id = change.id.as_deref().unwrap_or_default();
+            crate::api::delete(cfg, &format!("/api/v2/downtime/{id}"))
+                .await
+                .map_err(|e| {
+                    crate::api::failed(format!("failed to cancel downtime {name:?} ({id})"), e)
+                })?;
+            Ok(change.id.clone())
+        }
+        _ => Ok(change.id.clone()),
+    }
+}
+
+/// Ask before cancelling downtimes that were removed from the file.
+fn confirm_deletes(cfg: &Config, file: &str, changes: &[DowntimeChange]) -> Result<bool> {
+    let mut prompt = crate::confirm::Destructive::new("cancel", "downtime(s) no longer in", file)
+        .detail("These downtimes are no longer in the file and will be cancelled:");
+    for change in changes.iter().filter(|c| c.action == "delete") {
+        prompt = prompt.detail(format!(
+            "  {} ({})",
+            change.name,
+            change.id.as_deref().unwrap_or("?")
+        ));
+    }
+    prompt.confirm(cfg)
+}
+
+pub async fn apply(cfg: &Config, file: &str, dry_run: bool) -> Result<()> {
+    let contents =
+        std::fs::read_to_string(file).map_err(|e| anyhow::anyhow!("failed to read {file}: {e}"))?;
+    let windows = parse_mute_windows(&contents)?;
+    let remote = list_all_downtimes(cfg).await?;
+    let mut changes = plan_apply(&windows, &remote);
+
+    let has_deletes = changes.iter().any(|c| c.action == "delete");
+    if !dry_run && has_deletes && !confirm_deletes(cfg, file, &changes)? {
+        return Ok(());
+    }
+    if !dry_run {
+        for change in &mut changes {
+            change.id = apply_change(cfg, change).await?;
+        }
+    }
+
+    let count = |action: &str| changes.iter().filter(|c| c.action == action).count();
+    let summary = format!(
+        "{} created, {} updated, {} cancelled, {} unchanged",
+        count("create"),
+        count("update"),
+        count("delete"),
+        count("unchanged")
+    );
+    if dry_run {
+        eprintln!("Dry run: {summary}.");
+    } else {
+        eprintln!("Applied {file}: {summary}.");
+    }
+    let meta = Metadata {
+        count: Some(changes.len()),
+        truncated: false,
+        command: Some("downtimes apply".to_string()),
+        next_action: dry_run.then(|| "re-run without --dry-run to apply the changes".to_string()),
+    };
+    formatter::format_and_print(&changes, cfg, Some(&meta))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const FILE: &str = r#"
+downtimes:
+  - name: weekly-db
+    scope: env:prod AND service:db
+    monitor_tags: [team:db]
+    timezone: Europe/Paris
+    recurrences:
+      - rrule: FREQ=WEEKLY;BYDAY=SU
+        start: "2026-01-04T02:00:00"
+        duration: 2h
+    message: Weekly vacuum
+  - name: nightly-batch
+    scope: service:batch
+    recurrences:
+      - rrule: FREQ=DAILY
+        duration: 30m
+"#;
+
+    fn remote(id: &str, attrs: serde_json::Value) -> serde_json::Value {
+        serde_json::json!({"id": id, "type": "downtime", "attributes": attrs})
+    }
+
+    #[test]
+    fn test_parse_mute_windows() {
+        let windows = parse_mute_windows(FILE).unwrap();
+        assert_eq!(windows.len(), 2);
+        assert_eq!(windows[1].timezone, "UTC");
+
+        let dup = "downtimes:\n  - {name: a, scope: x, recurrences: [{rrule: FREQ=DAILY, duration: 1h}]}\n  - {name: a, scope: y, recurrences: [{rrule: FREQ=DAILY, duration: 1h}]}\n";
+        assert!(parse_mute_windows(dup)
+            .unwrap_err()
+            .to_string()
+            .contains("duplicate"));
+        let typo = "downtimes:\n  - {name: a, scope: x, recurence: []}\n";
+        assert!(parse_mute_windows(typo).is_err());
+        let bad_duration =
+            "downtimes:\n  - {name: a, scope: x, recurrences: [{rrule: FREQ=DAILY, duration: soon}]}\n";
+        assert!(parse_mute_windows(bad_duration).is_err());
+    }
+
+    #[test]
+    fn test_window_attributes() {
+        let windows = parse_mute_windows(FILE).unwrap();
+        let attrs = window_attributes(&windows[0]);
+        assert_eq!(attrs["monitor_identifier"]["monitor_tags"][0], "team:db");
+        assert_eq!(
+            attrs["schedule"]["recurrences"][0]["start"],
+            "2026-01-04T02:00"
+        );
+        assert_eq!(
+            attrs["message"],
+            "Weekly vacuum\n\n[pup:mute-window=weekly-db]"
+        );
+        assert_eq!(
+            managed_name(&remote("x", attrs)).as_deref(),
+            Some("weekly-db")
+        );
+
+        let attrs = window_attributes(&windows[1]);
+        assert_eq!(attrs["monitor_identifier"]["monitor_tags"][0], "*");
+        assert!(attrs["schedule"]["recurrences"][0].get("start").is_none());
+    }
+
+    #[test]
+    fn test_plan_apply() {
+        let windows = parse_mute_windows(FILE).unwrap();
+
+        // weekly-db as the API returns it: seconds on the start, extra fields.
+        let mut weekly = window_attributes(&windows[0]);
+        weekly["schedule"]["recurrences"][0]["start"] = "2026-01-04T02:00:00".into();
+        weekly["schedule"]["current_downtime"] = serde_json::json!({"start": "..."});
+        weekly["status"] = "scheduled".into();
+        // nightly-batch has drifted; the API chose a start, which isn't drift.
+        let mut nightly = window_attributes(&windows[1]);
+        nightly["schedule"]["recurrences"][0]["start"] = "2025-12-01T10:00".into();
+        nightly["scope"] = "service:batch-old".into();
+        let removed = window_attributes(&MuteWindow {
+            name: "old".into(),
+            recurrences: vec![],
+            ..windows[1].clone()
+        });
+        let mut cancelled = removed.clone();
+        cancelled["status"] = "canceled".into();
+        let unmanaged = serde_json::json!({"scope": "env:dev", "message": "manual"});
+
+        let plan = plan_apply(
+            &windows,
+            &[
+                remote("a", weekly),
+                remote("b", nightly),
+                remote("c", removed),
+                remote("d", cancelled),
+                remote("e", unmanaged),
+            ],
+        );
+        let actions: Vec<(&str, Option<&str>, &str)> = plan
+            .iter()
+            .map(|c| (c.name.as_str(), c.id.as_deref(), c.action))
+            .collect();
+        assert_eq!(
+            actions,
+            vec![
+                ("weekly-db", Some("a"), "unchanged"),
+                ("nightly-batch", Some("b"), "update"),
+                ("old", Some("c"), "delete"),
+            ]
+        );
+
+        let plan = plan_apply(&windows, &[]);
+        assert!(plan
+            .iter()
+            .all(|c| c.action == "create" && c.attributes.is_some()));
+    }
+}
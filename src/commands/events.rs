@@ -155,3 +155,327 @@ pub async fn get(cfg: &Config, id: i64) -> Result<()> {
     let data = crate::api::get(cfg, &path, &[]).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Send (v2 intake)
+// ---------------------------------------------------------------------------
+
+/// Inputs for `pup events send`.
+#[derive(Debug, Default)]
+pub struct EventSendOptions {
+    pub title: String,
+    pub message: String,
+    /// `change` or `alert`.
+    pub category: String,
+    /// Status for alert events: error, warn, or ok.
+    pub alert_status: String,
+    pub aggregation_key: Option<String>,
+    pub host: Option<String>,
+    pub tags: Vec<String>,
+    /// Monitor IDs, attached as `monitor_id:<id>` tags.
+    pub related_monitors: Vec<i64>,
+    /// Services impacted by a change event.
+    pub related_services: Vec<String>,
+    /// Source type, attached as a `source:<type>` tag.
+    pub source_type: Option<String>,
+    /// Name of the resource a change event changed; defaults to the title.
+    pub changed_resource: Option<String>,
+    /// Skip sending when an event with the same aggregation key was seen within this window.
+    pub dedup_window: Option<String>,
+}
+
+/// Build the `/api/v2/events` request body.
+pub fn build_event_body(opts: &EventSendOptions) -> Result<serde_json::Value> {
+    let mut tags = opts.tags.clone();
+    if let Some(source) = &opts.source_type {
+        tags.push(format!("source:{source}"));
+    }
+    for id in &opts.related_monitors {
+        tags.push(format!("monitor_id:{id}"));
+    }
+
+    let category_attrs = match opts.category.as_str() {
+        "change" => {
+            let mut attrs = serde_json::json!({
+                "changed_resource": {
+                    "name": opts.changed_resource.as_deref().unwrap_or(&opts.title),
+                    "type": "configuration",
+                }
+            });
+            if !opts.related_services.is_empty() {
+                attrs["impacted_resources"] = opts
+                    .related_services
+                    .iter()
+                    .map(|s| serde_json::json!({"name": s, "type": "service"}))
+                    .collect();
+            }
+            attrs
+        }
+        "alert" => {
+            if !opts.related_services.is_empty() {
+                bail!("--related-service is only supported for change events");
+            }
+            if opts.changed_resource.is_some() {
+                bail!("--changed-resource is only supported for change events");
+            }
+            match opts.alert_status.as_str() {
+                "error" | "warn" | "ok" => {}
+                other => bail!("invalid alert status: {other:?} (expected error, warn, or ok)"),
+            }
+            serde_json::json!({ "status": opts.alert_status })
+        }
+        other => bail!("invalid event category: {other:?} (expected change or alert)"),
+    };
+
+    let mut attributes = serde_json::json!({
+        "title": opts.title,
+        "message": opts.message,
+        "category": opts.category,
+        "tags": tags,
+        "attributes": category_attrs,
+    });
+    if let Some(key) = &opts.aggregation_key {
+        attributes["aggregation_key"] = serde_json::json!(key);
+    }
+    if let Some(host) = &opts.host {
+        attributes["host"] = serde_json::json!(host);
+    }
+    Ok(serde_json::json!({ "data": { "type": "event", "attributes": attributes } }))
+}
+
+/// Return the ID of the first event in a v2 search response carrying `aggregation_key`.
+pub fn find_duplicate(resp: &serde_json::Value, aggregation_key: &str) -> Option<String> {
+    resp.get("data")?.as_array()?.iter().find_map(|event| {
+        let attrs = event.get("attributes")?;
+        let key = attrs
+            .pointer("/attributes/aggregation_key")
+            .or_else(|| attrs.get("aggregation_key"))?
+            .as_str()?;
+        if key == aggregation_key {
+            event.get("id")?.as_str().map(String::from)
+        } else {
+            None
+        }
+    })
+}
+
+/// Events requested per page by the dedup check.
+const DEDUP_PAGE_SIZE: i64 = 1000;
+/// Pages the dedup check reads before giving up on finding a duplicate.
+const DEDUP_MAX_PAGES: usize = 5;
+
+/// Outcome of the `--dedup-window` check.
+#[derive(Debug, PartialEq)]
+pub enum DedupCheck {
+    /// An event with the aggregation key, by ID.
+    Duplicate(String),
+    NotFound,
+    /// More than `DEDUP_MAX_PAGES` pages matched without a duplicate.
+    Incomplete,
+}
+
+/// Search query for earlier copies of this event: the same source and tags,
+/// so the check reads those events rather than everything in the org.
+pub fn dedup_query(opts: &EventSendOptions) -> String {
+    let mut terms: Vec<String> = opts
+        .source_type
+        .iter()
+        .map(|s| format!("source:{s}"))
+        .collect();
+    terms.extend(
+        opts.tags
+            .iter()
+            .filter(|t| !t.is_empty())
+            .map(|t| format!("tags:{t}")),
+    );
+    if terms.is_empty() {
+        "*".to_string()
+    } else {
+        terms.join(" ")
+    }
+}
+
+/// Page through events matching `query` since `from`, newest first, looking
+/// for one carrying `aggregation_key`. Stops after `DEDUP_MAX_PAGES` pages.
+async fn find_recent_duplicate(
+    cfg: &Config,
+    query: &str,
+    from: &str,
+    aggregation_key: &str,
+) -> Result<DedupCheck> {
+    let from_ms = util::parse_time_to_unix_millis(from)?;
+    let from_str = chrono::DateTime::from_timestamp_millis(from_ms)
+        .ok_or_else(|| crate::exit_code::Failure::validation(format!("invalid time {from:?}")))?
+        .to_rfc3339();
+    let mut cursor: Option<String> = None;
+    for _ in 0..DEDUP_MAX_PAGES {
+        let mut page = serde_json::json!({ "limit": DEDUP_PAGE_SIZE });
+        if let Some(c) = &cursor {
+            page["cursor"] = serde_json::Value::String(c.clone());
+        }
+        let body = serde_json::json!({
+            "filter": { "query": query, "from": from_str },
+            "page": page,
+            "sort": "-timestamp"
+        });
+        let resp = crate::api::post(cfg, "/api/v2/events/search", &body)
+            .await
+            .map_err(|e| crate::api::failed("failed to search events for dedup", e))?;
+        if let Some(id) = find_duplicate(&resp, aggregation_key) {
+            return Ok(DedupCheck::Duplicate(id));
+        }
+        let empty = resp["data"].as_array().map_or(true, |d| d.is_empty());
+        cursor = resp
+            .pointer("/meta/page/after")
+            .and_then(|v| v.as_str())
+            .map(String::from);
+        if empty || cursor.is_none() {
+            return Ok(DedupCheck::NotFound);
+        }
+    }
+    Ok(DedupCheck::Incomplete)
+}
+
+pub async fn send(cfg: &Config, opts: EventSendOptions) -> Result<()> {
+    let body = build_event_body(&opts)?;
+
+    if let Some(window) = &opts.dedup_window {
+        let Some(key) = &opts.aggregation_key else {
+            bail!("--dedup-window requires --aggregation-key");
+        };
+        // Events search is OAuth-excluded — require API keys
+        if !cfg.has_api_keys() {
+            bail!(
+                "--dedup-window requires API key authentication (DD_API_KEY + DD_APP_KEY).\n\
+                 The events search endpoint does not support bearer token auth."
+            );
+        }
+        match find_recent_duplicate(cfg, &dedup_query(&opts), window, key).await? {
+            DedupCheck::Duplicate(existing) => {
+                eprintln!(
+                    "Skipped: event {existing} with aggregation key {key:?} was sent within the last {window}."
+                );
+                let data = serde_json::json!({
+                    "status": "duplicate",
+                    "aggregation_key": key,
+                    "existing_event_id": existing,
+                });
+                return formatter::output(cfg, &data);
+            }
+            DedupCheck::Incomplete => eprintln!(
+                "Warning: dedup check incomplete: more than {} matching events in the last \
+                 {window}; sending without ruling out a duplicate. Narrow it with \
+                 --source-type, --tags, or a shorter --dedup-window.",
+                DEDUP_MAX_PAGES as i64 * DEDUP_PAGE_SIZE
+            ),
+            DedupCheck::NotFound => {}
+        }
+    }
+
+    let resp = crate::client::raw_post(cfg, "/api/v2/events", body)
+        .await
+        .map_err(|e| crate::api::failed("failed to send event", e))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn opts() -> EventSendOptions {
+        EventSendOptions {
+            title: "Deploy web 1.2.3".into(),
+            message: "Rolled out".into(),
+            category: "change".into(),
+            alert_status: "warn".into(),
+            ..Default::default()
+        }
+    }
+
+    #[test]
+    fn test_build_event_body_change() {
+        let mut o = opts();
+        o.aggregation_key = Some("deploy-web-123".into());
+        o.source_type = Some("github".into());
+        o.changed_resource = Some("web-config".into());
+        o.related_monitors = vec![42];
+        o.related_services = vec!["web".into()];
+        o.tags = vec!["env:prod".into()];
+        let body = build_event_body(&o).unwrap();
+        let attrs = &body["data"]["attributes"];
+        assert_eq!(body["data"]["type"], "event");
+        assert_eq!(attrs["aggregation_key"], "deploy-web-123");
+        assert_eq!(
+            attrs["tags"],
+            serde_json::json!(["env:prod", "source:github", "monitor_id:42"])
+        );
+        assert_eq!(
+            attrs["attributes"]["changed_resource"]["name"],
+            "web-config"
+        );
+        assert_eq!(
+            attrs["attributes"]["impacted_resources"],
+            serde_json::json!([{"name": "web", "type": "service"}])
+        );
+        assert!(attrs.get("host").is_none());
+    }
+
+    #[test]
+    fn test_build_event_body_alert() {
+        let mut o = opts();
+        o.category = "alert".into();
+        o.alert_status = "error".into();
+        let body = build_event_body(&o).unwrap();
+        assert_eq!(body["data"]["attributes"]["attributes"]["status"], "error");
+
+        o.alert_status = "critical".into();
+        assert!(build_event_body(&o).is_err());
+        o.alert_status = "ok".into();
+        o.related_services = vec!["web".into()];
+        assert!(build_event_body(&o).is_err());
+        o.related_services.clear();
+        o.changed_resource = Some("web-config".into());
+        assert!(build_event_body(&o).is_err());
+    }
+
+    #[test]
+    fn test_build_event_body_changed_resource_defaults_to_title() {
+        let mut o = opts();
+        o.source_type = Some("github".into());
+        let body = build_event_body(&o).unwrap();
+        assert_eq!(
+            body["data"]["attributes"]["attributes"]["changed_resource"]["name"],
+            "Deploy web 1.2.3"
+        );
+    }
+
+    #[test]
+    fn test_build_event_body_invalid_category() {
+        let mut o = opts();
+        o.category = "info".into();
+        assert!(build_event_body(&o).is_err());
+    }
+
+    #[test]
+    fn test_dedup_query() {
+        assert_eq!(dedup_query(&opts()), "*");
+        let mut o = opts();
+        o.source_type = Some("github".into());
+        o.tags = vec!["env:prod".into(), "team:web".into()];
+        assert_eq!(dedup_query(&o), "source:github tags:env:prod tags:team:web");
+    }
+
+    #[test]
+    fn test_find_duplicate() {
+        let resp = serde_json::json!({"data": [
+            {"id": "e1", "attributes": {"attributes": {"aggregation_key": "other"}}},
+            {"id": "e2", "attributes": {"attributes": {"aggregation_key": "deploy-web-123"}}}
+        ]});
+        assert_eq!(
+            find_duplicate(&resp, "deploy-web-123").as_deref(),
+            Some("e2")
+        );
+        assert!(find_duplicate(&resp, "missing").is_none());
+        assert!(find_duplicate(&serde_json::json!({}), "x").is_none());
+    }
+}
@@ -0,0 +1,569 @@
+//! `pup examples`: ready-to-run command lines for a command, optionally run.
+//!
+//! Examples come from two places: a small set of parameterized templates below
+//! (placeholders are `{{name}}`, filled from `--var name=value` or a default),
+//! and the EXAMPLES section of every command's long help, which are listed
+//! as-is. Both are keyed by the command path the example invokes.
+
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+use crate::formatter;
+
+pub struct Template {
+    pub command: &'static str,
+    pub description: &'static str,
+    pub template: &'static str,
+    pub vars: &'static [(&'static str, &'static str)],
+}
+
+const fn template(
+    command: &'static str,
+    description: &'static str,
+    template: &'static str,
+    vars: &'static [(&'static str, &'static str)],
+) -> Template {
+    Template {
+        command,
+        description,
+        template,
+        vars,
+    }
+}
+
+pub const TEMPLATES: &[Template] = &[
+    template(
+        "logs search",
+        "Recent errors for one service",
+        "pup logs search --query=\"service:{{service}} status:error\" --from={{from}} --limit={{limit}}",
+        &[("service", "web-app"), ("from", "1h"), ("limit", "20")],
+    ),
+    template(
+        "logs search",
+        "Everything one user did",
+        "pup logs search --query=\"@usr.id:{{user_id}}\" --from={{from}}",
+        &[("user_id", "42"), ("from", "1d")],
+    ),
+    template(
+        "logs aggregate",
+        "Error counts per service in one environment",
+        "pup logs aggregate --query=\"status:error env:{{env}}\" --from={{from}} --compute=count --group-by=service",
+        &[("env", "prod"), ("from", "1h")],
+    ),
+    template(
+        "metrics query",
+        "One metric for one service",
+        "pup metrics query --query=\"avg:{{metric}}{service:{{service}}}\" --from={{from}}",
+        &[
+            ("metric", "system.cpu.user"),
+            ("service", "web-app"),
+            ("from", "1h"),
+        ],
+    ),
+    template(
+        "traces search",
+        "Server errors for one service",
+        "pup traces search --query=\"service:{{service}} @http.status_code:>=500\" --from={{from}} --limit={{limit}}",
+        &[("service", "web-app"), ("from", "1h"), ("limit", "20")],
+    ),
+    template(
+        "apm services list",
+        "Services reporting in one environment",
+        "pup apm services list --env={{env}} --from={{from}}",
+        &[("env", "prod"), ("from", "1h")],
+    ),
+    template(
+        "apm services stats",
+        "Throughput, errors, and latency per service",
+        "pup apm services stats --env={{env}} --from={{from}}",
+        &[("env", "prod"), ("from", "1h")],
+    ),
+    template(
+        "monitors list",
+        "Monitors owned by one team",
+        "pup monitors list --tags=\"team:{{team}}\"",
+        &[("team", "backend")],
+    ),
+    template(
+        "incidents list",
+        "Most recent incidents",
+        "pup incidents list --limit={{limit}}",
+        &[("limit", "10")],
+    ),
+    template(
+        "dashboards get",
+        "Full definition of one dashboard",
+        "pup dashboards get {{dashboard_id}}",
+        &[("dashboard_id", "abc-def-123")],
+    ),
+    template(
+        "find",
+        "Monitors, dashboards, SLOs, notebooks, and tests by name",
+        "pup find {{name}}",
+        &[("name", "checkout")],
+    ),
+];
+
+#[derive(Debug, Clone, PartialEq)]
+pub struct Example {
+    /// Command path the example invokes, e.g. `logs search`.
+    pub command: String,
+    pub description: String,
+    pub template: String,
+    /// Placeholder defaults; empty for examples taken from help text.
+    pub vars: Vec<(String, String)>,
+}
+
+/// `(description, command line)` pairs from the EXAMPLES section of a long
+/// help text. A `# comment` line describes the commands under it up to the
+/// next blank line; trailing `\` continuations are joined.
+pub fn help_examples(text: &str) -> Vec<(String, String)> {
+    let mut out = Vec::new();
+    let mut in_section = false;
+    let mut description = String::new();
+    let mut pending: Option<String> = None;
+    for raw in text.lines() {
+        if !raw.starts_with(' ') && raw.trim_end().ends_with(':') {
+            in_section = raw.trim() == "EXAMPLES:";
+            description.clear();
+            continue;
+        }
+        if !in_section {
+            continue;
+        }
+        let line = raw.trim();
+        let continued = line.ends_with('\\');
+        let part = line.trim_end_matches('\\').trim_end();
+        if let Some(mut cmd) = pending.take() {
+            cmd.push(' ');
+            cmd.push_str(part);
+            if continued {
+                pending = Some(cmd);
+            } else {
+                out.push((description.clone(), cmd));
+            }
+            continue;
+        }
+        if line.is_empty() {
+            description.clear();
+        } else if let Some(comment) = line.strip_prefix('#') {
+            description = comment.trim().to_string();
+        } else if line.starts_with("pup ") {
+            if continued {
+                pending = Some(part.to_string());
+            } else {
+                out.push((description.clone(), part.to_string()));
+            }
+        }
+    }
+    out
+}
+
+/// The subcommand path a `pup ...` line invokes, by walking `root`.
+pub fn command_path(root: &clap::Command, line: &str) -> Vec<String> {
+    let mut current = root;
+    let mut path = Vec::new();
+    for word in line.split_whitespace().skip(1) {
+        let Some(sub) = current
+            .get_subcommands()
+            .find(|s| s.get_name() == word || s.get_all_aliases().any(|a| a == word))
+        else {
+            break;
+        };
+        path.push(sub.get_name().to_string());
+        current = sub;
+    }
+    path
+}
+
+fn collect_help(root: &clap::Command, cmd: &clap::Command, out: &mut Vec<Example>) {
+    if let Some(text) = cmd.get_long_about() {
+        for (description, line) in help_examples(&text.to_string()) {
+            let path = command_path(root, &line);
+            if path.is_empty() || out.iter().any(|e| e.template == line) {
+                continue;
+            }
+            out.push(Example {
+                command: path.join(" "),
+                description,
+                template: line,
+                vars: Vec::new(),
+            });
+        }
+    }
+    for sub in cmd.get_subcommands() {
+        collect_help(root, sub, out);
+    }
+}
+
+/// Every known example: templates first, then help-text examples.
+pub fn all_examples(root: &clap::Command) -> Vec<Example> {
+    let mut out: Vec<Example> = TEMPLATES
+        .iter()
+        .map(|t| Example {
+            command: t.command.to_string(),
+            description: t.description.to_string(),
+            template: t.template.to_string(),
+            vars: t
+                .vars
+                .iter()
+                .map(|(k, v)| (k.to_string(), v.to_string()))
+                .collect(),
+        })
+        .collect();
+    collect_help(root, root, &mut out);
+    out
+}
+
+/// Examples for `path` and the commands under it.
+pub fn examples_for(all: &[Example], path: &[String]) -> Vec<Example> {
+    let prefix = path.join(" ");
+    all.iter()
+        .filter(|e| e.command == prefix || e.command.starts_with(&format!("{prefix} ")))
+        .cloned()
+        .collect()
+}
+
+/// Parse `--var key=value` arguments.
+pub fn parse_vars(vars: &[String]) -> Result<Vec<(String, String)>> {
+    vars.iter()
+        .map(|v| match v.split_once('=') {
+            Some((k, val)) if !k.trim().is_empty() => Ok((k.trim().to_string(), val.to_string())),
+            _ => bail!("invalid --var {v:?}: expected key=value"),
+        })
+        .collect()
+}
+
+/// Fill `{{name}}` placeholders from `overrides`, falling back to defaults.
+pub fn render(example: &Example, overrides: &[(String, String)]) -> String {
+    let mut line = example.template.clone();
+    for (name, default) in &example.vars {
+        let value = overrides
+            .iter()
+            .rev()
+            .find(|(k, _)| k == name)
+            .map(|(_, v)| v)
+            .unwrap_or(default);
+        line = line.replace(&format!("{{{{{name}}}}}"), value);
+    }
+    line
+}
+
+/// Reject `--var` names that none of `examples` take, so typos don't pass silently.
+fn check_vars(examples: &[Example], overrides: &[(String, String)]) -> Result<()> {
+    let mut known: Vec<&str> = examples
+        .iter()
+        .flat_map(|e| e.vars.iter().map(|(k, _)| k.as_str()))
+        .collect();
+    known.sort();
+    known.dedup();
+    for (name, _) in overrides {
+        if !known.contains(&name.as_str()) {
+            if known.is_empty() {
+                bail!("invalid --var {name:?}: these examples take no variables");
+            }
+            bail!(
+                "invalid --var {name:?}: these examples take {}",
+                known.join(", ")
+            );
+        }
+    }
+    Ok(())
+}
+
+fn rows(examples: &[Example], overrides: &[(String, String)]) -> serde_json::Value {
+    examples
+        .iter()
+        .enumerate()
+        .map(|(i, e)| {
+            serde_json::json!({
+                "index": i + 1,
+                "command": e.command,
+                "description": e.description,
+                "run": render(e, overrides),
+                "variables": e.vars.iter().map(|(k, _)| k.as_str()).collect::<Vec<_>>().join(","),
+            })
+        })
+        .collect()
+}
+
+/// `pup examples` with no path: each command with examples and how many.
+fn summary(all: &[Example]) -> serde_json::Value {
+    let mut counts: Vec<(String, usize)> = Vec::new();
+    for e in all {
+        match counts.iter_mut().find(|(c, _)| *c == e.command) {
+            Some((_, n)) => *n += 1,
+            None => counts.push((e.command.clone(), 1)),
+        }
+    }
+    counts.sort();
+    counts
+        .into_iter()
+        .map(|(command, n)| serde_json::json!({"command": command, "examples": n}))
+        .collect()
+}
+
+/// Shell operators an example may use when shown but that `--run` cannot honour.
+#[cfg(not(target_arch = "wasm32"))]
+const SHELL_OPERATORS: &[&str] = &["|", "||", "&&", ";", "&", ">", ">>", "<", "2>"];
+
+/// Split an example line into the arguments after `pup`. Lines never go
+/// through a shell, so `--var` values are passed as plain arguments and
+/// cannot run other commands.
+#[cfg(not(target_arch = "wasm32"))]
+fn example_args(line: &str) -> Result<Vec<String>> {
+    let words =
+        shell_words::split(line).map_err(|e| anyhow::anyhow!("cannot parse {line:?}: {e}"))?;
+    let args = match words.split_first() {
+        Some((first, args)) if first == "pup" => args,
+        _ => bail!("not a pup command: {line}"),
+    };
+    if let Some(op) = args.iter().find(|a| SHELL_OPERATORS.contains(&a.as_str())) {
+        bail!("cannot run {line:?}: it uses shell syntax ({op}); copy it into a shell instead");
+    }
+    Ok(args.to_vec())
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+fn execute(cfg: &Config, root: &clap::Command, lines: &[String]) -> Result<()> {
+    use crate::commands::capabilities;
+
+    let writes: Vec<&String> = lines
+        .iter()
+        .filter(|l| {
+            let path = command_path(root, l);
+            let path: Vec<&str> = path.iter().map(String::as_str).collect();
+            let domain = path.first().copied().unwrap_or_default();
+            capabilities::lookup(domain).is_some_and(|d| !d.endpoints.is_empty())
+                && capabilities::access(&path) != capabilities::Access::Read
+        })
+        .collect();
+    if !writes.is_empty() {
+        let mut prompt =
+            crate::confirm::Destructive::new("run", "examples that modify resources", "")
+                .detail("These examples modify resources:");
+        for line in &writes {
+            prompt = prompt.detail(format!("  {line}"));
+        }
+        if !prompt.confirm(cfg)? {
+            return Ok(());
+        }
+    }
+    // Run the same binary rather than whatever `pup` is first on PATH.
+    let exe = std::env::current_exe()
+        .map_err(|e| anyhow::anyhow!("failed to locate the pup executable: {e}"))?;
+    let argv = lines
+        .iter()
+        .map(|l| example_args(l))
+        .collect::<Result<Vec<_>>>()?;
+    for (line, args) in lines.iter().zip(argv) {
+        eprintln!("$ {line}");
+        let status = std::process::Command::new(&exe)
+            .args(&args)
+            .status()
+            .map_err(|e| anyhow::anyhow!("failed to run {line:?}: {e}"))?;
+        if !status.success() {
+            bail!("example failed with {status}: {line}");
+        }
+    }
+    Ok(())
+}
+
+#[cfg(target_arch = "wasm32")]
+fn execute(_cfg: &Config, _root: &clap::Command, _lines: &[String]) -> Result<()> {
+    bail!("pup examples --run is not available in WASM builds — subprocesses are not supported.")
+}
+
+pub fn run(
+    cfg: &Config,
+    root: &clap::Command,
+    command: &[String],
+    vars: &[String],
+    index: Option<usize>,
+    run: bool,
+) -> Result<()> {
+    let all = all_examples(root);
+    if command.is_empty() {
+        if run || index.is_some() || !vars.is_empty() {
+            bail!("invalid arguments: name a command, e.g. pup examples logs search");
+        }
+        return formatter::output(cfg, &summary(&all));
+    }
+
+    let path = command_path(root, &format!("pup {}", command.join(" ")));
+    if path.len() != command.len() {
+        return Err(crate::exit_code::Failure::not_found(format!(
+            "no command named \"pup {}\"",
+            command.join(" ")
+        )));
+    }
+    let mut examples = examples_for(&all, &path);
+    if examples.is_empty() {
+        bail!(
+            "no examples for \"pup {}\" (see 'pup examples' for commands that have them)",
+            path.join(" ")
+        );
+    }
+    if let Some(n) = index {
+        if n == 0 || n > examples.len() {
+            bail!(
+                "invalid --index {n}: \"pup {}\" has {} examples",
+                path.join(" "),
+                examples.len()
+            );
+        }
+        examples = vec![examples.remove(n - 1)];
+    }
+    let overrides = parse_vars(vars)?;
+    check_vars(&examples, &overrides)?;
+
+    if run {
+        if examples.len() > 1 {
+            bail!(
+                "invalid --run: \"pup {}\" has {} examples; pick one with --index",
+                path.join(" "),
+                examples.len()
+            );
+        }
+        let lines: Vec<String> = examples.iter().map(|e| render(e, &overrides)).collect();
+        return execute(cfg, root, &lines);
+    }
+    formatter::output(cfg, &rows(&examples, &overrides))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const HELP: &str = "Manage logs.
+
+EXAMPLES:
+  # Search for error logs
+  pup logs search --query=\"status:error\" --from=\"1h\"
+
+  pup logs aggregate --query=\"*\" \\
+    --compute=count
+
+AUTHENTICATION:
+  pup not-an-example
+";
+
+    fn sample_cli() -> clap::Command {
+        clap::Command::new("pup").subcommand(
+            clap::Command::new("logs")
+                .long_about(HELP)
+                .subcommand(clap::Command::new("search"))
+                .subcommand(clap::Command::new("aggregate"))
+                .subcommand(clap::Command::new("archives").subcommand(clap::Command::new("list"))),
+        )
+    }
+
+    #[test]
+    fn test_help_examples() {
+        let got = help_examples(HELP);
+        assert_eq!(
+            got,
+            vec![
+                (
+                    "Search for error logs".to_string(),
+                    "pup logs search --query=\"status:error\" --from=\"1h\"".to_string()
+                ),
+                (
+                    String::new(),
+                    "pup logs aggregate --query=\"*\" --compute=count".to_string()
+                ),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_command_path_stops_at_flags_and_args() {
+        let root = sample_cli();
+        assert_eq!(
+            command_path(&root, "pup logs search --query=x"),
+            vec!["logs", "search"]
+        );
+        assert_eq!(
+            command_path(&root, "pup logs archives list"),
+            vec!["logs", "archives", "list"]
+        );
+        assert!(command_path(&root, "pup widgets list").is_empty());
+    }
+
+    #[test]
+    fn test_examples_for_includes_templates_and_help() {
+        let all = all_examples(&sample_cli());
+        let search = examples_for(&all, &["logs".into(), "search".into()]);
+        assert!(search.iter().any(|e| !e.vars.is_empty()));
+        assert!(search.iter().any(|e| e
+            .template
+            .starts_with("pup logs search --query=\"status:error\"")));
+        assert!(search.iter().all(|e| e.command == "logs search"));
+        // A path prefix covers its subcommands.
+        let logs = examples_for(&all, &["logs".into()]);
+        assert!(logs.iter().any(|e| e.command == "logs aggregate"));
+    }
+
+    #[test]
+    fn test_render_with_vars_and_defaults() {
+        let ex = Example {
+            command: "metrics query".into(),
+            description: String::new(),
+            template: "pup metrics query --query=\"avg:{{metric}}{service:{{service}}}\"".into(),
+            vars: vec![
+                ("metric".into(), "system.cpu.user".into()),
+                ("service".into(), "web-app".into()),
+            ],
+        };
+        assert_eq!(
+            render(&ex, &[("service".into(), "api".into())]),
+            "pup metrics query --query=\"avg:system.cpu.user{service:api}\""
+        );
+    }
+
+    #[test]
+    fn test_example_args_never_use_a_shell() {
+        assert_eq!(
+            example_args(r#"pup logs search --query="service:web status:error" --from=1h"#)
+                .unwrap(),
+            vec![
+                "logs",
+                "search",
+                "--query=service:web status:error",
+                "--from=1h"
+            ]
+        );
+        // A --var value that tries to break out stays one literal argument.
+        let example = Example {
+            command: "logs search".into(),
+            description: String::new(),
+            template: "pup logs search --query=\"service:{{service}}\"".into(),
+            vars: vec![("service".into(), "web".into())],
+        };
+        let line = render(
+            &example,
+            &[("service".into(), "x$(touch /tmp/pwned)".into())],
+        );
+        assert_eq!(
+            example_args(&line).unwrap(),
+            vec!["logs", "search", "--query=service:x$(touch /tmp/pwned)"]
+        );
+        assert!(example_args("pup monitors list | jq .").is_err());
+        assert!(example_args("rm -rf /").is_err());
+        assert!(example_args("pup logs search --query=\"unterminated").is_err());
+    }
+
+    #[test]
+    fn test_vars_are_validated() {
+        assert!(parse_vars(&["service".into()]).is_err());
+        assert_eq!(
+            parse_vars(&["query=a=b".into()]).unwrap(),
+            vec![("query".to_string(), "a=b".to_string())]
+        );
+        let all = all_examples(&sample_cli());
+        let search = examples_for(&all, &["logs".into(), "search".into()]);
+        assert!(check_vars(&search, &[("service".into(), "api".into())]).is_ok());
+        let err = check_vars(&search, &[("servce".into(), "api".into())]).unwrap_err();
+        assert!(err.to_string().contains("service"), "{err}");
+    }
+}
@@ -0,0 +1,395 @@
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+#[cfg(not(target_arch = "wasm32"))]
+use crate::formatter;
+
+/// Org name that runs against the default session (no `--org`).
+pub const DEFAULT_ORG: &str = "default";
+
+/// Global flags fanout sets on each child itself.
+const RESERVED_FLAGS: &[&str] = &[
+    "--org",
+    "--profile",
+    "--output",
+    "-o",
+    "--max-output-bytes",
+    "--timeout",
+    "--connect-timeout",
+];
+
+/// What each child is pointed at: a named org session or a config profile.
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum Target {
+    Org,
+    Profile,
+}
+
+impl Target {
+    /// The flag that lists the targets on fanout itself.
+    fn list_flag(self) -> &'static str {
+        match self {
+            Target::Org => "--orgs",
+            Target::Profile => "--profiles",
+        }
+    }
+
+    /// The flag that selects one target on a child.
+    fn child_flag(self) -> &'static str {
+        match self {
+            Target::Org => "--org",
+            Target::Profile => "--profile",
+        }
+    }
+}
+
+/// Global flags given to fanout itself that every child inherits.
+#[derive(Debug, Default)]
+pub struct Inherited {
+    /// `--profile`, for `--orgs` runs; `--profiles` picks one per child.
+    pub profile: Option<String>,
+    pub timeout: Option<String>,
+    pub connect_timeout: Option<String>,
+}
+
+/// Split `--orgs` / `--profiles` into unique, non-empty names, keeping the
+/// order given.
+pub fn parse_targets(target: Target, names: &str) -> Result<Vec<String>> {
+    let mut out: Vec<String> = Vec::new();
+    for name in names.split(',').map(str::trim).filter(|s| !s.is_empty()) {
+        if !out.iter().any(|o| o == name) {
+            out.push(name.to_string());
+        }
+    }
+    if out.is_empty() {
+        let flag = target.list_flag();
+        bail!("{flag} requires at least one name (e.g. {flag} prod-us,prod-eu)");
+    }
+    Ok(out)
+}
+
+/// Reject commands that would fight with the flags fanout passes to each child.
+pub fn validate_command(command: &[String]) -> Result<()> {
+    let Some(first) = command.first() else {
+        bail!("no command given — usage: pup fanout --orgs a,b -- <command> [args...]");
+    };
+    if first == "fanout" {
+        bail!("pup fanout cannot run itself");
+    }
+    for arg in command {
+        let flag = arg.split('=').next().unwrap_or(arg);
+        if RESERVED_FLAGS.contains(&flag) {
+            bail!(
+                "{flag} is set by fanout for each child — pass it before 'fanout', \
+                 and list orgs or profiles with --orgs / --profiles"
+            );
+        }
+    }
+    Ok(())
+}
+
+/// Arguments for one child invocation: JSON output (the parent renders the
+/// merged result in its own `--output`), no output cap (the parent caps the
+/// merged result once), the org or profile, the inherited globals, then the
+/// command.
+pub fn child_args(
+    cfg: &Config,
+    target: Target,
+    name: &str,
+    inherited: &Inherited,
+    command: &[String],
+) -> Vec<String> {
+    let mut args: Vec<String> = vec![
+        "--output".into(),
+        "json".into(),
+        "--max-output-bytes".into(),
+        "0".into(),
+    ];
+    if target == Target::Profile || name != DEFAULT_ORG {
+        args.push(target.child_flag().into());
+        args.push(name.into());
+    }
+    if target == Target::Org {
+        if let Some(profile) = &inherited.profile {
+            args.push("--profile".into());
+            args.push(profile.clone());
+        }
+    }
+    if let Some(t) = &inherited.timeout {
+        args.push("--timeout".into());
+        args.push(t.clone());
+    }
+    if let Some(t) = &inherited.connect_timeout {
+        args.push("--connect-timeout".into());
+        args.push(t.clone());
+    }
+    if cfg.agent_mode {
+        args.push("--agent".into());
+    }
+    if cfg.auto_approve {
+        args.push("--yes".into());
+    }
+    args.extend(command.iter().cloned());
+    args
+}
+
+/// Turn one child's exit status and output into its entry in the merged result.
+/// Successful output is parsed as JSON (agent envelopes are unwrapped to their
+/// `data`); commands that print plain text are kept as a string.
+pub fn org_result(success: bool, stdout: &str, stderr: &str, agent: bool) -> serde_json::Value {
+    if !success {
+        let msg = stderr.trim();
+        let msg = if msg.is_empty() {
+            "command failed with no error output"
+        } else {
+            msg
+        };
+        return serde_json::json!({ "error": msg });
+    }
+    let out = stdout.trim();
+    if out.is_empty() {
+        return serde_json::Value::Null;
+    }
+    match serde_json::from_str::<serde_json::Value>(out) {
+        Ok(mut v) if agent && v.get("status").is_some() && v.get("data").is_some() => {
+            v["data"].take()
+        }
+        Ok(v) => v,
+        Err(_) => serde_json::Value::String(out.to_string()),
+    }
+}
+
+/// Run `command` against every org or profile concurrently (at most
+/// `concurrency` at once) by re-invoking pup with `--org` / `--profile`, and
+/// print the results keyed by name.
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn run(
+    cfg: &Config,
+    target: Target,
+    names: &str,
+    concurrency: usize,
+    inherited: &Inherited,
+    command: &[String],
+) -> Result<()> {
+    let names = parse_targets(target, names)?;
+    validate_command(command)?;
+    if target == Target::Profile && inherited.profile.is_some() {
+        bail!("--profile cannot be combined with --profiles");
+    }
+    let exe = std::env::current_exe()
+        .map_err(|e| anyhow::anyhow!("failed to locate the pup executable: {e}"))?;
+    let limit = std::sync::Arc::new(tokio::sync::Semaphore::new(concurrency.max(1)));
+
+    let mut handles = Vec::with_capacity(names.len());
+    for name in &names {
+        let mut cmd = tokio::process::Command::new(&exe);
+        cmd.args(child_args(cfg, target, name, inherited, command))
+            .stdin(std::process::Stdio::null());
+        let limit = limit.clone();
+        handles.push(tokio::spawn(async move {
+            let _permit = limit.acquire_owned().await;
+            cmd.output().await
+        }));
+    }
+
+    let mut merged = serde_json::Map::new();
+    let mut failed = Vec::new();
+    for (name, handle) in names.iter().zip(handles) {
+        let output = handle
+            .await
+            .map_err(|e| anyhow::anyhow!("fanout task for {name:?} panicked: {e}"))?
+            .map_err(|e| anyhow::anyhow!("failed to run pup for {name:?}: {e}"))?;
+        let success = output.status.success();
+        if !success {
+            failed.push(name.as_str());
+        }
+        merged.insert(
+            name.clone(),
+            org_result(
+                success,
+                &String::from_utf8_lossy(&output.stdout),
+                &String::from_utf8_lossy(&output.stderr),
+                cfg.agent_mode,
+            ),
+        );
+    }
+
+    formatter::output(cfg, &serde_json::Value::Object(merged))?;
+    if !failed.is_empty() {
+        bail!(
+            "{} of {} {} failed: {}",
+            failed.len(),
+            names.len(),
+            match target {
+                Target::Org => "orgs",
+                Target::Profile => "profiles",
+            },
+            failed.join(", ")
+        );
+    }
+    Ok(())
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn run(
+    _cfg: &Config,
+    _target: Target,
+    _names: &str,
+    _concurrency: usize,
+    _inherited: &Inherited,
+    _command: &[String],
+) -> Result<()> {
+    bail!("pup fanout is not available in WASM builds — subprocesses are not supported.")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn cfg() -> Config {
+        crate::test_utils::config()
+    }
+
+    fn args(v: &[&str]) -> Vec<String> {
+        v.iter().map(|s| s.to_string()).collect()
+    }
+
+    #[test]
+    fn test_parse_targets() {
+        assert_eq!(
+            parse_targets(Target::Org, "prod-us, prod-eu,,prod-us").unwrap(),
+            vec!["prod-us", "prod-eu"]
+        );
+        assert!(parse_targets(Target::Profile, " , ").is_err());
+    }
+
+    #[test]
+    fn test_validate_command() {
+        assert!(validate_command(&args(&["monitors", "list", "--tags=env:prod"])).is_ok());
+        assert!(validate_command(&[]).is_err());
+        assert!(validate_command(&args(&["fanout", "--orgs", "a"])).is_err());
+        assert!(validate_command(&args(&["monitors", "list", "--org=x"])).is_err());
+        assert!(validate_command(&args(&["monitors", "list", "-o", "table"])).is_err());
+        assert!(validate_command(&args(&["monitors", "list", "--profile", "x"])).is_err());
+        assert!(validate_command(&args(&["monitors", "list", "--timeout=5s"])).is_err());
+    }
+
+    #[test]
+    fn test_child_args() {
+        let mut c = cfg();
+        let none = Inherited::default();
+        assert_eq!(
+            child_args(
+                &c,
+                Target::Org,
+                "prod-eu",
+                &none,
+                &args(&["monitors", "list"])
+            ),
+            args(&[
+                "--output",
+                "json",
+                "--max-output-bytes",
+                "0",
+                "--org",
+                "prod-eu",
+                "monitors",
+                "list"
+            ])
+        );
+        c.agent_mode = true;
+        c.auto_approve = true;
+        assert_eq!(
+            child_args(
+                &c,
+                Target::Org,
+                DEFAULT_ORG,
+                &none,
+                &args(&["slos", "list"])
+            ),
+            args(&[
+                "--output",
+                "json",
+                "--max-output-bytes",
+                "0",
+                "--agent",
+                "--yes",
+                "slos",
+                "list"
+            ])
+        );
+        let inherited = Inherited {
+            profile: Some("staging".into()),
+            timeout: Some("30s".into()),
+            connect_timeout: Some("5s".into()),
+        };
+        c.agent_mode = false;
+        c.auto_approve = false;
+        assert_eq!(
+            child_args(&c, Target::Org, "eu", &inherited, &args(&["slos", "list"])),
+            args(&[
+                "--output",
+                "json",
+                "--max-output-bytes",
+                "0",
+                "--org",
+                "eu",
+                "--profile",
+                "staging",
+                "--timeout",
+                "30s",
+                "--connect-timeout",
+                "5s",
+                "slos",
+                "list"
+            ])
+        );
+        // Each profile is its own child; "default" is an ordinary profile name.
+        let timeout = Inherited {
+            timeout: Some("30s".into()),
+            ..Inherited::default()
+        };
+        assert_eq!(
+            child_args(
+                &c,
+                Target::Profile,
+                DEFAULT_ORG,
+                &timeout,
+                &args(&["slos", "list"])
+            ),
+            args(&[
+                "--output",
+                "json",
+                "--max-output-bytes",
+                "0",
+                "--profile",
+                "default",
+                "--timeout",
+                "30s",
+                "slos",
+                "list"
+            ])
+        );
+    }
+
+    #[test]
+    fn test_org_result() {
+        assert_eq!(
+            org_result(true, "[{\"id\": 1}]\n", "", false),
+            serde_json::json!([{"id": 1}])
+        );
+        assert_eq!(
+            org_result(true, r#"{"status": "success", "data": [1]}"#, "", true),
+            serde_json::json!([1])
+        );
+        assert_eq!(
+            org_result(true, "Monitor 1 deleted.\n", "", false),
+            serde_json::json!("Monitor 1 deleted.")
+        );
+        assert_eq!(
+            org_result(false, "", "Error: no valid session\n", false),
+            serde_json::json!({"error": "Error: no valid session"})
+        );
+        assert_eq!(org_result(true, "", "", false), serde_json::Value::Null);
+    }
+}
@@ -0,0 +1,312 @@
+//! `pup find`: one name search across monitors, dashboards, SLOs, notebooks,
+//! and synthetic tests, run in parallel.
+//!
+//! Every result is filtered locally by case-insensitive substring, so the
+//! server-side filters (which differ per API) only narrow what is fetched. A
+//! resource type that fails (e.g. a missing scope) is reported as a warning
+//! and the others are still returned.
+
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+use crate::formatter;
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum ResourceType {
+    Monitor,
+    Dashboard,
+    Slo,
+    Notebook,
+    Synthetic,
+}
+
+pub const ALL_TYPES: &[ResourceType] = &[
+    ResourceType::Monitor,
+    ResourceType::Dashboard,
+    ResourceType::Slo,
+    ResourceType::Notebook,
+    ResourceType::Synthetic,
+];
+
+impl ResourceType {
+    fn name(self) -> &'static str {
+        match self {
+            ResourceType::Monitor => "monitor",
+            ResourceType::Dashboard => "dashboard",
+            ResourceType::Slo => "slo",
+            ResourceType::Notebook => "notebook",
+            ResourceType::Synthetic => "synthetic",
+        }
+    }
+
+    fn parse(s: &str) -> Result<Self> {
+        match s.trim().trim_end_matches('s') {
+            "monitor" => Ok(ResourceType::Monitor),
+            "dashboard" => Ok(ResourceType::Dashboard),
+            "slo" => Ok(ResourceType::Slo),
+            "notebook" => Ok(ResourceType::Notebook),
+            "synthetic" | "test" => Ok(ResourceType::Synthetic),
+            _ => bail!(
+                "invalid type {s:?} (expected monitor, dashboard, slo, notebook, or synthetic)"
+            ),
+        }
+    }
+
+    /// List endpoint and server-side name filter.
+    fn request(self, query: &str) -> (&'static str, Vec<(&'static str, String)>) {
+        match self {
+            ResourceType::Monitor => ("/api/v1/monitor", vec![("name", query.to_string())]),
+            // Neither endpoint filters by name, so every summary is fetched.
+            ResourceType::Dashboard => ("/api/v1/dashboard", vec![]),
+            ResourceType::Synthetic => ("/api/v1/synthetics/tests", vec![]),
+            ResourceType::Slo => ("/api/v1/slo", vec![("query", query.to_string())]),
+            ResourceType::Notebook => ("/api/v1/notebooks", vec![("query", query.to_string())]),
+        }
+    }
+}
+
+/// Parse `--type monitor,dashboard`; empty means every type.
+pub fn parse_types(types: &[String]) -> Result<Vec<ResourceType>> {
+    if types.is_empty() {
+        return Ok(ALL_TYPES.to_vec());
+    }
+    let mut out = Vec::new();
+    for t in types.iter().flat_map(|t| t.split(',')) {
+        let t = ResourceType::parse(t)?;
+        if !out.contains(&t) {
+            out.push(t);
+        }
+    }
+    Ok(out)
+}
+
+fn id_text(v: &serde_json::Value) -> Option<String> {
+    match v {
+        serde_json::Value::String(s) => Some(s.clone()),
+        serde_json::Value::Number(n) => Some(n.to_string()),
+        _ => None,
+    }
+}
+
+/// Items in one type's response whose name contains `query`, as
+/// `{type, id, name, url}` rows with deep links under `app_url`.
+pub fn matches(
+    kind: ResourceType,
+    resp: &serde_json::Value,
+    query: &str,
+    app_url: &str,
+) -> Vec<serde_json::Value> {
+    let (items, id_key, name_ptr) = match kind {
+        ResourceType::Monitor => (resp, "id", "/name"),
+        ResourceType::Dashboard => (&resp["dashboards"], "id", "/title"),
+        ResourceType::Slo => (&resp["data"], "id", "/name"),
+        ResourceType::Notebook => (&resp["data"], "id", "/attributes/name"),
+        ResourceType::Synthetic => (&resp["tests"], "public_id", "/name"),
+    };
+    let needle = query.to_lowercase();
+    let mut out = Vec::new();
+    for item in items.as_array().into_iter().flatten() {
+        let (Some(id), Some(name)) = (
+            id_text(&item[id_key]),
+            item.pointer(name_ptr).and_then(|n| n.as_str()),
+        ) else {
+            continue;
+        };
+        if !name.to_lowercase().contains(&needle) {
+            continue;
+        }
+        let path = match kind {
+            ResourceType::Monitor => format!("/monitors/{id}"),
+            ResourceType::Dashboard => item["url"]
+                .as_str()
+                .map(str::to_string)
+                .unwrap_or_else(|| format!("/dashboard/{id}")),
+            ResourceType::Slo => format!("/slo?slo_id={id}"),
+            ResourceType::Notebook => format!("/notebook/{id}"),
+            ResourceType::Synthetic => format!("/synthetics/details/{id}"),
+        };
+        out.push(serde_json::json!({
+            "type": kind.name(),
+            "id": id,
+            "name": name,
+            "url": format!("{app_url}{path}"),
+        }));
+    }
+    out
+}
+
+/// Exact (case-insensitive) name matches first, then names starting with the
+/// query, then the rest; ties keep type order and sort by name.
+pub fn rank(rows: &mut [serde_json::Value], query: &str) {
+    let needle = query.to_lowercase();
+    let tier = |row: &serde_json::Value| {
+        let name = row["name"].as_str().unwrap_or_default().to_lowercase();
+        if name == needle {
+            0
+        } else if name.starts_with(&needle) {
+            1
+        } else {
+            2
+        }
+    };
+    let type_pos = |row: &serde_json::Value| ALL_TYPES.iter().position(|t| row["type"] == t.name());
+    rows.sort_by(|a, b| {
+        (
+            tier(a),
+            type_pos(a),
+            a["name"].as_str().map(str::to_lowercase),
+        )
+            .cmp(&(
+                tier(b),
+                type_pos(b),
+                b["name"].as_str().map(str::to_lowercase),
+            ))
+    });
+}
+
+async fn search_one(cfg: &Config, kind: ResourceType, query: &str) -> Result<serde_json::Value> {
+    let (path, params) = kind.request(query);
+    crate::api::get(cfg, path, &params)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to search {}s", kind.name()), e))
+}
+
+/// Search every type at once, returning results in `types` order.
+#[cfg(not(target_arch = "wasm32"))]
+async fn search_all(
+    cfg: &Config,
+    types: &[ResourceType],
+    query: &str,
+) -> Vec<(ResourceType, Result<serde_json::Value>)> {
+    let mut set = tokio::task::JoinSet::new();
+    for (i, &kind) in types.iter().enumerate() {
+        let cfg = cfg.clone();
+        let query = query.to_string();
+        set.spawn(async move { (i, kind, search_one(&cfg, kind, &query).await) });
+    }
+    let mut results = Vec::new();
+    while let Some(joined) = set.join_next().await {
+        results.push(joined.expect("search task panicked"));
+    }
+    results.sort_by_key(|(i, _, _)| *i);
+    results.into_iter().map(|(_, k, r)| (k, r)).collect()
+}
+
+/// The browser runtime is single-threaded; search sequentially.
+#[cfg(target_arch = "wasm32")]
+async fn search_all(
+    cfg: &Config,
+    types: &[ResourceType],
+    query: &str,
+) -> Vec<(ResourceType, Result<serde_json::Value>)> {
+    let mut results = Vec::new();
+    for &kind in types {
+        results.push((kind, search_one(cfg, kind, query).await));
+    }
+    results
+}
+
+pub async fn run(cfg: &Config, query: &str, types: &[String], limit: usize) -> Result<()> {
+    if query.trim().is_empty() {
+        bail!("invalid query: pass a name or part of one");
+    }
+    let types = parse_types(types)?;
+    let results = search_all(cfg, &types, query).await;
+
+    let app_url = cfg.app_base_url();
+    let mut rows = Vec::new();
+    let mut errors = Vec::new();
+    for (kind, result) in results {
+        match result {
+            Ok(resp) => rows.extend(matches(kind, &resp, query, &app_url)),
+            Err(e) => errors.push(e),
+        }
+    }
+    // With nothing to show, fail with the first error so its exit code applies.
+    if errors.len() == types.len() {
+        return Err(errors.remove(0));
+    }
+    for e in &errors {
+        eprintln!("warning: {e:#}");
+    }
+    rank(&mut rows, query);
+    let total = rows.len();
+    if limit > 0 && rows.len() > limit {
+        rows.truncate(limit);
+        eprintln!("showing {limit} of {total} matches; raise --limit to see more");
+    }
+    formatter::output(cfg, &serde_json::Value::Array(rows))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const APP: &str = "https://app.datadoghq.com";
+
+    #[test]
+    fn test_matches_per_type() {
+        let monitors = serde_json::json!([
+            {"id": 1, "name": "Checkout latency"},
+            {"id": 2, "name": "Cart errors"}
+        ]);
+        let rows = matches(ResourceType::Monitor, &monitors, "checkout", APP);
+        assert_eq!(rows.len(), 1);
+        assert_eq!(rows[0]["id"], "1");
+        assert_eq!(rows[0]["url"], "https://app.datadoghq.com/monitors/1");
+
+        let dashboards = serde_json::json!({"dashboards": [
+            {"id": "abc-def", "title": "Checkout Overview", "url": "/dashboard/abc-def/checkout-overview"}
+        ]});
+        let rows = matches(ResourceType::Dashboard, &dashboards, "CHECKOUT", APP);
+        assert_eq!(
+            rows[0]["url"],
+            "https://app.datadoghq.com/dashboard/abc-def/checkout-overview"
+        );
+
+        let tests =
+            serde_json::json!({"tests": [{"public_id": "xyz-123", "name": "Checkout flow"}]});
+        let rows = matches(ResourceType::Synthetic, &tests, "checkout", APP);
+        assert_eq!(rows[0]["type"], "synthetic");
+        assert_eq!(
+            rows[0]["url"],
+            "https://app.datadoghq.com/synthetics/details/xyz-123"
+        );
+
+        let notebooks =
+            serde_json::json!({"data": [{"id": 7, "attributes": {"name": "Checkout RCA"}}]});
+        let rows = matches(ResourceType::Notebook, &notebooks, "rca", APP);
+        assert_eq!(rows[0]["url"], "https://app.datadoghq.com/notebook/7");
+    }
+
+    #[test]
+    fn test_rank_exact_then_prefix_then_type() {
+        let mut rows = vec![
+            serde_json::json!({"type": "slo", "name": "API checkout availability"}),
+            serde_json::json!({"type": "dashboard", "name": "Checkout overview"}),
+            serde_json::json!({"type": "monitor", "name": "Checkout latency"}),
+            serde_json::json!({"type": "notebook", "name": "checkout"}),
+        ];
+        rank(&mut rows, "Checkout");
+        let names: Vec<&str> = rows.iter().map(|r| r["name"].as_str().unwrap()).collect();
+        assert_eq!(
+            names,
+            vec![
+                "checkout",
+                "Checkout latency",
+                "Checkout overview",
+                "API checkout availability"
+            ]
+        );
+    }
+
+    #[test]
+    fn test_parse_types() {
+        assert_eq!(parse_types(&[]).unwrap().len(), ALL_TYPES.len());
+        assert_eq!(
+            parse_types(&["monitors,slo".into(), "monitor".into()]).unwrap(),
+            vec![ResourceType::Monitor, ResourceType::Slo]
+        );
+        assert!(parse_types(&["widget".into()]).is_err());
+    }
+}
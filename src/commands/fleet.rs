@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV2::api_fleet_automation::{
     FleetAutomationAPI, GetFleetDeploymentOptionalParams, ListFleetAgentsOptionalParams,
@@ -7,8 +7,10 @@ use datadog_api_client::datadogV2::api_fleet_automation::{
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
+use serde::Deserialize;
+
 use crate::config::Config;
-use crate::formatter;
+use crate::formatter::{self, Metadata};
 use crate::util;
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -39,6 +41,31 @@ pub async fn agents_list(cfg: &Config, page_size: Option<i64>) -> Result<()> {
     crate::formatter::output(cfg, &data)
 }
 
+/// Page size used when walking every fleet agent page.
+const AGENTS_PAGE_MAX: usize = 100;
+
+/// Agent info requests in flight at once while checking drift.
+const DRIFT_CONCURRENCY: usize = 8;
+
+/// List every fleet agent, following page numbers (`--all`).
+pub async fn agents_list_all_pages(cfg: &Config, max_items: usize) -> Result<()> {
+    let size = util::page_size(AGENTS_PAGE_MAX, max_items);
+    let collected = util::collect_pages(
+        util::Paging::Number { size },
+        "/data/attributes/agents",
+        max_items,
+        |page| {
+            let query = vec![
+                ("page[size]", size.to_string()),
+                ("page[number]", page.number.to_string()),
+            ];
+            async move { crate::api::get(cfg, "/api/v2/fleet/agents", &query).await }
+        },
+    )
+    .await?;
+    util::print_collected(cfg, "fleet agents list", collected)
+}
+
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn agents_get(cfg: &Config, agent_key: &str) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
@@ -328,3 +355,394 @@ pub async fn schedules_trigger(cfg: &Config, schedule_id: &str) -> Result<()> {
     println!("Schedule {schedule_id} triggered.");
     Ok(())
 }
+
+// ---- Policy drift ----
+
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields)]
+struct PolicyFile {
+    rules: Vec<RawRule>,
+}
+
+#[derive(Debug, Deserialize)]
+#[serde(deny_unknown_fields)]
+struct RawRule {
+    name: String,
+    field: String,
+    #[serde(default)]
+    scope: Vec<String>,
+    equals: Option<serde_json::Value>,
+    one_of: Option<Vec<serde_json::Value>>,
+    contains: Option<serde_json::Value>,
+    min_version: Option<String>,
+    pattern: Option<String>,
+}
+
+/// The condition a policy rule holds an agent field to.
+#[derive(Debug)]
+pub enum Check {
+    Equals(serde_json::Value),
+    OneOf(Vec<serde_json::Value>),
+    Contains(serde_json::Value),
+    MinVersion(String),
+    Pattern(regex::Regex),
+}
+
+#[derive(Debug)]
+pub struct PolicyRule {
+    pub name: String,
+    /// Dotted path into the agent's fleet info, e.g. `agent_version`.
+    pub field: String,
+    /// Tags an agent must carry for the rule to apply; empty means every agent.
+    pub scope: Vec<String>,
+    pub check: Check,
+}
+
+pub fn parse_policy(contents: &str) -> Result<Vec<PolicyRule>> {
+    let file: PolicyFile =
+        serde_yaml::from_str(contents).map_err(|e| anyhow::anyhow!("invalid policy file: {e}"))?;
+    if file.rules.is_empty() {
+        bail!("policy file has no rules");
+    }
+    let mut seen = std::collections::HashSet::new();
+    let mut rules = Vec::with_capacity(file.rules.len());
+    for r in file.rules {
+        if r.name.trim().is_empty() {
+            bail!("policy rule names must be non-empty");
+        }
+        if !seen.insert(r.name.clone()) {
+            bail!("duplicate rule name {:?}", r.name);
+        }
+        if r.field.trim().is_empty() {
+            bail!("rule {:?}: field is required", r.name);
+        }
+        let mut checks = Vec::new();
+        if let Some(v) = r.equals {
+            checks.push(Check::Equals(v));
+        }
+        if let Some(v) = r.one_of {
+            checks.push(Check::OneOf(v));
+        }
+        if let Some(v) = r.contains {
+            checks.push(Check::Contains(v));
+        }
+        if let Some(v) = r.min_version {
+            if version_parts(&v).is_empty() {
+                bail!("rule {:?}: invalid min_version {v:?}", r.name);
+            }
+            checks.push(Check::MinVersion(v));
+        }
+        if let Some(p) = r.pattern {
+            let re = regex::Regex::new(&p)
+                .map_err(|e| anyhow::anyhow!("rule {:?}: invalid pattern: {e}", r.name))?;
+            checks.push(Check::Pattern(re));
+        }
+        if checks.len() != 1 {
+            bail!(
+                "rule {:?}: set exactly one of equals, one_of, contains, min_version, pattern",
+                r.name
+            );
+        }
+        rules.push(PolicyRule {
+            name: r.name,
+            field: r.field,
+            scope: r.scope,
+            check: checks.remove(0),
+        });
+    }
+    Ok(rules)
+}
+
+/// Leading numeric components of a version string: "7.52.1-rc.2" → [7, 52, 1].
+fn version_parts(v: &str) -> Vec<u64> {
+    let core = v.trim().trim_start_matches('v');
+    let core = core.split(['-', '+', ' ']).next().unwrap_or("");
+    core.split('.').map_while(|p| p.parse().ok()).collect()
+}
+
+fn version_at_least(actual: &str, min: &str) -> bool {
+    let (a, m) = (version_parts(actual), version_parts(min));
+    if a.is_empty() {
+        return false;
+    }
+    let len = a.len().max(m.len());
+    let pad = |v: &[u64]| {
+        (0..len)
+            .map(|i| v.get(i).copied().unwrap_or(0))
+            .collect::<Vec<_>>()
+    };
+    pad(&a) >= pad(&m)
+}
+
+/// Look up a dotted path; numeric segments index into arrays.
+pub fn field_value<'a>(agent: &'a serde_json::Value, path: &str) -> Option<&'a serde_json::Value> {
+    path.split('.').try_fold(agent, |v, seg| match v {
+        serde_json::Value::Array(items) => items.get(seg.parse::<usize>().ok()?),
+        _ => v.get(seg),
+    })
+}
+
+fn loosely_equal(a: &serde_json::Value, b: &serde_json::Value) -> bool {
+    match (a, b) {
+        (serde_json::Value::String(x), serde_json::Value::String(y)) => x.eq_ignore_ascii_case(y),
+        (serde_json::Value::Number(x), serde_json::Value::Number(y)) => x.as_f64() == y.as_f64(),
+        _ => a == b,
+    }
+}
+
+fn value_text(v: &serde_json::Value) -> String {
+    match v {
+        serde_json::Value::String(s) => s.clone(),
+        other => other.to_string(),
+    }
+}
+
+impl Check {
+    pub fn passes(&self, actual: Option<&serde_json::Value>) -> bool {
+        let Some(actual) = actual.filter(|v| !v.is_null()) else {
+            return false;
+        };
+        match self {
+            Check::Equals(want) => loosely_equal(actual, want),
+            Check::OneOf(allowed) => allowed.iter().any(|w| loosely_equal(actual, w)),
+            Check::Contains(want) => match actual {
+                serde_json::Value::Array(items) => items.iter().any(|i| loosely_equal(i, want)),
+                serde_json::Value::String(s) => s.contains(&value_text(want)),
+                _ => false,
+            },
+            Check::MinVersion(min) => actual.as_str().is_some_and(|a| version_at_least(a, min)),
+            Check::Pattern(re) => re.is_match(&value_text(actual)),
+        }
+    }
+
+    pub fn describe(&self) -> String {
+        match self {
+            Check::Equals(v) => format!("== {}", value_text(v)),
+            Check::OneOf(vs) => format!(
+                "one of {}",
+                vs.iter().map(value_text).collect::<Vec<_>>().join(", ")
+            ),
+            Check::Contains(v) => format!("contains {}", value_text(v)),
+            Check::MinVersion(v) => format!(">= {v}"),
+            Check::Pattern(re) => format!("matches {}", re.as_str()),
+        }
+    }
+}
+
+fn agent_tags(agent: &serde_json::Value) -> Vec<&str> {
+    agent["tags"]
+        .as_array()
+        .map(|t| t.iter().filter_map(|t| t.as_str()).collect())
+        .unwrap_or_default()
+}
+
+fn in_scope(rule: &PolicyRule, agent: &serde_json::Value) -> bool {
+    let tags = agent_tags(agent);
+    rule.scope.iter().all(|s| tags.contains(&s.as_str()))
+}
+
+/// Merge an agent list entry with its agent info response so rules can
+/// address fields from either; `agent_infos` fields are lifted to the top.
+pub fn agent_record(summary: &serde_json::Value, info: &serde_json::Value) -> serde_json::Value {
+    let mut record = summary.as_object().cloned().unwrap_or_default();
+    if let Some(attrs) = info.pointer("/data/attributes").and_then(|a| a.as_object()) {
+        for (k, v) in attrs {
+            if k == "agent_infos" {
+                if let Some(inner) = v.as_object() {
+                    for (ik, iv) in inner {
+                        record.insert(ik.clone(), iv.clone());
+                    }
+                    continue;
+                }
+            }
+            record.insert(k.clone(), v.clone());
+        }
+    }
+    serde_json::Value::Object(record)
+}
+
+/// One row per (rule, agent) pair that fails the rule, ordered by rule.
+pub fn drift_rows(rules: &[PolicyRule], agents: &[serde_json::Value]) -> Vec<serde_json::Value> {
+    let mut rows = Vec::new();
+    for rule in rules {
+        for agent in agents.iter().filter(|a| in_scope(rule, a)) {
+            let actual = field_value(agent, &rule.field);
+            if rule.check.passes(actual) {
+                continue;
+            }
+            rows.push(serde_json::json!({
+                "rule": rule.name,
+                "agent_key": agent["agent_key"].as_str().unwrap_or(""),
+                "hostname": agent["hostname"].as_str().unwrap_or(""),
+                "field": rule.field,
+                "expected": rule.check.describe(),
+                "actual": actual.cloned().unwrap_or(serde_json::Value::Null),
+            }));
+        }
+    }
+    rows
+}
+
+/// Check every fleet agent against a policy file and list violations.
+pub async fn agents_drift(cfg: &Config, policy: &str, max_items: usize) -> Result<()> {
+    let contents = std::fs::read_to_string(policy)
+        .map_err(|e| anyhow::anyhow!("failed to read {policy}: {e}"))?;
+    let rules = parse_policy(&contents)?;
+
+    let size = util::page_size(AGENTS_PAGE_MAX, max_items);
+    let collected = util::collect_pages(
+        util::Paging::Number { size },
+        "/data/attributes/agents",
+        max_items,
+        |page| {
+            let query = vec![
+                ("page[size]", size.to_string()),
+                ("page[number]", page.number.to_string()),
+            ];
+            async move { crate::api::get(cfg, "/api/v2/fleet/agents", &query).await }
+        },
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to list fleet agents", e))?;
+
+    let summaries: Vec<serde_json::Value> = collected
+        .items
+        .into_iter()
+        .filter(|s| s["agent_key"].is_string())
+        .collect();
+    let shared = std::sync::Arc::new(cfg.clone());
+    let fetched = util::run_bounded(summaries, DRIFT_CONCURRENCY, |summary| {
+        let cfg = shared.clone();
+        async move {
+            let key = summary["agent_key"].as_str().unwrap_or_default();
+            let info = crate::api::get(&cfg, &format!("/api/v2/fleet/agents/{key}"), &[])
+                .await
+                .map_err(|e| crate::api::failed(format!("failed to get fleet agent {key}"), e))?;
+            Ok::<_, anyhow::Error>(agent_record(&summary, &info))
+        }
+    })
+    .await;
+    let agents = fetched.into_iter().collect::<Result<Vec<_>>>()?;
+
+    let rows = drift_rows(&rules, &agents);
+    let failing: std::collections::HashSet<&str> = rows
+        .iter()
+        .filter_map(|r| r["agent_key"].as_str())
+        .collect();
+    eprintln!(
+        "{} of {} agents violate at least one of {} rules.",
+        failing.len(),
+        agents.len(),
+        rules.len()
+    );
+    let meta = Metadata {
+        count: Some(rows.len()),
+        truncated: collected.truncated,
+        command: Some("fleet agents drift".into()),
+        next_action: collected.truncated.then(|| {
+            "Stopped at --max-items; raise it (0 removes the cap) to check every agent".into()
+        }),
+    };
+    formatter::format_and_print(&rows, cfg, Some(&meta))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    const POLICY: &str = r#"
+rules:
+  - name: agent-version
+    field: agent_version
+    min_version: "7.60.0"
+  - name: logs-enabled
+    field: config.logs_enabled
+    equals: true
+  - name: apm-on-prod
+    scope: [env:prod]
+    field: enabled_products
+    contains: apm
+"#;
+
+    #[test]
+    fn test_parse_policy_validates_rules() {
+        let rules = parse_policy(POLICY).unwrap();
+        assert_eq!(rules.len(), 3);
+        assert_eq!(rules[2].scope, vec!["env:prod"]);
+        assert!(parse_policy("rules: []").is_err());
+        let two = "rules:\n  - {name: a, field: os, equals: linux, pattern: lin}";
+        assert!(parse_policy(two).is_err());
+        let none = "rules:\n  - {name: a, field: os}";
+        assert!(parse_policy(none).is_err());
+        let dup =
+            "rules:\n  - {name: a, field: os, equals: x}\n  - {name: a, field: os, equals: y}";
+        assert!(parse_policy(dup).is_err());
+        assert!(parse_policy("rules:\n  - {name: a, field: os, pattern: \"(\"}").is_err());
+    }
+
+    #[test]
+    fn test_version_at_least() {
+        assert!(version_at_least("7.60.0", "7.60.0"));
+        assert!(version_at_least("7.61.1-rc.2", "7.60"));
+        assert!(!version_at_least("7.9.3", "7.60.0"));
+        assert!(!version_at_least("unknown", "7.0.0"));
+    }
+
+    #[test]
+    fn test_drift_rows() {
+        let rules = parse_policy(POLICY).unwrap();
+        let agents = vec![
+            serde_json::json!({
+                "agent_key": "a1", "hostname": "web-1", "agent_version": "7.61.0",
+                "tags": ["env:prod"], "enabled_products": ["apm", "logs"],
+                "config": {"logs_enabled": true}
+            }),
+            serde_json::json!({
+                "agent_key": "a2", "hostname": "web-2", "agent_version": "7.50.0",
+                "tags": ["env:prod"], "enabled_products": ["logs"]
+            }),
+            serde_json::json!({
+                "agent_key": "a3", "hostname": "dev-1", "agent_version": "7.62.0",
+                "tags": ["env:dev"], "config": {"logs_enabled": true}
+            }),
+        ];
+        let rows = drift_rows(&rules, &agents);
+        let got: Vec<(&str, &str)> = rows
+            .iter()
+            .map(|r| {
+                (
+                    r["rule"].as_str().unwrap(),
+                    r["agent_key"].as_str().unwrap(),
+                )
+            })
+            .collect();
+        assert_eq!(
+            got,
+            vec![
+                ("agent-version", "a2"),
+                ("logs-enabled", "a2"),
+                ("apm-on-prod", "a2")
+            ]
+        );
+        assert_eq!(rows[0]["expected"], ">= 7.60.0");
+        assert_eq!(rows[0]["actual"], "7.50.0");
+        assert!(rows[1]["actual"].is_null());
+    }
+
+    #[test]
+    fn test_agent_record_lifts_agent_infos() {
+        let summary = serde_json::json!({"agent_key": "a1", "hostname": "web-1"});
+        let info = serde_json::json!({"data": {"attributes": {
+            "agent_infos": {"agent_version": "7.60.0"},
+            "configuration_files": []
+        }}});
+        let record = agent_record(&summary, &info);
+        assert_eq!(record["agent_version"], "7.60.0");
+        assert_eq!(record["hostname"], "web-1");
+        assert!(record["configuration_files"].is_array());
+        assert_eq!(
+            field_value(&serde_json::json!({"a": [{"b": 1}]}), "a.0.b").unwrap(),
+            1
+        );
+    }
+}
@@ -8,7 +8,7 @@ use datadog_api_client::datadogV2::api_incidents::{
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
-use crate::config::Config;
+use crate::config::{Config, OutputFormat};
 use crate::formatter;
 use crate::util;
 
@@ -68,6 +68,353 @@ pub async fn get(cfg: &Config, incident_id: &str) -> Result<()> {
     crate::formatter::output(cfg, &data)
 }
 
+// ---------------------------------------------------------------------------
+// Filtered list (incidents search)
+// ---------------------------------------------------------------------------
+
+/// Filters for `pup incidents list`, mapped onto the incidents search query.
+#[derive(Debug, Default)]
+pub struct IncidentFilters {
+    /// active, stable, or resolved; any of them match.
+    pub states: Vec<String>,
+    /// `SEV-2`, `sev2`, or `2`; any of them match.
+    pub severities: Vec<String>,
+    pub customer_impacted: Option<bool>,
+    /// Created at or after this time (`7d`, RFC3339, ...).
+    pub from: Option<String>,
+    /// Created at or before this time.
+    pub to: Option<String>,
+    /// Free text and any other search facets, ANDed with the rest.
+    pub query: Option<String>,
+}
+
+impl IncidentFilters {
+    pub fn is_empty(&self) -> bool {
+        self.states.is_empty()
+            && self.severities.is_empty()
+            && self.customer_impacted.is_none()
+            && self.from.is_none()
+            && self.to.is_none()
+            && self.query.is_none()
+    }
+}
+
+/// Paging and order for the search path of `pup incidents list`.
+#[derive(Debug)]
+pub struct SearchPage {
+    /// `created` (oldest first) or `-created` (newest first).
+    pub sort: String,
+    pub limit: i64,
+    /// 0-based page number.
+    pub page: i64,
+    pub all: bool,
+    pub max_items: usize,
+}
+
+/// Largest page the incidents search endpoint returns.
+const SEARCH_PAGE_MAX: i64 = 100;
+
+fn any_of(facet: &str, values: &[String]) -> String {
+    match values {
+        [one] => format!("{facet}:{one}"),
+        _ => format!("{facet}:({})", values.join(" OR ")),
+    }
+}
+
+fn rfc3339(input: &str) -> Result<String> {
+    let secs = util::parse_time_to_unix(input)?;
+    let Some(time) = chrono::DateTime::from_timestamp(secs, 0) else {
+        bail!("time out of range: {input:?}");
+    };
+    Ok(time.to_rfc3339_opts(chrono::SecondsFormat::Secs, true))
+}
+
+/// Incidents search query for the filters, e.g.
+/// `state:(active OR stable) AND severity:SEV-1 AND customer_impacted:true`.
+/// With no state filter every state matches, since the search requires one.
+pub fn search_query(filters: &IncidentFilters) -> Result<String> {
+    let mut states = Vec::new();
+    for state in &filters.states {
+        let state = state.trim().to_lowercase();
+        if !matches!(state.as_str(), "active" | "stable" | "resolved") {
+            bail!("invalid status {state:?} (expected active, stable, or resolved)");
+        }
+        states.push(state);
+    }
+    if states.is_empty() {
+        states = vec!["active".into(), "stable".into(), "resolved".into()];
+    }
+    let mut parts = vec![any_of("state", &states)];
+    if !filters.severities.is_empty() {
+        let severities = filters
+            .severities
+            .iter()
+            .map(|s| api_severity(s.trim()))
+            .collect::<Result<Vec<_>>>()?;
+        parts.push(any_of("severity", &severities));
+    }
+    if let Some(impacted) = filters.customer_impacted {
+        parts.push(format!("customer_impacted:{impacted}"));
+    }
+    if filters.from.is_some() || filters.to.is_some() {
+        let from = filters.from.as_deref().map(rfc3339).transpose()?;
+        let to = filters.to.as_deref().map(rfc3339).transpose()?;
+        parts.push(format!(
+            "created:[{} TO {}]",
+            from.as_deref().unwrap_or("*"),
+            to.as_deref().unwrap_or("*")
+        ));
+    }
+    if let Some(q) = filters.query.as_deref().map(str::trim) {
+        if !q.is_empty() {
+            parts.push(q.to_string());
+        }
+    }
+    Ok(parts.join(" AND "))
+}
+
+/// `created`/`oldest` or `-created`/`newest`.
+pub fn search_sort(input: &str) -> Result<&'static str> {
+    match input.to_lowercase().as_str() {
+        "created" | "oldest" | "asc" => Ok("created"),
+        "-created" | "newest" | "desc" => Ok("-created"),
+        _ => bail!("invalid sort {input:?} (expected created or -created)"),
+    }
+}
+
+/// Search results wrap each incident as `{"data": {...}}`; unwrap them so
+/// the output matches the plain list endpoint.
+fn unwrap_incident(item: &serde_json::Value) -> serde_json::Value {
+    item.get("data").unwrap_or(item).clone()
+}
+
+async fn search_page(
+    cfg: &Config,
+    query: &str,
+    sort: &str,
+    size: i64,
+    offset: i64,
+) -> Result<serde_json::Value> {
+    let params = [
+        ("query", query.to_string()),
+        ("sort", sort.to_string()),
+        ("page[size]", size.to_string()),
+        ("page[offset]", offset.to_string()),
+    ];
+    crate::api::get(cfg, "/api/v2/incidents/search", &params)
+        .await
+        .map_err(|e| crate::api::failed("failed to search incidents", e))
+}
+
+/// List incidents matching `filters` through the search endpoint.
+pub async fn search(cfg: &Config, filters: &IncidentFilters, page: SearchPage) -> Result<()> {
+    let query = search_query(filters)?;
+    let sort = search_sort(&page.sort)?;
+    if page.all {
+        let size = util::page_size(SEARCH_PAGE_MAX as usize, page.max_items);
+        let query = query.as_str();
+        let mut collected = util::collect_pages(
+            util::Paging::Number { size },
+            "/data/attributes/incidents",
+            page.max_items,
+            |req| async move {
+                let offset = (req.number * size) as i64;
+                search_page(cfg, query, sort, size as i64, offset).await
+            },
+        )
+        .await?;
+        collected.items = collected.items.iter().map(unwrap_incident).collect();
+        return util::print_collected(cfg, "incidents list", collected);
+    }
+    let size = page.limit.clamp(1, SEARCH_PAGE_MAX);
+    let offset = page.page.max(0) * size;
+    let resp = search_page(cfg, &query, sort, size, offset).await?;
+    let incidents: Vec<serde_json::Value> = resp["data"]["attributes"]["incidents"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .map(unwrap_incident)
+        .collect();
+    let total = resp["data"]["attributes"]["total"].as_i64();
+    if let Some(total) = total {
+        let shown = offset + incidents.len() as i64;
+        if shown < total && !cfg.agent_mode {
+            eprintln!(
+                "Showing {} of {total} incidents; use --page {} or --all for more.",
+                incidents.len(),
+                page.page.max(0) + 1
+            );
+        }
+    }
+    formatter::output(
+        cfg,
+        &serde_json::json!({ "data": incidents, "meta": { "query": query, "total": total } }),
+    )
+}
+
+// ---------------------------------------------------------------------------
+// Create / update
+// ---------------------------------------------------------------------------
+
+/// Inputs for `pup incidents create` and `pup incidents update`.
+#[derive(Debug, Default)]
+pub struct IncidentChanges {
+    pub title: Option<String>,
+    /// `SEV-2`, `sev2`, or `2`.
+    pub severity: Option<String>,
+    /// active, stable, or resolved.
+    pub state: Option<String>,
+    /// User UUID, email, or handle.
+    pub commander: Option<String>,
+    pub customer_impacted: Option<bool>,
+    pub customer_impact_scope: Option<String>,
+}
+
+/// The API's severity value (`SEV-2`) for `sev2`, `SEV-2`, `2`, or `unknown`.
+pub fn api_severity(input: &str) -> Result<String> {
+    let severity = normalize_severity(input);
+    if severity == "unknown" {
+        return Ok("UNKNOWN".into());
+    }
+    match severity.strip_prefix("sev") {
+        Some(n @ ("1" | "2" | "3" | "4" | "5")) => Ok(format!("SEV-{n}")),
+        _ => bail!("invalid severity {input:?} (expected SEV-1 through SEV-5 or UNKNOWN)"),
+    }
+}
+
+/// Request body for create (`id` None) or update. `commander_id` is a resolved user UUID.
+pub fn incident_body(
+    changes: &IncidentChanges,
+    commander_id: Option<&str>,
+    id: Option<&str>,
+) -> Result<serde_json::Value> {
+    let mut attrs = serde_json::json!({});
+    if let Some(title) = &changes.title {
+        attrs["title"] = title.as_str().into();
+    }
+    let mut fields = serde_json::Map::new();
+    if let Some(severity) = &changes.severity {
+        fields.insert(
+            "severity".into(),
+            serde_json::json!({"type": "dropdown", "value": api_severity(severity)?}),
+        );
+    }
+    if let Some(state) = &changes.state {
+        let state = state.to_lowercase();
+        if !matches!(state.as_str(), "active" | "stable" | "resolved") {
+            bail!("invalid status {state:?} (expected active, stable, or resolved)");
+        }
+        fields.insert(
+            "state".into(),
+            serde_json::json!({"type": "dropdown", "value": state}),
+        );
+    }
+    if !fields.is_empty() {
+        attrs["fields"] = fields.into();
+    }
+    // An impact scope only makes sense for a customer-impacting incident, so it
+    // wins over an explicit or defaulted `false`.
+    let impacted = match changes.customer_impact_scope {
+        Some(_) => Some(true),
+        None => changes.customer_impacted,
+    };
+    if let Some(impacted) = impacted {
+        attrs["customer_impacted"] = impacted.into();
+    }
+    if let Some(scope) = &changes.customer_impact_scope {
+        attrs["customer_impact_scope"] = scope.as_str().into();
+    }
+
+    let mut data = serde_json::json!({"type": "incidents", "attributes": attrs});
+    if let Some(id) = id {
+        data["id"] = id.into();
+    }
+    if let Some(user) = commander_id {
+        data["relationships"] = serde_json::json!({
+            "commander_user": {"data": {"type": "users", "id": user}}
+        });
+    }
+    Ok(serde_json::json!({ "data": data }))
+}
+
+/// ID of the user in a `/api/v2/users` response whose email or handle is `who`.
+pub fn find_user_id(resp: &serde_json::Value, who: &str) -> Option<String> {
+    resp.get("data")?.as_array()?.iter().find_map(|user| {
+        let attrs = user.get("attributes")?;
+        let matches = ["email", "handle"].iter().any(|field| {
+            attrs
+                .get(*field)
+                .and_then(|v| v.as_str())
+                .is_some_and(|v| v.eq_ignore_ascii_case(who))
+        });
+        if matches {
+            json_str(user, "/id")
+        } else {
+            None
+        }
+    })
+}
+
+async fn resolve_user_id(cfg: &Config, who: &str) -> Result<String> {
+    if uuid::Uuid::parse_str(who).is_ok() {
+        return Ok(who.to_string());
+    }
+    let who = who.trim_start_matches('@');
+    let resp = crate::api::get(cfg, "/api/v2/users", &[("filter", who.to_string())])
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to look up user {who:?}"), e))?;
+    match find_user_id(&resp, who) {
+        Some(id) => Ok(id),
+        None => bail!("no user with email or handle {who:?}"),
+    }
+}
+
+async fn commander_id(cfg: &Config, changes: &IncidentChanges) -> Result<Option<String>> {
+    match &changes.commander {
+        Some(who) => Ok(Some(resolve_user_id(cfg, who).await?)),
+        None => Ok(None),
+    }
+}
+
+/// Declare a new incident.
+pub async fn create(cfg: &Config, mut changes: IncidentChanges) -> Result<()> {
+    if changes
+        .title
+        .as_deref()
+        .map_or(true, |t| t.trim().is_empty())
+    {
+        bail!("--title is required");
+    }
+    // The API requires customer_impacted on create; incident_body turns it on
+    // when an impact scope is given.
+    changes.customer_impacted.get_or_insert(false);
+    let commander = commander_id(cfg, &changes).await?;
+    let body = incident_body(&changes, commander.as_deref(), None)?;
+    let resp = crate::api::post(cfg, "/api/v2/incidents", &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create incident", e))?;
+    formatter::output(cfg, &resp)
+}
+
+/// Change an incident's title, severity, status, commander, or customer impact.
+pub async fn update(cfg: &Config, incident_id: &str, changes: IncidentChanges) -> Result<()> {
+    let commander = commander_id(cfg, &changes).await?;
+    let body = incident_body(&changes, commander.as_deref(), Some(incident_id))?;
+    let no_attributes = body["data"]["attributes"]
+        .as_object()
+        .is_some_and(|a| a.is_empty());
+    if no_attributes && commander.is_none() {
+        bail!(
+            "nothing to update: pass --status, --severity, --title, --commander, \
+             or --customer-impact-scope"
+        );
+    }
+    let resp = crate::api::patch(cfg, &format!("/api/v2/incidents/{incident_id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update incident", e))?;
+    formatter::output(cfg, &resp)
+}
+
 // ---------------------------------------------------------------------------
 // Attachments
 // ---------------------------------------------------------------------------
@@ -104,7 +451,7 @@ pub async fn attachments_delete(
         incident_id,
         attachment_id
     );
-    let client = reqwest::Client::new();
+    let client = crate::client::http_client();
     let mut req = client.delete(&url);
 
     if let Some(token) = &cfg.access_token {
@@ -117,7 +464,7 @@ pub async fn attachments_delete(
         bail!("no authentication configured");
     }
 
-    let resp = req.header("Accept", "application/json").send().await?;
+    let resp = crate::http_debug::send(req.header("Accept", "application/json")).await?;
     if !resp.status().is_success() {
         let status = resp.status();
         let body = resp.text().await.unwrap_or_default();
@@ -351,3 +698,1338 @@ pub async fn postmortem_templates_delete(cfg: &Config, template_id: &str) -> Res
     println!("Postmortem template {template_id} deleted.");
     Ok(())
 }
+
+// ---------------------------------------------------------------------------
+// Postmortem export
+// ---------------------------------------------------------------------------
+
+/// Everything needed to render an incident postmortem document.
+#[derive(Debug, Default)]
+pub struct IncidentExport {
+    pub id: String,
+    pub public_id: Option<String>,
+    pub title: String,
+    pub severity: Option<String>,
+    pub state: Option<String>,
+    pub commander: Option<String>,
+    pub created: Option<String>,
+    pub detected: Option<String>,
+    pub resolved: Option<String>,
+    pub customer_impacted: bool,
+    pub customer_impact_scope: Option<String>,
+    /// Remaining incident fields (name, value), excluding state/severity.
+    pub fields: Vec<(String, String)>,
+    /// Timeline entries as (timestamp, markdown content).
+    pub timeline: Vec<(String, String)>,
+    pub postmortem_url: Option<String>,
+    /// Postmortem notebook cells rendered as markdown.
+    pub postmortem: Vec<String>,
+}
+
+fn json_str(v: &serde_json::Value, pointer: &str) -> Option<String> {
+    match v.pointer(pointer)? {
+        serde_json::Value::String(s) if !s.is_empty() => Some(s.clone()),
+        serde_json::Value::Number(n) => Some(n.to_string()),
+        _ => None,
+    }
+}
+
+/// Render an incident field value (`{"type": ..., "value": ...}`) as text.
+fn field_value(field: &serde_json::Value) -> Option<String> {
+    match field.get("value")? {
+        serde_json::Value::String(s) if !s.is_empty() => Some(s.clone()),
+        serde_json::Value::Array(items) if !items.is_empty() => Some(
+            items
+                .iter()
+                .map(|i| {
+                    i.as_str()
+                        .map(str::to_string)
+                        .unwrap_or_else(|| i.to_string())
+                })
+                .collect::<Vec<_>>()
+                .join(", "),
+        ),
+        serde_json::Value::Number(n) => Some(n.to_string()),
+        serde_json::Value::Bool(b) => Some(b.to_string()),
+        _ => None,
+    }
+}
+
+/// Build an export model from the incident response (with `include=commander_user`).
+pub fn parse_incident(resp: &serde_json::Value) -> IncidentExport {
+    let data = resp.get("data").unwrap_or(resp);
+    let attrs = data.get("attributes").cloned().unwrap_or_default();
+    let fields = attrs.get("fields").and_then(|f| f.as_object());
+
+    let commander_id = json_str(data, "/relationships/commander_user/data/id");
+    let commander = commander_id.as_ref().and_then(|id| {
+        resp.get("included")?
+            .as_array()?
+            .iter()
+            .find(|inc| inc.get("id").and_then(|v| v.as_str()) == Some(id.as_str()))
+            .and_then(|user| {
+                json_str(user, "/attributes/name").or_else(|| json_str(user, "/attributes/email"))
+            })
+    });
+
+    let from_fields = |name: &str| fields.and_then(|f| f.get(name)).and_then(field_value);
+
+    IncidentExport {
+        id: json_str(data, "/id").unwrap_or_default(),
+        public_id: json_str(&attrs, "/public_id"),
+        title: json_str(&attrs, "/title").unwrap_or_else(|| "Untitled incident".into()),
+        severity: json_str(&attrs, "/severity").or_else(|| from_fields("severity")),
+        state: json_str(&attrs, "/state").or_else(|| from_fields("state")),
+        commander: commander.or(commander_id),
+        created: json_str(&attrs, "/created"),
+        detected: json_str(&attrs, "/detected"),
+        resolved: json_str(&attrs, "/resolved"),
+        customer_impacted: attrs
+            .get("customer_impacted")
+            .and_then(|v| v.as_bool())
+            .unwrap_or(false),
+        customer_impact_scope: json_str(&attrs, "/customer_impact_scope"),
+        fields: fields
+            .map(|f| {
+                f.iter()
+                    .filter(|(k, _)| k.as_str() != "state" && k.as_str() != "severity")
+                    .filter_map(|(k, v)| field_value(v).map(|val| (k.clone(), val)))
+                    .collect()
+            })
+            .unwrap_or_default(),
+        ..Default::default()
+    }
+}
+
+/// Extract (timestamp, content) pairs from a timeline cells response.
+pub fn parse_timeline(resp: &serde_json::Value) -> Vec<(String, String)> {
+    parse_timeline_entries(resp)
+        .into_iter()
+        .map(|e| (e.created, e.content))
+        .collect()
+}
+
+/// Find the postmortem attachment's document URL, if any.
+pub fn postmortem_url(attachments: &serde_json::Value) -> Option<String> {
+    attachments
+        .get("data")?
+        .as_array()?
+        .iter()
+        .find(|a| {
+            a.pointer("/attributes/attachment_type")
+                .and_then(|t| t.as_str())
+                == Some("postmortem")
+        })
+        .and_then(|a| json_str(a, "/attributes/attachment/documentUrl"))
+}
+
+/// Notebook ID from a URL such as `https://app.datadoghq.com/notebook/12345/title`.
+pub fn notebook_id_from_url(url: &str) -> Option<i64> {
+    let mut parts = url.split('/');
+    parts.find(|p| *p == "notebook" || *p == "notebooks")?;
+    parts.next()?.parse().ok()
+}
+
+/// Render notebook cells as markdown; non-markdown widgets become placeholders.
+pub fn notebook_cells_markdown(notebook: &serde_json::Value) -> Vec<String> {
+    notebook
+        .pointer("/data/attributes/cells")
+        .and_then(|c| c.as_array())
+        .map(|cells| {
+            cells
+                .iter()
+                .filter_map(|cell| {
+                    let def = cell.pointer("/attributes/definition")?;
+                    match def.get("type").and_then(|t| t.as_str()) {
+                        Some("markdown") => json_str(def, "/text"),
+                        Some(other) => {
+                            let title = json_str(def, "/title")
+                                .map(|t| format!(": {t}"))
+                                .unwrap_or_default();
+                            Some(format!("_[{other} widget{title} — see notebook]_"))
+                        }
+                        None => None,
+                    }
+                })
+                .collect()
+        })
+        .unwrap_or_default()
+}
+
+fn summary_rows(e: &IncidentExport) -> Vec<(&'static str, String)> {
+    let mut rows = vec![(
+        "Incident",
+        e.public_id.clone().unwrap_or_else(|| e.id.clone()),
+    )];
+    let optional = [
+        ("Severity", &e.severity),
+        ("State", &e.state),
+        ("Commander", &e.commander),
+        ("Created", &e.created),
+        ("Detected", &e.detected),
+        ("Resolved", &e.resolved),
+    ];
+    for (label, value) in optional {
+        if let Some(v) = value {
+            rows.push((label, v.clone()));
+        }
+    }
+    rows.push((
+        "Customer impact",
+        match (&e.customer_impacted, &e.customer_impact_scope) {
+            (true, Some(scope)) => format!("yes — {scope}"),
+            (true, None) => "yes".into(),
+            (false, _) => "no".into(),
+        },
+    ));
+    rows
+}
+
+fn md_cell(s: &str) -> String {
+    s.replace('|', "\\|").replace('\n', " ")
+}
+
+/// Render the export as a single Markdown document.
+pub fn render_markdown(e: &IncidentExport) -> String {
+    let mut out = format!(
+        "# {}\n\n## Summary\n\n| Field | Value |\n| --- | --- |\n",
+        e.title
+    );
+    for (label, value) in summary_rows(e) {
+        out.push_str(&format!("| {label} | {} |\n", md_cell(&value)));
+    }
+    for (name, value) in &e.fields {
+        out.push_str(&format!("| {} | {} |\n", md_cell(name), md_cell(value)));
+    }
+
+    out.push_str("\n## Timeline\n\n");
+    if e.timeline.is_empty() {
+        out.push_str("_No timeline entries._\n");
+    }
+    for (at, content) in &e.timeline {
+        out.push_str(&format!(
+            "- **{at}** — {}\n",
+            content.trim().replace('\n', "\n  ")
+        ));
+    }
+
+    out.push_str("\n## Postmortem\n\n");
+    if let Some(url) = &e.postmortem_url {
+        out.push_str(&format!("Source: {url}\n\n"));
+    }
+    if e.postmortem.is_empty() {
+        out.push_str("_No postmortem notebook attached._\n");
+    }
+    for cell in &e.postmortem {
+        out.push_str(cell.trim());
+        out.push_str("\n\n");
+    }
+    out.trim_end().to_string() + "\n"
+}
+
+fn xml_escape(s: &str) -> String {
+    s.replace('&', "&amp;")
+        .replace('<', "&lt;")
+        .replace('>', "&gt;")
+        .replace('"', "&quot;")
+}
+
+/// Markdown body embedded via Confluence's markdown macro (CDATA-safe).
+fn confluence_markdown(md: &str) -> String {
+    format!(
+        "<ac:structured-macro ac:name=\"markdown\"><ac:plain-text-body><![CDATA[{}]]></ac:plain-text-body></ac:structured-macro>",
+        md.replace("]]>", "]]]]><![CDATA[>")
+    )
+}
+
+/// Render the export in Confluence storage format (XHTML).
+pub fn render_confluence(e: &IncidentExport) -> String {
+    let mut out = format!(
+        "<h1>{}</h1>\n<h2>Summary</h2>\n<table><tbody>\n",
+        xml_escape(&e.title)
+    );
+    let rows = summary_rows(e)
+        .into_iter()
+        .map(|(l, v)| (l.to_string(), v))
+        .chain(e.fields.iter().cloned());
+    for (label, value) in rows {
+        out.push_str(&format!(
+            "<tr><th>{}</th><td>{}</td></tr>\n",
+            xml_escape(&label),
+            xml_escape(&value)
+        ));
+    }
+    out.push_str("</tbody></table>\n<h2>Timeline</h2>\n");
+    if e.timeline.is_empty() {
+        out.push_str("<p><em>No timeline entries.</em></p>\n");
+    } else {
+        out.push_str("<table><tbody>\n<tr><th>Time</th><th>Entry</th></tr>\n");
+        for (at, content) in &e.timeline {
+            out.push_str(&format!(
+                "<tr><td>{}</td><td>{}</td></tr>\n",
+                xml_escape(at),
+                confluence_markdown(content.trim())
+            ));
+        }
+        out.push_str("</tbody></table>\n");
+    }
+    out.push_str("<h2>Postmortem</h2>\n");
+    if let Some(url) = &e.postmortem_url {
+        out.push_str(&format!(
+            "<p>Source: <a href=\"{0}\">{0}</a></p>\n",
+            xml_escape(url)
+        ));
+    }
+    if e.postmortem.is_empty() {
+        out.push_str("<p><em>No postmortem notebook attached.</em></p>\n");
+    } else {
+        let md: Vec<&str> = e.postmortem.iter().map(|c| c.trim()).collect();
+        out.push_str(&confluence_markdown(&md.join("\n\n")));
+        out.push('\n');
+    }
+    out
+}
+
+/// Assemble incident metadata, timeline, and the postmortem notebook into one document.
+/// Timeline and postmortem lookups are best-effort: failures are reported on stderr
+/// and the corresponding section is left empty.
+pub async fn export(cfg: &Config, incident_id: &str, format: &str) -> Result<()> {
+    let render: fn(&IncidentExport) -> String = match format.to_lowercase().as_str() {
+        "markdown" | "md" => render_markdown,
+        "confluence" => render_confluence,
+        _ => bail!("invalid export format: {format:?} (expected markdown or confluence)"),
+    };
+
+    let resp = crate::client::raw_get(
+        cfg,
+        &format!("/api/v2/incidents/{incident_id}?include=commander_user"),
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to get incident", e))?;
+    let mut doc = parse_incident(&resp);
+
+    match crate::client::raw_get(cfg, &format!("/api/v2/incidents/{incident_id}/timeline")).await {
+        Ok(timeline) => doc.timeline = parse_timeline(&timeline),
+        Err(e) => eprintln!("warning: could not fetch incident timeline: {e}"),
+    }
+
+    let attachments = crate::client::raw_get(
+        cfg,
+        &format!("/api/v2/incidents/{incident_id}/attachments?filter[attachment_type]=postmortem"),
+    )
+    .await;
+    match attachments {
+        Ok(a) => doc.postmortem_url = postmortem_url(&a),
+        Err(e) => eprintln!("warning: could not fetch incident attachments: {e}"),
+    }
+    if let Some(id) = doc.postmortem_url.as_deref().and_then(notebook_id_from_url) {
+        match crate::client::raw_get(cfg, &format!("/api/v1/notebooks/{id}")).await {
+            Ok(nb) => doc.postmortem = notebook_cells_markdown(&nb),
+            Err(e) => eprintln!("warning: could not fetch postmortem notebook {id}: {e}"),
+        }
+    }
+
+    let document = render(&doc);
+    if cfg.agent_mode {
+        let data = serde_json::json!({
+            "incident_id": incident_id,
+            "format": format.to_lowercase(),
+            "document": document,
+        });
+        return formatter::output(cfg, &data);
+    }
+    print!("{document}");
+    Ok(())
+}
+
+// ---------------------------------------------------------------------------
+// Timeline
+// ---------------------------------------------------------------------------
+
+/// A single incident timeline cell.
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct TimelineEntry {
+    pub id: String,
+    pub created: String,
+    /// Cell type as reported by the API (e.g. `markdown`).
+    pub kind: String,
+    pub content: String,
+}
+
+impl TimelineEntry {
+    /// Identity used to de-duplicate entries across polls. Falls back to
+    /// timestamp + content for cells without an ID.
+    fn key(&self) -> String {
+        if self.id.is_empty() {
+            format!("{}\u{0}{}", self.created, self.content)
+        } else {
+            self.id.clone()
+        }
+    }
+}
+
+/// Parse a timeline cells response into entries sorted by creation time.
+pub fn parse_timeline_entries(resp: &serde_json::Value) -> Vec<TimelineEntry> {
+    let mut entries: Vec<TimelineEntry> = resp
+        .get("data")
+        .and_then(|d| d.as_array())
+        .map(|cells| {
+            cells
+                .iter()
+                .filter_map(|cell| {
+                    let attrs = cell.get("attributes")?;
+                    let content = json_str(attrs, "/content/content")
+                        .or_else(|| json_str(attrs, "/content/message"))?;
+                    Some(TimelineEntry {
+                        id: json_str(cell, "/id").unwrap_or_default(),
+                        created: json_str(attrs, "/created").unwrap_or_default(),
+                        kind: json_str(attrs, "/cell_type").unwrap_or_default(),
+                        content,
+                    })
+                })
+                .collect()
+        })
+        .unwrap_or_default();
+    entries.sort_by(|a, b| a.created.cmp(&b.created));
+    entries
+}
+
+/// Entries not seen in earlier polls; records them as seen.
+pub fn fresh_entries(
+    entries: Vec<TimelineEntry>,
+    seen: &mut std::collections::HashSet<String>,
+) -> Vec<TimelineEntry> {
+    entries
+        .into_iter()
+        .filter(|e| seen.insert(e.key()))
+        .collect()
+}
+
+fn print_timeline_entry(cfg: &Config, entry: &TimelineEntry) -> Result<()> {
+    if cfg.agent_mode || cfg.output_format == OutputFormat::Json {
+        // One object per line so followers can pipe the stream into jq.
+        println!("{}", serde_json::to_string(entry)?);
+        return Ok(());
+    }
+    if cfg.output_format == OutputFormat::Yaml {
+        // One YAML document per entry.
+        print!("---\n{}", formatter::to_yaml(entry)?);
+        return Ok(());
+    }
+    let kind = if entry.kind.is_empty() {
+        String::new()
+    } else {
+        format!(" [{}]", entry.kind)
+    };
+    println!("{}{kind} {}", entry.created, entry.content);
+    Ok(())
+}
+
+async fn fetch_timeline(cfg: &Config, incident_id: &str) -> Result<Vec<TimelineEntry>> {
+    let resp = crate::client::raw_get(cfg, &format!("/api/v2/incidents/{incident_id}/timeline"))
+        .await
+        .map_err(|e| crate::api::failed("failed to get incident timeline", e))?;
+    Ok(parse_timeline_entries(&resp))
+}
+
+/// Print an incident's timeline. With `follow`, keep polling every `interval_secs`
+/// and print entries as they appear until interrupted.
+pub async fn timeline(
+    cfg: &Config,
+    incident_id: &str,
+    follow: bool,
+    interval_secs: u64,
+) -> Result<()> {
+    let entries = fetch_timeline(cfg, incident_id).await?;
+    if !follow {
+        return formatter::output(cfg, &entries);
+    }
+    follow_timeline(cfg, incident_id, entries, interval_secs).await
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn follow_timeline(
+    cfg: &Config,
+    incident_id: &str,
+    initial: Vec<TimelineEntry>,
+    interval_secs: u64,
+) -> Result<()> {
+    let mut seen = std::collections::HashSet::new();
+    for entry in fresh_entries(initial, &mut seen) {
+        print_timeline_entry(cfg, &entry)?;
+    }
+    eprintln!("Following timeline for incident {incident_id} (Ctrl-C to stop)...");
+    let interval = std::time::Duration::from_secs(interval_secs.max(1));
+    loop {
+        tokio::time::sleep(interval).await;
+        // Keep following through transient failures; responders shouldn't lose the stream.
+        match fetch_timeline(cfg, incident_id).await {
+            Ok(entries) => {
+                for entry in fresh_entries(entries, &mut seen) {
+                    print_timeline_entry(cfg, &entry)?;
+                }
+            }
+            Err(e) => eprintln!("warning: {e}"),
+        }
+    }
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn follow_timeline(
+    _cfg: &Config,
+    _incident_id: &str,
+    _initial: Vec<TimelineEntry>,
+    _interval_secs: u64,
+) -> Result<()> {
+    bail!("--follow is not supported in WASM builds")
+}
+
+/// Request body for a markdown timeline cell.
+pub fn timeline_cell_body(content: &str) -> serde_json::Value {
+    serde_json::json!({
+        "data": {
+            "type": "incident_timeline_cells",
+            "attributes": {
+                "cell_type": "markdown",
+                "content": {"content": content}
+            }
+        }
+    })
+}
+
+/// Add a markdown note to an incident's timeline. `-` reads the note from stdin.
+pub async fn timeline_add(cfg: &Config, incident_id: &str, content: &str) -> Result<()> {
+    let content = if content == "-" {
+        std::io::read_to_string(std::io::stdin())?
+    } else {
+        content.to_string()
+    };
+    if content.trim().is_empty() {
+        bail!("--content must not be empty");
+    }
+    let body = timeline_cell_body(content.trim_end());
+    let resp = crate::api::post(
+        cfg,
+        &format!("/api/v2/incidents/{incident_id}/timeline"),
+        &body,
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to add timeline entry", e))?;
+    formatter::output(cfg, &resp)
+}
+
+// ---------------------------------------------------------------------------
+// Jira tickets
+// ---------------------------------------------------------------------------
+
+/// `integration_type` for Jira in incident integration metadata. The API
+/// defines 1 (Slack) and 8 (Jira) only, so there is no ServiceNow equivalent.
+const JIRA_INTEGRATION_TYPE: i64 = 8;
+/// Integration metadata `status` for a ticket Datadog failed to create.
+const INTEGRATION_FAILED: i64 = 4;
+
+/// Request body asking Datadog to open a Jira issue linked to an incident.
+pub fn jira_integration_body(
+    account: &str,
+    project_key: &str,
+    issue_type_id: Option<&str>,
+) -> serde_json::Value {
+    let mut issue = serde_json::json!({ "account": account, "project_key": project_key });
+    if let Some(issue_type_id) = issue_type_id {
+        issue["issuetype_id"] = issue_type_id.into();
+    }
+    serde_json::json!({
+        "data": {
+            "type": "incident_integrations",
+            "attributes": {
+                "integration_type": JIRA_INTEGRATION_TYPE,
+                "metadata": { "issues": [issue] }
+            }
+        }
+    })
+}
+
+/// Where the Jira issue in an integration metadata response stands.
+pub fn integration_ticket_state(resp: &serde_json::Value) -> super::cases::TicketState {
+    use super::cases::TicketState;
+    let attrs = &resp["data"]["attributes"];
+    let issue = &attrs["metadata"]["issues"][0];
+    if let Some(url) = issue["redirect_url"].as_str().filter(|u| !u.is_empty()) {
+        return TicketState::Ready(url.to_string());
+    }
+    if attrs["status"].as_i64() == Some(INTEGRATION_FAILED) {
+        return TicketState::Failed("Datadog could not create the Jira issue".into());
+    }
+    TicketState::Pending
+}
+
+/// Open a Jira issue linked to an incident and print its URL.
+pub async fn create_ticket(
+    cfg: &Config,
+    incident_id: &str,
+    account: &str,
+    project_key: &str,
+    issue_type_id: Option<&str>,
+) -> Result<()> {
+    let path = format!("/api/v2/incidents/{incident_id}/relationships/integrations");
+    let body = jira_integration_body(account, project_key, issue_type_id);
+    let created = crate::api::post(cfg, &path, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create Jira ticket for incident", e))?;
+    let Some(integration_id) = created["data"]["id"].as_str() else {
+        bail!("unexpected response creating the Jira ticket: no integration ID");
+    };
+    let mut state = Some(integration_ticket_state(&created));
+    let url = super::cases::wait_for_ticket(|| {
+        let first = state.take();
+        let path = format!("{path}/{integration_id}");
+        async move {
+            if let Some(state) = first {
+                return Ok(state);
+            }
+            let resp = crate::api::get(cfg, &path, &[])
+                .await
+                .map_err(|e| crate::api::failed("failed to get incident integration", e))?;
+            Ok(integration_ticket_state(&resp))
+        }
+    })
+    .await?;
+    super::cases::ticket_output(
+        cfg,
+        "incident_id",
+        incident_id,
+        "Jira",
+        url,
+        &format!("pup incidents get {incident_id}"),
+    )
+}
+
+// ---------------------------------------------------------------------------
+// Escalation watch
+// ---------------------------------------------------------------------------
+
+/// An `--escalate-after` rule: escalate incidents of `severity` open longer than `after_secs`.
+#[derive(Debug, Clone, PartialEq)]
+pub struct EscalationRule {
+    /// Normalized severity (`sev2`), or `*` for severities without their own rule.
+    pub severity: String,
+    pub after_secs: i64,
+}
+
+/// `SEV-2`, `sev2`, and `2` all name the same severity.
+pub fn normalize_severity(severity: &str) -> String {
+    let s: String = severity
+        .chars()
+        .filter(|c| c.is_ascii_alphanumeric())
+        .collect::<String>()
+        .to_lowercase();
+    if !s.is_empty() && s.chars().all(|c| c.is_ascii_digit()) {
+        format!("sev{s}")
+    } else {
+        s
+    }
+}
+
+/// Parse `--escalate-after` values such as `sev1=15m`, `SEV-2=30m`, or `*=2h`.
+pub fn parse_escalation_rules(specs: &[String]) -> Result<Vec<EscalationRule>> {
+    if specs.is_empty() {
+        bail!("at least one --escalate-after rule is required (e.g. --escalate-after sev2=30m)");
+    }
+    specs
+        .iter()
+        .map(|spec| {
+            let Some((severity, after)) = spec.split_once('=') else {
+                bail!(
+                    "invalid --escalate-after {spec:?} (expected <severity>=<duration>, e.g. sev2=30m)"
+                );
+            };
+            let severity = match severity.trim() {
+                "*" | "any" | "default" => "*".to_string(),
+                s => normalize_severity(s),
+            };
+            if severity.is_empty() {
+                bail!("invalid --escalate-after {spec:?}: missing severity");
+            }
+            Ok(EscalationRule {
+                severity,
+                after_secs: util::parse_duration_secs(after)?,
+            })
+        })
+        .collect()
+}
+
+fn threshold_for(rules: &[EscalationRule], severity: &str) -> Option<i64> {
+    let severity = normalize_severity(severity);
+    rules
+        .iter()
+        .find(|r| r.severity == severity)
+        .or_else(|| rules.iter().find(|r| r.severity == "*"))
+        .map(|r| r.after_secs)
+}
+
+/// An open incident past its severity's escalation threshold.
+#[derive(Debug, Clone, serde::Serialize)]
+pub struct Escalation {
+    pub incident_id: String,
+    pub public_id: Option<String>,
+    pub title: String,
+    pub severity: String,
+    pub state: String,
+    pub age_secs: i64,
+    pub threshold_secs: i64,
+}
+
+impl Escalation {
+    /// Escalated once per severity: a re-classified incident is escalated again.
+    fn key(&self) -> String {
+        format!(
+            "{}:{}",
+            self.incident_id,
+            normalize_severity(&self.severity)
+        )
+    }
+
+    fn label(&self) -> String {
+        match &self.public_id {
+            Some(n) => format!("#{n}"),
+            None => self.incident_id.clone(),
+        }
+    }
+}
+
+/// "45m", "2h5m", "3d4h".
+pub fn format_age(secs: i64) -> String {
+    let (d, h, m) = (secs / 86400, secs % 86400 / 3600, secs % 3600 / 60);
+    match (d, h) {
+        (0, 0) => format!("{m}m"),
+        (0, _) if m == 0 => format!("{h}h"),
+        (0, _) => format!("{h}h{m}m"),
+        _ if h == 0 => format!("{d}d"),
+        _ => format!("{d}d{h}h"),
+    }
+}
+
+/// Open incidents in a list response whose age exceeds their severity's threshold.
+pub fn due_escalations(
+    incidents: &[serde_json::Value],
+    rules: &[EscalationRule],
+    now_ms: i64,
+) -> Vec<Escalation> {
+    incidents
+        .iter()
+        .filter_map(|item| {
+            let inc = parse_incident(item);
+            let state = inc.state.unwrap_or_default();
+            if matches!(state.to_lowercase().as_str(), "resolved" | "completed") {
+                return None;
+            }
+            let severity = inc.severity.unwrap_or_else(|| "UNKNOWN".into());
+            let threshold_secs = threshold_for(rules, &severity)?;
+            let created = chrono::DateTime::parse_from_rfc3339(inc.created.as_deref()?).ok()?;
+            let age_secs = (now_ms - created.timestamp_millis()) / 1000;
+            (age_secs >= threshold_secs).then_some(Escalation {
+                incident_id: inc.id,
+                public_id: inc.public_id,
+                title: inc.title,
+                severity,
+                state,
+                age_secs,
+                threshold_secs,
+            })
+        })
+        .collect()
+}
+
+/// Notification title and body; `notify` handles (`@pagerduty-oncall`) go on their own line.
+pub fn escalation_message(esc: &Escalation, notify: &[String]) -> (String, String) {
+    let title = format!(
+        "[Escalation] Incident {} ({}) open {}: {}",
+        esc.label(),
+        esc.severity,
+        format_age(esc.age_secs),
+        esc.title
+    );
+    let mut message = format!(
+        "Incident {} \"{}\" ({}, {}) has been open for {}, past the {} escalation threshold.",
+        esc.label(),
+        esc.title,
+        esc.severity,
+        esc.state,
+        format_age(esc.age_secs),
+        format_age(esc.threshold_secs)
+    );
+    if !notify.is_empty() {
+        message.push_str("\n\n");
+        message.push_str(&notify.join(" "));
+    }
+    (title, message)
+}
+
+/// Inputs for `pup incidents watch`.
+#[derive(Debug, Default)]
+pub struct WatchOptions {
+    pub rules: Vec<EscalationRule>,
+    /// Handles mentioned in the notification, e.g. `@slack-incidents`.
+    pub notify: Vec<String>,
+    /// Post to this URL instead of sending a Datadog event.
+    pub webhook: Option<String>,
+    /// Run a single pass and exit (for cron).
+    pub once: bool,
+    pub interval_secs: u64,
+    /// Where escalated incidents are remembered across runs.
+    pub state_file: Option<String>,
+    /// Report what would be escalated without notifying or saving state.
+    pub dry_run: bool,
+}
+
+fn watch_state_path(opts: &WatchOptions) -> Option<std::path::PathBuf> {
+    match &opts.state_file {
+        Some(path) => Some(path.into()),
+        None => crate::config::config_dir().map(|d| d.join("incident-escalations.json")),
+    }
+}
+
+fn load_escalated(path: Option<&std::path::Path>) -> std::collections::HashSet<String> {
+    path.and_then(|p| std::fs::read_to_string(p).ok())
+        .and_then(|s| serde_json::from_str::<serde_json::Value>(&s).ok())
+        .and_then(|v| v["escalated"].as_array().cloned())
+        .map(|keys| {
+            keys.iter()
+                .filter_map(|k| k.as_str().map(String::from))
+                .collect()
+        })
+        .unwrap_or_default()
+}
+
+fn save_escalated(
+    path: Option<&std::path::Path>,
+    escalated: &std::collections::HashSet<String>,
+) -> Result<()> {
+    let Some(path) = path else {
+        return Ok(());
+    };
+    if let Some(parent) = path.parent().filter(|p| !p.as_os_str().is_empty()) {
+        std::fs::create_dir_all(parent)?;
+    }
+    let mut keys: Vec<&String> = escalated.iter().collect();
+    keys.sort();
+    let body = serde_json::json!({ "escalated": keys });
+    std::fs::write(path, format!("{}\n", serde_json::to_string_pretty(&body)?))
+        .map_err(|e| anyhow::anyhow!("failed to write {}: {e}", path.display()))
+}
+
+const INCIDENTS_PAGE_SIZE: usize = 100;
+
+async fn fetch_incidents(cfg: &Config) -> Result<Vec<serde_json::Value>> {
+    let collected = util::collect_pages(
+        util::Paging::Number {
+            size: INCIDENTS_PAGE_SIZE,
+        },
+        "/data",
+        util::DEFAULT_MAX_ITEMS,
+        |req| async move {
+            let query = [
+                ("page[size]", INCIDENTS_PAGE_SIZE.to_string()),
+                (
+                    "page[offset]",
+                    (req.number * INCIDENTS_PAGE_SIZE).to_string(),
+                ),
+            ];
+            crate::api::get(cfg, "/api/v2/incidents", &query)
+                .await
+                .map_err(|e| crate::api::failed("failed to list incidents", e))
+        },
+    )
+    .await?;
+    Ok(collected.items)
+}
+
+async fn send_escalation(cfg: &Config, esc: &Escalation, opts: &WatchOptions) -> Result<()> {
+    let (title, message) = escalation_message(esc, &opts.notify);
+    if let Some(url) = &opts.webhook {
+        // `text` renders in Slack/Teams-style receivers; `escalation` is for everything else.
+        let body = serde_json::json!({
+            "text": format!("{title}\n{message}"),
+            "escalation": esc,
+        });
+        let resp = crate::api::http_client()
+            .post(url)
+            .json(&body)
+            .send()
+            .await
+            .map_err(|e| anyhow::anyhow!("failed to post escalation webhook: {e}"))?;
+        if !resp.status().is_success() {
+            bail!("escalation webhook returned HTTP {}", resp.status());
+        }
+        return Ok(());
+    }
+    let body = super::events::build_event_body(&super::events::EventSendOptions {
+        title,
+        message,
+        category: "alert".into(),
+        alert_status: "error".into(),
+        aggregation_key: Some(format!("incident-escalation-{}", esc.incident_id)),
+        tags: vec![
+            format!("incident_id:{}", esc.incident_id),
+            format!("severity:{}", normalize_severity(&esc.severity)),
+        ],
+        ..Default::default()
+    })?;
+    crate::client::raw_post(cfg, "/api/v2/events", body)
+        .await
+        .map_err(|e| crate::api::failed("failed to send escalation event", e))?;
+    Ok(())
+}
+
+fn print_escalation(cfg: &Config, esc: &Escalation, dry_run: bool) -> Result<()> {
+    if cfg.agent_mode || cfg.output_format == OutputFormat::Json {
+        let mut line = serde_json::to_value(esc)?;
+        line["dry_run"] = dry_run.into();
+        println!("{}", serde_json::to_string(&line)?);
+        return Ok(());
+    }
+    let verb = if dry_run {
+        "would escalate"
+    } else {
+        "escalated"
+    };
+    println!(
+        "{verb} incident {} ({}, open {} > {}): {}",
+        esc.label(),
+        esc.severity,
+        format_age(esc.age_secs),
+        format_age(esc.threshold_secs),
+        esc.title
+    );
+    Ok(())
+}
+
+/// One pass: notify for newly overdue incidents. `escalated` is replaced by the
+/// keys still overdue, so resolved incidents drop out of the state.
+async fn watch_pass(
+    cfg: &Config,
+    opts: &WatchOptions,
+    escalated: &mut std::collections::HashSet<String>,
+) -> Result<()> {
+    let incidents = fetch_incidents(cfg).await?;
+    let now_ms = chrono::Utc::now().timestamp_millis();
+    let mut still_due = std::collections::HashSet::new();
+    for esc in due_escalations(&incidents, &opts.rules, now_ms) {
+        let key = esc.key();
+        if !escalated.contains(&key) {
+            if !opts.dry_run {
+                if let Err(e) = send_escalation(cfg, &esc, opts).await {
+                    // Not recorded, so the next pass retries.
+                    eprintln!("warning: incident {}: {e}", esc.label());
+                    continue;
+                }
+            }
+            print_escalation(cfg, &esc, opts.dry_run)?;
+        }
+        still_due.insert(key);
+    }
+    *escalated = still_due;
+    Ok(())
+}
+
+/// Watch open incidents and notify when one stays open past its severity's
+/// `--escalate-after` threshold. Each incident is escalated once per severity.
+pub async fn watch(cfg: &Config, opts: WatchOptions) -> Result<()> {
+    if let Some(url) = &opts.webhook {
+        if !url.starts_with("https://") && !url.starts_with("http://") {
+            bail!("--webhook must be an http(s) URL");
+        }
+    }
+    let state_path = watch_state_path(&opts);
+    let mut escalated = load_escalated(state_path.as_deref());
+    watch_pass(cfg, &opts, &mut escalated).await?;
+    if !opts.dry_run {
+        save_escalated(state_path.as_deref(), &escalated)?;
+    }
+    if opts.once {
+        return Ok(());
+    }
+    follow_watch(cfg, &opts, state_path.as_deref(), escalated).await
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn follow_watch(
+    cfg: &Config,
+    opts: &WatchOptions,
+    state_path: Option<&std::path::Path>,
+    mut escalated: std::collections::HashSet<String>,
+) -> Result<()> {
+    eprintln!("Watching open incidents (Ctrl-C to stop)...");
+    let interval = std::time::Duration::from_secs(opts.interval_secs.max(1));
+    loop {
+        tokio::time::sleep(interval).await;
+        // A long-lived watcher outlives API blips; keep going and retry next pass.
+        match watch_pass(cfg, opts, &mut escalated).await {
+            Ok(()) if !opts.dry_run => {
+                if let Err(e) = save_escalated(state_path, &escalated) {
+                    eprintln!("warning: {e}");
+                }
+            }
+            Ok(()) => {}
+            Err(e) => eprintln!("warning: {e}"),
+        }
+    }
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn follow_watch(
+    _cfg: &Config,
+    _opts: &WatchOptions,
+    _state_path: Option<&std::path::Path>,
+    _escalated: std::collections::HashSet<String>,
+) -> Result<()> {
+    bail!("continuous watching is not supported in WASM builds; use --once")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_jira_integration_ticket() {
+        use crate::commands::cases::TicketState;
+        let body = jira_integration_body("acme", "OPS", None);
+        let attrs = &body["data"]["attributes"];
+        assert_eq!(attrs["integration_type"], 8);
+        assert_eq!(
+            attrs["metadata"]["issues"][0],
+            serde_json::json!({"account": "acme", "project_key": "OPS"})
+        );
+
+        let pending = serde_json::json!({"data": {"id": "i1", "attributes": {"status": 0, "metadata": {"issues": [{"project_key": "OPS"}]}}}});
+        assert_eq!(integration_ticket_state(&pending), TicketState::Pending);
+        let ready = serde_json::json!({"data": {"attributes": {"status": 3, "metadata": {"issues": [
+            {"issue_key": "OPS-7", "redirect_url": "https://acme.atlassian.net/browse/OPS-7"}
+        ]}}}});
+        assert_eq!(
+            integration_ticket_state(&ready),
+            TicketState::Ready("https://acme.atlassian.net/browse/OPS-7".into())
+        );
+        let failed = serde_json::json!({"data": {"attributes": {"status": 4, "metadata": {"issues": [{}]}}}});
+        assert!(matches!(
+            integration_ticket_state(&failed),
+            TicketState::Failed(_)
+        ));
+    }
+
+    #[test]
+    fn test_search_query() {
+        let filters = IncidentFilters {
+            states: vec!["Active".into(), "stable".into()],
+            severities: vec!["sev1".into()],
+            customer_impacted: Some(true),
+            query: Some("checkout".into()),
+            ..Default::default()
+        };
+        assert_eq!(
+            search_query(&filters).unwrap(),
+            "state:(active OR stable) AND severity:SEV-1 AND customer_impacted:true AND checkout"
+        );
+        assert_eq!(
+            search_query(&IncidentFilters::default()).unwrap(),
+            "state:(active OR stable OR resolved)"
+        );
+        let window = IncidentFilters {
+            states: vec!["resolved".into()],
+            from: Some("2026-01-01T00:00:00Z".into()),
+            ..Default::default()
+        };
+        assert_eq!(
+            search_query(&window).unwrap(),
+            "state:resolved AND created:[2026-01-01T00:00:00Z TO *]"
+        );
+    }
+
+    #[test]
+    fn test_search_query_rejects_bad_values() {
+        let bad_state = IncidentFilters {
+            states: vec!["open".into()],
+            ..Default::default()
+        };
+        assert!(search_query(&bad_state).is_err());
+        let bad_severity = IncidentFilters {
+            severities: vec!["sev9".into()],
+            ..Default::default()
+        };
+        assert!(search_query(&bad_severity).is_err());
+        assert_eq!(search_sort("newest").unwrap(), "-created");
+        assert!(search_sort("title").is_err());
+    }
+
+    fn sample_incident() -> serde_json::Value {
+        serde_json::json!({
+            "data": {
+                "id": "abc-123",
+                "type": "incidents",
+                "attributes": {
+                    "public_id": 42,
+                    "title": "Checkout latency <p99>",
+                    "severity": "SEV-2",
+                    "state": "resolved",
+                    "created": "2024-01-02T03:00:00Z",
+                    "resolved": "2024-01-02T05:00:00Z",
+                    "customer_impacted": true,
+                    "customer_impact_scope": "EU checkout",
+                    "fields": {
+                        "state": {"type": "dropdown", "value": "resolved"},
+                        "root_cause": {"type": "textbox", "value": "bad deploy"},
+                        "teams": {"type": "autocomplete", "value": ["payments", "sre"]},
+                        "empty": {"type": "textbox", "value": null}
+                    }
+                },
+                "relationships": {
+                    "commander_user": {"data": {"id": "u1", "type": "users"}}
+                }
+            },
+            "included": [
+                {"id": "u1", "type": "users", "attributes": {"name": "Jane Doe"}}
+            ]
+        })
+    }
+
+    #[test]
+    fn test_parse_incident() {
+        let e = parse_incident(&sample_incident());
+        assert_eq!(e.id, "abc-123");
+        assert_eq!(e.public_id.as_deref(), Some("42"));
+        assert_eq!(e.commander.as_deref(), Some("Jane Doe"));
+        assert_eq!(e.severity.as_deref(), Some("SEV-2"));
+        assert!(e.customer_impacted);
+        assert_eq!(
+            e.fields,
+            vec![
+                ("root_cause".to_string(), "bad deploy".to_string()),
+                ("teams".to_string(), "payments, sre".to_string()),
+            ]
+        );
+    }
+
+    #[test]
+    fn test_parse_timeline_sorted() {
+        let resp = serde_json::json!({"data": [
+            {"attributes": {"created": "2024-01-02T04:00:00Z", "content": {"content": "mitigated"}}},
+            {"attributes": {"created": "2024-01-02T03:00:00Z", "content": {"content": "paged"}}},
+            {"attributes": {"created": "2024-01-02T03:30:00Z", "content": {}}}
+        ]});
+        let t = parse_timeline(&resp);
+        assert_eq!(t.len(), 2);
+        assert_eq!(t[0].1, "paged");
+        assert_eq!(t[1].1, "mitigated");
+    }
+
+    #[test]
+    fn test_postmortem_url_and_notebook_id() {
+        let resp = serde_json::json!({"data": [
+            {"attributes": {"attachment_type": "link", "attachment": {"documentUrl": "https://x"}}},
+            {"attributes": {"attachment_type": "postmortem", "attachment": {
+                "documentUrl": "https://app.datadoghq.com/notebook/98765/postmortem", "title": "PM"
+            }}}
+        ]});
+        let url = postmortem_url(&resp).unwrap();
+        assert_eq!(notebook_id_from_url(&url), Some(98765));
+        assert_eq!(notebook_id_from_url("https://example.com/doc/1"), None);
+    }
+
+    #[test]
+    fn test_notebook_cells_markdown() {
+        let nb = serde_json::json!({"data": {"attributes": {"cells": [
+            {"attributes": {"definition": {"type": "markdown", "text": "## Root cause\nbad deploy"}}},
+            {"attributes": {"definition": {"type": "timeseries", "title": "p99"}}}
+        ]}}});
+        let cells = notebook_cells_markdown(&nb);
+        assert_eq!(cells[0], "## Root cause\nbad deploy");
+        assert!(cells[1].contains("timeseries widget: p99"));
+    }
+
+    #[test]
+    fn test_render_markdown() {
+        let mut e = parse_incident(&sample_incident());
+        e.timeline = vec![("2024-01-02T03:00:00Z".into(), "paged | oncall".into())];
+        e.postmortem = vec!["## Root cause\nbad deploy".into()];
+        let md = render_markdown(&e);
+        assert!(md.starts_with("# Checkout latency <p99>\n"));
+        assert!(md.contains("| Commander | Jane Doe |"));
+        assert!(md.contains("| Customer impact | yes — EU checkout |"));
+        assert!(md.contains("- **2024-01-02T03:00:00Z** — paged | oncall"));
+        assert!(md.contains("## Root cause\nbad deploy"));
+    }
+
+    #[test]
+    fn test_render_markdown_empty_sections() {
+        let md = render_markdown(&parse_incident(&sample_incident()));
+        assert!(md.contains("_No timeline entries._"));
+        assert!(md.contains("_No postmortem notebook attached._"));
+    }
+
+    #[test]
+    fn test_render_confluence_escapes() {
+        let mut e = parse_incident(&sample_incident());
+        e.postmortem = vec!["code ]]> end".into()];
+        let xml = render_confluence(&e);
+        assert!(xml.contains("<h1>Checkout latency &lt;p99&gt;</h1>"));
+        assert!(xml.contains("<tr><th>Commander</th><td>Jane Doe</td></tr>"));
+        assert!(xml.contains("code ]]]]><![CDATA[> end"));
+    }
+
+    #[test]
+    fn test_fresh_entries_dedupes_across_polls() {
+        let first = serde_json::json!({"data": [
+            {"id": "c1", "attributes": {"created": "2024-01-02T03:00:00Z", "cell_type": "markdown", "content": {"content": "paged"}}}
+        ]});
+        let second = serde_json::json!({"data": [
+            {"id": "c2", "attributes": {"created": "2024-01-02T03:05:00Z", "cell_type": "markdown", "content": {"content": "state -> stable"}}},
+            {"id": "c1", "attributes": {"created": "2024-01-02T03:00:00Z", "cell_type": "markdown", "content": {"content": "paged"}}}
+        ]});
+        let mut seen = std::collections::HashSet::new();
+        let new = fresh_entries(parse_timeline_entries(&first), &mut seen);
+        assert_eq!(new.len(), 1);
+        assert_eq!(new[0].kind, "markdown");
+        let new = fresh_entries(parse_timeline_entries(&second), &mut seen);
+        assert_eq!(new.len(), 1);
+        assert_eq!(new[0].id, "c2");
+        assert_eq!(new[0].content, "state -> stable");
+    }
+
+    #[test]
+    fn test_parse_escalation_rules() {
+        let rules =
+            parse_escalation_rules(&["SEV-1=15m".into(), "2=30m".into(), "*=2h".into()]).unwrap();
+        assert_eq!(rules[0].severity, "sev1");
+        assert_eq!(rules[1].severity, "sev2");
+        assert_eq!(rules[1].after_secs, 1800);
+        assert_eq!(threshold_for(&rules, "SEV-2"), Some(1800));
+        assert_eq!(threshold_for(&rules, "SEV-4"), Some(7200));
+        assert!(parse_escalation_rules(&[]).is_err());
+        assert!(parse_escalation_rules(&["sev2".into()]).is_err());
+        assert!(parse_escalation_rules(&["sev2=soon".into()]).is_err());
+    }
+
+    #[test]
+    fn test_due_escalations() {
+        let rules = parse_escalation_rules(&["sev2=30m".into()]).unwrap();
+        let incidents = vec![
+            serde_json::json!({"id": "a", "attributes": {"public_id": 7, "title": "Checkout errors",
+                "severity": "SEV-2", "state": "active", "created": "2024-01-02T03:00:00Z"}}),
+            serde_json::json!({"id": "b", "attributes": {"title": "Too young",
+                "severity": "SEV-2", "state": "active", "created": "2024-01-02T03:40:00Z"}}),
+            serde_json::json!({"id": "c", "attributes": {"title": "Done",
+                "severity": "SEV-2", "state": "resolved", "created": "2024-01-02T01:00:00Z"}}),
+            serde_json::json!({"id": "d", "attributes": {"title": "No rule",
+                "severity": "SEV-3", "state": "active", "created": "2024-01-02T01:00:00Z"}}),
+        ];
+        let now = chrono::DateTime::parse_from_rfc3339("2024-01-02T03:45:00Z")
+            .unwrap()
+            .timestamp_millis();
+        let due = due_escalations(&incidents, &rules, now);
+        assert_eq!(due.len(), 1);
+        assert_eq!(due[0].incident_id, "a");
+        assert_eq!(due[0].age_secs, 2700);
+        assert_eq!(due[0].key(), "a:sev2");
+
+        let (title, message) = escalation_message(&due[0], &["@pagerduty-sre".into()]);
+        assert_eq!(
+            title,
+            "[Escalation] Incident #7 (SEV-2) open 45m: Checkout errors"
+        );
+        assert!(message.contains("past the 30m escalation threshold."));
+        assert!(message.ends_with("\n\n@pagerduty-sre"));
+    }
+
+    #[test]
+    fn test_format_age() {
+        assert_eq!(format_age(59), "0m");
+        assert_eq!(format_age(2700), "45m");
+        assert_eq!(format_age(7200), "2h");
+        assert_eq!(format_age(7500), "2h5m");
+        assert_eq!(format_age(3 * 86400 + 4 * 3600 + 60), "3d4h");
+    }
+
+    #[test]
+    fn test_api_severity() {
+        assert_eq!(api_severity("sev2").unwrap(), "SEV-2");
+        assert_eq!(api_severity("SEV-1").unwrap(), "SEV-1");
+        assert_eq!(api_severity("5").unwrap(), "SEV-5");
+        assert_eq!(api_severity("unknown").unwrap(), "UNKNOWN");
+        assert!(api_severity("sev9").is_err());
+        assert!(api_severity("high").is_err());
+    }
+
+    #[test]
+    fn test_incident_body() {
+        let create = IncidentChanges {
+            title: Some("Checkout errors".into()),
+            severity: Some("2".into()),
+            customer_impact_scope: Some("EU checkout".into()),
+            ..Default::default()
+        };
+        let body = incident_body(&create, Some("u1"), None).unwrap();
+        assert_eq!(
+            body,
+            serde_json::json!({"data": {
+                "type": "incidents",
+                "attributes": {
+                    "title": "Checkout errors",
+                    "fields": {"severity": {"type": "dropdown", "value": "SEV-2"}},
+                    "customer_impacted": true,
+                    "customer_impact_scope": "EU checkout"
+                },
+                "relationships": {"commander_user": {"data": {"type": "users", "id": "u1"}}}
+            }})
+        );
+
+        let resolve = IncidentChanges {
+            state: Some("Resolved".into()),
+            ..Default::default()
+        };
+        let body = incident_body(&resolve, None, Some("abc-123")).unwrap();
+        assert_eq!(body["data"]["id"], "abc-123");
+        assert_eq!(
+            body["data"]["attributes"],
+            serde_json::json!({"fields": {"state": {"type": "dropdown", "value": "resolved"}}})
+        );
+
+        let bad = IncidentChanges {
+            state: Some("closed".into()),
+            ..Default::default()
+        };
+        assert!(incident_body(&bad, None, Some("abc-123")).is_err());
+
+        // create defaults customer_impacted to false; a scope still turns it on.
+        let scoped = IncidentChanges {
+            customer_impacted: Some(false),
+            customer_impact_scope: Some("EU checkout".into()),
+            ..Default::default()
+        };
+        let body = incident_body(&scoped, None, None).unwrap();
+        assert_eq!(body["data"]["attributes"]["customer_impacted"], true);
+    }
+
+    #[test]
+    fn test_find_user_id() {
+        let resp = serde_json::json!({"data": [
+            {"id": "u1", "attributes": {"email": "jane@example.com", "handle": "jane@example.com"}},
+            {"id": "u2", "attributes": {"email": "janet@example.com", "handle": "janet"}}
+        ]});
+        assert_eq!(
+            find_user_id(&resp, "Jane@Example.com").as_deref(),
+            Some("u1")
+        );
+        assert_eq!(find_user_id(&resp, "janet").as_deref(), Some("u2"));
+        assert_eq!(find_user_id(&resp, "jan"), None);
+    }
+
+    #[test]
+    fn test_timeline_cell_body() {
+        let body = timeline_cell_body("rolled back v2.3.1");
+        assert_eq!(body["data"]["type"], "incident_timeline_cells");
+        assert_eq!(
+            body["data"]["attributes"]["content"]["content"],
+            "rolled back v2.3.1"
+        );
+    }
+}
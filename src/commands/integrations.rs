@@ -461,6 +461,197 @@ pub async fn pagerduty_list(_cfg: &Config) -> Result<()> {
     )
 }
 
+const PAGERDUTY_SERVICES_PATH: &str = "/api/v1/integration/pagerduty/configuration/services";
+
+pub async fn pagerduty_get(cfg: &Config, service_name: &str) -> Result<()> {
+    let resp = crate::api::get(
+        cfg,
+        &format!("{PAGERDUTY_SERVICES_PATH}/{service_name}"),
+        &[],
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to get PagerDuty service", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn pagerduty_create(cfg: &Config, service_name: &str, service_key: &str) -> Result<()> {
+    let body = serde_json::json!({ "service_name": service_name, "service_key": service_key });
+    let mut resp = crate::api::post(cfg, PAGERDUTY_SERVICES_PATH, &body)
+        .await
+        .map_err(|e| {
+            anyhow::anyhow!(
+                "failed to create PagerDuty service: {}",
+                util::redact_secret_text(&format!("{e:?}"), Some(service_key))
+            )
+        })?;
+    util::redact_secret(&mut resp, service_key);
+    formatter::output(cfg, &resp)
+}
+
+/// Replace a PagerDuty service's integration key.
+pub async fn pagerduty_update(cfg: &Config, service_name: &str, service_key: &str) -> Result<()> {
+    let body = serde_json::json!({ "service_key": service_key });
+    crate::api::put(
+        cfg,
+        &format!("{PAGERDUTY_SERVICES_PATH}/{service_name}"),
+        &body,
+    )
+    .await
+    .map_err(|e| {
+        anyhow::anyhow!(
+            "failed to update PagerDuty service: {}",
+            util::redact_secret_text(&format!("{e:?}"), Some(service_key))
+        )
+    })?;
+    println!("PagerDuty service '{service_name}' updated.");
+    Ok(())
+}
+
+pub async fn pagerduty_delete(cfg: &Config, service_name: &str) -> Result<()> {
+    crate::api::delete(cfg, &format!("{PAGERDUTY_SERVICES_PATH}/{service_name}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to delete PagerDuty service", e))?;
+    println!("PagerDuty service '{service_name}' deleted.");
+    Ok(())
+}
+
+// ---- Opsgenie ----
+
+const OPSGENIE_SERVICES_PATH: &str = "/api/v2/integration/opsgenie/services";
+
+/// Fields for an Opsgenie service create or update; `None` leaves a field
+/// unchanged on update.
+#[derive(Debug, Default)]
+pub struct OpsgenieService {
+    pub name: Option<String>,
+    pub api_key: Option<String>,
+    /// `us`, `eu`, or `custom`.
+    pub region: Option<String>,
+    /// Opsgenie API URL, only with the `custom` region.
+    pub custom_url: Option<String>,
+}
+
+/// Request body for an Opsgenie service; `id` is set for updates.
+pub fn opsgenie_body(service: &OpsgenieService, id: Option<&str>) -> Result<serde_json::Value> {
+    if let Some(region) = service.region.as_deref() {
+        if !["us", "eu", "custom"].contains(&region) {
+            anyhow::bail!("invalid region {region:?}: expected us, eu, or custom");
+        }
+    }
+    match (service.region.as_deref(), service.custom_url.is_some()) {
+        (Some("custom"), false) => anyhow::bail!("--region custom requires --custom-url"),
+        (Some("us" | "eu"), true) => anyhow::bail!("--custom-url needs --region custom"),
+        _ => {}
+    }
+    let mut attributes = serde_json::Map::new();
+    if let Some(name) = &service.name {
+        attributes.insert("name".into(), name.as_str().into());
+    }
+    if let Some(key) = &service.api_key {
+        attributes.insert("opsgenie_api_key".into(), key.as_str().into());
+    }
+    if let Some(region) = &service.region {
+        attributes.insert("region".into(), region.as_str().into());
+    }
+    if let Some(url) = &service.custom_url {
+        attributes.insert("custom_url".into(), url.as_str().into());
+    }
+    if id.is_some() && attributes.is_empty() {
+        anyhow::bail!("nothing to update: pass --name, --region, --custom-url, or a new API key");
+    }
+    let mut data = serde_json::json!({ "type": "opsgenie_service", "attributes": attributes });
+    if let Some(id) = id {
+        data["id"] = id.into();
+    }
+    Ok(serde_json::json!({ "data": data }))
+}
+
+/// The ID of the service named `name` in a list response.
+pub fn opsgenie_service_id(resp: &serde_json::Value, name: &str) -> Option<String> {
+    resp["data"]
+        .as_array()?
+        .iter()
+        .find(|s| s["attributes"]["name"].as_str() == Some(name))
+        .and_then(|s| s["id"].as_str())
+        .map(str::to_string)
+}
+
+/// Accept a service ID or its name, so scripts replicating services across
+/// orgs can refer to them the same way everywhere.
+async fn resolve_opsgenie_service(cfg: &Config, service: &str) -> Result<String> {
+    if util::parse_uuid(service, "service").is_ok() {
+        return Ok(service.to_string());
+    }
+    let resp = crate::api::get(cfg, OPSGENIE_SERVICES_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list Opsgenie services", e))?;
+    opsgenie_service_id(&resp, service)
+        .ok_or_else(|| anyhow::anyhow!("no Opsgenie service named {service:?}"))
+}
+
+pub async fn opsgenie_services_list(cfg: &Config) -> Result<()> {
+    let resp = crate::api::get(cfg, OPSGENIE_SERVICES_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list Opsgenie services", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn opsgenie_services_get(cfg: &Config, service: &str) -> Result<()> {
+    let id = resolve_opsgenie_service(cfg, service).await?;
+    let resp = crate::api::get(cfg, &format!("{OPSGENIE_SERVICES_PATH}/{id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get Opsgenie service", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn opsgenie_services_create(cfg: &Config, service: &OpsgenieService) -> Result<()> {
+    let body = opsgenie_body(service, None)?;
+    let key = service.api_key.as_deref();
+    let mut resp = crate::api::post(cfg, OPSGENIE_SERVICES_PATH, &body)
+        .await
+        .map_err(|e| {
+            anyhow::anyhow!(
+                "failed to create Opsgenie service: {}",
+                util::redact_secret_text(&format!("{e:?}"), key)
+            )
+        })?;
+    if let Some(key) = key {
+        util::redact_secret(&mut resp, key);
+    }
+    formatter::output(cfg, &resp)
+}
+
+pub async fn opsgenie_services_update(
+    cfg: &Config,
+    service: &str,
+    changes: &OpsgenieService,
+) -> Result<()> {
+    let id = resolve_opsgenie_service(cfg, service).await?;
+    let body = opsgenie_body(changes, Some(&id))?;
+    let key = changes.api_key.as_deref();
+    let mut resp = crate::api::patch(cfg, &format!("{OPSGENIE_SERVICES_PATH}/{id}"), &body)
+        .await
+        .map_err(|e| {
+            anyhow::anyhow!(
+                "failed to update Opsgenie service: {}",
+                util::redact_secret_text(&format!("{e:?}"), key)
+            )
+        })?;
+    if let Some(key) = key {
+        util::redact_secret(&mut resp, key);
+    }
+    formatter::output(cfg, &resp)
+}
+
+pub async fn opsgenie_services_delete(cfg: &Config, service: &str) -> Result<()> {
+    let id = resolve_opsgenie_service(cfg, service).await?;
+    crate::api::delete(cfg, &format!("{OPSGENIE_SERVICES_PATH}/{id}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to delete Opsgenie service", e))?;
+    println!("Opsgenie service '{service}' deleted.");
+    Ok(())
+}
+
 // ---- Webhooks ----
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -487,3 +678,468 @@ pub async fn webhooks_list(cfg: &Config) -> Result<()> {
     .await?;
     crate::formatter::output(cfg, &data)
 }
+
+/// Parse a `--header "Name: value"` flag.
+fn parse_header(header: &str) -> Result<(String, String)> {
+    match header.split_once(':') {
+        Some((name, value)) if !name.trim().is_empty() => {
+            Ok((name.trim().to_string(), value.trim().to_string()))
+        }
+        _ => anyhow::bail!("invalid header {header:?}: expected \"Name: value\""),
+    }
+}
+
+const WEBHOOKS_PATH: &str = "/api/v1/integration/webhooks/configuration/webhooks";
+
+/// Headers and secret for a webhook body. `{{secret}}` in a header or the
+/// payload is filled from `secret` here, at send time, rather than on the
+/// command line.
+fn finish_webhook_body(
+    mut body: serde_json::Value,
+    headers: &[String],
+    secret: Option<&str>,
+) -> Result<serde_json::Value> {
+    let mut custom_headers = serde_json::Map::new();
+    for h in headers {
+        let (k, v) = parse_header(h)?;
+        custom_headers.insert(k, v.into());
+    }
+    if !custom_headers.is_empty() {
+        body["custom_headers"] = serde_json::Value::Object(custom_headers);
+    }
+    util::apply_secret(&mut body, secret)?;
+    // The API takes the headers as a JSON-encoded string.
+    if let Some(h) = body.get("custom_headers").cloned() {
+        body["custom_headers"] = h.to_string().into();
+    }
+    Ok(body)
+}
+
+/// Request body for a new webhook.
+pub fn webhook_body(
+    name: &str,
+    url: &str,
+    payload: Option<&str>,
+    headers: &[String],
+    encode_as: &str,
+    secret: Option<&str>,
+) -> Result<serde_json::Value> {
+    let mut body = serde_json::json!({
+        "name": name,
+        "url": url,
+        "encode_as": encode_as,
+    });
+    if let Some(payload) = payload {
+        body["payload"] = payload.into();
+    }
+    finish_webhook_body(body, headers, secret)
+}
+
+/// Request body for a webhook update: only the fields given change. Headers
+/// replace the webhook's whole header set.
+pub fn webhook_update_body(
+    url: Option<&str>,
+    payload: Option<&str>,
+    headers: &[String],
+    encode_as: Option<&str>,
+    secret: Option<&str>,
+) -> Result<serde_json::Value> {
+    let mut body = serde_json::json!({});
+    if let Some(url) = url {
+        body["url"] = url.into();
+    }
+    if let Some(payload) = payload {
+        body["payload"] = payload.into();
+    }
+    if let Some(encode_as) = encode_as {
+        body["encode_as"] = encode_as.into();
+    }
+    if body.as_object().is_some_and(|b| b.is_empty()) && headers.is_empty() {
+        anyhow::bail!("nothing to update: pass --url, --payload, --header, or --encode-as");
+    }
+    finish_webhook_body(body, headers, secret)
+}
+
+/// `--payload`: inline, or `@file` to read it from a file.
+fn read_payload(payload: Option<String>) -> Result<Option<String>> {
+    match payload {
+        Some(p) if p.starts_with('@') => {
+            Ok(Some(std::fs::read_to_string(&p[1..]).map_err(|e| {
+                anyhow::anyhow!("failed to read payload file {:?}: {e}", &p[1..])
+            })?))
+        }
+        p => Ok(p),
+    }
+}
+
+pub async fn webhooks_create(
+    cfg: &Config,
+    name: &str,
+    url: &str,
+    payload: Option<String>,
+    headers: &[String],
+    encode_as: &str,
+    secret: Option<String>,
+) -> Result<()> {
+    let payload = read_payload(payload)?;
+    let secret = secret.as_deref();
+    let body = webhook_body(name, url, payload.as_deref(), headers, encode_as, secret)?;
+    let mut resp = crate::api::post(cfg, WEBHOOKS_PATH, &body)
+        .await
+        .map_err(|e| {
+            anyhow::anyhow!(
+                "failed to create webhook: {}",
+                util::redact_secret_text(&format!("{e:?}"), secret)
+            )
+        })?;
+    if let Some(secret) = secret {
+        util::redact_secret(&mut resp, secret);
+    }
+    formatter::output(cfg, &resp)
+}
+
+pub async fn webhooks_update(
+    cfg: &Config,
+    name: &str,
+    url: Option<&str>,
+    payload: Option<String>,
+    headers: &[String],
+    encode_as: Option<&str>,
+    secret: Option<String>,
+) -> Result<()> {
+    let payload = read_payload(payload)?;
+    let secret = secret.as_deref();
+    let body = webhook_update_body(url, payload.as_deref(), headers, encode_as, secret)?;
+    let mut resp = crate::api::put(cfg, &format!("{WEBHOOKS_PATH}/{name}"), &body)
+        .await
+        .map_err(|e| {
+            anyhow::anyhow!(
+                "failed to update webhook: {}",
+                util::redact_secret_text(&format!("{e:?}"), secret)
+            )
+        })?;
+    if let Some(secret) = secret {
+        util::redact_secret(&mut resp, secret);
+    }
+    formatter::output(cfg, &resp)
+}
+
+pub async fn webhooks_delete(cfg: &Config, name: &str) -> Result<()> {
+    crate::api::delete(cfg, &format!("{WEBHOOKS_PATH}/{name}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to delete webhook", e))?;
+    println!("Webhook '{name}' deleted.");
+    Ok(())
+}
+
+/// Datadog's default webhook payload, used when neither `--payload` nor the
+/// webhook sets one.
+const DEFAULT_WEBHOOK_PAYLOAD: &str = r#"{
+    "body": "$EVENT_MSG",
+    "last_updated": "$LAST_UPDATED",
+    "event_type": "$EVENT_TYPE",
+    "title": "$EVENT_TITLE",
+    "date": "$DATE",
+    "org": {"id": "$ORG_ID", "name": "$ORG_NAME"},
+    "id": "$ID"
+}"#;
+
+/// Sample values for webhook template variables. None contain characters
+/// that need escaping inside a JSON string.
+const SAMPLE_VARIABLES: &[(&str, &str)] = &[
+    ("AGGREG_KEY", "9bd4ac313a4d1e8fb4fb1b3b1f4ed5e6"),
+    ("ALERT_CYCLE_KEY", "1234567890123456789"),
+    ("ALERT_ID", "1234"),
+    ("ALERT_METRIC", "system.load.1"),
+    ("ALERT_PRIORITY", "P3"),
+    ("ALERT_QUERY", "avg(last_5m):avg:system.load.1{*} > 2"),
+    ("ALERT_SCOPE", "host:web-1"),
+    (
+        "ALERT_STATUS",
+        "system.load.1 over host:web-1 was > 2.0 on average during the last 5m.",
+    ),
+    ("ALERT_TITLE", "[Triggered] Test alert from pup"),
+    ("ALERT_TRANSITION", "Triggered"),
+    ("ALERT_TYPE", "error"),
+    ("DATE", "1700000000000"),
+    ("EMAIL", "pup-test@example.com"),
+    (
+        "EVENT_MSG",
+        "This is a test notification sent by pup integrations webhooks test.",
+    ),
+    ("EVENT_TITLE", "[Triggered] Test alert from pup"),
+    ("EVENT_TYPE", "metric_alert_monitor"),
+    ("HOSTNAME", "web-1"),
+    ("ID", "1234567890123456789"),
+    ("LAST_UPDATED", "1700000000000"),
+    (
+        "LINK",
+        "https://app.datadoghq.com/event/event?id=1234567890123456789",
+    ),
+    ("LOGS_SAMPLE", ""),
+    ("METRIC_NAMESPACE", "system"),
+    ("ORG_ID", "1"),
+    ("ORG_NAME", "Test Org"),
+    ("PRIORITY", "normal"),
+    ("SECURITY_RULE_NAME", ""),
+    ("SNAPSHOT", ""),
+    ("TAGS", "env:test,service:pup"),
+    (
+        "TEXT_ONLY_MSG",
+        "This is a test notification sent by pup integrations webhooks test.",
+    ),
+    ("USER", "pup"),
+    ("USERNAME", "pup-test@example.com"),
+];
+
+/// Fill `$VARIABLE`s in a payload template with sample values. Unknown names
+/// are left as-is so a typo shows up in what the endpoint receives.
+pub fn render_sample_payload(template: &str) -> String {
+    let mut out = String::with_capacity(template.len());
+    let mut rest = template;
+    while let Some(i) = rest.find('$') {
+        out.push_str(&rest[..i]);
+        let after = &rest[i + 1..];
+        let len = after
+            .find(|c: char| !(c.is_ascii_uppercase() || c.is_ascii_digit() || c == '_'))
+            .unwrap_or(after.len());
+        let name = &after[..len];
+        match SAMPLE_VARIABLES.iter().find(|(n, _)| *n == name) {
+            Some((_, value)) => out.push_str(value),
+            None => {
+                out.push('$');
+                out.push_str(name);
+            }
+        }
+        rest = &after[len..];
+    }
+    out.push_str(rest);
+    out
+}
+
+/// Form fields for a payload sent with `encode_as: form`.
+pub fn form_fields(payload: &str) -> Result<Vec<(String, String)>> {
+    let value: serde_json::Value = serde_json::from_str(payload)
+        .map_err(|e| anyhow::anyhow!("form-encoded webhook payload must be a JSON object: {e}"))?;
+    let Some(map) = value.as_object() else {
+        anyhow::bail!("form-encoded webhook payload must be a JSON object");
+    };
+    Ok(map
+        .iter()
+        .map(|(k, v)| match v {
+            serde_json::Value::String(s) => (k.clone(), s.clone()),
+            other => (k.clone(), other.to_string()),
+        })
+        .collect())
+}
+
+/// Send a sample event to a webhook's URL with its headers, so the receiving
+/// end can be checked without waiting for a real alert. The request goes out
+/// from this machine, not Datadog's IP ranges.
+pub async fn webhooks_test(
+    cfg: &Config,
+    name: &str,
+    payload: Option<String>,
+    secret: Option<String>,
+) -> Result<()> {
+    let webhook = crate::api::get(cfg, &format!("{WEBHOOKS_PATH}/{name}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get webhook", e))?;
+    let Some(url) = webhook["url"].as_str() else {
+        anyhow::bail!("webhook '{name}' has no URL");
+    };
+    let template = match read_payload(payload)? {
+        Some(p) => p,
+        None => webhook["payload"]
+            .as_str()
+            .filter(|p| !p.trim().is_empty())
+            .unwrap_or(DEFAULT_WEBHOOK_PAYLOAD)
+            .to_string(),
+    };
+    let headers = match webhook["custom_headers"].as_str() {
+        Some(h) if !h.trim().is_empty() => serde_json::from_str(h)
+            .map_err(|e| anyhow::anyhow!("webhook '{name}' has invalid custom headers: {e}"))?,
+        _ => serde_json::json!({}),
+    };
+    let secret = secret.as_deref();
+    let mut request = serde_json::json!({
+        "url": url,
+        "headers": headers,
+        "payload": render_sample_payload(&template),
+    });
+    util::apply_secret(&mut request, secret)?;
+
+    let url = request["url"].as_str().unwrap_or_default();
+    let payload = request["payload"].as_str().unwrap_or_default();
+    let mut req = crate::api::http_client().post(url);
+    if let Some(headers) = request["headers"].as_object() {
+        for (k, v) in headers {
+            req = req.header(k.as_str(), v.as_str().unwrap_or_default());
+        }
+    }
+    req = if webhook["encode_as"] == "form" {
+        req.form(&form_fields(payload)?)
+    } else {
+        req.header("Content-Type", "application/json")
+            .body(payload.to_string())
+    };
+    let resp = req.send().await.map_err(|e| {
+        anyhow::anyhow!(
+            "failed to send test event to webhook '{name}': {}",
+            util::redact_secret_text(&e.to_string(), secret)
+        )
+    })?;
+    let status = resp.status();
+    let mut text = resp.text().await.unwrap_or_default();
+    if text.len() > 500 {
+        let end = (0..=500)
+            .rev()
+            .find(|&i| text.is_char_boundary(i))
+            .unwrap_or(0);
+        text.truncate(end);
+        text.push('…');
+    }
+    let mut row = serde_json::json!({
+        "webhook": name,
+        "url": url,
+        "status": status.as_u16(),
+        "ok": status.is_success(),
+        "response": text,
+    });
+    if let Some(secret) = secret {
+        util::redact_secret(&mut row, secret);
+    }
+    formatter::output(cfg, &row)?;
+    if !status.is_success() {
+        anyhow::bail!("webhook endpoint returned {status}");
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_opsgenie_body() {
+        let create = OpsgenieService {
+            name: Some("sre".into()),
+            api_key: Some("k".into()),
+            region: Some("eu".into()),
+            custom_url: None,
+        };
+        let body = opsgenie_body(&create, None).unwrap();
+        assert_eq!(body["data"]["type"], "opsgenie_service");
+        assert_eq!(body["data"]["attributes"]["opsgenie_api_key"], "k");
+        assert!(body["data"].get("id").is_none());
+
+        let rename = OpsgenieService {
+            name: Some("sre-eu".into()),
+            ..Default::default()
+        };
+        let body = opsgenie_body(&rename, Some("svc-1")).unwrap();
+        assert_eq!(body["data"]["id"], "svc-1");
+        assert_eq!(
+            body["data"]["attributes"],
+            serde_json::json!({"name": "sre-eu"})
+        );
+
+        assert!(opsgenie_body(&OpsgenieService::default(), Some("svc-1")).is_err());
+        let custom = OpsgenieService {
+            region: Some("custom".into()),
+            ..Default::default()
+        };
+        assert!(opsgenie_body(&custom, None).is_err());
+        let bad = OpsgenieService {
+            region: Some("apac".into()),
+            ..Default::default()
+        };
+        assert!(opsgenie_body(&bad, None).is_err());
+    }
+
+    #[test]
+    fn test_opsgenie_service_id() {
+        let resp = serde_json::json!({"data": [
+            {"id": "svc-1", "attributes": {"name": "sre"}},
+            {"id": "svc-2", "attributes": {"name": "db"}}
+        ]});
+        assert_eq!(opsgenie_service_id(&resp, "db").as_deref(), Some("svc-2"));
+        assert_eq!(opsgenie_service_id(&resp, "web"), None);
+    }
+
+    #[test]
+    fn test_webhook_body_injects_secret() {
+        let body = webhook_body(
+            "deploys",
+            "https://hooks.example.com/in",
+            Some(r#"{"title": "$EVENT_TITLE"}"#),
+            &[
+                "Authorization: Bearer {{secret}}".to_string(),
+                "X-Source: datadog".to_string(),
+            ],
+            "json",
+            Some("s3cr3t"),
+        )
+        .unwrap();
+        let headers: serde_json::Value =
+            serde_json::from_str(body["custom_headers"].as_str().unwrap()).unwrap();
+        assert_eq!(headers["Authorization"], "Bearer s3cr3t");
+        assert_eq!(headers["X-Source"], "datadog");
+        assert_eq!(body["payload"], r#"{"title": "$EVENT_TITLE"}"#);
+    }
+
+    #[test]
+    fn test_webhook_update_body() {
+        let body = webhook_update_body(Some("https://x/new"), None, &[], None, None).unwrap();
+        assert_eq!(body, serde_json::json!({"url": "https://x/new"}));
+        let body = webhook_update_body(
+            None,
+            None,
+            &["X-Api-Key: {{secret}}".to_string()],
+            Some("form"),
+            Some("k"),
+        )
+        .unwrap();
+        assert_eq!(body["encode_as"], "form");
+        assert_eq!(body["custom_headers"], r#"{"X-Api-Key":"k"}"#);
+        assert!(webhook_update_body(None, None, &[], None, None).is_err());
+    }
+
+    #[test]
+    fn test_render_sample_payload() {
+        let out = render_sample_payload(
+            r#"{"title": "$EVENT_TITLE", "host": "$HOSTNAME", "x": "$NOPE", "cost": "$5"}"#,
+        );
+        let v: serde_json::Value = serde_json::from_str(&out).unwrap();
+        assert_eq!(v["title"], "[Triggered] Test alert from pup");
+        assert_eq!(v["host"], "web-1");
+        assert_eq!(v["x"], "$NOPE");
+        assert_eq!(v["cost"], "$5");
+        assert!(
+            serde_json::from_str::<serde_json::Value>(&render_sample_payload(
+                DEFAULT_WEBHOOK_PAYLOAD
+            ))
+            .is_ok()
+        );
+    }
+
+    #[test]
+    fn test_form_fields() {
+        let fields = form_fields(r#"{"text": "hi", "n": 2}"#).unwrap();
+        assert_eq!(
+            fields,
+            vec![("text".into(), "hi".into()), ("n".into(), "2".into())]
+        );
+        assert!(form_fields("[1]").is_err());
+    }
+
+    #[test]
+    fn test_webhook_body_secret_and_placeholder_must_pair() {
+        let headers = ["Authorization: Bearer {{secret}}".to_string()];
+        let err = webhook_body("h", "https://x", None, &headers, "json", None).unwrap_err();
+        assert!(err.to_string().contains("--secret-from-env"), "{err}");
+        let err = webhook_body("h", "https://x", None, &[], "json", Some("s")).unwrap_err();
+        assert!(err.to_string().contains("nothing in the request"), "{err}");
+        assert!(webhook_body("h", "https://x", None, &["bad".into()], "json", None).is_err());
+    }
+}
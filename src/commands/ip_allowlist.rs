@@ -0,0 +1,340 @@
+//! IP allowlist: the CIDR blocks allowed to use the org's API and application
+//! keys. Every change is a read-modify-write of the whole list, since the API
+//! replaces the entries it is sent.
+
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+use crate::formatter;
+
+const PATH: &str = "/api/v2/ip_allowlist";
+
+/// A bare address becomes a single-host block (`/32` or `/128`); a block's
+/// address and prefix length are checked.
+pub fn normalize_cidr(input: &str) -> Result<String> {
+    let input = input.trim();
+    let (addr, prefix) = match input.split_once('/') {
+        Some((addr, prefix)) => (addr, Some(prefix)),
+        None => (input, None),
+    };
+    let Ok(ip) = addr.parse::<std::net::IpAddr>() else {
+        bail!("invalid CIDR {input:?}: expected an IP address or block like 203.0.113.0/24");
+    };
+    let max = if ip.is_ipv4() { 32 } else { 128 };
+    let prefix = match prefix {
+        None => max,
+        Some(p) => match p.parse::<u8>() {
+            Ok(n) if n <= max => n,
+            _ => bail!("invalid CIDR {input:?}: prefix length must be 0-{max}"),
+        },
+    };
+    Ok(format!("{ip}/{prefix}"))
+}
+
+/// `(cidr_block, note)` for each entry in a get response.
+pub fn entries(resp: &serde_json::Value) -> Vec<(String, String)> {
+    resp["data"]["attributes"]["entries"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|e| {
+            let attrs = &e["data"]["attributes"];
+            Some((
+                attrs["cidr_block"].as_str()?.to_string(),
+                attrs["note"].as_str().unwrap_or_default().to_string(),
+            ))
+        })
+        .collect()
+}
+
+fn is_enabled(resp: &serde_json::Value) -> bool {
+    resp["data"]["attributes"]["enabled"]
+        .as_bool()
+        .unwrap_or(false)
+}
+
+/// Edits for `update`, `enable`, and `disable`.
+#[derive(Debug, Default)]
+pub struct AllowlistChanges {
+    pub add: Vec<String>,
+    pub remove: Vec<String>,
+    /// Note stored on added entries.
+    pub note: Option<String>,
+    pub enabled: Option<bool>,
+}
+
+/// PATCH body for the allowlist after `changes`, plus one row per change.
+/// Adding a block that is already listed and removing one that isn't are
+/// errors, so a typo can't silently leave the old office IP in place.
+pub fn apply_changes(
+    current: &serde_json::Value,
+    changes: &AllowlistChanges,
+) -> Result<(serde_json::Value, Vec<serde_json::Value>)> {
+    let mut list = entries(current);
+    let mut rows = Vec::new();
+    for cidr in &changes.remove {
+        let cidr = normalize_cidr(cidr)?;
+        let Some(pos) = list.iter().position(|(c, _)| *c == cidr) else {
+            bail!("{cidr} is not in the IP allowlist");
+        };
+        list.remove(pos);
+        rows.push(serde_json::json!({ "change": "remove", "cidr_block": cidr }));
+    }
+    for cidr in &changes.add {
+        let cidr = normalize_cidr(cidr)?;
+        if list.iter().any(|(c, _)| *c == cidr) {
+            bail!("{cidr} is already in the IP allowlist");
+        }
+        let note = changes.note.clone().unwrap_or_default();
+        rows.push(serde_json::json!({ "change": "add", "cidr_block": cidr, "note": note }));
+        list.push((cidr, note));
+    }
+    let enabled = changes.enabled.unwrap_or_else(|| is_enabled(current));
+    if changes.enabled.is_some_and(|e| e != is_enabled(current)) {
+        let change = if enabled { "enable" } else { "disable" };
+        rows.push(serde_json::json!({ "change": change }));
+    }
+    if enabled && list.is_empty() {
+        bail!("refusing to enable an empty IP allowlist: it would block every API request");
+    }
+    let entries: Vec<serde_json::Value> = list
+        .into_iter()
+        .map(|(cidr, note)| {
+            serde_json::json!({
+                "data": {
+                    "type": "ip_allowlist_entry",
+                    "attributes": { "cidr_block": cidr, "note": note }
+                }
+            })
+        })
+        .collect();
+    let body = serde_json::json!({
+        "data": {
+            "type": "ip_allowlist",
+            "attributes": { "enabled": enabled, "entries": entries }
+        }
+    });
+    Ok((body, rows))
+}
+
+/// One row per difference between the current allowlist and a full
+/// replacement body, in the same shape as `apply_changes` rows.
+pub fn replacement_rows(
+    current: &serde_json::Value,
+    body: &serde_json::Value,
+) -> Result<Vec<serde_json::Value>> {
+    let before = entries(current);
+    let after = entries(body);
+    let enabled = body["data"]["attributes"]["enabled"].as_bool();
+    if enabled.unwrap_or_else(|| is_enabled(current)) && after.is_empty() {
+        bail!("refusing to enable an empty IP allowlist: it would block every API request");
+    }
+    let mut rows = Vec::new();
+    for (cidr, _) in &before {
+        if !after.iter().any(|(c, _)| c == cidr) {
+            rows.push(serde_json::json!({ "change": "remove", "cidr_block": cidr }));
+        }
+    }
+    for (cidr, note) in &after {
+        match before.iter().find(|(c, _)| c == cidr) {
+            None => {
+                rows.push(serde_json::json!({ "change": "add", "cidr_block": cidr, "note": note }))
+            }
+            Some((_, old)) if old != note => {
+                rows.push(serde_json::json!({ "change": "note", "cidr_block": cidr, "note": note }))
+            }
+            Some(_) => {}
+        }
+    }
+    if let Some(enabled) = enabled.filter(|e| *e != is_enabled(current)) {
+        let change = if enabled { "enable" } else { "disable" };
+        rows.push(serde_json::json!({ "change": change }));
+    }
+    Ok(rows)
+}
+
+/// Flatten a response into `enabled` plus an `entries` table.
+pub fn summary(resp: &serde_json::Value) -> serde_json::Value {
+    let entries: Vec<serde_json::Value> = entries(resp)
+        .into_iter()
+        .map(|(cidr, note)| serde_json::json!({ "cidr_block": cidr, "note": note }))
+        .collect();
+    serde_json::json!({ "enabled": is_enabled(resp), "entries": entries })
+}
+
+async fn fetch(cfg: &Config) -> Result<serde_json::Value> {
+    crate::api::get(cfg, PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get IP allowlist", e))
+}
+
+pub async fn get(cfg: &Config) -> Result<()> {
+    let resp = fetch(cfg).await?;
+    formatter::output(cfg, &summary(&resp))
+}
+
+/// Replace the allowlist with a JSON file (full request body). The changes
+/// against the current list are shown, and confirmed unless `dry_run`.
+pub async fn update_from_file(cfg: &Config, file: &str, dry_run: bool) -> Result<()> {
+    let body: serde_json::Value = crate::util::read_json_file(file)?;
+    let current = fetch(cfg).await?;
+    let rows = replacement_rows(&current, &body)?;
+    if rows.is_empty() {
+        eprintln!("IP allowlist already up to date.");
+        return formatter::output(cfg, &summary(&current));
+    }
+    if dry_run {
+        eprintln!("Dry run: no changes made.");
+        return formatter::output(cfg, &rows);
+    }
+    let mut prompt = crate::confirm::Destructive::new("replace", "IP allowlist", "")
+        .detail(format!("{file} replaces the whole list:"));
+    for row in &rows {
+        let change = row["change"].as_str().unwrap_or_default();
+        let cidr = row["cidr_block"].as_str().unwrap_or_default();
+        prompt = prompt.detail(format!("  {change} {cidr}").trim_end().to_string());
+    }
+    if !prompt.confirm(cfg)? {
+        return Ok(());
+    }
+    let resp = crate::api::patch(cfg, PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update IP allowlist", e))?;
+    formatter::output(cfg, &summary(&resp))
+}
+
+/// Read the current allowlist, apply `changes`, and write it back.
+pub async fn update(cfg: &Config, changes: &AllowlistChanges, dry_run: bool) -> Result<()> {
+    let current = fetch(cfg).await?;
+    let (body, rows) = apply_changes(&current, changes)?;
+    if rows.is_empty() {
+        eprintln!("IP allowlist already up to date.");
+        return formatter::output(cfg, &summary(&current));
+    }
+    if dry_run {
+        eprintln!("Dry run: no changes made.");
+        return formatter::output(cfg, &rows);
+    }
+    let resp = crate::api::patch(cfg, PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update IP allowlist", e))?;
+    formatter::output(cfg, &summary(&resp))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn current(enabled: bool) -> serde_json::Value {
+        serde_json::json!({"data": {"type": "ip_allowlist", "attributes": {
+            "enabled": enabled,
+            "entries": [
+                {"data": {"type": "ip_allowlist_entry", "attributes": {"cidr_block": "198.51.100.7/32", "note": "old office"}}},
+                {"data": {"type": "ip_allowlist_entry", "attributes": {"cidr_block": "10.0.0.0/8", "note": "vpn"}}}
+            ]
+        }}})
+    }
+
+    #[test]
+    fn test_normalize_cidr() {
+        assert_eq!(normalize_cidr("203.0.113.5").unwrap(), "203.0.113.5/32");
+        assert_eq!(
+            normalize_cidr(" 203.0.113.0/24 ").unwrap(),
+            "203.0.113.0/24"
+        );
+        assert_eq!(normalize_cidr("2001:db8::1").unwrap(), "2001:db8::1/128");
+        assert!(normalize_cidr("203.0.113.0/33").is_err());
+        assert!(normalize_cidr("office").is_err());
+    }
+
+    #[test]
+    fn test_apply_changes_rotates_cidr() {
+        let changes = AllowlistChanges {
+            add: vec!["203.0.113.9".into()],
+            remove: vec!["198.51.100.7".into()],
+            note: Some("new office".into()),
+            enabled: None,
+        };
+        let (body, rows) = apply_changes(&current(true), &changes).unwrap();
+        let attrs = &body["data"]["attributes"];
+        assert_eq!(attrs["enabled"], true);
+        let cidrs: Vec<&str> = attrs["entries"]
+            .as_array()
+            .unwrap()
+            .iter()
+            .map(|e| e["data"]["attributes"]["cidr_block"].as_str().unwrap())
+            .collect();
+        assert_eq!(cidrs, ["10.0.0.0/8", "203.0.113.9/32"]);
+        assert_eq!(rows.len(), 2);
+        assert_eq!(rows[1]["note"], "new office");
+    }
+
+    #[test]
+    fn test_apply_changes_rejects_mistakes() {
+        let missing = AllowlistChanges {
+            remove: vec!["192.0.2.1".into()],
+            ..Default::default()
+        };
+        assert!(apply_changes(&current(true), &missing).is_err());
+        let duplicate = AllowlistChanges {
+            add: vec!["10.0.0.0/8".into()],
+            ..Default::default()
+        };
+        assert!(apply_changes(&current(true), &duplicate).is_err());
+        let empty = serde_json::json!({"data": {"attributes": {"enabled": false, "entries": []}}});
+        let enable = AllowlistChanges {
+            enabled: Some(true),
+            ..Default::default()
+        };
+        assert!(apply_changes(&empty, &enable).is_err());
+    }
+
+    #[test]
+    fn test_replacement_rows() {
+        let body = serde_json::json!({"data": {"type": "ip_allowlist", "attributes": {
+            "enabled": true,
+            "entries": [
+                {"data": {"type": "ip_allowlist_entry", "attributes": {"cidr_block": "10.0.0.0/8", "note": "corp vpn"}}},
+                {"data": {"type": "ip_allowlist_entry", "attributes": {"cidr_block": "203.0.113.9/32", "note": "new office"}}}
+            ]
+        }}});
+        let rows = replacement_rows(&current(true), &body).unwrap();
+        assert_eq!(
+            rows,
+            vec![
+                serde_json::json!({"change": "remove", "cidr_block": "198.51.100.7/32"}),
+                serde_json::json!({"change": "note", "cidr_block": "10.0.0.0/8", "note": "corp vpn"}),
+                serde_json::json!({"change": "add", "cidr_block": "203.0.113.9/32", "note": "new office"}),
+            ]
+        );
+        assert!(replacement_rows(&current(true), &current(true))
+            .unwrap()
+            .is_empty());
+        let emptied = serde_json::json!({"data": {"attributes": {"entries": []}}});
+        assert!(replacement_rows(&current(true), &emptied).is_err());
+        assert_eq!(
+            replacement_rows(&current(false), &emptied).unwrap().len(),
+            2
+        );
+    }
+
+    #[test]
+    fn test_apply_changes_toggle() {
+        let disable = AllowlistChanges {
+            enabled: Some(false),
+            ..Default::default()
+        };
+        let (body, rows) = apply_changes(&current(true), &disable).unwrap();
+        assert_eq!(body["data"]["attributes"]["enabled"], false);
+        assert_eq!(
+            body["data"]["attributes"]["entries"]
+                .as_array()
+                .unwrap()
+                .len(),
+            2
+        );
+        assert_eq!(rows, vec![serde_json::json!({"change": "disable"})]);
+        let (_, rows) = apply_changes(&current(false), &disable).unwrap();
+        assert!(rows.is_empty());
+    }
+}
@@ -44,6 +44,7 @@ pub async fn search(
     from: String,
     to: String,
     limit: i32,
+    indexes: Vec<String>,
     storage: Option<String>,
 ) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
@@ -64,6 +65,9 @@ pub async fn search(
     if let Some(tier) = storage_tier {
         filter = filter.storage_tier(tier);
     }
+    if !indexes.is_empty() {
+        filter = filter.indexes(indexes);
+    }
 
     let body = LogsListRequest::new()
         .filter(filter)
@@ -96,7 +100,7 @@ pub async fn search(
     } else {
         None
     };
-    formatter::format_and_print(&resp, &cfg.output_format, cfg.agent_mode, meta.as_ref())?;
+    formatter::format_and_print(&resp, cfg, meta.as_ref())?;
     Ok(())
 }
 
@@ -107,6 +111,7 @@ pub async fn search(
     from: String,
     to: String,
     limit: i32,
+    indexes: Vec<String>,
     storage: Option<String>,
 ) -> Result<()> {
     let from_ms = util::parse_time_to_unix_millis(&from)?;
@@ -119,6 +124,9 @@ pub async fn search(
     if let Some(tier) = storage {
         filter["storage_tier"] = serde_json::Value::String(tier);
     }
+    if !indexes.is_empty() {
+        filter["indexes"] = serde_json::json!(indexes);
+    }
     let body = serde_json::json!({
         "filter": filter,
         "page": { "limit": limit },
@@ -128,6 +136,53 @@ pub async fn search(
     crate::formatter::output(cfg, &data)
 }
 
+/// Search logs across every page, following `meta.page.after` (`--all`).
+pub async fn search_all_pages(
+    cfg: &Config,
+    query: String,
+    from: String,
+    to: String,
+    indexes: Vec<String>,
+    storage: Option<String>,
+    max_items: usize,
+) -> Result<()> {
+    let from_ms = util::parse_time_to_unix_millis(&from)?;
+    let to_ms = util::parse_time_to_unix_millis(&to)?;
+    let mut filter = serde_json::json!({
+        "query": query,
+        "from": from_ms.to_string(),
+        "to": to_ms.to_string()
+    });
+    if let Some(tier) = storage {
+        filter["storage_tier"] = serde_json::Value::String(tier);
+    }
+    if !indexes.is_empty() {
+        filter["indexes"] = serde_json::json!(indexes);
+    }
+    let limit = util::page_size(LOGS_SEARCH_MAX_PAGE, max_items);
+    let collected = util::collect_pages(
+        util::Paging::Cursor {
+            next: "/meta/page/after",
+        },
+        "/data",
+        max_items,
+        |page| {
+            let mut page_body = serde_json::json!({ "limit": limit });
+            if let Some(cursor) = page.cursor {
+                page_body["cursor"] = serde_json::Value::String(cursor);
+            }
+            let body = serde_json::json!({
+                "filter": filter,
+                "page": page_body,
+                "sort": "-timestamp"
+            });
+            async move { crate::api::post(cfg, "/api/v2/logs/events/search", &body).await }
+        },
+    )
+    .await?;
+    util::print_collected(cfg, "logs search", collected)
+}
+
 /// Alias for `search` with the same interface.
 pub async fn list(
     cfg: &Config,
@@ -135,9 +190,10 @@ pub async fn list(
     from: String,
     to: String,
     limit: i32,
+    indexes: Vec<String>,
     storage: Option<String>,
 ) -> Result<()> {
-    search(cfg, query, from, to, limit, storage).await
+    search(cfg, query, from, to, limit, indexes, storage).await
 }
 
 /// Alias for `search` with the same interface.
@@ -147,9 +203,10 @@ pub async fn query(
     from: String,
     to: String,
     limit: i32,
+    indexes: Vec<String>,
     storage: Option<String>,
 ) -> Result<()> {
-    search(cfg, query, from, to, limit, storage).await
+    search(cfg, query, from, to, limit, indexes, storage).await
 }
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -158,6 +215,7 @@ pub async fn aggregate(
     query: String,
     from: String,
     to: String,
+    indexes: Vec<String>,
     storage: Option<String>,
 ) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
@@ -178,6 +236,9 @@ pub async fn aggregate(
     if let Some(tier) = storage_tier {
         filter = filter.storage_tier(tier);
     }
+    if !indexes.is_empty() {
+        filter = filter.indexes(indexes);
+    }
 
     let body = LogsAggregateRequest::new()
         .filter(filter)
@@ -198,6 +259,7 @@ pub async fn aggregate(
     query: String,
     from: String,
     to: String,
+    indexes: Vec<String>,
     storage: Option<String>,
 ) -> Result<()> {
     let from_ms = util::parse_time_to_unix_millis(&from)?;
@@ -210,6 +272,9 @@ pub async fn aggregate(
     if let Some(tier) = storage {
         filter["storage_tier"] = serde_json::Value::String(tier);
     }
+    if !indexes.is_empty() {
+        filter["indexes"] = serde_json::json!(indexes);
+    }
     let body = serde_json::json!({
         "filter": filter,
         "compute": [{ "type": "count" }]
@@ -289,6 +354,161 @@ pub async fn archives_delete(cfg: &Config, archive_id: &str) -> Result<()> {
     Ok(())
 }
 
+pub async fn archives_create(cfg: &Config, file: &str) -> Result<()> {
+    let body: serde_json::Value = util::read_json_file(file)?;
+    let data = crate::api::post(cfg, "/api/v2/logs/config/archives", &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create log archive", e))?;
+    formatter::output(cfg, &data)
+}
+
+pub async fn archives_update(cfg: &Config, archive_id: &str, file: &str) -> Result<()> {
+    let body: serde_json::Value = util::read_json_file(file)?;
+    let path = format!("/api/v2/logs/config/archives/{archive_id}");
+    let data = crate::api::put(cfg, &path, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update log archive", e))?;
+    formatter::output(cfg, &data)
+}
+
+// ---------------------------------------------------------------------------
+// Archive validation
+// ---------------------------------------------------------------------------
+
+/// One step of an archive connection check.
+#[derive(serde::Serialize, Debug, PartialEq)]
+pub struct ArchiveCheck {
+    pub check: &'static str,
+    /// `pass`, `fail`, or `unknown`.
+    pub status: &'static str,
+    pub detail: String,
+}
+
+/// Describe the archive destination and the identity Datadog uses to reach it.
+fn archive_destination_checks(destination: &serde_json::Value) -> Vec<ArchiveCheck> {
+    let kind = destination["type"].as_str().unwrap_or("");
+    let (target, identity) = match kind {
+        "s3" => (
+            destination["bucket"].as_str().map(String::from),
+            match (
+                destination
+                    .pointer("/integration/account_id")
+                    .and_then(|v| v.as_str()),
+                destination
+                    .pointer("/integration/role_name")
+                    .and_then(|v| v.as_str()),
+            ) {
+                (Some(account), Some(role)) => Some(format!("arn:aws:iam::{account}:role/{role}")),
+                _ => None,
+            },
+        ),
+        "gcs" => (
+            destination["bucket"].as_str().map(String::from),
+            destination
+                .pointer("/integration/client_email")
+                .and_then(|v| v.as_str())
+                .map(String::from),
+        ),
+        "azure" => (
+            match (
+                destination["storage_account"].as_str(),
+                destination["container"].as_str(),
+            ) {
+                (Some(account), Some(container)) => Some(format!("{account}/{container}")),
+                _ => None,
+            },
+            destination
+                .pointer("/integration/client_id")
+                .and_then(|v| v.as_str())
+                .map(|c| format!("app {c}")),
+        ),
+        _ => (None, None),
+    };
+    let path = destination["path"]
+        .as_str()
+        .filter(|p| !p.is_empty())
+        .map(|p| format!("/{}", p.trim_start_matches('/')))
+        .unwrap_or_default();
+    vec![
+        match target {
+            Some(t) => ArchiveCheck {
+                check: "destination",
+                status: "pass",
+                detail: format!("{kind}://{t}{path}"),
+            },
+            None => ArchiveCheck {
+                check: "destination",
+                status: "fail",
+                detail: format!("incomplete {kind:?} destination"),
+            },
+        },
+        match identity {
+            Some(i) => ArchiveCheck {
+                check: "integration",
+                status: "pass",
+                detail: i,
+            },
+            None => ArchiveCheck {
+                check: "integration",
+                status: "fail",
+                detail: "no cloud integration role configured for the destination".into(),
+            },
+        },
+    ]
+}
+
+/// Map the archive `state` Datadog reports from its last write to a check.
+fn archive_state_check(state: Option<&str>) -> ArchiveCheck {
+    let (status, detail) = match state {
+        Some("WORKING") => ("pass", "archive is writing logs"),
+        Some("WORKING_AUTH_LEGACY") => (
+            "pass",
+            "archive is writing logs using legacy credentials; switch to a role-based integration",
+        ),
+        Some("FAILING") => ("fail", "Datadog reports the archive is failing to write"),
+        _ => (
+            "unknown",
+            "Datadog has not reported a state for this archive yet",
+        ),
+    };
+    ArchiveCheck {
+        check: "state",
+        status,
+        detail: detail.into(),
+    }
+}
+
+/// Check an archive's destination, integration role, and the state Datadog
+/// reports from its last write, without changing the archive. Exits non-zero
+/// when any check fails so provisioning pipelines can gate on it.
+pub async fn archives_validate(cfg: &Config, archive_id: &str) -> Result<()> {
+    let path = format!("/api/v2/logs/config/archives/{archive_id}");
+    let archive = crate::api::get(cfg, &path, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get log archive", e))?;
+    let attrs = archive
+        .pointer("/data/attributes")
+        .cloned()
+        .unwrap_or(serde_json::Value::Null);
+
+    let mut checks = archive_destination_checks(&attrs["destination"]);
+    let state = attrs["state"].as_str();
+    checks.push(archive_state_check(state));
+
+    let failed = checks.iter().filter(|c| c.status == "fail").count();
+    let report = serde_json::json!({
+        "archive_id": archive_id,
+        "name": attrs["name"],
+        "valid": failed == 0,
+        "checks": checks,
+    });
+    formatter::output(cfg, &report)?;
+    if failed > 0 {
+        anyhow::bail!("log archive {archive_id} failed {failed} validation check(s)");
+    }
+    Ok(())
+}
+
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn custom_destinations_list(cfg: &Config) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
@@ -336,6 +556,30 @@ pub async fn custom_destinations_get(cfg: &Config, destination_id: &str) -> Resu
     crate::formatter::output(cfg, &data)
 }
 
+/// Create a custom destination from a JSON body; `{{secret}}` anywhere in it
+/// (an auth header value or password) is filled from `secret` at send time.
+pub async fn custom_destinations_create(
+    cfg: &Config,
+    body: &str,
+    secret: Option<String>,
+) -> Result<()> {
+    let mut body: serde_json::Value = util::read_json_body(body)?;
+    let secret = secret.as_deref();
+    util::apply_secret(&mut body, secret)?;
+    let mut resp = crate::api::post(cfg, "/api/v2/logs/config/custom_destinations", &body)
+        .await
+        .map_err(|e| {
+            anyhow::anyhow!(
+                "failed to create custom destination: {}",
+                util::redact_secret_text(&format!("{e:?}"), secret)
+            )
+        })?;
+    if let Some(secret) = secret {
+        util::redact_secret(&mut resp, secret);
+    }
+    formatter::output(cfg, &resp)
+}
+
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn metrics_list(cfg: &Config) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
@@ -436,3 +680,1003 @@ pub async fn restriction_queries_get(cfg: &Config, query_id: &str) -> Result<()>
     let data = crate::api::get(cfg, &path, &[]).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Indexes
+// ---------------------------------------------------------------------------
+
+const INDEXES_PATH: &str = "/api/v1/logs/config/indexes";
+
+pub async fn indexes_list(cfg: &Config) -> Result<()> {
+    let data = crate::api::get(cfg, INDEXES_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list log indexes", e))?;
+    formatter::output(cfg, &data)
+}
+
+pub async fn indexes_get(cfg: &Config, name: &str) -> Result<()> {
+    let path = format!("{INDEXES_PATH}/{name}");
+    let data = crate::api::get(cfg, &path, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get log index", e))?;
+    formatter::output(cfg, &data)
+}
+
+/// Exclusion filter changes for `logs indexes update`, applied in order:
+/// removals, then additions, then sample rates.
+#[derive(Debug, Default)]
+pub struct ExclusionEdits {
+    /// `name=query`; an existing filter with that name gets the new query.
+    pub add: Vec<String>,
+    pub remove: Vec<String>,
+    /// `name=rate`, where rate is the fraction of matching logs excluded.
+    pub sample_rates: Vec<String>,
+}
+
+impl ExclusionEdits {
+    fn is_empty(&self) -> bool {
+        self.add.is_empty() && self.remove.is_empty() && self.sample_rates.is_empty()
+    }
+}
+
+fn split_pair<'a>(flag: &str, arg: &'a str) -> Result<(&'a str, &'a str)> {
+    match arg.split_once('=') {
+        Some((name, value)) if !name.trim().is_empty() => Ok((name.trim(), value.trim())),
+        _ => anyhow::bail!("invalid {flag} {arg:?}: expected name=value"),
+    }
+}
+
+/// Rebuild an index update request from a fetched index with `edits`
+/// applied. Fields the update endpoint rejects (`name`, `is_rate_limited`)
+/// are dropped.
+pub fn index_update_body(
+    index: &serde_json::Value,
+    edits: &ExclusionEdits,
+) -> Result<serde_json::Value> {
+    let index_name = index["name"].as_str().unwrap_or_default();
+    let mut body = serde_json::Map::new();
+    for key in [
+        "filter",
+        "exclusion_filters",
+        "daily_limit",
+        "daily_limit_reset",
+        "daily_limit_warning_threshold_percentage",
+        "disable_daily_limit",
+        "num_retention_days",
+        "num_flex_logs_retention_days",
+    ] {
+        if let Some(v) = index.get(key).filter(|v| !v.is_null()) {
+            body.insert(key.to_string(), v.clone());
+        }
+    }
+    let mut filters: Vec<serde_json::Value> = body
+        .get("exclusion_filters")
+        .and_then(|f| f.as_array())
+        .cloned()
+        .unwrap_or_default();
+    let position = |filters: &[serde_json::Value], name: &str| {
+        filters
+            .iter()
+            .position(|f| f["name"].as_str() == Some(name))
+    };
+
+    for name in &edits.remove {
+        let Some(i) = position(&filters, name) else {
+            return Err(crate::exit_code::Failure::not_found(format!(
+                "no exclusion filter named {name:?} on index {index_name:?}"
+            )));
+        };
+        filters.remove(i);
+    }
+    for arg in &edits.add {
+        let (name, query) = split_pair("--add-exclusion", arg)?;
+        match position(&filters, name) {
+            Some(i) => filters[i]["filter"]["query"] = serde_json::json!(query),
+            None => filters.push(serde_json::json!({
+                "name": name,
+                "is_enabled": true,
+                "filter": {"query": query, "sample_rate": 1.0}
+            })),
+        }
+    }
+    for arg in &edits.sample_rates {
+        let (name, rate) = split_pair("--sample-rate", arg)?;
+        let rate: f64 = rate
+            .parse()
+            .ok()
+            .filter(|r| (0.0..=1.0).contains(r))
+            .ok_or_else(|| {
+                anyhow::anyhow!("invalid --sample-rate {arg:?}: rate must be between 0 and 1")
+            })?;
+        let Some(i) = position(&filters, name) else {
+            return Err(crate::exit_code::Failure::not_found(format!(
+                "no exclusion filter named {name:?} on index {index_name:?}"
+            )));
+        };
+        filters[i]["filter"]["sample_rate"] = serde_json::json!(rate);
+    }
+    body.insert(
+        "exclusion_filters".to_string(),
+        serde_json::Value::Array(filters),
+    );
+    Ok(serde_json::Value::Object(body))
+}
+
+/// Edit an index's exclusion filters. The update endpoint replaces the whole
+/// index definition, so the current one is fetched and re-submitted.
+pub async fn indexes_update(cfg: &Config, name: &str, edits: &ExclusionEdits) -> Result<()> {
+    if edits.is_empty() {
+        anyhow::bail!(
+            "invalid arguments: pass --add-exclusion, --remove-exclusion, or --sample-rate"
+        );
+    }
+    let path = format!("{INDEXES_PATH}/{name}");
+    let index = crate::api::get(cfg, &path, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get log index", e))?;
+    let body = index_update_body(&index, edits)?;
+    let data = crate::api::put(cfg, &path, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update log index", e))?;
+    formatter::output(cfg, &data)
+}
+
+// ---------------------------------------------------------------------------
+// Pipelines
+// ---------------------------------------------------------------------------
+
+const PIPELINES_PATH: &str = "/api/v1/logs/config/pipelines";
+const PIPELINE_ORDER_PATH: &str = "/api/v1/logs/config/pipeline-order";
+
+pub async fn pipelines_list(cfg: &Config) -> Result<()> {
+    let data = crate::api::get(cfg, PIPELINES_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list log pipelines", e))?;
+    formatter::output(cfg, &data)
+}
+
+pub async fn pipelines_get(cfg: &Config, pipeline_id: &str) -> Result<()> {
+    let path = format!("{PIPELINES_PATH}/{pipeline_id}");
+    let data = crate::api::get(cfg, &path, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get log pipeline", e))?;
+    formatter::output(cfg, &data)
+}
+
+pub async fn pipelines_create(cfg: &Config, file: &str) -> Result<()> {
+    let body: serde_json::Value = util::read_json_file(file)?;
+    let data = crate::api::post(cfg, PIPELINES_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create log pipeline", e))?;
+    formatter::output(cfg, &data)
+}
+
+pub async fn pipelines_update(cfg: &Config, pipeline_id: &str, file: &str) -> Result<()> {
+    let body: serde_json::Value = util::read_json_file(file)?;
+    let path = format!("{PIPELINES_PATH}/{pipeline_id}");
+    let data = crate::api::put(cfg, &path, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update log pipeline", e))?;
+    formatter::output(cfg, &data)
+}
+
+pub async fn pipelines_delete(cfg: &Config, pipeline_id: &str) -> Result<()> {
+    let path = format!("{PIPELINES_PATH}/{pipeline_id}");
+    crate::api::delete(cfg, &path)
+        .await
+        .map_err(|e| crate::api::failed("failed to delete log pipeline", e))?;
+    println!("Log pipeline {pipeline_id} deleted.");
+    Ok(())
+}
+
+/// New processing order: `first` in the order given, then every other
+/// pipeline in its current relative order. The API replaces the whole order,
+/// so every current pipeline must appear exactly once.
+pub fn pipeline_order(current: &[String], first: &[String]) -> Result<Vec<String>> {
+    let mut order: Vec<String> = Vec::new();
+    for id in first {
+        if !current.contains(id) {
+            anyhow::bail!("invalid pipeline id {id:?}: not in the current pipeline order");
+        }
+        if !order.contains(id) {
+            order.push(id.clone());
+        }
+    }
+    let rest: Vec<String> = current
+        .iter()
+        .filter(|id| !order.contains(id))
+        .cloned()
+        .collect();
+    order.extend(rest);
+    Ok(order)
+}
+
+/// Move `pipeline_ids` to the front of the processing order.
+pub async fn pipelines_reorder(cfg: &Config, pipeline_ids: &[String]) -> Result<()> {
+    let resp = crate::api::get(cfg, PIPELINE_ORDER_PATH, &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get log pipeline order", e))?;
+    let current: Vec<String> = resp["pipeline_ids"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|id| id.as_str().map(String::from))
+        .collect();
+    let order = pipeline_order(&current, pipeline_ids)?;
+    if order == current {
+        eprintln!("Pipeline order unchanged.");
+        return formatter::output(cfg, &resp);
+    }
+    let body = serde_json::json!({ "pipeline_ids": order });
+    let data = crate::api::put(cfg, PIPELINE_ORDER_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update log pipeline order", e))?;
+    formatter::output(cfg, &data)
+}
+
+// ---------------------------------------------------------------------------
+// Tail (poll the search endpoint and stream new events)
+// ---------------------------------------------------------------------------
+
+/// Each poll re-reads this far behind the newest event seen, so logs indexed
+/// late (ingestion lag) are still picked up; duplicates are dropped by id.
+const TAIL_LOOKBACK_MS: i64 = 30_000;
+
+/// Wait after a 429 whose response carried no rate-limit reset header.
+const TAIL_RATE_LIMIT_BACKOFF: std::time::Duration = std::time::Duration::from_secs(30);
+
+const ANSI_RED: &str = "\x1b[31m";
+const ANSI_YELLOW: &str = "\x1b[33m";
+const ANSI_GREEN: &str = "\x1b[32m";
+const ANSI_DIM: &str = "\x1b[2m";
+const ANSI_RESET: &str = "\x1b[0m";
+
+/// One log event from the search endpoint, with the raw item kept for JSON output.
+#[derive(Debug, Clone)]
+pub struct TailEvent {
+    pub id: String,
+    pub timestamp_ms: i64,
+    pub raw: serde_json::Value,
+}
+
+impl TailEvent {
+    fn attr(&self, key: &str) -> &str {
+        self.raw
+            .pointer(&format!("/attributes/{key}"))
+            .and_then(|v| v.as_str())
+            .unwrap_or("")
+    }
+}
+
+/// Parse one search result item. Items without an id or a parseable
+/// timestamp are skipped.
+pub fn tail_event(item: &serde_json::Value) -> Option<TailEvent> {
+    let id = item["id"].as_str()?.to_string();
+    let ts = item.pointer("/attributes/timestamp")?.as_str()?;
+    let timestamp_ms = chrono::DateTime::parse_from_rfc3339(ts)
+        .ok()?
+        .timestamp_millis();
+    Some(TailEvent {
+        id,
+        timestamp_ms,
+        raw: item.clone(),
+    })
+}
+
+/// Tracks what has been printed across polls.
+pub struct TailCursor {
+    seen: std::collections::HashMap<String, i64>,
+    newest_ms: i64,
+    /// Events older than this are never printed (before `--from`, or older
+    /// than the backlog shown on start).
+    floor_ms: i64,
+}
+
+impl TailCursor {
+    pub fn new(from_ms: i64) -> Self {
+        Self {
+            seen: Default::default(),
+            newest_ms: from_ms,
+            floor_ms: from_ms,
+        }
+    }
+
+    /// Lower time bound for the next poll.
+    pub fn next_from_ms(&self) -> i64 {
+        (self.newest_ms - TAIL_LOOKBACK_MS).max(self.floor_ms)
+    }
+
+    /// Events not printed before, oldest first; records them as seen and
+    /// forgets ids that have fallen out of the lookback window.
+    pub fn fresh(&mut self, mut events: Vec<TailEvent>) -> Vec<TailEvent> {
+        events.sort_by_key(|e| e.timestamp_ms);
+        let floor = self.floor_ms;
+        let fresh: Vec<TailEvent> = events
+            .into_iter()
+            .filter(|e| e.timestamp_ms >= floor)
+            .filter(|e| self.seen.insert(e.id.clone(), e.timestamp_ms).is_none())
+            .collect();
+        if let Some(last) = fresh.last() {
+            self.newest_ms = self.newest_ms.max(last.timestamp_ms);
+        }
+        let horizon = self.next_from_ms();
+        self.seen.retain(|_, ts| *ts >= horizon);
+        fresh
+    }
+}
+
+fn severity_color(status: &str) -> &'static str {
+    match status.to_lowercase().as_str() {
+        "emergency" | "emerg" | "alert" | "critical" | "crit" | "error" | "err" => ANSI_RED,
+        "warning" | "warn" => ANSI_YELLOW,
+        "notice" | "info" | "ok" => ANSI_GREEN,
+        _ => ANSI_DIM,
+    }
+}
+
+/// Render an event as `<time> <STATUS> <service> <host>: <message>`.
+pub fn format_tail_event(event: &TailEvent, color: bool) -> String {
+    let status = event.attr("status");
+    let time = chrono::DateTime::from_timestamp_millis(event.timestamp_ms)
+        .map(|t| t.format("%Y-%m-%dT%H:%M:%S%.3fZ").to_string())
+        .unwrap_or_default();
+    let label = format!("{:<5}", status.to_uppercase());
+    let label = if color {
+        format!("{}{label}{ANSI_RESET}", severity_color(status))
+    } else {
+        label
+    };
+    let source = [event.attr("service"), event.attr("host")]
+        .iter()
+        .filter(|s| !s.is_empty())
+        .copied()
+        .collect::<Vec<_>>()
+        .join(" ");
+    let message = event.attr("message").trim_end();
+    if source.is_empty() {
+        format!("{time} {label} {message}")
+    } else {
+        format!("{time} {label} {source}: {message}")
+    }
+}
+
+fn tail_filter(query: &str, indexes: &[String], from_ms: i64) -> serde_json::Value {
+    let mut filter = serde_json::json!({
+        "query": query,
+        "from": from_ms.to_string(),
+        "to": "now"
+    });
+    if !indexes.is_empty() {
+        filter["indexes"] = serde_json::json!(indexes);
+    }
+    filter
+}
+
+/// Fetch every event in `[from_ms, now]`, oldest first, up to `max` events.
+async fn fetch_tail_events(
+    cfg: &Config,
+    query: &str,
+    indexes: &[String],
+    from_ms: i64,
+    max: usize,
+) -> Result<Vec<TailEvent>> {
+    let filter = tail_filter(query, indexes, from_ms);
+    let limit = util::page_size(LOGS_SEARCH_MAX_PAGE, max);
+    let collected = util::collect_pages(
+        util::Paging::Cursor {
+            next: "/meta/page/after",
+        },
+        "/data",
+        max,
+        |page| {
+            let mut page_body = serde_json::json!({ "limit": limit });
+            if let Some(cursor) = page.cursor {
+                page_body["cursor"] = serde_json::Value::String(cursor);
+            }
+            let body = serde_json::json!({
+                "filter": filter,
+                "page": page_body,
+                "sort": "timestamp"
+            });
+            async move { crate::api::post(cfg, "/api/v2/logs/events/search", &body).await }
+        },
+    )
+    .await?;
+    Ok(collected.items.iter().filter_map(tail_event).collect())
+}
+
+/// Which stream format to print: JSON lines, or colorized text.
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum TailFormat {
+    Json,
+    Pretty,
+}
+
+impl std::str::FromStr for TailFormat {
+    type Err = anyhow::Error;
+    fn from_str(s: &str) -> Result<Self> {
+        match s.to_lowercase().as_str() {
+            "json" => Ok(TailFormat::Json),
+            "pretty" => Ok(TailFormat::Pretty),
+            _ => anyhow::bail!("invalid --format {s:?} (expected json or pretty)"),
+        }
+    }
+}
+
+fn print_tail_event(event: &TailEvent, format: TailFormat, color: bool) -> Result<()> {
+    match format {
+        // One object per line so the stream can be piped into jq.
+        TailFormat::Json => println!("{}", serde_json::to_string(&event.raw)?),
+        TailFormat::Pretty => println!("{}", format_tail_event(event, color)),
+    }
+    Ok(())
+}
+
+/// Print the last `lines` events matching `query` since `from`, oldest first.
+/// With `follow`, keep polling every `interval_secs` and print new events as
+/// they are indexed until interrupted.
+#[allow(clippy::too_many_arguments)]
+pub async fn tail(
+    cfg: &Config,
+    query: String,
+    from: String,
+    lines: usize,
+    indexes: Vec<String>,
+    format: Option<String>,
+    follow: bool,
+    interval_secs: u64,
+) -> Result<()> {
+    let format = match format {
+        Some(f) => f.parse()?,
+        None if cfg.agent_mode => TailFormat::Json,
+        None => TailFormat::Pretty,
+    };
+    #[cfg(not(target_arch = "wasm32"))]
+    let color = {
+        use std::io::IsTerminal;
+        std::io::stdout().is_terminal() && std::env::var_os("NO_COLOR").is_none()
+    };
+    #[cfg(target_arch = "wasm32")]
+    let color = false;
+
+    let from_ms = util::parse_time_to_unix_millis(&from)?;
+    let mut cursor = TailCursor::new(from_ms);
+    // Newest-first so `lines` keeps the most recent events, then print oldest first.
+    let body = serde_json::json!({
+        "filter": tail_filter(&query, &indexes, from_ms),
+        "page": { "limit": lines.clamp(1, LOGS_SEARCH_MAX_PAGE) },
+        "sort": "-timestamp"
+    });
+    let resp = crate::api::post(cfg, "/api/v2/logs/events/search", &body).await?;
+    let items = resp["data"].as_array().cloned().unwrap_or_default();
+    // A full backlog means older matches were left out; never print those later.
+    let full = items.len() >= lines;
+    let mut backlog: Vec<TailEvent> = items.iter().filter_map(tail_event).collect();
+    backlog.truncate(lines);
+    let printed = cursor.fresh(backlog);
+    for event in &printed {
+        print_tail_event(event, format, color)?;
+    }
+    if full {
+        cursor.floor_ms = printed
+            .first()
+            .map(|e| e.timestamp_ms)
+            .unwrap_or_else(|| chrono::Utc::now().timestamp_millis());
+    }
+    if !follow {
+        return Ok(());
+    }
+    follow_tail(cfg, &query, &indexes, cursor, format, color, interval_secs).await
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn follow_tail(
+    cfg: &Config,
+    query: &str,
+    indexes: &[String],
+    mut cursor: TailCursor,
+    format: TailFormat,
+    color: bool,
+    interval_secs: u64,
+) -> Result<()> {
+    eprintln!("Tailing logs matching {query:?} (Ctrl-C to stop)...");
+    let interval = std::time::Duration::from_secs(interval_secs.max(1));
+    let mut wait = interval;
+    loop {
+        tokio::time::sleep(wait).await;
+        wait = interval;
+        // Keep following through transient failures; a blip shouldn't end the stream.
+        match fetch_tail_events(
+            cfg,
+            query,
+            indexes,
+            cursor.next_from_ms(),
+            util::DEFAULT_MAX_ITEMS,
+        )
+        .await
+        {
+            Ok(events) => {
+                for event in cursor.fresh(events) {
+                    print_tail_event(&event, format, color)?;
+                }
+            }
+            Err(e) => match crate::api::rate_limited(&e) {
+                // Wait out the window rather than polling into more 429s.
+                Some(limited) => {
+                    wait = tail_backoff(limited.reset, interval);
+                    eprintln!(
+                        "warning: rate limited; polling again in {}s",
+                        wait.as_secs()
+                    );
+                }
+                None => eprintln!("warning: {e}"),
+            },
+        }
+    }
+}
+
+/// Delay before the next poll after a 429: until the rate-limit window
+/// resets, or `TAIL_RATE_LIMIT_BACKOFF` when the response didn't say.
+/// Never shorter than the poll interval.
+pub fn tail_backoff(
+    reset: Option<std::time::Duration>,
+    interval: std::time::Duration,
+) -> std::time::Duration {
+    reset.unwrap_or(TAIL_RATE_LIMIT_BACKOFF).max(interval)
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn follow_tail(
+    _cfg: &Config,
+    _query: &str,
+    _indexes: &[String],
+    _cursor: TailCursor,
+    _format: TailFormat,
+    _color: bool,
+    _interval_secs: u64,
+) -> Result<()> {
+    anyhow::bail!("--follow is not supported in WASM builds")
+}
+
+// ---------------------------------------------------------------------------
+// Patterns (client-side clustering of sampled log messages)
+// ---------------------------------------------------------------------------
+
+/// Placeholder for variable tokens in a pattern template.
+const WILDCARD: &str = "<*>";
+
+/// Max page size accepted by the logs search endpoint.
+const LOGS_SEARCH_MAX_PAGE: usize = 1000;
+
+/// A cluster of log messages sharing the same template.
+#[derive(serde::Serialize, Debug)]
+pub struct LogPattern {
+    pub count: usize,
+    pub percent: f64,
+    pub pattern: String,
+    pub example: String,
+}
+
+struct Cluster {
+    tokens: Vec<String>,
+    count: usize,
+    example: String,
+}
+
+/// Mask tokens that are almost certainly variable (numbers, IDs, IPs, hashes)
+/// before clustering, so they don't split otherwise identical messages.
+fn mask_token(token: &str) -> String {
+    if let Some((key, value)) = token.split_once('=') {
+        if !key.is_empty() && !value.is_empty() && is_variable(value) {
+            return format!("{key}={WILDCARD}");
+        }
+    }
+    if is_variable(token) {
+        WILDCARD.to_string()
+    } else {
+        token.to_string()
+    }
+}
+
+fn is_variable(token: &str) -> bool {
+    let trimmed = token
+        .trim_matches(|c: char| matches!(c, ',' | ';' | ':' | '"' | '\'' | '(' | ')' | '[' | ']'));
+    if !trimmed.chars().any(|c| c.is_ascii_digit()) {
+        return false;
+    }
+    // Numbers, durations, IPs, timestamps: digits plus light punctuation
+    let numeric = trimmed
+        .chars()
+        .all(|c| c.is_ascii_digit() || matches!(c, '.' | ':' | '-' | '+' | '/' | '%' | '_'))
+        || trimmed
+            .trim_end_matches(|c: char| c.is_ascii_alphabetic())
+            .chars()
+            .all(|c| c.is_ascii_digit() || c == '.');
+    // Hex hashes and UUIDs
+    let hex = trimmed.len() >= 8 && trimmed.chars().all(|c| c.is_ascii_hexdigit() || c == '-');
+    numeric || hex
+}
+
+/// Fraction of positions where the template matches the candidate tokens.
+/// Wildcard positions in the template always match.
+fn similarity(template: &[String], tokens: &[String]) -> f64 {
+    if template.is_empty() {
+        return 1.0;
+    }
+    let same = template
+        .iter()
+        .zip(tokens)
+        .filter(|(t, c)| *t == WILDCARD || t == c)
+        .count();
+    same as f64 / template.len() as f64
+}
+
+/// Drain-style clustering: messages with the same token count are merged into
+/// the most similar existing template when similarity >= threshold; differing
+/// positions become wildcards. Returns patterns sorted by count (descending).
+pub fn cluster_messages(messages: &[String], threshold: f64) -> Vec<LogPattern> {
+    let mut clusters: Vec<Cluster> = Vec::new();
+    for message in messages {
+        let tokens: Vec<String> = message.split_whitespace().map(mask_token).collect();
+        if tokens.is_empty() {
+            continue;
+        }
+        let best = clusters
+            .iter_mut()
+            .filter(|c| c.tokens.len() == tokens.len())
+            .map(|c| {
+                let sim = similarity(&c.tokens, &tokens);
+                (c, sim)
+            })
+            .filter(|(_, sim)| *sim >= threshold)
+            .max_by(|a, b| a.1.total_cmp(&b.1));
+        match best {
+            Some((cluster, _)) => {
+                for (t, c) in cluster.tokens.iter_mut().zip(&tokens) {
+                    if t != c {
+                        *t = WILDCARD.to_string();
+                    }
+                }
+                cluster.count += 1;
+            }
+            None => clusters.push(Cluster {
+                tokens,
+                count: 1,
+                example: message.clone(),
+            }),
+        }
+    }
+
+    let total: usize = clusters.iter().map(|c| c.count).sum();
+    let mut patterns: Vec<LogPattern> = clusters
+        .into_iter()
+        .map(|c| LogPattern {
+            count: c.count,
+            percent: (c.count as f64 * 1000.0 / total as f64).round() / 10.0,
+            pattern: c.tokens.join(" "),
+            example: c.example,
+        })
+        .collect();
+    patterns.sort_by(|a, b| {
+        b.count
+            .cmp(&a.count)
+            .then_with(|| a.pattern.cmp(&b.pattern))
+    });
+    patterns
+}
+
+/// Fetch up to `sample` log messages for a query, following pagination cursors.
+async fn sample_messages(
+    cfg: &Config,
+    query: &str,
+    from_ms: i64,
+    to_ms: i64,
+    sample: usize,
+) -> Result<Vec<String>> {
+    let mut messages = Vec::new();
+    let mut cursor: Option<String> = None;
+    while messages.len() < sample {
+        let mut page = serde_json::json!({
+            "limit": (sample - messages.len()).min(LOGS_SEARCH_MAX_PAGE)
+        });
+        if let Some(c) = &cursor {
+            page["cursor"] = serde_json::Value::String(c.clone());
+        }
+        let body = serde_json::json!({
+            "filter": {
+                "query": query,
+                "from": from_ms.to_string(),
+                "to": to_ms.to_string()
+            },
+            "page": page,
+            "sort": "-timestamp"
+        });
+        let resp = crate::client::raw_post(cfg, "/api/v2/logs/events/search", body).await?;
+        let logs = resp
+            .get("data")
+            .and_then(|d| d.as_array())
+            .cloned()
+            .unwrap_or_default();
+        if logs.is_empty() {
+            break;
+        }
+        messages.extend(logs.iter().filter_map(|log| {
+            log.pointer("/attributes/message")
+                .and_then(|m| m.as_str())
+                .filter(|m| !m.trim().is_empty())
+                .map(String::from)
+        }));
+        cursor = resp
+            .pointer("/meta/page/after")
+            .and_then(|c| c.as_str())
+            .map(String::from);
+        if cursor.is_none() {
+            break;
+        }
+    }
+    messages.truncate(sample);
+    Ok(messages)
+}
+
+pub async fn pattern(
+    cfg: &Config,
+    query: String,
+    from: String,
+    to: String,
+    sample: usize,
+    top: usize,
+    similarity: f64,
+) -> Result<()> {
+    if !(0.0..=1.0).contains(&similarity) {
+        anyhow::bail!("--similarity must be between 0 and 1, got {similarity}");
+    }
+    let from_ms = util::parse_time_to_unix_millis(&from)?;
+    let to_ms = util::parse_time_to_unix_millis(&to)?;
+    let messages = sample_messages(cfg, &query, from_ms, to_ms, sample.max(1)).await?;
+    if messages.is_empty() {
+        eprintln!("No log messages found matching the query.");
+        return Ok(());
+    }
+
+    let mut patterns = cluster_messages(&messages, similarity);
+    let total_patterns = patterns.len();
+    patterns.truncate(top);
+
+    let truncated = total_patterns > patterns.len();
+    let meta = formatter::Metadata {
+        count: Some(patterns.len()),
+        truncated,
+        command: Some("logs pattern".into()),
+        next_action: if truncated {
+            Some(format!(
+                "Showing top {top} of {total_patterns} patterns from {} sampled logs. Use --top to see more",
+                messages.len()
+            ))
+        } else {
+            None
+        },
+    };
+    formatter::format_and_print(&patterns, cfg, Some(&meta))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn msgs(lines: &[&str]) -> Vec<String> {
+        lines.iter().map(|s| s.to_string()).collect()
+    }
+
+    fn log_item(id: &str, ts: &str, status: &str) -> serde_json::Value {
+        serde_json::json!({"id": id, "type": "log", "attributes": {
+            "timestamp": ts, "status": status, "service": "web", "host": "i-1",
+            "message": "GET /health 200\n"
+        }})
+    }
+
+    fn events(items: &[(&str, &str)]) -> Vec<TailEvent> {
+        items
+            .iter()
+            .filter_map(|(id, ts)| tail_event(&log_item(id, ts, "info")))
+            .collect()
+    }
+
+    #[test]
+    fn test_tail_event_parse() {
+        let e = tail_event(&log_item("a", "2024-05-01T12:00:00.250Z", "error")).unwrap();
+        assert_eq!(e.id, "a");
+        assert_eq!(e.timestamp_ms, 1_714_564_800_250);
+        assert!(tail_event(&serde_json::json!({"id": "b", "attributes": {}})).is_none());
+        assert!(tail_event(&log_item("c", "yesterday", "info")).is_none());
+    }
+
+    #[test]
+    fn test_tail_cursor_dedupes_and_orders() {
+        let start = 1_714_564_800_000; // 2024-05-01T12:00:00Z
+        let mut cursor = TailCursor::new(start);
+        let first = cursor.fresh(events(&[
+            ("b", "2024-05-01T12:00:02Z"),
+            ("a", "2024-05-01T12:00:01Z"),
+        ]));
+        let ids: Vec<&str> = first.iter().map(|e| e.id.as_str()).collect();
+        assert_eq!(ids, vec!["a", "b"]);
+        assert_eq!(cursor.next_from_ms(), start);
+
+        // Re-read overlap is dropped; a late-indexed older event is still new.
+        let second = cursor.fresh(events(&[
+            ("a", "2024-05-01T12:00:01Z"),
+            ("late", "2024-05-01T12:00:01.500Z"),
+            ("c", "2024-05-01T12:01:00Z"),
+        ]));
+        let ids: Vec<&str> = second.iter().map(|e| e.id.as_str()).collect();
+        assert_eq!(ids, vec!["late", "c"]);
+        assert_eq!(cursor.next_from_ms(), start + 60_000 - TAIL_LOOKBACK_MS);
+    }
+
+    #[test]
+    fn test_tail_cursor_floor() {
+        let mut cursor = TailCursor::new(0);
+        cursor.floor_ms = 1_714_564_801_000;
+        let fresh = cursor.fresh(events(&[
+            ("old", "2024-05-01T12:00:00Z"),
+            ("new", "2024-05-01T12:00:01Z"),
+        ]));
+        assert_eq!(fresh.len(), 1);
+        assert_eq!(fresh[0].id, "new");
+    }
+
+    #[test]
+    fn test_tail_backoff() {
+        let interval = std::time::Duration::from_secs(2);
+        let secs = std::time::Duration::from_secs;
+        assert_eq!(tail_backoff(Some(secs(17)), interval), secs(17));
+        assert_eq!(tail_backoff(Some(secs(0)), interval), interval);
+        assert_eq!(tail_backoff(None, interval), TAIL_RATE_LIMIT_BACKOFF);
+    }
+
+    #[test]
+    fn test_format_tail_event() {
+        let e = tail_event(&log_item("a", "2024-05-01T12:00:00.250Z", "warn")).unwrap();
+        assert_eq!(
+            format_tail_event(&e, false),
+            "2024-05-01T12:00:00.250Z WARN  web i-1: GET /health 200"
+        );
+        let colored = format_tail_event(&e, true);
+        assert!(colored.contains(&format!("{ANSI_YELLOW}WARN {ANSI_RESET}")));
+        assert_eq!("JSON".parse::<TailFormat>().unwrap(), TailFormat::Json);
+        assert!("xml".parse::<TailFormat>().is_err());
+    }
+
+    #[test]
+    fn test_index_update_body_edits_exclusions() {
+        let index = serde_json::json!({
+            "name": "main", "is_rate_limited": false, "num_retention_days": 15,
+            "filter": {"query": "*"}, "daily_limit": null,
+            "exclusion_filters": [
+                {"name": "health", "is_enabled": true, "filter": {"query": "path:/health", "sample_rate": 1.0}},
+                {"name": "debug", "is_enabled": true, "filter": {"query": "status:debug", "sample_rate": 1.0}}
+            ]
+        });
+        let edits = ExclusionEdits {
+            add: msgs(&["bots=@http.useragent:*bot*", "health=path:/healthz"]),
+            remove: msgs(&["debug"]),
+            sample_rates: msgs(&["bots=0.9"]),
+        };
+        let body = index_update_body(&index, &edits).unwrap();
+        assert!(body.get("name").is_none());
+        assert!(body.get("is_rate_limited").is_none());
+        assert!(body.get("daily_limit").is_none());
+        assert_eq!(body["num_retention_days"], 15);
+        let filters = body["exclusion_filters"].as_array().unwrap();
+        assert_eq!(filters.len(), 2);
+        assert_eq!(filters[0]["filter"]["query"], "path:/healthz");
+        assert_eq!(filters[1]["name"], "bots");
+        assert_eq!(filters[1]["filter"]["sample_rate"], 0.9);
+
+        for bad in [
+            ExclusionEdits {
+                remove: msgs(&["nope"]),
+                ..Default::default()
+            },
+            ExclusionEdits {
+                sample_rates: msgs(&["health=2"]),
+                ..Default::default()
+            },
+            ExclusionEdits {
+                add: msgs(&["no-query"]),
+                ..Default::default()
+            },
+        ] {
+            assert!(index_update_body(&index, &bad).is_err(), "{bad:?}");
+        }
+    }
+
+    #[test]
+    fn test_pipeline_order_moves_ids_to_front() {
+        let current = msgs(&["a", "b", "c", "d"]);
+        assert_eq!(
+            pipeline_order(&current, &msgs(&["c", "a", "c"])).unwrap(),
+            msgs(&["c", "a", "b", "d"])
+        );
+        assert_eq!(pipeline_order(&current, &[]).unwrap(), current);
+        let err = pipeline_order(&current, &msgs(&["z"])).unwrap_err();
+        assert!(err.to_string().contains("\"z\""), "{err}");
+    }
+
+    #[test]
+    fn test_archive_destination_checks() {
+        let checks = archive_destination_checks(&serde_json::json!({
+            "type": "s3", "bucket": "logs", "path": "/prod",
+            "integration": {"account_id": "123456789012", "role_name": "DatadogArchive"}
+        }));
+        assert_eq!(checks[0].detail, "s3://logs/prod");
+        assert_eq!(checks[1].status, "pass");
+        assert_eq!(
+            checks[1].detail,
+            "arn:aws:iam::123456789012:role/DatadogArchive"
+        );
+
+        let checks = archive_destination_checks(&serde_json::json!({
+            "type": "azure", "storage_account": "acct", "container": "logs"
+        }));
+        assert_eq!(checks[0].detail, "azure://acct/logs");
+        assert_eq!(checks[1].status, "fail");
+    }
+
+    #[test]
+    fn test_archive_state_check() {
+        assert_eq!(archive_state_check(Some("WORKING")).status, "pass");
+        assert_eq!(archive_state_check(Some("FAILING")).status, "fail");
+        assert_eq!(archive_state_check(Some("UNKNOWN")).status, "unknown");
+        assert_eq!(archive_state_check(None).status, "unknown");
+    }
+
+    #[test]
+    fn test_mask_token() {
+        assert_eq!(mask_token("12345"), WILDCARD);
+        assert_eq!(mask_token("10.0.0.1"), WILDCARD);
+        assert_eq!(mask_token("250ms"), WILDCARD);
+        assert_eq!(mask_token("3f2a9c1e-1b2c-4d5e-8f90-a1b2c3d4e5f6"), WILDCARD);
+        assert_eq!(mask_token("user_id=42"), "user_id=<*>");
+        assert_eq!(mask_token("timeout"), "timeout");
+        assert_eq!(mask_token("http2"), "http2");
+    }
+
+    #[test]
+    fn test_cluster_merges_similar_messages() {
+        let patterns = cluster_messages(
+            &msgs(&[
+                "Connection to db-primary refused",
+                "Connection to db-replica refused",
+                "Connection to cache refused",
+                "User 42 logged in",
+                "User 77 logged in",
+            ]),
+            0.5,
+        );
+        assert_eq!(patterns.len(), 2);
+        assert_eq!(patterns[0].count, 3);
+        assert_eq!(patterns[0].pattern, "Connection to <*> refused");
+        assert_eq!(patterns[0].example, "Connection to db-primary refused");
+        assert_eq!(patterns[1].pattern, "User <*> logged in");
+        assert_eq!(patterns[0].percent, 60.0);
+    }
+
+    #[test]
+    fn test_cluster_respects_threshold() {
+        let lines = msgs(&["a b c d", "a x y z"]);
+        assert_eq!(cluster_messages(&lines, 0.5).len(), 2);
+        assert_eq!(cluster_messages(&lines, 0.25).len(), 1);
+    }
+
+    #[test]
+    fn test_cluster_different_lengths_never_merge() {
+        let patterns = cluster_messages(&msgs(&["request failed", "request failed again"]), 0.0);
+        assert_eq!(patterns.len(), 2);
+    }
+
+    #[test]
+    fn test_cluster_skips_blank_messages() {
+        assert!(cluster_messages(&msgs(&["", "   "]), 0.5).is_empty());
+    }
+}
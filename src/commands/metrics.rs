@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV1::api_metrics::{
     ListActiveMetricsOptionalParams, MetricsAPI as MetricsV1API,
@@ -12,7 +12,7 @@ use datadog_api_client::datadogV2::model::MetricPayload;
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
-use crate::config::Config;
+use crate::config::{Config, OutputFormat};
 use crate::formatter;
 use crate::util;
 
@@ -172,14 +172,75 @@ pub async fn metadata_update(cfg: &Config, metric_name: &str, file: &str) -> Res
     crate::formatter::output(cfg, &data)
 }
 
+/// Inputs for flag-based `pup metrics submit`.
+#[derive(Debug, Default)]
+pub struct SubmitOptions {
+    pub name: String,
+    pub value: f64,
+    /// gauge, count, or rate.
+    pub metric_type: String,
+    pub tags: Vec<String>,
+    pub host: Option<String>,
+    /// Seconds covered by a count or rate point.
+    pub interval: i64,
+    /// Unix seconds.
+    pub timestamp: i64,
+}
+
+/// `/api/v2/series` payload with a single point.
+pub fn series_payload(opts: &SubmitOptions) -> Result<serde_json::Value> {
+    if opts.name.trim().is_empty() {
+        bail!("--name must not be empty");
+    }
+    if !opts.value.is_finite() {
+        bail!("--value must be a finite number");
+    }
+    // MetricIntakeType: 0 unspecified, 1 count, 2 rate, 3 gauge.
+    let kind = match opts.metric_type.to_lowercase().as_str() {
+        "count" => 1,
+        "rate" => 2,
+        "gauge" => 3,
+        other => bail!("invalid metric type {other:?} (expected gauge, count, or rate)"),
+    };
+    if kind == 2 && opts.interval <= 0 {
+        bail!("--interval is required for rate metrics");
+    }
+    let mut series = serde_json::json!({
+        "metric": opts.name,
+        "type": kind,
+        "points": [{"timestamp": opts.timestamp, "value": opts.value}],
+    });
+    if !opts.tags.is_empty() {
+        series["tags"] = serde_json::json!(opts.tags);
+    }
+    if let Some(host) = &opts.host {
+        series["resources"] = serde_json::json!([{"name": host, "type": "host"}]);
+    }
+    if opts.interval > 0 {
+        series["interval"] = opts.interval.into();
+    }
+    Ok(serde_json::json!({ "series": [series] }))
+}
+
+/// Read a `--file` payload: `{"series": [...]}`, or a bare array of series for batches.
+pub fn read_series_file(file: &str) -> Result<serde_json::Value> {
+    let body: serde_json::Value = util::read_json_file(file)?;
+    Ok(if body.is_array() {
+        serde_json::json!({ "series": body })
+    } else {
+        body
+    })
+}
+
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn submit(cfg: &Config, file: &str) -> Result<()> {
+pub async fn submit(cfg: &Config, body: serde_json::Value) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
     let api = match client::make_bearer_client(cfg) {
         Some(c) => MetricsV2API::with_client_and_config(dd_cfg, c),
         None => MetricsV2API::with_config(dd_cfg),
     };
-    let body: MetricPayload = util::read_json_file(file)?;
+    let body: MetricPayload = serde_json::from_value(body)
+        .map_err(|e| anyhow::anyhow!("invalid metrics payload: {e}"))?;
     let resp = api
         .submit_metrics(
             body,
@@ -191,8 +252,7 @@ pub async fn submit(cfg: &Config, file: &str) -> Result<()> {
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn submit(cfg: &Config, file: &str) -> Result<()> {
-    let body: serde_json::Value = util::read_json_file(file)?;
+pub async fn submit(cfg: &Config, body: serde_json::Value) -> Result<()> {
     let data = crate::api::post(cfg, "/api/v2/series", &body).await?;
     crate::formatter::output(cfg, &data)
 }
@@ -222,3 +282,420 @@ pub async fn tags_list(cfg: &Config, metric_name: &str) -> Result<()> {
     let data = crate::api::get(cfg, &path, &[]).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Anomaly / forecast detection
+// ---------------------------------------------------------------------------
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum DetectAlgorithm {
+    Anomalies,
+    Forecast,
+}
+
+impl std::str::FromStr for DetectAlgorithm {
+    type Err = anyhow::Error;
+
+    fn from_str(s: &str) -> Result<Self> {
+        match s.to_lowercase().as_str() {
+            "anomalies" | "anomaly" => Ok(Self::Anomalies),
+            "forecast" => Ok(Self::Forecast),
+            _ => bail!("invalid --algorithm {s:?}: expected anomalies or forecast"),
+        }
+    }
+}
+
+#[derive(Debug, Clone)]
+pub struct DetectOptions {
+    pub query: String,
+    pub algorithm: DetectAlgorithm,
+    /// anomalies: basic, agile, robust; forecast: linear, seasonal.
+    pub method: Option<String>,
+    /// Width of the band: deviations for anomalies and forecast alike.
+    pub bounds: f64,
+    /// History evaluated (anomalies) or fed to the forecast.
+    pub window: String,
+    /// How far ahead to forecast.
+    pub horizon: String,
+    /// Forecast only: flag where the forecast crosses this value.
+    pub threshold: Option<f64>,
+    /// Forecast only: flag values below `threshold` instead of above.
+    pub below: bool,
+}
+
+/// The query wrapped in `anomalies()` or `forecast()`.
+pub fn detect_expression(opts: &DetectOptions) -> Result<String> {
+    let query = opts.query.trim();
+    if query.is_empty() {
+        bail!("--query is required");
+    }
+    if opts.bounds.is_nan() || opts.bounds <= 0.0 {
+        bail!("--bounds must be positive");
+    }
+    let (func, methods): (&str, &[&str]) = match opts.algorithm {
+        DetectAlgorithm::Anomalies => ("anomalies", &["basic", "agile", "robust"]),
+        DetectAlgorithm::Forecast => ("forecast", &["linear", "seasonal"]),
+    };
+    let method = opts.method.as_deref().unwrap_or(methods[0]);
+    if !methods.contains(&method) {
+        bail!(
+            "invalid --method {method:?} for {func}: expected {}",
+            methods.join(", ")
+        );
+    }
+    Ok(format!("{func}({query}, '{method}', {})", opts.bounds))
+}
+
+#[derive(Debug, Clone, PartialEq)]
+pub struct DetectPoint {
+    pub ts_ms: i64,
+    pub value: f64,
+    pub lower: Option<f64>,
+    pub upper: Option<f64>,
+}
+
+fn band_kind(series: &serde_json::Value) -> Option<&'static str> {
+    let label = format!(
+        "{} {}",
+        series["display_name"].as_str().unwrap_or_default(),
+        series["expression"].as_str().unwrap_or_default()
+    )
+    .to_lowercase();
+    if label.contains("upper") {
+        Some("upper")
+    } else if label.contains("lower") {
+        Some("lower")
+    } else {
+        None
+    }
+}
+
+/// Points per scope from a `/api/v1/query` response for an `anomalies()` or
+/// `forecast()` expression. Bands arrive either as extra pointlist columns
+/// (`[ts, value, lower, upper]`) or as separate upper/lower series for the same
+/// scope; both are folded into `DetectPoint`s.
+pub fn detect_series(resp: &serde_json::Value) -> Vec<(String, Vec<DetectPoint>)> {
+    let series = resp["series"].as_array().cloned().unwrap_or_default();
+    let mut bands: std::collections::HashMap<(String, &'static str), Vec<(i64, f64)>> =
+        Default::default();
+    let mut values: Vec<(String, Vec<DetectPoint>)> = Vec::new();
+    for s in &series {
+        let scope = s["scope"].as_str().unwrap_or("*").to_string();
+        let points = s["pointlist"].as_array().cloned().unwrap_or_default();
+        if let Some(kind) = band_kind(s) {
+            let entry = bands.entry((scope, kind)).or_default();
+            entry.extend(
+                points
+                    .iter()
+                    .filter_map(|p| Some((p.get(0)?.as_f64()? as i64, p.get(1)?.as_f64()?))),
+            );
+            continue;
+        }
+        let points = points
+            .iter()
+            .filter_map(|p| {
+                Some(DetectPoint {
+                    ts_ms: p.get(0)?.as_f64()? as i64,
+                    value: p.get(1)?.as_f64()?,
+                    lower: p.get(2).and_then(|v| v.as_f64()),
+                    upper: p.get(3).and_then(|v| v.as_f64()),
+                })
+            })
+            .collect();
+        values.push((scope, points));
+    }
+    for (scope, points) in &mut values {
+        for (kind, slot) in [("lower", 0), ("upper", 1)] {
+            let Some(band) = bands.get(&(scope.clone(), kind)) else {
+                continue;
+            };
+            let band: std::collections::HashMap<i64, f64> = band.iter().copied().collect();
+            for p in points.iter_mut() {
+                if let Some(v) = band.get(&p.ts_ms) {
+                    if slot == 0 {
+                        p.lower = Some(*v);
+                    } else {
+                        p.upper = Some(*v);
+                    }
+                }
+            }
+        }
+    }
+    values
+}
+
+fn point_flagged(p: &DetectPoint, opts: &DetectOptions) -> bool {
+    match opts.algorithm {
+        DetectAlgorithm::Anomalies => {
+            p.upper.is_some_and(|u| p.value > u) || p.lower.is_some_and(|l| p.value < l)
+        }
+        DetectAlgorithm::Forecast => match opts.threshold {
+            Some(t) if opts.below => p.lower.unwrap_or(p.value) <= t,
+            Some(t) => p.upper.unwrap_or(p.value) >= t,
+            None => false,
+        },
+    }
+}
+
+fn iso_ms(ts_ms: i64) -> String {
+    chrono::DateTime::from_timestamp_millis(ts_ms)
+        .map(|t| t.to_rfc3339_opts(chrono::SecondsFormat::Secs, true))
+        .unwrap_or_default()
+}
+
+/// Runs of consecutive flagged points, one interval each.
+pub fn flagged_intervals(
+    scope: &str,
+    points: &[DetectPoint],
+    opts: &DetectOptions,
+) -> Vec<serde_json::Value> {
+    let mut intervals = Vec::new();
+    let mut run: Vec<&DetectPoint> = Vec::new();
+    let flush = |run: &mut Vec<&DetectPoint>, intervals: &mut Vec<serde_json::Value>| {
+        let (Some(first), Some(last)) = (run.first(), run.last()) else {
+            return;
+        };
+        // Anomalies: the point furthest outside its band; forecast: the extreme value.
+        let peak = match opts.algorithm {
+            DetectAlgorithm::Anomalies => run
+                .iter()
+                .max_by(|a, b| band_distance(a).total_cmp(&band_distance(b)))
+                .map(|p| p.value),
+            DetectAlgorithm::Forecast if opts.below => {
+                run.iter().map(|p| p.value).min_by(f64::total_cmp)
+            }
+            DetectAlgorithm::Forecast => run.iter().map(|p| p.value).max_by(f64::total_cmp),
+        };
+        intervals.push(serde_json::json!({
+            "scope": scope,
+            "start": iso_ms(first.ts_ms),
+            "end": iso_ms(last.ts_ms),
+            "points": run.len(),
+            "peak": peak,
+        }));
+        run.clear();
+    };
+    for p in points {
+        if point_flagged(p, opts) {
+            run.push(p);
+        } else {
+            flush(&mut run, &mut intervals);
+        }
+    }
+    flush(&mut run, &mut intervals);
+    intervals
+}
+
+fn band_distance(p: &DetectPoint) -> f64 {
+    let above = p.upper.map(|u| p.value - u).unwrap_or(0.0);
+    let below = p.lower.map(|l| l - p.value).unwrap_or(0.0);
+    above.max(below)
+}
+
+/// Range and final value of one series, e.g. where a forecast ends up.
+pub fn series_summary(scope: &str, points: &[DetectPoint]) -> serde_json::Value {
+    let values = points.iter().map(|p| p.value);
+    serde_json::json!({
+        "scope": scope,
+        "points": points.len(),
+        "min": values.clone().min_by(f64::total_cmp),
+        "max": values.max_by(f64::total_cmp),
+        "last": points.last().map(|p| p.value),
+        "last_at": points.last().map(|p| iso_ms(p.ts_ms)),
+    })
+}
+
+pub async fn detect(cfg: &Config, opts: DetectOptions) -> Result<()> {
+    let expression = detect_expression(&opts)?;
+    if opts.algorithm == DetectAlgorithm::Anomalies && opts.threshold.is_some() {
+        bail!("--threshold applies to --algorithm forecast only");
+    }
+    let now = chrono::Utc::now().timestamp();
+    let from = now - util::parse_duration_secs(&opts.window)?;
+    let to = match opts.algorithm {
+        DetectAlgorithm::Anomalies => now,
+        DetectAlgorithm::Forecast => now + util::parse_duration_secs(&opts.horizon)?,
+    };
+    let resp = crate::api::get(
+        cfg,
+        "/api/v1/query",
+        &[
+            ("from", from.to_string()),
+            ("to", to.to_string()),
+            ("query", expression.clone()),
+        ],
+    )
+    .await
+    .map_err(|e| crate::api::failed(format!("failed to evaluate {expression}"), e))?;
+
+    let series = detect_series(&resp);
+    if series.is_empty() {
+        bail!("no data for {:?} in the last {}", opts.query, opts.window);
+    }
+    let flagged: Vec<serde_json::Value> = series
+        .iter()
+        .flat_map(|(scope, points)| flagged_intervals(scope, points, &opts))
+        .collect();
+    let summaries: Vec<serde_json::Value> = series
+        .iter()
+        .map(|(scope, points)| series_summary(scope, points))
+        .collect();
+    let result = serde_json::json!({
+        "expression": expression,
+        "from": iso_ms(from * 1000),
+        "to": iso_ms(to * 1000),
+        "series": summaries,
+        "flagged": flagged,
+    });
+    if cfg.output_format == OutputFormat::Table && !cfg.agent_mode {
+        eprintln!(
+            "{expression}: {} series, {} flagged interval(s)",
+            series.len(),
+            flagged.len()
+        );
+        // Without a threshold a forecast has nothing to flag; show where it's heading.
+        if opts.algorithm == DetectAlgorithm::Forecast && opts.threshold.is_none() {
+            return formatter::output(cfg, &result["series"]);
+        }
+        return formatter::output(cfg, &result["flagged"]);
+    }
+    formatter::output(cfg, &result)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_series_payload() {
+        let opts = SubmitOptions {
+            name: "custom.queue_depth".into(),
+            value: 3.2,
+            metric_type: "gauge".into(),
+            tags: vec!["env:dev".into(), "team:sre".into()],
+            host: Some("web-1".into()),
+            timestamp: 1_700_000_000,
+            ..Default::default()
+        };
+        assert_eq!(
+            series_payload(&opts).unwrap(),
+            serde_json::json!({"series": [{
+                "metric": "custom.queue_depth",
+                "type": 3,
+                "points": [{"timestamp": 1_700_000_000, "value": 3.2}],
+                "tags": ["env:dev", "team:sre"],
+                "resources": [{"name": "web-1", "type": "host"}]
+            }]})
+        );
+    }
+
+    #[test]
+    fn test_series_payload_validation() {
+        let rate = SubmitOptions {
+            name: "custom.rps".into(),
+            metric_type: "rate".into(),
+            ..Default::default()
+        };
+        assert!(series_payload(&rate).is_err());
+        let rate = SubmitOptions {
+            interval: 10,
+            ..rate
+        };
+        let body = series_payload(&rate).unwrap();
+        assert_eq!(body["series"][0]["type"], 2);
+        assert_eq!(body["series"][0]["interval"], 10);
+
+        let bad = SubmitOptions {
+            name: "custom.x".into(),
+            metric_type: "histogram".into(),
+            ..Default::default()
+        };
+        assert!(series_payload(&bad).is_err());
+    }
+
+    fn detect_opts(algorithm: DetectAlgorithm) -> DetectOptions {
+        DetectOptions {
+            query: "avg:checkout.latency{env:prod}".into(),
+            algorithm,
+            method: None,
+            bounds: 2.0,
+            window: "4h".into(),
+            horizon: "1d".into(),
+            threshold: None,
+            below: false,
+        }
+    }
+
+    #[test]
+    fn test_detect_expression() {
+        let mut opts = detect_opts(DetectAlgorithm::Anomalies);
+        assert_eq!(
+            detect_expression(&opts).unwrap(),
+            "anomalies(avg:checkout.latency{env:prod}, 'basic', 2)"
+        );
+        opts.method = Some("seasonal".into());
+        assert!(detect_expression(&opts).is_err());
+        opts.algorithm = DetectAlgorithm::Forecast;
+        opts.bounds = 1.5;
+        assert_eq!(
+            detect_expression(&opts).unwrap(),
+            "forecast(avg:checkout.latency{env:prod}, 'seasonal', 1.5)"
+        );
+        assert!("forecast".parse::<DetectAlgorithm>().is_ok());
+        assert!("outliers".parse::<DetectAlgorithm>().is_err());
+    }
+
+    #[test]
+    fn test_detect_series_band_columns_and_series() {
+        let resp = serde_json::json!({"series": [
+            {"scope": "env:prod", "expression": "anomalies(...)",
+             "pointlist": [[1000.0, 5.0, 1.0, 10.0], [2000.0, null]]},
+            {"scope": "host:a", "pointlist": [[1000.0, 3.0], [2000.0, 50.0]]},
+            {"scope": "host:a", "display_name": "upper bound", "pointlist": [[1000.0, 10.0], [2000.0, 10.0]]},
+            {"scope": "host:a", "display_name": "lower bound", "pointlist": [[1000.0, 0.0]]}
+        ]});
+        let series = detect_series(&resp);
+        assert_eq!(series.len(), 2);
+        assert_eq!(series[0].1.len(), 1);
+        assert_eq!(series[0].1[0].upper, Some(10.0));
+        assert_eq!(series[1].1[1].upper, Some(10.0));
+        assert_eq!(series[1].1[1].lower, None);
+    }
+
+    #[test]
+    fn test_flagged_intervals() {
+        let point = |ts: i64, value: f64| DetectPoint {
+            ts_ms: ts * 60_000,
+            value,
+            lower: Some(0.0),
+            upper: Some(10.0),
+        };
+        let points = vec![
+            point(0, 5.0),
+            point(1, 12.0),
+            point(2, 30.0),
+            point(3, 5.0),
+            point(4, -4.0),
+        ];
+        let opts = detect_opts(DetectAlgorithm::Anomalies);
+        let flagged = flagged_intervals("env:prod", &points, &opts);
+        assert_eq!(flagged.len(), 2);
+        assert_eq!(flagged[0]["start"], "1970-01-01T00:01:00Z");
+        assert_eq!(flagged[0]["end"], "1970-01-01T00:02:00Z");
+        assert_eq!(flagged[0]["points"], 2);
+        assert_eq!(flagged[0]["peak"], 30.0);
+        assert_eq!(flagged[1]["peak"], -4.0);
+
+        // Forecast: flag where the upper band reaches the threshold.
+        let mut opts = detect_opts(DetectAlgorithm::Forecast);
+        assert!(flagged_intervals("env:prod", &points, &opts).is_empty());
+        opts.threshold = Some(10.0);
+        assert_eq!(flagged_intervals("env:prod", &points, &opts).len(), 1);
+        opts.threshold = Some(0.0);
+        opts.below = true;
+        let below = flagged_intervals("env:prod", &points, &opts);
+        assert_eq!(below.len(), 1);
+        assert_eq!(below[0]["points"], 5);
+        assert_eq!(below[0]["peak"], -4.0);
+    }
+}
@@ -5,22 +5,31 @@ pub mod apm;
 pub mod app_keys;
 pub mod audit_logs;
 pub mod auth;
+pub mod capabilities;
 pub mod cases;
 pub mod cicd;
 pub mod cloud;
+pub mod cloud_cost;
 pub mod code_coverage;
+pub mod codegen;
+pub mod config;
 pub mod cost;
 pub mod dashboards;
 pub mod data_governance;
+pub mod doctor;
 pub mod downtime;
 pub mod error_tracking;
 pub mod events;
+pub mod examples;
+pub mod fanout;
+pub mod find;
 pub mod fleet;
 pub mod hamr;
 pub mod incidents;
 pub mod infrastructure;
 pub mod integrations;
 pub mod investigations;
+pub mod ip_allowlist;
 pub mod logs;
 pub mod metrics;
 pub mod misc;
@@ -31,6 +40,8 @@ pub mod obs_pipelines;
 pub mod on_call;
 pub mod organizations;
 pub mod product_analytics;
+pub mod reference_tables;
+pub mod restriction_policies;
 pub mod rum;
 pub mod scorecards;
 pub mod security;
@@ -42,5 +53,8 @@ pub mod synthetics;
 pub mod tags;
 pub mod test;
 pub mod traces;
+pub mod ui;
 pub mod usage;
 pub mod users;
+pub mod watchdog;
+pub mod workflows;
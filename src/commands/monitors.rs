@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV1::api_monitors::{
     DeleteMonitorOptionalParams, GetMonitorOptionalParams, ListMonitorsOptionalParams, MonitorsAPI,
@@ -6,10 +6,11 @@ use datadog_api_client::datadogV1::api_monitors::{
 };
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV1::model::Monitor;
+use serde::Serialize;
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
-use crate::config::Config;
+use crate::config::{Config, OutputFormat};
 use crate::formatter::{self, Metadata};
 use crate::util;
 
@@ -19,6 +20,7 @@ pub async fn list(
     name: Option<String>,
     tags: Option<String>,
     limit: i32,
+    include_downtimes: bool,
 ) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
     let api = if let Some(http_client) = client::make_bearer_client(cfg) {
@@ -34,6 +36,9 @@ pub async fn list(
     if let Some(tags) = tags {
         params = params.monitor_tags(tags);
     }
+    if include_downtimes {
+        params = params.with_downtimes(true);
+    }
 
     let limit = limit.clamp(1, 1000);
     params = params.page_size(limit).page(0);
@@ -55,7 +60,12 @@ pub async fn list(
         command: Some("monitors list".to_string()),
         next_action: None,
     };
-    formatter::format_and_print(&monitors, &cfg.output_format, cfg.agent_mode, Some(&meta))?;
+    if include_downtimes {
+        let mut monitors = serde_json::to_value(&monitors)?;
+        annotate_downtimes(&mut monitors);
+        return formatter::format_and_print(&monitors, cfg, Some(&meta));
+    }
+    formatter::format_and_print(&monitors, cfg, Some(&meta))?;
     Ok(())
 }
 
@@ -65,6 +75,7 @@ pub async fn list(
     name: Option<String>,
     tags: Option<String>,
     limit: i32,
+    include_downtimes: bool,
 ) -> Result<()> {
     let mut query = vec![];
     if let Some(n) = &name {
@@ -76,20 +87,30 @@ pub async fn list(
     let limit = limit.clamp(1, 1000);
     query.push(("page_size", limit.to_string()));
     query.push(("page", "0".to_string()));
-    let data = crate::api::get(cfg, "/api/v1/monitor", &query).await?;
+    if include_downtimes {
+        query.push(("with_downtimes", "true".to_string()));
+    }
+    let mut data = crate::api::get(cfg, "/api/v1/monitor", &query).await?;
+    if include_downtimes {
+        annotate_downtimes(&mut data);
+    }
     crate::formatter::output(cfg, &data)
 }
 
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn get(cfg: &Config, monitor_id: i64) -> Result<()> {
+pub async fn get(cfg: &Config, monitor_id: i64, include_downtimes: bool) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
     let api = if let Some(http_client) = client::make_bearer_client(cfg) {
         MonitorsAPI::with_client_and_config(dd_cfg, http_client)
     } else {
         MonitorsAPI::with_config(dd_cfg)
     };
+    let mut params = GetMonitorOptionalParams::default();
+    if include_downtimes {
+        params = params.with_downtimes(true);
+    }
     let resp = api
-        .get_monitor(monitor_id, GetMonitorOptionalParams::default())
+        .get_monitor(monitor_id, params)
         .await
         .map_err(|e| anyhow::anyhow!("failed to get monitor: {:?}", e))?;
     let meta = Metadata {
@@ -98,29 +119,66 @@ pub async fn get(cfg: &Config, monitor_id: i64) -> Result<()> {
         command: Some("monitors get".to_string()),
         next_action: None,
     };
-    formatter::format_and_print(&resp, &cfg.output_format, cfg.agent_mode, Some(&meta))
+    if include_downtimes {
+        let mut resp = serde_json::to_value(&resp)?;
+        annotate_downtimes(&mut resp);
+        return formatter::format_and_print(&resp, cfg, Some(&meta));
+    }
+    formatter::format_and_print(&resp, cfg, Some(&meta))
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn get(cfg: &Config, monitor_id: i64) -> Result<()> {
-    let data = crate::api::get(cfg, &format!("/api/v1/monitor/{monitor_id}"), &[]).await?;
+pub async fn get(cfg: &Config, monitor_id: i64, include_downtimes: bool) -> Result<()> {
+    let query = if include_downtimes {
+        vec![("with_downtimes", "true".to_string())]
+    } else {
+        vec![]
+    };
+    let mut data = crate::api::get(cfg, &format!("/api/v1/monitor/{monitor_id}"), &query).await?;
+    if include_downtimes {
+        annotate_downtimes(&mut data);
+    }
     crate::formatter::output(cfg, &data)
 }
 
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn create(cfg: &Config, file: &str) -> Result<()> {
     let body: Monitor = util::read_json_file(file)?;
-    let dd_cfg = client::make_dd_config(cfg);
-    let api = if let Some(http_client) = client::make_bearer_client(cfg) {
-        MonitorsAPI::with_client_and_config(dd_cfg, http_client)
-    } else {
-        MonitorsAPI::with_config(dd_cfg)
+    let api = monitors_api(cfg);
+    let started = chrono::Utc::now();
+    let (api, body) = (&api, &body);
+    let resp = client::create_with_retry(
+        "monitor",
+        move || async move {
+            api.create_monitor(body.clone())
+                .await
+                .map_err(|e| anyhow::anyhow!("failed to create monitor: {:?}", e))
+        },
+        move || find_created_monitor(api, body.name.as_deref(), started),
+    )
+    .await?;
+    formatter::output(cfg, &resp)
+}
+
+/// Look for a monitor with this exact name created since `since`, i.e. by an
+/// earlier attempt of the current create.
+#[cfg(not(target_arch = "wasm32"))]
+async fn find_created_monitor(
+    api: &MonitorsAPI,
+    name: Option<&str>,
+    since: chrono::DateTime<chrono::Utc>,
+) -> Result<Option<Monitor>> {
+    let Some(name) = name else {
+        bail!("monitor has no name to look up");
     };
-    let resp = api
-        .create_monitor(body)
+    let monitors = api
+        .list_monitors(ListMonitorsOptionalParams::default().name(name.to_string()))
         .await
-        .map_err(|e| anyhow::anyhow!("failed to create monitor: {:?}", e))?;
-    formatter::output(cfg, &resp)
+        .map_err(|e| anyhow::anyhow!("failed to list monitors: {:?}", e))?;
+    Ok(monitors.into_iter().find(|m| {
+        m.name.as_deref() == Some(name)
+            && serde_json::to_value(m).is_ok_and(|v| client::created_since(&v, "created", since))
+    }))
 }
 
 #[cfg(target_arch = "wasm32")]
@@ -155,7 +213,7 @@ pub async fn update(cfg: &Config, monitor_id: i64, file: &str) -> Result<()> {
 }
 
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn search(cfg: &Config, query: Option<String>) -> Result<()> {
+pub async fn search(cfg: &Config, query: Option<String>, page: i64, limit: i64) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
     let api = if let Some(http_client) = client::make_bearer_client(cfg) {
         MonitorsAPI::with_client_and_config(dd_cfg, http_client)
@@ -163,7 +221,9 @@ pub async fn search(cfg: &Config, query: Option<String>) -> Result<()> {
         MonitorsAPI::with_config(dd_cfg)
     };
 
-    let mut params = SearchMonitorsOptionalParams::default();
+    let mut params = SearchMonitorsOptionalParams::default()
+        .page(page)
+        .per_page(limit);
     if let Some(q) = query {
         params = params.query(q);
     }
@@ -176,8 +236,8 @@ pub async fn search(cfg: &Config, query: Option<String>) -> Result<()> {
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn search(cfg: &Config, query: Option<String>) -> Result<()> {
-    let mut q = vec![];
+pub async fn search(cfg: &Config, query: Option<String>, page: i64, limit: i64) -> Result<()> {
+    let mut q = vec![("page", page.to_string()), ("per_page", limit.to_string())];
     if let Some(qstr) = &query {
         q.push(("query", qstr.clone()));
     }
@@ -205,3 +265,1961 @@ pub async fn delete(cfg: &Config, monitor_id: i64) -> Result<()> {
     let data = crate::api::delete(cfg, &format!("/api/v1/monitor/{monitor_id}")).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Downtime awareness
+// ---------------------------------------------------------------------------
+
+/// Scopes as strings; downtime scopes arrive as either a string or a list.
+fn scope_list(scope: &serde_json::Value) -> Vec<String> {
+    match scope {
+        serde_json::Value::String(s) => vec![s.clone()],
+        serde_json::Value::Array(a) => a
+            .iter()
+            .filter_map(|s| s.as_str().map(String::from))
+            .collect(),
+        _ => vec![],
+    }
+}
+
+/// Summarize why a monitor is (or isn't) silenced from the `matching_downtimes`
+/// returned with `with_downtimes=true` and legacy mutes in `options.silenced`.
+pub fn downtime_status(monitor: &serde_json::Value) -> serde_json::Value {
+    let mut muted_scopes: Vec<String> = Vec::new();
+    let mut active = Vec::new();
+    for dt in monitor
+        .get("matching_downtimes")
+        .and_then(|d| d.as_array())
+        .into_iter()
+        .flatten()
+    {
+        if dt.get("active").and_then(|a| a.as_bool()) == Some(false) {
+            continue;
+        }
+        let scopes = scope_list(dt.get("scope").unwrap_or(&serde_json::Value::Null));
+        for scope in &scopes {
+            if !muted_scopes.contains(scope) {
+                muted_scopes.push(scope.clone());
+            }
+        }
+        active.push(serde_json::json!({
+            "id": dt.get("id").cloned().unwrap_or_default(),
+            "scope": scopes,
+            "end": dt.get("end").cloned().unwrap_or_default(),
+        }));
+    }
+    if let Some(silenced) = monitor
+        .pointer("/options/silenced")
+        .and_then(|s| s.as_object())
+    {
+        for scope in silenced.keys() {
+            if !muted_scopes.contains(scope) {
+                muted_scopes.push(scope.clone());
+            }
+        }
+    }
+    serde_json::json!({
+        "silenced": !muted_scopes.is_empty(),
+        "muted_scopes": muted_scopes,
+        "active_downtimes": active,
+    })
+}
+
+/// Add a `downtime_status` field to a monitor or each monitor in a list.
+pub fn annotate_downtimes(data: &mut serde_json::Value) {
+    match data {
+        serde_json::Value::Array(monitors) => monitors.iter_mut().for_each(annotate_downtimes),
+        serde_json::Value::Object(_) => {
+            let status = downtime_status(data);
+            if let Some(obj) = data.as_object_mut() {
+                obj.insert("downtime_status".into(), status);
+            }
+        }
+        _ => {}
+    }
+}
+
+// ---------------------------------------------------------------------------
+// Composite tree
+// ---------------------------------------------------------------------------
+
+/// Guard against runaway recursion through deeply nested composites.
+const COMPOSITE_MAX_DEPTH: usize = 10;
+
+/// One monitor in a resolved composite tree.
+#[derive(Serialize, Debug)]
+pub struct MonitorNode {
+    pub id: i64,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub name: Option<String>,
+    #[serde(rename = "type", skip_serializing_if = "Option::is_none")]
+    pub monitor_type: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub overall_state: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub query: Option<String>,
+    /// Set when the monitor could not be fetched or was already visited (cycle).
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub error: Option<String>,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub children: Vec<MonitorNode>,
+}
+
+/// Monitor IDs referenced by a composite query such as `123 && (456 || !789)`,
+/// in order of first appearance.
+pub fn composite_ids(query: &str) -> Vec<i64> {
+    let mut ids = Vec::new();
+    for token in query.split(|c: char| !c.is_ascii_digit()) {
+        if let Ok(id) = token.parse::<i64>() {
+            if !ids.contains(&id) {
+                ids.push(id);
+            }
+        }
+    }
+    ids
+}
+
+fn is_composite(monitor: &serde_json::Value) -> bool {
+    monitor.get("type").and_then(|t| t.as_str()) == Some("composite")
+}
+
+/// Build the tree from already-fetched monitors. Missing entries become error leaves.
+fn build_tree(
+    id: i64,
+    monitors: &std::collections::HashMap<i64, Result<serde_json::Value, String>>,
+    path: &mut Vec<i64>,
+) -> MonitorNode {
+    let mut node = MonitorNode {
+        id,
+        name: None,
+        monitor_type: None,
+        overall_state: None,
+        query: None,
+        error: None,
+        children: Vec::new(),
+    };
+    if path.contains(&id) {
+        node.error = Some("cycle: monitor already referenced by an ancestor".into());
+        return node;
+    }
+    let monitor = match monitors.get(&id) {
+        Some(Ok(m)) => m,
+        Some(Err(e)) => {
+            node.error = Some(e.clone());
+            return node;
+        }
+        None => {
+            node.error = Some(format!("not resolved (max depth {COMPOSITE_MAX_DEPTH})"));
+            return node;
+        }
+    };
+    let str_field = |k: &str| monitor.get(k).and_then(|v| v.as_str()).map(String::from);
+    node.name = str_field("name");
+    node.monitor_type = str_field("type");
+    node.overall_state = str_field("overall_state");
+    if is_composite(monitor) {
+        node.query = str_field("query");
+        path.push(id);
+        node.children = composite_ids(node.query.as_deref().unwrap_or_default())
+            .into_iter()
+            .map(|child| build_tree(child, monitors, path))
+            .collect();
+        path.pop();
+    }
+    node
+}
+
+/// Render the tree with box-drawing connectors, one monitor per line.
+pub fn render_tree(node: &MonitorNode) -> String {
+    fn label(n: &MonitorNode) -> String {
+        let mut s = format!(
+            "{} [{}] {}",
+            n.id,
+            n.overall_state.as_deref().unwrap_or("?"),
+            n.name.as_deref().unwrap_or("")
+        );
+        if let Some(q) = &n.query {
+            s.push_str(&format!("  ({q})"));
+        }
+        if let Some(e) = &n.error {
+            s.push_str(&format!("  <{e}>"));
+        }
+        s.trim_end().to_string()
+    }
+    fn walk(n: &MonitorNode, prefix: &str, out: &mut String) {
+        for (i, child) in n.children.iter().enumerate() {
+            let last = i + 1 == n.children.len();
+            out.push_str(&format!(
+                "{prefix}{}{}\n",
+                if last { "└── " } else { "├── " },
+                label(child)
+            ));
+            let next = format!("{prefix}{}", if last { "    " } else { "│   " });
+            walk(child, &next, out);
+        }
+    }
+    let mut out = format!("{}\n", label(node));
+    walk(node, "", &mut out);
+    out
+}
+
+/// Recursively resolve a composite monitor's children with their current state.
+pub async fn composite_tree(cfg: &Config, monitor_id: i64) -> Result<()> {
+    let mut monitors = std::collections::HashMap::new();
+    let mut frontier = vec![monitor_id];
+    for depth in 0..=COMPOSITE_MAX_DEPTH {
+        let mut next = Vec::new();
+        for id in frontier {
+            if monitors.contains_key(&id) {
+                continue;
+            }
+            let fetched = crate::client::raw_get(cfg, &format!("/api/v1/monitor/{id}")).await;
+            if id == monitor_id {
+                if let Err(e) = &fetched {
+                    bail!("failed to get monitor: {e:?}");
+                }
+            }
+            if let Ok(m) = &fetched {
+                if is_composite(m) && depth < COMPOSITE_MAX_DEPTH {
+                    let query = m.get("query").and_then(|q| q.as_str()).unwrap_or_default();
+                    next.extend(composite_ids(query));
+                }
+            }
+            monitors.insert(id, fetched.map_err(|e| e.to_string()));
+        }
+        if next.is_empty() {
+            break;
+        }
+        frontier = next;
+    }
+
+    let root = &monitors[&monitor_id];
+    if let Ok(m) = root {
+        if !is_composite(m) {
+            eprintln!("Monitor {monitor_id} is not a composite monitor; showing it alone.");
+        }
+    }
+
+    let tree = build_tree(monitor_id, &monitors, &mut Vec::new());
+    if !cfg.agent_mode && cfg.output_format == OutputFormat::Table {
+        print!("{}", render_tree(&tree));
+        return Ok(());
+    }
+    let meta = Metadata {
+        count: Some(monitors.len()),
+        truncated: false,
+        command: Some("monitors composite-tree".to_string()),
+        next_action: None,
+    };
+    formatter::format_and_print(&tree, cfg, Some(&meta))
+}
+
+// ---------------------------------------------------------------------------
+// Dependents
+// ---------------------------------------------------------------------------
+
+/// Dashboards fetched at once while scanning for alert widgets.
+const DEPENDENTS_CONCURRENCY: usize = 8;
+
+/// Something that references a monitor and breaks or goes stale when the
+/// monitor is deleted.
+#[derive(Serialize, Debug, PartialEq)]
+pub struct Dependent {
+    pub kind: &'static str,
+    pub id: String,
+    pub name: String,
+}
+
+impl Dependent {
+    fn new(kind: &'static str, id: &serde_json::Value, name: &serde_json::Value) -> Self {
+        let id = match id {
+            serde_json::Value::String(s) => s.clone(),
+            other => other.to_string(),
+        };
+        Dependent {
+            kind,
+            id,
+            name: name.as_str().unwrap_or_default().to_string(),
+        }
+    }
+}
+
+/// Composite monitors whose query names `monitor_id`.
+pub fn composite_dependents(monitors: &[serde_json::Value], monitor_id: i64) -> Vec<Dependent> {
+    monitors
+        .iter()
+        .filter(|m| is_composite(m))
+        .filter(|m| composite_ids(m["query"].as_str().unwrap_or_default()).contains(&monitor_id))
+        .map(|m| Dependent::new("composite monitor", &m["id"], &m["name"]))
+        .collect()
+}
+
+/// Monitor-based SLOs that include `monitor_id`.
+pub fn slo_dependents(slos: &[serde_json::Value], monitor_id: i64) -> Vec<Dependent> {
+    slos.iter()
+        .filter(|slo| {
+            slo["monitor_ids"]
+                .as_array()
+                .is_some_and(|ids| ids.iter().any(|id| id.as_i64() == Some(monitor_id)))
+        })
+        .map(|slo| Dependent::new("SLO", &slo["id"], &slo["name"]))
+        .collect()
+}
+
+/// Whether any widget in a dashboard (including nested group widgets) shows
+/// `monitor_id` through an `alert_id`, as alert graph and alert value widgets do.
+pub fn references_alert_id(value: &serde_json::Value, monitor_id: i64) -> bool {
+    match value {
+        serde_json::Value::Object(map) => map.iter().any(|(key, v)| {
+            let direct = key == "alert_id"
+                && (v.as_str() == Some(monitor_id.to_string().as_str())
+                    || v.as_i64() == Some(monitor_id));
+            direct || references_alert_id(v, monitor_id)
+        }),
+        serde_json::Value::Array(items) => items.iter().any(|v| references_alert_id(v, monitor_id)),
+        _ => false,
+    }
+}
+
+/// Downtimes scoped to `monitor_id` specifically.
+pub fn downtime_dependents(downtimes: &[serde_json::Value], monitor_id: i64) -> Vec<Dependent> {
+    downtimes
+        .iter()
+        .filter(|dt| {
+            dt["attributes"]["monitor_identifier"]["monitor_id"].as_i64() == Some(monitor_id)
+        })
+        .map(|dt| {
+            // The first line of the message is the closest thing a downtime has to a name.
+            let message = dt["attributes"]["message"].as_str().unwrap_or_default();
+            let name = serde_json::json!(message.lines().next().unwrap_or_default());
+            Dependent::new("downtime", &dt["id"], &name)
+        })
+        .collect()
+}
+
+async fn dashboard_dependents(cfg: &Config, monitor_id: i64) -> Result<Vec<Dependent>> {
+    let resp = crate::api::get(cfg, "/api/v1/dashboard", &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to list dashboards", e))?;
+    let summaries = resp["dashboards"].as_array().cloned().unwrap_or_default();
+    let shared = std::sync::Arc::new(cfg.clone());
+    let found = util::run_bounded(summaries, DEPENDENTS_CONCURRENCY, |summary| {
+        let cfg = shared.clone();
+        async move {
+            let id = summary["id"].as_str()?;
+            let dashboard = crate::api::get(&cfg, &format!("/api/v1/dashboard/{id}"), &[])
+                .await
+                .ok()?;
+            references_alert_id(&dashboard["widgets"], monitor_id)
+                .then(|| Dependent::new("dashboard", &summary["id"], &summary["title"]))
+        }
+    })
+    .await;
+    Ok(found.into_iter().flatten().collect())
+}
+
+async fn downtimes_for(cfg: &Config, monitor_id: i64) -> Result<Vec<Dependent>> {
+    let resp = crate::api::get(
+        cfg,
+        "/api/v2/downtime",
+        &[("current_only", "true".to_string())],
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to list downtimes", e))?;
+    let downtimes = resp["data"].as_array().cloned().unwrap_or_default();
+    Ok(downtime_dependents(&downtimes, monitor_id))
+}
+
+/// Composite monitors, SLOs, dashboards, and downtimes that reference
+/// `monitor_id`. A resource type that can't be scanned is reported as a
+/// warning and skipped, so one missing permission doesn't hide the rest.
+pub async fn find_dependents(cfg: &Config, monitor_id: i64) -> Vec<Dependent> {
+    let mut found = Vec::new();
+    match list_all_monitors(cfg, None).await {
+        Ok(monitors) => found.extend(composite_dependents(&monitors, monitor_id)),
+        Err(e) => eprintln!("warning: could not check composite monitors: {e:#}"),
+    }
+    match super::slos::list_all(cfg, None).await {
+        Ok(slos) => found.extend(slo_dependents(&slos, monitor_id)),
+        Err(e) => eprintln!("warning: could not check SLOs: {e:#}"),
+    }
+    match dashboard_dependents(cfg, monitor_id).await {
+        Ok(dashboards) => found.extend(dashboards),
+        Err(e) => eprintln!("warning: could not check dashboards: {e:#}"),
+    }
+    match downtimes_for(cfg, monitor_id).await {
+        Ok(downtimes) => found.extend(downtimes),
+        Err(e) => eprintln!("warning: could not check downtimes: {e:#}"),
+    }
+    found
+}
+
+/// Warning lines for `monitors delete --check-dependencies`.
+pub fn dependents_warning(monitor_id: i64, dependents: &[Dependent]) -> Vec<String> {
+    if dependents.is_empty() {
+        return vec![format!(
+            "No composite monitors, SLOs, dashboards, or downtimes reference monitor {monitor_id}."
+        )];
+    }
+    let mut lines = vec![format!(
+        "warning: monitor {monitor_id} is referenced by {} resource(s):",
+        dependents.len()
+    )];
+    lines.extend(dependents.iter().map(|d| {
+        if d.name.is_empty() {
+            format!("  {} {}", d.kind, d.id)
+        } else {
+            format!("  {} {} {:?}", d.kind, d.id, d.name)
+        }
+    }));
+    lines
+}
+
+// ---------------------------------------------------------------------------
+// Bulk rewrite
+// ---------------------------------------------------------------------------
+
+/// Monitors requested per search page when resolving `--query`.
+const REWRITE_SEARCH_PAGE_SIZE: i64 = 100;
+
+/// Inputs for `pup monitors rewrite`.
+#[derive(Debug, Default)]
+pub struct RewriteOptions {
+    /// Monitor search query selecting the monitors to rewrite.
+    pub query: String,
+    pub add_tags: Vec<String>,
+    pub remove_tags: Vec<String>,
+    /// Message substitutions as `old=new`, applied in order.
+    pub replacements: Vec<String>,
+    pub dry_run: bool,
+    pub concurrency: usize,
+}
+
+/// Per-monitor outcome of a rewrite.
+#[derive(Serialize, Debug)]
+pub struct MonitorChange {
+    pub id: i64,
+    pub name: String,
+    /// One of `unchanged`, `planned`, `updated`, or `failed`.
+    pub status: String,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub tags_added: Vec<String>,
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub tags_removed: Vec<String>,
+    pub message_changed: bool,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub error: Option<String>,
+}
+
+/// Split `old=new` message substitutions. The first `=` separates the pair.
+pub fn parse_replacements(pairs: &[String]) -> Result<Vec<(String, String)>> {
+    pairs
+        .iter()
+        .map(|p| match p.split_once('=') {
+            Some((old, new)) if !old.is_empty() => Ok((old.to_string(), new.to_string())),
+            _ => bail!("invalid --replace-in-message {p:?} (expected old=new)"),
+        })
+        .collect()
+}
+
+/// Monitor IDs and page count from a `/api/v1/monitor/search` response.
+fn search_page_ids(resp: &serde_json::Value) -> (Vec<i64>, i64) {
+    let ids = resp
+        .get("monitors")
+        .and_then(|m| m.as_array())
+        .map(|a| a.iter().filter_map(|m| m["id"].as_i64()).collect())
+        .unwrap_or_default();
+    let pages = resp
+        .pointer("/metadata/page_count")
+        .and_then(|p| p.as_i64())
+        .unwrap_or(1);
+    (ids, pages)
+}
+
+/// Work out the change for one monitor and the partial update body, if any.
+pub fn plan_rewrite(
+    monitor: &serde_json::Value,
+    opts: &RewriteOptions,
+    replacements: &[(String, String)],
+) -> (MonitorChange, Option<serde_json::Value>) {
+    let tags: Vec<String> = monitor
+        .get("tags")
+        .and_then(|t| t.as_array())
+        .map(|a| {
+            a.iter()
+                .filter_map(|t| t.as_str().map(String::from))
+                .collect()
+        })
+        .unwrap_or_default();
+    let tags_removed: Vec<String> = tags
+        .iter()
+        .filter(|t| opts.remove_tags.contains(t))
+        .cloned()
+        .collect();
+    let mut new_tags: Vec<String> = tags
+        .iter()
+        .filter(|t| !opts.remove_tags.contains(t))
+        .cloned()
+        .collect();
+    let mut tags_added = Vec::new();
+    for t in &opts.add_tags {
+        if !new_tags.contains(t) {
+            new_tags.push(t.clone());
+            tags_added.push(t.clone());
+        }
+    }
+
+    let message = monitor
+        .get("message")
+        .and_then(|m| m.as_str())
+        .unwrap_or_default();
+    let new_message = replacements
+        .iter()
+        .fold(message.to_string(), |m, (old, new)| m.replace(old, new));
+    let message_changed = new_message != message;
+
+    let mut body = serde_json::Map::new();
+    if !tags_added.is_empty() || !tags_removed.is_empty() {
+        body.insert("tags".into(), serde_json::json!(new_tags));
+    }
+    if message_changed {
+        body.insert("message".into(), serde_json::json!(new_message));
+    }
+
+    let change = MonitorChange {
+        id: monitor["id"].as_i64().unwrap_or_default(),
+        name: monitor["name"].as_str().unwrap_or_default().to_string(),
+        status: if body.is_empty() {
+            "unchanged"
+        } else {
+            "planned"
+        }
+        .to_string(),
+        tags_added,
+        tags_removed,
+        message_changed,
+        error: None,
+    };
+    let body = (!body.is_empty()).then_some(serde_json::Value::Object(body));
+    (change, body)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+fn monitors_api(cfg: &Config) -> MonitorsAPI {
+    let dd_cfg = client::make_dd_config(cfg);
+    if let Some(http_client) = client::make_bearer_client(cfg) {
+        MonitorsAPI::with_client_and_config(dd_cfg, http_client)
+    } else {
+        MonitorsAPI::with_config(dd_cfg)
+    }
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn search_monitor_ids(cfg: &Config, query: &str) -> Result<Vec<i64>> {
+    let api = monitors_api(cfg);
+    let mut ids = Vec::new();
+    let mut page = 0;
+    loop {
+        let params = SearchMonitorsOptionalParams::default()
+            .query(query.to_string())
+            .page(page)
+            .per_page(REWRITE_SEARCH_PAGE_SIZE);
+        let resp = api
+            .search_monitors(params)
+            .await
+            .map_err(|e| anyhow::anyhow!("failed to search monitors: {:?}", e))?;
+        let (page_ids, pages) = search_page_ids(&serde_json::to_value(resp)?);
+        if page_ids.is_empty() {
+            break;
+        }
+        ids.extend(page_ids);
+        page += 1;
+        if page >= pages {
+            break;
+        }
+    }
+    Ok(ids)
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn search_monitor_ids(cfg: &Config, query: &str) -> Result<Vec<i64>> {
+    let mut ids = Vec::new();
+    let mut page = 0;
+    loop {
+        let q = vec![
+            ("query", query.to_string()),
+            ("page", page.to_string()),
+            ("per_page", REWRITE_SEARCH_PAGE_SIZE.to_string()),
+        ];
+        let resp = crate::api::get(cfg, "/api/v1/monitor/search", &q).await?;
+        let (page_ids, pages) = search_page_ids(&resp);
+        if page_ids.is_empty() {
+            break;
+        }
+        ids.extend(page_ids);
+        page += 1;
+        if page >= pages {
+            break;
+        }
+    }
+    Ok(ids)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn fetch_monitor(cfg: &Config, id: i64) -> Result<serde_json::Value> {
+    let resp = monitors_api(cfg)
+        .get_monitor(id, GetMonitorOptionalParams::default())
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to get monitor {id}: {:?}", e))?;
+    Ok(serde_json::to_value(resp)?)
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn fetch_monitor(cfg: &Config, id: i64) -> Result<serde_json::Value> {
+    crate::api::get(cfg, &format!("/api/v1/monitor/{id}"), &[]).await
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn apply_monitor_update(cfg: &Config, id: i64, body: serde_json::Value) -> Result<()> {
+    let body: datadog_api_client::datadogV1::model::MonitorUpdateRequest =
+        serde_json::from_value(body)?;
+    monitors_api(cfg)
+        .update_monitor(id, body)
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to update monitor {id}: {:?}", e))?;
+    Ok(())
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn apply_monitor_update(cfg: &Config, id: i64, body: serde_json::Value) -> Result<()> {
+    crate::api::put(cfg, &format!("/api/v1/monitor/{id}"), &body).await?;
+    Ok(())
+}
+
+/// Bulk retag monitors and rewrite their messages, printing a per-monitor change report.
+pub async fn rewrite(cfg: &Config, opts: RewriteOptions) -> Result<()> {
+    if opts.add_tags.is_empty() && opts.remove_tags.is_empty() && opts.replacements.is_empty() {
+        bail!("nothing to do: pass --add-tag, --remove-tag, or --replace-in-message");
+    }
+    let replacements = parse_replacements(&opts.replacements)?;
+
+    let ids = search_monitor_ids(cfg, &opts.query).await?;
+    if ids.is_empty() {
+        eprintln!("No monitors matched query {:?}.", opts.query);
+        return Ok(());
+    }
+
+    let shared = std::sync::Arc::new(cfg.clone());
+    let fetched = crate::util::run_bounded(ids.clone(), opts.concurrency, |id| {
+        let cfg = shared.clone();
+        async move { fetch_monitor(&cfg, id).await }
+    })
+    .await;
+
+    let mut changes = Vec::new();
+    let mut updates = Vec::new();
+    for (id, monitor) in ids.into_iter().zip(fetched) {
+        match monitor {
+            Ok(m) => {
+                let (change, body) = plan_rewrite(&m, &opts, &replacements);
+                if let Some(body) = body {
+                    updates.push((changes.len(), id, body));
+                }
+                changes.push(change);
+            }
+            Err(e) => changes.push(MonitorChange {
+                id,
+                name: String::new(),
+                status: "failed".to_string(),
+                tags_added: vec![],
+                tags_removed: vec![],
+                message_changed: false,
+                error: Some(e.to_string()),
+            }),
+        }
+    }
+
+    if !opts.dry_run {
+        let positions: Vec<usize> = updates.iter().map(|(pos, _, _)| *pos).collect();
+        let results = crate::util::run_bounded(updates, opts.concurrency, |(_, id, body)| {
+            let cfg = shared.clone();
+            async move { apply_monitor_update(&cfg, id, body).await }
+        })
+        .await;
+        for (pos, result) in positions.into_iter().zip(results) {
+            let change = &mut changes[pos];
+            match result {
+                Ok(()) => change.status = "updated".to_string(),
+                Err(e) => {
+                    change.status = "failed".to_string();
+                    change.error = Some(e.to_string());
+                }
+            }
+        }
+    }
+
+    let count = |status: &str| changes.iter().filter(|c| c.status == status).count();
+    let failed = count("failed");
+    if opts.dry_run {
+        eprintln!(
+            "Dry run: {} of {} monitors would change.",
+            count("planned"),
+            changes.len()
+        );
+    } else {
+        eprintln!(
+            "Updated {} of {} monitors ({} unchanged, {} failed).",
+            count("updated"),
+            changes.len(),
+            count("unchanged"),
+            failed
+        );
+    }
+
+    let meta = Metadata {
+        count: Some(changes.len()),
+        truncated: false,
+        command: Some("monitors rewrite".to_string()),
+        next_action: opts
+            .dry_run
+            .then(|| "re-run without --dry-run to apply the planned changes".to_string()),
+    };
+    formatter::format_and_print(&changes, cfg, Some(&meta))?;
+    if failed > 0 {
+        bail!("{failed} monitor(s) failed to rewrite");
+    }
+    Ok(())
+}
+
+// ---------------------------------------------------------------------------
+// Export / import
+// ---------------------------------------------------------------------------
+
+/// Monitors requested per page when listing for export/import.
+const EXPORT_PAGE_SIZE: usize = 1000;
+
+/// Server-managed monitor fields dropped from exported files. `id` is kept so
+/// import can update the monitor in place.
+const MONITOR_READ_ONLY_FIELDS: &[&str] = &[
+    "created",
+    "creator",
+    "deleted",
+    "matching_downtimes",
+    "modified",
+    "multi",
+    "org_id",
+    "overall_state",
+    "overall_state_modified",
+    "state",
+];
+
+/// Normalize a monitor for the export directory: drop server-managed fields and
+/// sort keys so an unchanged monitor always produces an identical file.
+pub fn export_body(mut monitor: serde_json::Value) -> serde_json::Value {
+    if let Some(obj) = monitor.as_object_mut() {
+        for field in MONITOR_READ_ONLY_FIELDS {
+            obj.remove(*field);
+        }
+    }
+    formatter::sort_json_value(monitor)
+}
+
+/// The part of a monitor that import compares and sends: the export body without `id`.
+pub fn import_body(monitor: serde_json::Value) -> serde_json::Value {
+    let mut body = export_body(monitor);
+    if let Some(obj) = body.as_object_mut() {
+        obj.remove("id");
+    }
+    body
+}
+
+/// Find the existing monitor a file refers to: by `id` first, then by exact
+/// name, so files exported from another org still line up with their copies.
+pub fn match_monitor<'a>(
+    local: &serde_json::Value,
+    existing: &'a [serde_json::Value],
+) -> Option<&'a serde_json::Value> {
+    let by_id = local["id"]
+        .as_i64()
+        .and_then(|id| existing.iter().find(|m| m["id"].as_i64() == Some(id)));
+    by_id.or_else(|| {
+        let name = local["name"].as_str()?;
+        existing.iter().find(|m| m["name"].as_str() == Some(name))
+    })
+}
+
+fn export_contents(monitor: serde_json::Value) -> Result<String> {
+    Ok(format!(
+        "{}\n",
+        serde_json::to_string_pretty(&export_body(monitor))?
+    ))
+}
+
+/// Every monitor, optionally filtered by monitor tags, across all list pages.
+async fn list_all_monitors(cfg: &Config, tags: Option<&str>) -> Result<Vec<serde_json::Value>> {
+    let collected = util::collect_pages(
+        util::Paging::Number {
+            size: EXPORT_PAGE_SIZE,
+        },
+        "",
+        0,
+        |page| {
+            let mut query = vec![
+                ("page_size", EXPORT_PAGE_SIZE.to_string()),
+                ("page", page.number.to_string()),
+            ];
+            if let Some(tags) = tags {
+                query.push(("monitor_tags", tags.to_string()));
+            }
+            async move {
+                crate::api::get(cfg, "/api/v1/monitor", &query)
+                    .await
+                    .map_err(|e| crate::api::failed("failed to list monitors", e))
+            }
+        },
+    )
+    .await?;
+    Ok(collected.items)
+}
+
+/// Snapshot monitors (optionally only those with `tags`) into `dir`, one
+/// `<name-slug>.json` file each, removing files an earlier export wrote for
+/// monitors this one no longer includes (renamed, deleted, or untagged).
+pub async fn export(cfg: &Config, dir: &str, tags: Option<&str>) -> Result<()> {
+    let monitors = list_all_monitors(cfg, tags).await?;
+    std::fs::create_dir_all(dir)
+        .map_err(|e| anyhow::anyhow!("failed to create directory {dir:?}: {e}"))?;
+    let names: Vec<(String, String)> = monitors
+        .iter()
+        .map(|m| {
+            (
+                m["id"].to_string(),
+                m["name"].as_str().unwrap_or_default().to_string(),
+            )
+        })
+        .collect();
+
+    let mut results = Vec::new();
+    let mut written = Vec::new();
+    for (monitor, file) in monitors
+        .into_iter()
+        .zip(util::export_file_names(&names, "monitor"))
+    {
+        let path = std::path::Path::new(dir).join(file);
+        let (id, name) = (monitor["id"].clone(), monitor["name"].clone());
+        let contents = export_contents(monitor)?;
+        let action = match std::fs::read_to_string(&path) {
+            Ok(existing) if existing == contents => "unchanged",
+            Ok(_) => "updated",
+            Err(_) => "created",
+        };
+        if action != "unchanged" {
+            std::fs::write(&path, contents)
+                .map_err(|e| anyhow::anyhow!("failed to write {}: {e}", path.display()))?;
+        }
+        results.push(serde_json::json!({
+            "id": id,
+            "name": name,
+            "file": path.display().to_string(),
+            "action": action,
+        }));
+        written.push(path);
+    }
+    // Left in place, these would be re-imported as monitors that no longer exist.
+    for path in util::stale_export_files(dir, &written)? {
+        let stale: serde_json::Value = util::read_json_file(&path.display().to_string())?;
+        std::fs::remove_file(&path)
+            .map_err(|e| anyhow::anyhow!("failed to remove {}: {e}", path.display()))?;
+        results.push(serde_json::json!({
+            "id": stale["id"],
+            "name": stale["name"],
+            "file": path.display().to_string(),
+            "action": "removed",
+        }));
+    }
+    let meta = Metadata {
+        count: Some(results.len()),
+        truncated: false,
+        command: Some("monitors export".to_string()),
+        next_action: None,
+    };
+    formatter::format_and_print(&results, cfg, Some(&meta))
+}
+
+/// Push the monitors in `dir` back: files matching an existing monitor (by `id`,
+/// then name) are updated when they differ, files without an `id` are created
+/// and get their new `id` written back. A file whose `id` no longer exists is
+/// skipped rather than re-creating a monitor deleted upstream, and two files
+/// with the same `id` are refused. With `dry_run`, only report what would happen.
+pub async fn import(cfg: &Config, dir: &str, dry_run: bool) -> Result<()> {
+    let paths = util::json_files(dir)?;
+    if paths.is_empty() {
+        bail!("no monitor .json files found in {dir:?}");
+    }
+    let mut files = Vec::new();
+    for path in &paths {
+        let file = path.display().to_string();
+        let local: serde_json::Value = util::read_json_file(&file)?;
+        if local["name"].as_str().is_none() || local["query"].as_str().is_none() {
+            bail!("{file}: not a monitor (missing \"name\" or \"query\")");
+        }
+        files.push((file, local));
+    }
+    util::check_unique_ids(&files)?;
+
+    let existing = list_all_monitors(cfg, None).await?;
+    let mut results = Vec::new();
+    for (path, (file, local)) in paths.iter().zip(files) {
+        let body = import_body(local.clone());
+        let target = match_monitor(&local, &existing);
+
+        let (id, action) = match target {
+            Some(remote) if import_body(remote.clone()) == body => {
+                (remote["id"].clone(), "unchanged")
+            }
+            Some(remote) => {
+                let id = remote["id"].as_i64().unwrap_or_default();
+                if !dry_run {
+                    crate::api::put(cfg, &format!("/api/v1/monitor/{id}"), &body)
+                        .await
+                        .map_err(|e| {
+                            crate::api::failed(format!("failed to update monitor {id}"), e)
+                        })?;
+                }
+                (serde_json::json!(id), "updated")
+            }
+            None if !local["id"].is_null() => {
+                eprintln!(
+                    "{file}: monitor {} no longer exists; skipped (remove \"id\" from the \
+                     file to create it again)",
+                    local["id"]
+                );
+                (local["id"].clone(), "skipped")
+            }
+            None => {
+                let mut id = serde_json::Value::Null;
+                if !dry_run {
+                    let created = crate::api::post(cfg, "/api/v1/monitor", &body)
+                        .await
+                        .map_err(|e| {
+                            crate::api::failed(format!("failed to create monitor from {file}"), e)
+                        })?;
+                    id = created["id"].clone();
+                    std::fs::write(path, export_contents(created)?)
+                        .map_err(|e| anyhow::anyhow!("failed to write {file}: {e}"))?;
+                }
+                (id, "created")
+            }
+        };
+        results.push(serde_json::json!({
+            "file": file,
+            "id": id,
+            "name": body["name"],
+            "action": action,
+            "dry_run": dry_run,
+        }));
+    }
+
+    let count = |action: &str| results.iter().filter(|r| r["action"] == action).count();
+    let summary = format!(
+        "{} created, {} updated, {} unchanged, {} skipped",
+        count("created"),
+        count("updated"),
+        count("unchanged"),
+        count("skipped")
+    );
+    if dry_run {
+        eprintln!("Dry run: {summary}.");
+    } else {
+        eprintln!("Imported {} monitors: {summary}.", results.len());
+    }
+    let meta = Metadata {
+        count: Some(results.len()),
+        truncated: false,
+        command: Some("monitors import".to_string()),
+        next_action: dry_run.then(|| "re-run without --dry-run to apply the changes".to_string()),
+    };
+    formatter::format_and_print(&results, cfg, Some(&meta))
+}
+
+// ---------------------------------------------------------------------------
+// Bulk delete
+// ---------------------------------------------------------------------------
+
+const BULK_DELETE_OPERATION: &str = "monitors bulk-delete";
+
+/// Default checkpoint file, in the working directory.
+pub const BULK_DELETE_CHECKPOINT: &str = "pup-monitors-bulk-delete.json";
+
+/// Delete every monitor matching `query`, or finish the run recorded in
+/// `resume`. Progress is checkpointed after each monitor (see `checkpoint`).
+pub async fn bulk_delete(
+    cfg: &Config,
+    query: Option<&str>,
+    checkpoint_path: &str,
+    resume: Option<&str>,
+) -> Result<()> {
+    let mut cp = match (resume, query) {
+        (Some(path), _) => {
+            let cp = crate::checkpoint::Checkpoint::load(path.as_ref(), BULK_DELETE_OPERATION)?;
+            eprintln!(
+                "Resuming {}: {} of {} monitors left.",
+                path,
+                cp.remaining().len(),
+                cp.targets.len()
+            );
+            cp
+        }
+        (None, Some(query)) => {
+            let ids: Vec<String> = search_monitor_ids(cfg, query)
+                .await?
+                .iter()
+                .map(|id| id.to_string())
+                .collect();
+            if ids.is_empty() {
+                eprintln!("No monitors match {query:?}.");
+                return Ok(());
+            }
+            let confirmed = crate::confirm::Destructive::new("delete", "monitors matching", query)
+                .detail(format!("{} monitor(s) match {query:?}.", ids.len()))
+                .typed()
+                .confirm(cfg)?;
+            if !confirmed {
+                return Ok(());
+            }
+            crate::checkpoint::Checkpoint::create(
+                checkpoint_path.as_ref(),
+                BULK_DELETE_OPERATION,
+                ids,
+            )?
+        }
+        (None, None) => bail!("pass --query to select monitors, or --resume <checkpoint>"),
+    };
+
+    let results = crate::checkpoint::run(&mut cp, |id| async move {
+        crate::api::delete(cfg, &format!("/api/v1/monitor/{id}"))
+            .await
+            .map(|_| ())
+    })
+    .await?;
+    let rows: Vec<serde_json::Value> = results
+        .iter()
+        .map(|(id, outcome)| {
+            serde_json::json!({
+                "id": id,
+                "result": match outcome {
+                    crate::checkpoint::ItemOutcome::Done => "deleted",
+                    crate::checkpoint::ItemOutcome::AlreadyGone => "already deleted",
+                },
+            })
+        })
+        .collect();
+    cp.finish()?;
+    let meta = Metadata {
+        count: Some(rows.len()),
+        truncated: false,
+        command: Some(BULK_DELETE_OPERATION.to_string()),
+        next_action: None,
+    };
+    formatter::format_and_print(&rows, cfg, Some(&meta))
+}
+
+// ---------------------------------------------------------------------------
+// Threshold tuning
+// ---------------------------------------------------------------------------
+
+/// Metric monitor query split into the parts tuning needs, e.g.
+/// `avg(last_5m):avg:system.cpu.user{env:prod} by {host} > 90`.
+#[derive(Debug, Clone, PartialEq)]
+pub struct MetricCondition {
+    /// Time aggregator applied over each evaluation window, e.g. `avg`.
+    pub aggregation: String,
+    /// Evaluation window, e.g. `5m` from `last_5m`.
+    pub window: String,
+    pub metric_query: String,
+    pub comparator: String,
+    pub threshold: f64,
+}
+
+impl MetricCondition {
+    /// The metric query rolled up the way the monitor evaluates it: one point
+    /// per evaluation window, aggregated with the monitor's time aggregator,
+    /// so the percentiles are of the values the threshold is compared with.
+    /// Queries that already roll up, or combine several metrics, are kept
+    /// as they are.
+    pub fn evaluation_query(&self) -> Result<String> {
+        if !matches!(self.aggregation.as_str(), "avg" | "min" | "max" | "sum") {
+            bail!(
+                "unsupported time aggregation {:?}; tuning supports avg, min, max, and sum",
+                self.aggregation
+            );
+        }
+        let window = util::parse_duration_secs(&self.window)?;
+        let outside_braces = regex::Regex::new(r"\{[^}]*\}")
+            .unwrap()
+            .replace_all(&self.metric_query, "");
+        let combined = outside_braces.contains(['+', '*', '/']) || outside_braces.contains(" - ");
+        if combined || self.metric_query.contains(".rollup(") {
+            return Ok(self.metric_query.clone());
+        }
+        Ok(format!(
+            "{}.rollup({}, {window})",
+            self.metric_query, self.aggregation
+        ))
+    }
+
+    fn above(&self) -> bool {
+        self.comparator.starts_with('>')
+    }
+
+    fn breaches(&self, value: f64, threshold: f64) -> bool {
+        match self.comparator.as_str() {
+            ">" => value > threshold,
+            ">=" => value >= threshold,
+            "<" => value < threshold,
+            _ => value <= threshold,
+        }
+    }
+}
+
+pub fn parse_metric_condition(query: &str) -> Result<MetricCondition> {
+    let re = regex::Regex::new(
+        r"^\s*(\w+)\(\s*last_(\w+)\s*\)\s*:\s*(.+?)\s*(>=|<=|>|<)\s*(-?\d+(?:\.\d+)?)\s*$",
+    )
+    .unwrap();
+    let Some(caps) = re.captures(query) else {
+        bail!(
+            "unsupported monitor query {query:?}\n\
+             Expected a metric alert like: avg(last_5m):avg:system.cpu.user{{*}} > 90"
+        );
+    };
+    Ok(MetricCondition {
+        aggregation: caps[1].to_string(),
+        window: caps[2].to_string(),
+        metric_query: caps[3].to_string(),
+        comparator: caps[4].to_string(),
+        threshold: caps[5].parse()?,
+    })
+}
+
+/// The query with its threshold replaced; the API requires it to match
+/// `options.thresholds.critical`.
+pub fn with_threshold(query: &str, threshold: f64) -> String {
+    let re = regex::Regex::new(r"(>=|<=|>|<)\s*-?\d+(?:\.\d+)?\s*$").unwrap();
+    re.replace(query.trim_end(), format!("${{1}} {threshold}"))
+        .into_owned()
+}
+
+/// Time-ordered non-null values, one vector per series of a `/api/v1/query` response.
+pub fn series_values(resp: &serde_json::Value) -> Vec<Vec<f64>> {
+    resp["series"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .map(|s| {
+            let mut points: Vec<(f64, f64)> = s["pointlist"]
+                .as_array()
+                .into_iter()
+                .flatten()
+                .filter_map(|p| Some((p.get(0)?.as_f64()?, p.get(1)?.as_f64()?)))
+                .collect();
+            points.sort_by(|a, b| a.0.total_cmp(&b.0));
+            points.into_iter().map(|(_, v)| v).collect()
+        })
+        .filter(|v: &Vec<f64>| !v.is_empty())
+        .collect()
+}
+
+/// Linear-interpolated percentile (0-100) of an ascending slice.
+pub fn percentile(sorted: &[f64], p: f64) -> f64 {
+    if sorted.is_empty() {
+        return f64::NAN;
+    }
+    let rank = (p / 100.0).clamp(0.0, 1.0) * (sorted.len() - 1) as f64;
+    let (lo, hi) = (rank.floor() as usize, rank.ceil() as usize);
+    sorted[lo] + (sorted[hi] - sorted[lo]) * (rank - lo as f64)
+}
+
+/// Round to two significant figures, away from zero when `up` (toward it otherwise),
+/// so a suggested threshold never lands inside the observed percentile.
+pub fn round_threshold(value: f64, up: bool) -> f64 {
+    if value == 0.0 || !value.is_finite() {
+        return value;
+    }
+    let unit = 10f64.powf(value.abs().log10().floor() - 1.0);
+    let scaled = value / unit;
+    let rounded = if up {
+        (scaled - 1e-9).ceil()
+    } else {
+        (scaled + 1e-9).floor()
+    };
+    ((rounded * unit) * 1e6).round() / 1e6
+}
+
+/// How often `threshold` would have been crossed: the share of points in breach
+/// and the number of separate breach episodes per day of history.
+pub fn breach_frequency(
+    cond: &MetricCondition,
+    series: &[Vec<f64>],
+    threshold: f64,
+    lookback_secs: i64,
+) -> serde_json::Value {
+    let (mut total, mut breaching, mut episodes) = (0usize, 0usize, 0usize);
+    for values in series {
+        let mut in_breach = false;
+        for v in values {
+            total += 1;
+            let breach = cond.breaches(*v, threshold);
+            if breach {
+                breaching += 1;
+                if !in_breach {
+                    episodes += 1;
+                }
+            }
+            in_breach = breach;
+        }
+    }
+    let days = (lookback_secs as f64 / 86400.0).max(1.0 / 24.0);
+    let ratio = if total == 0 {
+        0.0
+    } else {
+        breaching as f64 / total as f64
+    };
+    serde_json::json!({
+        "threshold": threshold,
+        "breach_ratio": (ratio * 1e4).round() / 1e4,
+        "episodes": episodes,
+        "episodes_per_day": ((episodes as f64 / days) * 100.0).round() / 100.0,
+    })
+}
+
+/// Percentile statistics over the history plus current and suggested thresholds.
+/// Above-threshold monitors get critical at p99 and warning at p95; below-threshold
+/// monitors mirror that at p1 and p5.
+pub fn tune_analysis(
+    monitor: &serde_json::Value,
+    cond: &MetricCondition,
+    series: &[Vec<f64>],
+    lookback_secs: i64,
+) -> Result<serde_json::Value> {
+    let mut values: Vec<f64> = series.iter().flatten().copied().collect();
+    if values.is_empty() {
+        bail!(
+            "no data for {:?} in the lookback window — nothing to tune against",
+            cond.metric_query
+        );
+    }
+    values.sort_by(|a, b| a.total_cmp(b));
+    let stat = |p: f64| (percentile(&values, p) * 1e6).round() / 1e6;
+    let mean = values.iter().sum::<f64>() / values.len() as f64;
+
+    let above = cond.above();
+    let (critical_p, warning_p) = if above { (99.0, 95.0) } else { (1.0, 5.0) };
+    let critical = round_threshold(stat(critical_p), above);
+    let mut warning = round_threshold(stat(warning_p), above);
+    // Coarse rounding can collapse the two; a warning must trip before critical.
+    if (above && warning >= critical) || (!above && warning <= critical) {
+        warning = critical;
+    }
+    let thresholds = &monitor["options"]["thresholds"];
+
+    let mut current = serde_json::json!({
+        "critical": breach_frequency(cond, series, cond.threshold, lookback_secs),
+    });
+    if let Some(w) = thresholds["warning"].as_f64() {
+        current["warning"] = breach_frequency(cond, series, w, lookback_secs);
+    }
+    let mut suggested = serde_json::json!({
+        "critical": breach_frequency(cond, series, critical, lookback_secs),
+    });
+    if warning != critical {
+        suggested["warning"] = breach_frequency(cond, series, warning, lookback_secs);
+    }
+
+    Ok(serde_json::json!({
+        "monitor_id": monitor["id"],
+        "name": monitor["name"],
+        "query": monitor["query"],
+        "metric_query": cond.metric_query,
+        "evaluation_query": cond.evaluation_query()?,
+        "comparator": cond.comparator,
+        "lookback_secs": lookback_secs,
+        "series": series.len(),
+        "points": values.len(),
+        "stats": {
+            "min": stat(0.0),
+            "p1": stat(1.0),
+            "p5": stat(5.0),
+            "p50": stat(50.0),
+            "p90": stat(90.0),
+            "p95": stat(95.0),
+            "p99": stat(99.0),
+            "max": stat(100.0),
+            "mean": (mean * 1e6).round() / 1e6,
+        },
+        "current": current,
+        "suggested": suggested,
+    }))
+}
+
+/// Update body applying the suggested thresholds, for `pup monitors update --file`.
+pub fn tune_patch(monitor: &serde_json::Value, analysis: &serde_json::Value) -> serde_json::Value {
+    let critical = analysis["suggested"]["critical"]["threshold"]
+        .as_f64()
+        .unwrap_or_default();
+    let mut thresholds = monitor["options"]["thresholds"]
+        .as_object()
+        .cloned()
+        .unwrap_or_default();
+    thresholds.insert("critical".into(), critical.into());
+    match analysis["suggested"]["warning"]["threshold"].as_f64() {
+        Some(w) => thresholds.insert("warning".into(), w.into()),
+        None => thresholds.remove("warning"),
+    };
+    let mut options = monitor["options"].as_object().cloned().unwrap_or_default();
+    options.insert("thresholds".into(), serde_json::Value::Object(thresholds));
+    serde_json::json!({
+        "query": with_threshold(monitor["query"].as_str().unwrap_or_default(), critical),
+        "options": options,
+    })
+}
+
+pub async fn tune(cfg: &Config, monitor_id: i64, lookback: &str, patch: bool) -> Result<()> {
+    let lookback_secs = util::parse_duration_secs(lookback)?;
+    let monitor = fetch_monitor(cfg, monitor_id).await?;
+    let kind = monitor["type"].as_str().unwrap_or_default();
+    if kind != "metric alert" && kind != "query alert" {
+        bail!("monitor {monitor_id} is a {kind:?} monitor; tuning supports metric alert monitors");
+    }
+    let cond = parse_metric_condition(monitor["query"].as_str().unwrap_or_default())?;
+    let evaluation_query = cond.evaluation_query()?;
+
+    let to = chrono::Utc::now().timestamp();
+    let from = to - lookback_secs;
+    let resp = crate::api::get(
+        cfg,
+        "/api/v1/query",
+        &[
+            ("from", from.to_string()),
+            ("to", to.to_string()),
+            ("query", evaluation_query),
+        ],
+    )
+    .await
+    .map_err(|e| crate::api::failed(format!("failed to query monitor {monitor_id} history"), e))?;
+    let analysis = tune_analysis(&monitor, &cond, &series_values(&resp), lookback_secs)?;
+
+    if patch {
+        return formatter::output(cfg, &tune_patch(&monitor, &analysis));
+    }
+    if cfg.output_format == OutputFormat::Table && !cfg.agent_mode {
+        println!("{}", tune_summary(&analysis));
+        return Ok(());
+    }
+    formatter::output(cfg, &analysis)
+}
+
+fn tune_summary(a: &serde_json::Value) -> String {
+    let line = |label: &str, f: &serde_json::Value| {
+        format!(
+            "  {label:<9} {} {}  ({:.2}% of points, {} episodes/day)\n",
+            a["comparator"].as_str().unwrap_or_default(),
+            f["threshold"],
+            f["breach_ratio"].as_f64().unwrap_or_default() * 100.0,
+            f["episodes_per_day"],
+        )
+    };
+    let s = &a["stats"];
+    let mut out = format!(
+        "Monitor {} — {}\n{}\n\n{} points across {} series\n  min {}  p50 {}  p90 {}  p95 {}  p99 {}  max {}\n",
+        a["monitor_id"],
+        a["name"].as_str().unwrap_or_default(),
+        a["evaluation_query"].as_str().unwrap_or_default(),
+        a["points"],
+        a["series"],
+        s["min"],
+        s["p50"],
+        s["p90"],
+        s["p95"],
+        s["p99"],
+        s["max"],
+    );
+    for (title, section) in [("Current", &a["current"]), ("Suggested", &a["suggested"])] {
+        out.push_str(&format!("\n{title}:\n"));
+        out.push_str(&line("critical", &section["critical"]));
+        if section.get("warning").is_some() {
+            out.push_str(&line("warning", &section["warning"]));
+        }
+    }
+    out.push_str("\nRe-run with --patch for an update body to apply the suggestion.");
+    out
+}
+
+// ---------------------------------------------------------------------------
+// State history
+// ---------------------------------------------------------------------------
+
+/// Pages of alert events read per history request (1000 events each).
+const HISTORY_MAX_PAGES: usize = 10;
+
+fn ts_rfc3339(secs: &serde_json::Value) -> serde_json::Value {
+    secs.as_i64()
+        .filter(|s| *s > 0)
+        .and_then(|s| chrono::DateTime::from_timestamp(s, 0))
+        .map(|t| serde_json::json!(t.to_rfc3339_opts(chrono::SecondsFormat::Secs, true)))
+        .unwrap_or(serde_json::Value::Null)
+}
+
+/// Whether a v1 event is a notification from `monitor_id`.
+fn is_monitor_event(event: &serde_json::Value, monitor_id: i64) -> bool {
+    let tag = format!("monitor_id:{monitor_id}");
+    event["monitor_id"].as_i64() == Some(monitor_id)
+        || event["tags"]
+            .as_array()
+            .is_some_and(|tags| tags.iter().any(|t| t.as_str() == Some(tag.as_str())))
+}
+
+/// One state change from a monitor notification event. The state and group
+/// come from the title (`[Triggered on {host:web-1}] High CPU`), falling
+/// back to `alert_type` when the title has no bracketed state.
+pub fn transition(event: &serde_json::Value) -> Option<serde_json::Value> {
+    let time = ts_rfc3339(&event["date_happened"]);
+    if time.is_null() {
+        return None;
+    }
+    let title = event["title"].as_str().unwrap_or_default();
+    let (label, group) = match title
+        .strip_prefix('[')
+        .and_then(|rest| rest.split_once(']'))
+    {
+        Some((head, _)) => match head.split_once(" on ") {
+            Some((label, group)) => (
+                label.trim(),
+                group.trim().trim_start_matches('{').trim_end_matches('}'),
+            ),
+            None => (head.trim(), ""),
+        },
+        None => ("", ""),
+    };
+    let lower = label.to_lowercase();
+    let base = lower.strip_prefix("re-").unwrap_or(&lower);
+    let state = match base {
+        "triggered" | "alert" => "alert",
+        "warn" | "warning" => "warn",
+        "no data" => "no_data",
+        _ if base.ends_with("recovered") => "recovery",
+        _ => match event["alert_type"].as_str() {
+            Some("error") => "alert",
+            Some("warning") => "warn",
+            Some("success") => "recovery",
+            _ => return None,
+        },
+    };
+    Some(serde_json::json!({
+        "time": time,
+        "state": state,
+        "renotify": lower.starts_with("re-"),
+        "group": group,
+        "title": title,
+    }))
+}
+
+/// Current per-group states from a monitor fetched with `group_states=all`.
+pub fn group_states(monitor: &serde_json::Value) -> Vec<serde_json::Value> {
+    let mut groups: Vec<serde_json::Value> = monitor
+        .pointer("/state/groups")
+        .and_then(|g| g.as_object())
+        .into_iter()
+        .flatten()
+        .map(|(name, g)| {
+            serde_json::json!({
+                "group": name,
+                "status": g["status"],
+                "last_triggered": ts_rfc3339(&g["last_triggered_ts"]),
+                "last_resolved": ts_rfc3339(&g["last_resolved_ts"]),
+                "last_nodata": ts_rfc3339(&g["last_nodata_ts"]),
+            })
+        })
+        .collect();
+    groups.sort_by(|a, b| a["group"].as_str().cmp(&b["group"].as_str()));
+    groups
+}
+
+fn render_history(h: &serde_json::Value) -> String {
+    let mut out = format!(
+        "Monitor {} — {} (now {})\n\n",
+        h["monitor_id"],
+        h["name"].as_str().unwrap_or_default(),
+        h["overall_state"].as_str().unwrap_or("unknown"),
+    );
+    let transitions = h["transitions"].as_array().cloned().unwrap_or_default();
+    if transitions.is_empty() {
+        out.push_str("No state changes in this window.\n");
+    }
+    for t in &transitions {
+        let mut state = t["state"].as_str().unwrap_or_default().to_uppercase();
+        if t["renotify"] == true {
+            state.push_str(" (renotify)");
+        }
+        out.push_str(&format!(
+            "{}  {state:<18} {}\n",
+            t["time"].as_str().unwrap_or_default(),
+            t["group"].as_str().filter(|g| !g.is_empty()).unwrap_or("*"),
+        ));
+    }
+    let groups = h["groups"].as_array().cloned().unwrap_or_default();
+    if !groups.is_empty() {
+        out.push_str("\nCurrent groups:\n");
+        for g in &groups {
+            out.push_str(&format!(
+                "  {:<30} {:<8} last triggered {}\n",
+                g["group"].as_str().unwrap_or_default(),
+                g["status"].as_str().unwrap_or_default(),
+                g["last_triggered"].as_str().unwrap_or("never"),
+            ));
+        }
+    }
+    out
+}
+
+/// Alert, warn, no-data, and recovery transitions for a monitor in
+/// `[from, to]`, oldest first, with each group's current state.
+pub async fn history(cfg: &Config, monitor_id: i64, from: &str, to: &str) -> Result<()> {
+    let start = util::parse_time_to_unix_millis(from)? / 1000;
+    let end = util::parse_time_to_unix_millis(to)? / 1000;
+    let monitor = crate::api::get(
+        cfg,
+        &format!("/api/v1/monitor/{monitor_id}"),
+        &[("group_states", "all".to_string())],
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to get monitor", e))?;
+
+    let mut transitions = Vec::new();
+    for page in 0..HISTORY_MAX_PAGES {
+        let resp = crate::api::get(
+            cfg,
+            "/api/v1/events",
+            &[
+                ("start", start.to_string()),
+                ("end", end.to_string()),
+                ("sources", "alert".to_string()),
+                ("tags", format!("monitor_id:{monitor_id}")),
+                ("unaggregated", "true".to_string()),
+                ("page", page.to_string()),
+            ],
+        )
+        .await
+        .map_err(|e| crate::api::failed("failed to list monitor events", e))?;
+        let events = resp["events"].as_array().cloned().unwrap_or_default();
+        if events.is_empty() {
+            break;
+        }
+        transitions.extend(
+            events
+                .iter()
+                .filter(|e| is_monitor_event(e, monitor_id))
+                .filter_map(transition),
+        );
+        if page + 1 == HISTORY_MAX_PAGES {
+            eprintln!("warning: stopped after {HISTORY_MAX_PAGES} pages of alert events; narrow --from/--to for the full history");
+        }
+    }
+    transitions.sort_by(|a, b| a["time"].as_str().cmp(&b["time"].as_str()));
+
+    let history = serde_json::json!({
+        "monitor_id": monitor_id,
+        "name": monitor["name"],
+        "overall_state": monitor["overall_state"],
+        "groups": group_states(&monitor),
+        "transitions": transitions,
+    });
+    if !cfg.agent_mode && cfg.output_format == OutputFormat::Table {
+        print!("{}", render_history(&history));
+        return Ok(());
+    }
+    formatter::output(cfg, &history)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::collections::HashMap;
+
+    fn monitor(id: i64, kind: &str, state: &str, query: &str) -> serde_json::Value {
+        serde_json::json!({
+            "id": id, "name": format!("m{id}"), "type": kind,
+            "overall_state": state, "query": query
+        })
+    }
+
+    #[test]
+    fn test_dependents() {
+        let monitors = vec![
+            serde_json::json!({"id": 10, "name": "db composite", "type": "composite", "query": "123 && 456"}),
+            serde_json::json!({"id": 11, "name": "other", "type": "composite", "query": "1234 || 5"}),
+            serde_json::json!({"id": 12, "name": "plain", "type": "metric alert", "query": "avg(last_5m):123 > 1"}),
+        ];
+        assert_eq!(
+            composite_dependents(&monitors, 123),
+            vec![Dependent {
+                kind: "composite monitor",
+                id: "10".into(),
+                name: "db composite".into()
+            }]
+        );
+
+        let slos = vec![
+            serde_json::json!({"id": "abc", "name": "Checkout", "type": "monitor", "monitor_ids": [123, 7]}),
+            serde_json::json!({"id": "def", "name": "Latency", "type": "metric"}),
+        ];
+        let found = slo_dependents(&slos, 123);
+        assert_eq!(found.len(), 1);
+        assert_eq!(found[0].id, "abc");
+
+        let downtimes = vec![
+            serde_json::json!({"id": "dt-1", "attributes": {"message": "Maintenance\nmore", "monitor_identifier": {"monitor_id": 123}}}),
+            serde_json::json!({"id": "dt-2", "attributes": {"monitor_identifier": {"monitor_tags": ["*"]}}}),
+        ];
+        let found = downtime_dependents(&downtimes, 123);
+        assert_eq!(found.len(), 1);
+        assert_eq!(found[0].name, "Maintenance");
+    }
+
+    #[test]
+    fn test_references_alert_id() {
+        let widgets = serde_json::json!([
+            {"definition": {"type": "group", "widgets": [
+                {"definition": {"type": "alert_graph", "alert_id": "123", "viz_type": "timeseries"}}
+            ]}},
+            {"definition": {"type": "note", "content": "alert_id 456"}}
+        ]);
+        assert!(references_alert_id(&widgets, 123));
+        assert!(!references_alert_id(&widgets, 456));
+        assert!(!references_alert_id(&widgets, 12));
+    }
+
+    #[test]
+    fn test_dependents_warning() {
+        let lines = dependents_warning(
+            123,
+            &[Dependent {
+                kind: "SLO",
+                id: "abc".into(),
+                name: "Checkout".into(),
+            }],
+        );
+        assert_eq!(
+            lines[0],
+            "warning: monitor 123 is referenced by 1 resource(s):"
+        );
+        assert_eq!(lines[1], "  SLO abc \"Checkout\"");
+        assert!(dependents_warning(123, &[])[0].starts_with("No composite monitors"));
+    }
+
+    #[test]
+    fn test_composite_ids() {
+        assert_eq!(composite_ids("123 && (456 || !789)"), vec![123, 456, 789]);
+        assert_eq!(composite_ids("1 || 1"), vec![1]);
+        assert!(composite_ids("").is_empty());
+    }
+
+    #[test]
+    fn test_build_tree_nested_with_error_and_cycle() {
+        let mut monitors = HashMap::new();
+        monitors.insert(1, Ok(monitor(1, "composite", "Alert", "2 && 3")));
+        monitors.insert(2, Ok(monitor(2, "metric alert", "OK", "avg:x{*} > 1")));
+        monitors.insert(3, Ok(monitor(3, "composite", "Alert", "4 || 1")));
+        monitors.insert(4, Err("API error (HTTP 404)".to_string()));
+
+        let tree = build_tree(1, &monitors, &mut Vec::new());
+        assert_eq!(tree.children.len(), 2);
+        assert!(tree.children[0].query.is_none());
+        assert_eq!(
+            tree.children[1].children[0].error.as_deref(),
+            Some("API error (HTTP 404)")
+        );
+        assert!(tree.children[1].children[1]
+            .error
+            .as_deref()
+            .unwrap()
+            .starts_with("cycle"));
+    }
+
+    #[test]
+    fn test_render_tree() {
+        let mut monitors = HashMap::new();
+        monitors.insert(1, Ok(monitor(1, "composite", "Alert", "2 && 3")));
+        monitors.insert(2, Ok(monitor(2, "metric alert", "OK", "")));
+        monitors.insert(3, Ok(monitor(3, "metric alert", "Alert", "")));
+        let out = render_tree(&build_tree(1, &monitors, &mut Vec::new()));
+        assert_eq!(
+            out,
+            "1 [Alert] m1  (2 && 3)\n├── 2 [OK] m2\n└── 3 [Alert] m3\n"
+        );
+    }
+
+    #[test]
+    fn test_parse_replacements() {
+        let parsed = parse_replacements(&["@old=@new".into(), "a==b".into()]).unwrap();
+        assert_eq!(parsed[0], ("@old".to_string(), "@new".to_string()));
+        assert_eq!(parsed[1], ("a".to_string(), "=b".to_string()));
+        assert!(parse_replacements(&["no-separator".into()]).is_err());
+        assert!(parse_replacements(&["=x".into()]).is_err());
+    }
+
+    #[test]
+    fn test_plan_rewrite() {
+        let opts = RewriteOptions {
+            add_tags: vec!["team:payments".into()],
+            remove_tags: vec!["legacy-team".into()],
+            ..Default::default()
+        };
+        let replacements = vec![("@old-handle".to_string(), "@new-handle".to_string())];
+        let m = serde_json::json!({
+            "id": 7, "name": "CPU", "tags": ["legacy-team", "env:prod"],
+            "message": "High CPU @old-handle"
+        });
+        let (change, body) = plan_rewrite(&m, &opts, &replacements);
+        assert_eq!(change.status, "planned");
+        assert_eq!(change.tags_removed, vec!["legacy-team"]);
+        assert_eq!(change.tags_added, vec!["team:payments"]);
+        assert!(change.message_changed);
+        assert_eq!(
+            body.unwrap(),
+            serde_json::json!({
+                "tags": ["env:prod", "team:payments"],
+                "message": "High CPU @new-handle"
+            })
+        );
+
+        let done = serde_json::json!({
+            "id": 8, "name": "Disk", "tags": ["team:payments"], "message": "@new-handle"
+        });
+        let (change, body) = plan_rewrite(&done, &opts, &replacements);
+        assert_eq!(change.status, "unchanged");
+        assert!(body.is_none());
+    }
+
+    #[test]
+    fn test_search_page_ids() {
+        let resp = serde_json::json!({
+            "monitors": [{"id": 1}, {"id": 2}],
+            "metadata": {"page": 0, "page_count": 3}
+        });
+        assert_eq!(search_page_ids(&resp), (vec![1, 2], 3));
+        assert_eq!(search_page_ids(&serde_json::json!({})), (vec![], 1));
+    }
+
+    #[test]
+    fn test_annotate_downtimes() {
+        let mut monitors = serde_json::json!([
+            {
+                "id": 1,
+                "matching_downtimes": [
+                    {"id": 10, "active": true, "scope": ["env:prod"], "end": 1700000000},
+                    {"id": 11, "active": false, "scope": ["env:staging"]}
+                ],
+                "options": {"silenced": {"host:web-1": null}}
+            },
+            {"id": 2, "options": {}}
+        ]);
+        annotate_downtimes(&mut monitors);
+        let first = &monitors[0]["downtime_status"];
+        assert_eq!(first["silenced"], true);
+        assert_eq!(
+            first["muted_scopes"],
+            serde_json::json!(["env:prod", "host:web-1"])
+        );
+        assert_eq!(first["active_downtimes"][0]["id"], 10);
+        assert_eq!(first["active_downtimes"].as_array().unwrap().len(), 1);
+        assert_eq!(monitors[1]["downtime_status"]["silenced"], false);
+    }
+
+    #[test]
+    fn test_export_and_import_body() {
+        let fetched = serde_json::json!({
+            "id": 42, "name": "CPU high", "type": "metric alert",
+            "query": "avg(last_5m):avg:cpu{*} > 90", "tags": ["team:a"],
+            "created": "2024-01-01T00:00:00Z", "creator": {"handle": "a@example.com"},
+            "overall_state": "OK", "org_id": 1, "options": {"thresholds": {"critical": 90}}
+        });
+        let exported = export_body(fetched.clone());
+        assert_eq!(exported["id"], 42);
+        assert!(exported.get("creator").is_none());
+        assert!(exported.get("overall_state").is_none());
+        let keys: Vec<&String> = exported.as_object().unwrap().keys().collect();
+        let mut sorted = keys.clone();
+        sorted.sort();
+        assert_eq!(keys, sorted);
+
+        // A state change on the server doesn't count as a difference.
+        let mut refetched = fetched;
+        refetched["overall_state"] = serde_json::json!("Alert");
+        let body = import_body(exported);
+        assert!(body.get("id").is_none());
+        assert_eq!(import_body(refetched.clone()), body);
+        refetched["query"] = serde_json::json!("avg(last_5m):avg:cpu{*} > 95");
+        assert_ne!(import_body(refetched), body);
+    }
+
+    #[test]
+    fn test_match_monitor() {
+        let existing = vec![
+            serde_json::json!({"id": 1, "name": "CPU high"}),
+            serde_json::json!({"id": 2, "name": "Disk full"}),
+        ];
+        let by_id = serde_json::json!({"id": 2, "name": "Renamed"});
+        assert_eq!(match_monitor(&by_id, &existing).unwrap()["id"], 2);
+        let by_name = serde_json::json!({"id": 99, "name": "CPU high"});
+        assert_eq!(match_monitor(&by_name, &existing).unwrap()["id"], 1);
+        let new = serde_json::json!({"name": "Memory"});
+        assert!(match_monitor(&new, &existing).is_none());
+    }
+
+    #[test]
+    fn test_parse_metric_condition() {
+        let cond =
+            parse_metric_condition("avg(last_5m):avg:system.cpu.user{env:prod} by {host} >= 90.5")
+                .unwrap();
+        assert_eq!(cond.metric_query, "avg:system.cpu.user{env:prod} by {host}");
+        assert_eq!(cond.aggregation, "avg");
+        assert_eq!(cond.window, "5m");
+        assert_eq!(cond.comparator, ">=");
+        assert_eq!(cond.threshold, 90.5);
+        assert_eq!(
+            cond.evaluation_query().unwrap(),
+            "avg:system.cpu.user{env:prod} by {host}.rollup(avg, 300)"
+        );
+        let max = parse_metric_condition("max(last_1h):max:disk.used{env:pre-prod} > 9").unwrap();
+        assert_eq!(
+            max.evaluation_query().unwrap(),
+            "max:disk.used{env:pre-prod}.rollup(max, 3600)"
+        );
+        let ratio =
+            parse_metric_condition("avg(last_5m):sum:errors{*} / sum:hits{*} > 0.1").unwrap();
+        assert_eq!(
+            ratio.evaluation_query().unwrap(),
+            "sum:errors{*} / sum:hits{*}"
+        );
+        assert!(
+            parse_metric_condition("sum(last_1h):sum:free{*} < -1")
+                .unwrap()
+                .threshold
+                < 0.0
+        );
+        assert!(parse_metric_condition("logs(\"status:error\").index(\"*\") > 5").is_err());
+    }
+
+    #[test]
+    fn test_with_threshold() {
+        assert_eq!(
+            with_threshold("avg(last_5m):avg:cpu{*} > 90", 72.5),
+            "avg(last_5m):avg:cpu{*} > 72.5"
+        );
+        assert_eq!(
+            with_threshold("avg(last_5m):avg:cpu{*}<=1.5 ", 2.0),
+            "avg(last_5m):avg:cpu{*}<= 2"
+        );
+    }
+
+    #[test]
+    fn test_percentile_and_rounding() {
+        let sorted: Vec<f64> = (1..=101).map(f64::from).collect();
+        assert_eq!(percentile(&sorted, 50.0), 51.0);
+        assert_eq!(percentile(&sorted, 99.0), 100.0);
+        assert_eq!(percentile(&[3.0, 5.0], 50.0), 4.0);
+        assert!(percentile(&[], 50.0).is_nan());
+        assert_eq!(round_threshold(87.3, true), 88.0);
+        assert_eq!(round_threshold(87.3, false), 87.0);
+        assert_eq!(round_threshold(0.0123, true), 0.013);
+        assert_eq!(round_threshold(1200.0, true), 1200.0);
+    }
+
+    #[test]
+    fn test_tune_analysis_and_patch() {
+        let monitor = serde_json::json!({
+            "id": 7, "name": "CPU", "type": "metric alert",
+            "query": "avg(last_5m):avg:cpu{*} > 50",
+            "options": {"thresholds": {"critical": 50.0, "warning": 40.0}, "notify_no_data": true}
+        });
+        let cond = parse_metric_condition(monitor["query"].as_str().unwrap()).unwrap();
+        let resp = serde_json::json!({"series": [
+            {"pointlist": (0..100).map(|i| serde_json::json!([i * 1000, f64::from(i)])).collect::<Vec<_>>()},
+            {"pointlist": [[0, null]]}
+        ]});
+        let series = series_values(&resp);
+        assert_eq!(series.len(), 1);
+
+        let a = tune_analysis(&monitor, &cond, &series, 2 * 86400).unwrap();
+        assert_eq!(a["points"], 100);
+        assert_eq!(a["suggested"]["critical"]["threshold"], 99.0);
+        assert_eq!(a["suggested"]["warning"]["threshold"], 95.0);
+        assert_eq!(a["current"]["critical"]["breach_ratio"], 0.49);
+        assert_eq!(a["current"]["critical"]["episodes_per_day"], 0.5);
+        assert_eq!(a["suggested"]["critical"]["episodes"], 0);
+
+        let patch = tune_patch(&monitor, &a);
+        assert_eq!(patch["query"], "avg(last_5m):avg:cpu{*} > 99");
+        assert_eq!(patch["options"]["thresholds"]["warning"], 95.0);
+        assert_eq!(patch["options"]["notify_no_data"], true);
+
+        assert!(tune_analysis(&monitor, &cond, &[], 86400).is_err());
+    }
+
+    #[test]
+    fn test_transition_from_title() {
+        let event = |title: &str, alert_type: &str| serde_json::json!({"date_happened": 1714564800, "title": title, "alert_type": alert_type});
+        let t = transition(&event(
+            "[Triggered on {host:web-1,env:prod}] High CPU",
+            "error",
+        ))
+        .unwrap();
+        assert_eq!(t["state"], "alert");
+        assert_eq!(t["group"], "host:web-1,env:prod");
+        assert_eq!(t["time"], "2024-05-01T12:00:00Z");
+        assert_eq!(t["renotify"], false);
+
+        let t = transition(&event("[Re-Triggered] High CPU", "error")).unwrap();
+        assert_eq!(
+            (t["state"].as_str(), t["renotify"].as_bool()),
+            (Some("alert"), Some(true))
+        );
+        assert_eq!(
+            transition(&event("[Warn Recovered on {host:a}] x", "success")).unwrap()["state"],
+            "recovery"
+        );
+        assert_eq!(
+            transition(&event("[No Data] x", "warning")).unwrap()["state"],
+            "no_data"
+        );
+        assert_eq!(
+            transition(&event("Free text", "warning")).unwrap()["state"],
+            "warn"
+        );
+        assert!(transition(&event("Free text", "info")).is_none());
+        assert!(transition(&serde_json::json!({"title": "[Triggered] x"})).is_none());
+    }
+
+    #[test]
+    fn test_is_monitor_event_and_group_states() {
+        assert!(is_monitor_event(&serde_json::json!({"monitor_id": 7}), 7));
+        assert!(is_monitor_event(
+            &serde_json::json!({"tags": ["monitor_id:7"]}),
+            7
+        ));
+        assert!(!is_monitor_event(
+            &serde_json::json!({"monitor_id": 8, "tags": []}),
+            7
+        ));
+
+        let m = serde_json::json!({"state": {"groups": {
+            "host:b": {"status": "OK", "last_triggered_ts": 0, "last_resolved_ts": 1714564800},
+            "host:a": {"status": "Alert", "last_triggered_ts": 1714564800}
+        }}});
+        let groups = group_states(&m);
+        assert_eq!(groups[0]["group"], "host:a");
+        assert_eq!(groups[0]["last_triggered"], "2024-05-01T12:00:00Z");
+        assert!(groups[1]["last_triggered"].is_null());
+    }
+}
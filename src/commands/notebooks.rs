@@ -114,3 +114,388 @@ pub async fn update(cfg: &Config, notebook_id: i64, file: &str) -> Result<()> {
     let data = crate::api::put(cfg, &format!("/api/v1/notebooks/{notebook_id}"), &body).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Clone
+// ---------------------------------------------------------------------------
+
+/// Notebook attributes accepted by the create endpoint.
+const NOTEBOOK_CREATE_ATTRIBUTES: &[&str] = &["name", "cells", "time", "status", "metadata"];
+
+/// Turn a fetched notebook into a create body, dropping server-assigned fields.
+pub fn prepare_clone(
+    notebook: &serde_json::Value,
+    name: Option<String>,
+) -> Result<serde_json::Value> {
+    let Some(attrs) = notebook
+        .pointer("/data/attributes")
+        .and_then(|a| a.as_object())
+    else {
+        anyhow::bail!("unexpected notebook response: missing data.attributes");
+    };
+    let mut out = serde_json::Map::new();
+    for key in NOTEBOOK_CREATE_ATTRIBUTES {
+        if let Some(v) = attrs.get(*key) {
+            out.insert((*key).into(), v.clone());
+        }
+    }
+    let name = name.unwrap_or_else(|| {
+        format!(
+            "{} (copy)",
+            attrs
+                .get("name")
+                .and_then(|n| n.as_str())
+                .unwrap_or("Notebook")
+        )
+    });
+    out.insert("name".into(), serde_json::json!(name));
+    if let Some(cells) = out.get_mut("cells").and_then(|c| c.as_array_mut()) {
+        for cell in cells.iter_mut().filter_map(|c| c.as_object_mut()) {
+            cell.remove("id");
+        }
+    }
+    Ok(serde_json::json!({ "data": { "type": "notebooks", "attributes": out } }))
+}
+
+/// Copy a notebook under a new name, optionally into another named org session.
+pub async fn clone(
+    cfg: &Config,
+    notebook_id: i64,
+    name: Option<String>,
+    to_org: Option<&str>,
+) -> Result<()> {
+    let dest = match to_org {
+        Some(org) => cfg.for_org(org)?,
+        None => cfg.clone(),
+    };
+    let source = crate::api::get(cfg, &format!("/api/v1/notebooks/{notebook_id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get notebook", e))?;
+    let body = prepare_clone(&source, name)?;
+    let resp = crate::api::post(&dest, "/api/v1/notebooks", &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create notebook copy", e))?;
+    formatter::output(cfg, &resp)
+}
+
+// ---------------------------------------------------------------------------
+// Cells
+// ---------------------------------------------------------------------------
+
+/// An edit to a notebook's cell list. Positions are 1-based, as in `cells list`.
+#[derive(Debug, Clone, PartialEq)]
+pub enum CellEdit {
+    Append(serde_json::Value),
+    Update {
+        cell_id: String,
+        attributes: serde_json::Value,
+    },
+    Delete {
+        cell_id: String,
+    },
+    Move {
+        cell_id: String,
+        position: usize,
+    },
+}
+
+/// Cell attributes from a `--body`: either `{"definition": ...}` (optionally
+/// with `graph_size`, `split_by`, `time`) or a bare widget definition.
+pub fn cell_attributes(body: serde_json::Value) -> Result<serde_json::Value> {
+    if !body.is_object() {
+        anyhow::bail!("cell body must be a JSON object");
+    }
+    let attributes = if body.get("definition").is_some() {
+        body
+    } else {
+        serde_json::json!({ "definition": body })
+    };
+    if attributes["definition"]["type"].as_str().is_none() {
+        anyhow::bail!("cell definition must have a \"type\" (e.g. \"markdown\", \"timeseries\")");
+    }
+    Ok(attributes)
+}
+
+fn cell_position(cells: &[serde_json::Value], cell_id: &str) -> Result<usize> {
+    cells
+        .iter()
+        .position(|c| c["id"].as_str() == Some(cell_id))
+        .ok_or_else(|| anyhow::anyhow!("notebook has no cell {cell_id:?}; see 'cells list'"))
+}
+
+/// Apply `edit` to a notebook's cells.
+pub fn apply_cell_edit(cells: &mut Vec<serde_json::Value>, edit: CellEdit) -> Result<()> {
+    match edit {
+        CellEdit::Append(attributes) => {
+            cells.push(serde_json::json!({ "type": "notebook_cells", "attributes": attributes }));
+        }
+        CellEdit::Update {
+            cell_id,
+            attributes,
+        } => {
+            let i = cell_position(cells, &cell_id)?;
+            cells[i]["attributes"] = attributes;
+        }
+        CellEdit::Delete { cell_id } => {
+            let i = cell_position(cells, &cell_id)?;
+            if cells.len() == 1 {
+                anyhow::bail!("cannot delete the only cell; a notebook needs at least one");
+            }
+            cells.remove(i);
+        }
+        CellEdit::Move { cell_id, position } => {
+            if position == 0 || position > cells.len() {
+                anyhow::bail!("--position must be between 1 and {}", cells.len());
+            }
+            let i = cell_position(cells, &cell_id)?;
+            let cell = cells.remove(i);
+            cells.insert(position - 1, cell);
+        }
+    }
+    Ok(())
+}
+
+/// Update body that replaces a notebook's cells and keeps its other settings.
+/// Existing cells keep their IDs; new ones get IDs from the API.
+pub fn cells_update_body(
+    notebook: &serde_json::Value,
+    cells: Vec<serde_json::Value>,
+) -> Result<serde_json::Value> {
+    let mut body = prepare_clone(notebook, None)?;
+    let attrs = &mut body["data"]["attributes"];
+    attrs["name"] = notebook["data"]["attributes"]["name"].clone();
+    attrs["cells"] = serde_json::Value::Array(
+        cells
+            .into_iter()
+            .map(|c| {
+                let mut cell = serde_json::json!({
+                    "type": "notebook_cells",
+                    "attributes": c["attributes"],
+                });
+                if let Some(id) = c.get("id") {
+                    cell["id"] = id.clone();
+                }
+                cell
+            })
+            .collect(),
+    );
+    Ok(body)
+}
+
+/// One line of text describing a cell: its markdown, title, or first query.
+fn cell_summary(definition: &serde_json::Value) -> String {
+    let text = definition["text"]
+        .as_str()
+        .or(definition["title"].as_str())
+        .or(definition.pointer("/requests/0/q").and_then(|q| q.as_str()))
+        .or(definition
+            .pointer("/requests/0/queries/0/query")
+            .and_then(|q| q.as_str()))
+        .unwrap_or_default();
+    let line = text
+        .lines()
+        .find(|l| !l.trim().is_empty())
+        .unwrap_or_default();
+    let line = line.trim();
+    if line.chars().count() > 80 {
+        format!("{}…", line.chars().take(79).collect::<String>())
+    } else {
+        line.to_string()
+    }
+}
+
+/// Rows for `cells list`: 1-based position, cell ID, widget type, and summary.
+pub fn cell_rows(notebook: &serde_json::Value) -> Vec<serde_json::Value> {
+    notebook["data"]["attributes"]["cells"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .enumerate()
+        .map(|(i, cell)| {
+            let definition = &cell["attributes"]["definition"];
+            serde_json::json!({
+                "position": i + 1,
+                "id": cell["id"],
+                "type": definition["type"],
+                "summary": cell_summary(definition),
+            })
+        })
+        .collect()
+}
+
+async fn fetch_notebook(cfg: &Config, notebook_id: i64) -> Result<serde_json::Value> {
+    crate::api::get(cfg, &format!("/api/v1/notebooks/{notebook_id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get notebook", e))
+}
+
+pub async fn cells_list(cfg: &Config, notebook_id: i64) -> Result<()> {
+    let notebook = fetch_notebook(cfg, notebook_id).await?;
+    formatter::output(cfg, &cell_rows(&notebook))
+}
+
+/// Fetch the notebook, edit its cells, and write it back. The notebook API
+/// only replaces cells as a whole, so a concurrent edit in between is lost.
+pub async fn cells_edit(cfg: &Config, notebook_id: i64, edit: CellEdit) -> Result<()> {
+    let notebook = fetch_notebook(cfg, notebook_id).await?;
+    let mut cells = notebook["data"]["attributes"]["cells"]
+        .as_array()
+        .cloned()
+        .unwrap_or_default();
+    apply_cell_edit(&mut cells, edit)?;
+    let body = cells_update_body(&notebook, cells)?;
+    let updated = crate::api::put(cfg, &format!("/api/v1/notebooks/{notebook_id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update notebook", e))?;
+    formatter::output(cfg, &cell_rows(&updated))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_prepare_clone() {
+        let nb = serde_json::json!({"data": {"id": 1, "type": "notebooks", "attributes": {
+            "name": "Runbook",
+            "author": {"handle": "a@example.com"},
+            "created": "2024-01-01T00:00:00Z",
+            "status": "published",
+            "time": {"live_span": "1h"},
+            "cells": [{"id": "c1", "type": "notebook_cells", "attributes": {
+                "definition": {"type": "markdown", "text": "hi"}
+            }}]
+        }}});
+        let body = prepare_clone(&nb, None).unwrap();
+        let attrs = &body["data"]["attributes"];
+        assert_eq!(body["data"]["type"], "notebooks");
+        assert_eq!(attrs["name"], "Runbook (copy)");
+        assert!(attrs.get("author").is_none());
+        assert!(attrs.get("created").is_none());
+        assert!(attrs["cells"][0].get("id").is_none());
+        assert_eq!(attrs["cells"][0]["attributes"]["definition"]["text"], "hi");
+
+        let body = prepare_clone(&nb, Some("Team runbook".into())).unwrap();
+        assert_eq!(body["data"]["attributes"]["name"], "Team runbook");
+        assert!(prepare_clone(&serde_json::json!({}), None).is_err());
+    }
+
+    fn cells(ids: &[&str]) -> Vec<serde_json::Value> {
+        ids.iter()
+            .map(|id| {
+                serde_json::json!({"id": id, "type": "notebook_cells", "attributes": {
+                    "definition": {"type": "markdown", "text": format!("# {id}")}
+                }})
+            })
+            .collect()
+    }
+
+    fn ids(cells: &[serde_json::Value]) -> Vec<&str> {
+        cells
+            .iter()
+            .map(|c| c["id"].as_str().unwrap_or("new"))
+            .collect()
+    }
+
+    #[test]
+    fn test_cell_attributes() {
+        let bare = cell_attributes(serde_json::json!({"type": "markdown", "text": "hi"})).unwrap();
+        assert_eq!(bare["definition"]["text"], "hi");
+        let full = serde_json::json!({"definition": {"type": "markdown", "text": "hi"}, "graph_size": "m"});
+        assert_eq!(cell_attributes(full.clone()).unwrap(), full);
+        assert!(cell_attributes(serde_json::json!({"text": "no type"})).is_err());
+        assert!(cell_attributes(serde_json::json!([])).is_err());
+    }
+
+    #[test]
+    fn test_apply_cell_edit() {
+        let mut c = cells(&["a", "b", "c"]);
+        apply_cell_edit(
+            &mut c,
+            CellEdit::Move {
+                cell_id: "c".into(),
+                position: 1,
+            },
+        )
+        .unwrap();
+        assert_eq!(ids(&c), vec!["c", "a", "b"]);
+        apply_cell_edit(
+            &mut c,
+            CellEdit::Delete {
+                cell_id: "a".into(),
+            },
+        )
+        .unwrap();
+        assert_eq!(ids(&c), vec!["c", "b"]);
+        let attributes = serde_json::json!({"definition": {"type": "markdown", "text": "new"}});
+        apply_cell_edit(
+            &mut c,
+            CellEdit::Update {
+                cell_id: "b".into(),
+                attributes: attributes.clone(),
+            },
+        )
+        .unwrap();
+        assert_eq!(c[1]["attributes"], attributes);
+        apply_cell_edit(&mut c, CellEdit::Append(attributes)).unwrap();
+        assert_eq!(ids(&c), vec!["c", "b", "new"]);
+
+        assert!(apply_cell_edit(
+            &mut c,
+            CellEdit::Delete {
+                cell_id: "x".into()
+            }
+        )
+        .is_err());
+        let out_of_range = CellEdit::Move {
+            cell_id: "c".into(),
+            position: 4,
+        };
+        assert!(apply_cell_edit(&mut c, out_of_range).is_err());
+        let mut one = cells(&["a"]);
+        assert!(apply_cell_edit(
+            &mut one,
+            CellEdit::Delete {
+                cell_id: "a".into()
+            }
+        )
+        .is_err());
+    }
+
+    #[test]
+    fn test_cells_update_body_keeps_ids_and_settings() {
+        let nb = serde_json::json!({"data": {"id": 1, "attributes": {
+            "name": "Runbook",
+            "status": "published",
+            "time": {"live_span": "1h"},
+            "cells": cells(&["a"])
+        }}});
+        let mut c = cells(&["a"]);
+        apply_cell_edit(
+            &mut c,
+            CellEdit::Append(serde_json::json!({"definition": {"type": "markdown", "text": "x"}})),
+        )
+        .unwrap();
+        let body = cells_update_body(&nb, c).unwrap();
+        let attrs = &body["data"]["attributes"];
+        assert_eq!(attrs["name"], "Runbook");
+        assert_eq!(attrs["time"]["live_span"], "1h");
+        assert_eq!(attrs["cells"][0]["id"], "a");
+        assert!(attrs["cells"][1].get("id").is_none());
+    }
+
+    #[test]
+    fn test_cell_rows() {
+        let mut c = cells(&["a"]);
+        c.push(serde_json::json!({"id": "b", "attributes": {"definition": {
+            "type": "timeseries",
+            "requests": [{"q": "avg:system.cpu.user{*}"}]
+        }}}));
+        let nb = serde_json::json!({"data": {"attributes": {"cells": c}}});
+        let rows = cell_rows(&nb);
+        assert_eq!(rows[0]["position"], 1);
+        assert_eq!(rows[0]["summary"], "# a");
+        assert_eq!(rows[1]["type"], "timeseries");
+        assert_eq!(rows[1]["summary"], "avg:system.cpu.user{*}");
+    }
+}
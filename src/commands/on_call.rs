@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV2::api_teams::{
     GetTeamMembershipsOptionalParams, ListTeamsOptionalParams, TeamsAPI,
@@ -142,13 +142,15 @@ pub async fn teams_update(cfg: &Config, team_id: &str, name: &str, handle: &str)
 }
 
 #[cfg(not(target_arch = "wasm32"))]
-pub async fn memberships_list(cfg: &Config, team_id: &str, page_size: i64) -> Result<()> {
+pub async fn memberships_list(cfg: &Config, team_id: &str, limit: i64, page: i64) -> Result<()> {
     let dd_cfg = client::make_dd_config(cfg);
     let api = match client::make_bearer_client(cfg) {
         Some(c) => TeamsAPI::with_client_and_config(dd_cfg, c),
         None => TeamsAPI::with_config(dd_cfg),
     };
-    let params = GetTeamMembershipsOptionalParams::default().page_size(page_size);
+    let params = GetTeamMembershipsOptionalParams::default()
+        .page_size(limit)
+        .page_number(page);
     let resp = api
         .get_team_memberships(team_id.to_string(), params)
         .await
@@ -157,8 +159,11 @@ pub async fn memberships_list(cfg: &Config, team_id: &str, page_size: i64) -> Re
 }
 
 #[cfg(target_arch = "wasm32")]
-pub async fn memberships_list(cfg: &Config, team_id: &str, page_size: i64) -> Result<()> {
-    let q = vec![("page[size]", page_size.to_string())];
+pub async fn memberships_list(cfg: &Config, team_id: &str, limit: i64, page: i64) -> Result<()> {
+    let q = vec![
+        ("page[size]", limit.to_string()),
+        ("page[number]", page.to_string()),
+    ];
     let data = crate::api::get(cfg, &format!("/api/v2/teams/{team_id}/memberships"), &q).await?;
     crate::formatter::output(cfg, &data)
 }
@@ -302,3 +307,344 @@ pub async fn memberships_remove(cfg: &Config, team_id: &str, user_id: &str) -> R
     println!("Membership for user {user_id} removed from team {team_id}.");
     Ok(())
 }
+
+// ---- Schedules and escalation policies ----
+
+const SCHEDULES_PATH: &str = "/api/v2/on-call/schedules";
+const ESCALATION_POLICIES_PATH: &str = "/api/v2/on-call/escalation-policies";
+
+/// An on-call resource served under `path`, for the list/get/create/update
+/// commands schedules and escalation policies share.
+#[derive(Debug, Clone, Copy)]
+pub struct Resource {
+    pub path: &'static str,
+    /// Singular, for messages.
+    pub label: &'static str,
+}
+
+pub const SCHEDULES: Resource = Resource {
+    path: SCHEDULES_PATH,
+    label: "schedule",
+};
+
+pub const ESCALATION_POLICIES: Resource = Resource {
+    path: ESCALATION_POLICIES_PATH,
+    label: "escalation policy",
+};
+
+/// An update body must name the resource it updates: fill in `data.id` when
+/// the file leaves it out, and refuse a file written for a different one.
+pub fn with_id(mut body: serde_json::Value, id: &str) -> Result<serde_json::Value> {
+    if !body["data"].is_object() {
+        bail!("invalid request body: expected a top-level \"data\" object");
+    }
+    match body["data"]["id"].as_str() {
+        Some(existing) if existing != id => {
+            bail!("the file is for {existing}, not {id}: fix data.id or pass the matching ID")
+        }
+        Some(_) => {}
+        None => body["data"]["id"] = id.into(),
+    }
+    Ok(body)
+}
+
+pub async fn list(cfg: &Config, resource: Resource) -> Result<()> {
+    let resp = crate::api::get(cfg, resource.path, &[])
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to list {}s", resource.label), e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn get(cfg: &Config, resource: Resource, id: &str, include: Option<&str>) -> Result<()> {
+    let mut query = Vec::new();
+    if let Some(include) = include {
+        query.push(("include", include.to_string()));
+    }
+    let resp = crate::api::get(cfg, &format!("{}/{id}", resource.path), &query)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to get {}", resource.label), e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn create(cfg: &Config, resource: Resource, file: &str) -> Result<()> {
+    let body: serde_json::Value = crate::util::read_json_file(file)?;
+    let resp = crate::api::post(cfg, resource.path, &body)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to create {}", resource.label), e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn update(cfg: &Config, resource: Resource, id: &str, file: &str) -> Result<()> {
+    let body = with_id(crate::util::read_json_file(file)?, id)?;
+    let resp = crate::api::put(cfg, &format!("{}/{id}", resource.path), &body)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to update {}", resource.label), e))?;
+    formatter::output(cfg, &resp)
+}
+
+// ---- Overrides ----
+
+/// Start and end of an override as RFC3339. `start` is `now` or RFC3339;
+/// `end` is RFC3339 or a duration after `start` such as `12h`.
+pub fn override_window(
+    start: &str,
+    end: &str,
+    now: chrono::DateTime<chrono::Utc>,
+) -> Result<(String, String)> {
+    let parse = |flag: &str, input: &str| {
+        chrono::DateTime::parse_from_rfc3339(input)
+            .map(|t| t.with_timezone(&chrono::Utc))
+            .map_err(|_| anyhow::anyhow!("invalid {flag} {input:?}: expected RFC3339"))
+    };
+    let start_at = if start.eq_ignore_ascii_case("now") {
+        now
+    } else {
+        parse("--start", start)?
+    };
+    let end_at = match crate::util::parse_duration_secs(end) {
+        Ok(secs) => start_at + chrono::Duration::seconds(secs),
+        Err(_) => parse("--end", end)?,
+    };
+    if end_at <= start_at {
+        bail!("--end must be after --start");
+    }
+    if end_at <= now {
+        bail!("the override would already be over: --end is in the past");
+    }
+    let fmt =
+        |t: chrono::DateTime<chrono::Utc>| t.to_rfc3339_opts(chrono::SecondsFormat::Secs, true);
+    Ok((fmt(start_at), fmt(end_at)))
+}
+
+/// Request body for an override putting `user_id` on call.
+pub fn override_body(user_id: &str, start: &str, end: &str) -> serde_json::Value {
+    serde_json::json!({
+        "data": {
+            "type": "overrides",
+            "attributes": { "start": start, "end": end },
+            "relationships": {
+                "user": { "data": { "type": "users", "id": user_id } }
+            }
+        }
+    })
+}
+
+/// Put `user_id` on call for a schedule between `start` and `end`, e.g. to
+/// cover a swap.
+pub async fn overrides_create(
+    cfg: &Config,
+    schedule_id: &str,
+    user_id: &str,
+    start: &str,
+    end: &str,
+) -> Result<()> {
+    let (start, end) = override_window(start, end, chrono::Utc::now())?;
+    let body = override_body(user_id, &start, &end);
+    let resp = crate::api::post(
+        cfg,
+        &format!("{SCHEDULES_PATH}/{schedule_id}/overrides"),
+        &body,
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to create override", e))?;
+    formatter::output(cfg, &resp)
+}
+
+// ---- Who is on call ----
+
+/// Page size used when walking every on-call schedule.
+const SCHEDULES_PAGE_MAX: usize = 100;
+
+/// `(id, name)` of the listed schedules that belong to `team_id`.
+pub fn team_schedules(schedules: &[serde_json::Value], team_id: &str) -> Vec<(String, String)> {
+    schedules
+        .iter()
+        .filter(|s| {
+            s["relationships"]["teams"]["data"]
+                .as_array()
+                .is_some_and(|teams| teams.iter().any(|t| t["id"] == team_id))
+        })
+        .filter_map(|s| {
+            Some((
+                s["id"].as_str()?.to_string(),
+                s["attributes"]["name"]
+                    .as_str()
+                    .unwrap_or_default()
+                    .to_string(),
+            ))
+        })
+        .collect()
+}
+
+/// One row for the user a schedule's on-call response names, or None when
+/// nobody is on call.
+pub fn responder_row(schedule: &str, resp: &serde_json::Value) -> Option<serde_json::Value> {
+    let shift = &resp["data"];
+    let user_id = shift["relationships"]["user"]["data"]["id"].as_str()?;
+    let user = resp["included"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .find(|i| i["type"] == "users" && i["id"] == user_id);
+    let attr = |name: &str| {
+        user.and_then(|u| u["attributes"][name].as_str())
+            .unwrap_or_default()
+            .to_string()
+    };
+    let email = attr("email");
+    let handle = match attr("handle") {
+        h if h.is_empty() => email.clone(),
+        h => h,
+    };
+    Some(serde_json::json!({
+        "schedule": schedule,
+        "name": attr("name"),
+        "handle": handle,
+        "email": email,
+        "shift_end": shift["attributes"]["end"].as_str().unwrap_or_default(),
+    }))
+}
+
+/// Row for a schedule whose on-call lookup failed, so the other schedules
+/// still print.
+pub fn error_row(schedule: &str, err: &anyhow::Error) -> serde_json::Value {
+    serde_json::json!({
+        "schedule": schedule,
+        "name": "",
+        "handle": "",
+        "email": "",
+        "shift_end": "",
+        "error": format!("{err:#}"),
+    })
+}
+
+/// Who is on call right now for a team, across its schedules: name, handle,
+/// and when each shift ends.
+pub async fn who(cfg: &Config, team: &str) -> Result<()> {
+    let team_id = if crate::util::parse_uuid(team, "team").is_ok() {
+        team.to_string()
+    } else {
+        crate::resolve::resolve(cfg, crate::resolve::Kind::Team, team).await?
+    };
+    let collected = crate::util::collect_pages(
+        crate::util::Paging::Number {
+            size: SCHEDULES_PAGE_MAX,
+        },
+        "/data",
+        0,
+        |page| {
+            let query = vec![
+                ("include", "teams".to_string()),
+                ("page[size]", SCHEDULES_PAGE_MAX.to_string()),
+                ("page[number]", page.number.to_string()),
+            ];
+            async move { crate::api::get(cfg, SCHEDULES_PATH, &query).await }
+        },
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to list schedules", e))?;
+    let schedules = team_schedules(&collected.items, &team_id);
+    if schedules.is_empty() {
+        bail!("team {team} has no on-call schedules");
+    }
+    let mut rows = Vec::new();
+    let mut failed = 0;
+    for (id, name) in &schedules {
+        let resp = crate::api::get(
+            cfg,
+            &format!("{SCHEDULES_PATH}/{id}/on-call"),
+            &[("include", "user".to_string())],
+        )
+        .await;
+        match resp {
+            Ok(resp) => match responder_row(name, &resp) {
+                Some(row) => rows.push(row),
+                None => eprintln!("Nobody is on call for schedule {name}."),
+            },
+            Err(e) => {
+                failed += 1;
+                rows.push(error_row(name, &e));
+            }
+        }
+    }
+    // Failed schedules sort last.
+    rows.sort_by_key(|r| {
+        (
+            r.get("error").is_some(),
+            r["shift_end"].as_str().map(String::from),
+        )
+    });
+    formatter::output(cfg, &rows)?;
+    if failed == schedules.len() {
+        bail!("failed to get the on-call user for every schedule of team {team}");
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_team_schedules() {
+        let schedules = vec![
+            serde_json::json!({"id": "sch-1", "attributes": {"name": "SRE primary"},
+             "relationships": {"teams": {"data": [{"id": "team-a", "type": "teams"}]}}}),
+            serde_json::json!({"id": "sch-2", "attributes": {"name": "DB"},
+             "relationships": {"teams": {"data": [{"id": "team-b", "type": "teams"}]}}}),
+            serde_json::json!({"id": "sch-3", "attributes": {"name": "Unowned"}}),
+        ];
+        assert_eq!(
+            team_schedules(&schedules, "team-a"),
+            vec![("sch-1".to_string(), "SRE primary".to_string())]
+        );
+        assert!(team_schedules(&schedules, "team-c").is_empty());
+    }
+
+    #[test]
+    fn test_responder_row() {
+        let resp = serde_json::json!({
+            "data": {"type": "shifts", "attributes": {"start": "2026-03-02T09:00:00Z", "end": "2026-03-09T09:00:00Z"},
+                     "relationships": {"user": {"data": {"id": "u-1", "type": "users"}}}},
+            "included": [{"type": "users", "id": "u-1", "attributes": {"name": "Ada", "email": "ada@example.com", "handle": ""}}]
+        });
+        let row = responder_row("SRE primary", &resp).unwrap();
+        assert_eq!(row["name"], "Ada");
+        assert_eq!(row["handle"], "ada@example.com");
+        assert_eq!(row["shift_end"], "2026-03-09T09:00:00Z");
+        assert!(responder_row("SRE primary", &serde_json::json!({"data": null})).is_none());
+
+        let row = error_row("DB", &anyhow::anyhow!("403 Forbidden"));
+        assert_eq!(row["schedule"], "DB");
+        assert_eq!(row["error"], "403 Forbidden");
+    }
+
+    #[test]
+    fn test_with_id() {
+        let body =
+            serde_json::json!({"data": {"type": "schedules", "attributes": {"name": "SRE"}}});
+        let body = with_id(body, "sch-1").unwrap();
+        assert_eq!(body["data"]["id"], "sch-1");
+        assert!(with_id(body.clone(), "sch-1").is_ok());
+        assert!(with_id(body, "sch-2").is_err());
+        assert!(with_id(serde_json::json!({"name": "SRE"}), "sch-1").is_err());
+    }
+
+    #[test]
+    fn test_override_window() {
+        let now = chrono::DateTime::parse_from_rfc3339("2026-03-02T09:00:00Z")
+            .unwrap()
+            .with_timezone(&chrono::Utc);
+        assert_eq!(
+            override_window("now", "12h", now).unwrap(),
+            ("2026-03-02T09:00:00Z".into(), "2026-03-02T21:00:00Z".into())
+        );
+        assert_eq!(
+            override_window("2026-03-03T18:00:00+01:00", "2026-03-04T09:00:00Z", now).unwrap(),
+            ("2026-03-03T17:00:00Z".into(), "2026-03-04T09:00:00Z".into())
+        );
+        assert!(override_window("2026-03-03T18:00:00Z", "2026-03-03T08:00:00Z", now).is_err());
+        assert!(override_window("2026-03-01T00:00:00Z", "1h", now).is_err());
+        assert!(override_window("tomorrow", "1h", now).is_err());
+    }
+}
@@ -46,3 +46,151 @@ pub async fn get(cfg: &Config) -> Result<()> {
     let data = crate::api::get(cfg, "/api/v1/org/current", &[]).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// SSO inspection (login methods, IdP metadata)
+// ---------------------------------------------------------------------------
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn current_org(cfg: &Config) -> Result<serde_json::Value> {
+    let dd_cfg = client::make_dd_config(cfg);
+    let api = match client::make_bearer_client(cfg) {
+        Some(c) => OrganizationsAPI::with_client_and_config(dd_cfg, c),
+        None => OrganizationsAPI::with_config(dd_cfg),
+    };
+    let resp = api
+        .get_org("current".to_string())
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to get org: {e:?}"))?;
+    Ok(serde_json::to_value(resp)?)
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn current_org(cfg: &Config) -> Result<serde_json::Value> {
+    crate::api::get(cfg, "/api/v1/org/current", &[]).await
+}
+
+fn setting_enabled(settings: &serde_json::Value, key: &str) -> bool {
+    settings
+        .get(key)
+        .and_then(|s| s.get("enabled").or(Some(s)))
+        .and_then(|v| v.as_bool())
+        .unwrap_or(false)
+}
+
+/// Summarize the org's login settings from its v1 settings. The API reports
+/// only SAML; whether password and Google login are allowed is inferred from
+/// SAML strict mode and labeled as such.
+pub fn login_methods(org: &serde_json::Value) -> serde_json::Value {
+    let org = org.get("org").unwrap_or(org);
+    let settings = org.get("settings").cloned().unwrap_or_default();
+    let saml = setting_enabled(&settings, "saml");
+    let strict = setting_enabled(&settings, "saml_strict_mode");
+
+    let methods: Vec<&str> = if saml { vec!["saml"] } else { Vec::new() };
+    serde_json::json!({
+        "org_name": org.get("name"),
+        "public_id": org.get("public_id"),
+        "login_methods": methods,
+        "inferred": {
+            "password_and_google_allowed": !(saml && strict),
+            "basis": "not reported by the API; allowed unless SAML strict mode is on",
+        },
+        "saml_enabled": saml,
+        "saml_can_be_enabled": settings.get("saml_can_be_enabled"),
+        "saml_strict_mode": strict,
+        "saml_idp_initiated_login": setting_enabled(&settings, "saml_idp_initiated_login"),
+        "saml_autocreate_access_role": settings.get("saml_autocreate_access_role"),
+        "saml_autocreate_users_domains": settings
+            .pointer("/saml_autocreate_users_domains/domains")
+            .cloned()
+            .unwrap_or_else(|| serde_json::json!([])),
+    })
+}
+
+/// IdP metadata state for the org's SAML configuration.
+pub fn idp_metadata(org: &serde_json::Value) -> serde_json::Value {
+    let org = org.get("org").unwrap_or(org);
+    let settings = org.get("settings").cloned().unwrap_or_default();
+    serde_json::json!({
+        "public_id": org.get("public_id"),
+        "metadata_uploaded": settings
+            .get("saml_idp_metadata_uploaded")
+            .and_then(|v| v.as_bool())
+            .unwrap_or(false),
+        "idp_endpoint": settings.get("saml_idp_endpoint"),
+        "login_url": settings.get("saml_login_url"),
+        "saml_enabled": setting_enabled(&settings, "saml"),
+    })
+}
+
+pub async fn login_methods_get(cfg: &Config) -> Result<()> {
+    let org = current_org(cfg).await?;
+    formatter::output(cfg, &login_methods(&org))
+}
+
+pub async fn idp_metadata_get(cfg: &Config) -> Result<()> {
+    let org = current_org(cfg).await?;
+    formatter::output(cfg, &idp_metadata(&org))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn org(saml: bool, strict: bool) -> serde_json::Value {
+        serde_json::json!({"org": {
+            "name": "Acme",
+            "public_id": "abc123",
+            "settings": {
+                "saml": {"enabled": saml},
+                "saml_strict_mode": {"enabled": strict},
+                "saml_idp_initiated_login": {"enabled": true},
+                "saml_can_be_enabled": true,
+                "saml_autocreate_access_role": "ro",
+                "saml_autocreate_users_domains": {"domains": ["acme.com"], "enabled": true},
+                "saml_idp_endpoint": "https://idp.acme.com/sso",
+                "saml_idp_metadata_uploaded": true,
+                "saml_login_url": "https://app.datadoghq.com/account/saml/login/abc123"
+            }
+        }})
+    }
+
+    #[test]
+    fn test_login_methods_saml_strict() {
+        let out = login_methods(&org(true, true));
+        assert_eq!(out["login_methods"], serde_json::json!(["saml"]));
+        assert_eq!(out["inferred"]["password_and_google_allowed"], false);
+        assert_eq!(out["saml_strict_mode"], true);
+        assert_eq!(out["saml_idp_initiated_login"], true);
+        assert_eq!(
+            out["saml_autocreate_users_domains"],
+            serde_json::json!(["acme.com"])
+        );
+    }
+
+    #[test]
+    fn test_login_methods_saml_not_strict() {
+        let out = login_methods(&org(true, false));
+        assert_eq!(out["login_methods"], serde_json::json!(["saml"]));
+        assert_eq!(out["inferred"]["password_and_google_allowed"], true);
+    }
+
+    #[test]
+    fn test_login_methods_no_saml() {
+        let out = login_methods(&org(false, true));
+        assert_eq!(out["login_methods"], serde_json::json!([]));
+        assert_eq!(out["inferred"]["password_and_google_allowed"], true);
+        assert_eq!(out["saml_enabled"], false);
+    }
+
+    #[test]
+    fn test_idp_metadata() {
+        let out = idp_metadata(&org(true, false));
+        assert_eq!(out["metadata_uploaded"], true);
+        assert_eq!(out["idp_endpoint"], "https://idp.acme.com/sso");
+        assert_eq!(out["public_id"], "abc123");
+        let empty = idp_metadata(&serde_json::json!({}));
+        assert_eq!(empty["metadata_uploaded"], false);
+    }
+}
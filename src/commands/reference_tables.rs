@@ -0,0 +1,552 @@
+//! Reference tables: CSV enrichment data joined into logs by pipeline
+//! lookup processors. Local CSV files go through the chunked upload flow:
+//! request an upload, PUT each part to its presigned URL, then create or
+//! update the table with the upload ID. Datadog then processes the file in
+//! the background; `--wait` polls the table until that finishes.
+
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+use crate::formatter;
+use crate::util;
+
+const TABLES_PATH: &str = "/api/v2/reference-tables/tables";
+const UPLOADS_PATH: &str = "/api/v2/reference-tables/uploads";
+
+/// Size of each uploaded part, and the most parts one upload may have.
+const PART_SIZE: usize = 5 * 1024 * 1024;
+const MAX_PARTS: usize = 20;
+
+/// Attempts per part PUT, and the delay before the first retry (doubled
+/// after each failure).
+const PART_MAX_ATTEMPTS: u32 = 3;
+const PART_RETRY_BASE_DELAY_MS: u64 = 500;
+
+/// Seconds between table status checks with `--wait`.
+const STATUS_POLL_SECS: u64 = 5;
+
+/// A CSV file read for upload: its header row and raw contents.
+#[derive(Debug)]
+pub struct CsvFile {
+    pub headers: Vec<String>,
+    pub bytes: Vec<u8>,
+}
+
+/// Column names from a CSV header line. Quoted names may contain commas and
+/// `""` escapes.
+pub fn parse_header(line: &str) -> Result<Vec<String>> {
+    let line = line.trim_start_matches('\u{feff}').trim_end_matches('\r');
+    let mut headers = Vec::new();
+    let mut field = String::new();
+    let mut quoted = false;
+    let mut chars = line.chars().peekable();
+    while let Some(c) = chars.next() {
+        match c {
+            '"' if quoted && chars.peek() == Some(&'"') => {
+                field.push('"');
+                chars.next();
+            }
+            '"' => quoted = !quoted,
+            ',' if !quoted => headers.push(std::mem::take(&mut field).trim().to_string()),
+            c => field.push(c),
+        }
+    }
+    if quoted {
+        bail!("invalid CSV header: unterminated quote");
+    }
+    headers.push(field.trim().to_string());
+    if let Some(pos) = headers.iter().position(String::is_empty) {
+        bail!("invalid CSV header: column {} has no name", pos + 1);
+    }
+    for (i, h) in headers.iter().enumerate() {
+        if headers[..i].contains(h) {
+            bail!("invalid CSV header: duplicate column {h:?}");
+        }
+    }
+    Ok(headers)
+}
+
+pub fn read_csv(path: &str) -> Result<CsvFile> {
+    let bytes =
+        std::fs::read(path).map_err(|e| anyhow::anyhow!("failed to read CSV {path:?}: {e}"))?;
+    let text = std::str::from_utf8(&bytes)
+        .map_err(|_| anyhow::anyhow!("CSV {path:?} is not valid UTF-8"))?;
+    let Some(first) = text.lines().next().filter(|l| !l.trim().is_empty()) else {
+        bail!("CSV {path:?} is empty: expected a header row");
+    };
+    let headers = parse_header(first)?;
+    Ok(CsvFile { headers, bytes })
+}
+
+/// `(part_size, part_count)` for a file of `len` bytes.
+pub fn upload_parts(len: usize) -> Result<(usize, usize)> {
+    if len == 0 {
+        bail!("cannot upload an empty CSV");
+    }
+    let count = len.div_ceil(PART_SIZE);
+    if count > MAX_PARTS {
+        bail!(
+            "CSV is too large: {len} bytes exceeds the {} MiB upload limit",
+            PART_SIZE * MAX_PARTS / (1024 * 1024)
+        );
+    }
+    Ok((len.min(PART_SIZE), count))
+}
+
+/// Table schema for `headers`: every column is a STRING unless listed in
+/// `int_columns`. Primary keys and integer columns must be in the header.
+pub fn schema(
+    headers: &[String],
+    primary_keys: &[String],
+    int_columns: &[String],
+) -> Result<serde_json::Value> {
+    if primary_keys.is_empty() {
+        bail!("at least one primary key column is required");
+    }
+    for col in primary_keys.iter().chain(int_columns) {
+        if !headers.contains(col) {
+            bail!(
+                "column {col:?} is not in the CSV header ({})",
+                headers.join(", ")
+            );
+        }
+    }
+    let fields: Vec<serde_json::Value> = headers
+        .iter()
+        .map(|h| {
+            let ty = if int_columns.contains(h) {
+                "INT32"
+            } else {
+                "STRING"
+            };
+            serde_json::json!({ "name": h, "type": ty })
+        })
+        .collect();
+    Ok(serde_json::json!({ "primary_keys": primary_keys, "fields": fields }))
+}
+
+/// Schema for replacing a table's rows with a CSV that has `headers`. Column
+/// types carry over by name and new columns are STRINGs; the table's primary
+/// keys must still be present.
+pub fn replacement_schema(
+    table: &serde_json::Value,
+    headers: &[String],
+) -> Result<serde_json::Value> {
+    let current = &table["data"]["attributes"]["schema"];
+    let primary_keys: Vec<String> = current["primary_keys"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|k| k.as_str().map(str::to_string))
+        .collect();
+    let int_columns: Vec<String> = current["fields"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter(|f| f["type"] == "INT32")
+        .filter_map(|f| f["name"].as_str().map(str::to_string))
+        .filter(|name| headers.contains(name))
+        .collect();
+    schema(headers, &primary_keys, &int_columns)
+        .map_err(|e| anyhow::anyhow!("CSV does not match the table's schema: {e}"))
+}
+
+/// Fields set on `create` and `update`.
+#[derive(Debug, Default)]
+pub struct TableChanges {
+    pub csv: Option<String>,
+    pub primary_keys: Vec<String>,
+    pub int_columns: Vec<String>,
+    pub description: Option<String>,
+    pub tags: Vec<String>,
+}
+
+/// Accept a table ID or its name, so cron jobs can refer to tables by the
+/// name their pipelines use.
+async fn resolve_table(cfg: &Config, table: &str) -> Result<String> {
+    if util::parse_uuid(table, "table").is_ok() {
+        return Ok(table.to_string());
+    }
+    let resp = crate::api::get(
+        cfg,
+        TABLES_PATH,
+        &[("filter[table_name][exact]", table.to_string())],
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to list reference tables", e))?;
+    resp["data"][0]["id"]
+        .as_str()
+        .map(str::to_string)
+        .ok_or_else(|| {
+            crate::exit_code::Failure::not_found(format!("no reference table named {table:?}"))
+        })
+}
+
+/// Upload `csv` in parts and return the upload ID to attach to a table.
+async fn upload_csv(cfg: &Config, table_name: &str, csv: &CsvFile) -> Result<String> {
+    let (part_size, part_count) = upload_parts(csv.bytes.len())?;
+    let body = serde_json::json!({
+        "data": {
+            "type": "upload",
+            "attributes": {
+                "table_name": table_name,
+                "headers": csv.headers,
+                "part_size": part_size,
+                "part_count": part_count,
+            }
+        }
+    });
+    let resp = crate::api::post(cfg, UPLOADS_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to start reference table upload", e))?;
+    let Some(upload_id) = resp["data"]["id"].as_str() else {
+        bail!("unexpected response starting the upload: no upload ID");
+    };
+    let urls: Vec<&str> = resp["data"]["attributes"]["part_urls"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|u| u.as_str())
+        .collect();
+    if urls.len() != part_count {
+        bail!(
+            "unexpected response starting the upload: {} part URLs for {part_count} parts",
+            urls.len()
+        );
+    }
+    // Presigned URLs carry their own authorization; Datadog credentials must
+    // not be sent to them.
+    let client = crate::api::http_client();
+    for (i, (url, chunk)) in urls.iter().zip(csv.bytes.chunks(PART_SIZE)).enumerate() {
+        put_part(&client, url, chunk)
+            .await
+            .map_err(|e| anyhow::anyhow!("failed to upload part {}/{part_count}: {e}", i + 1))?;
+    }
+    Ok(upload_id.to_string())
+}
+
+/// Whether a failed part PUT is worth repeating: throttling and server errors
+/// are; a rejected or expired URL is not.
+pub fn part_retryable(status: u16) -> bool {
+    matches!(status, 408 | 429 | 500..=599)
+}
+
+/// PUT one part, retrying connection failures and retryable statuses with
+/// backoff. A part is stored whole or not at all, so repeating it is safe.
+async fn put_part(client: &reqwest::Client, url: &str, chunk: &[u8]) -> Result<()> {
+    let mut attempt = 1;
+    loop {
+        let err = match client.put(url).body(chunk.to_vec()).send().await {
+            Ok(resp) if resp.status().is_success() => return Ok(()),
+            Ok(resp) if !part_retryable(resp.status().as_u16()) => {
+                bail!("HTTP {}", resp.status())
+            }
+            Ok(resp) => format!("HTTP {}", resp.status()),
+            Err(e) => e.to_string(),
+        };
+        if attempt >= PART_MAX_ATTEMPTS || cfg!(target_arch = "wasm32") {
+            bail!("{err} (after {attempt} attempts)");
+        }
+        let delay = PART_RETRY_BASE_DELAY_MS * 2u64.pow(attempt - 1);
+        eprintln!("warning: part upload failed ({err}); retrying in {delay}ms");
+        #[cfg(not(target_arch = "wasm32"))]
+        tokio::time::sleep(std::time::Duration::from_millis(delay)).await;
+        attempt += 1;
+    }
+}
+
+/// Where a table is in processing its latest upload.
+#[derive(Debug, PartialEq)]
+pub enum TableState {
+    Done,
+    Failed(String),
+    Pending,
+}
+
+pub fn table_state(table: &serde_json::Value) -> TableState {
+    let attrs = &table["data"]["attributes"];
+    match attrs["status"]
+        .as_str()
+        .map(str::to_ascii_uppercase)
+        .as_deref()
+    {
+        Some("DONE") => TableState::Done,
+        Some("ERROR") => {
+            let reason = attrs["file_metadata"]["error_message"]
+                .as_str()
+                .filter(|m| !m.is_empty())
+                .unwrap_or("no error message");
+            TableState::Failed(reason.to_string())
+        }
+        _ => TableState::Pending,
+    }
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn wait_for_table(cfg: &Config, id: &str, timeout_secs: i64) -> Result<serde_json::Value> {
+    let deadline = std::time::Instant::now() + std::time::Duration::from_secs(timeout_secs as u64);
+    eprintln!("Waiting up to {timeout_secs}s for reference table {id} to finish processing...");
+    loop {
+        let table = crate::api::get(cfg, &format!("{TABLES_PATH}/{id}"), &[])
+            .await
+            .map_err(|e| crate::api::failed("failed to get reference table", e))?;
+        match table_state(&table) {
+            TableState::Done => return Ok(table),
+            TableState::Failed(reason) => {
+                formatter::output(cfg, &table)?;
+                bail!("reference table {id} failed to process: {reason}");
+            }
+            TableState::Pending => {}
+        }
+        if std::time::Instant::now() >= deadline {
+            bail!(
+                "timed out after {timeout_secs}s waiting for reference table {id} (status {})",
+                table["data"]["attributes"]["status"]
+                    .as_str()
+                    .unwrap_or("unknown")
+            );
+        }
+        tokio::time::sleep(std::time::Duration::from_secs(STATUS_POLL_SECS)).await;
+    }
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn wait_for_table(_cfg: &Config, _id: &str, _timeout_secs: i64) -> Result<serde_json::Value> {
+    bail!("--wait is not supported in WASM builds")
+}
+
+/// Print the create or update response, or with `wait` (a timeout in
+/// seconds), the table once it has finished processing.
+async fn finish(cfg: &Config, resp: serde_json::Value, wait: Option<i64>) -> Result<()> {
+    let Some(timeout_secs) = wait else {
+        return formatter::output(cfg, &resp);
+    };
+    let Some(id) = resp["data"]["id"].as_str() else {
+        bail!("response has no table ID to wait on: {resp}");
+    };
+    let table = wait_for_table(cfg, id, timeout_secs).await?;
+    formatter::output(cfg, &table)
+}
+
+pub async fn list(cfg: &Config, name: Option<&str>, limit: i64) -> Result<()> {
+    let mut query = vec![("page[limit]", limit.to_string())];
+    if let Some(name) = name {
+        query.push(("filter[table_name][contains]", name.to_string()));
+    }
+    let resp = crate::api::get(cfg, TABLES_PATH, &query)
+        .await
+        .map_err(|e| crate::api::failed("failed to list reference tables", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn get(cfg: &Config, table: &str) -> Result<()> {
+    let id = resolve_table(cfg, table).await?;
+    let resp = crate::api::get(cfg, &format!("{TABLES_PATH}/{id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get reference table", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn create_from_file(cfg: &Config, file: &str, wait: Option<i64>) -> Result<()> {
+    let body: serde_json::Value = util::read_json_file(file)?;
+    let resp = crate::api::post(cfg, TABLES_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create reference table", e))?;
+    finish(cfg, resp, wait).await
+}
+
+/// Create a table named `name` from a local CSV.
+pub async fn create(
+    cfg: &Config,
+    name: &str,
+    changes: &TableChanges,
+    wait: Option<i64>,
+) -> Result<()> {
+    let Some(path) = changes.csv.as_deref() else {
+        bail!("--csv is required to create a reference table");
+    };
+    let csv = read_csv(path)?;
+    let schema = schema(&csv.headers, &changes.primary_keys, &changes.int_columns)?;
+    let upload_id = upload_csv(cfg, name, &csv).await?;
+    let body = serde_json::json!({
+        "data": {
+            "type": "reference_table",
+            "attributes": {
+                "table_name": name,
+                "description": changes.description.clone().unwrap_or_default(),
+                "source": "LOCAL_FILE",
+                "file_metadata": { "upload_id": upload_id },
+                "schema": schema,
+                "tags": changes.tags,
+            }
+        }
+    });
+    let resp = crate::api::post(cfg, TABLES_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create reference table", e))?;
+    finish(cfg, resp, wait).await
+}
+
+pub async fn update_from_file(
+    cfg: &Config,
+    table: &str,
+    file: &str,
+    wait: Option<i64>,
+) -> Result<()> {
+    let id = resolve_table(cfg, table).await?;
+    let body: serde_json::Value = util::read_json_file(file)?;
+    let resp = crate::api::patch(cfg, &format!("{TABLES_PATH}/{id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update reference table", e))?;
+    finish(cfg, resp, wait).await
+}
+
+/// Replace a table's rows with a local CSV and/or change its description
+/// and tags.
+pub async fn update(
+    cfg: &Config,
+    table: &str,
+    changes: &TableChanges,
+    wait: Option<i64>,
+) -> Result<()> {
+    let id = resolve_table(cfg, table).await?;
+    let path = format!("{TABLES_PATH}/{id}");
+    let mut attributes = serde_json::json!({});
+    if let Some(csv_path) = changes.csv.as_deref() {
+        let current = crate::api::get(cfg, &path, &[])
+            .await
+            .map_err(|e| crate::api::failed("failed to get reference table", e))?;
+        let csv = read_csv(csv_path)?;
+        attributes["schema"] = replacement_schema(&current, &csv.headers)?;
+        let name = current["data"]["attributes"]["table_name"]
+            .as_str()
+            .unwrap_or(table);
+        let upload_id = upload_csv(cfg, name, &csv).await?;
+        attributes["file_metadata"] = serde_json::json!({ "upload_id": upload_id });
+    }
+    if let Some(description) = &changes.description {
+        attributes["description"] = description.as_str().into();
+    }
+    if !changes.tags.is_empty() {
+        attributes["tags"] = serde_json::json!(changes.tags);
+    }
+    if attributes.as_object().is_some_and(|a| a.is_empty()) {
+        bail!("nothing to update: pass --csv, --description, --tags, or --file");
+    }
+    let body = serde_json::json!({
+        "data": { "type": "reference_table", "attributes": attributes }
+    });
+    let resp = crate::api::patch(cfg, &path, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update reference table", e))?;
+    finish(cfg, resp, wait).await
+}
+
+pub async fn delete(cfg: &Config, table: &str) -> Result<()> {
+    let id = resolve_table(cfg, table).await?;
+    crate::api::delete(cfg, &format!("{TABLES_PATH}/{id}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to delete reference table", e))?;
+    eprintln!("Reference table {table} deleted.");
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn cols(names: &[&str]) -> Vec<String> {
+        names.iter().map(|s| s.to_string()).collect()
+    }
+
+    #[test]
+    fn test_parse_header() {
+        assert_eq!(
+            parse_header("\u{feff}host, team ,\"owner, primary\",\"say \"\"hi\"\"\"\r").unwrap(),
+            cols(&["host", "team", "owner, primary", "say \"hi\""])
+        );
+        assert!(parse_header("host,,team").is_err());
+        assert!(parse_header("host,host").is_err());
+        assert!(parse_header("\"host").is_err());
+    }
+
+    #[test]
+    fn test_upload_parts() {
+        assert_eq!(upload_parts(100).unwrap(), (100, 1));
+        assert_eq!(upload_parts(PART_SIZE * 2 + 1).unwrap(), (PART_SIZE, 3));
+        assert!(upload_parts(0).is_err());
+        assert!(upload_parts(PART_SIZE * MAX_PARTS + 1).is_err());
+    }
+
+    #[test]
+    fn test_part_retryable() {
+        assert!(part_retryable(503));
+        assert!(part_retryable(429));
+        assert!(!part_retryable(403));
+        assert!(!part_retryable(400));
+    }
+
+    #[test]
+    fn test_table_state() {
+        let table = |attrs: serde_json::Value| serde_json::json!({"data": {"attributes": attrs}});
+        assert_eq!(
+            table_state(&table(serde_json::json!({"status": "DONE"}))),
+            TableState::Done
+        );
+        assert_eq!(
+            table_state(&table(serde_json::json!({"status": "PROCESSING"}))),
+            TableState::Pending
+        );
+        assert_eq!(
+            table_state(&table(serde_json::json!({}))),
+            TableState::Pending
+        );
+        assert_eq!(
+            table_state(&table(serde_json::json!({
+                "status": "ERROR",
+                "file_metadata": {"error_message": "row 3: bad INT32"}
+            }))),
+            TableState::Failed("row 3: bad INT32".into())
+        );
+    }
+
+    #[test]
+    fn test_schema() {
+        let headers = cols(&["host", "team", "cost"]);
+        let s = schema(&headers, &cols(&["host"]), &cols(&["cost"])).unwrap();
+        assert_eq!(s["primary_keys"], serde_json::json!(["host"]));
+        assert_eq!(
+            s["fields"][1],
+            serde_json::json!({"name": "team", "type": "STRING"})
+        );
+        assert_eq!(
+            s["fields"][2],
+            serde_json::json!({"name": "cost", "type": "INT32"})
+        );
+        assert!(schema(&headers, &[], &[]).is_err());
+        assert!(schema(&headers, &cols(&["id"]), &[]).is_err());
+    }
+
+    #[test]
+    fn test_replacement_schema() {
+        let table = serde_json::json!({"data": {"attributes": {"schema": {
+            "primary_keys": ["host"],
+            "fields": [
+                {"name": "host", "type": "STRING"},
+                {"name": "cost", "type": "INT32"},
+                {"name": "old", "type": "INT32"}
+            ]
+        }}}});
+        let s = replacement_schema(&table, &cols(&["host", "cost", "team"])).unwrap();
+        assert_eq!(
+            s["fields"],
+            serde_json::json!([
+                {"name": "host", "type": "STRING"},
+                {"name": "cost", "type": "INT32"},
+                {"name": "team", "type": "STRING"}
+            ])
+        );
+        let err = replacement_schema(&table, &cols(&["team"])).unwrap_err();
+        assert!(err.to_string().contains("\"host\""), "{err}");
+    }
+}
@@ -0,0 +1,406 @@
+use anyhow::{bail, Result};
+
+use crate::client;
+use crate::config::Config;
+use crate::formatter;
+use crate::util;
+
+/// Principal types accepted in `--editor` / `--viewer` bindings.
+const PRINCIPAL_TYPES: &[&str] = &["role", "team", "user", "org"];
+
+/// Validate a `<resource-type>:<id>` resource identifier, e.g. `dashboard:abc-def-ghi`.
+pub fn parse_resource_id(resource_id: &str) -> Result<(&str, &str)> {
+    match resource_id.split_once(':') {
+        Some((kind, id)) if !kind.is_empty() && !id.is_empty() => Ok((kind, id)),
+        _ => bail!(
+            "invalid resource ID: {resource_id:?} (expected <resource-type>:<id>, \
+             e.g. dashboard:abc-def-ghi, monitor:12345, security-rule:abc-123)"
+        ),
+    }
+}
+
+/// Validate a `<principal-type>:<id>` principal, e.g. `role:00000000-...`.
+fn validate_principal(principal: &str) -> Result<()> {
+    match principal.split_once(':') {
+        Some((kind, id)) if PRINCIPAL_TYPES.contains(&kind) && !id.is_empty() => Ok(()),
+        _ => bail!(
+            "invalid principal: {principal:?} (expected role:<id>, team:<id>, user:<id>, or org:<id>)"
+        ),
+    }
+}
+
+/// Build a restriction policy update body from relation → principals bindings.
+pub fn build_policy_body(
+    resource_id: &str,
+    bindings: &[(&str, &[String])],
+) -> Result<serde_json::Value> {
+    let mut out = Vec::new();
+    for (relation, principals) in bindings {
+        if principals.is_empty() {
+            continue;
+        }
+        for p in principals.iter() {
+            validate_principal(p)?;
+        }
+        out.push(serde_json::json!({ "relation": relation, "principals": principals }));
+    }
+    if out.is_empty() {
+        bail!("no bindings given — pass --editor/--viewer principals or --file");
+    }
+    Ok(serde_json::json!({
+        "data": {
+            "id": resource_id,
+            "type": "restriction_policy",
+            "attributes": { "bindings": out }
+        }
+    }))
+}
+
+pub async fn get(cfg: &Config, resource_id: &str) -> Result<()> {
+    parse_resource_id(resource_id)?;
+    let data = client::raw_get(cfg, &format!("/api/v2/restriction_policy/{resource_id}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to get restriction policy", e))?;
+    formatter::output(cfg, &data)
+}
+
+/// Replace the policy's bindings. Omitted relations are removed by the API, so
+/// callers should pass every relation they want to keep.
+pub async fn update(
+    cfg: &Config,
+    resource_id: &str,
+    file: Option<String>,
+    editors: Vec<String>,
+    viewers: Vec<String>,
+) -> Result<()> {
+    parse_resource_id(resource_id)?;
+    let body = match file {
+        Some(_) if !editors.is_empty() || !viewers.is_empty() => {
+            bail!("--file cannot be combined with --editor/--viewer")
+        }
+        Some(f) => util::read_json_file(&f)?,
+        None => build_policy_body(
+            resource_id,
+            &[
+                ("editor", editors.as_slice()),
+                ("viewer", viewers.as_slice()),
+            ],
+        )?,
+    };
+    let data = client::raw_post(
+        cfg,
+        &format!("/api/v2/restriction_policy/{resource_id}"),
+        body,
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to update restriction policy", e))?;
+    formatter::output(cfg, &data)
+}
+
+// ---- Preflight ----
+
+/// The authenticated caller, in the terms restriction policies use.
+#[derive(Debug, Default)]
+pub struct Caller {
+    pub user_id: String,
+    pub org_id: Option<String>,
+    pub roles: Vec<String>,
+    pub teams: Vec<String>,
+    pub permissions: Vec<String>,
+}
+
+impl Caller {
+    /// Every `<type>:<id>` principal that stands for this caller.
+    fn principals(&self) -> Vec<String> {
+        let mut out = vec![format!("user:{}", self.user_id)];
+        out.extend(self.org_id.iter().map(|o| format!("org:{o}")));
+        out.extend(self.roles.iter().map(|r| format!("role:{r}")));
+        out.extend(self.teams.iter().map(|t| format!("team:{t}")));
+        out
+    }
+}
+
+/// Why a write would be refused.
+#[derive(Debug, PartialEq)]
+pub enum Blocker {
+    /// None of the caller's roles grant this permission.
+    MissingPermission(&'static str),
+    /// The restriction policy limits editing to these principals.
+    NotEditor(Vec<String>),
+    /// The resource's legacy `restricted_roles` exclude all of the caller's roles.
+    RestrictedRoles(Vec<String>),
+}
+
+/// Permission needed to modify a resource type.
+pub fn write_permission(kind: &str) -> Option<&'static str> {
+    match kind {
+        "monitor" => Some("monitors_write"),
+        "dashboard" => Some("dashboards_write"),
+        "slo" => Some("slos_write"),
+        _ => None,
+    }
+}
+
+/// Check the caller against a resource's policy; no blockers means the write
+/// should be allowed.
+pub fn check_write_access(
+    caller: &Caller,
+    kind: &str,
+    policy: &serde_json::Value,
+    restricted_roles: &[String],
+) -> Vec<Blocker> {
+    let mut out = Vec::new();
+    if let Some(perm) = write_permission(kind) {
+        if !caller.permissions.iter().any(|p| p == perm) {
+            out.push(Blocker::MissingPermission(perm));
+        }
+    }
+    let editors: Vec<String> = policy
+        .pointer("/data/attributes/bindings")
+        .and_then(|b| b.as_array())
+        .into_iter()
+        .flatten()
+        .filter(|b| b["relation"] == "editor")
+        .flat_map(|b| b["principals"].as_array().cloned().unwrap_or_default())
+        .filter_map(|p| p.as_str().map(str::to_string))
+        .collect();
+    let mine = caller.principals();
+    if !editors.is_empty() && !editors.iter().any(|e| mine.contains(e)) {
+        out.push(Blocker::NotEditor(editors));
+    }
+    if !restricted_roles.is_empty() && !restricted_roles.iter().any(|r| caller.roles.contains(r)) {
+        out.push(Blocker::RestrictedRoles(
+            restricted_roles
+                .iter()
+                .map(|r| format!("role:{r}"))
+                .collect(),
+        ));
+    }
+    out
+}
+
+fn ids(v: &serde_json::Value, pointer: &str) -> Vec<String> {
+    v.as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|item| item.pointer(pointer).and_then(|id| id.as_str()))
+        .map(str::to_string)
+        .collect()
+}
+
+async fn whoami(cfg: &Config) -> Result<Caller> {
+    let me = client::raw_get(cfg, "/api/v2/current_user").await?;
+    let Some(user_id) = me["data"]["id"].as_str().map(str::to_string) else {
+        bail!("current user response has no ID");
+    };
+    let permissions = client::raw_get(cfg, &format!("/api/v2/users/{user_id}/permissions")).await?;
+    let memberships = client::raw_get(cfg, &format!("/api/v2/users/{user_id}/memberships")).await?;
+    Ok(Caller {
+        org_id: me
+            .pointer("/data/relationships/org/data/id")
+            .and_then(|o| o.as_str())
+            .map(str::to_string),
+        roles: ids(&me["data"]["relationships"]["roles"]["data"], "/id"),
+        teams: ids(&memberships["data"], "/relationships/team/data/id"),
+        permissions: ids(&permissions["data"], "/attributes/name"),
+        user_id,
+    })
+}
+
+/// `Payments (team:abc)` when the principal's name can be looked up.
+async fn principal_label(cfg: &Config, principal: &str) -> String {
+    let (path, name) = match principal.split_once(':') {
+        Some(("team", id)) => (format!("/api/v2/team/{id}"), "/data/attributes/name"),
+        Some(("role", id)) => (format!("/api/v2/roles/{id}"), "/data/attributes/name"),
+        Some(("user", id)) => (format!("/api/v2/users/{id}"), "/data/attributes/email"),
+        _ => return principal.to_string(),
+    };
+    match client::raw_get(cfg, &path).await {
+        Ok(v) => match v.pointer(name).and_then(|n| n.as_str()) {
+            Some(n) => format!("{n} ({principal})"),
+            None => principal.to_string(),
+        },
+        Err(_) => principal.to_string(),
+    }
+}
+
+async fn labels(cfg: &Config, principals: &[String]) -> String {
+    let mut out = Vec::with_capacity(principals.len());
+    for p in principals {
+        out.push(principal_label(cfg, p).await);
+    }
+    out.join(", ")
+}
+
+/// Legacy per-resource role restrictions, which predate restriction policies.
+async fn restricted_roles(cfg: &Config, kind: &str, id: &str) -> Result<Vec<String>> {
+    let path = match kind {
+        "monitor" => format!("/api/v1/monitor/{id}"),
+        "dashboard" => format!("/api/v1/dashboard/{id}"),
+        _ => return Ok(Vec::new()),
+    };
+    let resource = client::raw_get(cfg, &path)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to get {kind} {id}"), e))?;
+    Ok(resource["restricted_roles"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|r| r.as_str().map(str::to_string))
+        .collect())
+}
+
+/// Verify before a `--preflight` update or delete that the caller may modify
+/// `<kind>:<id>`, turning what would be a bare 403 into who can make the change.
+/// When the caller's identity can't be read (e.g. a key without user scopes)
+/// the check is skipped with a warning.
+pub async fn preflight(cfg: &Config, kind: &str, id: &str) -> Result<()> {
+    let caller = match whoami(cfg).await {
+        Ok(c) => c,
+        Err(e) => {
+            eprintln!("warning: preflight skipped: could not read your roles and teams: {e:#}");
+            return Ok(());
+        }
+    };
+    let policy =
+        match client::raw_get(cfg, &format!("/api/v2/restriction_policy/{kind}:{id}")).await {
+            Ok(p) => p,
+            Err(e) if e.chain().find_map(crate::api::status_of) == Some(404) => {
+                serde_json::json!({})
+            }
+            Err(e) => {
+                return Err(crate::api::failed(
+                    format!("failed to get restriction policy for {kind}:{id}"),
+                    e,
+                ))
+            }
+        };
+    let roles = restricted_roles(cfg, kind, id).await?;
+    let blockers = check_write_access(&caller, kind, &policy, &roles);
+    if blockers.is_empty() {
+        eprintln!("preflight: write access to {kind} {id} confirmed");
+        return Ok(());
+    }
+    let mut lines = Vec::new();
+    for b in &blockers {
+        lines.push(match b {
+            Blocker::MissingPermission(perm) => format!(
+                "none of your roles grant {perm}; ask an org admin to add it to one of your roles"
+            ),
+            Blocker::NotEditor(editors) => format!(
+                "its restriction policy limits editing to {}; ask one of them, or update the policy \
+                 with 'pup restriction-policies update {kind}:{id}'",
+                labels(cfg, editors).await
+            ),
+            Blocker::RestrictedRoles(roles) => format!(
+                "it is restricted to {}, and you hold none of those roles",
+                labels(cfg, roles).await
+            ),
+        });
+    }
+    Err(crate::exit_code::Failure::auth(format!(
+        "preflight: you cannot modify {kind} {id}:\n  - {}",
+        lines.join("\n  - ")
+    )))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_resource_id() {
+        assert_eq!(
+            parse_resource_id("dashboard:abc-def").unwrap(),
+            ("dashboard", "abc-def")
+        );
+        assert!(parse_resource_id("abc-def").is_err());
+        assert!(parse_resource_id("monitor:").is_err());
+        assert!(parse_resource_id(":123").is_err());
+    }
+
+    #[test]
+    fn test_build_policy_body() {
+        let editors = vec!["role:r1".to_string(), "team:t1".to_string()];
+        let viewers = vec!["org:o1".to_string()];
+        let body = build_policy_body(
+            "monitor:123",
+            &[
+                ("editor", editors.as_slice()),
+                ("viewer", viewers.as_slice()),
+            ],
+        )
+        .unwrap();
+        assert_eq!(body["data"]["id"], "monitor:123");
+        assert_eq!(body["data"]["type"], "restriction_policy");
+        let bindings = body["data"]["attributes"]["bindings"].as_array().unwrap();
+        assert_eq!(bindings.len(), 2);
+        assert_eq!(bindings[0]["relation"], "editor");
+        assert_eq!(bindings[0]["principals"][1], "team:t1");
+    }
+
+    #[test]
+    fn test_build_policy_body_skips_empty_relations() {
+        let editors = vec!["user:u1".to_string()];
+        let body = build_policy_body(
+            "dashboard:x",
+            &[("editor", editors.as_slice()), ("viewer", &[])],
+        )
+        .unwrap();
+        assert_eq!(
+            body["data"]["attributes"]["bindings"]
+                .as_array()
+                .unwrap()
+                .len(),
+            1
+        );
+    }
+
+    #[test]
+    fn test_build_policy_body_invalid() {
+        assert!(build_policy_body("dashboard:x", &[("editor", &[])]).is_err());
+        let bad = vec!["group:g1".to_string()];
+        assert!(build_policy_body("dashboard:x", &[("viewer", bad.as_slice())]).is_err());
+    }
+
+    fn caller() -> Caller {
+        Caller {
+            user_id: "u1".into(),
+            org_id: Some("o1".into()),
+            roles: vec!["r1".into()],
+            teams: vec!["t1".into()],
+            permissions: vec!["monitors_write".into()],
+        }
+    }
+
+    fn policy(editors: &[&str]) -> serde_json::Value {
+        serde_json::json!({"data": {"attributes": {"bindings": [
+            {"relation": "viewer", "principals": ["org:o1"]},
+            {"relation": "editor", "principals": editors}
+        ]}}})
+    }
+
+    #[test]
+    fn test_check_write_access_allows_editor_team() {
+        let c = caller();
+        assert!(check_write_access(&c, "monitor", &policy(&["team:t1"]), &[]).is_empty());
+        assert!(check_write_access(&c, "monitor", &serde_json::json!({}), &[]).is_empty());
+        assert!(check_write_access(&c, "monitor", &policy(&["org:o1"]), &["r1".into()]).is_empty());
+    }
+
+    #[test]
+    fn test_check_write_access_blockers() {
+        let c = caller();
+        assert_eq!(
+            check_write_access(&c, "monitor", &policy(&["team:t2"]), &[]),
+            vec![Blocker::NotEditor(vec!["team:t2".into()])]
+        );
+        assert_eq!(
+            check_write_access(&c, "dashboard", &serde_json::json!({}), &["r9".into()]),
+            vec![
+                Blocker::MissingPermission("dashboards_write"),
+                Blocker::RestrictedRoles(vec!["role:r9".into()])
+            ]
+        );
+    }
+}
@@ -157,6 +157,37 @@ pub async fn findings_search(cfg: &Config, query: Option<String>, limit: i64) ->
     crate::formatter::output(cfg, &data)
 }
 
+/// Largest `page[limit]` the findings endpoint accepts.
+const FINDINGS_PAGE_MAX: usize = 1000;
+
+/// Search findings across every page, following `meta.page.cursor` (`--all`).
+pub async fn findings_search_all_pages(
+    cfg: &Config,
+    query: Option<String>,
+    max_items: usize,
+) -> Result<()> {
+    let limit = util::page_size(FINDINGS_PAGE_MAX, max_items);
+    let collected = util::collect_pages(
+        util::Paging::Cursor {
+            next: "/meta/page/cursor",
+        },
+        "/data",
+        max_items,
+        |page| {
+            let mut q: Vec<(&str, String)> = vec![("page[limit]", limit.to_string())];
+            if let Some(tags) = &query {
+                q.push(("filter[tags]", tags.clone()));
+            }
+            if let Some(cursor) = page.cursor {
+                q.push(("page[cursor]", cursor));
+            }
+            async move { crate::api::get(cfg, "/api/v2/posture_management/findings", &q).await }
+        },
+    )
+    .await?;
+    util::print_collected(cfg, "security findings search", collected)
+}
+
 // ---- Bulk Export ----
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -198,6 +229,135 @@ pub async fn rules_bulk_export(cfg: &Config, rule_ids: Vec<String>) -> Result<()
     Ok(())
 }
 
+// ---- Rule Management ----
+
+const RULES_PATH: &str = "/api/v2/security_monitoring/rules";
+
+/// Largest page the rules list endpoint returns.
+const RULES_PAGE_SIZE: usize = 100;
+
+pub async fn rules_create(cfg: &Config, body: &str) -> Result<()> {
+    let body: serde_json::Value = util::read_json_body(body)?;
+    let resp = crate::api::post(cfg, RULES_PATH, &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to create rule", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn rules_update(cfg: &Config, rule_id: &str, body: &str) -> Result<()> {
+    let body: serde_json::Value = util::read_json_body(body)?;
+    let resp = crate::api::put(cfg, &format!("{RULES_PATH}/{rule_id}"), &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to update rule", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn rules_delete(cfg: &Config, rule_id: &str) -> Result<()> {
+    crate::api::delete(cfg, &format!("{RULES_PATH}/{rule_id}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to delete rule", e))?;
+    println!("Rule '{rule_id}' deleted successfully.");
+    Ok(())
+}
+
+/// Whether a rule carries every tag in `tags` (exact `key:value` matches).
+pub fn rule_has_tags(rule: &serde_json::Value, tags: &[String]) -> bool {
+    let rule_tags: Vec<&str> = rule["tags"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|t| t.as_str())
+        .collect();
+    tags.iter().all(|t| rule_tags.contains(&t.as_str()))
+}
+
+/// Rules to toggle: the given IDs, or every rule carrying all of `tags`.
+pub async fn rules_matching(
+    cfg: &Config,
+    rule_ids: &[String],
+    tags: &[String],
+) -> Result<Vec<serde_json::Value>> {
+    if !rule_ids.is_empty() {
+        let mut rules = Vec::new();
+        for id in rule_ids {
+            let rule = crate::api::get(cfg, &format!("{RULES_PATH}/{id}"), &[])
+                .await
+                .map_err(|e| crate::api::failed(format!("failed to get rule {id}"), e))?;
+            rules.push(rule);
+        }
+        return Ok(rules);
+    }
+    if tags.is_empty() {
+        anyhow::bail!("pass rule IDs or --tags to select rules");
+    }
+    let collected = util::collect_pages(
+        util::Paging::Number {
+            size: RULES_PAGE_SIZE,
+        },
+        "/data",
+        0,
+        |page| async move {
+            let query = vec![
+                ("page[size]", RULES_PAGE_SIZE.to_string()),
+                ("page[number]", page.number.to_string()),
+            ];
+            crate::api::get(cfg, RULES_PATH, &query)
+                .await
+                .map_err(|e| crate::api::failed("failed to list rules", e))
+        },
+    )
+    .await?;
+    let rules: Vec<serde_json::Value> = collected
+        .items
+        .into_iter()
+        .filter(|r| rule_has_tags(r, tags))
+        .collect();
+    if rules.is_empty() {
+        anyhow::bail!("no security rules carry all of: {}", tags.join(", "));
+    }
+    Ok(rules)
+}
+
+/// Enable or disable `rules`, skipping those already in that state. Every
+/// rule gets a result row; the command fails if any update did.
+pub async fn rules_set_enabled(
+    cfg: &Config,
+    rules: Vec<serde_json::Value>,
+    enabled: bool,
+) -> Result<()> {
+    let total = rules.len();
+    let shared = std::sync::Arc::new(cfg.clone());
+    let rows = util::run_bounded(rules, 8, |rule| {
+        let cfg = shared.clone();
+        async move {
+            let id = rule["id"].as_str().unwrap_or_default().to_string();
+            let mut row = serde_json::json!({
+                "id": id,
+                "name": rule["name"],
+                "status": "updated",
+                "error": null,
+            });
+            if rule["isEnabled"].as_bool() == Some(enabled) {
+                row["status"] = "unchanged".into();
+                return row;
+            }
+            let body = serde_json::json!({ "isEnabled": enabled });
+            if let Err(e) = crate::api::put(&cfg, &format!("{RULES_PATH}/{id}"), &body).await {
+                row["status"] = "failed".into();
+                row["error"] = format!("{e:#}").into();
+            }
+            row
+        }
+    })
+    .await;
+    let failed = rows.iter().filter(|r| r["status"] == "failed").count();
+    formatter::output(cfg, &rows)?;
+    if failed > 0 {
+        anyhow::bail!("{failed} of {total} rules failed to update");
+    }
+    Ok(())
+}
+
 // ---- Content Packs ----
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -303,3 +463,23 @@ pub async fn risk_scores_list(cfg: &Config, query: Option<String>) -> Result<()>
     let data = crate::api::get(cfg, "/api/v2/entity_risk_scores", &q).await?;
     crate::formatter::output(cfg, &data)
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_rule_has_tags() {
+        let rule = serde_json::json!({"tags": ["team:sec", "source:cloudtrail"]});
+        assert!(rule_has_tags(&rule, &["team:sec".into()]));
+        assert!(rule_has_tags(
+            &rule,
+            &["source:cloudtrail".into(), "team:sec".into()]
+        ));
+        assert!(!rule_has_tags(
+            &rule,
+            &["team:sec".into(), "env:prod".into()]
+        ));
+        assert!(!rule_has_tags(&serde_json::json!({}), &["team:sec".into()]));
+    }
+}
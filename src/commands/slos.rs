@@ -1,7 +1,7 @@
 use anyhow::Result;
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV1::api_service_level_objectives::{
-    DeleteSLOOptionalParams, GetSLOOptionalParams, ListSLOsOptionalParams,
+    DeleteSLOOptionalParams, GetSLOOptionalParams, ListSLOsOptionalParams, SearchSLOOptionalParams,
     ServiceLevelObjectivesAPI,
 };
 #[cfg(not(target_arch = "wasm32"))]
@@ -9,8 +9,8 @@ use datadog_api_client::datadogV1::model::{ServiceLevelObjective, ServiceLevelOb
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
-use crate::config::Config;
-use crate::formatter;
+use crate::config::{Config, OutputFormat};
+use crate::formatter::{self, Metadata};
 use crate::util;
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -149,3 +149,977 @@ pub async fn status(cfg: &Config, id: &str, from_ts: i64, to_ts: i64) -> Result<
     let data = crate::api::get(cfg, &format!("/api/v2/slo/{id}/status"), &query).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---------------------------------------------------------------------------
+// Corrections
+// ---------------------------------------------------------------------------
+
+/// Inputs for `pup slos corrections create`. Times are Unix seconds.
+#[derive(Debug, Default)]
+pub struct CorrectionOptions {
+    pub slo_id: String,
+    pub start: i64,
+    pub end: Option<i64>,
+    /// Length of each occurrence in seconds; used instead of `end`, and with `rrule`.
+    pub duration: Option<i64>,
+    pub category: String,
+    pub description: Option<String>,
+    pub timezone: Option<String>,
+    /// iCalendar recurrence rule, e.g. `FREQ=WEEKLY;BYDAY=SU`.
+    pub rrule: Option<String>,
+}
+
+/// The API's correction category for `maintenance`, `outside-business-hours`, etc.
+pub fn correction_category(input: &str) -> Result<&'static str> {
+    let normalized = input.to_lowercase().replace(['-', '_'], " ");
+    Ok(match normalized.trim() {
+        "scheduled maintenance" | "maintenance" => "Scheduled Maintenance",
+        "outside business hours" => "Outside Business Hours",
+        "deployment" => "Deployment",
+        "other" => "Other",
+        _ => anyhow::bail!(
+            "invalid correction category {input:?} (expected scheduled-maintenance, \
+             outside-business-hours, deployment, or other)"
+        ),
+    })
+}
+
+/// Request body for `POST /api/v1/slo/correction`.
+pub fn correction_body(opts: &CorrectionOptions) -> Result<serde_json::Value> {
+    let mut attrs = serde_json::json!({
+        "slo_id": opts.slo_id,
+        "start": opts.start,
+        "category": correction_category(&opts.category)?,
+    });
+    match (opts.end, opts.duration) {
+        (Some(_), Some(_)) => anyhow::bail!("--end and --duration are mutually exclusive"),
+        (Some(end), None) if end <= opts.start => {
+            anyhow::bail!("--end must be after --start")
+        }
+        (Some(end), None) => attrs["end"] = end.into(),
+        (None, Some(duration)) => attrs["duration"] = duration.into(),
+        (None, None) => anyhow::bail!("one of --end or --duration is required"),
+    }
+    if opts.rrule.is_some() && opts.duration.is_none() {
+        anyhow::bail!("--rrule requires --duration");
+    }
+    for (key, value) in [
+        ("description", &opts.description),
+        ("timezone", &opts.timezone),
+        ("rrule", &opts.rrule),
+    ] {
+        if let Some(v) = value {
+            attrs[key] = v.as_str().into();
+        }
+    }
+    Ok(serde_json::json!({"data": {"type": "correction", "attributes": attrs}}))
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn corrections_list(cfg: &Config, slo_id: Option<&str>) -> Result<()> {
+    use datadog_api_client::datadogV1::api_service_level_objective_corrections::{
+        ListSLOCorrectionOptionalParams, ServiceLevelObjectiveCorrectionsAPI,
+    };
+
+    let dd_cfg = client::make_dd_config(cfg);
+    let bearer = client::make_bearer_client(cfg);
+    if let Some(slo_id) = slo_id {
+        let api = match bearer {
+            Some(c) => ServiceLevelObjectivesAPI::with_client_and_config(dd_cfg, c),
+            None => ServiceLevelObjectivesAPI::with_config(dd_cfg),
+        };
+        let resp = api
+            .get_slo_corrections(slo_id.to_string())
+            .await
+            .map_err(|e| anyhow::anyhow!("failed to list SLO corrections: {e:?}"))?;
+        return formatter::output(cfg, &resp);
+    }
+    let api = match bearer {
+        Some(c) => ServiceLevelObjectiveCorrectionsAPI::with_client_and_config(dd_cfg, c),
+        None => ServiceLevelObjectiveCorrectionsAPI::with_config(dd_cfg),
+    };
+    let resp = api
+        .list_slo_correction(ListSLOCorrectionOptionalParams::default())
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to list SLO corrections: {e:?}"))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn corrections_list(cfg: &Config, slo_id: Option<&str>) -> Result<()> {
+    let path = match slo_id {
+        Some(id) => format!("/api/v1/slo/{id}/corrections"),
+        None => "/api/v1/slo/correction".to_string(),
+    };
+    let data = crate::api::get(cfg, &path, &[]).await?;
+    crate::formatter::output(cfg, &data)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn corrections_create(cfg: &Config, opts: &CorrectionOptions) -> Result<()> {
+    use datadog_api_client::datadogV1::api_service_level_objective_corrections::ServiceLevelObjectiveCorrectionsAPI;
+    use datadog_api_client::datadogV1::model::SLOCorrectionCreateRequest;
+
+    let body: SLOCorrectionCreateRequest = serde_json::from_value(correction_body(opts)?)
+        .map_err(|e| anyhow::anyhow!("invalid SLO correction: {e}"))?;
+    let dd_cfg = client::make_dd_config(cfg);
+    let api = match client::make_bearer_client(cfg) {
+        Some(c) => ServiceLevelObjectiveCorrectionsAPI::with_client_and_config(dd_cfg, c),
+        None => ServiceLevelObjectiveCorrectionsAPI::with_config(dd_cfg),
+    };
+    let resp = api
+        .create_slo_correction(body)
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to create SLO correction: {e:?}"))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn corrections_create(cfg: &Config, opts: &CorrectionOptions) -> Result<()> {
+    let body = correction_body(opts)?;
+    let data = crate::api::post(cfg, "/api/v1/slo/correction", &body).await?;
+    crate::formatter::output(cfg, &data)
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn corrections_delete(cfg: &Config, correction_id: &str) -> Result<()> {
+    use datadog_api_client::datadogV1::api_service_level_objective_corrections::ServiceLevelObjectiveCorrectionsAPI;
+
+    let dd_cfg = client::make_dd_config(cfg);
+    let api = match client::make_bearer_client(cfg) {
+        Some(c) => ServiceLevelObjectiveCorrectionsAPI::with_client_and_config(dd_cfg, c),
+        None => ServiceLevelObjectiveCorrectionsAPI::with_config(dd_cfg),
+    };
+    api.delete_slo_correction(correction_id.to_string())
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to delete SLO correction: {e:?}"))?;
+    println!("Successfully deleted SLO correction {correction_id}");
+    Ok(())
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn corrections_delete(cfg: &Config, correction_id: &str) -> Result<()> {
+    crate::api::delete(cfg, &format!("/api/v1/slo/correction/{correction_id}")).await?;
+    println!("Successfully deleted SLO correction {correction_id}");
+    Ok(())
+}
+
+// ---------------------------------------------------------------------------
+// Search
+// ---------------------------------------------------------------------------
+
+/// Flatten a `/api/v1/slo/search` response into `{slos, facets, pagination}`.
+///
+/// Each search hit is wrapped as `{"data": {"id", "attributes": {...}}}`; the
+/// attributes are lifted to the top level alongside the ID.
+pub fn shape_search_response(resp: &serde_json::Value) -> serde_json::Value {
+    let attrs = resp.pointer("/data/attributes");
+    let slos: Vec<serde_json::Value> = attrs
+        .and_then(|a| a.get("slos"))
+        .and_then(|s| s.as_array())
+        .map(|hits| {
+            hits.iter()
+                .map(|hit| {
+                    let data = hit.get("data").unwrap_or(hit);
+                    let mut row = serde_json::Map::new();
+                    if let Some(id) = data.get("id") {
+                        row.insert("id".into(), id.clone());
+                    }
+                    if let Some(fields) = data.get("attributes").and_then(|a| a.as_object()) {
+                        row.extend(fields.clone());
+                    }
+                    serde_json::Value::Object(row)
+                })
+                .collect()
+        })
+        .unwrap_or_default();
+    serde_json::json!({
+        "slos": slos,
+        "facets": attrs.and_then(|a| a.get("facets")).cloned().unwrap_or_default(),
+        "pagination": resp.pointer("/meta/pagination").cloned().unwrap_or_default(),
+    })
+}
+
+/// One line per facet, e.g. `team: payments (12), checkout (4)`, largest buckets first.
+pub fn facet_summary(facets: &serde_json::Value) -> Vec<String> {
+    let Some(facets) = facets.as_object() else {
+        return vec![];
+    };
+    let mut lines = Vec::new();
+    for (facet, buckets) in facets {
+        let Some(buckets) = buckets.as_array() else {
+            continue;
+        };
+        if buckets.is_empty() {
+            continue;
+        }
+        let mut buckets: Vec<(String, i64)> = buckets
+            .iter()
+            .map(|b| {
+                let name = match &b["name"] {
+                    serde_json::Value::String(s) => s.clone(),
+                    other => other.to_string(),
+                };
+                (name, b["count"].as_i64().unwrap_or_default())
+            })
+            .collect();
+        buckets.sort_by(|a, b| b.1.cmp(&a.1).then_with(|| a.0.cmp(&b.0)));
+        let values: Vec<String> = buckets
+            .iter()
+            .map(|(name, count)| format!("{name} ({count})"))
+            .collect();
+        lines.push(format!("{facet}: {}", values.join(", ")));
+    }
+    lines
+}
+
+fn print_search(cfg: &Config, resp: &serde_json::Value) -> Result<()> {
+    let shaped = shape_search_response(resp);
+    let slos = shaped["slos"].as_array().cloned().unwrap_or_default();
+    if !cfg.agent_mode && cfg.output_format == OutputFormat::Table {
+        if slos.is_empty() {
+            eprintln!("No SLOs matched the search.");
+        } else {
+            formatter::output(cfg, &slos)?;
+        }
+        let lines = facet_summary(&shaped["facets"]);
+        if !lines.is_empty() {
+            eprintln!("\nFacets:");
+            for line in lines {
+                eprintln!("  {line}");
+            }
+        }
+        return Ok(());
+    }
+    let meta = Metadata {
+        count: Some(slos.len()),
+        truncated: false,
+        command: Some("slos search".to_string()),
+        next_action: None,
+    };
+    formatter::format_and_print(&shaped, cfg, Some(&meta))
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn search(cfg: &Config, query: Option<String>, page: i64, page_size: i64) -> Result<()> {
+    let dd_cfg = client::make_dd_config(cfg);
+    let api = match client::make_bearer_client(cfg) {
+        Some(c) => ServiceLevelObjectivesAPI::with_client_and_config(dd_cfg, c),
+        None => ServiceLevelObjectivesAPI::with_config(dd_cfg),
+    };
+    let mut params = SearchSLOOptionalParams::default()
+        .page_number(page)
+        .page_size(page_size)
+        .include_facets(true);
+    if let Some(q) = query {
+        params = params.query(q);
+    }
+    let resp = api
+        .search_slo(params)
+        .await
+        .map_err(|e| anyhow::anyhow!("failed to search SLOs: {e:?}"))?;
+    print_search(cfg, &serde_json::to_value(resp)?)
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn search(cfg: &Config, query: Option<String>, page: i64, page_size: i64) -> Result<()> {
+    let mut q = vec![
+        ("page[number]", page.to_string()),
+        ("page[size]", page_size.to_string()),
+        ("include_facets", "true".to_string()),
+    ];
+    if let Some(query) = query {
+        q.push(("query", query));
+    }
+    let data = crate::api::get(cfg, "/api/v1/slo/search", &q).await?;
+    print_search(cfg, &data)
+}
+
+// ---------------------------------------------------------------------------
+// Report
+// ---------------------------------------------------------------------------
+
+/// Largest page the SLO list endpoint returns.
+const SLO_PAGE_SIZE: usize = 1000;
+
+/// SLO histories fetched at once for `slos report`.
+const REPORT_CONCURRENCY: usize = 8;
+
+/// Remaining budget (percent) below which an SLO is reported as at risk.
+const AT_RISK_BUDGET_PCT: f64 = 25.0;
+
+/// Every SLO across all list pages, optionally narrowed to one tag server-side.
+pub async fn list_all(cfg: &Config, tags_query: Option<&str>) -> Result<Vec<serde_json::Value>> {
+    let collected = util::collect_pages(
+        util::Paging::Number {
+            size: SLO_PAGE_SIZE,
+        },
+        "/data",
+        0,
+        |page| {
+            let mut query = vec![
+                ("limit", SLO_PAGE_SIZE.to_string()),
+                ("offset", (page.number * SLO_PAGE_SIZE).to_string()),
+            ];
+            if let Some(tag) = tags_query {
+                query.push(("tags_query", tag.to_string()));
+            }
+            async move {
+                crate::api::get(cfg, "/api/v1/slo", &query)
+                    .await
+                    .map_err(|e| crate::api::failed("failed to list SLOs", e))
+            }
+        },
+    )
+    .await?;
+    Ok(collected.items)
+}
+
+/// Whether an SLO carries every one of `tags`.
+pub fn has_tags(slo: &serde_json::Value, tags: &[String]) -> bool {
+    let slo_tags: Vec<&str> = slo["tags"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|t| t.as_str())
+        .collect();
+    tags.iter().all(|t| slo_tags.contains(&t.as_str()))
+}
+
+/// The SLO's target for a report window: the threshold whose timeframe
+/// matches the window (`30d` for a 30-day report), else the first one.
+pub fn report_target(slo: &serde_json::Value, window_days: i64) -> Option<f64> {
+    let thresholds = slo["thresholds"].as_array()?;
+    let timeframe = format!("{window_days}d");
+    thresholds
+        .iter()
+        .find(|t| t["timeframe"].as_str() == Some(timeframe.as_str()))
+        .or_else(|| thresholds.first())
+        .and_then(|t| t["target"].as_f64())
+}
+
+/// Error-budget figures for an observed SLI against a target, both percent.
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub struct Budget {
+    /// Share of the window's error budget still unspent; negative once breached.
+    pub remaining_pct: f64,
+    /// How fast the budget was spent: 1.0 uses it up exactly over the window.
+    pub burn_rate: f64,
+}
+
+/// Budget math: the budget is `100 - target`, the spend is `100 - sli`.
+/// None for a 100% target, which has no budget to spend.
+pub fn error_budget(target: f64, sli: f64) -> Option<Budget> {
+    let allowed = 100.0 - target;
+    if allowed <= 0.0 {
+        return None;
+    }
+    let spent = (100.0 - sli).max(0.0);
+    Some(Budget {
+        remaining_pct: (allowed - spent) / allowed * 100.0,
+        burn_rate: spent / allowed,
+    })
+}
+
+fn round2(v: f64) -> f64 {
+    (v * 100.0).round() / 100.0
+}
+
+/// One report row from an SLO and its history response (or the error fetching it).
+pub fn report_row(
+    slo: &serde_json::Value,
+    history: Result<&serde_json::Value, String>,
+    window_days: i64,
+) -> serde_json::Value {
+    let target = report_target(slo, window_days);
+    let mut row = serde_json::json!({
+        "id": slo["id"],
+        "name": slo["name"],
+        "type": slo["type"],
+        "target": target,
+        "sli": null,
+        "budget_remaining_pct": null,
+        "burn_rate": null,
+        "status": "no data",
+    });
+    let history = match history {
+        Ok(h) => h,
+        Err(e) => {
+            row["status"] = format!("error: {e}").into();
+            return row;
+        }
+    };
+    let Some(sli) = history["data"]["overall"]["sli_value"].as_f64() else {
+        return row;
+    };
+    row["sli"] = round2(sli.min(100.0)).into();
+    let Some(budget) = target.and_then(|t| error_budget(t, sli)) else {
+        row["status"] = if target.is_some_and(|t| sli >= t) {
+            "ok"
+        } else {
+            "breached"
+        }
+        .into();
+        return row;
+    };
+    row["budget_remaining_pct"] = round2(budget.remaining_pct).into();
+    row["burn_rate"] = round2(budget.burn_rate).into();
+    row["status"] = if budget.remaining_pct < 0.0 {
+        "breached"
+    } else if budget.remaining_pct < AT_RISK_BUDGET_PCT {
+        "at risk"
+    } else {
+        "ok"
+    }
+    .into();
+    row
+}
+
+/// Worst first: least budget left at the top, rows without a budget last.
+pub fn sort_report(rows: &mut [serde_json::Value]) {
+    rows.sort_by(|a, b| {
+        let key = |r: &serde_json::Value| r["budget_remaining_pct"].as_f64().unwrap_or(f64::MAX);
+        key(a).total_cmp(&key(b))
+    });
+}
+
+/// Target, observed SLI, remaining error budget, and burn rate for every SLO
+/// carrying all of `tags`, over `from_ts..to_ts` (Unix seconds).
+pub async fn report(cfg: &Config, tags: &[String], from_ts: i64, to_ts: i64) -> Result<()> {
+    if to_ts <= from_ts {
+        anyhow::bail!("--from must be before --to");
+    }
+    let slos: Vec<serde_json::Value> = list_all(cfg, tags.first().map(String::as_str))
+        .await?
+        .into_iter()
+        .filter(|slo| has_tags(slo, tags))
+        .collect();
+    if slos.is_empty() {
+        eprintln!("No SLOs matched.");
+        return formatter::output(cfg, &Vec::<serde_json::Value>::new());
+    }
+    let window_days = ((to_ts - from_ts) as f64 / 86400.0).round() as i64;
+    let shared = std::sync::Arc::new(cfg.clone());
+    let histories = util::run_bounded(slos.clone(), REPORT_CONCURRENCY, |slo| {
+        let cfg = shared.clone();
+        async move {
+            let id = slo["id"].as_str().unwrap_or_default().to_string();
+            let query = [
+                ("from_ts", from_ts.to_string()),
+                ("to_ts", to_ts.to_string()),
+            ];
+            crate::api::get(&cfg, &format!("/api/v1/slo/{id}/history"), &query)
+                .await
+                .map_err(|e| format!("{e:#}"))
+        }
+    })
+    .await;
+    let mut rows: Vec<serde_json::Value> = slos
+        .iter()
+        .zip(&histories)
+        .map(|(slo, history)| report_row(slo, history.as_ref().map_err(Clone::clone), window_days))
+        .collect();
+    sort_report(&mut rows);
+    formatter::output(cfg, &rows)
+}
+
+// ---------------------------------------------------------------------------
+// Suggest
+// ---------------------------------------------------------------------------
+
+/// Monitor types the API accepts in monitor-based SLOs.
+const SLO_MONITOR_TYPES: &[&str] = &[
+    "metric alert",
+    "query alert",
+    "service check",
+    "synthetics alert",
+    "trace-analytics alert",
+];
+
+/// Monitor-based SLOs accept at most this many monitors.
+const SLO_MAX_MONITORS: usize = 20;
+
+/// Discovered trace operations probed for data when `--operation` isn't given.
+const SUGGEST_MAX_OPERATIONS: usize = 25;
+
+const SUGGEST_DESCRIPTION: &str =
+    "Suggested by 'pup slos suggest' — review the queries, thresholds, and target before creating.";
+
+/// Tag scope for the service, e.g. `service:api,env:prod`.
+pub fn service_scope(service: &str, env: Option<&str>) -> String {
+    match env {
+        Some(env) => format!("service:{service},env:{env}"),
+        None => format!("service:{service}"),
+    }
+}
+
+fn slo_tags(service: &str, env: Option<&str>) -> Vec<String> {
+    service_scope(service, env)
+        .split(',')
+        .map(String::from)
+        .collect()
+}
+
+/// Trace operation names that have a `trace.<operation>.hits` metric, deduplicated.
+pub fn trace_operations(metrics: &[String]) -> Vec<String> {
+    let mut ops: Vec<String> = Vec::new();
+    for op in metrics
+        .iter()
+        .filter_map(|m| m.strip_prefix("trace.")?.strip_suffix(".hits"))
+    {
+        if !op.is_empty() && !ops.iter().any(|o| o == op) {
+            ops.push(op.to_string());
+        }
+    }
+    ops
+}
+
+/// Mean of the non-null points across every series in a `/api/v1/query` response.
+pub fn series_mean(resp: &serde_json::Value) -> Option<f64> {
+    let points: Vec<f64> = resp["series"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .flat_map(|s| s["pointlist"].as_array().into_iter().flatten())
+        .filter_map(|p| p.get(1).and_then(|v| v.as_f64()))
+        .collect();
+    if points.is_empty() {
+        return None;
+    }
+    Some(points.iter().sum::<f64>() / points.len() as f64)
+}
+
+/// Latency threshold for an observed p95: 20% headroom, rounded up to two
+/// significant figures (0.25s -> 0.3s, 1.37s -> 1.7s).
+pub fn suggest_threshold(observed: f64) -> f64 {
+    if !observed.is_finite() || observed <= 0.0 {
+        return 1.0;
+    }
+    let padded = observed * 1.2;
+    let unit = 10f64.powf(padded.log10().floor() - 1.0);
+    let rounded = ((padded / unit) - 1e-9).ceil() * unit;
+    (rounded * 1e6).round() / 1e6
+}
+
+/// Availability from monitor uptime across the service's SLO-eligible monitors.
+pub fn monitor_slo(
+    service: &str,
+    env: Option<&str>,
+    monitors: &[serde_json::Value],
+    target: f64,
+) -> Option<serde_json::Value> {
+    let ids: Vec<i64> = monitors
+        .iter()
+        .filter(|m| {
+            m["type"]
+                .as_str()
+                .is_some_and(|t| SLO_MONITOR_TYPES.contains(&t))
+        })
+        .filter_map(|m| m["id"].as_i64())
+        .take(SLO_MAX_MONITORS)
+        .collect();
+    if ids.is_empty() {
+        return None;
+    }
+    Some(serde_json::json!({
+        "name": format!("{service} availability (monitor uptime)"),
+        "type": "monitor",
+        "description": SUGGEST_DESCRIPTION,
+        "monitor_ids": ids,
+        "tags": slo_tags(service, env),
+        "thresholds": [{"timeframe": "30d", "target": target}],
+    }))
+}
+
+/// Availability as the share of non-error requests for a trace operation.
+pub fn request_slo(
+    service: &str,
+    env: Option<&str>,
+    operation: &str,
+    target: f64,
+) -> serde_json::Value {
+    let scope = service_scope(service, env);
+    serde_json::json!({
+        "name": format!("{service} {operation} availability"),
+        "type": "metric",
+        "description": SUGGEST_DESCRIPTION,
+        "query": {
+            "numerator": format!(
+                "sum:trace.{operation}.hits{{{scope}}}.as_count() - sum:trace.{operation}.errors{{{scope}}}.as_count()"
+            ),
+            "denominator": format!("sum:trace.{operation}.hits{{{scope}}}.as_count()"),
+        },
+        "tags": slo_tags(service, env),
+        "thresholds": [{"timeframe": "30d", "target": target}],
+    })
+}
+
+/// p95 latency as a time-slice SLO: a 5-minute slice is good when p95 stays at
+/// or under `threshold` seconds.
+pub fn latency_slo(
+    service: &str,
+    env: Option<&str>,
+    operation: &str,
+    threshold: f64,
+    target: f64,
+) -> serde_json::Value {
+    let scope = service_scope(service, env);
+    serde_json::json!({
+        "name": format!("{service} {operation} p95 latency"),
+        "type": "time_slice",
+        "description": SUGGEST_DESCRIPTION,
+        "sli_specification": {
+            "time_slice": {
+                "comparator": "<=",
+                "threshold": threshold,
+                "query_interval_seconds": 300,
+                "query": {
+                    "formulas": [{"formula": "query1"}],
+                    "queries": [{
+                        "data_source": "metrics",
+                        "name": "query1",
+                        "query": format!("p95:trace.{operation}{{{scope}}}"),
+                    }],
+                },
+            },
+        },
+        "tags": slo_tags(service, env),
+        "thresholds": [{"timeframe": "30d", "target": target}],
+    })
+}
+
+/// Monitors tagged for the service (monitor tags or query scope), deduplicated by ID.
+async fn service_monitors(cfg: &Config, scope: &str) -> Result<Vec<serde_json::Value>> {
+    let mut monitors: Vec<serde_json::Value> = Vec::new();
+    for param in ["monitor_tags", "tags"] {
+        let resp = crate::api::get(cfg, "/api/v1/monitor", &[(param, scope.to_string())])
+            .await
+            .map_err(|e| crate::api::failed("failed to list monitors", e))?;
+        for m in resp.as_array().into_iter().flatten() {
+            if !monitors.iter().any(|o| o["id"] == m["id"]) {
+                monitors.push(m.clone());
+            }
+        }
+    }
+    Ok(monitors)
+}
+
+/// Propose SLO definitions for a service: availability from its monitors, and
+/// availability plus p95 latency for each trace operation with recent data.
+/// With `dir`, each suggestion is written as a file for `pup slos create --file`.
+pub async fn suggest(
+    cfg: &Config,
+    service: &str,
+    env: Option<&str>,
+    operations: Vec<String>,
+    target: f64,
+    dir: Option<&str>,
+) -> Result<()> {
+    if !(target > 0.0 && target < 100.0) {
+        anyhow::bail!("--target must be between 0 and 100 (exclusive), got {target}");
+    }
+    let scope = service_scope(service, env);
+    let mut suggestions: Vec<serde_json::Value> = Vec::new();
+    suggestions.extend(monitor_slo(
+        service,
+        env,
+        &service_monitors(cfg, &scope).await?,
+        target,
+    ));
+
+    let operations = if operations.is_empty() {
+        let found = crate::api::get(
+            cfg,
+            "/api/v1/search",
+            &[("q", "metrics:trace.".to_string())],
+        )
+        .await
+        .map_err(|e| crate::api::failed("failed to search trace metrics", e))?;
+        let names: Vec<String> = found
+            .pointer("/results/metrics")
+            .and_then(|m| m.as_array())
+            .into_iter()
+            .flatten()
+            .filter_map(|m| m.as_str().map(String::from))
+            .collect();
+        let mut ops = trace_operations(&names);
+        ops.truncate(SUGGEST_MAX_OPERATIONS);
+        ops
+    } else {
+        operations
+    };
+
+    let from = util::parse_time_to_unix("7d")?.to_string();
+    let to = util::parse_time_to_unix("now")?.to_string();
+    for op in &operations {
+        let query = format!("p95:trace.{op}{{{scope}}}");
+        let resp = crate::api::get(
+            cfg,
+            "/api/v1/query",
+            &[("from", from.clone()), ("to", to.clone()), ("query", query)],
+        )
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to query trace.{op} latency"), e))?;
+        // No points means the service doesn't emit this operation.
+        let Some(p95) = series_mean(&resp) else {
+            continue;
+        };
+        suggestions.push(request_slo(service, env, op, target));
+        suggestions.push(latency_slo(
+            service,
+            env,
+            op,
+            suggest_threshold(p95),
+            target,
+        ));
+    }
+
+    if suggestions.is_empty() {
+        eprintln!(
+            "No SLO candidates found for {scope}: no SLO-eligible monitors and no trace \
+             metrics with data in the last 7 days."
+        );
+    }
+    let Some(dir) = dir else {
+        return formatter::output(cfg, &suggestions);
+    };
+    std::fs::create_dir_all(dir)
+        .map_err(|e| anyhow::anyhow!("failed to create directory {dir:?}: {e}"))?;
+    let mut written = Vec::new();
+    for slo in &suggestions {
+        let name = slo["name"].as_str().unwrap_or_default();
+        let path = std::path::Path::new(dir).join(format!("{}.json", util::slug(name, "slo")));
+        std::fs::write(&path, format!("{}\n", serde_json::to_string_pretty(slo)?))
+            .map_err(|e| anyhow::anyhow!("failed to write {}: {e}", path.display()))?;
+        written.push(serde_json::json!({
+            "name": name,
+            "type": slo["type"],
+            "file": path.display().to_string(),
+        }));
+    }
+    formatter::output(cfg, &written)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn search_response() -> serde_json::Value {
+        serde_json::json!({
+            "data": {
+                "type": "search_slo",
+                "attributes": {
+                    "slos": [{
+                        "data": {
+                            "id": "abc123",
+                            "type": "slo",
+                            "attributes": {"name": "Checkout availability", "slo_type": "metric"}
+                        }
+                    }],
+                    "facets": {
+                        "team": [{"name": "checkout", "count": 4}, {"name": "payments", "count": 12}],
+                        "slo_type": [{"name": 1, "count": 7}],
+                        "env": []
+                    }
+                }
+            },
+            "meta": {"pagination": {"number": 0, "size": 25, "total": 1}}
+        })
+    }
+
+    #[test]
+    fn test_error_budget() {
+        let b = error_budget(99.9, 99.95).unwrap();
+        assert!((b.remaining_pct - 50.0).abs() < 1e-6, "{b:?}");
+        assert!((b.burn_rate - 0.5).abs() < 1e-6, "{b:?}");
+        let breached = error_budget(99.0, 97.0).unwrap();
+        assert!((breached.remaining_pct + 200.0).abs() < 1e-6);
+        assert!((breached.burn_rate - 3.0).abs() < 1e-6);
+        assert!(error_budget(100.0, 100.0).is_none());
+    }
+
+    #[test]
+    fn test_report_row() {
+        let slo = serde_json::json!({
+            "id": "abc",
+            "name": "Checkout availability",
+            "type": "monitor",
+            "tags": ["team:payments"],
+            "thresholds": [
+                {"timeframe": "7d", "target": 99.5},
+                {"timeframe": "30d", "target": 99.9}
+            ]
+        });
+        assert_eq!(report_target(&slo, 30), Some(99.9));
+        assert_eq!(report_target(&slo, 14), Some(99.5));
+        assert!(has_tags(&slo, &["team:payments".into()]));
+        assert!(!has_tags(
+            &slo,
+            &["team:payments".into(), "env:prod".into()]
+        ));
+
+        let history = serde_json::json!({"data": {"overall": {"sli_value": 99.88}}});
+        let row = report_row(&slo, Ok(&history), 30);
+        assert_eq!(row["target"], 99.9);
+        assert_eq!(row["sli"], 99.88);
+        assert_eq!(row["budget_remaining_pct"], -20.0);
+        assert_eq!(row["burn_rate"], 1.2);
+        assert_eq!(row["status"], "breached");
+
+        let row = report_row(&slo, Err("HTTP 403".into()), 30);
+        assert_eq!(row["status"], "error: HTTP 403");
+        let row = report_row(&slo, Ok(&serde_json::json!({"data": {}})), 30);
+        assert_eq!(row["status"], "no data");
+    }
+
+    #[test]
+    fn test_sort_report() {
+        let mut rows = vec![
+            serde_json::json!({"id": "a", "budget_remaining_pct": 80.0}),
+            serde_json::json!({"id": "b", "budget_remaining_pct": null}),
+            serde_json::json!({"id": "c", "budget_remaining_pct": -5.0}),
+        ];
+        sort_report(&mut rows);
+        let ids: Vec<&str> = rows.iter().map(|r| r["id"].as_str().unwrap()).collect();
+        assert_eq!(ids, ["c", "a", "b"]);
+    }
+
+    #[test]
+    fn test_shape_search_response() {
+        let shaped = shape_search_response(&search_response());
+        assert_eq!(shaped["slos"][0]["id"], "abc123");
+        assert_eq!(shaped["slos"][0]["name"], "Checkout availability");
+        assert_eq!(shaped["pagination"]["total"], 1);
+        assert_eq!(
+            shape_search_response(&serde_json::json!({}))["slos"],
+            serde_json::json!([])
+        );
+    }
+
+    #[test]
+    fn test_facet_summary() {
+        let shaped = shape_search_response(&search_response());
+        assert_eq!(
+            facet_summary(&shaped["facets"]),
+            vec!["team: payments (12), checkout (4)", "slo_type: 1 (7)"]
+        );
+        assert!(facet_summary(&serde_json::Value::Null).is_empty());
+    }
+
+    #[test]
+    fn test_trace_operations() {
+        let names: Vec<String> = [
+            "trace.http.request.hits",
+            "trace.http.request.hits.by_http_status",
+            "trace.http.request.errors",
+            "trace.rack.request.hits",
+            "trace.http.request.hits",
+            "system.cpu.user",
+        ]
+        .iter()
+        .map(|s| s.to_string())
+        .collect();
+        assert_eq!(
+            trace_operations(&names),
+            vec!["http.request", "rack.request"]
+        );
+    }
+
+    #[test]
+    fn test_series_mean() {
+        let resp = serde_json::json!({"series": [
+            {"pointlist": [[1, 0.2], [2, null], [3, 0.4]]},
+            {"pointlist": [[1, 0.6]]}
+        ]});
+        assert!((series_mean(&resp).unwrap() - 0.4).abs() < 1e-9);
+        assert_eq!(series_mean(&serde_json::json!({"series": []})), None);
+    }
+
+    #[test]
+    fn test_suggest_threshold() {
+        assert_eq!(suggest_threshold(0.25), 0.3);
+        assert_eq!(suggest_threshold(1.37), 1.7);
+        assert_eq!(suggest_threshold(0.0123), 0.015);
+        assert_eq!(suggest_threshold(0.0), 1.0);
+    }
+
+    #[test]
+    fn test_suggested_slo_bodies() {
+        let monitors = vec![
+            serde_json::json!({"id": 1, "type": "metric alert"}),
+            serde_json::json!({"id": 2, "type": "log alert"}),
+            serde_json::json!({"id": 3, "type": "service check"}),
+        ];
+        let slo = monitor_slo("api", Some("prod"), &monitors, 99.9).unwrap();
+        assert_eq!(slo["type"], "monitor");
+        assert_eq!(slo["monitor_ids"], serde_json::json!([1, 3]));
+        assert_eq!(slo["tags"], serde_json::json!(["service:api", "env:prod"]));
+        assert!(monitor_slo("api", None, &monitors[1..2], 99.9).is_none());
+
+        let slo = request_slo("api", None, "http.request", 99.5);
+        assert_eq!(
+            slo["query"]["denominator"],
+            "sum:trace.http.request.hits{service:api}.as_count()"
+        );
+        assert_eq!(slo["thresholds"][0]["target"], 99.5);
+
+        let slo = latency_slo("api", Some("prod"), "http.request", 0.3, 99.0);
+        let spec = &slo["sli_specification"]["time_slice"];
+        assert_eq!(spec["threshold"], 0.3);
+        assert_eq!(
+            spec["query"]["queries"][0]["query"],
+            "p95:trace.http.request{service:api,env:prod}"
+        );
+    }
+
+    #[test]
+    fn test_correction_category() {
+        assert_eq!(
+            correction_category("scheduled-maintenance").unwrap(),
+            "Scheduled Maintenance"
+        );
+        assert_eq!(
+            correction_category("Outside_Business_Hours").unwrap(),
+            "Outside Business Hours"
+        );
+        assert_eq!(correction_category("deployment").unwrap(), "Deployment");
+        assert!(correction_category("outage").is_err());
+    }
+
+    #[test]
+    fn test_correction_body() {
+        let mut opts = CorrectionOptions {
+            slo_id: "abc".into(),
+            start: 1_700_000_000,
+            end: Some(1_700_003_600),
+            category: "maintenance".into(),
+            description: Some("DB upgrade".into()),
+            ..Default::default()
+        };
+        assert_eq!(
+            correction_body(&opts).unwrap(),
+            serde_json::json!({"data": {"type": "correction", "attributes": {
+                "slo_id": "abc",
+                "start": 1_700_000_000,
+                "category": "Scheduled Maintenance",
+                "end": 1_700_003_600,
+                "description": "DB upgrade"
+            }}})
+        );
+
+        opts.duration = Some(3600);
+        assert!(correction_body(&opts).is_err());
+        opts.end = None;
+        opts.rrule = Some("FREQ=WEEKLY;BYDAY=SU".into());
+        opts.timezone = Some("UTC".into());
+        let body = correction_body(&opts).unwrap();
+        assert_eq!(body["data"]["attributes"]["duration"], 3600);
+        assert_eq!(body["data"]["attributes"]["rrule"], "FREQ=WEEKLY;BYDAY=SU");
+
+        opts.duration = None;
+        assert!(correction_body(&opts).is_err());
+        opts.rrule = None;
+        opts.end = Some(opts.start);
+        assert!(correction_body(&opts).is_err());
+    }
+}
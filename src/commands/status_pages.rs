@@ -620,7 +620,7 @@ fn format_third_party_table(providers: &[ThirdPartyProvider]) -> String {
 }
 
 pub async fn third_party_list(cfg: &Config, search: Option<&str>, active: bool) -> Result<()> {
-    let client = reqwest::Client::new();
+    let client = crate::api::http_client();
     let resp = client
         .get(THIRD_PARTY_OUTAGES_URL)
         .header("Accept", "application/json")
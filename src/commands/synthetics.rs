@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV1::api_synthetics::{
     ListTestsOptionalParams, SearchTestsOptionalParams, SyntheticsAPI,
@@ -16,7 +16,8 @@ use datadog_api_client::datadogV2::model::{
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
 use crate::config::Config;
-use crate::formatter;
+use crate::formatter::{self, Metadata};
+use crate::util;
 
 #[cfg(not(target_arch = "wasm32"))]
 pub async fn tests_list(cfg: &Config) -> Result<()> {
@@ -131,6 +132,241 @@ pub async fn locations_list(cfg: &Config) -> Result<()> {
     crate::formatter::output(cfg, &data)
 }
 
+// ---- Test definitions ----
+
+/// Fields the API sets; present in `tests get` output but rejected or ignored
+/// on create and update, so a fetched test can be edited and pushed back.
+const TEST_READ_ONLY_FIELDS: &[&str] = &[
+    "public_id",
+    "monitor_id",
+    "created_at",
+    "created_by",
+    "creator",
+    "modified_at",
+    "modified_by",
+    "deleted_at",
+    "overall_state",
+    "overall_state_modified",
+];
+
+/// Endpoint family for a test definition's `type`: api, browser, or mobile.
+pub fn test_kind(body: &serde_json::Value) -> Result<&'static str> {
+    match body["type"].as_str() {
+        Some("api") => Ok("api"),
+        Some("browser") => Ok("browser"),
+        Some("mobile") => Ok("mobile"),
+        Some(other) => bail!("unsupported test type {other:?}: expected api, browser, or mobile"),
+        None => bail!("test definition is missing \"type\" (api, browser, or mobile)"),
+    }
+}
+
+/// A create/update body from a local definition, with server-set fields removed.
+pub fn test_body(mut body: serde_json::Value) -> Result<(&'static str, serde_json::Value)> {
+    let Some(obj) = body.as_object_mut() else {
+        bail!("test definition must be a JSON object");
+    };
+    if obj.get("name").and_then(|n| n.as_str()).is_none() {
+        bail!("test definition is missing \"name\"");
+    }
+    for field in TEST_READ_ONLY_FIELDS {
+        obj.remove(*field);
+    }
+    let kind = test_kind(&body)?;
+    Ok((kind, body))
+}
+
+pub async fn tests_create(cfg: &Config, body: &str) -> Result<()> {
+    let (kind, body) = test_body(util::read_json_body(body)?)?;
+    let data = crate::api::post(cfg, &format!("/api/v1/synthetics/tests/{kind}"), &body)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to create {kind} test"), e))?;
+    formatter::output(cfg, &data)
+}
+
+pub async fn tests_update(cfg: &Config, public_id: &str, body: &str) -> Result<()> {
+    let (kind, body) = test_body(util::read_json_body(body)?)?;
+    let path = format!("/api/v1/synthetics/tests/{kind}/{public_id}");
+    let data = crate::api::put(cfg, &path, &body)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to update test {public_id}"), e))?;
+    formatter::output(cfg, &data)
+}
+
+pub async fn tests_delete(cfg: &Config, public_ids: &[String]) -> Result<()> {
+    let body = serde_json::json!({ "public_ids": public_ids });
+    let data = crate::api::post(cfg, "/api/v1/synthetics/tests/delete", &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to delete tests", e))?;
+    formatter::output(cfg, &data)
+}
+
+/// Pause (`paused`) or resume (`live`) a test.
+pub async fn tests_set_status(cfg: &Config, public_id: &str, status: &str) -> Result<()> {
+    let body = serde_json::json!({ "new_status": status });
+    crate::api::put(
+        cfg,
+        &format!("/api/v1/synthetics/tests/{public_id}/status"),
+        &body,
+    )
+    .await
+    .map_err(|e| crate::api::failed(format!("failed to set test {public_id} to {status}"), e))?;
+    if cfg.agent_mode {
+        return formatter::output(
+            cfg,
+            &serde_json::json!({ "public_id": public_id, "status": status }),
+        );
+    }
+    let verb = if status == "paused" {
+        "paused"
+    } else {
+        "resumed"
+    };
+    println!("Test {public_id} {verb}.");
+    Ok(())
+}
+
+// ---- CI trigger ----
+
+/// Seconds between batch status polls with `--wait`.
+#[cfg(not(target_arch = "wasm32"))]
+const BATCH_POLL_SECS: u64 = 5;
+
+pub fn trigger_body(public_ids: &[String]) -> serde_json::Value {
+    let tests: Vec<serde_json::Value> = public_ids
+        .iter()
+        .map(|id| serde_json::json!({ "public_id": id }))
+        .collect();
+    serde_json::json!({ "tests": tests })
+}
+
+/// Whether a `/api/v1/synthetics/ci/batch/{id}` response has finished running.
+pub fn batch_done(batch: &serde_json::Value) -> bool {
+    !matches!(batch["data"]["status"].as_str(), None | Some("in_progress"))
+}
+
+/// One row per result: test, location, status, and whether a failure blocks the batch.
+pub fn batch_rows(batch: &serde_json::Value) -> Vec<serde_json::Value> {
+    batch["data"]["results"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .map(|r| {
+            serde_json::json!({
+                "public_id": r["test_public_id"],
+                "name": r["test_name"],
+                "location": r["location"],
+                "status": r["status"],
+                "execution_rule": r["execution_rule"],
+                "duration_ms": r["duration"],
+                "timed_out": r["timed_out"].as_bool().unwrap_or(false),
+                "result_id": r["result_id"],
+            })
+        })
+        .collect()
+}
+
+/// Failed results that fail the batch; `non_blocking` failures are reported but tolerated.
+pub fn blocking_failures(rows: &[serde_json::Value]) -> usize {
+    rows.iter()
+        .filter(|r| r["status"] == "failed" && r["execution_rule"] != "non_blocking")
+        .count()
+}
+
+async fn fetch_batch(cfg: &Config, batch_id: &str) -> Result<serde_json::Value> {
+    crate::api::get(cfg, &format!("/api/v1/synthetics/ci/batch/{batch_id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to get batch {batch_id}"), e))
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn wait_for_batch(
+    cfg: &Config,
+    batch_id: &str,
+    timeout_secs: i64,
+) -> Result<serde_json::Value> {
+    let deadline = std::time::Instant::now() + std::time::Duration::from_secs(timeout_secs as u64);
+    let mut last_done = usize::MAX;
+    loop {
+        let batch = fetch_batch(cfg, batch_id).await?;
+        if batch_done(&batch) {
+            return Ok(batch);
+        }
+        let rows = batch_rows(&batch);
+        let done = rows.iter().filter(|r| r["status"] != "in_progress").count();
+        if done != last_done {
+            eprintln!(
+                "Batch {batch_id}: {done}/{} results finished...",
+                rows.len()
+            );
+            last_done = done;
+        }
+        if std::time::Instant::now() >= deadline {
+            bail!(
+                "timed out after {timeout_secs}s waiting for batch {batch_id} \
+                 ({done}/{} results finished)",
+                rows.len()
+            );
+        }
+        tokio::time::sleep(std::time::Duration::from_secs(BATCH_POLL_SECS)).await;
+    }
+}
+
+#[cfg(target_arch = "wasm32")]
+async fn wait_for_batch(
+    _cfg: &Config,
+    _batch_id: &str,
+    _timeout_secs: i64,
+) -> Result<serde_json::Value> {
+    bail!("--wait is not supported in WASM builds")
+}
+
+/// Trigger CI runs of the given tests. With `wait`, poll until the batch
+/// finishes and fail when any blocking result failed, so the command can gate
+/// a pipeline.
+pub async fn tests_trigger(
+    cfg: &Config,
+    public_ids: &[String],
+    wait: bool,
+    timeout: &str,
+) -> Result<()> {
+    let timeout_secs = util::parse_duration_secs(timeout)?;
+    let resp = crate::api::post(
+        cfg,
+        "/api/v1/synthetics/tests/trigger/ci",
+        &trigger_body(public_ids),
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to trigger tests", e))?;
+    if !wait {
+        return formatter::output(cfg, &resp);
+    }
+    let Some(batch_id) = resp["batch_id"].as_str() else {
+        bail!("trigger response has no batch_id to wait on: {resp}");
+    };
+    eprintln!("Triggered batch {batch_id}; waiting up to {timeout_secs}s for results...");
+    let batch = wait_for_batch(cfg, batch_id, timeout_secs).await?;
+    let rows = batch_rows(&batch);
+    let failed = blocking_failures(&rows);
+    let meta = Metadata {
+        count: Some(rows.len()),
+        truncated: false,
+        command: Some("synthetics tests trigger".to_string()),
+        next_action: None,
+    };
+    formatter::format_and_print(&rows, cfg, Some(&meta))?;
+    if failed > 0 {
+        bail!(
+            "{failed} of {} synthetics results failed (batch {batch_id})",
+            rows.len()
+        );
+    }
+    eprintln!(
+        "Batch {batch_id} {}.",
+        batch["data"]["status"].as_str().unwrap_or("finished")
+    );
+    Ok(())
+}
+
 // ---- Suites (V2 API) ----
 
 #[cfg(not(target_arch = "wasm32"))]
@@ -256,3 +492,54 @@ pub async fn suites_delete(cfg: &Config, suite_ids: Vec<String>) -> Result<()> {
     let data = crate::api::post(cfg, "/api/v2/synthetics/suites/delete", &body).await?;
     crate::formatter::output(cfg, &data)
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_trigger_body() {
+        let body = trigger_body(&["abc-def-ghi".into(), "jkl-mno-pqr".into()]);
+        assert_eq!(
+            body,
+            serde_json::json!({"tests": [{"public_id": "abc-def-ghi"}, {"public_id": "jkl-mno-pqr"}]})
+        );
+    }
+
+    #[test]
+    fn test_batch_rows_and_failures() {
+        let batch = serde_json::json!({"data": {"status": "failed", "results": [
+            {"test_public_id": "a", "status": "passed", "execution_rule": "blocking"},
+            {"test_public_id": "b", "status": "failed", "execution_rule": "non_blocking"},
+            {"test_public_id": "c", "status": "failed", "execution_rule": "blocking", "timed_out": true}
+        ]}});
+        assert!(batch_done(&batch));
+        let rows = batch_rows(&batch);
+        assert_eq!(rows.len(), 3);
+        assert_eq!(rows[2]["timed_out"], true);
+        assert_eq!(blocking_failures(&rows), 1);
+
+        assert!(!batch_done(
+            &serde_json::json!({"data": {"status": "in_progress"}})
+        ));
+        assert!(!batch_done(&serde_json::json!({})));
+    }
+
+    #[test]
+    fn test_test_body_strips_read_only_fields() {
+        let fetched = serde_json::json!({
+            "public_id": "abc-def-ghi", "monitor_id": 42, "created_at": "2026-01-01",
+            "creator": {"email": "a@b.c"}, "name": "Checkout", "type": "browser",
+            "status": "paused", "config": {}
+        });
+        let (kind, body) = test_body(fetched).unwrap();
+        assert_eq!(kind, "browser");
+        assert_eq!(
+            body,
+            serde_json::json!({"name": "Checkout", "type": "browser", "status": "paused", "config": {}})
+        );
+        assert!(test_body(serde_json::json!({"name": "x", "type": "multi"})).is_err());
+        assert!(test_body(serde_json::json!({"type": "api"})).is_err());
+        assert!(test_body(serde_json::json!([])).is_err());
+    }
+}
@@ -127,3 +127,256 @@ pub async fn delete(cfg: &Config, hostname: &str) -> Result<()> {
     println!("Successfully deleted all tags from host {hostname}");
     Ok(())
 }
+
+// ---------------------------------------------------------------------------
+// Bulk operations
+// ---------------------------------------------------------------------------
+
+const HOSTS_PAGE_SIZE: usize = 1000;
+
+/// Host tags written by bulk operations live under the user source, leaving
+/// tags from integrations and the Agent untouched.
+const USER_SOURCE: &str = "users";
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum BulkOp {
+    Add,
+    Remove,
+}
+
+/// Hostnames from a file: one per line, blank lines and `#` comments skipped,
+/// duplicates dropped.
+pub fn parse_hostnames(contents: &str) -> Vec<String> {
+    let mut hosts: Vec<String> = Vec::new();
+    for host in contents
+        .lines()
+        .map(str::trim)
+        .filter(|l| !l.is_empty() && !l.starts_with('#'))
+    {
+        if !hosts.iter().any(|h| h == host) {
+            hosts.push(host.to_string());
+        }
+    }
+    hosts
+}
+
+/// Tags left after removing `remove`. A bare key (`env`) removes every value
+/// of that key; `env:staging` removes only that tag.
+pub fn remaining_tags(current: &[String], remove: &[String]) -> Vec<String> {
+    current
+        .iter()
+        .filter(|tag| {
+            !remove.iter().any(|r| {
+                *tag == r || (!r.contains(':') && tag.split(':').next() == Some(r.as_str()))
+            })
+        })
+        .cloned()
+        .collect()
+}
+
+/// Names of every host matching a host-map filter, e.g. `env:staging`.
+async fn hosts_matching(cfg: &Config, filter: &str) -> Result<Vec<String>> {
+    let collected = crate::util::collect_pages(
+        crate::util::Paging::Number {
+            size: HOSTS_PAGE_SIZE,
+        },
+        "/host_list",
+        0,
+        |page| {
+            let query = vec![
+                ("filter", filter.to_string()),
+                ("count", HOSTS_PAGE_SIZE.to_string()),
+                ("start", (page.number * HOSTS_PAGE_SIZE).to_string()),
+            ];
+            async move {
+                crate::api::get(cfg, "/api/v1/hosts", &query)
+                    .await
+                    .map_err(|e| crate::api::failed("failed to list hosts", e))
+            }
+        },
+    )
+    .await?;
+    let mut hosts: Vec<String> = Vec::new();
+    for host in &collected.items {
+        if let Some(name) = host["host_name"].as_str().or(host["name"].as_str()) {
+            if !hosts.iter().any(|h| h == name) {
+                hosts.push(name.to_string());
+            }
+        }
+    }
+    Ok(hosts)
+}
+
+/// Apply one bulk mutation to one host and describe the outcome.
+async fn bulk_one(cfg: &Config, host: &str, op: BulkOp, tags: &[String]) -> serde_json::Value {
+    let path = format!("/api/v1/tags/hosts/{host}?source={USER_SOURCE}");
+    let result: Result<(&str, serde_json::Value)> = async {
+        match op {
+            BulkOp::Add => {
+                let body = serde_json::json!({ "host": host, "tags": tags });
+                let resp = crate::api::post(cfg, &path, &body)
+                    .await
+                    .map_err(|e| crate::api::failed("failed to add tags", e))?;
+                Ok(("updated", resp["tags"].clone()))
+            }
+            BulkOp::Remove => {
+                let current = crate::api::get(cfg, &path, &[])
+                    .await
+                    .map_err(|e| crate::api::failed("failed to get tags", e))?;
+                let current: Vec<String> =
+                    serde_json::from_value(current["tags"].clone()).unwrap_or_default();
+                let remaining = remaining_tags(&current, tags);
+                if remaining.len() == current.len() {
+                    return Ok(("unchanged", serde_json::json!(current)));
+                }
+                if remaining.is_empty() {
+                    crate::api::delete(cfg, &path)
+                        .await
+                        .map_err(|e| crate::api::failed("failed to delete tags", e))?;
+                } else {
+                    let body = serde_json::json!({ "host": host, "tags": remaining });
+                    crate::api::put(cfg, &path, &body)
+                        .await
+                        .map_err(|e| crate::api::failed("failed to update tags", e))?;
+                }
+                Ok(("updated", serde_json::json!(remaining)))
+            }
+        }
+    }
+    .await;
+    match result {
+        Ok((status, tags)) => serde_json::json!({"host": host, "status": status, "tags": tags}),
+        Err(e) => serde_json::json!({"host": host, "status": "failed", "error": format!("{e:#}")}),
+    }
+}
+
+fn progress_bar(done: usize, total: usize) -> String {
+    const WIDTH: usize = 30;
+    let filled = if total == 0 {
+        WIDTH
+    } else {
+        done * WIDTH / total
+    };
+    format!(
+        "[{}{}] {done}/{total} hosts",
+        "#".repeat(filled),
+        "-".repeat(WIDTH - filled)
+    )
+}
+
+/// Add or remove user tags on every host matching `filter` and/or listed in
+/// `file`, `concurrency` hosts at a time. Every host gets a result row; the
+/// command fails afterwards if any host did.
+pub async fn bulk(
+    cfg: &Config,
+    op: BulkOp,
+    tags: &[String],
+    filter: Option<&str>,
+    file: Option<&str>,
+    concurrency: usize,
+) -> Result<()> {
+    if tags.is_empty() {
+        anyhow::bail!("no tags given");
+    }
+    if filter.is_none() && file.is_none() {
+        anyhow::bail!("no hosts selected: pass --filter or --file");
+    }
+    let mut hosts = match file {
+        Some(path) => parse_hostnames(
+            &std::fs::read_to_string(path)
+                .map_err(|e| anyhow::anyhow!("failed to read {path:?}: {e}"))?,
+        ),
+        None => Vec::new(),
+    };
+    if let Some(filter) = filter {
+        for host in hosts_matching(cfg, filter).await? {
+            if !hosts.contains(&host) {
+                hosts.push(host);
+            }
+        }
+    }
+    if hosts.is_empty() {
+        eprintln!("No hosts matched.");
+        return Ok(());
+    }
+    if matches!(op, BulkOp::Remove) {
+        let confirmed = crate::confirm::Destructive::new("remove", "tags", tags.join(","))
+            .detail(format!("From {} host(s).", hosts.len()))
+            .confirm(cfg)?;
+        if !confirmed {
+            return Ok(());
+        }
+    }
+
+    let total = hosts.len();
+    let show_progress = {
+        use std::io::IsTerminal;
+        !cfg.agent_mode && std::io::stderr().is_terminal()
+    };
+    let done = std::sync::Arc::new(std::sync::atomic::AtomicUsize::new(0));
+    let shared = std::sync::Arc::new(cfg.clone());
+    let tags = std::sync::Arc::new(tags.to_vec());
+    let rows = crate::util::run_bounded(hosts, concurrency, |host| {
+        let (cfg, tags, done) = (shared.clone(), tags.clone(), done.clone());
+        async move {
+            let row = bulk_one(&cfg, &host, op, &tags).await;
+            let n = done.fetch_add(1, std::sync::atomic::Ordering::Relaxed) + 1;
+            if show_progress {
+                eprint!("\r{}", progress_bar(n, total));
+            }
+            row
+        }
+    })
+    .await;
+    if show_progress {
+        eprintln!();
+    }
+
+    let failed = rows.iter().filter(|r| r["status"] == "failed").count();
+    let updated = rows.iter().filter(|r| r["status"] == "updated").count();
+    eprintln!(
+        "{updated} updated, {} unchanged, {failed} failed",
+        total - updated - failed
+    );
+    formatter::output(cfg, &serde_json::Value::Array(rows))?;
+    if failed > 0 {
+        anyhow::bail!("{failed} of {total} hosts failed");
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_hostnames() {
+        let hosts = parse_hostnames("web-1\n# staging\n\n  web-2  \nweb-1\n");
+        assert_eq!(hosts, vec!["web-1", "web-2"]);
+    }
+
+    #[test]
+    fn test_remaining_tags() {
+        let current: Vec<String> = ["env:staging", "env:qa", "team:web", "canary"]
+            .map(String::from)
+            .to_vec();
+        assert_eq!(
+            remaining_tags(&current, &["env:qa".into()]),
+            vec!["env:staging", "team:web", "canary"]
+        );
+        assert_eq!(
+            remaining_tags(&current, &["env".into(), "canary".into()]),
+            vec!["team:web"]
+        );
+        assert_eq!(remaining_tags(&current, &["role:db".into()]), current);
+    }
+
+    #[test]
+    fn test_progress_bar() {
+        assert_eq!(
+            progress_bar(15, 30),
+            format!("[{}{}] 15/30 hosts", "#".repeat(15), "-".repeat(15))
+        );
+        assert!(progress_bar(3, 3).starts_with(&format!("[{}]", "#".repeat(30))));
+    }
+}
@@ -2,7 +2,7 @@ use anyhow::Result;
 
 use crate::config::Config;
 
-fn mask_key(key: &str) -> String {
+pub(crate) fn mask_key(key: &str) -> String {
     if key.len() <= 12 {
         return "*".repeat(key.len());
     }
@@ -11,7 +11,7 @@ use datadog_api_client::datadogV2::model::{
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
-use crate::config::Config;
+use crate::config::{Config, OutputFormat};
 use crate::formatter;
 use crate::util;
 
@@ -169,7 +169,7 @@ pub async fn search(
     } else {
         None
     };
-    formatter::format_and_print(&resp, &cfg.output_format, cfg.agent_mode, meta.as_ref())?;
+    formatter::format_and_print(&resp, cfg, meta.as_ref())?;
     Ok(())
 }
 
@@ -264,7 +264,7 @@ pub async fn aggregate(
     } else {
         None
     };
-    formatter::format_and_print(&resp, &cfg.output_format, cfg.agent_mode, meta.as_ref())?;
+    formatter::format_and_print(&resp, cfg, meta.as_ref())?;
     Ok(())
 }
 
@@ -309,6 +309,514 @@ pub async fn aggregate(
     crate::formatter::output(cfg, &data)
 }
 
+// ---------------------------------------------------------------------------
+// Trace-to-logs pivot
+// ---------------------------------------------------------------------------
+
+/// Spans fetched to find a trace's time window; larger traces are rare and the
+/// first page already bounds the window well.
+const TRACE_SPANS_LIMIT: i32 = 1000;
+const LOGS_PAGE_MAX: usize = 1000;
+
+/// The forms a trace id may be stored in: the 64-bit decimal id classic
+/// tracers inject into logs, and the zero-padded hex id OpenTelemetry uses.
+/// 128-bit hex ids also match on their low 64 bits.
+pub fn trace_id_variants(trace_id: &str) -> Result<Vec<String>> {
+    let id = trace_id.trim().to_lowercase();
+    let id = id.strip_prefix("0x").unwrap_or(&id);
+    let low: u64 = if !id.is_empty() && id.chars().all(|c| c.is_ascii_digit()) {
+        match id.parse() {
+            Ok(n) => n,
+            Err(_) => bail!("invalid trace id {trace_id:?}: decimal ids must fit in 64 bits"),
+        }
+    } else if !id.is_empty() && id.len() <= 32 && id.chars().all(|c| c.is_ascii_hexdigit()) {
+        let tail = &id[id.len().saturating_sub(16)..];
+        u64::from_str_radix(tail, 16)?
+    } else {
+        bail!("invalid trace id {trace_id:?}: expected a decimal or hex trace id");
+    };
+    let mut variants = vec![id.to_string(), low.to_string(), format!("{low:016x}")];
+    if id.len() > 16 {
+        variants.push(format!("{id:0>32}"));
+    }
+    let mut seen = std::collections::HashSet::new();
+    variants.retain(|v| seen.insert(v.clone()));
+    Ok(variants)
+}
+
+/// Where and when a trace ran, derived from its spans.
+#[derive(Debug, Clone, PartialEq, serde::Serialize)]
+pub struct TraceWindow {
+    pub start_ms: i64,
+    pub end_ms: i64,
+    pub services: Vec<String>,
+    pub env: Option<String>,
+    pub spans: usize,
+}
+
+fn timestamp_ms(value: &serde_json::Value) -> Option<i64> {
+    let ts = value.as_str()?;
+    Some(
+        chrono::DateTime::parse_from_rfc3339(ts)
+            .ok()?
+            .timestamp_millis(),
+    )
+}
+
+/// Span window from a `/api/v2/spans/events/search` response; None without spans.
+pub fn trace_window(resp: &serde_json::Value) -> Option<TraceWindow> {
+    let spans = resp["data"].as_array()?;
+    let mut window: Option<TraceWindow> = None;
+    for span in spans {
+        let attrs = &span["attributes"];
+        let Some(start) = timestamp_ms(&attrs["start_timestamp"]) else {
+            continue;
+        };
+        let end = timestamp_ms(&attrs["end_timestamp"]).unwrap_or(start);
+        let w = window.get_or_insert(TraceWindow {
+            start_ms: start,
+            end_ms: end,
+            services: vec![],
+            env: None,
+            spans: 0,
+        });
+        w.start_ms = w.start_ms.min(start);
+        w.end_ms = w.end_ms.max(end);
+        w.spans += 1;
+        if let Some(service) = attrs["service"].as_str() {
+            if !w.services.iter().any(|s| s == service) {
+                w.services.push(service.to_string());
+            }
+        }
+        if w.env.is_none() {
+            w.env = attrs["env"].as_str().map(String::from);
+        }
+    }
+    if let Some(w) = window.as_mut() {
+        w.services.sort();
+    }
+    window
+}
+
+fn query_values(values: &[String]) -> String {
+    let quoted: Vec<String> = values
+        .iter()
+        .map(|v| {
+            if v.chars()
+                .all(|c| c.is_ascii_alphanumeric() || "-_.".contains(c))
+            {
+                v.clone()
+            } else {
+                format!("\"{}\"", v.replace('"', "\\\""))
+            }
+        })
+        .collect();
+    match quoted.as_slice() {
+        [one] => one.clone(),
+        _ => format!("({})", quoted.join(" OR ")),
+    }
+}
+
+/// Logs query for a trace: correlated by `trace_id`, or with `by_service`, every
+/// log from the trace's services and env (for services that don't inject trace ids).
+pub fn correlated_logs_query(
+    variants: &[String],
+    window: &TraceWindow,
+    by_service: bool,
+    extra: Option<&str>,
+) -> String {
+    let mut parts = Vec::new();
+    if by_service {
+        if !window.services.is_empty() {
+            parts.push(format!("service:{}", query_values(&window.services)));
+        }
+        if let Some(env) = &window.env {
+            parts.push(format!("env:{}", query_values(std::slice::from_ref(env))));
+        }
+    } else {
+        parts.push(format!("trace_id:{}", query_values(variants)));
+    }
+    if let Some(extra) = extra.map(str::trim).filter(|q| !q.is_empty()) {
+        parts.push(format!("({extra})"));
+    }
+    if parts.is_empty() {
+        "*".to_string()
+    } else {
+        parts.join(" ")
+    }
+}
+
+pub struct TraceLogsOptions {
+    pub trace_id: String,
+    /// How far back to look for the trace's spans.
+    pub lookback: String,
+    /// Added before and after the span window for the logs search.
+    pub padding: String,
+    pub limit: usize,
+    pub by_service: bool,
+    pub query: Option<String>,
+}
+
+pub async fn logs(cfg: &Config, opts: TraceLogsOptions) -> Result<()> {
+    let variants = trace_id_variants(&opts.trace_id)?;
+    let padding_ms = util::parse_duration_secs(&opts.padding)? * 1000;
+    let from_ms = util::parse_time_to_unix_millis(&opts.lookback)?;
+
+    let span_body = serde_json::json!({
+        "data": {
+            "type": "search_request",
+            "attributes": {
+                "filter": {
+                    "query": format!("trace_id:{}", query_values(&variants)),
+                    "from": from_ms.to_string(),
+                    "to": "now"
+                },
+                "page": { "limit": TRACE_SPANS_LIMIT },
+                "sort": "timestamp"
+            }
+        }
+    });
+    let spans = crate::api::post(cfg, "/api/v2/spans/events/search", &span_body)
+        .await
+        .map_err(|e| {
+            crate::api::failed(
+                format!("failed to search spans for trace {}", opts.trace_id),
+                e,
+            )
+        })?;
+    let Some(window) = trace_window(&spans) else {
+        bail!(
+            "no spans found for trace {} in the last {} — widen --lookback if the trace is older",
+            opts.trace_id,
+            opts.lookback
+        );
+    };
+
+    let query = correlated_logs_query(&variants, &window, opts.by_service, opts.query.as_deref());
+    let logs_body = serde_json::json!({
+        "filter": {
+            "query": query,
+            "from": (window.start_ms - padding_ms).to_string(),
+            "to": (window.end_ms + padding_ms).to_string()
+        },
+        "page": { "limit": opts.limit.clamp(1, LOGS_PAGE_MAX) },
+        "sort": "timestamp"
+    });
+    let resp = crate::api::post(cfg, "/api/v2/logs/events/search", &logs_body)
+        .await
+        .map_err(|e| {
+            crate::api::failed(
+                format!("failed to search logs for trace {}", opts.trace_id),
+                e,
+            )
+        })?;
+    let items = resp["data"].as_array().cloned().unwrap_or_default();
+
+    if cfg.agent_mode || cfg.output_format != OutputFormat::Table {
+        let result = serde_json::json!({
+            "trace_id": opts.trace_id,
+            "window": window,
+            "query": query,
+            "logs": items,
+        });
+        return formatter::output(cfg, &result);
+    }
+
+    #[cfg(not(target_arch = "wasm32"))]
+    let color = {
+        use std::io::IsTerminal;
+        std::io::stdout().is_terminal() && std::env::var_os("NO_COLOR").is_none()
+    };
+    #[cfg(target_arch = "wasm32")]
+    let color = false;
+
+    eprintln!(
+        "Trace {}: {} spans across {} ({} ms); logs matching {query:?}",
+        opts.trace_id,
+        window.spans,
+        window.services.join(", "),
+        window.end_ms - window.start_ms,
+    );
+    let events: Vec<_> = items
+        .iter()
+        .filter_map(crate::commands::logs::tail_event)
+        .collect();
+    if events.is_empty() {
+        eprintln!("No correlated logs found.");
+    }
+    for event in &events {
+        println!("{}", crate::commands::logs::format_tail_event(event, color));
+    }
+    Ok(())
+}
+
+// ---------------------------------------------------------------------------
+// Span tree
+// ---------------------------------------------------------------------------
+
+/// Width of the timeline bar drawn next to each span in `--tree` output.
+const TREE_BAR_WIDTH: usize = 24;
+
+/// A span and its children, rebuilt from parent ids.
+#[derive(Debug, Clone, PartialEq, serde::Serialize)]
+pub struct SpanNode {
+    pub span_id: String,
+    pub service: String,
+    pub name: String,
+    pub resource: String,
+    /// Offset from the start of the earliest span in the trace.
+    pub start_offset_ms: f64,
+    pub duration_ms: f64,
+    pub error: bool,
+    pub children: Vec<SpanNode>,
+}
+
+fn timestamp_ns(value: &serde_json::Value) -> Option<i64> {
+    chrono::DateTime::parse_from_rfc3339(value.as_str()?)
+        .ok()?
+        .timestamp_nanos_opt()
+}
+
+fn id_string(value: &serde_json::Value) -> Option<String> {
+    match value {
+        serde_json::Value::String(s) if !s.is_empty() => Some(s.clone()),
+        serde_json::Value::Number(n) => Some(n.to_string()),
+        _ => None,
+    }
+}
+
+/// Rebuild the span hierarchy of one trace from a `/api/v2/spans/events/search`
+/// response. Spans whose parent is missing from the response (or `0`) become
+/// roots; siblings are ordered by start time.
+pub fn span_tree(resp: &serde_json::Value) -> Result<Vec<SpanNode>> {
+    struct Flat {
+        parent: Option<String>,
+        start_ns: i64,
+        node: SpanNode,
+    }
+
+    let mut traces = std::collections::BTreeSet::new();
+    let mut flat: Vec<Flat> = Vec::new();
+    for span in resp["data"].as_array().into_iter().flatten() {
+        let attrs = &span["attributes"];
+        let (Some(span_id), Some(start_ns)) = (
+            id_string(&attrs["span_id"]),
+            timestamp_ns(&attrs["start_timestamp"]),
+        ) else {
+            continue;
+        };
+        if let Some(trace_id) = id_string(&attrs["trace_id"]) {
+            traces.insert(trace_id);
+        }
+        let duration_ns = attrs["custom"]["duration"]
+            .as_f64()
+            .or_else(|| timestamp_ns(&attrs["end_timestamp"]).map(|end| (end - start_ns) as f64))
+            .unwrap_or(0.0);
+        let text = |v: &serde_json::Value| v.as_str().unwrap_or_default().to_string();
+        flat.push(Flat {
+            parent: id_string(&attrs["parent_id"]).filter(|p| p != "0"),
+            start_ns,
+            node: SpanNode {
+                span_id,
+                service: text(&attrs["service"]),
+                name: text(&attrs["custom"]["operation_name"]),
+                resource: text(&attrs["resource_name"]),
+                start_offset_ms: 0.0,
+                duration_ms: duration_ns / 1e6,
+                error: attrs["custom"]["error"]["type"].is_string()
+                    || attrs["status"].as_str() == Some("error"),
+                children: Vec::new(),
+            },
+        });
+    }
+    if traces.len() > 1 {
+        bail!(
+            "--tree renders a single trace but the query matched {} traces; \
+             add trace_id:<id> to --query",
+            traces.len()
+        );
+    }
+    let Some(trace_start) = flat.iter().map(|f| f.start_ns).min() else {
+        return Ok(Vec::new());
+    };
+    flat.sort_by_key(|f| f.start_ns);
+
+    let ids: std::collections::HashSet<String> =
+        flat.iter().map(|f| f.node.span_id.clone()).collect();
+    let mut children: std::collections::HashMap<String, Vec<SpanNode>> =
+        std::collections::HashMap::new();
+    let mut roots = Vec::new();
+    for f in flat {
+        let mut node = f.node;
+        node.start_offset_ms = (f.start_ns - trace_start) as f64 / 1e6;
+        match f.parent.filter(|p| ids.contains(p) && *p != node.span_id) {
+            Some(parent) => children.entry(parent).or_default().push(node),
+            None => roots.push(node),
+        }
+    }
+    for root in &mut roots {
+        attach_children(root, &mut children);
+    }
+    Ok(roots)
+}
+
+/// Move each span's children under it. Taking them out of the map as we go
+/// means a malformed parent cycle can't recurse forever.
+fn attach_children(
+    node: &mut SpanNode,
+    children: &mut std::collections::HashMap<String, Vec<SpanNode>>,
+) {
+    if let Some(mut kids) = children.remove(&node.span_id) {
+        for kid in &mut kids {
+            attach_children(kid, children);
+        }
+        node.children = kids;
+    }
+}
+
+fn format_duration_ms(ms: f64) -> String {
+    if ms >= 1000.0 {
+        format!("{:.2} s", ms / 1000.0)
+    } else if ms >= 10.0 {
+        format!("{ms:.1} ms")
+    } else {
+        format!("{ms:.2} ms")
+    }
+}
+
+/// Where a span sits on the trace timeline, drawn `TREE_BAR_WIDTH` wide.
+fn timeline_bar(offset_ms: f64, duration_ms: f64, total_ms: f64) -> String {
+    let scale = |ms: f64| {
+        if total_ms <= 0.0 {
+            0
+        } else {
+            ((ms / total_ms) * TREE_BAR_WIDTH as f64).round() as usize
+        }
+    };
+    let start = scale(offset_ms).min(TREE_BAR_WIDTH - 1);
+    let len = scale(duration_ms).clamp(1, TREE_BAR_WIDTH - start);
+    format!(
+        "|{}{}{}|",
+        " ".repeat(start),
+        "█".repeat(len),
+        " ".repeat(TREE_BAR_WIDTH - start - len)
+    )
+}
+
+fn trace_end_ms(nodes: &[SpanNode]) -> f64 {
+    nodes
+        .iter()
+        .map(|n| (n.start_offset_ms + n.duration_ms).max(trace_end_ms(&n.children)))
+        .fold(0.0, f64::max)
+}
+
+/// One line per span: timeline bar, duration, then the span indented under
+/// its parent. Errored spans are flagged (in red with `color`).
+pub fn render_tree(roots: &[SpanNode], color: bool) -> Vec<String> {
+    fn walk(
+        nodes: &[SpanNode],
+        indent: &str,
+        top: bool,
+        total_ms: f64,
+        color: bool,
+        out: &mut Vec<String>,
+    ) {
+        for (i, node) in nodes.iter().enumerate() {
+            let last = i + 1 == nodes.len();
+            let (branch, next) = match (top, last) {
+                (true, _) => ("", String::new()),
+                (false, false) => ("├─ ", format!("{indent}│  ")),
+                (false, true) => ("└─ ", format!("{indent}   ")),
+            };
+            let label = [node.service.as_str(), &node.name, &node.resource]
+                .iter()
+                .filter(|s| !s.is_empty())
+                .copied()
+                .collect::<Vec<_>>()
+                .join(" ");
+            let mut line = format!(
+                "{} {:>10}  {indent}{branch}{label}",
+                timeline_bar(node.start_offset_ms, node.duration_ms, total_ms),
+                format_duration_ms(node.duration_ms),
+            );
+            if node.error {
+                line = if color {
+                    format!("{ANSI_RED}{line} [error]{ANSI_RESET}")
+                } else {
+                    format!("{line} [error]")
+                };
+            }
+            out.push(line);
+            walk(&node.children, &next, false, total_ms, color, out);
+        }
+    }
+    let mut out = Vec::new();
+    walk(roots, "", true, trace_end_ms(roots), color, &mut out);
+    out
+}
+
+const ANSI_RED: &str = "\x1b[31m";
+const ANSI_RESET: &str = "\x1b[0m";
+
+/// Fetch the spans matching `query` (normally `trace_id:<id>`) and show them
+/// as a tree. Table output draws it; other formats get the nested spans.
+pub async fn tree(cfg: &Config, query: String, from: String, to: String) -> Result<()> {
+    let from_ms = util::parse_time_to_unix_millis(&from)?;
+    let to_ms = util::parse_time_to_unix_millis(&to)?;
+    let body = serde_json::json!({
+        "data": {
+            "type": "search_request",
+            "attributes": {
+                "filter": {
+                    "query": query,
+                    "from": from_ms.to_string(),
+                    "to": to_ms.to_string()
+                },
+                "page": { "limit": TRACE_SPANS_LIMIT },
+                "sort": "timestamp"
+            }
+        }
+    });
+    let resp = crate::api::post(cfg, "/api/v2/spans/events/search", &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to search spans", e))?;
+    let roots = span_tree(&resp)?;
+    if roots.is_empty() {
+        bail!("no spans matched {query:?} — widen --from if the trace is older");
+    }
+    let spans = resp["data"].as_array().map_or(0, Vec::len);
+
+    if cfg.agent_mode || cfg.output_format != OutputFormat::Table {
+        let result = serde_json::json!({
+            "query": query,
+            "spans": spans,
+            "duration_ms": trace_end_ms(&roots),
+            "tree": roots,
+        });
+        return formatter::output(cfg, &result);
+    }
+
+    #[cfg(not(target_arch = "wasm32"))]
+    let color = {
+        use std::io::IsTerminal;
+        std::io::stdout().is_terminal() && std::env::var_os("NO_COLOR").is_none()
+    };
+    #[cfg(target_arch = "wasm32")]
+    let color = false;
+
+    eprintln!(
+        "{spans} spans, {} total",
+        format_duration_ms(trace_end_ms(&roots))
+    );
+    if spans as i32 >= TRACE_SPANS_LIMIT {
+        eprintln!("Warning: stopped at {TRACE_SPANS_LIMIT} spans; the tree may be incomplete.");
+    }
+    for line in render_tree(&roots, color) {
+        println!("{line}");
+    }
+    Ok(())
+}
+
 #[cfg(all(test, not(target_arch = "wasm32")))]
 mod tests {
     use super::*;
@@ -427,4 +935,135 @@ mod tests {
         assert!(validate_sort("").is_err());
         assert!(validate_sort("asc").is_err());
     }
+
+    #[test]
+    fn test_trace_id_variants() {
+        assert_eq!(
+            trace_id_variants("1234567890").unwrap(),
+            vec!["1234567890", "00000000499602d2"]
+        );
+        // 128-bit OTel id: low 64 bits as decimal and hex.
+        assert_eq!(
+            trace_id_variants("0x6553F5B100000000000000000000002A").unwrap(),
+            vec!["6553f5b100000000000000000000002a", "42", "000000000000002a"]
+        );
+        assert!(trace_id_variants("not-a-trace").is_err());
+        assert!(trace_id_variants("99999999999999999999999").is_err());
+    }
+
+    #[test]
+    fn test_trace_window() {
+        let resp = serde_json::json!({"data": [
+            {"attributes": {"service": "web", "env": "prod",
+                "start_timestamp": "2026-01-01T00:00:01.000Z", "end_timestamp": "2026-01-01T00:00:03.500Z"}},
+            {"attributes": {"service": "db",
+                "start_timestamp": "2026-01-01T00:00:00.500Z", "end_timestamp": "2026-01-01T00:00:02.000Z"}},
+            {"attributes": {"service": "web"}}
+        ]});
+        let w = trace_window(&resp).unwrap();
+        assert_eq!(w.end_ms - w.start_ms, 3000);
+        assert_eq!(w.services, vec!["db", "web"]);
+        assert_eq!(w.env.as_deref(), Some("prod"));
+        assert_eq!(w.spans, 2);
+        assert!(trace_window(&serde_json::json!({"data": []})).is_none());
+    }
+
+    #[test]
+    fn test_correlated_logs_query() {
+        let w = TraceWindow {
+            start_ms: 0,
+            end_ms: 1,
+            services: vec!["db".into(), "web app".into()],
+            env: Some("prod".into()),
+            spans: 2,
+        };
+        let ids = vec!["42".to_string(), "000000000000002a".to_string()];
+        assert_eq!(
+            correlated_logs_query(&ids, &w, false, Some("status:error")),
+            "trace_id:(42 OR 000000000000002a) (status:error)"
+        );
+        assert_eq!(
+            correlated_logs_query(&ids, &w, true, None),
+            "service:(db OR \"web app\") env:prod"
+        );
+    }
+
+    fn span(id: &str, parent: &str, start: &str, end: &str, service: &str) -> serde_json::Value {
+        serde_json::json!({
+            "attributes": {
+                "trace_id": "abc",
+                "span_id": id,
+                "parent_id": parent,
+                "service": service,
+                "resource_name": format!("res-{id}"),
+                "start_timestamp": start,
+                "end_timestamp": end,
+                "custom": {"operation_name": "op"}
+            }
+        })
+    }
+
+    #[test]
+    fn test_span_tree_nests_by_parent_id() {
+        let resp = serde_json::json!({"data": [
+            span("3", "2", "2024-01-01T00:00:00.030Z", "2024-01-01T00:00:00.050Z", "db"),
+            span("1", "0", "2024-01-01T00:00:00.000Z", "2024-01-01T00:00:00.100Z", "web"),
+            span("2", "1", "2024-01-01T00:00:00.010Z", "2024-01-01T00:00:00.060Z", "api"),
+            span("4", "1", "2024-01-01T00:00:00.070Z", "2024-01-01T00:00:00.090Z", "cache"),
+        ]});
+        let roots = span_tree(&resp).unwrap();
+        assert_eq!(roots.len(), 1);
+        let root = &roots[0];
+        assert_eq!(root.span_id, "1");
+        assert_eq!(root.duration_ms, 100.0);
+        let kids: Vec<&str> = root.children.iter().map(|c| c.span_id.as_str()).collect();
+        assert_eq!(kids, vec!["2", "4"]);
+        assert_eq!(root.children[0].children[0].span_id, "3");
+        assert_eq!(root.children[0].children[0].start_offset_ms, 30.0);
+    }
+
+    #[test]
+    fn test_span_tree_orphans_become_roots() {
+        let resp = serde_json::json!({"data": [
+            span("1", "0", "2024-01-01T00:00:00.000Z", "2024-01-01T00:00:00.100Z", "web"),
+            span("9", "8", "2024-01-01T00:00:00.020Z", "2024-01-01T00:00:00.040Z", "worker"),
+        ]});
+        let roots = span_tree(&resp).unwrap();
+        assert_eq!(roots.len(), 2);
+        assert_eq!(roots[1].span_id, "9");
+    }
+
+    #[test]
+    fn test_span_tree_rejects_multiple_traces() {
+        let mut other = span(
+            "5",
+            "0",
+            "2024-01-01T00:00:00Z",
+            "2024-01-01T00:00:01Z",
+            "web",
+        );
+        other["attributes"]["trace_id"] = "def".into();
+        let resp = serde_json::json!({"data": [
+            span("1", "0", "2024-01-01T00:00:00Z", "2024-01-01T00:00:01Z", "web"),
+            other,
+        ]});
+        let err = span_tree(&resp).unwrap_err().to_string();
+        assert!(err.contains("matched 2 traces"), "{err}");
+    }
+
+    #[test]
+    fn test_render_tree() {
+        let resp = serde_json::json!({"data": [
+            span("1", "0", "2024-01-01T00:00:00.000Z", "2024-01-01T00:00:00.100Z", "web"),
+            span("2", "1", "2024-01-01T00:00:00.000Z", "2024-01-01T00:00:00.050Z", "api"),
+            span("3", "1", "2024-01-01T00:00:00.050Z", "2024-01-01T00:00:00.100Z", "db"),
+        ]});
+        let lines = render_tree(&span_tree(&resp).unwrap(), false);
+        assert_eq!(lines.len(), 3);
+        assert!(lines[0].ends_with("  web op res-1"), "{}", lines[0]);
+        assert!(lines[1].contains("├─ api op res-2"));
+        assert!(lines[2].contains("└─ db op res-3"));
+        assert!(lines[0].contains("100.0 ms"));
+        assert!(lines[2].starts_with(&format!("|{}{}|", " ".repeat(12), "█".repeat(12))));
+    }
 }
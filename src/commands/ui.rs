@@ -0,0 +1,619 @@
+use anyhow::Result;
+
+use crate::config::Config;
+
+/// Most items fetched per pane on each refresh.
+#[cfg(not(target_arch = "wasm32"))]
+const PANE_LIMIT: usize = 50;
+/// How far back the logs pane looks.
+#[cfg(not(target_arch = "wasm32"))]
+const LOGS_LOOKBACK_MS: i64 = 15 * 60 * 1000;
+
+const HELP: &str = "↑/↓ move  Enter detail  Esc back  Tab/1-3 switch pane  r refresh  q quit";
+
+/// Keyboard input, decoupled from the terminal backend.
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum Key {
+    Up,
+    Down,
+    PageUp,
+    PageDown,
+    Tab,
+    BackTab,
+    Enter,
+    Back,
+    Char(char),
+    Quit,
+}
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum Action {
+    Refresh,
+    Quit,
+}
+
+/// One selectable line in a pane and the JSON shown when drilling into it.
+#[derive(Debug, Clone, PartialEq)]
+pub struct Row {
+    pub label: String,
+    pub detail: serde_json::Value,
+}
+
+#[derive(Debug, Default)]
+pub struct Pane {
+    pub title: &'static str,
+    pub rows: Vec<Row>,
+    pub selected: usize,
+    pub error: Option<String>,
+}
+
+#[derive(Debug)]
+pub struct UiState {
+    pub scope: String,
+    pub panes: Vec<Pane>,
+    pub active: usize,
+    /// Detail view of the selected row and its scroll offset, when open.
+    pub detail: Option<usize>,
+    pub updated: Option<String>,
+    /// A refresh is in flight; input keeps working while it runs.
+    pub refreshing: bool,
+}
+
+impl UiState {
+    pub fn new(scope: &str) -> Self {
+        let pane = |title| Pane {
+            title,
+            ..Default::default()
+        };
+        UiState {
+            scope: scope.to_string(),
+            panes: vec![pane("Monitors"), pane("Incidents"), pane("Logs")],
+            active: 0,
+            detail: None,
+            updated: None,
+            refreshing: false,
+        }
+    }
+
+    fn pane(&mut self) -> &mut Pane {
+        &mut self.panes[self.active]
+    }
+
+    /// Replace a pane's rows, keeping the selection in range.
+    pub fn set_rows(&mut self, pane: usize, result: Result<Vec<Row>>) {
+        let p = &mut self.panes[pane];
+        match result {
+            Ok(rows) => {
+                p.rows = rows;
+                p.error = None;
+            }
+            Err(e) => p.error = Some(format!("{e:#}")),
+        }
+        p.selected = p.selected.min(p.rows.len().saturating_sub(1));
+    }
+
+    /// Apply one refresh's pane results, in pane order.
+    pub fn apply(&mut self, fetched: Fetched) {
+        for (pane, result) in fetched.into_iter().enumerate() {
+            self.set_rows(pane, result);
+        }
+        self.refreshing = false;
+        self.updated = Some(chrono::Local::now().format("%H:%M:%S").to_string());
+    }
+
+    pub fn handle(&mut self, key: Key) -> Option<Action> {
+        match key {
+            Key::Quit | Key::Char('q') => return Some(Action::Quit),
+            Key::Char('r') => return Some(Action::Refresh),
+            _ => {}
+        }
+        if let Some(scroll) = self.detail.as_mut() {
+            match key {
+                Key::Back | Key::Enter => self.detail = None,
+                Key::Up | Key::Char('k') => *scroll = scroll.saturating_sub(1),
+                Key::Down | Key::Char('j') => *scroll += 1,
+                Key::PageUp => *scroll = scroll.saturating_sub(10),
+                Key::PageDown => *scroll += 10,
+                _ => {}
+            }
+            return None;
+        }
+        let n = self.panes.len();
+        match key {
+            Key::Tab => self.active = (self.active + 1) % n,
+            Key::BackTab => self.active = (self.active + n - 1) % n,
+            Key::Char(c @ '1'..='9') => {
+                let i = c as usize - '1' as usize;
+                if i < n {
+                    self.active = i;
+                }
+            }
+            Key::Up | Key::Char('k') => {
+                let p = self.pane();
+                p.selected = p.selected.saturating_sub(1);
+            }
+            Key::Down | Key::Char('j') => {
+                let p = self.pane();
+                if p.selected + 1 < p.rows.len() {
+                    p.selected += 1;
+                }
+            }
+            Key::PageUp => {
+                let p = self.pane();
+                p.selected = p.selected.saturating_sub(10);
+            }
+            Key::PageDown => {
+                let p = self.pane();
+                p.selected = (p.selected + 10).min(p.rows.len().saturating_sub(1));
+            }
+            Key::Enter if !self.panes[self.active].rows.is_empty() => self.detail = Some(0),
+            _ => {}
+        }
+        None
+    }
+}
+
+/// A rendered screen line; `highlight` marks the selected row.
+#[derive(Debug, Clone, PartialEq)]
+pub struct Line {
+    pub text: String,
+    pub highlight: bool,
+}
+
+fn line(text: String) -> Line {
+    Line {
+        text,
+        highlight: false,
+    }
+}
+
+fn truncate(s: &str, width: usize) -> String {
+    s.chars().take(width).collect()
+}
+
+/// Lay out the whole screen as `height` lines of at most `width` characters.
+pub fn render(state: &UiState, width: usize, height: usize) -> Vec<Line> {
+    let mut tabs: Vec<String> = state
+        .panes
+        .iter()
+        .enumerate()
+        .map(|(i, p)| {
+            let label = format!("{} {} ({})", i + 1, p.title, p.rows.len());
+            if i == state.active {
+                format!("[{label}]")
+            } else {
+                format!(" {label} ")
+            }
+        })
+        .collect();
+    if !state.scope.is_empty() {
+        tabs.push(format!(" scope: {}", state.scope));
+    }
+    if state.refreshing {
+        tabs.push(" refreshing...".to_string());
+    } else if let Some(t) = &state.updated {
+        tabs.push(format!(" updated {t}"));
+    }
+    let mut lines = vec![
+        line(format!("pup ui  {}", tabs.join(" "))),
+        line(String::new()),
+    ];
+    let body_height = height.saturating_sub(lines.len() + 2);
+    let pane = &state.panes[state.active];
+
+    if let (Some(scroll), Some(detail)) = (state.detail, pane.rows.get(pane.selected)) {
+        lines.push(line(format!("── {} ──", detail.label)));
+        let json = serde_json::to_string_pretty(&detail.detail).unwrap_or_default();
+        let json_lines: Vec<&str> = json.lines().collect();
+        let start = scroll.min(json_lines.len().saturating_sub(1));
+        lines.extend(
+            json_lines
+                .iter()
+                .skip(start)
+                .take(body_height.saturating_sub(1))
+                .map(|l| line(l.to_string())),
+        );
+    } else if let Some(err) = &pane.error {
+        lines.push(line(format!("error: {err}")));
+    } else if pane.rows.is_empty() {
+        lines.push(line(format!("No {} in scope.", pane.title.to_lowercase())));
+    } else {
+        // Keep the selection on screen.
+        let start = pane.selected.saturating_sub(body_height.saturating_sub(1));
+        for (i, row) in pane.rows.iter().enumerate().skip(start).take(body_height) {
+            lines.push(Line {
+                text: row.label.clone(),
+                highlight: i == pane.selected,
+            });
+        }
+    }
+
+    lines.truncate(height.saturating_sub(1));
+    while lines.len() + 1 < height {
+        lines.push(line(String::new()));
+    }
+    lines.push(line(HELP.to_string()));
+    for l in &mut lines {
+        l.text = truncate(&l.text, width);
+    }
+    lines
+}
+
+fn text<'a>(v: &'a serde_json::Value, pointer: &str) -> &'a str {
+    v.pointer(pointer).and_then(|s| s.as_str()).unwrap_or("")
+}
+
+/// Monitors from a `/api/v1/monitor/search` response.
+pub fn monitor_rows(resp: &serde_json::Value) -> Vec<Row> {
+    resp["monitors"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .map(|m| Row {
+            label: format!(
+                "{:<8} {}  #{}",
+                text(m, "/status").to_uppercase(),
+                text(m, "/name"),
+                m["id"]
+            ),
+            detail: m.clone(),
+        })
+        .collect()
+}
+
+/// Unresolved incidents from a `/api/v2/incidents` response.
+pub fn incident_rows(resp: &serde_json::Value) -> Vec<Row> {
+    resp["data"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter(|i| !text(i, "/attributes/state").eq_ignore_ascii_case("resolved"))
+        .map(|i| Row {
+            label: format!(
+                "{:<6} {:<8} {}  #{}",
+                text(i, "/attributes/severity"),
+                text(i, "/attributes/state"),
+                text(i, "/attributes/title"),
+                i.pointer("/attributes/public_id")
+                    .cloned()
+                    .unwrap_or_default()
+            ),
+            detail: i.clone(),
+        })
+        .collect()
+}
+
+/// Log events from a `/api/v2/logs/events/search` response.
+pub fn log_rows(resp: &serde_json::Value) -> Vec<Row> {
+    resp["data"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(super::logs::tail_event)
+        .map(|e| Row {
+            label: super::logs::format_tail_event(&e, false),
+            detail: e.raw,
+        })
+        .collect()
+}
+
+/// Monitor search query for alerting monitors carrying every scope tag.
+pub fn monitor_query(scope: &str) -> String {
+    let mut q = "status:(alert OR warn OR \"no data\")".to_string();
+    for tag in scope.split_whitespace() {
+        q.push_str(&format!(" tag:\"{tag}\""));
+    }
+    q
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn fetch_monitors(cfg: &Config, scope: &str) -> Result<Vec<Row>> {
+    let query = vec![
+        ("query", monitor_query(scope)),
+        ("per_page", PANE_LIMIT.to_string()),
+    ];
+    let resp = crate::api::get(cfg, "/api/v1/monitor/search", &query).await?;
+    Ok(monitor_rows(&resp))
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn fetch_incidents(cfg: &Config) -> Result<Vec<Row>> {
+    let query = vec![("page[size]", PANE_LIMIT.to_string())];
+    let resp = crate::api::get(cfg, "/api/v2/incidents", &query).await?;
+    Ok(incident_rows(&resp))
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+async fn fetch_logs(cfg: &Config, scope: &str) -> Result<Vec<Row>> {
+    let now = chrono::Utc::now().timestamp_millis();
+    let body = serde_json::json!({
+        "filter": {
+            "query": if scope.is_empty() { "*" } else { scope },
+            "from": (now - LOGS_LOOKBACK_MS).to_string(),
+            "to": now.to_string(),
+        },
+        "page": { "limit": PANE_LIMIT },
+        "sort": "-timestamp"
+    });
+    let resp = crate::api::post(cfg, "/api/v2/logs/events/search", &body).await?;
+    Ok(log_rows(&resp))
+}
+
+/// Rows (or the error) for each pane from one refresh.
+pub type Fetched = [Result<Vec<Row>>; 3];
+
+/// Reload every pane concurrently; a failing pane shows its error instead of rows.
+#[cfg(not(target_arch = "wasm32"))]
+async fn fetch_all(cfg: &Config, scope: &str) -> Fetched {
+    let (monitors, incidents, logs) = tokio::join!(
+        fetch_monitors(cfg, scope),
+        fetch_incidents(cfg),
+        fetch_logs(cfg, scope)
+    );
+    [monitors, incidents, logs]
+}
+
+#[cfg(not(target_arch = "wasm32"))]
+mod term {
+    use std::io::Write;
+
+    use crossterm::event::{KeyCode, KeyEvent, KeyEventKind, KeyModifiers};
+    use crossterm::style::{Attribute, Print, SetAttribute};
+    use crossterm::{cursor, execute, queue, terminal};
+
+    use super::{Key, Line};
+
+    /// Raw mode on an alternate screen, restored on drop (including on error).
+    pub struct Guard;
+
+    impl Guard {
+        pub fn enter() -> anyhow::Result<Guard> {
+            terminal::enable_raw_mode()?;
+            execute!(
+                std::io::stdout(),
+                terminal::EnterAlternateScreen,
+                cursor::Hide
+            )?;
+            Ok(Guard)
+        }
+    }
+
+    impl Drop for Guard {
+        fn drop(&mut self) {
+            let _ = execute!(
+                std::io::stdout(),
+                cursor::Show,
+                terminal::LeaveAlternateScreen
+            );
+            let _ = terminal::disable_raw_mode();
+        }
+    }
+
+    pub fn size() -> (usize, usize) {
+        terminal::size()
+            .map(|(w, h)| (w as usize, h as usize))
+            .unwrap_or((80, 24))
+    }
+
+    pub fn draw(lines: &[Line]) -> anyhow::Result<()> {
+        let mut out = std::io::stdout();
+        queue!(out, terminal::Clear(terminal::ClearType::All))?;
+        for (i, l) in lines.iter().enumerate() {
+            queue!(out, cursor::MoveTo(0, i as u16))?;
+            if l.highlight {
+                queue!(
+                    out,
+                    SetAttribute(Attribute::Reverse),
+                    Print(&l.text),
+                    SetAttribute(Attribute::Reset)
+                )?;
+            } else {
+                queue!(out, Print(&l.text))?;
+            }
+        }
+        out.flush()?;
+        Ok(())
+    }
+
+    pub fn key(ev: KeyEvent) -> Option<Key> {
+        if ev.kind != KeyEventKind::Press {
+            return None;
+        }
+        if ev.modifiers.contains(KeyModifiers::CONTROL) && ev.code == KeyCode::Char('c') {
+            return Some(Key::Quit);
+        }
+        Some(match ev.code {
+            KeyCode::Up => Key::Up,
+            KeyCode::Down => Key::Down,
+            KeyCode::PageUp => Key::PageUp,
+            KeyCode::PageDown => Key::PageDown,
+            KeyCode::Tab => Key::Tab,
+            KeyCode::BackTab => Key::BackTab,
+            KeyCode::Enter | KeyCode::Right => Key::Enter,
+            KeyCode::Esc | KeyCode::Backspace | KeyCode::Left => Key::Back,
+            KeyCode::Char(c) => Key::Char(c),
+            _ => return None,
+        })
+    }
+}
+
+/// Interactive status view of monitors, incidents, and recent logs.
+///
+/// Keys are read on a separate thread and refreshes run as a pending future
+/// alongside them, so the view stays responsive while panes reload.
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn run(cfg: &Config, scope: &str, refresh_secs: u64) -> Result<()> {
+    use crossterm::event::{self, Event};
+    use std::future::Future;
+    use std::io::IsTerminal;
+    use std::pin::Pin;
+    use std::time::Duration;
+
+    if !std::io::stdout().is_terminal() || !std::io::stdin().is_terminal() {
+        anyhow::bail!("pup ui needs an interactive terminal");
+    }
+    let mut state = UiState::new(scope);
+    let _guard = term::Guard::enter()?;
+
+    let (tx, mut events) = tokio::sync::mpsc::unbounded_channel();
+    std::thread::spawn(move || {
+        while let Ok(ev) = event::read() {
+            if tx.send(ev).is_err() {
+                break;
+            }
+        }
+    });
+
+    let mut pending: Option<Pin<Box<dyn Future<Output = Fetched> + '_>>> =
+        Some(Box::pin(fetch_all(cfg, scope)));
+    state.refreshing = true;
+    let interval = Duration::from_secs(refresh_secs.max(1));
+    let mut ticker = tokio::time::interval_at(tokio::time::Instant::now() + interval, interval);
+    ticker.set_missed_tick_behavior(tokio::time::MissedTickBehavior::Delay);
+    loop {
+        let (width, height) = term::size();
+        term::draw(&render(&state, width, height))?;
+        let mut start_refresh = false;
+        tokio::select! {
+            ev = events.recv() => {
+                let Some(ev) = ev else { break };
+                let action = match ev {
+                    Event::Key(k) => term::key(k).and_then(|k| state.handle(k)),
+                    _ => None,
+                };
+                match action {
+                    Some(Action::Quit) => break,
+                    Some(Action::Refresh) => start_refresh = true,
+                    None => {}
+                }
+            }
+            fetched = async { pending.as_mut().expect("checked").await }, if pending.is_some() => {
+                pending = None;
+                state.apply(fetched);
+                ticker.reset();
+            }
+            _ = ticker.tick(), if pending.is_none() => start_refresh = true,
+        }
+        if start_refresh && pending.is_none() {
+            pending = Some(Box::pin(fetch_all(cfg, scope)));
+            state.refreshing = true;
+        }
+    }
+    Ok(())
+}
+
+#[cfg(target_arch = "wasm32")]
+pub async fn run(_cfg: &Config, _scope: &str, _refresh_secs: u64) -> Result<()> {
+    anyhow::bail!("pup ui is not supported in WASM builds")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn state_with_rows() -> UiState {
+        let mut state = UiState::new("env:prod");
+        let rows = (0..3)
+            .map(|i| Row {
+                label: format!("monitor {i}"),
+                detail: serde_json::json!({"id": i, "name": format!("monitor {i}")}),
+            })
+            .collect();
+        state.set_rows(0, Ok(rows));
+        state
+    }
+
+    #[test]
+    fn test_navigation_and_detail() {
+        let mut state = state_with_rows();
+        state.handle(Key::Down);
+        state.handle(Key::Down);
+        state.handle(Key::Down);
+        assert_eq!(state.panes[0].selected, 2);
+        state.handle(Key::Up);
+        assert_eq!(state.panes[0].selected, 1);
+
+        state.handle(Key::Enter);
+        assert_eq!(state.detail, Some(0));
+        state.handle(Key::Down);
+        assert_eq!(state.detail, Some(1));
+        state.handle(Key::Back);
+        assert_eq!(state.detail, None);
+
+        state.handle(Key::Tab);
+        assert_eq!(state.active, 1);
+        state.handle(Key::Enter);
+        assert_eq!(state.detail, None, "empty pane has nothing to open");
+        state.handle(Key::Char('3'));
+        assert_eq!(state.active, 2);
+        state.handle(Key::BackTab);
+        assert_eq!(state.active, 1);
+
+        assert_eq!(state.handle(Key::Char('r')), Some(Action::Refresh));
+        assert_eq!(state.handle(Key::Quit), Some(Action::Quit));
+    }
+
+    #[test]
+    fn test_render_list_and_detail() {
+        let mut state = state_with_rows();
+        state.handle(Key::Down);
+        let lines = render(&state, 80, 10);
+        assert_eq!(lines.len(), 10);
+        assert!(lines[0].text.contains("[1 Monitors (3)]"));
+        assert!(lines[0].text.contains("scope: env:prod"));
+        assert_eq!(lines[3].text, "monitor 1");
+        assert!(lines[3].highlight && !lines[2].highlight);
+        assert_eq!(lines[9].text, truncate(HELP, 80));
+
+        state.handle(Key::Enter);
+        let lines = render(&state, 80, 10);
+        assert_eq!(lines[2].text, "── monitor 1 ──");
+        assert_eq!(lines[3].text, "{");
+        assert!(lines[4].text.contains("\"id\": 1"));
+
+        state.handle(Key::Back);
+        state.set_rows(1, Err(anyhow::anyhow!("HTTP 403")));
+        state.handle(Key::Tab);
+        assert_eq!(render(&state, 80, 10)[2].text, "error: HTTP 403");
+        assert_eq!(render(&state, 12, 10)[0].text.chars().count(), 12);
+    }
+
+    #[test]
+    fn test_apply_refresh() {
+        let mut state = state_with_rows();
+        state.refreshing = true;
+        assert!(render(&state, 80, 10)[0].text.contains("refreshing..."));
+
+        let row = Row {
+            label: "DB down".into(),
+            detail: serde_json::json!({}),
+        };
+        state.apply([Err(anyhow::anyhow!("HTTP 500")), Ok(vec![row]), Ok(vec![])]);
+        assert!(!state.refreshing);
+        assert!(state.updated.is_some());
+        assert_eq!(state.panes[0].rows.len(), 3, "failed pane keeps its rows");
+        assert_eq!(state.panes[0].error.as_deref(), Some("HTTP 500"));
+        assert_eq!(state.panes[1].rows[0].label, "DB down");
+        assert!(render(&state, 80, 10)[0].text.contains("updated "));
+    }
+
+    #[test]
+    fn test_pane_rows() {
+        let monitors =
+            serde_json::json!({"monitors": [{"id": 5, "name": "CPU", "status": "Alert"}]});
+        assert_eq!(monitor_rows(&monitors)[0].label, "ALERT    CPU  #5");
+
+        let incidents = serde_json::json!({"data": [
+            {"id": "a", "attributes": {"title": "DB down", "severity": "SEV-1", "state": "active", "public_id": 42}},
+            {"id": "b", "attributes": {"title": "Old", "severity": "SEV-3", "state": "resolved", "public_id": 7}}
+        ]});
+        let rows = incident_rows(&incidents);
+        assert_eq!(rows.len(), 1);
+        assert_eq!(rows[0].label, "SEV-1  active   DB down  #42");
+
+        assert_eq!(
+            monitor_query("env:prod service:api"),
+            "status:(alert OR warn OR \"no data\") tag:\"env:prod\" tag:\"service:api\""
+        );
+    }
+}
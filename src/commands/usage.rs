@@ -8,7 +8,7 @@ use datadog_api_client::datadogV1::model::HourlyUsageAttributionUsageType;
 
 #[cfg(not(target_arch = "wasm32"))]
 use crate::client;
-use crate::config::Config;
+use crate::config::{Config, OutputFormat};
 use crate::formatter;
 use crate::util;
 
@@ -92,3 +92,296 @@ pub async fn hourly(cfg: &Config, start: String, end: Option<String>) -> Result<
     let data = crate::api::get(cfg, "/api/v1/usage/hourly-attribution", &query).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---- Trend report ----
+
+fn round2(x: f64) -> f64 {
+    (x * 100.0).round() / 100.0
+}
+
+/// Percent change from `prev` to `cur`; `None` when there is no baseline.
+fn change_pct(prev: f64, cur: f64) -> Option<f64> {
+    (prev > 0.0).then(|| round2((cur - prev) * 100.0 / prev))
+}
+
+/// UTC midnight on or before the given time: YYYY-MM-DD, or anything
+/// `parse_time_to_unix_millis` accepts.
+fn day_floor(input: &str) -> Result<chrono::NaiveDate> {
+    if let Ok(d) = chrono::NaiveDate::parse_from_str(input.trim(), "%Y-%m-%d") {
+        return Ok(d);
+    }
+    let ms = util::parse_time_to_unix_millis(input)?;
+    chrono::DateTime::from_timestamp_millis(ms)
+        .map(|t| t.date_naive())
+        .ok_or_else(|| anyhow::anyhow!("time out of range: {input:?}"))
+}
+
+/// Usage-type suffixes of point-in-time counts (hosts, containers, ...)
+/// rather than volumes; hours of these are not additive.
+const GAUGE_SUFFIXES: &[&str] = &[
+    "host_count",
+    "hosts",
+    "container_count",
+    "containers",
+    "device_count",
+    "devices",
+    "function_count",
+    "functions",
+    "instance_count",
+    "task_count",
+    "pod_count",
+    "node_count",
+];
+
+/// Whether hourly values of `usage_type` are gauges (a count at a point in
+/// time) rather than volumes such as ingested bytes or indexed events.
+pub fn is_gauge(usage_type: &str) -> bool {
+    GAUGE_SUFFIXES.iter().any(|s| usage_type.ends_with(s))
+}
+
+/// Roll hourly measurements up into per-day values keyed by (product family,
+/// usage type): volumes are summed, gauges take the day's peak hour, so 24
+/// hours of 10 hosts reads as 10 rather than 240.
+pub fn daily_totals(
+    items: &[serde_json::Value],
+) -> std::collections::BTreeMap<(String, String), std::collections::BTreeMap<String, f64>> {
+    let mut out: std::collections::BTreeMap<_, std::collections::BTreeMap<String, f64>> =
+        std::collections::BTreeMap::new();
+    for item in items {
+        let attrs = &item["attributes"];
+        let family = attrs["product_family"].as_str().unwrap_or("unknown");
+        let Some(day) = attrs["timestamp"].as_str().and_then(|t| t.get(..10)) else {
+            continue;
+        };
+        for m in attrs["measurements"].as_array().into_iter().flatten() {
+            let (Some(usage_type), Some(value)) = (m["usage_type"].as_str(), m["value"].as_f64())
+            else {
+                continue;
+            };
+            let total = out
+                .entry((family.to_string(), usage_type.to_string()))
+                .or_default()
+                .entry(day.to_string())
+                .or_default();
+            if is_gauge(usage_type) {
+                *total = total.max(value);
+            } else {
+                *total += value;
+            }
+        }
+    }
+    out
+}
+
+/// Trend rows per product: first/last day, overall change, and days whose
+/// day-over-day change exceeds `threshold_pct`, largest overall change first.
+pub fn trend_report(
+    items: &[serde_json::Value],
+    days: &[String],
+    threshold_pct: f64,
+) -> Vec<serde_json::Value> {
+    let mut rows = Vec::new();
+    for ((family, usage_type), totals) in daily_totals(items) {
+        let series: Vec<f64> = days
+            .iter()
+            .map(|d| totals.get(d).copied().unwrap_or(0.0))
+            .collect();
+        if series.iter().all(|v| *v == 0.0) {
+            continue;
+        }
+        let mut daily = Vec::with_capacity(days.len());
+        let mut anomalies = Vec::new();
+        let mut max_jump: Option<(f64, &str)> = None;
+        for (i, (day, total)) in days.iter().zip(&series).enumerate() {
+            let change = i.checked_sub(1).and_then(|p| change_pct(series[p], *total));
+            if let Some(c) = change {
+                if max_jump.is_none_or(|(m, _)| c.abs() > m.abs()) {
+                    max_jump = Some((c, day));
+                }
+                if c.abs() >= threshold_pct {
+                    anomalies.push(serde_json::json!({"date": day, "change_pct": c}));
+                }
+            }
+            daily.push(serde_json::json!({
+                "date": day,
+                "total": round2(*total),
+                "change_pct": change,
+            }));
+        }
+        let first = series[0];
+        let last = series[series.len() - 1];
+        rows.push(serde_json::json!({
+            "product_family": family,
+            "usage_type": usage_type,
+            "daily_value": if is_gauge(&usage_type) { "peak" } else { "sum" },
+            "first": round2(first),
+            "last": round2(last),
+            "change_pct": change_pct(first, last),
+            "avg_daily": round2(series.iter().sum::<f64>() / series.len() as f64),
+            "max_jump_pct": max_jump.map(|(c, _)| c),
+            "max_jump_date": max_jump.map(|(_, d)| d),
+            "anomalies": anomalies,
+            "daily": daily,
+        }));
+    }
+    let key = |r: &serde_json::Value| r["change_pct"].as_f64().map(f64::abs).unwrap_or(-1.0);
+    rows.sort_by(|a, b| key(b).total_cmp(&key(a)));
+    rows
+}
+
+/// Daily usage totals per product with percent changes over a date range.
+pub async fn report(
+    cfg: &Config,
+    from: String,
+    to: Option<String>,
+    product_families: &str,
+    threshold_pct: f64,
+) -> Result<()> {
+    let start = day_floor(&from)?;
+    // Default to UTC midnight today so a partial day doesn't read as a drop.
+    let end = match to.as_deref() {
+        Some(t) => day_floor(t)?,
+        None => chrono::Utc::now().date_naive(),
+    };
+    if end <= start {
+        anyhow::bail!("--to must be at least one day after --from");
+    }
+    let days: Vec<String> = start
+        .iter_days()
+        .take_while(|d| *d < end)
+        .map(|d| d.format("%Y-%m-%d").to_string())
+        .collect();
+
+    let collected = util::collect_pages(
+        util::Paging::Cursor {
+            next: "/meta/pagination/next_record_id",
+        },
+        "/data",
+        0,
+        |page| {
+            let mut query = vec![
+                ("filter[timestamp][start]", format!("{start}T00:00:00Z")),
+                ("filter[timestamp][end]", format!("{end}T00:00:00Z")),
+                ("filter[product_families]", product_families.to_string()),
+            ];
+            if let Some(id) = page.cursor {
+                query.push(("page[next_record_id]", id));
+            }
+            async move {
+                crate::api::get(cfg, "/api/v2/usage/hourly_usage", &query)
+                    .await
+                    .map_err(|e| crate::api::failed("failed to get hourly usage", e))
+            }
+        },
+    )
+    .await?;
+
+    let products = trend_report(&collected.items, &days, threshold_pct);
+    if !cfg.agent_mode && matches!(cfg.output_format, OutputFormat::Table | OutputFormat::Csv) {
+        let rows: Vec<serde_json::Value> = products
+            .iter()
+            .map(|p| {
+                let mut row = p.clone();
+                if let Some(obj) = row.as_object_mut() {
+                    obj.remove("daily");
+                    obj["anomalies"] = obj["anomalies"].as_array().map_or(0, |a| a.len()).into();
+                }
+                row
+            })
+            .collect();
+        formatter::output(cfg, &rows)?;
+        if cfg.output_format == OutputFormat::Table {
+            eprintln!(
+                "\n{} products from {start} to {end} (exclusive); anomalies are day-over-day changes of {threshold_pct}% or more.",
+                rows.len()
+            );
+        }
+        return Ok(());
+    }
+    formatter::output(
+        cfg,
+        &serde_json::json!({
+            "from": start.to_string(),
+            "to": end.to_string(),
+            "threshold_pct": threshold_pct,
+            "products": products,
+        }),
+    )
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn hour(family: &str, ts: &str, usage_type: &str, value: f64) -> serde_json::Value {
+        serde_json::json!({
+            "type": "usage_timeseries",
+            "attributes": {
+                "product_family": family,
+                "timestamp": ts,
+                "measurements": [{"usage_type": usage_type, "value": value}]
+            }
+        })
+    }
+
+    #[test]
+    fn test_daily_totals_sums_volumes_and_peaks_gauges() {
+        let items = vec![
+            hour("logs", "2026-03-01T00:00:00+00:00", "ingested_bytes", 10.0),
+            hour("logs", "2026-03-01T01:00:00+00:00", "ingested_bytes", 5.0),
+            hour("logs", "2026-03-02T00:00:00+00:00", "ingested_bytes", 30.0),
+            hour(
+                "infra_hosts",
+                "2026-03-01T00:00:00+00:00",
+                "infra_host_count",
+                4.0,
+            ),
+            hour(
+                "infra_hosts",
+                "2026-03-01T01:00:00+00:00",
+                "infra_host_count",
+                6.0,
+            ),
+        ];
+        let totals = daily_totals(&items);
+        let logs = &totals[&("logs".to_string(), "ingested_bytes".to_string())];
+        assert_eq!(logs["2026-03-01"], 15.0);
+        assert_eq!(logs["2026-03-02"], 30.0);
+        let hosts = &totals[&("infra_hosts".to_string(), "infra_host_count".to_string())];
+        assert_eq!(hosts["2026-03-01"], 6.0);
+        assert_eq!(totals.len(), 2);
+        assert!(is_gauge("apm_host_count"));
+        assert!(!is_gauge("indexed_events_count"));
+    }
+
+    #[test]
+    fn test_trend_report_changes_and_anomalies() {
+        let items = vec![
+            hour("logs", "2026-03-01T00:00:00Z", "ingested_bytes", 100.0),
+            hour("logs", "2026-03-02T00:00:00Z", "ingested_bytes", 110.0),
+            hour("logs", "2026-03-03T00:00:00Z", "ingested_bytes", 200.0),
+            hour("apm", "2026-03-01T00:00:00Z", "apm_host_count", 10.0),
+            hour("apm", "2026-03-02T00:00:00Z", "apm_host_count", 10.0),
+            hour("apm", "2026-03-03T00:00:00Z", "apm_host_count", 11.0),
+        ];
+        let days: Vec<String> = ["2026-03-01", "2026-03-02", "2026-03-03"]
+            .iter()
+            .map(|d| d.to_string())
+            .collect();
+        let rows = trend_report(&items, &days, 25.0);
+        assert_eq!(rows[0]["product_family"], "logs");
+        assert_eq!(rows[0]["change_pct"], 100.0);
+        assert_eq!(rows[0]["max_jump_pct"], 81.82);
+        assert_eq!(rows[0]["max_jump_date"], "2026-03-03");
+        assert_eq!(rows[0]["anomalies"].as_array().unwrap().len(), 1);
+        assert_eq!(rows[0]["daily"][0]["change_pct"], serde_json::Value::Null);
+        assert_eq!(rows[1]["change_pct"], 10.0);
+        assert!(rows[1]["anomalies"].as_array().unwrap().is_empty());
+    }
+
+    #[test]
+    fn test_change_pct_without_baseline() {
+        assert_eq!(change_pct(0.0, 5.0), None);
+        assert_eq!(change_pct(50.0, 25.0), Some(-50.0));
+    }
+}
@@ -1,4 +1,4 @@
-use anyhow::Result;
+use anyhow::{bail, Result};
 #[cfg(not(target_arch = "wasm32"))]
 use datadog_api_client::datadogV2::api_roles::{ListRolesOptionalParams, RolesAPI};
 #[cfg(not(target_arch = "wasm32"))]
@@ -68,3 +68,228 @@ pub async fn roles_list(cfg: &Config) -> Result<()> {
     let data = crate::api::get(cfg, "/api/v2/roles", &[]).await?;
     crate::formatter::output(cfg, &data)
 }
+
+// ---- Invite, disable, role assignment ----
+
+/// Emails from `--email` flags plus an optional batch file (one per line,
+/// blank lines and `#` comments skipped), deduplicated in order.
+pub fn invite_emails(emails: &[String], file_contents: Option<&str>) -> Result<Vec<String>> {
+    let from_file = file_contents
+        .into_iter()
+        .flat_map(str::lines)
+        .map(str::trim)
+        .filter(|l| !l.is_empty() && !l.starts_with('#'));
+    let mut out: Vec<String> = Vec::new();
+    for email in emails.iter().map(|e| e.trim()).chain(from_file) {
+        if !email.contains('@') || email.contains(char::is_whitespace) {
+            bail!("invalid email {email:?}");
+        }
+        if !out.iter().any(|e| e.eq_ignore_ascii_case(email)) {
+            out.push(email.to_string());
+        }
+    }
+    if out.is_empty() {
+        bail!("no emails given: pass --email or --file");
+    }
+    Ok(out)
+}
+
+/// ID of the role named (case-insensitively) or identified by `role`.
+pub fn pick_role(roles: &serde_json::Value, role: &str) -> Result<String> {
+    let items = roles["data"].as_array().cloned().unwrap_or_default();
+    let found = items.iter().find(|r| r["id"] == role).or_else(|| {
+        items.iter().find(|r| {
+            r["attributes"]["name"]
+                .as_str()
+                .is_some_and(|n| n.eq_ignore_ascii_case(role))
+        })
+    });
+    match found.and_then(|r| r["id"].as_str()) {
+        Some(id) => Ok(id.to_string()),
+        None => {
+            let names: Vec<&str> = items
+                .iter()
+                .filter_map(|r| r["attributes"]["name"].as_str())
+                .collect();
+            return Err(crate::exit_code::Failure::not_found(format!(
+                "no role named {role:?}; available: {}",
+                names.join(", ")
+            )));
+        }
+    }
+}
+
+async fn role_ids(cfg: &Config, roles: &[String]) -> Result<Vec<String>> {
+    if roles.is_empty() {
+        return Ok(Vec::new());
+    }
+    let resp = crate::api::get(cfg, "/api/v2/roles", &[("page[size]", "100".to_string())])
+        .await
+        .map_err(|e| crate::api::failed("failed to list roles", e))?;
+    roles.iter().map(|r| pick_role(&resp, r)).collect()
+}
+
+pub fn user_body(email: &str, role_ids: &[String]) -> serde_json::Value {
+    let roles: Vec<serde_json::Value> = role_ids
+        .iter()
+        .map(|id| serde_json::json!({"type": "roles", "id": id}))
+        .collect();
+    serde_json::json!({
+        "data": {
+            "type": "users",
+            "attributes": {"email": email},
+            "relationships": {"roles": {"data": roles}}
+        }
+    })
+}
+
+/// Create the user with its roles, then send the invitation email.
+async fn invite_one(cfg: &Config, email: &str, role_ids: &[String]) -> Result<String> {
+    let created = crate::api::post(cfg, "/api/v2/users", &user_body(email, role_ids))
+        .await
+        .map_err(|e| crate::api::failed("failed to create user", e))?;
+    let Some(user_id) = created["data"]["id"].as_str().map(str::to_string) else {
+        bail!("create user response has no ID");
+    };
+    let invitation = serde_json::json!({
+        "data": [{
+            "type": "user_invitations",
+            "relationships": {"user": {"data": {"type": "users", "id": user_id}}}
+        }]
+    });
+    crate::api::post(cfg, "/api/v2/user_invitations", &invitation)
+        .await
+        .map_err(|e| {
+            crate::api::failed(
+                format!("user {user_id} created but the invitation failed"),
+                e,
+            )
+        })?;
+    Ok(user_id)
+}
+
+/// Invite each email with the given roles. One failure doesn't stop the
+/// batch; every email gets a result row and the command fails afterwards if
+/// any did.
+pub async fn invite(
+    cfg: &Config,
+    emails: &[String],
+    file: Option<&str>,
+    roles: &[String],
+) -> Result<()> {
+    let contents = match file {
+        Some(path) => Some(
+            std::fs::read_to_string(path)
+                .map_err(|e| anyhow::anyhow!("failed to read {path:?}: {e}"))?,
+        ),
+        None => None,
+    };
+    let emails = invite_emails(emails, contents.as_deref())?;
+    let role_ids = role_ids(cfg, roles).await?;
+
+    let mut rows = Vec::with_capacity(emails.len());
+    let mut failed = 0;
+    for email in &emails {
+        rows.push(match invite_one(cfg, email, &role_ids).await {
+            Ok(user_id) => {
+                serde_json::json!({"email": email, "user_id": user_id, "status": "invited"})
+            }
+            Err(e) => {
+                failed += 1;
+                serde_json::json!({"email": email, "status": "failed", "error": format!("{e:#}")})
+            }
+        });
+    }
+    formatter::output(cfg, &serde_json::Value::Array(rows))?;
+    if failed > 0 {
+        bail!("{failed} of {} invitations failed", emails.len());
+    }
+    Ok(())
+}
+
+/// Disable a user. Datadog keeps disabled users (and what they own); they
+/// can no longer log in.
+pub async fn disable(cfg: &Config, user_id: &str) -> Result<()> {
+    crate::api::delete(cfg, &format!("/api/v2/users/{user_id}"))
+        .await
+        .map_err(|e| crate::api::failed("failed to disable user", e))?;
+    println!("Successfully disabled user {user_id}");
+    Ok(())
+}
+
+fn user_ref(user_id: &str) -> serde_json::Value {
+    serde_json::json!({"data": {"type": "users", "id": user_id}})
+}
+
+pub async fn roles_assign(cfg: &Config, role: &str, user_ids: &[String]) -> Result<()> {
+    let role_id = pick_role_id(cfg, role).await?;
+    for user_id in user_ids {
+        crate::api::post(
+            cfg,
+            &format!("/api/v2/roles/{role_id}/users"),
+            &user_ref(user_id),
+        )
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to add user {user_id} to role"), e))?;
+        println!("Added user {user_id} to role {role}");
+    }
+    Ok(())
+}
+
+pub async fn roles_remove(cfg: &Config, role: &str, user_ids: &[String]) -> Result<()> {
+    let role_id = pick_role_id(cfg, role).await?;
+    for user_id in user_ids {
+        crate::api::delete_with_body(
+            cfg,
+            &format!("/api/v2/roles/{role_id}/users"),
+            &user_ref(user_id),
+        )
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to remove user {user_id} from role"), e))?;
+        println!("Removed user {user_id} from role {role}");
+    }
+    Ok(())
+}
+
+async fn pick_role_id(cfg: &Config, role: &str) -> Result<String> {
+    Ok(role_ids(cfg, &[role.to_string()]).await?.remove(0))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_invite_emails_merges_flags_and_file() {
+        let flags = vec!["ana@example.com".to_string()];
+        let file = "# wave 3\nbo@example.com\n\n  ANA@example.com  \ncy@example.com\n";
+        assert_eq!(
+            invite_emails(&flags, Some(file)).unwrap(),
+            vec!["ana@example.com", "bo@example.com", "cy@example.com"]
+        );
+        assert!(invite_emails(&["not-an-email".into()], None).is_err());
+        assert!(invite_emails(&[], Some("# nobody\n")).is_err());
+    }
+
+    #[test]
+    fn test_pick_role_by_name_or_id() {
+        let roles = serde_json::json!({"data": [
+            {"id": "r-std", "attributes": {"name": "Datadog Standard Role"}},
+            {"id": "r-ro", "attributes": {"name": "Datadog Read Only Role"}}
+        ]});
+        assert_eq!(pick_role(&roles, "datadog standard role").unwrap(), "r-std");
+        assert_eq!(pick_role(&roles, "r-ro").unwrap(), "r-ro");
+        let err = pick_role(&roles, "Admin").unwrap_err().to_string();
+        assert!(err.contains("available: Datadog Standard Role, Datadog Read Only Role"));
+    }
+
+    #[test]
+    fn test_user_body() {
+        let body = user_body("ana@example.com", &["r-std".into()]);
+        assert_eq!(body["data"]["attributes"]["email"], "ana@example.com");
+        assert_eq!(
+            body["data"]["relationships"]["roles"]["data"][0]["id"],
+            "r-std"
+        );
+    }
+}
@@ -0,0 +1,171 @@
+//! Watchdog anomaly alerts. Datadog has no public Watchdog API; detections
+//! are published as events with `source:watchdog`, so these commands are a
+//! filtered, normalized view over event search.
+
+use anyhow::Result;
+
+use crate::config::Config;
+use crate::formatter;
+use crate::util;
+
+/// Largest page the events search endpoint returns.
+const EVENTS_PAGE_MAX: i32 = 1000;
+
+fn quote(value: &str) -> String {
+    if value
+        .chars()
+        .all(|c| c.is_ascii_alphanumeric() || "-_.:/".contains(c))
+    {
+        value.to_string()
+    } else {
+        format!("\"{}\"", value.replace('"', "\\\""))
+    }
+}
+
+/// Events query selecting Watchdog alerts, narrowed by service, resource, and env.
+pub fn alerts_query(
+    service: Option<&str>,
+    resource: Option<&str>,
+    env: Option<&str>,
+    extra: Option<&str>,
+) -> String {
+    let mut parts = vec!["source:watchdog".to_string()];
+    if let Some(service) = service {
+        parts.push(format!("service:{}", quote(service)));
+    }
+    if let Some(resource) = resource {
+        parts.push(format!("resource_name:{}", quote(resource)));
+    }
+    if let Some(env) = env {
+        parts.push(format!("env:{}", quote(env)));
+    }
+    if let Some(extra) = extra.map(str::trim).filter(|q| !q.is_empty()) {
+        parts.push(format!("({extra})"));
+    }
+    parts.join(" ")
+}
+
+fn tag_value<'a>(tags: &'a [serde_json::Value], key: &str) -> Option<&'a str> {
+    tags.iter()
+        .filter_map(|t| t.as_str())
+        .find_map(|t| t.strip_prefix(key)?.strip_prefix(':'))
+}
+
+/// Flatten a v2 event into the fields tooling usually wants from an alert.
+pub fn alert_row(event: &serde_json::Value) -> serde_json::Value {
+    let outer = &event["attributes"];
+    let inner = &outer["attributes"];
+    let tags = outer["tags"].as_array().map(Vec::as_slice).unwrap_or(&[]);
+    let field = |key: &str| {
+        inner[key]
+            .as_str()
+            .or_else(|| tag_value(tags, key))
+            .map(String::from)
+    };
+    serde_json::json!({
+        "id": event["id"],
+        "timestamp": outer["timestamp"],
+        "title": inner["title"].as_str().or(outer["title"].as_str()),
+        "service": field("service"),
+        "resource": inner["resource_name"]
+            .as_str()
+            .or_else(|| tag_value(tags, "resource_name")),
+        "env": field("env"),
+        "status": inner["status"].as_str().or(inner["alert_type"].as_str()),
+        "priority": inner["priority"],
+        "message": outer["message"].as_str().or(inner["text"].as_str()),
+    })
+}
+
+pub struct AlertsListOptions {
+    pub from: String,
+    pub to: String,
+    pub service: Option<String>,
+    pub resource: Option<String>,
+    pub env: Option<String>,
+    pub query: Option<String>,
+    pub limit: i32,
+}
+
+pub async fn alerts_list(cfg: &Config, opts: AlertsListOptions) -> Result<()> {
+    let from_ms = util::parse_time_to_unix_millis(&opts.from)?;
+    let to_ms = util::parse_time_to_unix_millis(&opts.to)?;
+    let rfc3339 = |ms: i64| {
+        chrono::DateTime::from_timestamp_millis(ms)
+            .map(|t| t.to_rfc3339())
+            .unwrap_or_default()
+    };
+    let query = alerts_query(
+        opts.service.as_deref(),
+        opts.resource.as_deref(),
+        opts.env.as_deref(),
+        opts.query.as_deref(),
+    );
+    let body = serde_json::json!({
+        "filter": {
+            "query": query,
+            "from": rfc3339(from_ms),
+            "to": rfc3339(to_ms)
+        },
+        "page": { "limit": opts.limit.clamp(1, EVENTS_PAGE_MAX) },
+        "sort": "-timestamp"
+    });
+    let resp = crate::api::post(cfg, "/api/v2/events/search", &body)
+        .await
+        .map_err(|e| crate::api::failed("failed to search watchdog alerts", e))?;
+    let rows: Vec<serde_json::Value> = resp["data"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .map(alert_row)
+        .collect();
+    formatter::output(cfg, &rows)
+}
+
+pub async fn alerts_get(cfg: &Config, alert_id: &str) -> Result<()> {
+    let resp = crate::api::get(cfg, &format!("/api/v2/events/{alert_id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get watchdog alert", e))?;
+    formatter::output(cfg, &resp)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_alerts_query() {
+        assert_eq!(alerts_query(None, None, None, None), "source:watchdog");
+        assert_eq!(
+            alerts_query(
+                Some("api"),
+                Some("GET /users"),
+                Some("prod"),
+                Some("status:error")
+            ),
+            "source:watchdog service:api resource_name:\"GET /users\" env:prod (status:error)"
+        );
+    }
+
+    #[test]
+    fn test_alert_row_reads_attributes_and_tags() {
+        let event = serde_json::json!({
+            "id": "AAA",
+            "type": "event",
+            "attributes": {
+                "timestamp": "2024-05-01T10:00:00Z",
+                "message": "Latency increased on GET /users",
+                "tags": ["service:api", "env:prod", "resource_name:GET /users"],
+                "attributes": {"title": "Watchdog: latency anomaly", "status": "warning"}
+            }
+        });
+        let row = alert_row(&event);
+        assert_eq!(row["id"], "AAA");
+        assert_eq!(row["title"], "Watchdog: latency anomaly");
+        assert_eq!(row["service"], "api");
+        assert_eq!(row["resource"], "GET /users");
+        assert_eq!(row["env"], "prod");
+        assert_eq!(row["status"], "warning");
+        assert_eq!(row["message"], "Latency increased on GET /users");
+    }
+}
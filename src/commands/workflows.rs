@@ -0,0 +1,232 @@
+//! Workflow Automation: find workflows, trigger runs, and follow or cancel
+//! their instances. Triggering only works for workflows with an API trigger.
+
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+use crate::formatter;
+
+/// Largest page the workflow list endpoints return.
+const PAGE_MAX: i64 = 100;
+
+/// Flatten a workflow into the fields a list needs.
+pub fn workflow_row(workflow: &serde_json::Value) -> serde_json::Value {
+    let attrs = &workflow["attributes"];
+    let triggers: Vec<String> = attrs["spec"]["triggers"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .filter_map(|t| {
+            t.as_object()?
+                .keys()
+                .find(|k| k.ends_with("Trigger"))
+                .cloned()
+        })
+        .collect();
+    serde_json::json!({
+        "id": workflow["id"],
+        "name": attrs["name"],
+        "published": attrs["published"].as_bool().unwrap_or(false),
+        "triggers": triggers,
+        "tags": attrs["tags"],
+        "updated_at": attrs["updatedAt"].as_str().or(attrs["updated_at"].as_str()),
+    })
+}
+
+/// Flatten a workflow instance into id, status, and timing.
+pub fn instance_row(instance: &serde_json::Value) -> serde_json::Value {
+    let attrs = &instance["attributes"];
+    serde_json::json!({
+        "id": instance["id"],
+        "status": attrs["instanceStatus"]["detailedStatus"]
+            .as_str()
+            .or(attrs["instanceStatus"]["status"].as_str())
+            .or(attrs["status"].as_str()),
+        "started_at": attrs["startedAt"].as_str().or(attrs["created_at"].as_str()),
+        "completed_at": attrs["completedAt"].as_str(),
+    })
+}
+
+/// Build the trigger payload from a JSON object file and `key=value` inputs.
+/// Input values are parsed as JSON when they can be (numbers, booleans,
+/// arrays), otherwise kept as strings; inputs override the file.
+pub fn build_payload(
+    base: Option<serde_json::Value>,
+    inputs: &[String],
+) -> Result<serde_json::Value> {
+    let mut payload = match base {
+        None => serde_json::Map::new(),
+        Some(serde_json::Value::Object(map)) => map,
+        Some(_) => bail!("invalid --payload: expected a JSON object of workflow inputs"),
+    };
+    for input in inputs {
+        let (key, value) = match input.split_once('=') {
+            Some((k, v)) if !k.trim().is_empty() => (k.trim(), v),
+            _ => bail!("invalid --input {input:?}: expected key=value"),
+        };
+        let value = serde_json::from_str(value)
+            .unwrap_or_else(|_| serde_json::Value::String(value.to_string()));
+        payload.insert(key.to_string(), value);
+    }
+    Ok(serde_json::Value::Object(payload))
+}
+
+fn page_query(limit: i64, page: i64) -> Vec<(&'static str, String)> {
+    vec![
+        ("page[size]", limit.clamp(1, PAGE_MAX).to_string()),
+        ("page[number]", page.max(0).to_string()),
+    ]
+}
+
+pub async fn list(cfg: &Config, query: Option<String>, limit: i64, page: i64) -> Result<()> {
+    let mut params = page_query(limit, page);
+    if let Some(q) = query {
+        params.push(("filter[query]", q));
+    }
+    let resp = crate::api::get(cfg, "/api/v2/workflows", &params)
+        .await
+        .map_err(|e| crate::api::failed("failed to list workflows", e))?;
+    let rows: Vec<serde_json::Value> = resp["data"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .map(workflow_row)
+        .collect();
+    formatter::output(cfg, &rows)
+}
+
+pub async fn get(cfg: &Config, workflow_id: &str) -> Result<()> {
+    let resp = crate::api::get(cfg, &format!("/api/v2/workflows/{workflow_id}"), &[])
+        .await
+        .map_err(|e| crate::api::failed("failed to get workflow", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn trigger(
+    cfg: &Config,
+    workflow_id: &str,
+    payload_file: Option<String>,
+    inputs: &[String],
+) -> Result<()> {
+    let base = payload_file
+        .as_deref()
+        .map(crate::util::read_json_body)
+        .transpose()?;
+    let body = serde_json::json!({
+        "meta": { "payload": build_payload(base, inputs)? }
+    });
+    let resp = crate::api::post(
+        cfg,
+        &format!("/api/v2/workflows/{workflow_id}/instances"),
+        &body,
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to trigger workflow", e))?;
+    let Some(instance_id) = resp["data"]["id"].as_str() else {
+        bail!("workflow trigger response did not include an instance id");
+    };
+    let out = serde_json::json!({
+        "workflow_id": workflow_id,
+        "instance_id": instance_id,
+        "url": format!(
+            "{}/workflow/{workflow_id}?instance_id={instance_id}",
+            cfg.app_base_url()
+        ),
+    });
+    formatter::output(cfg, &out)
+}
+
+pub async fn cancel(cfg: &Config, workflow_id: &str, instance_id: &str) -> Result<()> {
+    let resp = crate::api::put(
+        cfg,
+        &format!("/api/v2/workflows/{workflow_id}/instances/{instance_id}/cancel"),
+        &serde_json::json!({}),
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to cancel workflow instance", e))?;
+    formatter::output(cfg, &resp)
+}
+
+pub async fn instances_list(cfg: &Config, workflow_id: &str, limit: i64, page: i64) -> Result<()> {
+    let resp = crate::api::get(
+        cfg,
+        &format!("/api/v2/workflows/{workflow_id}/instances"),
+        &page_query(limit, page),
+    )
+    .await
+    .map_err(|e| crate::api::failed("failed to list workflow instances", e))?;
+    let rows: Vec<serde_json::Value> = resp["data"]
+        .as_array()
+        .into_iter()
+        .flatten()
+        .map(instance_row)
+        .collect();
+    formatter::output(cfg, &rows)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_build_payload_merges_inputs_over_file() {
+        let base = serde_json::json!({"host": "web-1", "dry_run": true});
+        let inputs = vec![
+            "dry_run=false".to_string(),
+            "count=3".to_string(),
+            "note=restart now".to_string(),
+        ];
+        assert_eq!(
+            build_payload(Some(base), &inputs).unwrap(),
+            serde_json::json!({"host": "web-1", "dry_run": false, "count": 3, "note": "restart now"})
+        );
+        assert_eq!(build_payload(None, &[]).unwrap(), serde_json::json!({}));
+    }
+
+    #[test]
+    fn test_build_payload_rejects_bad_input() {
+        assert!(build_payload(None, &["novalue".into()]).is_err());
+        assert!(build_payload(None, &["=x".into()]).is_err());
+        assert!(build_payload(Some(serde_json::json!([1])), &[]).is_err());
+    }
+
+    #[test]
+    fn test_workflow_row() {
+        let wf = serde_json::json!({
+            "id": "wf-1",
+            "attributes": {
+                "name": "Restart service",
+                "published": true,
+                "tags": ["team:sre"],
+                "updatedAt": "2026-01-02T03:04:05Z",
+                "spec": {"triggers": [
+                    {"apiTrigger": {}, "startStepNames": ["a"]},
+                    {"monitorTrigger": {}}
+                ]}
+            }
+        });
+        let row = workflow_row(&wf);
+        assert_eq!(row["name"], "Restart service");
+        assert_eq!(row["published"], true);
+        assert_eq!(
+            row["triggers"],
+            serde_json::json!(["apiTrigger", "monitorTrigger"])
+        );
+        assert_eq!(row["updated_at"], "2026-01-02T03:04:05Z");
+    }
+
+    #[test]
+    fn test_instance_row() {
+        let inst = serde_json::json!({
+            "id": "i-1",
+            "attributes": {
+                "instanceStatus": {"status": "IN_PROGRESS"},
+                "startedAt": "2026-01-02T03:04:05Z"
+            }
+        });
+        let row = instance_row(&inst);
+        assert_eq!(row["status"], "IN_PROGRESS");
+        assert_eq!(row["started_at"], "2026-01-02T03:04:05Z");
+        assert!(row["completed_at"].is_null());
+    }
+}
@@ -4,6 +4,7 @@ use serde::Deserialize;
 use std::path::PathBuf;
 
 /// Runtime configuration with precedence: flag > env > file > default.
+#[derive(Clone)]
 pub struct Config {
     pub api_key: Option<String>,
     pub app_key: Option<String>,
@@ -13,6 +14,16 @@ pub struct Config {
     pub output_format: OutputFormat,
     pub auto_approve: bool,
     pub agent_mode: bool,
+    /// Cap on rendered JSON output in bytes (0 disables the guardrail).
+    pub max_output_bytes: usize,
+    /// Rendering for timestamp columns in table output; None leaves API values as-is.
+    pub time_format: Option<TimeFormat>,
+    /// jq filter applied to command output before formatting (`--jq`).
+    pub jq: Option<String>,
+    /// `credential_process` command for keys not set any other way. Run by
+    /// `resolve_credential_process` when a command needs credentials, so
+    /// commands that never call the API don't spawn it.
+    pub credential_process: Option<String>,
 }
 
 #[derive(Clone, Debug, PartialEq)]
@@ -20,6 +31,7 @@ pub enum OutputFormat {
     Json,
     Table,
     Yaml,
+    Csv,
 }
 
 impl std::fmt::Display for OutputFormat {
@@ -28,6 +40,7 @@ impl std::fmt::Display for OutputFormat {
             OutputFormat::Json => write!(f, "json"),
             OutputFormat::Table => write!(f, "table"),
             OutputFormat::Yaml => write!(f, "yaml"),
+            OutputFormat::Csv => write!(f, "csv"),
         }
     }
 }
@@ -39,7 +52,41 @@ impl std::str::FromStr for OutputFormat {
             "json" => Ok(OutputFormat::Json),
             "table" => Ok(OutputFormat::Table),
             "yaml" => Ok(OutputFormat::Yaml),
-            _ => bail!("invalid output format: {s:?} (expected json, table, or yaml)"),
+            "csv" => Ok(OutputFormat::Csv),
+            _ => bail!("invalid output format: {s:?} (expected json, table, yaml, or csv)"),
+        }
+    }
+}
+
+/// Timestamp rendering for table output (--time-format).
+#[derive(Clone, Copy, Debug, PartialEq)]
+pub enum TimeFormat {
+    Relative,
+    Iso,
+    Epoch,
+    Local,
+}
+
+impl std::fmt::Display for TimeFormat {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        match self {
+            TimeFormat::Relative => write!(f, "relative"),
+            TimeFormat::Iso => write!(f, "iso"),
+            TimeFormat::Epoch => write!(f, "epoch"),
+            TimeFormat::Local => write!(f, "local"),
+        }
+    }
+}
+
+impl std::str::FromStr for TimeFormat {
+    type Err = anyhow::Error;
+    fn from_str(s: &str) -> Result<Self> {
+        match s.to_lowercase().as_str() {
+            "relative" => Ok(TimeFormat::Relative),
+            "iso" => Ok(TimeFormat::Iso),
+            "epoch" => Ok(TimeFormat::Epoch),
+            "local" => Ok(TimeFormat::Local),
+            _ => bail!("invalid time format: {s:?} (expected relative, iso, epoch, or local)"),
         }
     }
 }
@@ -50,11 +97,98 @@ impl std::str::FromStr for OutputFormat {
 struct FileConfig {
     api_key: Option<String>,
     app_key: Option<String>,
+    api_key_file: Option<String>,
+    app_key_file: Option<String>,
+    /// Command whose stdout is a JSON object with `api_key`/`app_key` fields.
+    credential_process: Option<String>,
     access_token: Option<String>,
     site: Option<String>,
     org: Option<String>,
     output: Option<String>,
     auto_approve: Option<bool>,
+    max_output_bytes: Option<usize>,
+    time_format: Option<String>,
+    /// OAuth token storage backend: `keyring` or `file`.
+    credential_store: Option<String>,
+    /// Per-request API timeout, e.g. `30s` or `2m` (a number is seconds).
+    timeout: Option<serde_yaml::Value>,
+    /// TCP/TLS connect timeout for API requests.
+    connect_timeout: Option<serde_yaml::Value>,
+    /// Named profiles selected with `--profile` / PUP_PROFILE.
+    #[serde(default)]
+    profiles: std::collections::BTreeMap<String, Profile>,
+}
+
+/// A named set of connection settings under `profiles:` in config.yaml.
+/// Set fields override the top-level config values when the profile is active.
+#[cfg(not(feature = "browser"))]
+#[derive(serde::Serialize, Deserialize, Default, Clone, Debug, PartialEq)]
+pub struct Profile {
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub site: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub api_key: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub app_key: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub api_key_file: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub app_key_file: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub output: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub org: Option<String>,
+}
+
+#[cfg(not(feature = "browser"))]
+impl FileConfig {
+    /// Overlay the named profile onto the top-level settings.
+    fn with_profile(mut self, name: &str) -> Result<Self> {
+        let Some(profile) = self.profiles.get(name).cloned() else {
+            bail!("profile {name:?} not found in config.yaml (see 'pup config profiles list')");
+        };
+        self.site = profile.site.or(self.site);
+        self.output = profile.output.or(self.output);
+        self.org = profile.org.or(self.org);
+        // Keys travel together: a profile's keys replace both inline and file keys.
+        if profile.api_key.is_some() || profile.api_key_file.is_some() {
+            self.api_key = profile.api_key;
+            self.api_key_file = profile.api_key_file;
+        }
+        if profile.app_key.is_some() || profile.app_key_file.is_some() {
+            self.app_key = profile.app_key;
+            self.app_key_file = profile.app_key_file;
+        }
+        Ok(self)
+    }
+}
+
+#[cfg(not(feature = "browser"))]
+static PROFILE: std::sync::OnceLock<String> = std::sync::OnceLock::new();
+
+/// Select a profile from `--profile`; wins over PUP_PROFILE. Must be called
+/// before `Config::from_env`.
+#[cfg(not(feature = "browser"))]
+pub fn set_profile(name: &str) {
+    let _ = PROFILE.set(name.to_string());
+}
+
+/// The profile in effect: `--profile`, then PUP_PROFILE.
+#[cfg(not(feature = "browser"))]
+pub fn active_profile() -> Option<String> {
+    PROFILE
+        .get()
+        .cloned()
+        .or_else(|| env_or("PUP_PROFILE", None))
+}
+
+/// The profile in effect and where it was selected.
+#[cfg(not(feature = "browser"))]
+fn profile_source() -> Option<(String, String)> {
+    match PROFILE.get() {
+        Some(name) => Some((name.clone(), "flag --profile".to_string())),
+        None => env_or_file("PUP_PROFILE", None, String::new()),
+    }
 }
 
 impl Config {
@@ -62,32 +196,221 @@ impl Config {
     /// Flag overrides are applied by the caller after this returns.
     #[cfg(not(feature = "browser"))]
     pub fn from_env() -> Result<Self> {
+        Self::load().map(|(cfg, _)| cfg)
+    }
+
+    /// `from_env`, also recording where each setting came from as it is
+    /// resolved, for `pup config show --sources`.
+    #[cfg(not(feature = "browser"))]
+    pub fn load() -> Result<(Self, Vec<SettingSource>)> {
         let file_cfg = load_config_file().unwrap_or_default();
+        let profile = profile_source();
+        // What the active profile sets; those fields win over the top level.
+        let overlay = profile
+            .as_ref()
+            .and_then(|(name, _)| file_cfg.profiles.get(name).cloned())
+            .unwrap_or_default();
+        let file_cfg = match &profile {
+            Some((name, _)) => file_cfg.with_profile(name)?,
+            None => file_cfg,
+        };
+        let in_file = |key: &str, in_profile: bool| match &profile {
+            Some((name, _)) if in_profile => format!("config.yaml (profiles.{name}.{key})"),
+            _ => format!("config.yaml ({key})"),
+        };
 
-        let access_token = env_or("DD_ACCESS_TOKEN", file_cfg.access_token);
-        let site = env_or("DD_SITE", file_cfg.site).unwrap_or_else(|| "datadoghq.com".into());
-        let org = env_or("DD_ORG", file_cfg.org); // flag override applied in main_inner
+        let access_token = env_or_file(
+            "DD_ACCESS_TOKEN",
+            file_cfg.access_token,
+            in_file("access_token", false),
+        );
+        let (site, site_source) = env_or_file(
+            "DD_SITE",
+            file_cfg.site,
+            in_file("site", overlay.site.is_some()),
+        )
+        .unwrap_or_else(|| ("datadoghq.com".into(), "default".into()));
+        // flag override applied in main_inner
+        let org = env_or_file(
+            "DD_ORG",
+            file_cfg.org,
+            in_file("org", overlay.org.is_some()),
+        );
 
         // If no token from env/file, try loading from keychain/storage (where `pup auth login` saves)
         #[cfg(not(target_arch = "wasm32"))]
-        let access_token = access_token.or_else(|| load_token_from_storage(&site, org.as_deref()));
+        let access_token = access_token.or_else(|| {
+            load_token_from_storage(&site, org.as_ref().map(|(o, _)| o.as_str()))
+                .map(|token| (token, "token storage".to_string()))
+        });
+
+        // Keys: env value > *_FILE (env, then config) > config value > credential_process,
+        // which is only recorded here and run once credentials are needed.
+        // A profile's keys replace both the top-level inline key and key file.
+        let api_in_profile = overlay.api_key.is_some() || overlay.api_key_file.is_some();
+        let app_in_profile = overlay.app_key.is_some() || overlay.app_key_file.is_some();
+        let mut api_key = match env_or_file("DD_API_KEY", None, String::new()) {
+            Some(key) => Some(key),
+            None => {
+                key_from_file("DD_API_KEY_FILE", file_cfg.api_key_file.as_deref())?.map(|key| {
+                    (
+                        key,
+                        file_source("DD_API_KEY_FILE", in_file("api_key_file", api_in_profile)),
+                    )
+                })
+            }
+        };
+        let mut app_key = match env_or_file("DD_APP_KEY", None, String::new()) {
+            Some(key) => Some(key),
+            None => {
+                key_from_file("DD_APP_KEY_FILE", file_cfg.app_key_file.as_deref())?.map(|key| {
+                    (
+                        key,
+                        file_source("DD_APP_KEY_FILE", in_file("app_key_file", app_in_profile)),
+                    )
+                })
+            }
+        };
+        api_key = api_key.or_else(|| {
+            file_cfg
+                .api_key
+                .map(|key| (key, in_file("api_key", api_in_profile)))
+        });
+        app_key = app_key.or_else(|| {
+            file_cfg
+                .app_key
+                .map(|key| (key, in_file("app_key", app_in_profile)))
+        });
+        let credential_process = if api_key.is_none() || app_key.is_none() {
+            env_or_file(
+                "PUP_CREDENTIAL_PROCESS",
+                file_cfg.credential_process,
+                in_file("credential_process", false),
+            )
+        } else {
+            None
+        };
+        let key_source = |key: &Option<(String, String)>| match (key, &credential_process) {
+            (Some((_, source)), _) => source.clone(),
+            (None, Some((_, source))) => format!("{source}, run when needed"),
+            (None, None) => "not set".to_string(),
+        };
+
+        // Unparseable values fall back to the default, so they are not the source.
+        let output = env_or_file(
+            "DD_OUTPUT",
+            file_cfg.output,
+            in_file("output", overlay.output.is_some()),
+        )
+        .and_then(|(v, source)| Some((v.parse::<OutputFormat>().ok()?, source)));
+        let auto_approve = ["DD_AUTO_APPROVE", "DD_CLI_AUTO_APPROVE"]
+            .into_iter()
+            .find(|var| env_bool(var))
+            .map(|var| format!("env {var}"))
+            .or_else(|| {
+                file_cfg
+                    .auto_approve
+                    .filter(|&on| on)
+                    .map(|_| in_file("auto_approve", false))
+            });
+        let max_output_bytes = env_or("PUP_MAX_OUTPUT_BYTES", None)
+            .and_then(|s| s.parse().ok())
+            .map(|n| (n, "env PUP_MAX_OUTPUT_BYTES".to_string()))
+            .or_else(|| {
+                file_cfg
+                    .max_output_bytes
+                    .map(|n| (n, in_file("max_output_bytes", false)))
+            });
+        let time_format = env_or_file(
+            "PUP_TIME_FORMAT",
+            file_cfg.time_format,
+            in_file("time_format", false),
+        )
+        .and_then(|(v, source)| Some((v.parse::<TimeFormat>().ok()?, source)));
+        // Resolved by request_timeouts and the token store; recorded here so
+        // every setting's source comes from one place.
+        let timeout = timeout_setting(
+            "PUP_TIMEOUT",
+            file_cfg.timeout.as_ref(),
+            in_file("timeout", false),
+        );
+        let connect_timeout = timeout_setting(
+            "PUP_CONNECT_TIMEOUT",
+            file_cfg.connect_timeout.as_ref(),
+            in_file("connect_timeout", false),
+        );
+        let credential_store = env_or_file(
+            "DD_TOKEN_STORAGE",
+            file_cfg.credential_store,
+            in_file("credential_store", false),
+        );
+
+        let source_of = |found: Option<&String>, unset: &str| {
+            found.cloned().unwrap_or_else(|| unset.to_string())
+        };
+        let setting = |setting: &'static str, source: String| SettingSource {
+            setting,
+            source,
+            raw: None,
+        };
+        let sources = vec![
+            setting(
+                "profile",
+                source_of(profile.as_ref().map(|p| &p.1), "not set"),
+            ),
+            setting("site", site_source),
+            setting("org", source_of(org.as_ref().map(|o| &o.1), "not set")),
+            setting("api_key", key_source(&api_key)),
+            setting("app_key", key_source(&app_key)),
+            setting(
+                "access_token",
+                source_of(access_token.as_ref().map(|t| &t.1), "not set"),
+            ),
+            setting(
+                "output",
+                source_of(output.as_ref().map(|o| &o.1), "default"),
+            ),
+            setting("auto_approve", source_of(auto_approve.as_ref(), "default")),
+            setting(
+                "max_output_bytes",
+                source_of(max_output_bytes.as_ref().map(|m| &m.1), "default"),
+            ),
+            setting(
+                "time_format",
+                source_of(time_format.as_ref().map(|t| &t.1), "not set"),
+            ),
+            setting(
+                "timeout",
+                source_of(timeout.as_ref().map(|t| &t.1), "not set"),
+            ),
+            setting(
+                "connect_timeout",
+                source_of(connect_timeout.as_ref().map(|t| &t.1), "default"),
+            ),
+            SettingSource {
+                setting: "credential_store",
+                source: source_of(credential_store.as_ref().map(|c| &c.1), "default"),
+                raw: credential_store.map(|(v, _)| v),
+            },
+        ];
 
         let cfg = Config {
-            api_key: env_or("DD_API_KEY", file_cfg.api_key),
-            app_key: env_or("DD_APP_KEY", file_cfg.app_key),
-            access_token,
+            api_key: api_key.map(|(k, _)| k),
+            app_key: app_key.map(|(k, _)| k),
+            access_token: access_token.map(|(t, _)| t),
             site,
-            org,
-            output_format: env_or("DD_OUTPUT", file_cfg.output)
-                .and_then(|s| s.parse().ok())
-                .unwrap_or(OutputFormat::Json),
-            auto_approve: env_bool("DD_AUTO_APPROVE")
-                || env_bool("DD_CLI_AUTO_APPROVE")
-                || file_cfg.auto_approve.unwrap_or(false),
+            org: org.map(|(o, _)| o),
+            output_format: output.map_or(OutputFormat::Json, |(o, _)| o),
+            auto_approve: auto_approve.is_some(),
             agent_mode: false, // set by caller from --agent flag or useragent detection
+            max_output_bytes: max_output_bytes
+                .map_or(crate::formatter::DEFAULT_MAX_OUTPUT_BYTES, |(n, _)| n),
+            time_format: time_format.map(|(t, _)| t),
+            jq: None, // set by caller from --jq flag
+            credential_process: credential_process.map(|(c, _)| c),
         };
 
-        Ok(cfg)
+        Ok((cfg, sources))
     }
 
     /// Create configuration from explicit parameters (no env vars or filesystem).
@@ -108,33 +431,80 @@ impl Config {
             output_format: OutputFormat::Json,
             auto_approve: false,
             agent_mode: false,
+            max_output_bytes: crate::formatter::DEFAULT_MAX_OUTPUT_BYTES,
+            time_format: None,
+            jq: None,
+            credential_process: None,
         }
     }
 
-    /// Validate that sufficient auth credentials are configured.
-    pub fn validate_auth(&self) -> Result<()> {
+    /// Run the pending `credential_process`, if any, to fill in the keys not
+    /// set any other way. Runs at most once.
+    pub fn resolve_credential_process(&mut self) -> Result<()> {
+        #[cfg(not(feature = "browser"))]
+        if let Some(command) = self.credential_process.take() {
+            if self.api_key.is_none() || self.app_key.is_none() {
+                let creds = run_credential_process(&command)?;
+                self.api_key = self.api_key.take().or(creds.api_key);
+                self.app_key = self.app_key.take().or(creds.app_key);
+                crate::runlog::add_secrets(self);
+            }
+        }
+        Ok(())
+    }
+
+    /// Validate that sufficient auth credentials are configured, running a
+    /// pending `credential_process` first.
+    pub fn validate_auth(&mut self) -> Result<()> {
+        self.resolve_credential_process()?;
         if self.access_token.is_none() && (self.api_key.is_none() || self.app_key.is_none()) {
-            bail!(
+            return Err(crate::exit_code::Failure::auth(
                 "authentication required: set DD_ACCESS_TOKEN for bearer auth, \
                  run 'pup auth login' for OAuth2, \
-                 or set DD_API_KEY and DD_APP_KEY for API+APP key auth"
-            );
+                 or set DD_API_KEY and DD_APP_KEY for API+APP key auth",
+            ));
         }
         Ok(())
     }
 
     /// Validate that both DD_API_KEY and DD_APP_KEY are configured.
     /// Used for endpoints that require API key auth and do not accept OAuth2 tokens.
-    pub fn validate_api_and_app_keys(&self) -> Result<()> {
+    pub fn validate_api_and_app_keys(&mut self) -> Result<()> {
+        self.resolve_credential_process()?;
         if self.api_key.is_none() || self.app_key.is_none() {
-            bail!(
+            return Err(crate::exit_code::Failure::auth(
                 "this command requires both DD_API_KEY and DD_APP_KEY — \
-                 OAuth2 bearer tokens are not supported here"
-            );
+                 OAuth2 bearer tokens are not supported here",
+            ));
         }
         Ok(())
     }
 
+    /// Copy of this config targeting another named org session (cross-org commands).
+    /// Uses the org's stored OAuth token; API/APP keys are dropped since they belong
+    /// to the current org.
+    #[cfg(all(not(feature = "browser"), not(target_arch = "wasm32")))]
+    pub fn for_org(&self, org: &str) -> Result<Config> {
+        let token = load_token_from_storage(&self.site, Some(org)).ok_or_else(|| {
+            crate::exit_code::Failure::auth(format!(
+                "no valid session for org {org:?} — run 'pup auth login --org {org}'"
+            ))
+        })?;
+        Ok(Config {
+            api_key: None,
+            app_key: None,
+            access_token: Some(token),
+            org: Some(org.to_string()),
+            credential_process: None,
+            ..self.clone()
+        })
+    }
+
+    #[cfg(all(target_arch = "wasm32", not(feature = "browser")))]
+    pub fn for_org(&self, _org: &str) -> Result<Config> {
+        bail!("named org sessions are not available in WASM builds — token storage is unavailable")
+    }
+
     pub fn has_api_keys(&self) -> bool {
         self.api_key.is_some() && self.app_key.is_some()
     }
@@ -172,6 +542,37 @@ impl Config {
         }
         format!("https://{}", self.api_host())
     }
+
+    /// Web app base URL for deep links. Two-label sites (datadoghq.com,
+    /// datadoghq.eu, ddog-gov.com) serve the app on `app.<site>`; regional
+    /// sites such as us3.datadoghq.com serve it on the site host itself.
+    pub fn app_base_url(&self) -> String {
+        if self.site.split('.').count() > 2 {
+            format!("https://{}", self.site)
+        } else {
+            format!("https://app.{}", self.site)
+        }
+    }
+}
+
+/// Normalize a site given as a flag: accept a pasted URL or `app.`/`api.` host
+/// and reduce it to the bare site (`https://app.datadoghq.eu/` -> `datadoghq.eu`).
+pub fn normalize_site(site: &str) -> Result<String> {
+    let host = site
+        .trim()
+        .trim_start_matches("https://")
+        .trim_start_matches("http://")
+        .trim_end_matches('/');
+    let host = host
+        .strip_prefix("app.")
+        .or_else(|| host.strip_prefix("api."))
+        .unwrap_or(host);
+    if host.is_empty() || host.contains(['/', ' ', ':']) {
+        bail!(
+            "invalid site {site:?}: expected a Datadog site such as datadoghq.com or datadoghq.eu"
+        );
+    }
+    Ok(host.to_ascii_lowercase())
 }
 
 /// Config file path: ~/.config/pup/config.yaml
@@ -202,9 +603,195 @@ pub fn config_dir() -> Option<PathBuf> {
 fn load_config_file() -> Option<FileConfig> {
     let path = config_dir()?.join("config.yaml");
     let contents = std::fs::read_to_string(path).ok()?;
+    warn_config_issues(&contents);
     serde_yaml::from_str(&contents).ok()
 }
 
+/// Parse config.yaml strictly and resolve the active profile against it.
+/// Returns the file's path, or None when there is no config file. Unlike
+/// config loading, parse errors are reported rather than ignored.
+#[cfg(not(feature = "browser"))]
+pub fn check_config_file() -> Result<Option<PathBuf>> {
+    let Some(path) = config_dir().map(|d| d.join("config.yaml")) else {
+        return Ok(None);
+    };
+    let contents = match std::fs::read_to_string(&path) {
+        Ok(c) => c,
+        Err(e) if e.kind() == std::io::ErrorKind::NotFound => return Ok(None),
+        Err(e) => bail!("failed to read {}: {e}", path.display()),
+    };
+    let file_cfg: FileConfig = serde_yaml::from_str(&contents)
+        .map_err(|e| anyhow::anyhow!("failed to parse {}: {e}", path.display()))?;
+    if let Some(name) = active_profile() {
+        file_cfg.with_profile(&name)?;
+    }
+    Ok(Some(path))
+}
+
+/// `credential_store` from the config file, used to pick the token storage
+/// backend when neither `--credential-store` nor DD_TOKEN_STORAGE is set.
+#[cfg(all(not(feature = "browser"), not(target_arch = "wasm32")))]
+pub fn file_credential_store() -> Option<String> {
+    load_config_file()?.credential_store
+}
+
+/// Connect timeout used unless `--connect-timeout`, PUP_CONNECT_TIMEOUT, or
+/// config `connect_timeout` says otherwise.
+pub const DEFAULT_CONNECT_TIMEOUT: std::time::Duration = std::time::Duration::from_secs(10);
+
+/// Parse a timeout: `500ms`, `30s`, `2m`, `1h`, or a bare number of seconds.
+/// Zero means no timeout (None).
+pub fn parse_timeout(input: &str) -> Result<Option<std::time::Duration>> {
+    let input = input.trim();
+    let split = input
+        .find(|c: char| !c.is_ascii_digit())
+        .unwrap_or(input.len());
+    let (num, unit) = input.split_at(split);
+    let Ok(num) = num.parse::<u64>() else {
+        bail!("invalid timeout {input:?}: expected e.g. 30s, 2m, or 0 to disable");
+    };
+    let unit_millis = match unit.trim() {
+        "ms" => 1,
+        "" | "s" => 1000,
+        "m" => 60_000,
+        "h" => 3_600_000,
+        _ => bail!("invalid timeout {input:?}: expected e.g. 30s, 2m, or 0 to disable"),
+    };
+    let Some(millis) = num.checked_mul(unit_millis) else {
+        bail!("invalid timeout {input:?}: too large");
+    };
+    Ok((millis > 0).then(|| std::time::Duration::from_millis(millis)))
+}
+
+/// Request and connect timeouts: flag > env (PUP_TIMEOUT, PUP_CONNECT_TIMEOUT)
+/// > config file. No request timeout by default; connecting gives up after
+/// `DEFAULT_CONNECT_TIMEOUT`.
+#[cfg(not(feature = "browser"))]
+pub fn request_timeouts(
+    flag: Option<&str>,
+    connect_flag: Option<&str>,
+) -> Result<(Option<std::time::Duration>, Option<std::time::Duration>)> {
+    let file_cfg = load_config_file().unwrap_or_default();
+    let pick = |flag: Option<&str>, var: &str, file: Option<&serde_yaml::Value>| {
+        flag.map(String::from)
+            .or_else(|| timeout_setting(var, file, String::new()).map(|(t, _)| t))
+    };
+    let timeout = match pick(flag, "PUP_TIMEOUT", file_cfg.timeout.as_ref()) {
+        Some(t) => parse_timeout(&t)?,
+        None => None,
+    };
+    let connect = match pick(
+        connect_flag,
+        "PUP_CONNECT_TIMEOUT",
+        file_cfg.connect_timeout.as_ref(),
+    ) {
+        Some(t) => parse_timeout(&t)?,
+        None => Some(DEFAULT_CONNECT_TIMEOUT),
+    };
+    Ok((timeout, connect))
+}
+
+/// A timeout from env var `var` or the config file, with its source.
+#[cfg(not(feature = "browser"))]
+fn timeout_setting(
+    var: &str,
+    file: Option<&serde_yaml::Value>,
+    file_source: String,
+) -> Option<(String, String)> {
+    env_or_file(var, file.and_then(yaml_scalar), file_source)
+}
+
+/// Read a key from the file named by env var `var`, falling back to the config
+/// file's `*_file` path. A configured but unreadable or empty file is an error
+/// rather than a silent fall-through to weaker credentials.
+#[cfg(not(feature = "browser"))]
+fn key_from_file(var: &str, fallback: Option<&str>) -> Result<Option<String>> {
+    let Some(path) = env_or(var, fallback.map(String::from)) else {
+        return Ok(None);
+    };
+    let path = expand_home(&path);
+    let contents = std::fs::read_to_string(&path)
+        .map_err(|e| anyhow::anyhow!("failed to read {var} {}: {e}", path.display()))?;
+    let key = contents.trim();
+    if key.is_empty() {
+        bail!("{var} {} is empty", path.display());
+    }
+    Ok(Some(key.to_string()))
+}
+
+#[cfg(not(feature = "browser"))]
+fn expand_home(path: &str) -> PathBuf {
+    #[cfg(not(target_arch = "wasm32"))]
+    if let Some(rest) = path.strip_prefix("~/") {
+        if let Some(home) = dirs::home_dir() {
+            return home.join(rest);
+        }
+    }
+    PathBuf::from(path)
+}
+
+/// Keys returned by a `credential_process` command.
+#[cfg(not(feature = "browser"))]
+#[derive(Deserialize, Default, Debug, PartialEq)]
+struct ProcessCredentials {
+    #[serde(alias = "DD_API_KEY")]
+    api_key: Option<String>,
+    #[serde(alias = "DD_APP_KEY", alias = "application_key")]
+    app_key: Option<String>,
+}
+
+#[cfg(not(feature = "browser"))]
+fn parse_process_credentials(stdout: &str) -> Result<ProcessCredentials> {
+    let creds: ProcessCredentials = serde_json::from_str(stdout.trim()).map_err(|e| {
+        anyhow::anyhow!(
+            "credential_process output must be a JSON object with api_key and app_key: {e}"
+        )
+    })?;
+    let creds = ProcessCredentials {
+        api_key: creds.api_key.filter(|s| !s.is_empty()),
+        app_key: creds.app_key.filter(|s| !s.is_empty()),
+    };
+    if creds.api_key.is_none() && creds.app_key.is_none() {
+        bail!("credential_process returned neither api_key nor app_key");
+    }
+    Ok(creds)
+}
+
+/// Run the configured credential command through the shell and parse its stdout.
+/// Its stderr is passed through so interactive helpers (e.g. vault login prompts) work.
+#[cfg(all(not(feature = "browser"), not(target_arch = "wasm32")))]
+fn run_credential_process(command: &str) -> Result<ProcessCredentials> {
+    #[cfg(windows)]
+    let mut cmd = {
+        let mut c = std::process::Command::new("cmd");
+        c.args(["/C", command]);
+        c
+    };
+    #[cfg(not(windows))]
+    let mut cmd = {
+        let mut c = std::process::Command::new("sh");
+        c.args(["-c", command]);
+        c
+    };
+    let output = cmd
+        .stdin(std::process::Stdio::inherit())
+        .stderr(std::process::Stdio::inherit())
+        .output()
+        .map_err(|e| anyhow::anyhow!("failed to run credential_process {command:?}: {e}"))?;
+    if !output.status.success() {
+        bail!(
+            "credential_process {command:?} failed with {}",
+            output.status
+        );
+    }
+    parse_process_credentials(&String::from_utf8_lossy(&output.stdout))
+}
+
+#[cfg(all(target_arch = "wasm32", not(feature = "browser")))]
+fn run_credential_process(_command: &str) -> Result<ProcessCredentials> {
+    bail!("credential_process is not available in WASM builds — subprocesses are not supported")
+}
+
 /// Try to load a valid (non-expired) access token from keychain/file storage.
 /// Returns None silently on any error — callers fall through to other auth methods.
 #[cfg(all(not(feature = "browser"), not(target_arch = "wasm32")))]
@@ -227,6 +814,30 @@ fn env_or(key: &str, fallback: Option<String>) -> Option<String> {
         .or(fallback)
 }
 
+/// `env_or`, also saying which of the two supplied the value: `env VAR` or
+/// `file_source`.
+#[cfg(not(feature = "browser"))]
+fn env_or_file(
+    key: &str,
+    fallback: Option<String>,
+    file_source: String,
+) -> Option<(String, String)> {
+    match env_or(key, None) {
+        Some(value) => Some((value, format!("env {key}"))),
+        None => fallback.map(|value| (value, file_source)),
+    }
+}
+
+/// Source of a key read by `key_from_file`: the env var naming the file if
+/// set, otherwise the config file's `*_file` entry.
+#[cfg(not(feature = "browser"))]
+fn file_source(var: &str, config_source: String) -> String {
+    match env_or(var, None) {
+        Some(_) => format!("env {var}"),
+        None => config_source,
+    }
+}
+
 #[cfg(not(feature = "browser"))]
 fn env_bool(key: &str) -> bool {
     matches!(
@@ -238,6 +849,367 @@ fn env_bool(key: &str) -> bool {
     )
 }
 
+// ---------------------------------------------------------------------------
+// Lint
+// ---------------------------------------------------------------------------
+
+/// Sites served by Datadog. Anything else is usually a typo.
+pub const KNOWN_SITES: &[&str] = &[
+    "datadoghq.com",
+    "us3.datadoghq.com",
+    "us5.datadoghq.com",
+    "ap1.datadoghq.com",
+    "ap2.datadoghq.com",
+    "datadoghq.eu",
+    "ddog-gov.com",
+];
+
+/// What a config.yaml value must be.
+#[cfg(not(feature = "browser"))]
+#[derive(Clone, Copy)]
+enum ValueKind {
+    Str,
+    Bool,
+    Uint,
+    Site,
+    Output,
+    TimeFormat,
+    CredentialStore,
+    Timeout,
+    Profiles,
+}
+
+/// Top-level keys `FileConfig` reads; keep in sync with its fields.
+#[cfg(not(feature = "browser"))]
+const FILE_KEYS: &[(&str, ValueKind)] = &[
+    ("api_key", ValueKind::Str),
+    ("app_key", ValueKind::Str),
+    ("api_key_file", ValueKind::Str),
+    ("app_key_file", ValueKind::Str),
+    ("credential_process", ValueKind::Str),
+    ("access_token", ValueKind::Str),
+    ("site", ValueKind::Site),
+    ("org", ValueKind::Str),
+    ("output", ValueKind::Output),
+    ("auto_approve", ValueKind::Bool),
+    ("max_output_bytes", ValueKind::Uint),
+    ("time_format", ValueKind::TimeFormat),
+    ("credential_store", ValueKind::CredentialStore),
+    ("timeout", ValueKind::Timeout),
+    ("connect_timeout", ValueKind::Timeout),
+    ("profiles", ValueKind::Profiles),
+];
+
+/// Keys a profile may set; keep in sync with `Profile`.
+#[cfg(not(feature = "browser"))]
+const PROFILE_KEYS: &[(&str, ValueKind)] = &[
+    ("site", ValueKind::Site),
+    ("api_key", ValueKind::Str),
+    ("app_key", ValueKind::Str),
+    ("api_key_file", ValueKind::Str),
+    ("app_key_file", ValueKind::Str),
+    ("output", ValueKind::Output),
+    ("org", ValueKind::Str),
+];
+
+/// A problem found in config.yaml or aliases.yaml.
+#[cfg(not(feature = "browser"))]
+#[derive(serde::Serialize, Debug, PartialEq)]
+pub struct LintIssue {
+    /// `error` or `warning`.
+    pub level: &'static str,
+    /// Dotted path to the offending key, e.g. `profiles.prod.site`.
+    pub key: String,
+    pub message: String,
+}
+
+#[cfg(not(feature = "browser"))]
+impl LintIssue {
+    fn error(key: impl Into<String>, message: impl Into<String>) -> Self {
+        LintIssue {
+            level: "error",
+            key: key.into(),
+            message: message.into(),
+        }
+    }
+
+    fn warning(key: impl Into<String>, message: impl Into<String>) -> Self {
+        LintIssue {
+            level: "warning",
+            key: key.into(),
+            message: message.into(),
+        }
+    }
+}
+
+/// Levenshtein distance, for "did you mean" suggestions.
+#[cfg(not(feature = "browser"))]
+fn edit_distance(a: &str, b: &str) -> usize {
+    let b: Vec<char> = b.chars().collect();
+    let mut prev: Vec<usize> = (0..=b.len()).collect();
+    for (i, ca) in a.chars().enumerate() {
+        let mut cur = vec![i + 1; b.len() + 1];
+        for (j, cb) in b.iter().enumerate() {
+            let cost = usize::from(ca != *cb);
+            cur[j + 1] = (prev[j] + cost).min(prev[j + 1] + 1).min(cur[j] + 1);
+        }
+        prev = cur;
+    }
+    prev[b.len()]
+}
+
+/// The closest candidate within two edits of `name`.
+#[cfg(not(feature = "browser"))]
+pub fn suggest<'a>(name: &str, candidates: impl IntoIterator<Item = &'a str>) -> Option<&'a str> {
+    candidates
+        .into_iter()
+        .map(|c| (edit_distance(name, c), c))
+        .filter(|(d, _)| *d <= 2)
+        .min_by_key(|(d, _)| *d)
+        .map(|(_, c)| c)
+}
+
+#[cfg(not(feature = "browser"))]
+fn lint_value(path: &str, kind: ValueKind, value: &serde_yaml::Value, out: &mut Vec<LintIssue>) {
+    use serde_yaml::Value;
+    let as_str = || value.as_str();
+    match kind {
+        ValueKind::Str => {
+            if as_str().is_none() {
+                out.push(LintIssue::error(path, "expected a string"));
+            }
+        }
+        ValueKind::Bool => {
+            if !value.is_bool() {
+                out.push(LintIssue::error(path, "expected true or false"));
+            }
+        }
+        ValueKind::Uint => {
+            if value.as_u64().is_none() {
+                out.push(LintIssue::error(path, "expected a non-negative integer"));
+            }
+        }
+        ValueKind::Site => match as_str() {
+            None => out.push(LintIssue::error(path, "expected a string")),
+            Some(site)
+                if site.contains("://") || site.starts_with("api.") || site.starts_with("app.") =>
+            {
+                out.push(LintIssue::error(
+                    path,
+                    format!("{site:?} is not a bare site name (e.g. datadoghq.eu)"),
+                ));
+            }
+            Some(site) if !KNOWN_SITES.contains(&site) => {
+                let hint = suggest(site, KNOWN_SITES.iter().copied())
+                    .map(|s| format!("; did you mean {s:?}?"))
+                    .unwrap_or_default();
+                out.push(LintIssue::warning(
+                    path,
+                    format!("{site:?} is not a known Datadog site{hint}"),
+                ));
+            }
+            Some(_) => {}
+        },
+        ValueKind::Output => match as_str().map(str::parse::<OutputFormat>) {
+            None => out.push(LintIssue::error(path, "expected a string")),
+            Some(Err(e)) => out.push(LintIssue::error(path, e.to_string())),
+            Some(Ok(_)) => {}
+        },
+        ValueKind::TimeFormat => match as_str().map(str::parse::<TimeFormat>) {
+            None => out.push(LintIssue::error(path, "expected a string")),
+            Some(Err(e)) => out.push(LintIssue::error(path, e.to_string())),
+            Some(Ok(_)) => {}
+        },
+        ValueKind::CredentialStore => match as_str() {
+            Some("keyring" | "keychain" | "file") => {}
+            _ => out.push(LintIssue::error(path, "expected keyring or file")),
+        },
+        ValueKind::Timeout => match yaml_scalar(value).map(|t| parse_timeout(&t)) {
+            Some(Ok(_)) => {}
+            Some(Err(e)) => out.push(LintIssue::error(path, e.to_string())),
+            None => out.push(LintIssue::error(path, "expected a duration such as 30s")),
+        },
+        ValueKind::Profiles => match value {
+            Value::Mapping(profiles) => {
+                for (name, profile) in profiles {
+                    let name = name.as_str().unwrap_or("?");
+                    lint_mapping(&format!("{path}.{name}"), PROFILE_KEYS, profile, out);
+                }
+            }
+            _ => out.push(LintIssue::error(
+                path,
+                "expected a mapping of profile names",
+            )),
+        },
+    }
+}
+
+#[cfg(not(feature = "browser"))]
+fn lint_mapping(
+    path: &str,
+    keys: &[(&str, ValueKind)],
+    value: &serde_yaml::Value,
+    out: &mut Vec<LintIssue>,
+) {
+    let Some(map) = value.as_mapping() else {
+        out.push(LintIssue::error(path, "expected a mapping of settings"));
+        return;
+    };
+    for (key, value) in map {
+        let Some(key) = key.as_str() else {
+            out.push(LintIssue::error(path, "keys must be strings"));
+            continue;
+        };
+        let full = if path.is_empty() {
+            key.to_string()
+        } else {
+            format!("{path}.{key}")
+        };
+        match keys.iter().find(|(k, _)| *k == key) {
+            Some((_, kind)) => lint_value(&full, *kind, value, out),
+            None => {
+                let hint = suggest(key, keys.iter().map(|(k, _)| *k))
+                    .map(|k| format!("; did you mean {k:?}?"))
+                    .unwrap_or_default();
+                out.push(LintIssue::error(
+                    full,
+                    format!("unknown key {key:?} is ignored{hint}"),
+                ));
+            }
+        }
+    }
+}
+
+/// Check config.yaml contents against the settings pup reads.
+#[cfg(not(feature = "browser"))]
+pub fn lint_config(contents: &str) -> Vec<LintIssue> {
+    let doc: serde_yaml::Value = match serde_yaml::from_str(contents) {
+        Ok(doc) => doc,
+        Err(e) => return vec![LintIssue::error("", format!("invalid YAML: {e}"))],
+    };
+    let mut out = Vec::new();
+    if !doc.is_null() {
+        lint_mapping("", FILE_KEYS, &doc, &mut out);
+    }
+    out
+}
+
+/// Check aliases.yaml: each alias must name a string command that starts
+/// with a pup command, and must not shadow a built-in command.
+#[cfg(not(feature = "browser"))]
+pub fn lint_aliases(contents: &str, commands: &[String]) -> Vec<LintIssue> {
+    let doc: serde_yaml::Value = match serde_yaml::from_str(contents) {
+        Ok(doc) => doc,
+        Err(e) => return vec![LintIssue::error("", format!("invalid YAML: {e}"))],
+    };
+    let mut out = Vec::new();
+    let Some(map) = doc.as_mapping() else {
+        if !doc.is_null() {
+            out.push(LintIssue::error(
+                "",
+                "expected a mapping of alias names to commands",
+            ));
+        }
+        return out;
+    };
+    for (name, target) in map {
+        let name = name.as_str().unwrap_or("?");
+        if commands.iter().any(|c| c == name) {
+            out.push(LintIssue::warning(
+                name,
+                format!("alias {name:?} has the same name as a built-in command"),
+            ));
+        }
+        let Some(target) = target.as_str() else {
+            out.push(LintIssue::error(
+                name,
+                "expected the aliased command as a string",
+            ));
+            continue;
+        };
+        let Some(first) = target.split_whitespace().next() else {
+            out.push(LintIssue::error(name, "alias command is empty"));
+            continue;
+        };
+        if !commands.iter().any(|c| c == first) {
+            let hint = suggest(first, commands.iter().map(String::as_str))
+                .map(|c| format!("; did you mean {c:?}?"))
+                .unwrap_or_default();
+            out.push(LintIssue::error(
+                name,
+                format!("{first:?} is not a pup command{hint}"),
+            ));
+        }
+    }
+    out
+}
+
+#[cfg(not(feature = "browser"))]
+static CONFIG_WARNED: std::sync::Once = std::sync::Once::new();
+
+/// Print config.yaml problems as warnings, once per process, so a typo like
+/// `sitee:` doesn't silently fall back to defaults.
+#[cfg(not(feature = "browser"))]
+fn warn_config_issues(contents: &str) {
+    CONFIG_WARNED.call_once(|| {
+        for issue in lint_config(contents) {
+            let key = if issue.key.is_empty() {
+                String::new()
+            } else {
+                format!("{}: ", issue.key)
+            };
+            eprintln!(
+                "warning: config.yaml: {key}{} (see 'pup config lint')",
+                issue.message
+            );
+        }
+    });
+}
+
+/// Skip the load-time warnings; `pup config lint` reports the same issues itself.
+#[cfg(not(feature = "browser"))]
+pub fn suppress_config_warnings() {
+    CONFIG_WARNED.call_once(|| {});
+}
+
+// ---------------------------------------------------------------------------
+// Provenance
+// ---------------------------------------------------------------------------
+
+/// Where an effective setting came from, for `pup config show --sources`.
+#[cfg(not(feature = "browser"))]
+#[derive(Debug, PartialEq)]
+pub struct SettingSource {
+    pub setting: &'static str,
+    /// e.g. `flag --org`, `env DD_SITE`, `config.yaml (profiles.prod.site)`, `default`.
+    pub source: String,
+    /// The value as written at the source, for settings `Config` doesn't keep.
+    pub raw: Option<String>,
+}
+
+/// Relabel the settings the caller changed after loading (global flags, agent
+/// mode); `overrides` maps a setting to a label such as `flag --org`, and a
+/// later entry for the same setting wins.
+#[cfg(not(feature = "browser"))]
+pub fn apply_overrides(sources: &mut [SettingSource], overrides: &[(&str, String)]) {
+    for (setting, label) in overrides {
+        if let Some(s) = sources.iter_mut().find(|s| s.setting == *setting) {
+            s.source = label.clone();
+        }
+    }
+}
+
+#[cfg(not(feature = "browser"))]
+fn yaml_scalar(value: &serde_yaml::Value) -> Option<String> {
+    match value {
+        serde_yaml::Value::Null => None,
+        serde_yaml::Value::String(s) => Some(s.clone()),
+        other => serde_yaml::to_string(other)
+            .ok()
+            .map(|s| s.trim().to_string()),
+    }
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -248,11 +1220,7 @@ mod tests {
             api_key: api_key.map(String::from),
             app_key: app_key.map(String::from),
             access_token: token.map(String::from),
-            site: "datadoghq.com".into(),
-            org: None,
-            output_format: OutputFormat::Json,
-            auto_approve: false,
-            agent_mode: false,
+            ..crate::test_utils::config()
         }
     }
 
@@ -265,6 +1233,7 @@ mod tests {
             OutputFormat::Table
         );
         assert_eq!("yaml".parse::<OutputFormat>().unwrap(), OutputFormat::Yaml);
+        assert_eq!("csv".parse::<OutputFormat>().unwrap(), OutputFormat::Csv);
         assert!("xml".parse::<OutputFormat>().is_err());
     }
 
@@ -273,47 +1242,58 @@ mod tests {
         assert_eq!(OutputFormat::Json.to_string(), "json");
         assert_eq!(OutputFormat::Table.to_string(), "table");
         assert_eq!(OutputFormat::Yaml.to_string(), "yaml");
+        assert_eq!(OutputFormat::Csv.to_string(), "csv");
+    }
+
+    #[test]
+    fn test_app_base_url() {
+        let mut cfg = make_cfg(Some("key"), Some("app"), None);
+        assert_eq!(cfg.app_base_url(), "https://app.datadoghq.com");
+        cfg.site = "us3.datadoghq.com".into();
+        assert_eq!(cfg.app_base_url(), "https://us3.datadoghq.com");
+        cfg.site = "datadoghq.eu".into();
+        assert_eq!(cfg.app_base_url(), "https://app.datadoghq.eu");
     }
 
     #[test]
     fn test_validate_api_and_app_keys_ok() {
-        let cfg = make_cfg(Some("key"), Some("app"), None);
+        let mut cfg = make_cfg(Some("key"), Some("app"), None);
         assert!(cfg.validate_api_and_app_keys().is_ok());
     }
 
     #[test]
     fn test_validate_api_and_app_keys_bearer_only_fails() {
-        let cfg = make_cfg(None, None, Some("token"));
+        let mut cfg = make_cfg(None, None, Some("token"));
         assert!(cfg.validate_api_and_app_keys().is_err());
     }
 
     #[test]
     fn test_validate_api_and_app_keys_missing_app_key_fails() {
-        let cfg = make_cfg(Some("key"), None, None);
+        let mut cfg = make_cfg(Some("key"), None, None);
         assert!(cfg.validate_api_and_app_keys().is_err());
     }
 
     #[test]
     fn test_validate_auth_api_keys() {
-        let cfg = make_cfg(Some("key"), Some("app"), None);
+        let mut cfg = make_cfg(Some("key"), Some("app"), None);
         assert!(cfg.validate_auth().is_ok());
     }
 
     #[test]
     fn test_validate_auth_bearer() {
-        let cfg = make_cfg(None, None, Some("token"));
+        let mut cfg = make_cfg(None, None, Some("token"));
         assert!(cfg.validate_auth().is_ok());
     }
 
     #[test]
     fn test_validate_auth_none() {
-        let cfg = make_cfg(None, None, None);
+        let mut cfg = make_cfg(None, None, None);
         assert!(cfg.validate_auth().is_err());
     }
 
     #[test]
     fn test_validate_auth_partial_keys() {
-        let cfg = make_cfg(Some("key"), None, None);
+        let mut cfg = make_cfg(Some("key"), None, None);
         assert!(cfg.validate_auth().is_err());
     }
 
@@ -471,6 +1451,108 @@ mod tests {
         std::env::remove_var("__PUP_TEST_ENV_OR__");
     }
 
+    #[test]
+    fn test_key_from_file_trims_contents() {
+        let _guard = ENV_LOCK.lock().unwrap_or_else(|p| p.into_inner());
+        let path = std::env::temp_dir().join("pup_test_api_key_file");
+        std::fs::write(&path, "abc123\n").unwrap();
+        std::env::set_var("__PUP_TEST_KEY_FILE__", &path);
+        let key = key_from_file("__PUP_TEST_KEY_FILE__", None);
+        std::env::remove_var("__PUP_TEST_KEY_FILE__");
+        std::fs::remove_file(&path).ok();
+        assert_eq!(key.unwrap(), Some("abc123".into()));
+    }
+
+    #[test]
+    fn test_key_from_file_config_fallback_and_errors() {
+        let path = std::env::temp_dir().join("pup_test_app_key_file");
+        std::fs::write(&path, "  \n").unwrap();
+        let empty = key_from_file("__PUP_TEST_KEY_FILE_UNSET__", path.to_str());
+        std::fs::remove_file(&path).ok();
+        assert!(empty.unwrap_err().to_string().contains("is empty"));
+
+        let missing = key_from_file("__PUP_TEST_KEY_FILE_UNSET__", Some("/nonexistent/pup/key"));
+        assert!(missing.is_err());
+        assert_eq!(
+            key_from_file("__PUP_TEST_KEY_FILE_UNSET__", None).unwrap(),
+            None
+        );
+    }
+
+    #[test]
+    fn test_parse_process_credentials() {
+        let creds = parse_process_credentials(r#"{"api_key": "a", "app_key": "b"}"#).unwrap();
+        assert_eq!(creds.api_key.as_deref(), Some("a"));
+        assert_eq!(creds.app_key.as_deref(), Some("b"));
+
+        let creds = parse_process_credentials(r#"{"DD_API_KEY": "a", "extra": 1}"#).unwrap();
+        assert_eq!(creds.api_key.as_deref(), Some("a"));
+        assert!(creds.app_key.is_none());
+
+        assert!(parse_process_credentials("not json").is_err());
+        assert!(parse_process_credentials(r#"{"api_key": ""}"#).is_err());
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn test_run_credential_process() {
+        let creds =
+            run_credential_process(r#"printf '{"api_key":"from-cmd","app_key":"app-cmd"}'"#)
+                .unwrap();
+        assert_eq!(creds.api_key.as_deref(), Some("from-cmd"));
+        assert_eq!(creds.app_key.as_deref(), Some("app-cmd"));
+        assert!(run_credential_process("exit 3").is_err());
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn test_resolve_credential_process_only_fills_missing_keys() {
+        let mut cfg = make_cfg(Some("env-key"), None, None);
+        cfg.credential_process =
+            Some(r#"printf '{"api_key":"from-cmd","app_key":"app-cmd"}'"#.into());
+        cfg.validate_auth().unwrap();
+        assert_eq!(cfg.api_key.as_deref(), Some("env-key"));
+        assert_eq!(cfg.app_key.as_deref(), Some("app-cmd"));
+        assert!(cfg.credential_process.is_none());
+
+        // Keys already complete: the command is dropped without running.
+        let mut cfg = make_cfg(Some("k"), Some("a"), None);
+        cfg.credential_process = Some("exit 3".into());
+        assert!(cfg.resolve_credential_process().is_ok());
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn test_load_defers_credential_process() {
+        let _guard = ENV_LOCK.lock().unwrap_or_else(|p| p.into_inner());
+        let dir = std::env::temp_dir().join("pup_test_load_deferred_process");
+        std::fs::create_dir_all(&dir).unwrap();
+        std::fs::write(dir.join("config.yaml"), "site: datadoghq.com\n").unwrap();
+        for var in [
+            "DD_API_KEY",
+            "DD_APP_KEY",
+            "DD_API_KEY_FILE",
+            "DD_APP_KEY_FILE",
+            "DD_ACCESS_TOKEN",
+            "PUP_PROFILE",
+        ] {
+            std::env::remove_var(var);
+        }
+        std::env::set_var("PUP_CONFIG_DIR", &dir);
+        std::env::set_var("PUP_CREDENTIAL_PROCESS", "exit 3");
+        let loaded = Config::load();
+        std::env::remove_var("PUP_CONFIG_DIR");
+        std::env::remove_var("PUP_CREDENTIAL_PROCESS");
+        std::fs::remove_dir_all(&dir).ok();
+
+        // A failing command doesn't fail loading; it fails once keys are needed.
+        let (mut cfg, sources) = loaded.unwrap();
+        assert_eq!(cfg.credential_process.as_deref(), Some("exit 3"));
+        let api = sources.iter().find(|s| s.setting == "api_key").unwrap();
+        assert_eq!(api.source, "env PUP_CREDENTIAL_PROCESS, run when needed");
+        assert!(cfg.validate_auth().is_err());
+    }
+
     #[test]
     fn test_env_or_empty_env_uses_fallback() {
         std::env::set_var("__PUP_TEST_ENV_EMPTY__", "");
@@ -480,4 +1562,195 @@ mod tests {
         );
         std::env::remove_var("__PUP_TEST_ENV_EMPTY__");
     }
+
+    #[test]
+    fn test_file_config_with_profile() {
+        let file_cfg: FileConfig = serde_yaml::from_str(
+            "site: datadoghq.com\napi_key: top\napp_key_file: /top/app\noutput: json\n\
+             profiles:\n  staging:\n    site: datadoghq.eu\n    app_key: stg-app\n    org: stg\n",
+        )
+        .unwrap();
+        let staging = file_cfg.with_profile("staging").unwrap();
+        assert_eq!(staging.site.as_deref(), Some("datadoghq.eu"));
+        assert_eq!(staging.org.as_deref(), Some("stg"));
+        assert_eq!(staging.output.as_deref(), Some("json"));
+        // Unset profile keys fall back to the top level.
+        assert_eq!(staging.api_key.as_deref(), Some("top"));
+        // A profile key replaces the top-level key file.
+        assert_eq!(staging.app_key.as_deref(), Some("stg-app"));
+        assert!(staging.app_key_file.is_none());
+
+        let file_cfg: FileConfig = serde_yaml::from_str("site: datadoghq.com\n").unwrap();
+        let err = file_cfg.with_profile("prod").err().unwrap().to_string();
+        assert!(err.contains("profile \"prod\" not found"), "{err}");
+    }
+
+    #[test]
+    fn test_lint_config_unknown_keys_and_values() {
+        let issues = lint_config(
+            "sitee: datadoghq.eu\noutput: xml\nauto_approve: yes\n\
+             profiles:\n  prod:\n    site: https://app.datadoghq.com\n    org_name: x\n",
+        );
+        let found: Vec<(&str, &str)> = issues.iter().map(|i| (i.level, i.key.as_str())).collect();
+        assert_eq!(
+            found,
+            vec![
+                ("error", "sitee"),
+                ("error", "output"),
+                ("error", "auto_approve"),
+                ("error", "profiles.prod.site"),
+                ("error", "profiles.prod.org_name"),
+            ]
+        );
+        assert!(
+            issues[0].message.contains("did you mean \"site\""),
+            "{:?}",
+            issues[0]
+        );
+
+        let issues = lint_config("site: datadoghq.euu\n");
+        assert_eq!(issues[0].level, "warning");
+        assert!(issues[0].message.contains("did you mean \"datadoghq.eu\""));
+
+        assert!(
+            lint_config("site: datadoghq.eu\ntime_format: iso\nmax_output_bytes: 100\n").is_empty()
+        );
+        assert!(lint_config("").is_empty());
+        assert!(lint_config("site: [unclosed\n")[0]
+            .message
+            .starts_with("invalid YAML"));
+    }
+
+    #[test]
+    fn test_lint_aliases() {
+        let commands: Vec<String> = ["monitors", "logs", "config"].map(String::from).to_vec();
+        let issues = lint_aliases(
+            "errs: logs search --query status:error\nmon: monitor list\nconfig: logs\nempty: \"\"\n",
+            &commands,
+        );
+        let found: Vec<(&str, &str)> = issues.iter().map(|i| (i.level, i.key.as_str())).collect();
+        assert_eq!(
+            found,
+            vec![("error", "mon"), ("warning", "config"), ("error", "empty")]
+        );
+        assert!(issues[0].message.contains("did you mean \"monitors\""));
+    }
+
+    #[test]
+    fn test_load_records_sources() {
+        let _guard = ENV_LOCK.lock().unwrap_or_else(|p| p.into_inner());
+        let dir = std::env::temp_dir().join("pup_test_load_sources");
+        std::fs::create_dir_all(&dir).unwrap();
+        let key_path = dir.join("app_key");
+        std::fs::write(&key_path, "profile-app-key\n").unwrap();
+        std::fs::write(
+            dir.join("config.yaml"),
+            format!(
+                "site: datadoghq.com\norg: top\napi_key: top-key\noutput: table\n\
+                 time_format: sometimes\n\
+                 profiles:\n  prod:\n    site: datadoghq.eu\n    app_key_file: {}\n",
+                key_path.display()
+            ),
+        )
+        .unwrap();
+        let unset = [
+            "DD_API_KEY",
+            "DD_APP_KEY",
+            "DD_API_KEY_FILE",
+            "DD_APP_KEY_FILE",
+            "DD_SITE",
+            "PUP_CREDENTIAL_PROCESS",
+            "PUP_TIME_FORMAT",
+            "PUP_MAX_OUTPUT_BYTES",
+            "DD_AUTO_APPROVE",
+            "DD_CLI_AUTO_APPROVE",
+            "PUP_TIMEOUT",
+            "PUP_CONNECT_TIMEOUT",
+        ];
+        let set = [
+            ("PUP_CONFIG_DIR", dir.to_str().unwrap()),
+            ("PUP_PROFILE", "prod"),
+            ("DD_ORG", "env-org"),
+            // Invalid, so the default applies rather than env or config.yaml.
+            ("DD_OUTPUT", "xml"),
+            ("DD_ACCESS_TOKEN", "env-token"),
+            ("DD_TOKEN_STORAGE", "file"),
+        ];
+        for var in unset {
+            std::env::remove_var(var);
+        }
+        for (var, value) in set {
+            std::env::set_var(var, value);
+        }
+        let loaded = Config::load();
+        for (var, _) in set {
+            std::env::remove_var(var);
+        }
+        std::fs::remove_dir_all(&dir).ok();
+
+        let (cfg, mut sources) = loaded.unwrap();
+        assert_eq!(cfg.site, "datadoghq.eu");
+        assert_eq!(cfg.app_key.as_deref(), Some("profile-app-key"));
+        assert_eq!(cfg.output_format, OutputFormat::Json);
+        apply_overrides(
+            &mut sources,
+            &[
+                ("org", "flag --org".to_string()),
+                ("access_token", "token storage (--site)".to_string()),
+                ("access_token", "token storage (--org)".to_string()),
+            ],
+        );
+        let source = |name: &str| {
+            sources
+                .iter()
+                .find(|s| s.setting == name)
+                .map(|s| s.source.as_str())
+                .unwrap()
+        };
+        assert_eq!(source("profile"), "env PUP_PROFILE");
+        assert_eq!(source("site"), "config.yaml (profiles.prod.site)");
+        assert_eq!(source("org"), "flag --org");
+        assert_eq!(source("api_key"), "config.yaml (api_key)");
+        assert_eq!(
+            source("app_key"),
+            "config.yaml (profiles.prod.app_key_file)"
+        );
+        assert_eq!(source("access_token"), "token storage (--org)");
+        assert_eq!(source("output"), "default");
+        assert_eq!(source("max_output_bytes"), "default");
+        assert_eq!(source("time_format"), "not set");
+        assert_eq!(source("credential_store"), "env DD_TOKEN_STORAGE");
+        assert_eq!(sources.last().unwrap().raw.as_deref(), Some("file"));
+    }
+
+    #[test]
+    fn test_normalize_site() {
+        assert_eq!(normalize_site("datadoghq.eu").unwrap(), "datadoghq.eu");
+        assert_eq!(
+            normalize_site("https://app.datadoghq.com/").unwrap(),
+            "datadoghq.com"
+        );
+        assert_eq!(
+            normalize_site("api.US5.datadoghq.com").unwrap(),
+            "us5.datadoghq.com"
+        );
+        assert!(normalize_site("").is_err());
+        assert!(normalize_site("https://app.datadoghq.com/dashboard/lists").is_err());
+    }
+
+    #[test]
+    fn test_parse_timeout() {
+        use std::time::Duration;
+        assert_eq!(parse_timeout("30s").unwrap(), Some(Duration::from_secs(30)));
+        assert_eq!(parse_timeout("45").unwrap(), Some(Duration::from_secs(45)));
+        assert_eq!(parse_timeout("2m").unwrap(), Some(Duration::from_secs(120)));
+        assert_eq!(
+            parse_timeout("500ms").unwrap(),
+            Some(Duration::from_millis(500))
+        );
+        assert_eq!(parse_timeout("0").unwrap(), None);
+        assert!(parse_timeout("soon").is_err());
+        assert!(parse_timeout("10d").is_err());
+        assert!(parse_timeout("").is_err());
+    }
 }
@@ -0,0 +1,178 @@
+//! Confirmation before destructive operations.
+//!
+//! Every delete, cancel, and disable goes through `Destructive::confirm` so
+//! they all behave the same way:
+//!
+//! - `--yes`, `DD_AUTO_APPROVE`, and agent mode approve without asking.
+//! - Without a terminal on stdin there is nobody to ask, so the command fails
+//!   rather than proceeding (or hanging on a pipe).
+//! - The prompt accepts `y` or `yes` in any case; anything else cancels.
+//! - High-risk resources use `typed()`: the user has to type the resource's
+//!   ID or name back instead of `yes`.
+
+use std::io::BufRead;
+
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+
+/// A destructive operation awaiting confirmation, e.g. "delete monitor 123".
+#[derive(Debug, Clone)]
+pub struct Destructive {
+    action: String,
+    resource: String,
+    id: String,
+    details: Vec<String>,
+    typed: bool,
+}
+
+impl Destructive {
+    pub fn new(action: &str, resource: &str, id: impl std::fmt::Display) -> Self {
+        Destructive {
+            action: action.to_string(),
+            resource: resource.to_string(),
+            id: id.to_string(),
+            details: Vec::new(),
+            typed: false,
+        }
+    }
+
+    /// A line printed above the prompt (what will be affected, why it matters).
+    pub fn detail(mut self, line: impl Into<String>) -> Self {
+        self.details.push(line.into());
+        self
+    }
+
+    /// Require typing the ID back instead of `yes`.
+    pub fn typed(mut self) -> Self {
+        self.typed = true;
+        self
+    }
+
+    fn target(&self) -> String {
+        if self.id.is_empty() {
+            self.resource.clone()
+        } else {
+            format!("{} {}", self.resource, self.id)
+        }
+    }
+
+    /// Ask on the terminal. Ok(false) means the user declined; the caller
+    /// should stop without making changes.
+    pub fn confirm(&self, cfg: &Config) -> Result<bool> {
+        use std::io::IsTerminal;
+        let interactive = std::io::stdin().is_terminal();
+        self.confirm_with(cfg, interactive, &mut std::io::stdin().lock())
+    }
+
+    fn confirm_with(
+        &self,
+        cfg: &Config,
+        interactive: bool,
+        input: &mut impl BufRead,
+    ) -> Result<bool> {
+        if cfg.auto_approve {
+            return Ok(true);
+        }
+        if !interactive {
+            bail!(
+                "refusing to {} {} without confirmation: stdin is not a terminal. \
+                 Re-run with --yes (or DD_AUTO_APPROVE=true) to approve",
+                self.action,
+                self.target()
+            );
+        }
+        for line in &self.details {
+            eprintln!("{line}");
+        }
+        if self.typed {
+            eprint!(
+                "This cannot be undone. Type {:?} to {} {}: ",
+                self.id,
+                self.action,
+                self.target()
+            );
+        } else {
+            eprint!("{} {}? [y/N]: ", capitalize(&self.action), self.target());
+        }
+        let _ = std::io::Write::flush(&mut std::io::stderr());
+        let mut answer = String::new();
+        input.read_line(&mut answer)?;
+        let approved = self.accepts(&answer);
+        if !approved {
+            eprintln!("Operation cancelled.");
+        }
+        Ok(approved)
+    }
+
+    fn accepts(&self, answer: &str) -> bool {
+        let answer = answer.trim();
+        if self.typed {
+            answer == self.id
+        } else {
+            answer.eq_ignore_ascii_case("y") || answer.eq_ignore_ascii_case("yes")
+        }
+    }
+}
+
+fn capitalize(s: &str) -> String {
+    let mut chars = s.chars();
+    match chars.next() {
+        Some(first) => first.to_uppercase().chain(chars).collect(),
+        None => String::new(),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn cfg(auto_approve: bool) -> Config {
+        Config {
+            auto_approve,
+            ..crate::test_utils::config()
+        }
+    }
+
+    fn answer(d: &Destructive, input: &str) -> Result<bool> {
+        d.confirm_with(&cfg(false), true, &mut input.as_bytes())
+    }
+
+    #[test]
+    fn test_accepts_y_and_yes_in_any_case() {
+        let d = Destructive::new("delete", "monitor", 123);
+        for input in ["y\n", "Y\n", "yes\n", " YES \n"] {
+            assert!(answer(&d, input).unwrap(), "{input:?}");
+        }
+        for input in ["\n", "n\n", "no\n", "yess\n"] {
+            assert!(!answer(&d, input).unwrap(), "{input:?}");
+        }
+    }
+
+    #[test]
+    fn test_typed_requires_the_id() {
+        let d = Destructive::new("delete", "API key", "abc-123").typed();
+        assert!(answer(&d, "abc-123\n").unwrap());
+        assert!(!answer(&d, "yes\n").unwrap());
+        assert!(!answer(&d, "ABC-123\n").unwrap());
+    }
+
+    #[test]
+    fn test_auto_approve_skips_prompt() {
+        let d = Destructive::new("delete", "monitor", 1).typed();
+        assert!(d
+            .confirm_with(&cfg(true), false, &mut "".as_bytes())
+            .unwrap());
+    }
+
+    #[test]
+    fn test_non_interactive_fails_closed() {
+        let d = Destructive::new("cancel", "downtime", "dt-1");
+        let err = d
+            .confirm_with(&cfg(false), false, &mut "yes\n".as_bytes())
+            .unwrap_err()
+            .to_string();
+        assert!(err.contains("refusing to cancel downtime dt-1"), "{err}");
+        assert!(err.contains("--yes"), "{err}");
+    }
+}
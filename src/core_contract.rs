@@ -0,0 +1,302 @@
+//! Agent-mode output contracts for pup's core read commands.
+//!
+//! Every agent-mode response is a `{status, data, metadata, warning}` envelope,
+//! but only the commands in `COVERED_COMMANDS` (list and get for monitors,
+//! dashboards, SLOs, and API and application keys) publish a JSON Schema for
+//! `data`. Their output is validated before printing so agents never receive a
+//! silently different structure. Every other command is passthrough:
+//! `--schema-out` describes its envelope with an unconstrained `data`.
+
+use anyhow::{bail, Result};
+use serde_json::{json, Value};
+use std::sync::OnceLock;
+
+static COMMAND: OnceLock<String> = OnceLock::new();
+
+/// Record the running command path (e.g. `monitors list`) so output printed
+/// without explicit metadata can still be checked against its contract.
+pub fn set_command(path: &str) {
+    let _ = COMMAND.set(path.to_string());
+}
+
+pub fn current_command() -> Option<&'static str> {
+    COMMAND.get().map(String::as_str)
+}
+
+/// An object schema requiring each `(field, type)` pair.
+fn object(fields: &[(&str, &str)]) -> Value {
+    let required: Vec<&str> = fields.iter().map(|(k, _)| *k).collect();
+    let properties: serde_json::Map<String, Value> = fields
+        .iter()
+        .map(|(k, t)| (k.to_string(), json!({"type": t})))
+        .collect();
+    json!({"type": "object", "required": required, "properties": properties})
+}
+
+fn list_of(item: Value) -> Value {
+    json!({"type": "array", "items": item})
+}
+
+/// Commands whose `data` has a published schema; everything else is passthrough.
+pub const COVERED_COMMANDS: &[&str] = &[
+    "monitors list",
+    "monitors get",
+    "dashboards list",
+    "dashboards get",
+    "slos list",
+    "slos get",
+    "api-keys list",
+    "api-keys get",
+    "app-keys list",
+    "app-keys get",
+];
+
+/// Schema for the envelope's `data` (after `data` hoisting) of a command in
+/// `COVERED_COMMANDS`.
+pub fn data_schema(command: &str) -> Option<Value> {
+    let monitor = object(&[("id", "integer"), ("name", "string")]);
+    let resource = object(&[("id", "string")]);
+    Some(match command {
+        "monitors list" => list_of(monitor),
+        "monitors get" => monitor,
+        "dashboards list" => json!({
+            "type": "object",
+            "required": ["dashboards"],
+            "properties": {
+                "dashboards": list_of(object(&[("id", "string"), ("title", "string")])),
+            },
+        }),
+        "dashboards get" => object(&[("id", "string"), ("title", "string"), ("widgets", "array")]),
+        "slos list" => list_of(object(&[("id", "string"), ("name", "string")])),
+        "slos get" => object(&[("id", "string"), ("name", "string")]),
+        "api-keys list" | "app-keys list" => list_of(resource),
+        "api-keys get" | "app-keys get" => resource,
+        _ => return None,
+    })
+}
+
+/// Full JSON Schema of the agent-mode envelope for `command`.
+pub fn envelope_schema(command: &str) -> Value {
+    let data = data_schema(command).unwrap_or_else(|| {
+        json!({
+            "description": "Only pup's core read commands publish a data contract; \
+                            this command's data is passed through as returned by the API."
+        })
+    });
+    let id = if command.is_empty() {
+        "pup".to_string()
+    } else {
+        command.replace(' ', "-")
+    };
+    json!({
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "$id": format!("pup://schemas/agent/{id}"),
+        "title": format!("pup {command} (agent mode)").replace("  ", " "),
+        "x-pup-version": crate::version::VERSION,
+        "x-pup-contract": if data_schema(command).is_some() { "stable" } else { "passthrough" },
+        "type": "object",
+        "required": ["status", "data"],
+        "properties": {
+            "status": {"const": "success"},
+            "data": data,
+            "metadata": {
+                "type": "object",
+                "properties": {
+                    "count": {"type": "integer"},
+                    "truncated": {"type": "boolean"},
+                    "command": {"type": "string"},
+                    "next_action": {"type": "string"},
+                },
+            },
+            "warning": {
+                "type": "object",
+                "required": ["code", "message", "max_output_bytes", "original_bytes"],
+                "properties": {
+                    "code": {"type": "string"},
+                    "message": {"type": "string"},
+                    "max_output_bytes": {"type": "integer"},
+                    "original_bytes": {"type": "integer"},
+                    "items_shown": {"type": "integer"},
+                    "items_total": {"type": "integer"},
+                    "next_offset": {"type": "integer"},
+                },
+            },
+        },
+    })
+}
+
+/// Fail when `data` doesn't match the published contract for `command`.
+/// Commands without a contract always pass.
+pub fn check(command: &str, data: &Value) -> Result<()> {
+    let Some(schema) = data_schema(command) else {
+        return Ok(());
+    };
+    if let Err(problem) = validate(&schema, data) {
+        bail!(
+            "agent output contract violation for 'pup {command}': {problem}\n\
+             The API response could not be normalized to the published schema \
+             (see 'pup {command} --schema-out'). Please report this with the pup version."
+        );
+    }
+    Ok(())
+}
+
+fn kind(v: &Value) -> &'static str {
+    match v {
+        Value::Null => "null",
+        Value::Bool(_) => "boolean",
+        Value::Number(n) if n.is_f64() => "number",
+        Value::Number(_) => "integer",
+        Value::String(_) => "string",
+        Value::Array(_) => "array",
+        Value::Object(_) => "object",
+    }
+}
+
+fn type_matches(t: &str, v: &Value) -> bool {
+    match t {
+        "object" => v.is_object(),
+        "array" => v.is_array(),
+        "string" => v.is_string(),
+        "integer" => v.is_i64() || v.is_u64(),
+        "number" => v.is_number(),
+        "boolean" => v.is_boolean(),
+        "null" => v.is_null(),
+        _ => true,
+    }
+}
+
+/// Check `value` against the JSON Schema subset used by the contracts (`type`,
+/// `const`, `enum`, `required`, `properties`, `items`). Returns the first
+/// mismatch as `data<pointer>: <problem>`.
+pub fn validate(schema: &Value, value: &Value) -> std::result::Result<(), String> {
+    validate_at(schema, value, "data")
+}
+
+fn validate_at(schema: &Value, value: &Value, path: &str) -> std::result::Result<(), String> {
+    let ok = match schema.get("type") {
+        Some(Value::String(t)) => type_matches(t, value),
+        Some(Value::Array(ts)) => ts
+            .iter()
+            .filter_map(|t| t.as_str())
+            .any(|t| type_matches(t, value)),
+        _ => true,
+    };
+    if !ok {
+        return Err(format!(
+            "{path}: expected {}, got {}",
+            schema["type"],
+            kind(value)
+        ));
+    }
+    if let Some(c) = schema.get("const") {
+        if c != value {
+            return Err(format!("{path}: expected {c}, got {value}"));
+        }
+    }
+    if let Some(Value::Array(options)) = schema.get("enum") {
+        if !options.contains(value) {
+            return Err(format!("{path}: {value} is not one of {options:?}"));
+        }
+    }
+    if let Value::Object(obj) = value {
+        let required = schema.get("required").and_then(|r| r.as_array());
+        for key in required.into_iter().flatten().filter_map(|k| k.as_str()) {
+            if !obj.contains_key(key) {
+                return Err(format!("{path}: missing required field {key:?}"));
+            }
+        }
+        if let Some(Value::Object(props)) = schema.get("properties") {
+            for (key, sub) in props {
+                if let Some(v) = obj.get(key) {
+                    validate_at(sub, v, &format!("{path}/{key}"))?;
+                }
+            }
+        }
+    }
+    if let (Value::Array(items), Some(item_schema)) = (value, schema.get("items")) {
+        for (i, v) in items.iter().enumerate() {
+            validate_at(item_schema, v, &format!("{path}/{i}"))?;
+        }
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_check_monitors_list() {
+        let ok = json!([{"id": 1, "name": "cpu", "extra": null}]);
+        assert!(check("monitors list", &ok).is_ok());
+        assert!(check("monitors list", &json!([])).is_ok());
+
+        let err = check("monitors list", &json!([{"id": "1", "name": "cpu"}]))
+            .unwrap_err()
+            .to_string();
+        assert!(
+            err.contains("data/0/id: expected \"integer\", got string"),
+            "{err}"
+        );
+
+        let err = check("monitors list", &json!({"monitors": []}))
+            .unwrap_err()
+            .to_string();
+        assert!(
+            err.contains("data: expected \"array\", got object"),
+            "{err}"
+        );
+
+        let err = check("monitors get", &json!({"id": 1}))
+            .unwrap_err()
+            .to_string();
+        assert!(err.contains("missing required field \"name\""), "{err}");
+    }
+
+    #[test]
+    fn test_covered_commands_have_schemas() {
+        for command in COVERED_COMMANDS {
+            assert!(data_schema(command).is_some(), "{command} has no schema");
+        }
+        assert!(data_schema("monitors search").is_none());
+    }
+
+    #[test]
+    fn test_check_passthrough_commands() {
+        assert!(data_schema("logs search").is_none());
+        assert!(check("logs search", &json!("anything")).is_ok());
+    }
+
+    #[test]
+    fn test_envelope_schema() {
+        let schema = envelope_schema("dashboards list");
+        assert_eq!(schema["$id"], "pup://schemas/agent/dashboards-list");
+        assert_eq!(schema["x-pup-contract"], "stable");
+        assert_eq!(
+            schema["properties"]["data"]["required"],
+            json!(["dashboards"])
+        );
+        let schema = envelope_schema("logs search");
+        assert_eq!(schema["x-pup-contract"], "passthrough");
+
+        // The envelope schema accepts a real envelope.
+        let envelope = json!({
+            "status": "success",
+            "data": {"dashboards": [{"id": "abc", "title": "t"}]},
+            "metadata": {"count": 1, "command": "dashboards list"}
+        });
+        assert!(validate(&envelope_schema("dashboards list"), &envelope).is_ok());
+        let bad = json!({"status": "error", "data": {}});
+        assert!(validate(&envelope_schema("logs search"), &bad).is_err());
+    }
+
+    #[test]
+    fn test_validate_type_union_and_enum() {
+        let schema = json!({"type": ["string", "null"], "enum": ["a", null]});
+        assert!(validate(&schema, &json!("a")).is_ok());
+        assert!(validate(&schema, &json!(null)).is_ok());
+        assert!(validate(&schema, &json!("b")).is_err());
+        assert!(validate(&schema, &json!(1)).is_err());
+    }
+}
@@ -0,0 +1,219 @@
+//! Process exit codes by failure class, so scripts can branch on why a command
+//! failed instead of matching stderr text.
+//!
+//! | Code | Meaning |
+//! |------|---------|
+//! | 0 | success |
+//! | 1 | any other error |
+//! | 2 | authentication or permission (missing credentials, HTTP 401/403) |
+//! | 3 | not found (HTTP 404, unknown `--name`) |
+//! | 4 | rate limited (HTTP 429) |
+//! | 5 | validation (usage errors, unparseable times, durations or IDs, HTTP 400/422) |
+//!
+//! `pup auth exec` is the exception: it exits with the wrapped command's code.
+
+pub const SUCCESS: i32 = 0;
+pub const GENERIC: i32 = 1;
+pub const AUTH: i32 = 2;
+pub const NOT_FOUND: i32 = 3;
+pub const RATE_LIMITED: i32 = 4;
+pub const VALIDATION: i32 = 5;
+
+/// The HTTP status in an API error, as formatted by either client:
+/// `API error (HTTP 404 Not Found)` or `ResponseContent { status: 404, .. }`.
+pub fn status_code(err: &str) -> Option<u16> {
+    ["HTTP ", "status: "].iter().find_map(|marker| {
+        err.match_indices(marker).find_map(|(i, _)| {
+            let code: String = err[i + marker.len()..]
+                .chars()
+                .take_while(|c| c.is_ascii_digit())
+                .collect();
+            code.parse().ok()
+        })
+    })
+}
+
+/// A failure whose class is known where it is raised, so `classify` doesn't
+/// have to infer it from the message.
+#[derive(Debug)]
+pub struct Failure {
+    code: i32,
+    message: String,
+}
+
+impl Failure {
+    /// Missing or rejected credentials, or a permission the caller lacks.
+    pub fn auth(message: impl Into<String>) -> anyhow::Error {
+        Self::new(AUTH, message)
+    }
+
+    /// A resource named on the command line doesn't exist.
+    pub fn not_found(message: impl Into<String>) -> anyhow::Error {
+        Self::new(NOT_FOUND, message)
+    }
+
+    /// A flag or argument value that can't be used.
+    pub fn validation(message: impl Into<String>) -> anyhow::Error {
+        Self::new(VALIDATION, message)
+    }
+
+    fn new(code: i32, message: impl Into<String>) -> anyhow::Error {
+        anyhow::Error::new(Self {
+            code,
+            message: message.into(),
+        })
+    }
+}
+
+impl std::fmt::Display for Failure {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        f.write_str(&self.message)
+    }
+}
+
+impl std::error::Error for Failure {}
+
+/// A command run by `pup auth exec` exited non-zero. pup exits with the same
+/// code, after the run log and stats footer are written.
+#[derive(Debug)]
+pub struct ChildExit(pub i32);
+
+impl std::fmt::Display for ChildExit {
+    fn fmt(&self, f: &mut std::fmt::Formatter<'_>) -> std::fmt::Result {
+        write!(f, "command exited with status {}", self.0)
+    }
+}
+
+impl std::error::Error for ChildExit {}
+
+fn for_status(status: u16) -> i32 {
+    match status {
+        401 | 403 => AUTH,
+        404 => NOT_FOUND,
+        429 => RATE_LIMITED,
+        400 | 422 => VALIDATION,
+        _ => GENERIC,
+    }
+}
+
+/// Exit code for an error returned by a command, from the typed errors in its
+/// chain: a `Failure`, a clap usage error, or a failed API request.
+pub fn classify(err: &anyhow::Error) -> i32 {
+    let typed = err.chain().find_map(|cause| {
+        if let Some(failure) = cause.downcast_ref::<Failure>() {
+            Some(failure.code)
+        } else if let Some(child) = cause.downcast_ref::<ChildExit>() {
+            Some(child.0)
+        } else if is_usage_error(cause) {
+            Some(VALIDATION)
+        } else {
+            crate::api::status_of(cause).map(for_status)
+        }
+    });
+    // The generated API client's errors reach us already formatted, so its
+    // `ResponseContent { status: .. }` is the one status read from text.
+    typed
+        .or_else(|| client_status(&format!("{err:?}")).map(for_status))
+        .unwrap_or(GENERIC)
+}
+
+#[cfg(not(feature = "browser"))]
+fn is_usage_error(err: &(dyn std::error::Error + 'static)) -> bool {
+    err.is::<clap::Error>()
+}
+
+#[cfg(feature = "browser")]
+fn is_usage_error(_err: &(dyn std::error::Error + 'static)) -> bool {
+    false
+}
+
+/// The HTTP status in a generated API client error's debug output.
+fn client_status(err: &str) -> Option<u16> {
+    let (_, rest) = err.split_once("ResponseContent { status: ")?;
+    let code: String = rest.chars().take_while(|c| c.is_ascii_digit()).collect();
+    code.parse().ok()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_classify_child_exit() {
+        let err = anyhow::Error::new(ChildExit(42));
+        assert_eq!(classify(&err), 42);
+        assert_eq!(err.to_string(), "command exited with status 42");
+    }
+
+    #[test]
+    fn test_status_code_both_client_formats() {
+        assert_eq!(status_code("API error (HTTP 404 Not Found): {}"), Some(404));
+        assert_eq!(
+            status_code("ResponseContent { status: 429, .. }"),
+            Some(429)
+        );
+        assert_eq!(status_code("connection refused"), None);
+    }
+
+    fn api_error(status: u16) -> anyhow::Error {
+        anyhow::Error::new(crate::api::ApiError {
+            status,
+            reason: String::new(),
+            body: "{}".into(),
+            reset: None,
+        })
+    }
+
+    #[test]
+    fn test_classify_api_errors() {
+        let cases = [
+            (401, AUTH),
+            (403, AUTH),
+            (404, NOT_FOUND),
+            (429, RATE_LIMITED),
+            (400, VALIDATION),
+            (503, GENERIC),
+        ];
+        for (status, code) in cases {
+            assert_eq!(classify(&api_error(status)), code, "{status}");
+            let described = crate::api::failed("failed to get monitor", api_error(status));
+            assert_eq!(classify(&described), code, "described {status}");
+        }
+        // Status-like text in a message is not a status.
+        assert_eq!(
+            classify(&anyhow::anyhow!("API error (HTTP 404 Not Found): {{}}")),
+            GENERIC
+        );
+    }
+
+    #[test]
+    fn test_classify_generated_client_errors() {
+        let err = anyhow::anyhow!(
+            "failed to get monitor: ResponseError(ResponseContent {{ status: 404, content: \"\" }})"
+        );
+        assert_eq!(classify(&err), NOT_FOUND);
+    }
+
+    #[test]
+    fn test_classify_local_errors() {
+        assert_eq!(classify(&Failure::auth("authentication required")), AUTH);
+        assert_eq!(
+            classify(&Failure::not_found("no monitor named \"checkout\"")),
+            NOT_FOUND
+        );
+        assert_eq!(
+            classify(
+                &Failure::validation("unable to parse duration: \"5x\"").context("--wait-timeout")
+            ),
+            VALIDATION
+        );
+        let usage = clap::Command::new("pup")
+            .try_get_matches_from(["pup", "--no-such-flag"])
+            .unwrap_err();
+        assert_eq!(classify(&usage.into()), VALIDATION);
+        assert_eq!(
+            classify(&anyhow::anyhow!("invalid duration: \"5x\"")),
+            GENERIC
+        );
+    }
+}
@@ -1,7 +1,13 @@
 use anyhow::Result;
 use serde::Serialize;
 
-use crate::config::OutputFormat;
+use crate::config::{Config, OutputFormat, TimeFormat};
+
+/// Default cap on rendered JSON output (agent and JSON modes): 10 MB.
+pub const DEFAULT_MAX_OUTPUT_BYTES: usize = 10 * 1024 * 1024;
+
+/// Space kept free for the warning object when shrinking oversized output.
+const TRUNCATION_RESERVE_BYTES: usize = 1024;
 
 /// Agent mode metadata envelope.
 #[derive(Serialize)]
@@ -16,17 +22,36 @@ pub struct Metadata {
     pub next_action: Option<String>,
 }
 
-/// Agent mode wrapper: { status, data, metadata }
+/// Emitted when output exceeds `--max-output-bytes` and list items were dropped.
+#[derive(Serialize, Debug)]
+pub struct OutputWarning {
+    pub code: &'static str,
+    pub message: String,
+    pub max_output_bytes: usize,
+    pub original_bytes: usize,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub items_shown: Option<usize>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub items_total: Option<usize>,
+    /// Where to continue. The API's own page cursor points past the whole
+    /// fetched page, so following it would skip the dropped items.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub next_offset: Option<usize>,
+}
+
+/// Agent mode wrapper: { status, data, metadata, warning }
 #[derive(Serialize)]
 struct AgentEnvelope<'a, T: Serialize> {
     status: &'static str,
     data: &'a T,
     #[serde(skip_serializing_if = "Option::is_none")]
     metadata: Option<&'a Metadata>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    warning: Option<&'a OutputWarning>,
 }
 
 /// Recursively sort all JSON object keys alphabetically.
-fn sort_json_value(v: serde_json::Value) -> serde_json::Value {
+pub fn sort_json_value(v: serde_json::Value) -> serde_json::Value {
     match v {
         serde_json::Value::Object(map) => {
             let mut sorted: std::collections::BTreeMap<String, serde_json::Value> =
@@ -43,6 +68,17 @@ fn sort_json_value(v: serde_json::Value) -> serde_json::Value {
     }
 }
 
+/// Run a `--jq` filter over `value`. A single result is printed as-is; a
+/// stream of results (e.g. from `.[] | {id, name}`) is collected into an array.
+fn apply_jq(filter: &str, value: &serde_json::Value) -> Result<serde_json::Value> {
+    let mut results = crate::jq::run(filter, value)?;
+    Ok(if results.len() == 1 {
+        results.remove(0)
+    } else {
+        serde_json::Value::Array(results)
+    })
+}
+
 /// Go's encoding/json escapes <, >, and & for HTML safety.
 /// Apply the same escaping to match Go output exactly.
 fn go_html_escape(json: &str) -> String {
@@ -51,14 +87,52 @@ fn go_html_escape(json: &str) -> String {
         .replace('>', "\\u003e")
 }
 
-/// Format and print data to stdout.
+/// Format and print data to stdout using config settings (-o flag, agent mode,
+/// --max-output-bytes).
 pub fn format_and_print<T: Serialize>(
     data: &T,
-    format: &OutputFormat,
-    agent_mode: bool,
+    cfg: &Config,
     meta: Option<&Metadata>,
 ) -> Result<()> {
-    if agent_mode {
+    // Agents rely on the published output contract; check the unfiltered data
+    // (before --jq) so a changed upstream shape fails loudly instead of leaking.
+    if cfg.agent_mode {
+        let command = meta
+            .and_then(|m| m.command.as_deref())
+            .or_else(crate::core_contract::current_command);
+        if let Some(command) = command {
+            let value = serde_json::to_value(data)?;
+            let hoisted = match &value {
+                serde_json::Value::Object(obj) if obj.contains_key("data") => &obj["data"],
+                _ => &value,
+            };
+            crate::core_contract::check(command, hoisted)?;
+        }
+    }
+
+    if let Some(filter) = &cfg.jq {
+        let mut value = serde_json::to_value(data)?;
+        // Agents see the hoisted `data` payload, so that is what the filter runs on.
+        if cfg.agent_mode {
+            if let Some(inner) = value.as_object_mut().and_then(|obj| obj.remove("data")) {
+                value = inner;
+            }
+        }
+        let filtered = apply_jq(filter, &value)?;
+        // The original item count no longer describes the filtered output.
+        let meta = meta.map(|m| Metadata {
+            count: None,
+            truncated: m.truncated,
+            command: m.command.clone(),
+            next_action: m.next_action.clone(),
+        });
+        return render(&filtered, cfg, meta.as_ref());
+    }
+    render(data, cfg, meta)
+}
+
+fn render<T: Serialize>(data: &T, cfg: &Config, meta: Option<&Metadata>) -> Result<()> {
+    if cfg.agent_mode {
         let sorted_data = sort_json_value(serde_json::to_value(data)?);
         // Hoist: when the API wraps its list/object in a nested "data" key,
         // use that inner value directly so agents see .data[*] instead of .data.data[*].
@@ -66,26 +140,145 @@ pub fn format_and_print<T: Serialize>(
             serde_json::Value::Object(obj) if obj.contains_key("data") => obj["data"].clone(),
             _ => sorted_data.clone(),
         };
+        let mut warning = None;
+        let effective_data = match shrink_to_fit(&effective_data, cfg.max_output_bytes) {
+            Some((shrunk, w)) => {
+                warning = Some(w);
+                shrunk
+            }
+            None => effective_data,
+        };
+        let truncated_meta = warning.as_ref().map(|w| Metadata {
+            count: w.items_shown,
+            truncated: true,
+            command: meta.and_then(|m| m.command.clone()),
+            next_action: Some(w.message.clone()),
+        });
         let envelope = AgentEnvelope {
             status: "success",
             data: &effective_data,
-            metadata: meta,
+            metadata: truncated_meta.as_ref().or(meta),
+            warning: warning.as_ref(),
         };
         let json = go_html_escape(&serde_json::to_string_pretty(&envelope)?);
         println!("{json}");
         return Ok(());
     }
 
-    match format {
-        OutputFormat::Json => print_json(data),
-        OutputFormat::Yaml => print_yaml(data),
-        OutputFormat::Table => print_table(data),
+    match cfg.output_format {
+        OutputFormat::Json => print_json(&shrink_for_terminal(data, cfg.max_output_bytes)?),
+        OutputFormat::Yaml => print_yaml(&shrink_for_terminal(data, cfg.max_output_bytes)?),
+        OutputFormat::Table => print_table(data, cfg.time_format),
+        OutputFormat::Csv => {
+            print!("{}", to_csv(&serde_json::to_value(data)?));
+            Ok(())
+        }
     }
 }
 
 /// Convenience: format and print using config settings (respects -o flag and agent mode).
-pub fn output<T: Serialize>(cfg: &crate::config::Config, data: &T) -> Result<()> {
-    format_and_print(data, &cfg.output_format, cfg.agent_mode, None)
+pub fn output<T: Serialize>(cfg: &Config, data: &T) -> Result<()> {
+    format_and_print(data, cfg, None)
+}
+
+/// `-o json`/`-o yaml` output: drop list items over `max_bytes` with the
+/// warning on stderr. A value with no list to shrink is printed in full.
+fn shrink_for_terminal<T: Serialize>(data: &T, max_bytes: usize) -> Result<serde_json::Value> {
+    let sorted_data = sort_json_value(serde_json::to_value(data)?);
+    let Some((shrunk, warning)) = shrink_to_fit(&sorted_data, max_bytes) else {
+        return Ok(sorted_data);
+    };
+    eprintln!("{}", serde_json::to_string(&warning)?);
+    Ok(if warning.items_shown.is_some() {
+        shrunk
+    } else {
+        sorted_data
+    })
+}
+
+/// When the pretty-printed value exceeds `max_bytes`, drop trailing items from
+/// its list (a top-level array or a "data" array) until it fits. Returns None
+/// when the value already fits or the limit is disabled (0). A value with no
+/// list to shrink comes back as null, which only agent mode uses.
+fn shrink_to_fit(
+    value: &serde_json::Value,
+    max_bytes: usize,
+) -> Option<(serde_json::Value, OutputWarning)> {
+    if max_bytes == 0 {
+        return None;
+    }
+    let original_bytes = serde_json::to_string_pretty(value).ok()?.len();
+    if original_bytes <= max_bytes {
+        return None;
+    }
+
+    let items = match value {
+        serde_json::Value::Array(arr) => Some(arr),
+        serde_json::Value::Object(obj) => obj.get("data").and_then(|d| d.as_array()),
+        _ => None,
+    };
+    let with_items = |n: usize| -> serde_json::Value {
+        match value {
+            serde_json::Value::Array(arr) => serde_json::Value::Array(arr[..n].to_vec()),
+            _ => {
+                let mut v = value.clone();
+                if let Some(serde_json::Value::Array(arr)) = v.get_mut("data") {
+                    arr.truncate(n);
+                }
+                v
+            }
+        }
+    };
+
+    let budget = max_bytes.saturating_sub(TRUNCATION_RESERVE_BYTES);
+    let Some(items) = items else {
+        let warning = OutputWarning {
+            code: "output_truncated",
+            message: format!(
+                "Output of {original_bytes} bytes exceeds --max-output-bytes={max_bytes} and \
+                 has no list to truncate; narrow the query or raise --max-output-bytes"
+            ),
+            max_output_bytes: max_bytes,
+            original_bytes,
+            items_shown: None,
+            items_total: None,
+            next_offset: None,
+        };
+        return Some((serde_json::Value::Null, warning));
+    };
+
+    // Binary search the largest prefix whose rendering fits the budget.
+    let fits = |n: usize| {
+        serde_json::to_string_pretty(&with_items(n))
+            .map(|s| s.len() <= budget)
+            .unwrap_or(false)
+    };
+    let (mut lo, mut hi) = (0, items.len());
+    while lo < hi {
+        let mid = (lo + hi + 1) / 2;
+        if fits(mid) {
+            lo = mid;
+        } else {
+            hi = mid - 1;
+        }
+    }
+    let shown = lo;
+    let total = items.len();
+    let warning = OutputWarning {
+        code: "output_truncated",
+        message: format!(
+            "Output truncated to {shown} of {total} items to stay under \
+             --max-output-bytes={max_bytes}; continue from next_offset={shown} with a \
+             smaller --limit or a narrower query (the API's page cursor would skip the \
+             dropped items)"
+        ),
+        max_output_bytes: max_bytes,
+        original_bytes,
+        items_shown: Some(shown),
+        items_total: Some(total),
+        next_offset: Some(shown),
+    };
+    Some((with_items(shown), warning))
 }
 
 pub fn print_json<T: Serialize>(data: &T) -> Result<()> {
@@ -95,10 +288,15 @@ pub fn print_json<T: Serialize>(data: &T) -> Result<()> {
     Ok(())
 }
 
-fn print_yaml<T: Serialize>(data: &T) -> Result<()> {
+/// Serialize to YAML with keys sorted at every level, so output is stable
+/// across runs and can be diffed or committed.
+pub fn to_yaml<T: Serialize>(data: &T) -> Result<String> {
     let sorted_data = sort_json_value(serde_json::to_value(data)?);
-    let yaml = serde_yaml::to_string(&sorted_data)?;
-    print!("{yaml}");
+    Ok(serde_yaml::to_string(&sorted_data)?)
+}
+
+fn print_yaml<T: Serialize>(data: &T) -> Result<()> {
+    print!("{}", to_yaml(data)?);
     Ok(())
 }
 
@@ -129,7 +327,122 @@ fn flatten_row(value: &serde_json::Value) -> serde_json::Value {
     }
 }
 
-fn print_table<T: Serialize>(data: &T) -> Result<()> {
+/// Quote a CSV field when it contains a delimiter, quote, or newline.
+pub fn csv_field(v: &serde_json::Value) -> String {
+    csv_escape(&csv_text(v))
+}
+
+/// Escape one CSV cell. Text a spreadsheet would evaluate as a formula
+/// (leading `=`, `+`, `-`, or `@`) is prefixed with `'` so opening an export
+/// can't run attacker-controlled content, e.g. a monitor name. Plain numbers
+/// such as `-5` are left alone.
+fn csv_escape(s: &str) -> String {
+    let formula = s.starts_with(['=', '+', '-', '@']) && s.parse::<f64>().is_err();
+    let s = if formula {
+        format!("'{s}")
+    } else {
+        s.to_string()
+    };
+    if s.contains([',', '"', '\n', '\r']) {
+        format!("\"{}\"", s.replace('"', "\"\""))
+    } else {
+        s
+    }
+}
+
+/// Rows for CSV output: like `extract_rows`, but a wrapper object whose only
+/// list is an array of objects (e.g. `{"host_list": [...], "total_returned": 3}`)
+/// yields that list.
+fn csv_rows(value: &serde_json::Value) -> Vec<&serde_json::Value> {
+    if let serde_json::Value::Object(map) = value {
+        if !map.contains_key("data") {
+            let lists: Vec<&serde_json::Value> = map
+                .values()
+                .filter(|v| {
+                    v.as_array()
+                        .is_some_and(|a| a.iter().any(|i| i.is_object()))
+                })
+                .collect();
+            if let [list] = lists[..] {
+                return extract_rows(list);
+            }
+        }
+    }
+    extract_rows(value)
+}
+
+/// Flatten nested objects at any depth into dot-notation columns. Arrays of
+/// scalars are joined with `;`; other arrays are kept as compact JSON.
+fn flatten_csv(prefix: &str, value: &serde_json::Value, out: &mut Vec<(String, String)>) {
+    match value {
+        serde_json::Value::Object(map) if !map.is_empty() || prefix.is_empty() => {
+            for (k, v) in map {
+                let key = if prefix.is_empty() {
+                    k.clone()
+                } else {
+                    format!("{prefix}.{k}")
+                };
+                flatten_csv(&key, v, out);
+            }
+        }
+        serde_json::Value::Array(items)
+            if items.iter().all(|i| !i.is_object() && !i.is_array()) =>
+        {
+            let joined: Vec<String> = items.iter().map(csv_text).collect();
+            out.push((prefix.to_string(), joined.join(";")));
+        }
+        other => {
+            let key = if prefix.is_empty() { "value" } else { prefix };
+            out.push((key.to_string(), csv_text(other)));
+        }
+    }
+}
+
+fn csv_text(v: &serde_json::Value) -> String {
+    match v {
+        serde_json::Value::String(s) => s.clone(),
+        serde_json::Value::Null => String::new(),
+        other => other.to_string(),
+    }
+}
+
+/// Render list responses as CSV: one row per item, a header line, and columns
+/// that are the union of flattened keys in first-seen order.
+pub fn to_csv(value: &serde_json::Value) -> String {
+    let rows: Vec<Vec<(String, String)>> = csv_rows(value)
+        .into_iter()
+        .map(|r| {
+            let mut cells = Vec::new();
+            flatten_csv("", r, &mut cells);
+            cells
+        })
+        .collect();
+    let mut headers: Vec<&str> = Vec::new();
+    let mut seen = std::collections::HashSet::new();
+    for (key, _) in rows.iter().flatten() {
+        if seen.insert(key.as_str()) {
+            headers.push(key);
+        }
+    }
+    if headers.is_empty() {
+        return String::new();
+    }
+    let mut out = headers.join(",");
+    out.push('\n');
+    for row in &rows {
+        let cells: std::collections::HashMap<&str, &str> =
+            row.iter().map(|(k, v)| (k.as_str(), v.as_str())).collect();
+        let line: Vec<String> = headers
+            .iter()
+            .map(|h| csv_escape(cells.get(h).copied().unwrap_or("")))
+            .collect();
+        out.push_str(&line.join(","));
+        out.push('\n');
+    }
+    out
+}
+
+fn print_table<T: Serialize>(data: &T, time_format: Option<TimeFormat>) -> Result<()> {
     // Convert to serde_json::Value to inspect structure
     let value = serde_json::to_value(data)?;
     let raw_rows = extract_rows(&value);
@@ -196,7 +509,11 @@ fn print_table<T: Serialize>(data: &T) -> Result<()> {
             .iter()
             .map(|h| {
                 if let serde_json::Value::Object(map) = row {
-                    format_cell(map.get(h.as_str()))
+                    let value = map.get(h.as_str());
+                    time_format
+                        .filter(|_| is_time_column(h))
+                        .and_then(|tf| format_timestamp(value?, tf))
+                        .unwrap_or_else(|| format_cell(value))
                 } else {
                     String::new()
                 }
@@ -274,6 +591,120 @@ fn format_cell(value: Option<&serde_json::Value>) -> String {
     }
 }
 
+/// Whether a (possibly flattened) column name holds a timestamp.
+/// Matches the naming used across v1/v2 APIs: created_at, date_happened,
+/// attributes.modified, last_triggered_ts, customer_impact_start, etc.
+fn is_time_column(key: &str) -> bool {
+    let field = key.rsplit('.').next().unwrap_or(key).to_lowercase();
+    matches!(
+        field.as_str(),
+        "created"
+            | "modified"
+            | "deleted"
+            | "resolved"
+            | "detected"
+            | "declared"
+            | "timestamp"
+            | "time"
+            | "date"
+            | "start"
+            | "end"
+            | "date_happened"
+    ) || [
+        "_at",
+        "_ts",
+        "_time",
+        "_timestamp",
+        "_date",
+        "_modified",
+        "_start",
+        "_end",
+    ]
+    .iter()
+    .any(|suffix| field.ends_with(suffix))
+}
+
+/// Parse a timestamp cell: RFC 3339 strings, or epoch numbers in seconds,
+/// milliseconds, microseconds or nanoseconds (guessed from magnitude).
+#[cfg(not(feature = "browser"))]
+fn parse_timestamp(value: &serde_json::Value) -> Option<chrono::DateTime<chrono::Utc>> {
+    use chrono::{DateTime, NaiveDateTime, TimeZone, Utc};
+    match value {
+        serde_json::Value::String(s) => DateTime::parse_from_rfc3339(s)
+            .map(|dt| dt.with_timezone(&Utc))
+            .ok()
+            .or_else(|| {
+                NaiveDateTime::parse_from_str(s, "%Y-%m-%dT%H:%M:%S%.f")
+                    .ok()
+                    .map(|dt| dt.and_utc())
+            }),
+        serde_json::Value::Number(n) => {
+            let v = n.as_f64()?;
+            if v <= 0.0 {
+                return None;
+            }
+            let nanos = if v >= 1e17 {
+                v
+            } else if v >= 1e14 {
+                v * 1e3
+            } else if v >= 1e11 {
+                v * 1e6
+            } else {
+                v * 1e9
+            };
+            Some(Utc.timestamp_nanos(nanos as i64))
+        }
+        _ => None,
+    }
+}
+
+/// Render a timestamp cell per --time-format. Returns None when the value is not
+/// a recognizable timestamp so the caller falls back to the raw rendering.
+#[cfg(not(feature = "browser"))]
+fn format_timestamp(value: &serde_json::Value, time_format: TimeFormat) -> Option<String> {
+    let dt = parse_timestamp(value)?;
+    Some(match time_format {
+        TimeFormat::Iso => dt.to_rfc3339_opts(chrono::SecondsFormat::Secs, true),
+        TimeFormat::Epoch => dt.timestamp().to_string(),
+        TimeFormat::Local => dt
+            .with_timezone(&chrono::Local)
+            .format("%Y-%m-%d %H:%M:%S %:z")
+            .to_string(),
+        TimeFormat::Relative => format_relative(dt, chrono::Utc::now()),
+    })
+}
+
+#[cfg(feature = "browser")]
+fn format_timestamp(_value: &serde_json::Value, _time_format: TimeFormat) -> Option<String> {
+    None
+}
+
+/// Human-friendly distance between `dt` and `now`, e.g. "5m ago" or "in 2h".
+#[cfg(not(feature = "browser"))]
+fn format_relative(
+    dt: chrono::DateTime<chrono::Utc>,
+    now: chrono::DateTime<chrono::Utc>,
+) -> String {
+    let secs = (now - dt).num_seconds();
+    let abs = secs.unsigned_abs();
+    if abs < 5 {
+        return "just now".to_string();
+    }
+    let span = match abs {
+        0..=59 => format!("{abs}s"),
+        60..=3_599 => format!("{}m", abs / 60),
+        3_600..=86_399 => format!("{}h", abs / 3_600),
+        86_400..=2_591_999 => format!("{}d", abs / 86_400),
+        2_592_000..=31_535_999 => format!("{}mo", abs / 2_592_000),
+        _ => format!("{}y", abs / 31_536_000),
+    };
+    if secs >= 0 {
+        format!("{span} ago")
+    } else {
+        format!("in {span}")
+    }
+}
+
 /// Format an API error with contextual guidance.
 #[allow(dead_code)]
 pub fn format_api_error(operation: &str, status: Option<u16>, body: Option<&str>) -> String {
@@ -311,6 +742,14 @@ pub fn format_api_error(operation: &str, status: Option<u16>, body: Option<&str>
 mod tests {
     use super::*;
 
+    fn test_cfg(output_format: OutputFormat, agent_mode: bool) -> Config {
+        Config {
+            output_format,
+            agent_mode,
+            ..crate::test_utils::config()
+        }
+    }
+
     #[test]
     fn test_format_cell_string() {
         assert_eq!(format_cell(Some(&serde_json::json!("hello"))), "hello");
@@ -614,6 +1053,73 @@ mod tests {
         assert_eq!(go_html_escape("<div>"), r"\u003cdiv\u003e");
     }
 
+    #[test]
+    fn test_to_csv_rows_and_quoting() {
+        let data = serde_json::json!({"data": [
+            {"id": "a", "attributes": {"name": "web, api"}},
+            {"id": "b", "attributes": {"note": "say \"hi\""}}
+        ]});
+        assert_eq!(
+            to_csv(&data),
+            "id,attributes.name,attributes.note\na,\"web, api\",\nb,,\"say \"\"hi\"\"\"\n"
+        );
+        assert_eq!(to_csv(&serde_json::json!([])), "");
+    }
+
+    #[test]
+    fn test_csv_escape_guards_formulas() {
+        assert_eq!(
+            csv_escape("=HYPERLINK(\"x\")"),
+            "\"'=HYPERLINK(\"\"x\"\")\""
+        );
+        assert_eq!(csv_escape("+cmd"), "'+cmd");
+        assert_eq!(
+            csv_escape("-2+3+cmd|' /C calc'!A0"),
+            "'-2+3+cmd|' /C calc'!A0"
+        );
+        assert_eq!(csv_escape("@SUM(A1)"), "'@SUM(A1)");
+        assert_eq!(csv_escape("-5"), "-5");
+        assert_eq!(csv_escape("-0.25"), "-0.25");
+        assert_eq!(csv_escape("a=b"), "a=b");
+    }
+
+    #[test]
+    fn test_to_csv_flattens_nested_attributes() {
+        let findings = serde_json::json!({"data": [{
+            "id": "f1",
+            "attributes": {
+                "rule": {"name": "Open bucket", "severity": {"level": "high"}},
+                "tags": ["env:prod", "team:sec"],
+                "resources": [{"id": "r1"}]
+            }
+        }]});
+        assert_eq!(
+            to_csv(&findings),
+            "id,attributes.rule.name,attributes.rule.severity.level,attributes.tags,attributes.resources\n\
+             f1,Open bucket,high,env:prod;team:sec,\"[{\"\"id\"\":\"\"r1\"\"}]\"\n"
+        );
+    }
+
+    #[test]
+    fn test_to_csv_list_shapes() {
+        // Monitors: a bare array.
+        let monitors = serde_json::json!([{"id": 1, "name": "CPU", "options": {"thresholds": {"critical": 90}}}]);
+        assert_eq!(
+            to_csv(&monitors),
+            "id,name,options.thresholds.critical\n1,CPU,90\n"
+        );
+        // Hosts: the list sits beside counters in a wrapper object.
+        let hosts = serde_json::json!({
+            "host_list": [{"name": "web-1", "meta": {"platform": "linux"}}, {"name": "web-2"}],
+            "total_matching": 2,
+            "total_returned": 2
+        });
+        assert_eq!(to_csv(&hosts), "name,meta.platform\nweb-1,linux\nweb-2,\n");
+        // A single object becomes one row.
+        let user = serde_json::json!({"data": {"id": "u1", "attributes": {"email": "a@b.c"}}});
+        assert_eq!(to_csv(&user), "id,attributes.email\nu1,a@b.c\n");
+    }
+
     #[test]
     fn test_go_html_escape_no_change() {
         assert_eq!(go_html_escape("hello world"), "hello world");
@@ -630,21 +1136,38 @@ mod tests {
     #[test]
     fn test_format_and_print_json() {
         let data = serde_json::json!({"name": "test"});
-        let result = format_and_print(&data, &OutputFormat::Json, false, None);
+        let result = format_and_print(&data, &test_cfg(OutputFormat::Json, false), None);
         assert!(result.is_ok());
     }
 
     #[test]
     fn test_format_and_print_yaml() {
         let data = serde_json::json!({"name": "test"});
-        let result = format_and_print(&data, &OutputFormat::Yaml, false, None);
+        let result = format_and_print(&data, &test_cfg(OutputFormat::Yaml, false), None);
         assert!(result.is_ok());
     }
 
+    #[test]
+    fn test_to_yaml_sorted_and_nested() {
+        let data = serde_json::json!({
+            "name": "cpu",
+            "id": 1,
+            "options": {"thresholds": {"critical": 90}, "notify_no_data": false},
+            "tags": ["env:prod", "team:core"]
+        });
+        let yaml = to_yaml(&data).unwrap();
+        assert_eq!(
+            yaml,
+            "id: 1\nname: cpu\noptions:\n  notify_no_data: false\n  thresholds:\n    critical: 90\ntags:\n- env:prod\n- team:core\n"
+        );
+        let parsed: serde_json::Value = serde_yaml::from_str(&yaml).unwrap();
+        assert_eq!(parsed, data);
+    }
+
     #[test]
     fn test_format_and_print_table() {
         let data = serde_json::json!([{"id": 1, "name": "test"}]);
-        let result = format_and_print(&data, &OutputFormat::Table, false, None);
+        let result = format_and_print(&data, &test_cfg(OutputFormat::Table, false), None);
         assert!(result.is_ok());
     }
 
@@ -657,17 +1180,62 @@ mod tests {
             command: Some("test".into()),
             next_action: None,
         };
-        let result = format_and_print(&data, &OutputFormat::Json, true, Some(&meta));
+        let result = format_and_print(&data, &test_cfg(OutputFormat::Json, true), Some(&meta));
         assert!(result.is_ok());
     }
 
+    #[test]
+    fn test_format_and_print_agent_mode_contract_violation() {
+        let meta = Metadata {
+            count: None,
+            truncated: false,
+            command: Some("monitors get".into()),
+            next_action: None,
+        };
+        let cfg = test_cfg(OutputFormat::Json, true);
+        let ok = serde_json::json!({"id": 1, "name": "cpu"});
+        assert!(format_and_print(&ok, &cfg, Some(&meta)).is_ok());
+        let bad = serde_json::json!({"id": "1", "name": "cpu"});
+        assert!(format_and_print(&bad, &cfg, Some(&meta)).is_err());
+        // Only agent mode enforces the contract.
+        let cfg = test_cfg(OutputFormat::Json, false);
+        assert!(format_and_print(&bad, &cfg, Some(&meta)).is_ok());
+    }
+
     #[test]
     fn test_format_and_print_agent_mode_no_meta() {
         let data = serde_json::json!({"name": "test"});
-        let result = format_and_print(&data, &OutputFormat::Json, true, None);
+        let result = format_and_print(&data, &test_cfg(OutputFormat::Json, true), None);
         assert!(result.is_ok());
     }
 
+    #[test]
+    fn test_apply_jq_collects_streams() {
+        let data = serde_json::json!([{"id": 1, "name": "a"}, {"id": 2, "name": "b"}]);
+        assert_eq!(apply_jq("length", &data).unwrap(), serde_json::json!(2));
+        assert_eq!(
+            apply_jq(".[] | {id}", &data).unwrap(),
+            serde_json::json!([{"id": 1}, {"id": 2}])
+        );
+        assert_eq!(
+            apply_jq(".[] | select(.id > 5)", &data).unwrap(),
+            serde_json::json!([])
+        );
+    }
+
+    #[test]
+    fn test_format_and_print_with_jq() {
+        let data = serde_json::json!({"data": [{"id": 1, "name": "test"}]});
+        let mut cfg = test_cfg(OutputFormat::Table, false);
+        cfg.jq = Some(".data[] | {id, name}".into());
+        assert!(format_and_print(&data, &cfg, None).is_ok());
+        cfg.agent_mode = true;
+        cfg.jq = Some(".[0].name".into());
+        assert!(format_and_print(&data, &cfg, None).is_ok());
+        cfg.jq = Some(".[0] | .name.x".into());
+        assert!(format_and_print(&data, &cfg, None).is_err());
+    }
+
     #[test]
     fn test_print_json_sorted() {
         let data = serde_json::json!({"z": 1, "a": 2});
@@ -677,13 +1245,13 @@ mod tests {
     #[test]
     fn test_print_table_empty() {
         let data = serde_json::json!([]);
-        assert!(print_table(&data).is_ok());
+        assert!(print_table(&data, None).is_ok());
     }
 
     #[test]
     fn test_print_table_no_rows() {
         let data = serde_json::json!(42);
-        assert!(print_table(&data).is_ok());
+        assert!(print_table(&data, None).is_ok());
     }
 
     #[test]
@@ -708,16 +1276,7 @@ mod tests {
 
     #[test]
     fn test_output_helper() {
-        let cfg = crate::config::Config {
-            api_key: None,
-            app_key: None,
-            access_token: None,
-            site: "datadoghq.com".into(),
-            org: None,
-            output_format: OutputFormat::Json,
-            auto_approve: false,
-            agent_mode: false,
-        };
+        let cfg = test_cfg(OutputFormat::Json, false);
         let data = serde_json::json!({"hello": "world"});
         assert!(output(&cfg, &data).is_ok());
     }
@@ -727,7 +1286,7 @@ mod tests {
         let data = serde_json::json!([
             {"id": 1, "name": "Test", "status": "ok", "type": "metric", "extra": "val"}
         ]);
-        assert!(print_table(&data).is_ok());
+        assert!(print_table(&data, None).is_ok());
     }
 
     #[test]
@@ -737,6 +1296,135 @@ mod tests {
             obj.insert(format!("col_{i}"), serde_json::json!(i));
         }
         let data = serde_json::json!([obj]);
-        assert!(print_table(&data).is_ok());
+        assert!(print_table(&data, None).is_ok());
+    }
+
+    #[test]
+    fn test_shrink_to_fit_under_limit() {
+        let val = serde_json::json!([{"id": 1}, {"id": 2}]);
+        assert!(shrink_to_fit(&val, DEFAULT_MAX_OUTPUT_BYTES).is_none());
+    }
+
+    #[test]
+    fn test_shrink_to_fit_disabled() {
+        let val = serde_json::json!([{"id": "x".repeat(5000)}]);
+        assert!(shrink_to_fit(&val, 0).is_none());
+    }
+
+    #[test]
+    fn test_shrink_to_fit_top_level_array() {
+        let items: Vec<_> = (0..100)
+            .map(|i| serde_json::json!({"id": i, "msg": "x".repeat(100)}))
+            .collect();
+        let val = serde_json::Value::Array(items);
+        let (shrunk, warning) = shrink_to_fit(&val, 4096).unwrap();
+        let shown = shrunk.as_array().unwrap().len();
+        assert!(shown > 0 && shown < 100);
+        assert!(serde_json::to_string_pretty(&shrunk).unwrap().len() <= 4096);
+        assert_eq!(warning.items_shown, Some(shown));
+        assert_eq!(warning.items_total, Some(100));
+        assert_eq!(warning.next_offset, Some(shown));
+    }
+
+    #[test]
+    fn test_shrink_to_fit_data_wrapper_keeps_meta() {
+        let items: Vec<_> = (0..50)
+            .map(|_| serde_json::json!("y".repeat(200)))
+            .collect();
+        let val = serde_json::json!({"data": items, "meta": {"page": {"after": "abc"}}});
+        let (shrunk, warning) = shrink_to_fit(&val, 3000).unwrap();
+        assert!(shrunk["data"].as_array().unwrap().len() < 50);
+        assert_eq!(shrunk["meta"]["page"]["after"], "abc");
+        let warning = serde_json::to_value(&warning).unwrap();
+        assert!(warning.get("next_cursor").is_none());
+        assert!(warning["next_offset"].is_u64());
+    }
+
+    #[test]
+    fn test_shrink_to_fit_non_list() {
+        let val = serde_json::json!({"blob": "z".repeat(5000)});
+        let (shrunk, warning) = shrink_to_fit(&val, 2048).unwrap();
+        assert!(shrunk.is_null());
+        assert!(warning.items_shown.is_none());
+        // Outside agent mode the full value is printed alongside the warning.
+        assert_eq!(shrink_for_terminal(&val, 2048).unwrap(), val);
+    }
+
+    #[test]
+    fn test_format_and_print_agent_mode_truncated() {
+        let items: Vec<_> = (0..100).map(|i| serde_json::json!({"id": i})).collect();
+        let mut cfg = test_cfg(OutputFormat::Json, true);
+        cfg.max_output_bytes = 2048;
+        assert!(format_and_print(&items, &cfg, None).is_ok());
+    }
+
+    #[test]
+    fn test_is_time_column() {
+        assert!(is_time_column("created_at"));
+        assert!(is_time_column("attributes.modified"));
+        assert!(is_time_column("date_happened"));
+        assert!(is_time_column("attributes.customer_impact_start"));
+        assert!(is_time_column("last_triggered_ts"));
+        assert!(!is_time_column("name"));
+        assert!(!is_time_column("attributes.status"));
+    }
+
+    #[test]
+    fn test_format_timestamp_iso_from_epoch_units() {
+        let expected = Some("2024-01-02T03:04:05Z".to_string());
+        for v in [
+            serde_json::json!(1704164645),
+            serde_json::json!(1704164645000i64),
+            serde_json::json!(1704164645000000i64),
+            serde_json::json!(1704164645000000000i64),
+        ] {
+            assert_eq!(format_timestamp(&v, TimeFormat::Iso), expected);
+        }
+    }
+
+    #[test]
+    fn test_format_timestamp_epoch_from_rfc3339() {
+        let v = serde_json::json!("2024-01-02T04:04:05+01:00");
+        assert_eq!(
+            format_timestamp(&v, TimeFormat::Epoch),
+            Some("1704164645".to_string())
+        );
+        let v = serde_json::json!("2024-01-02T03:04:05.123456");
+        assert_eq!(
+            format_timestamp(&v, TimeFormat::Epoch),
+            Some("1704164645".to_string())
+        );
+    }
+
+    #[test]
+    fn test_format_timestamp_not_a_timestamp() {
+        assert!(format_timestamp(&serde_json::json!("yesterday"), TimeFormat::Iso).is_none());
+        assert!(format_timestamp(&serde_json::json!(0), TimeFormat::Iso).is_none());
+        assert!(format_timestamp(&serde_json::json!(null), TimeFormat::Iso).is_none());
+    }
+
+    #[test]
+    fn test_format_relative() {
+        use chrono::TimeZone;
+        let now = chrono::Utc.timestamp_opt(1_700_000_000, 0).unwrap();
+        let at = |offset: i64| {
+            chrono::Utc
+                .timestamp_opt(1_700_000_000 - offset, 0)
+                .unwrap()
+        };
+        assert_eq!(format_relative(at(2), now), "just now");
+        assert_eq!(format_relative(at(42), now), "42s ago");
+        assert_eq!(format_relative(at(300), now), "5m ago");
+        assert_eq!(format_relative(at(7_200), now), "2h ago");
+        assert_eq!(format_relative(at(3 * 86_400), now), "3d ago");
+        assert_eq!(format_relative(at(-600), now), "in 10m");
+    }
+
+    #[test]
+    fn test_print_table_with_time_format() {
+        let data = serde_json::json!([
+            {"id": 1, "created_at": "2024-01-02T03:04:05Z", "date_happened": 1704164645}
+        ]);
+        assert!(print_table(&data, Some(TimeFormat::Relative)).is_ok());
     }
 }
@@ -0,0 +1,356 @@
+//! HTTP trace logging for `--debug-http` / `PUP_DEBUG=http`.
+//!
+//! Every API call made through the native client helpers is logged to stderr:
+//! method, URL, status, latency, and the request ID Datadog returns. Bodies
+//! are logged too with `--debug-http-bodies` / `PUP_DEBUG=http,bodies`.
+//! Credentials never reach the log: auth headers are not printed, secret
+//! query parameters and JSON fields are masked, and the configured key and
+//! token values are scrubbed from anything that is printed.
+
+use std::sync::atomic::{AtomicBool, Ordering};
+use std::sync::Mutex;
+use std::time::{Duration, Instant};
+
+static ENABLED: AtomicBool = AtomicBool::new(false);
+static BODIES: AtomicBool = AtomicBool::new(false);
+static SECRETS: Mutex<Vec<String>> = Mutex::new(Vec::new());
+
+const REDACTED: &str = "[REDACTED]";
+
+/// Bodies longer than this are cut off in the log.
+const MAX_BODY_BYTES: usize = 4096;
+
+/// Query parameters and JSON fields whose values are always masked.
+const SECRET_NAMES: &[&str] = &[
+    "api_key",
+    "app_key",
+    "application_key",
+    "dd-api-key",
+    "dd-application-key",
+    // API and application key values returned by `api-keys` / `app-keys` create
+    "key",
+    "access_token",
+    "refresh_token",
+    "token",
+    "client_secret",
+    "password",
+    "secret",
+];
+
+/// Response headers that identify a request to Datadog support.
+const REQUEST_ID_HEADERS: &[&str] = &["x-request-id", "x-dd-request-id", "dd-request-id"];
+
+/// Turn on logging. `secrets` are the configured key and token values, which
+/// are scrubbed from every logged line.
+pub fn enable(bodies: bool, secrets: Vec<String>) {
+    ENABLED.store(true, Ordering::Relaxed);
+    BODIES.store(bodies, Ordering::Relaxed);
+    secrets.iter().for_each(|s| add_secret(s));
+}
+
+/// Scrub another value, e.g. a secret read by `--secret-from-env` or
+/// `--secret-from-file`, from everything logged after this call.
+pub fn add_secret(secret: &str) {
+    if secret.is_empty() {
+        return;
+    }
+    if let Ok(mut s) = SECRETS.lock() {
+        if !s.iter().any(|known| known == secret) {
+            s.push(secret.to_string());
+        }
+    }
+}
+
+/// Every value registered for scrubbing so far.
+pub fn secrets() -> Vec<String> {
+    SECRETS.lock().map(|s| s.clone()).unwrap_or_default()
+}
+
+pub fn is_enabled() -> bool {
+    ENABLED.load(Ordering::Relaxed)
+}
+
+fn bodies_enabled() -> bool {
+    BODIES.load(Ordering::Relaxed)
+}
+
+/// Parse `PUP_DEBUG`, a comma-separated list: `http` turns on logging and
+/// `bodies` (or `http-bodies`) adds bodies. Returns (enabled, bodies).
+pub fn parse_env(value: &str) -> (bool, bool) {
+    let parts: Vec<String> = value
+        .split(',')
+        .map(|p| p.trim().to_ascii_lowercase())
+        .collect();
+    let bodies = parts.iter().any(|p| p == "bodies" || p == "http-bodies");
+    let http = bodies || parts.iter().any(|p| p == "http" || p == "all" || p == "1");
+    (http, bodies)
+}
+
+fn is_secret_name(name: &str) -> bool {
+    let name = name.to_ascii_lowercase();
+    SECRET_NAMES.contains(&name.as_str())
+}
+
+/// Replace the configured key and token values wherever they appear.
+pub fn scrub(text: &str, secrets: &[String]) -> String {
+    let mut out = text.to_string();
+    for secret in secrets {
+        out = out.replace(secret.as_str(), REDACTED);
+    }
+    out
+}
+
+/// Mask secret query parameters, e.g. `?api_key=abc` -> `?api_key=[REDACTED]`.
+pub fn redact_url(url: &str) -> String {
+    let Some((base, query)) = url.split_once('?') else {
+        return url.to_string();
+    };
+    let params: Vec<String> = query
+        .split('&')
+        .map(|pair| match pair.split_once('=') {
+            Some((name, _)) if is_secret_name(name) => format!("{name}={REDACTED}"),
+            _ => pair.to_string(),
+        })
+        .collect();
+    format!("{base}?{}", params.join("&"))
+}
+
+fn redact_json(value: &mut serde_json::Value) {
+    match value {
+        serde_json::Value::Object(map) => {
+            for (key, v) in map.iter_mut() {
+                if is_secret_name(key) && !v.is_null() {
+                    *v = serde_json::json!(REDACTED);
+                } else {
+                    redact_json(v);
+                }
+            }
+        }
+        serde_json::Value::Array(items) => items.iter_mut().for_each(redact_json),
+        _ => {}
+    }
+}
+
+/// A body with secret JSON fields masked and secret values scrubbed.
+pub fn redact(body: &[u8], secrets: &[String]) -> String {
+    let text = match serde_json::from_slice::<serde_json::Value>(body) {
+        Ok(mut json) => {
+            redact_json(&mut json);
+            json.to_string()
+        }
+        Err(_) => String::from_utf8_lossy(body).into_owned(),
+    };
+    scrub(&text, secrets)
+}
+
+/// Render a body for the log: `redact`ed, and long bodies truncated.
+pub fn redact_body(body: &[u8], secrets: &[String]) -> String {
+    let mut text = redact(body, secrets);
+    if text.len() > MAX_BODY_BYTES {
+        let mut cut = MAX_BODY_BYTES;
+        while !text.is_char_boundary(cut) {
+            cut -= 1;
+        }
+        let total = text.len();
+        text.truncate(cut);
+        text.push_str(&format!("... ({total} bytes)"));
+    }
+    text
+}
+
+fn request_id(headers: &reqwest::header::HeaderMap) -> Option<String> {
+    REQUEST_ID_HEADERS
+        .iter()
+        .find_map(|h| headers.get(*h)?.to_str().ok())
+        .map(String::from)
+}
+
+/// Format the response line, e.g. `<- 400 Bad Request (182ms) request-id=abc`.
+pub fn format_response(
+    status: reqwest::StatusCode,
+    elapsed: Duration,
+    request_id: Option<&str>,
+) -> String {
+    let mut line = format!("<- {status} ({}ms)", elapsed.as_millis());
+    if let Some(id) = request_id {
+        line.push_str(&format!(" request-id={id}"));
+    }
+    line
+}
+
+fn log(line: &str) {
+    eprintln!("[http] {}", scrub(line, &secrets()));
+}
+
+fn log_body(direction: &str, body: &[u8]) {
+    if body.is_empty() {
+        return;
+    }
+    let secrets = secrets();
+    eprintln!("[http] {direction} {}", redact_body(body, &secrets));
+}
+
+/// One logged request/response pair.
+pub struct Exchange {
+    started: Instant,
+}
+
+impl Exchange {
+    /// Log an outgoing request and start its timer.
+    pub fn start(method: &str, url: &str, body: Option<&[u8]>) -> Exchange {
+        log(&format!("-> {method} {}", redact_url(url)));
+        if bodies_enabled() {
+            log_body(">", body.unwrap_or_default());
+        }
+        Exchange {
+            started: Instant::now(),
+        }
+    }
+
+    /// Log a request from the raw helpers, which hold a builder rather than a
+    /// built request. Returns None when logging is off.
+    pub fn start_builder(req: &reqwest::RequestBuilder) -> Option<Exchange> {
+        if !is_enabled() {
+            return None;
+        }
+        let built = req.try_clone()?.build().ok()?;
+        Some(Exchange::start(
+            built.method().as_str(),
+            built.url().as_str(),
+            built.body().and_then(|b| b.as_bytes()),
+        ))
+    }
+
+    /// Log the response status, latency, and request ID, and the body when
+    /// bodies are on and the caller has read it.
+    pub fn finish(
+        self,
+        status: reqwest::StatusCode,
+        headers: &reqwest::header::HeaderMap,
+        body: Option<&[u8]>,
+    ) {
+        log(&format_response(
+            status,
+            self.started.elapsed(),
+            request_id(headers).as_deref(),
+        ));
+        if bodies_enabled() {
+            if let Some(body) = body {
+                log_body("<", body);
+            }
+        }
+    }
+
+    /// Log a response. With bodies on, the body is buffered for the log and
+    /// the response rebuilt around it so the caller can still read it.
+    pub async fn finish_response(
+        self,
+        resp: reqwest::Response,
+    ) -> reqwest::Result<reqwest::Response> {
+        let status = resp.status();
+        let headers = resp.headers().clone();
+        if !bodies_enabled() {
+            self.finish(status, &headers, None);
+            return Ok(resp);
+        }
+        let bytes = resp.bytes().await?;
+        self.finish(status, &headers, Some(&bytes[..]));
+        let mut rebuilt = http::Response::new(bytes);
+        *rebuilt.status_mut() = status;
+        *rebuilt.headers_mut() = headers;
+        Ok(reqwest::Response::from(rebuilt))
+    }
+
+    /// Log a request that failed before any response arrived.
+    pub fn fail(self, err: &dyn std::fmt::Display) {
+        log(&format!(
+            "<- error after {}ms: {err}",
+            self.started.elapsed().as_millis()
+        ));
+    }
+}
+
+/// Send a request built by the raw helpers, logging it when tracing is on.
+pub async fn send(req: reqwest::RequestBuilder) -> reqwest::Result<reqwest::Response> {
+    let Some(exchange) = Exchange::start_builder(&req) else {
+        return req.send().await;
+    };
+    match req.send().await {
+        Ok(resp) => exchange.finish_response(resp).await,
+        Err(e) => {
+            exchange.fail(&e);
+            Err(e)
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_env() {
+        assert_eq!(parse_env("http"), (true, false));
+        assert_eq!(parse_env("HTTP, bodies"), (true, true));
+        assert_eq!(parse_env("http-bodies"), (true, true));
+        assert_eq!(parse_env("auth"), (false, false));
+        assert_eq!(parse_env(""), (false, false));
+    }
+
+    #[test]
+    fn test_redact_url() {
+        assert_eq!(
+            redact_url("https://api.datadoghq.com/api/v1/validate?api_key=abc&x=1"),
+            "https://api.datadoghq.com/api/v1/validate?api_key=[REDACTED]&x=1"
+        );
+        assert_eq!(
+            redact_url("https://api.datadoghq.com/api/v2/logs/events?filter[query]=x"),
+            "https://api.datadoghq.com/api/v2/logs/events?filter[query]=x"
+        );
+    }
+
+    #[test]
+    fn test_redact_body() {
+        let secrets = vec!["sekrit-app-key".to_string()];
+        let body =
+            br#"{"data":{"attributes":{"name":"ci","key":"x","client_secret":"s","note":"uses sekrit-app-key"}}}"#;
+        let out = redact_body(body, &secrets);
+        assert!(out.contains(r#""client_secret":"[REDACTED]""#), "{out}");
+        assert!(out.contains(r#""key":"[REDACTED]""#), "{out}");
+        assert!(out.contains(r#""note":"uses [REDACTED]""#), "{out}");
+        assert!(out.contains(r#""name":"ci""#), "{out}");
+
+        let long = vec![b'a'; MAX_BODY_BYTES + 10];
+        let out = redact_body(&long, &[]);
+        assert!(out.ends_with(&format!("... ({} bytes)", MAX_BODY_BYTES + 10)));
+    }
+
+    #[test]
+    fn test_add_secret() {
+        add_secret("from-env-secret-1");
+        add_secret("from-env-secret-1");
+        add_secret("");
+        let known = secrets();
+        assert_eq!(
+            known.iter().filter(|s| *s == "from-env-secret-1").count(),
+            1
+        );
+        assert!(!known.iter().any(|s| s.is_empty()));
+    }
+
+    #[test]
+    fn test_format_response() {
+        assert_eq!(
+            format_response(
+                reqwest::StatusCode::BAD_REQUEST,
+                Duration::from_millis(182),
+                Some("abc123")
+            ),
+            "<- 400 Bad Request (182ms) request-id=abc123"
+        );
+        assert_eq!(
+            format_response(reqwest::StatusCode::OK, Duration::from_millis(5), None),
+            "<- 200 OK (5ms)"
+        );
+    }
+}
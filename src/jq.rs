@@ -0,0 +1,678 @@
+//! A small jq-compatible filter evaluator for the global `--jq` flag.
+//!
+//! Covers the filters needed to reshape list and get output without piping
+//! to an external jq: paths (`.a.b`, `.[0]`, `.[]`, `.["k"]`), pipes and
+//! commas, array and object construction (including `{id, name}` shorthand),
+//! comparisons with `and`/`or`, and the `select`, `map`, `length`, `keys`
+//! and `not` builtins. Anything else is rejected at parse time.
+
+use anyhow::{bail, Result};
+use serde_json::Value;
+use std::cmp::Ordering;
+
+/// Parse and run `filter` against `input`, returning every output value.
+pub fn run(filter: &str, input: &Value) -> Result<Vec<Value>> {
+    let expr = parse(filter)?;
+    eval(&expr, input)
+}
+
+/// Parse a filter without running it (used to reject bad `--jq` early).
+pub fn parse(filter: &str) -> Result<Expr> {
+    let tokens = lex(filter)?;
+    let mut p = Parser { tokens, pos: 0 };
+    let expr = p.pipe()?;
+    if let Some(t) = p.peek() {
+        bail!("jq: unexpected {t:?} in {filter:?}");
+    }
+    Ok(expr)
+}
+
+// ---------------------------------------------------------------------------
+// Lexer
+// ---------------------------------------------------------------------------
+
+#[derive(Debug, Clone, PartialEq)]
+enum Tok {
+    Dot,
+    Field(String),
+    Ident(String),
+    Num(f64),
+    Str(String),
+    LParen,
+    RParen,
+    LBracket,
+    RBracket,
+    LBrace,
+    RBrace,
+    Pipe,
+    Comma,
+    Colon,
+    Op(&'static str),
+}
+
+fn is_ident_start(c: char) -> bool {
+    c.is_ascii_alphabetic() || c == '_'
+}
+
+fn is_ident_char(c: char) -> bool {
+    c.is_ascii_alphanumeric() || c == '_'
+}
+
+fn lex(src: &str) -> Result<Vec<Tok>> {
+    let chars: Vec<char> = src.chars().collect();
+    let mut out = Vec::new();
+    let mut i = 0;
+    while i < chars.len() {
+        let c = chars[i];
+        if c.is_whitespace() {
+            i += 1;
+            continue;
+        }
+        let next = chars.get(i + 1).copied();
+        match c {
+            '.' if next.is_some_and(is_ident_start) => {
+                let start = i + 1;
+                i = start;
+                while i < chars.len() && is_ident_char(chars[i]) {
+                    i += 1;
+                }
+                out.push(Tok::Field(chars[start..i].iter().collect()));
+                continue;
+            }
+            '.' => out.push(Tok::Dot),
+            '(' => out.push(Tok::LParen),
+            ')' => out.push(Tok::RParen),
+            '[' => out.push(Tok::LBracket),
+            ']' => out.push(Tok::RBracket),
+            '{' => out.push(Tok::LBrace),
+            '}' => out.push(Tok::RBrace),
+            ',' => out.push(Tok::Comma),
+            ':' => out.push(Tok::Colon),
+            '|' => out.push(Tok::Pipe),
+            '"' => {
+                let (s, end) = lex_string(&chars, i + 1)?;
+                out.push(Tok::Str(s));
+                i = end;
+                continue;
+            }
+            '=' | '!' | '<' | '>' => {
+                let op = match (c, next) {
+                    ('=', Some('=')) => "==",
+                    ('!', Some('=')) => "!=",
+                    ('<', Some('=')) => "<=",
+                    ('>', Some('=')) => ">=",
+                    ('<', _) => "<",
+                    ('>', _) => ">",
+                    _ => bail!("jq: unexpected character {c:?}"),
+                };
+                out.push(Tok::Op(op));
+                i += op.len();
+                continue;
+            }
+            _ if c.is_ascii_digit() || (c == '-' && next.is_some_and(|n| n.is_ascii_digit())) => {
+                let start = i;
+                i += 1;
+                while i < chars.len() && (chars[i].is_ascii_digit() || chars[i] == '.') {
+                    i += 1;
+                }
+                let text: String = chars[start..i].iter().collect();
+                let n = text
+                    .parse::<f64>()
+                    .map_err(|_| anyhow::anyhow!("jq: invalid number {text:?}"))?;
+                out.push(Tok::Num(n));
+                continue;
+            }
+            _ if is_ident_start(c) => {
+                let start = i;
+                while i < chars.len() && is_ident_char(chars[i]) {
+                    i += 1;
+                }
+                out.push(Tok::Ident(chars[start..i].iter().collect()));
+                continue;
+            }
+            _ => bail!("jq: unexpected character {c:?}"),
+        }
+        i += 1;
+    }
+    Ok(out)
+}
+
+/// Lex a string body starting just after the opening quote. Returns the
+/// string and the index just past the closing quote.
+fn lex_string(chars: &[char], mut i: usize) -> Result<(String, usize)> {
+    let mut s = String::new();
+    while i < chars.len() {
+        match chars[i] {
+            '"' => return Ok((s, i + 1)),
+            '\\' => {
+                match chars.get(i + 1) {
+                    Some('n') => s.push('\n'),
+                    Some('t') => s.push('\t'),
+                    Some(&c) if matches!(c, '"' | '\\' | '/') => s.push(c),
+                    Some(other) => bail!("jq: unsupported escape \\{other}"),
+                    None => break,
+                }
+                i += 2;
+            }
+            c => {
+                s.push(c);
+                i += 1;
+            }
+        }
+    }
+    bail!("jq: unterminated string")
+}
+
+// ---------------------------------------------------------------------------
+// Parser
+// ---------------------------------------------------------------------------
+
+#[derive(Debug, Clone)]
+pub enum Expr {
+    Identity,
+    Literal(Value),
+    Pipe(Box<Expr>, Box<Expr>),
+    Comma(Box<Expr>, Box<Expr>),
+    Index(Box<Expr>, Box<Expr>),
+    Iterate(Box<Expr>),
+    Compare(&'static str, Box<Expr>, Box<Expr>),
+    And(Box<Expr>, Box<Expr>),
+    Or(Box<Expr>, Box<Expr>),
+    Array(Option<Box<Expr>>),
+    Object(Vec<(String, Expr)>),
+    Call(String, Option<Box<Expr>>),
+}
+
+struct Parser {
+    tokens: Vec<Tok>,
+    pos: usize,
+}
+
+impl Parser {
+    fn peek(&self) -> Option<&Tok> {
+        self.tokens.get(self.pos)
+    }
+
+    fn next(&mut self) -> Option<Tok> {
+        let t = self.tokens.get(self.pos).cloned();
+        self.pos += 1;
+        t
+    }
+
+    fn eat(&mut self, t: &Tok) -> bool {
+        if self.peek() == Some(t) {
+            self.pos += 1;
+            true
+        } else {
+            false
+        }
+    }
+
+    fn expect(&mut self, t: Tok) -> Result<()> {
+        match self.next() {
+            Some(got) if got == t => Ok(()),
+            Some(got) => bail!("jq: expected {t:?}, found {got:?}"),
+            None => bail!("jq: expected {t:?}, found end of filter"),
+        }
+    }
+
+    fn eat_keyword(&mut self, kw: &str) -> bool {
+        self.eat(&Tok::Ident(kw.to_string()))
+    }
+
+    fn pipe(&mut self) -> Result<Expr> {
+        let lhs = self.comma()?;
+        if self.eat(&Tok::Pipe) {
+            return Ok(Expr::Pipe(Box::new(lhs), Box::new(self.pipe()?)));
+        }
+        Ok(lhs)
+    }
+
+    fn comma(&mut self) -> Result<Expr> {
+        let mut lhs = self.or()?;
+        while self.eat(&Tok::Comma) {
+            lhs = Expr::Comma(Box::new(lhs), Box::new(self.or()?));
+        }
+        Ok(lhs)
+    }
+
+    fn or(&mut self) -> Result<Expr> {
+        let mut lhs = self.and()?;
+        while self.eat_keyword("or") {
+            lhs = Expr::Or(Box::new(lhs), Box::new(self.and()?));
+        }
+        Ok(lhs)
+    }
+
+    fn and(&mut self) -> Result<Expr> {
+        let mut lhs = self.compare()?;
+        while self.eat_keyword("and") {
+            lhs = Expr::And(Box::new(lhs), Box::new(self.compare()?));
+        }
+        Ok(lhs)
+    }
+
+    fn compare(&mut self) -> Result<Expr> {
+        let lhs = self.postfix()?;
+        if let Some(Tok::Op(op)) = self.peek() {
+            let op = *op;
+            self.pos += 1;
+            return Ok(Expr::Compare(op, Box::new(lhs), Box::new(self.postfix()?)));
+        }
+        Ok(lhs)
+    }
+
+    fn postfix(&mut self) -> Result<Expr> {
+        let mut term = self.primary()?;
+        loop {
+            match self.peek() {
+                Some(Tok::Field(name)) => {
+                    let key = Expr::Literal(Value::String(name.clone()));
+                    self.pos += 1;
+                    term = Expr::Index(Box::new(term), Box::new(key));
+                }
+                Some(Tok::Dot) if self.tokens.get(self.pos + 1) == Some(&Tok::LBracket) => {
+                    self.pos += 1;
+                }
+                Some(Tok::LBracket) => {
+                    self.pos += 1;
+                    term = self.bracket_suffix(term)?;
+                }
+                _ => break,
+            }
+        }
+        Ok(term)
+    }
+
+    /// After `[`: `]` iterates, `expr]` indexes.
+    fn bracket_suffix(&mut self, term: Expr) -> Result<Expr> {
+        if self.eat(&Tok::RBracket) {
+            return Ok(Expr::Iterate(Box::new(term)));
+        }
+        let key = self.pipe()?;
+        self.expect(Tok::RBracket)?;
+        Ok(Expr::Index(Box::new(term), Box::new(key)))
+    }
+
+    fn primary(&mut self) -> Result<Expr> {
+        match self.next() {
+            Some(Tok::Dot) => {
+                if self.eat(&Tok::LBracket) {
+                    return self.bracket_suffix(Expr::Identity);
+                }
+                Ok(Expr::Identity)
+            }
+            Some(Tok::Field(name)) => Ok(Expr::Index(
+                Box::new(Expr::Identity),
+                Box::new(Expr::Literal(Value::String(name))),
+            )),
+            Some(Tok::Num(n)) => Ok(Expr::Literal(num(n))),
+            Some(Tok::Str(s)) => Ok(Expr::Literal(Value::String(s))),
+            Some(Tok::LParen) => {
+                let e = self.pipe()?;
+                self.expect(Tok::RParen)?;
+                Ok(e)
+            }
+            Some(Tok::LBracket) => {
+                if self.eat(&Tok::RBracket) {
+                    return Ok(Expr::Array(None));
+                }
+                let e = self.pipe()?;
+                self.expect(Tok::RBracket)?;
+                Ok(Expr::Array(Some(Box::new(e))))
+            }
+            Some(Tok::LBrace) => self.object(),
+            Some(Tok::Ident(name)) => match name.as_str() {
+                "true" => Ok(Expr::Literal(Value::Bool(true))),
+                "false" => Ok(Expr::Literal(Value::Bool(false))),
+                "null" => Ok(Expr::Literal(Value::Null)),
+                "length" | "keys" | "not" => Ok(Expr::Call(name, None)),
+                "select" | "map" => {
+                    self.expect(Tok::LParen)?;
+                    let arg = self.pipe()?;
+                    self.expect(Tok::RParen)?;
+                    Ok(Expr::Call(name, Some(Box::new(arg))))
+                }
+                _ => bail!("jq: unsupported function {name}"),
+            },
+            Some(t) => bail!("jq: unexpected {t:?}"),
+            None => bail!("jq: unexpected end of filter"),
+        }
+    }
+
+    fn object(&mut self) -> Result<Expr> {
+        let mut entries = Vec::new();
+        if self.eat(&Tok::RBrace) {
+            return Ok(Expr::Object(entries));
+        }
+        loop {
+            let key = match self.next() {
+                Some(Tok::Ident(name) | Tok::Str(name)) => name,
+                other => bail!("jq: invalid object key {other:?}"),
+            };
+            let value = if self.eat(&Tok::Colon) {
+                self.or()?
+            } else {
+                // `{name}` is shorthand for `{name: .name}`.
+                Expr::Index(
+                    Box::new(Expr::Identity),
+                    Box::new(Expr::Literal(Value::String(key.clone()))),
+                )
+            };
+            entries.push((key, value));
+            if self.eat(&Tok::RBrace) {
+                break;
+            }
+            self.expect(Tok::Comma)?;
+        }
+        Ok(Expr::Object(entries))
+    }
+}
+
+// ---------------------------------------------------------------------------
+// Evaluator
+// ---------------------------------------------------------------------------
+
+/// Build a JSON number, preferring integers so `1` doesn't print as `1.0`.
+fn num(n: f64) -> Value {
+    if n.fract() == 0.0 && n.abs() < 9.0e15 {
+        Value::from(n as i64)
+    } else {
+        serde_json::Number::from_f64(n)
+            .map(Value::Number)
+            .unwrap_or(Value::Null)
+    }
+}
+
+fn truthy(v: &Value) -> bool {
+    !matches!(v, Value::Null | Value::Bool(false))
+}
+
+fn type_name(v: &Value) -> &'static str {
+    match v {
+        Value::Null => "null",
+        Value::Bool(_) => "boolean",
+        Value::Number(_) => "number",
+        Value::String(_) => "string",
+        Value::Array(_) => "array",
+        Value::Object(_) => "object",
+    }
+}
+
+/// jq's total order: null < false < true < numbers < strings < arrays < objects.
+fn compare(a: &Value, b: &Value) -> Ordering {
+    fn rank(v: &Value) -> u8 {
+        match v {
+            Value::Null => 0,
+            Value::Bool(false) => 1,
+            Value::Bool(true) => 2,
+            Value::Number(_) => 3,
+            Value::String(_) => 4,
+            Value::Array(_) => 5,
+            Value::Object(_) => 6,
+        }
+    }
+    match (a, b) {
+        (Value::Number(x), Value::Number(y)) => x
+            .as_f64()
+            .unwrap_or(0.0)
+            .total_cmp(&y.as_f64().unwrap_or(0.0)),
+        (Value::String(x), Value::String(y)) => x.cmp(y),
+        (Value::Array(x), Value::Array(y)) => x
+            .iter()
+            .zip(y)
+            .map(|(i, j)| compare(i, j))
+            .find(|o| *o != Ordering::Equal)
+            .unwrap_or_else(|| x.len().cmp(&y.len())),
+        (Value::Object(x), Value::Object(y)) => {
+            let mut xk: Vec<&String> = x.keys().collect();
+            let mut yk: Vec<&String> = y.keys().collect();
+            xk.sort();
+            yk.sort();
+            xk.cmp(&yk).then_with(|| {
+                xk.iter()
+                    .map(|k| compare(&x[k.as_str()], &y[k.as_str()]))
+                    .find(|o| *o != Ordering::Equal)
+                    .unwrap_or(Ordering::Equal)
+            })
+        }
+        _ => rank(a).cmp(&rank(b)),
+    }
+}
+
+fn index(target: &Value, key: &Value) -> Result<Value> {
+    Ok(match (target, key) {
+        (Value::Null, _) => Value::Null,
+        (Value::Object(m), Value::String(k)) => m.get(k).cloned().unwrap_or(Value::Null),
+        (Value::Array(a), Value::Number(n)) => {
+            let i = n.as_f64().unwrap_or(0.0) as i64;
+            let i = if i < 0 { a.len() as i64 + i } else { i };
+            usize::try_from(i)
+                .ok()
+                .and_then(|i| a.get(i))
+                .cloned()
+                .unwrap_or(Value::Null)
+        }
+        _ => bail!(
+            "jq: cannot index {} with {}",
+            type_name(target),
+            type_name(key)
+        ),
+    })
+}
+
+fn iterate(v: &Value) -> Result<Vec<Value>> {
+    match v {
+        Value::Array(a) => Ok(a.clone()),
+        Value::Object(m) => Ok(m.values().cloned().collect()),
+        _ => bail!("jq: cannot iterate over {}", type_name(v)),
+    }
+}
+
+/// Evaluate `expr` against `input`. Each jq output becomes one element.
+pub fn eval(expr: &Expr, input: &Value) -> Result<Vec<Value>> {
+    Ok(match expr {
+        Expr::Identity => vec![input.clone()],
+        Expr::Literal(v) => vec![v.clone()],
+        Expr::Pipe(a, b) => {
+            let mut out = Vec::new();
+            for v in eval(a, input)? {
+                out.extend(eval(b, &v)?);
+            }
+            out
+        }
+        Expr::Comma(a, b) => {
+            let mut out = eval(a, input)?;
+            out.extend(eval(b, input)?);
+            out
+        }
+        Expr::Index(target, key) => {
+            let mut out = Vec::new();
+            for t in eval(target, input)? {
+                for k in eval(key, input)? {
+                    out.push(index(&t, &k)?);
+                }
+            }
+            out
+        }
+        Expr::Iterate(target) => {
+            let mut out = Vec::new();
+            for t in eval(target, input)? {
+                out.extend(iterate(&t)?);
+            }
+            out
+        }
+        Expr::Compare(op, a, b) => {
+            let mut out = Vec::new();
+            let rhs = eval(b, input)?;
+            for l in eval(a, input)? {
+                for r in &rhs {
+                    let o = compare(&l, r);
+                    out.push(Value::Bool(match *op {
+                        "==" => o == Ordering::Equal,
+                        "!=" => o != Ordering::Equal,
+                        "<" => o == Ordering::Less,
+                        "<=" => o != Ordering::Greater,
+                        ">" => o == Ordering::Greater,
+                        _ => o != Ordering::Less,
+                    }));
+                }
+            }
+            out
+        }
+        Expr::And(a, b) | Expr::Or(a, b) => {
+            let is_and = matches!(expr, Expr::And(..));
+            let mut out = Vec::new();
+            for l in eval(a, input)? {
+                if truthy(&l) != is_and {
+                    out.push(Value::Bool(!is_and));
+                    continue;
+                }
+                for r in eval(b, input)? {
+                    out.push(Value::Bool(truthy(&r)));
+                }
+            }
+            out
+        }
+        Expr::Array(None) => vec![Value::Array(Vec::new())],
+        Expr::Array(Some(e)) => vec![Value::Array(eval(e, input)?)],
+        Expr::Object(entries) => {
+            let mut objs = vec![serde_json::Map::new()];
+            for (key, v) in entries {
+                let vals = eval(v, input)?;
+                let mut next = Vec::new();
+                for obj in &objs {
+                    for val in &vals {
+                        let mut o = obj.clone();
+                        o.insert(key.clone(), val.clone());
+                        next.push(o);
+                    }
+                }
+                objs = next;
+            }
+            objs.into_iter().map(Value::Object).collect()
+        }
+        Expr::Call(name, arg) => call(name, arg.as_deref(), input)?,
+    })
+}
+
+fn call(name: &str, arg: Option<&Expr>, input: &Value) -> Result<Vec<Value>> {
+    Ok(match (name, arg) {
+        ("not", _) => vec![Value::Bool(!truthy(input))],
+        ("length", _) => vec![match input {
+            Value::Null => Value::from(0),
+            Value::Bool(_) => bail!("jq: boolean has no length"),
+            Value::Number(n) => num(n.as_f64().unwrap_or(0.0).abs()),
+            Value::String(s) => Value::from(s.chars().count()),
+            Value::Array(a) => Value::from(a.len()),
+            Value::Object(m) => Value::from(m.len()),
+        }],
+        ("keys", _) => vec![match input {
+            Value::Object(m) => {
+                let mut keys: Vec<&String> = m.keys().collect();
+                keys.sort();
+                Value::Array(keys.into_iter().map(|k| Value::String(k.clone())).collect())
+            }
+            Value::Array(a) => Value::Array((0..a.len()).map(Value::from).collect()),
+            other => bail!("jq: {} has no keys", type_name(other)),
+        }],
+        ("map", Some(f)) => {
+            let mut out = Vec::new();
+            for v in iterate(input)? {
+                out.extend(eval(f, &v)?);
+            }
+            vec![Value::Array(out)]
+        }
+        ("select", Some(f)) => eval(f, input)?
+            .iter()
+            .filter(|v| truthy(v))
+            .map(|_| input.clone())
+            .collect(),
+        _ => bail!("jq: unsupported function {name}"),
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use serde_json::json;
+
+    fn jq(filter: &str, input: Value) -> Vec<Value> {
+        run(filter, &input).unwrap_or_else(|e| panic!("{filter}: {e}"))
+    }
+
+    fn monitors() -> Value {
+        json!([
+            {"id": 1, "name": "cpu", "overall_state": "Alert", "tags": ["env:prod"]},
+            {"id": 2, "name": "disk", "overall_state": "OK", "tags": []},
+            {"id": 3, "name": "mem", "overall_state": "Alert", "tags": ["env:dev"]}
+        ])
+    }
+
+    #[test]
+    fn test_paths_and_iteration() {
+        assert_eq!(jq(".", json!(1)), vec![json!(1)]);
+        assert_eq!(jq(".a.b", json!({"a": {"b": 2}})), vec![json!(2)]);
+        assert_eq!(jq(".missing.x", json!({})), vec![json!(null)]);
+        assert_eq!(jq(".[1].name", monitors()), vec![json!("disk")]);
+        assert_eq!(jq(".[-1].id", monitors()), vec![json!(3)]);
+        assert_eq!(jq(".[].id", monitors()), vec![json!(1), json!(2), json!(3)]);
+        assert_eq!(jq(".[\"a-b\"]", json!({"a-b": 1})), vec![json!(1)]);
+        assert_eq!(jq(".[0].tags[0]", monitors()), vec![json!("env:prod")]);
+        assert!(run(".a", &json!([1])).is_err());
+    }
+
+    #[test]
+    fn test_construction_and_select() {
+        assert_eq!(
+            jq(
+                ".[] | select(.overall_state == \"Alert\") | {id, name}",
+                monitors()
+            ),
+            vec![
+                json!({"id": 1, "name": "cpu"}),
+                json!({"id": 3, "name": "mem"})
+            ]
+        );
+        assert_eq!(jq("[.[] | .id]", monitors()), vec![json!([1, 2, 3])]);
+        assert_eq!(
+            jq("{count: length, names: map(.name)}", monitors()),
+            vec![json!({"count": 3, "names": ["cpu", "disk", "mem"]})]
+        );
+        assert_eq!(
+            jq("map(select(.tags | length > 0)) | length", monitors()),
+            vec![json!(2)]
+        );
+        assert_eq!(
+            jq(".[0] | .id, .name", monitors()),
+            vec![json!(1), json!("cpu")]
+        );
+    }
+
+    #[test]
+    fn test_comparisons_and_builtins() {
+        assert_eq!(
+            jq(".[] | .id >= 2 and (.name != \"mem\")", monitors()),
+            vec![json!(false), json!(true), json!(false)]
+        );
+        assert_eq!(
+            jq(
+                ".[] | select(.id < 2 or (.overall_state == \"OK\" | not)) | .id",
+                monitors()
+            ),
+            vec![json!(1), json!(3)]
+        );
+        assert_eq!(jq("keys", json!({"b": 1, "a": 2})), vec![json!(["a", "b"])]);
+        assert_eq!(jq("null == false", json!(null)), vec![json!(false)]);
+    }
+
+    #[test]
+    fn test_parse_errors() {
+        assert!(parse(".[").is_err());
+        assert!(parse("{a: }").is_err());
+        assert!(parse("..").is_err());
+        assert!(parse("select(").is_err());
+        assert!(parse(".a // 1").is_err());
+        assert!(parse("sort_by(.id)").is_err());
+    }
+}
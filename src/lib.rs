@@ -18,8 +18,16 @@ mod api;
 #[cfg(feature = "browser")]
 mod config;
 #[cfg(feature = "browser")]
+#[allow(dead_code)]
+mod core_contract;
+#[cfg(feature = "browser")]
+#[allow(dead_code)]
+mod exit_code;
+#[cfg(feature = "browser")]
 mod formatter;
 #[cfg(feature = "browser")]
+mod jq;
+#[cfg(feature = "browser")]
 mod version;
 
 #[cfg(feature = "browser")]
@@ -71,7 +79,7 @@ impl PupClient {
     /// Create a new PupClient from options.
     #[wasm_bindgen(constructor)]
     pub fn new(opts: PupClientOptions) -> Result<PupClient, JsError> {
-        let cfg =
+        let mut cfg =
             config::Config::from_params(opts.site, opts.access_token, opts.api_key, opts.app_key);
         cfg.validate_auth()
             .map_err(|e| JsError::new(&e.to_string()))?;
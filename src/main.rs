@@ -1,10 +1,22 @@
 #[allow(dead_code)]
 mod api;
 mod auth;
+#[cfg(not(target_arch = "wasm32"))]
+mod cassette;
+mod checkpoint;
 mod client;
 mod commands;
 mod config;
+mod confirm;
+mod core_contract;
+mod exit_code;
 mod formatter;
+#[cfg(not(target_arch = "wasm32"))]
+mod http_debug;
+mod jq;
+mod resolve;
+mod runlog;
+mod stats;
 mod useragent;
 mod util;
 mod version;
@@ -18,16 +30,40 @@ pub(crate) mod test_utils {
     use std::sync::Mutex;
     /// Serialize tests that mutate global env vars (PUP_MOCK_SERVER, DD_API_KEY, etc.).
     pub static ENV_LOCK: Mutex<()> = Mutex::new(());
+
+    /// Config with no credentials and every setting at its default. Tests set
+    /// what they need with struct update syntax: `Config { api_key, ..config() }`.
+    pub fn config() -> crate::config::Config {
+        crate::config::Config {
+            api_key: None,
+            app_key: None,
+            access_token: None,
+            site: "datadoghq.com".into(),
+            org: None,
+            output_format: crate::config::OutputFormat::Json,
+            auto_approve: false,
+            agent_mode: false,
+            max_output_bytes: crate::formatter::DEFAULT_MAX_OUTPUT_BYTES,
+            time_format: None,
+            jq: None,
+            credential_process: None,
+        }
+    }
 }
 
 use clap::{CommandFactory, Parser, Subcommand};
 
 #[derive(Parser)]
-#[command(name = "pup", version = version::VERSION, about = "Datadog API CLI")]
+#[command(
+    name = "pup",
+    version = version::VERSION,
+    about = "Datadog API CLI",
+    after_help = "EXIT CODES:\n  0  success\n  1  other error\n  2  authentication or permission (HTTP 401/403)\n  3  not found (HTTP 404)\n  4  rate limited (HTTP 429)\n  5  invalid flags or arguments (HTTP 400/422)"
+)]
 struct Cli {
-    /// Output format (json, table, yaml)
-    #[arg(short, long, global = true, default_value = "json")]
-    output: String,
+    /// Output format (json, table, yaml, csv) [default: json, or DD_OUTPUT / config.yaml]
+    #[arg(short, long, global = true)]
+    output: Option<String>,
     /// Auto-approve destructive operations
     #[arg(short = 'y', long = "yes", global = true)]
     yes: bool,
@@ -37,6 +73,63 @@ struct Cli {
     /// Named org session (see 'pup auth login --org')
     #[arg(long, global = true)]
     org: Option<String>,
+    /// Datadog site for this invocation, e.g. datadoghq.eu (overrides DD_SITE)
+    #[arg(long, global = true)]
+    site: Option<String>,
+    /// API key for this invocation (overrides DD_API_KEY; visible to other local users via ps)
+    #[arg(long, global = true, value_name = "KEY")]
+    api_key: Option<String>,
+    /// Application key for this invocation (overrides DD_APP_KEY; visible to other local users via ps)
+    #[arg(long, global = true, value_name = "KEY")]
+    app_key: Option<String>,
+    /// Named config profile from config.yaml (overrides PUP_PROFILE)
+    #[arg(long, global = true)]
+    profile: Option<String>,
+    /// OAuth token storage backend (keyring or file)
+    #[arg(long, global = true, value_parser = ["keyring", "keychain", "file"])]
+    credential_store: Option<String>,
+    /// Filter output with a jq expression, e.g. '.[] | {id, name}'
+    #[arg(long, global = true)]
+    jq: Option<String>,
+    /// Truncate JSON/agent output larger than this many bytes (0 disables)
+    #[arg(long, global = true)]
+    max_output_bytes: Option<usize>,
+    /// Timestamp rendering in table output (relative, iso, epoch, local)
+    #[arg(long, global = true)]
+    time_format: Option<String>,
+    /// Print API call, byte, timing, and rate-limit stats to stderr
+    #[arg(long, global = true)]
+    stats: bool,
+    /// Log every API call (method, URL, status, latency, request ID) to stderr, credentials redacted
+    #[arg(long, global = true)]
+    debug_http: bool,
+    /// Like --debug-http, and also log request and response bodies
+    #[arg(long, global = true)]
+    debug_http_bodies: bool,
+    /// Give up on an API request after this long, e.g. 30s or 2m (0 disables; default: none)
+    #[arg(long, global = true, value_name = "DURATION")]
+    timeout: Option<String>,
+    /// Give up connecting to the API after this long (default: 10s)
+    #[arg(long, global = true, value_name = "DURATION")]
+    connect_timeout: Option<String>,
+    /// Print the agent-mode output JSON Schema for the command and exit
+    /// (data is only constrained for core list/get commands)
+    #[arg(long, global = true)]
+    schema_out: bool,
+    /// Send API requests to this base URL instead of the Datadog site
+    #[arg(long, global = true, value_name = "URL")]
+    mock_server: Option<String>,
+    /// Record API requests and responses to a JSON Lines cassette
+    #[arg(long, global = true, value_name = "FILE", conflicts_with_all = ["replay", "mock_server"])]
+    record: Option<std::path::PathBuf>,
+    /// Serve API responses from a recorded cassette instead of the API
+    #[arg(
+        long,
+        global = true,
+        value_name = "FILE",
+        conflicts_with = "mock_server"
+    )]
+    replay: Option<std::path::PathBuf>,
     #[command(subcommand)]
     command: Commands,
 }
@@ -199,6 +292,7 @@ enum Commands {
     ///
     /// CAPABILITIES:
     ///   • List services with performance statistics (requests, errors, latency)
+    ///   • Summarize one service per resource: requests/sec, p50/p95/p99, error rate
     ///   • Query entities with rich metadata (services, datastores, queues, inferred services)
     ///   • List operations and resources (endpoints) for services
     ///   • View service dependencies and flow maps with performance metrics
@@ -207,18 +301,36 @@ enum Commands {
     ///   services       List and query APM services with performance data
     ///   entities       Query APM entities (services, datastores, queues, etc.)
     ///   dependencies   View service dependencies and call relationships
+    ///   operations     Discover operation (span) names for a service
+    ///   resources      Discover resources (endpoints) for a service operation
     ///   flow-map       Visualize service flow with performance metrics
     ///
     /// EXAMPLES:
     ///   # List services with stats
     ///   pup apm services stats --start $(date -d '1 hour ago' +%s) --end $(date +%s)
     ///
+    ///   # Per-resource requests/sec, latency percentiles, and error rate
+    ///   pup apm services summary --service api --env prod --from 1h
+    ///
     ///   # Query entities with filtering
     ///   pup apm entities list --start $(date -d '1 hour ago' +%s) --end $(date +%s) --env prod
     ///
     ///   # View service dependencies
     ///   pup apm dependencies list --env prod --start $(date -d '1 hour ago' +%s) --end $(date +%s)
     ///
+    ///   # Discover span names and resources for building monitors and queries
+    ///   pup apm operations list --service api
+    ///   pup apm resources list --service api --operation web.request
+    ///
+    ///   # Get the flow map around a service (versioned, normalized shape)
+    ///   pup apm flow-map get --service web-app --env prod
+    ///
+    /// OUTPUT STABILITY:
+    ///   'entities list' and 'flow-map get' wrap unstable endpoints. Their output is
+    ///   normalized into a documented shape tagged with "schema_version" (currently "v1").
+    ///   If the API response changes shape, pup warns on stderr and prints the raw
+    ///   response. Use --raw to always get the unmodified API response.
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication (pup auth login) or API keys
     ///   (DD_API_KEY and DD_APP_KEY environment variables).
@@ -294,6 +406,7 @@ enum Commands {
     ///   status      Check current authentication status
     ///   refresh     Manually refresh access token
     ///   logout      Clear all stored credentials
+    ///   scopes      Compare granted scopes with the requested set
     ///
     /// OAUTH2 SCOPES:
     ///   The following scopes are requested during login:
@@ -313,10 +426,16 @@ enum Commands {
     ///   • Metrics: metrics_read, timeseries_query
     ///   • Usage: usage_read
     ///
+    ///   A 403 on an OAuth session names the scope the endpoint needs. Run
+    ///   'pup auth logout' then 'pup auth login' to re-register with new scopes.
+    ///
     /// EXAMPLES:
     ///   # Login with OAuth2
     ///   pup auth login
     ///
+    ///   # Login from an SSH session or headless host
+    ///   pup auth login --no-browser
+    ///
     ///   # Check authentication status
     ///   pup auth status
     ///
@@ -336,6 +455,16 @@ enum Commands {
     ///   # List all stored org sessions
     ///   pup auth list
     ///
+    ///   # Compare granted OAuth scopes with the ones login requests
+    ///   pup auth scopes
+    ///
+    ///   # Show which auth methods the site supports (no OAuth login on ddog-gov.com)
+    ///   pup auth capabilities
+    ///
+    ///   # Run a script with credentials injected only into its environment
+    ///   pup auth exec -- ./deploy-dashboards.sh --env prod
+    ///   pup auth exec --keys -- terraform plan
+    ///
     ///   # Use a named org session for any command
     ///   pup monitors list --org prod-child
     ///   DD_ORG=prod-child pup metrics query --query "avg:system.cpu.user{*}"
@@ -381,6 +510,40 @@ enum Commands {
         #[command(subcommand)]
         action: AuthActions,
     },
+    /// Machine-readable manifest of commands, auth requirements, and endpoints
+    ///
+    /// Emit a JSON manifest with one entry per command: its required auth
+    /// (OAuth support and scopes vs API keys), the API endpoints it touches, and
+    /// whether it is read-only, a write, or destructive.
+    ///
+    /// The manifest is generated from the command tree and a central capability
+    /// registry, so docs, verifiers, and agent integrations share one source of truth.
+    ///
+    /// CAPABILITIES:
+    ///   • List every leaf command with its access level (read, write, destructive)
+    ///   • Show OAuth scopes needed per command and whether OAuth is supported
+    ///   • Show the API path prefixes each command calls
+    ///
+    /// EXAMPLES:
+    ///   # Full manifest
+    ///   pup capabilities
+    ///
+    ///   # Only monitors commands
+    ///   pup capabilities --domain monitors
+    ///
+    ///   # Destructive commands an agent should confirm first
+    ///   pup capabilities | jq -r '.commands[] | select(.access == "destructive") | .command'
+    ///
+    /// AUTHENTICATION:
+    ///   None required. The manifest is built locally.
+    #[command(verbatim_doc_comment)]
+    Capabilities {
+        #[arg(
+            long,
+            help = "Limit the manifest to one top-level command (e.g. monitors)"
+        )]
+        domain: Option<String>,
+    },
     /// Manage case management cases and projects
     ///
     /// Manage Datadog Case Management for tracking and resolving issues.
@@ -415,6 +578,15 @@ enum Commands {
     ///   # Create a new case
     ///   pup cases create --title="Bug report" --type-id="type-uuid" --priority=P2
     ///
+    ///   # Work a case: assign, start, comment, close
+    ///   pup cases assign <case-id> --user ada@example.com
+    ///   pup cases status <case-id> in_progress
+    ///   pup cases comment <case-id> --message "Rolled back the deploy"
+    ///   pup cases status <case-id> closed
+    ///
+    ///   # Open a linked Jira issue and print its URL
+    ///   pup cases create-issue <case-id> --account <jira-account-id> --project 10001
+    ///
     ///   # List projects
     ///   pup cases projects list
     ///
@@ -473,21 +645,34 @@ enum Commands {
     /// and provide insights into cloud resource usage and performance.
     ///
     /// CAPABILITIES:
-    ///   • Manage AWS integrations
-    ///   • Manage GCP integrations
-    ///   • Manage Azure integrations
+    ///   • Manage AWS accounts and generate IAM role external IDs
+    ///   • Manage GCP STS service accounts (create, update, delete)
+    ///   • Manage Azure app registrations (create, update, delete)
     ///   • View cloud metrics
     ///
     /// EXAMPLES:
     ///   # List AWS integrations
     ///   pup cloud aws list
     ///
+    ///   # Integrate a new AWS account
+    ///   pup cloud aws generate-external-id
+    ///   pup cloud aws create --file aws-account.json
+    ///
+    ///   # Get an AWS account by its AWS account ID
+    ///   pup cloud aws get 123456789012
+    ///
     ///   # List GCP integrations
     ///   pup cloud gcp list
     ///
     ///   # List Azure integrations
     ///   pup cloud azure list
     ///
+    ///   # Add a GCP STS service account (the file is validated before upload)
+    ///   pup cloud gcp create --file gcp-sts-account.json
+    ///
+    ///   # Remove an Azure app registration
+    ///   pup cloud azure delete --tenant-id <tenant-id> --client-id <client-id>
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication or API keys.
     #[command(verbatim_doc_comment)]
@@ -495,6 +680,38 @@ enum Commands {
         #[command(subcommand)]
         action: CloudActions,
     },
+    /// Manage Cloud Cost Management billing configs
+    ///
+    /// Cloud Cost Management ingests AWS Cost and Usage Reports, Azure cost
+    /// exports, and GCP billing exports. These commands manage the account
+    /// configs that point Datadog at those exports and show whether each one
+    /// is ingesting.
+    ///
+    /// CAPABILITIES:
+    ///   • List, create, update, and delete AWS CUR, Azure, and GCP configs
+    ///   • Enable or disable a config without editing the rest of it
+    ///   • Check ingestion status and export errors across providers
+    ///
+    /// EXAMPLES:
+    ///   # Ingestion status for every config
+    ///   pup cloud-cost status -o table
+    ///
+    ///   # List AWS CUR configs
+    ///   pup cloud-cost aws list
+    ///
+    ///   # Create a GCP config from a JSON file of attributes
+    ///   pup cloud-cost gcp create --file gcp-billing.json
+    ///
+    ///   # Pause ingestion for an Azure config
+    ///   pup cloud-cost azure update 12345 --enabled false
+    ///
+    /// AUTHENTICATION:
+    ///   Requires either OAuth2 authentication or API keys.
+    #[command(verbatim_doc_comment)]
+    CloudCost {
+        #[command(subcommand)]
+        action: CloudCostActions,
+    },
     /// Query code coverage data
     ///
     /// Query code coverage summaries from Datadog Test Optimization.
@@ -516,6 +733,40 @@ enum Commands {
         #[command(subcommand)]
         action: CodeCoverageActions,
     },
+    /// Generate code that recreates a resource
+    ///
+    /// Fetch an existing resource and emit an equivalent snippet for the
+    /// Datadog Go or Python API client, or a Terraform resource block.
+    ///
+    /// Server-managed fields (IDs, timestamps, state) are stripped so the output
+    /// creates a copy. Monitors and dashboards render as datadog_monitor_json /
+    /// datadog_dashboard_json; metric and monitor SLOs render as
+    /// datadog_service_level_objective.
+    ///
+    /// EXAMPLES:
+    ///   # Terraform for a monitor
+    ///   pup codegen --resource monitor --id 123 --lang terraform > monitor.tf
+    ///
+    ///   # Go client program that recreates a dashboard
+    ///   pup codegen --resource dashboard --id abc-def-ghi --lang go
+    ///
+    ///   # Python client snippet for an SLO
+    ///   pup codegen --resource slo --id 0123456789abcdef --lang python
+    ///
+    /// AUTHENTICATION:
+    ///   Requires either OAuth2 authentication or API keys.
+    #[command(verbatim_doc_comment)]
+    Codegen {
+        /// Resource type: monitor, dashboard, slo
+        #[arg(long, value_parser = ["monitor", "dashboard", "slo"])]
+        resource: String,
+        /// Resource ID
+        #[arg(long)]
+        id: String,
+        /// Output language: go, python, terraform
+        #[arg(long, value_parser = ["go", "python", "terraform"])]
+        lang: String,
+    },
     /// Generate shell completions
     ///
     /// Generate shell completions for pup.
@@ -546,6 +797,47 @@ enum Commands {
         /// Shell to generate completions for
         shell: clap_complete::Shell,
     },
+    /// Manage pup configuration
+    ///
+    /// Manage named profiles in ~/.config/pup/config.yaml. A profile bundles a
+    /// site, API/application keys, default output format, and org session so
+    /// you can switch between Datadog orgs with --profile or PUP_PROFILE.
+    ///
+    /// Profile values override the top-level config file values; environment
+    /// variables and flags still take precedence.
+    ///
+    /// 'config lint' checks config.yaml for unknown keys (e.g. a typo like
+    /// 'sitee:'), invalid site/output/time_format values, and aliases.yaml for
+    /// aliases that don't point at a pup command. It exits non-zero on errors.
+    ///
+    /// 'config show --sources' prints every effective setting with where it came
+    /// from (flag, env var, config file, or default), for tracking down why pup
+    /// is using the wrong site, org, or keys.
+    ///
+    /// EXAMPLES:
+    ///   # Check the config files for mistakes
+    ///   pup config lint
+    ///
+    ///   # Show effective settings and where each came from
+    ///   pup config show --sources
+    ///
+    ///   # Create or update a profile
+    ///   pup config profiles set staging --site datadoghq.eu --api-key-file ~/.dd/stg_api --app-key-file ~/.dd/stg_app
+    ///
+    ///   # List profiles (keys are masked)
+    ///   pup config profiles list
+    ///
+    ///   # Run a command against a profile
+    ///   pup --profile staging monitors list
+    ///   PUP_PROFILE=staging pup monitors list
+    ///
+    ///   # Delete a profile
+    ///   pup config profiles delete staging
+    #[command(verbatim_doc_comment)]
+    Config {
+        #[command(subcommand)]
+        action: ConfigActions,
+    },
     /// Manage cost and billing data
     ///
     /// Query cost management and billing information.
@@ -557,6 +849,7 @@ enum Commands {
     ///   • View projected end-of-month costs
     ///   • Get cost attribution by tags and teams
     ///   • Query historical and estimated costs by organization
+    ///   • Check how much spend is missing required allocation tags
     ///
     /// EXAMPLES:
     ///   # Get projected costs for current month
@@ -568,6 +861,9 @@ enum Commands {
     ///   # Get actual costs for a specific month
     ///   pup cost by-org --start-month=2024-01
     ///
+    ///   # Spend missing team/service/env tags over the last month, as CSV
+    ///   pup cost tag-compliance --required-tags team,service,env --from 1M --csv
+    ///
     /// AUTHENTICATION:
     ///   Requires OAuth2 (via 'pup auth login') or valid API + Application keys.
     ///   Cost management features require billing:read permissions.
@@ -587,6 +883,7 @@ enum Commands {
     /// CAPABILITIES:
     ///   • List all dashboards with metadata
     ///   • Get detailed dashboard configuration including all widgets
+    ///   • Diff a dashboard against a local JSON file, widget by widget
     ///   • Delete dashboards (requires confirmation unless --yes flag is used)
     ///   • View dashboard layouts, templates, and template variables
     ///
@@ -618,12 +915,38 @@ enum Commands {
     ///   # Get dashboard and save to file
     ///   pup dashboards get abc-def-123 > dashboard.json
     ///
+    ///   # Clone a golden dashboard for a team, retagged with new template defaults
+    ///   pup dashboards clone abc-def-123 --title "Payments Overview" \
+    ///     --add-tag team:payments --remove-tag team:platform \
+    ///     --template-var service=payments-api --template-var env=prod
+    ///
+    ///   # Clone into another org session (see 'pup auth login --org')
+    ///   pup dashboards clone abc-def-123 --to-org staging-child
+    ///
+    ///   # Export all dashboards to ./dashboards (one <title-slug>.json each)
+    ///   pup dashboards export --dir ./dashboards
+    ///
+    ///   # Preview, then push changed or new files back
+    ///   pup dashboards import --dir ./dashboards --dry-run
+    ///   pup dashboards import --dir ./dashboards
+    ///
+    ///   # Review widget-level drift before pushing a local copy
+    ///   pup dashboards diff abc-def-123 --file dashboards/golden-service.json
+    ///
     ///   # Delete a dashboard with confirmation
     ///   pup dashboards delete abc-def-123
     ///
     ///   # Delete a dashboard without confirmation (automation)
     ///   pup dashboards delete abc-def-123 --yes
     ///
+    ///   # Curate a dashboard list
+    ///   pup dashboards lists create --name "Payments on-call"
+    ///   pup dashboards lists add-items 4567 --dashboard abc-def-123,ghi-jkl-456
+    ///
+    ///   # Audit and revoke a dashboard's public links
+    ///   pup dashboards shares list abc-def-123
+    ///   pup dashboards shares revoke abc-def-123 --token <token>
+    ///
     /// TEMPLATE VARIABLES:
     ///   Dashboards can include template variables for dynamic filtering:
     ///   • $env: Environment filter
@@ -647,14 +970,23 @@ enum Commands {
     ///   • Manage sensitive data scanner
     ///   • Configure data deletion policies
     ///   • View scan results
-    ///   • Manage scanning rules
+    ///   • Manage scanning groups and rules, and their scanning order
+    ///   • List standard (library) patterns
     ///
     /// EXAMPLES:
     ///   # List scanning rules
     ///   pup data-governance scanner rules list
     ///
-    ///   # Get rule details
-    ///   pup data-governance scanner rules get rule-id
+    ///   # Groups in scanning order
+    ///   pup data-governance scanner groups list -o table
+    ///
+    ///   # Create a group, then a rule from a standard pattern in it
+    ///   pup data-governance scanner groups create --file pii-group.json
+    ///   pup data-governance scanner rules create --group <group-id> \
+    ///     --standard-pattern <pattern-id> --file email-rule.json
+    ///
+    ///   # Scan the secrets group first
+    ///   pup data-governance scanner groups reorder <group-id>
     ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication or API keys.
@@ -663,6 +995,30 @@ enum Commands {
         #[command(subcommand)]
         action: DataGovActions,
     },
+    /// Diagnose configuration, credential, and connectivity problems
+    ///
+    /// Runs local and network checks and prints a remediation hint for each
+    /// warning or failure. Exits non-zero when any check fails.
+    ///
+    /// CHECKS:
+    ///   • config_file   config.yaml parses and the selected profile exists
+    ///   • credentials   an OAuth token or both API and application keys are set
+    ///   • token         the stored OAuth token has not expired
+    ///   • site          DD_SITE is a known Datadog site
+    ///   • proxy         HTTPS_PROXY / HTTP_PROXY / ALL_PROXY are valid URLs
+    ///   • reachability  the API host answers
+    ///   • api_key       Datadog accepts the API key
+    ///   • clock         the local clock agrees with the server's
+    ///   • scopes        the token's scopes cover the commands in DD_PUP_LOG_FILE
+    ///
+    /// EXAMPLES:
+    ///   # Check the current setup
+    ///   pup doctor
+    ///
+    ///   # Check a specific profile
+    ///   pup --profile staging doctor
+    #[command(verbatim_doc_comment)]
+    Doctor,
     /// Manage monitor downtimes
     ///
     /// Manage downtimes to silence monitors during maintenance windows.
@@ -676,6 +1032,7 @@ enum Commands {
     ///   • Create new downtimes
     ///   • Update existing downtimes
     ///   • Cancel downtimes
+    ///   • Reconcile recurring mute windows from a YAML file (apply)
     ///
     /// EXAMPLES:
     ///   # List all active downtimes
@@ -687,9 +1044,13 @@ enum Commands {
     ///   # Cancel a downtime
     ///   pup downtime cancel abc-123-def
     ///
+    ///   # Preview, then apply, the maintenance calendar kept in git
+    ///   pup downtimes apply --file mute-windows.yaml --dry-run
+    ///   pup downtimes apply --file mute-windows.yaml
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication or API keys.
-    #[command(verbatim_doc_comment)]
+    #[command(verbatim_doc_comment, alias = "downtimes")]
     Downtime {
         #[command(subcommand)]
         action: DowntimeActions,
@@ -721,7 +1082,7 @@ enum Commands {
     },
     /// Manage Datadog events
     ///
-    /// Query and search Datadog events.
+    /// Query, search, and send Datadog events.
     ///
     /// Events represent important occurrences in your infrastructure such as
     /// deployments, configuration changes, alerts, and custom events.
@@ -730,6 +1091,8 @@ enum Commands {
     ///   • List recent events
     ///   • Search events with queries
     ///   • Get event details
+    ///   • Send change/alert events via the v2 intake with aggregation keys,
+    ///     related monitors and services, and optional dedup
     ///
     /// EXAMPLES:
     ///   # List recent events
@@ -741,6 +1104,16 @@ enum Commands {
     ///   # Get specific event
     ///   pup events get 1234567890
     ///
+    ///   # Send a deployment event grouped with its retries
+    ///   pup events send --title="Deploy web 1.2.3" --text="Rolled out to prod" \
+    ///     --aggregation-key=deploy-web-1.2.3 --source-type=github \
+    ///     --changed-resource=web --related-service=web --related-monitor=12345 \
+    ///     --tags=env:prod
+    ///
+    ///   # Skip sending if the same aggregation key was seen in the last 10 minutes
+    ///   pup events send --title="Deploy web 1.2.3" --aggregation-key=deploy-web-1.2.3 \
+    ///     --dedup-window=10m
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication or API keys.
     #[command(verbatim_doc_comment)]
@@ -748,6 +1121,111 @@ enum Commands {
         #[command(subcommand)]
         action: EventActions,
     },
+    /// Show ready-to-run examples for a command
+    ///
+    /// Prints concrete command lines for a command and everything under it,
+    /// drawn from parameterized templates and from each command's help text.
+    /// Template placeholders are filled from --var or sensible defaults.
+    ///
+    /// EXAMPLES:
+    ///   # Commands that have examples
+    ///   pup examples
+    ///
+    ///   # Log search examples for the api service
+    ///   pup examples logs search --var service=api
+    ///
+    ///   # Run the first one
+    ///   pup examples logs search --var service=api --index 1 --run
+    ///
+    /// --run executes exactly one example (narrow with --index) using this
+    /// pup binary, without a shell, so examples with pipes or redirects are
+    /// refused; examples that modify resources ask for confirmation first.
+    #[command(verbatim_doc_comment)]
+    Examples {
+        /// Command path, e.g. "logs search" (omit to list commands with examples)
+        command: Vec<String>,
+        #[arg(long = "var", help = "Fill a placeholder: key=value (repeatable)")]
+        vars: Vec<String>,
+        #[arg(long, help = "Only the Nth example (1-based)")]
+        index: Option<usize>,
+        #[arg(long, help = "Execute the selected example instead of printing it")]
+        run: bool,
+    },
+    /// Run one command against several org sessions or profiles concurrently
+    ///
+    /// Re-runs the given pup command once per named org session (--orgs, see
+    /// 'pup auth login --org') or config.yaml profile (--profiles), in
+    /// parallel, and prints the results merged into one object keyed by name.
+    /// Those that fail report {"error": ...} in place of their result, and pup
+    /// exits non-zero after printing.
+    ///
+    /// The org name "default" runs against the default session (no --org).
+    /// Each child runs with JSON output; --output, --jq, and --max-output-bytes
+    /// on fanout itself apply to the merged result. --yes, --agent, --timeout,
+    /// --connect-timeout, and (with --orgs) --profile are passed to every child.
+    ///
+    /// CAPABILITIES:
+    ///   • Fan a read command out across many orgs or profiles in one call
+    ///   • Bound parallelism with --concurrency
+    ///   • Collect per-org failures without aborting the other orgs
+    ///
+    /// EXAMPLES:
+    ///   # Monitors across two orgs
+    ///   pup fanout --orgs prod-us,prod-eu -- monitors list --tags="team:core"
+    ///
+    ///   # Monitor count per org, including the default session
+    ///   pup fanout --orgs prod-us,prod-eu,default -- monitors list | jq 'map_values(length)'
+    ///
+    ///   # Monitors across two config profiles, as a table, 30s per request
+    ///   pup --output table --timeout 30s fanout --profiles prod-us,prod-eu -- monitors list
+    ///
+    /// AUTHENTICATION:
+    ///   Each org needs its own session from 'pup auth login --org <name>';
+    ///   each profile uses the credentials it configures.
+    #[command(verbatim_doc_comment)]
+    Fanout {
+        #[arg(
+            long,
+            required_unless_present = "profiles",
+            conflicts_with = "profiles",
+            help = "Comma-separated org session names (\"default\" = no --org)"
+        )]
+        orgs: Option<String>,
+        #[arg(long, help = "Comma-separated config.yaml profile names")]
+        profiles: Option<String>,
+        #[arg(long, default_value_t = 8, help = "Maximum orgs queried at once")]
+        concurrency: usize,
+        /// Command and arguments to run for each org (after --)
+        #[arg(trailing_var_arg = true, allow_hyphen_values = true, required = true)]
+        command: Vec<String>,
+    },
+    /// Find resources by name across the org
+    ///
+    /// Searches monitors, dashboards, SLOs, notebooks, and synthetic tests in
+    /// parallel for names containing the query (case-insensitive) and returns
+    /// {type, id, name, url} matches: exact names first, then prefixes.
+    ///
+    /// EXAMPLES:
+    ///   # Where is "checkout" configured?
+    ///   pup find checkout
+    ///
+    ///   # Only monitors and SLOs, as a table
+    ///   pup find "checkout latency" --type monitor,slo -o table
+    ///
+    /// A type that can't be searched (for example a missing scope) is reported
+    /// on stderr and the other types are still returned.
+    #[command(verbatim_doc_comment)]
+    Find {
+        /// Name or part of a name
+        query: String,
+        #[arg(
+            long = "type",
+            help = "Resource types to search: monitor, dashboard, slo, notebook, synthetic (comma-separated or repeated)"
+        )]
+        types: Vec<String>,
+        #[arg(long, default_value_t = 50, help = "Most matches to print (0 = all)")]
+        limit: usize,
+    },
     /// Manage Fleet Automation
     ///
     /// Manage Fleet Automation for remote agent configuration and deployment.
@@ -758,6 +1236,7 @@ enum Commands {
     ///
     /// CAPABILITIES:
     ///   • List and inspect fleet agents
+    ///   • Check agent configuration against a policy file
     ///   • Manage deployment configurations
     ///   • Schedule configuration changes
     ///   • Monitor agent health and status
@@ -769,6 +1248,9 @@ enum Commands {
     ///   # Get agent details
     ///   pup fleet agents get <agent-key>
     ///
+    ///   # Export agents that drift from a policy as CSV
+    ///   pup fleet agents drift --policy policy.yaml --output csv
+    ///
     ///   # List deployments
     ///   pup fleet deployments list
     ///
@@ -820,6 +1302,8 @@ enum Commands {
     ///   • Get detailed incident information including timeline, tasks, and attachments
     ///   • View incident severity, status, and customer impact
     ///   • Track incident response and resolution
+    ///   • Export an incident with its timeline and postmortem as Markdown or Confluence
+    ///   • Stream new timeline entries during an active incident (--follow)
     ///
     /// INCIDENT SEVERITIES:
     ///   • SEV-1: Critical impact - complete service outage
@@ -838,6 +1322,12 @@ enum Commands {
     ///   # List all incidents
     ///   pup incidents list
     ///
+    ///   # Active SEV-1/SEV-2 customer-impacting incidents from the last week
+    ///   pup incidents list --status active --severity SEV-1,SEV-2 --customer-impacted true --from 7d
+    ///
+    ///   # Free-text search, oldest first, every page
+    ///   pup incidents list --query checkout --sort created --all
+    ///
     ///   # Get detailed incident information
     ///   pup incidents get abc-123-def
     ///
@@ -847,6 +1337,21 @@ enum Commands {
     ///   # Check incident status
     ///   pup incidents get abc-123-def | jq '{status: .data.status, severity: .data.severity}'
     ///
+    ///   # Export a postmortem document for an external wiki
+    ///   pup incidents export abc-123-def --format markdown > postmortem.md
+    ///   pup incidents export abc-123-def --format confluence
+    ///
+    ///   # Keep a live timeline open in a terminal pane
+    ///   pup incidents timeline abc-123-def --follow --interval 15
+    ///
+    ///   # Declare, annotate, and resolve an incident
+    ///   pup incidents create --title "Checkout errors" --severity sev2 --commander jane@example.com
+    ///   pup incidents timeline add abc-123-def --content "Rolled back v2.3.1"
+    ///   pup incidents update abc-123-def --status resolved
+    ///
+    ///   # Open a linked Jira issue and print its URL
+    ///   pup incidents create-ticket abc-123-def --account acme --project OPS
+    ///
     /// INCIDENT FIELDS:
     ///   • id: Incident ID
     ///   • title: Incident title
@@ -906,7 +1411,7 @@ enum Commands {
     ///
     /// CAPABILITIES:
     ///   • List Slack integrations
-    ///   • Manage PagerDuty integrations
+    ///   • Manage PagerDuty and Opsgenie services
     ///   • Configure webhook integrations
     ///   • View integration status
     ///
@@ -917,6 +1422,9 @@ enum Commands {
     ///   # List PagerDuty integrations
     ///   pup integrations pagerduty list
     ///
+    ///   # Add an Opsgenie service, reading the API key from the environment
+    ///   pup integrations opsgenie services create --name sre --secret-from-env OPSGENIE_KEY
+    ///
     ///   # List webhooks
     ///   pup integrations webhooks list
     ///
@@ -956,6 +1464,39 @@ enum Commands {
         #[command(subcommand)]
         action: InvestigationActions,
     },
+    /// Manage the IP allowlist for API and application keys
+    ///
+    /// When enabled, only requests from the listed CIDR blocks may use the org's
+    /// API and application keys. Changes read the current list, apply the edit,
+    /// and write the whole list back.
+    ///
+    /// CAPABILITIES:
+    ///   • Show whether the allowlist is enabled and its entries
+    ///   • Add and remove CIDR blocks without hand-writing JSON
+    ///   • Enable or disable the allowlist
+    ///
+    /// EXAMPLES:
+    ///   # Show the allowlist
+    ///   pup ip-allowlist get -o table
+    ///
+    ///   # Rotate an office IP (preview first with --dry-run)
+    ///   pup ip-allowlist update --remove-cidr 198.51.100.7 --add-cidr 203.0.113.9 --note "NYC office" --dry-run
+    ///   pup ip-allowlist update --remove-cidr 198.51.100.7 --add-cidr 203.0.113.9 --note "NYC office"
+    ///
+    ///   # Replace the whole list from a file
+    ///   pup ip-allowlist update --file allowlist.json
+    ///
+    ///   # Turn enforcement on or off
+    ///   pup ip-allowlist enable
+    ///   pup ip-allowlist disable
+    ///
+    /// AUTHENTICATION:
+    ///   Requires either OAuth2 authentication or API keys with org management access.
+    #[command(name = "ip-allowlist", verbatim_doc_comment)]
+    IpAllowlist {
+        #[command(subcommand)]
+        action: IpAllowlistActions,
+    },
     /// Search and analyze logs
     ///
     /// Search and analyze log data with flexible queries and time ranges.
@@ -968,8 +1509,11 @@ enum Commands {
     ///   • Search logs with flexible queries (v1 API)
     ///   • Query and aggregate logs (v2 API)
     ///   • List logs with filtering (v2 API)
+    ///   • Cluster sampled messages into recurring patterns (client-side)
     ///   • Search across different storage tiers (indexes, online-archives, flex)
     ///   • Manage log archives (CRUD operations)
+    ///   • Manage log indexes and exclusion filters
+    ///   • Manage log pipelines and their processing order
     ///   • Manage custom destinations for logs
     ///   • Create and manage log-based metrics
     ///   • Configure restriction queries for access control
@@ -979,6 +1523,7 @@ enum Commands {
     ///   • indexes - Standard indexed logs (default, real-time searchable)
     ///   • online-archives - Rehydrated logs from archives (slower queries, lower cost)
     ///   • flex - Flex logs (cost-optimized storage tier, balanced performance)
+    ///   Within the indexes tier, --indexes limits search and aggregate to named log indexes.
     ///
     /// LOG QUERY SYNTAX:
     ///   Logs use a query language similar to web search:
@@ -1009,18 +1554,42 @@ enum Commands {
     ///   # Query logs from a specific service
     ///   pup logs query --query="service:web-app" --from="4h" --to="now"
     ///
+    ///   # Stream new errors as they are indexed (Ctrl-C to stop)
+    ///   pup logs tail --query="status:error service:web-app" --follow
+    ///
+    ///   # Stream as JSON lines for jq
+    ///   pup logs tail --query="service:api" -f --format=json | jq .attributes.message
+    ///
+    ///   # Search only the main and audit indexes
+    ///   pup logs search --query="@usr.id:42" --from="1d" --indexes=main,audit
+    ///
     ///   # Query online archives
     ///   pup logs query --query="service:web-app" --from="30d" --storage="online-archives"
     ///
     ///   # Aggregate logs by status
     ///   pup logs aggregate --query="*" --compute="count" --group-by="status"
     ///
+    ///   # Find what is spamming the logs (top message patterns)
+    ///   pup logs pattern --query="service:web-app" --from="1h" --top=10
+    ///
     ///   # List log archives
     ///   pup logs archives list
     ///
     ///   # Get specific archive details
     ///   pup logs archives get "my-archive-id"
     ///
+    ///   # Check an archive's bucket, role, and write state
+    ///   pup logs archives validate "my-archive-id"
+    ///
+    ///   # Stop indexing health checks in the main index
+    ///   pup logs indexes update main --add-exclusion "health=path:/health"
+    ///
+    ///   # Create a pipeline from a definition kept in git
+    ///   pup logs pipelines create --file=pipelines/nginx.json
+    ///
+    ///   # Run the nginx pipeline before all others
+    ///   pup logs pipelines reorder "nginx-pipeline-id"
+    ///
     ///   # List log-based metrics
     ///   pup logs metrics list
     ///
@@ -1055,6 +1624,7 @@ enum Commands {
     ///   • Get and update metric metadata (description, unit, type)
     ///   • Submit custom metrics to Datadog
     ///   • List metric tags and tag configurations
+    ///   • Evaluate anomalies() / forecast() ad hoc and report flagged intervals
     ///
     /// METRIC TYPES:
     ///   • gauge: Point-in-time value (e.g., CPU usage, memory)
@@ -1095,6 +1665,10 @@ enum Commands {
     ///   pup metrics tags list system.cpu.user
     ///   pup metrics tags list system.cpu.user --from="1h"
     ///
+    ///   # Check for anomalies or a forecast breach before building a monitor
+    ///   pup metrics detect --query="avg:checkout.latency{env:prod}" --algorithm=anomalies --window=4h
+    ///   pup metrics detect --query="avg:system.disk.in_use{*} by {host}" --algorithm=forecast --threshold=0.9
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication (pup auth login) or API keys
     ///   (DD_API_KEY and DD_APP_KEY environment variables).
@@ -1140,6 +1714,9 @@ enum Commands {
     ///   • Get detailed information about a specific monitor
     ///   • Delete monitors (requires confirmation unless --yes flag is used)
     ///   • View monitor configuration, thresholds, and notification settings
+    ///   • Resolve composite monitors into a tree of child monitors and their states
+    ///   • Bulk retag monitors and rewrite notification handles in their messages
+    ///   • Show active downtimes and muted scopes alongside monitors (--include-downtimes)
     ///
     /// MONITOR TYPES:
     ///   • metric alert: Alert on metric threshold
@@ -1163,12 +1740,34 @@ enum Commands {
     ///   # Get detailed information about a specific monitor
     ///   pup monitors get 12345678
     ///
+    ///   # Is this alert real or silenced?
+    ///   pup monitors get 12345678 --include-downtimes | jq '.downtime_status'
+    ///   pup monitors list --tags="team:backend" --include-downtimes
+    ///
+    ///   # Show a composite monitor's children and their current states
+    ///   pup monitors composite-tree 12345678 --output table
+    ///
+    ///   # Preview a team handover across all matching monitors
+    ///   pup monitors rewrite --query 'tag:legacy-team' --add-tag team:payments \
+    ///     --remove-tag legacy-team --replace-in-message '@old-handle=@new-handle' --dry-run
+    ///
+    ///   # Snapshot production monitors to git, then push edited files back
+    ///   pup monitors export --dir ./monitors --tags env:prod
+    ///   pup monitors import --dir ./monitors --dry-run
+    ///
     ///   # Delete a monitor with confirmation prompt
     ///   pup monitors delete 12345678
     ///
     ///   # Delete a monitor without confirmation (automation)
     ///   pup monitors delete 12345678 --yes
     ///
+    ///   # List composites, SLOs, dashboards, and downtimes that use it before deleting
+    ///   pup monitors delete 12345678 --check-dependencies
+    ///
+    ///   # Delete every matching monitor; resume the same run after an interruption
+    ///   pup monitors bulk-delete --query "tag:team:legacy"
+    ///   pup monitors bulk-delete --resume pup-monitors-bulk-delete.json
+    ///
     /// OUTPUT FORMAT:
     ///   All commands output JSON by default. Use --output flag for other formats.
     ///
@@ -1237,6 +1836,17 @@ enum Commands {
     ///   # Update a notebook
     ///   pup notebooks update 12345 --body @updated.json
     ///
+    ///   # Copy a notebook under a new name
+    ///   pup notebooks clone 12345 --name "Payments runbook"
+    ///
+    ///   # Clone into another org session (see 'pup auth login --org')
+    ///   pup notebooks clone 12345 --to-org staging-child
+    ///
+    ///   # Edit individual cells
+    ///   pup notebooks cells list 12345
+    ///   pup notebooks cells update 12345 abc-123 --body @cell.json
+    ///   pup notebooks cells move 12345 abc-123 --position 1
+    ///
     ///   # Delete a notebook
     ///   pup notebooks delete 12345
     ///
@@ -1277,7 +1887,8 @@ enum Commands {
     },
     /// Manage teams and on-call operations
     ///
-    /// Manage teams, memberships, links, and notification rules.
+    /// Manage teams, memberships, links, notification rules, schedules,
+    /// escalation policies, and overrides.
     ///
     /// Teams in Datadog represent groups of users that collaborate on monitoring,
     /// incident response, and on-call duties. Use this command to manage team
@@ -1288,8 +1899,14 @@ enum Commands {
     ///   • Manage team memberships and roles
     ///   • Configure team links (documentation, runbooks)
     ///   • Set up notification rules for team alerts
+    ///   • Create and update on-call schedules and escalation policies
+    ///   • Create schedule overrides to automate swaps
+    ///   • Look up who is on call for a team right now
     ///
     /// EXAMPLES:
+    ///   # Who is on call for a team right now
+    ///   pup on-call who --team sre-team
+    ///
     ///   # List all teams
     ///   pup on-call teams list
     ///
@@ -1302,6 +1919,9 @@ enum Commands {
     ///   # List team members
     ///   pup on-call teams memberships list <team-id>
     ///
+    ///   # Cover a shift for 12 hours starting now
+    ///   pup on-call overrides create --schedule <schedule-id> --user <user-id> --end 12h
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication (pup auth login) or API keys.
     #[command(name = "on-call", verbatim_doc_comment)]
@@ -1318,6 +1938,7 @@ enum Commands {
     ///   • List child organizations
     ///   • Manage organization settings
     ///   • Configure billing and usage
+    ///   • Inspect SSO state: SAML login settings and IdP metadata
     ///
     /// EXAMPLES:
     ///   # Get organization details
@@ -1326,6 +1947,10 @@ enum Commands {
     ///   # List child organizations
     ///   pup organizations list
     ///
+    ///   # Verify SSO configuration after an IdP change
+    ///   pup organizations login-methods get
+    ///   pup organizations idp metadata get
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication or API keys with org management permissions.
     #[command(verbatim_doc_comment)]
@@ -1363,6 +1988,87 @@ enum Commands {
         #[command(subcommand)]
         action: ProductAnalyticsActions,
     },
+    /// Manage reference tables used to enrich logs
+    ///
+    /// Reference tables hold CSV data that log pipeline lookup processors join
+    /// into matching logs (for example, host ownership or customer tiers).
+    /// Local CSV files are uploaded in parts, so a cron job can refresh a table
+    /// with a single command. Failed part uploads are retried, and --wait polls
+    /// until Datadog has processed the file.
+    ///
+    /// CAPABILITIES:
+    ///   • List and get tables by ID or name
+    ///   • Create a table from a CSV with its primary key columns
+    ///   • Replace a table's rows from a CSV, keeping its schema
+    ///   • Wait for processing, exiting nonzero if the table errors
+    ///   • Delete tables
+    ///
+    /// EXAMPLES:
+    ///   # List tables whose name contains "hosts"
+    ///   pup reference-tables list --name hosts
+    ///
+    ///   # Create a table keyed on host, with an integer cost column
+    ///   pup reference-tables create --name host_owners --csv owners.csv \
+    ///     --primary-key host --int-column cost --description "Host owners from CMDB"
+    ///
+    ///   # Nightly refresh from cron, failing the job if the rows are rejected
+    ///   pup reference-tables update host_owners --csv owners.csv --wait
+    ///
+    ///   # Delete a table
+    ///   pup reference-tables delete host_owners
+    ///
+    /// AUTHENTICATION:
+    ///   Requires either OAuth2 authentication or API keys.
+    #[command(name = "reference-tables", verbatim_doc_comment)]
+    ReferenceTables {
+        #[command(subcommand)]
+        action: ReferenceTableActions,
+    },
+    /// Manage resource restriction policies
+    ///
+    /// Manage granular access (restriction policies) on individual resources.
+    ///
+    /// A restriction policy binds relations (editor, viewer) to principals
+    /// (roles, teams, users, or the whole org) for a single resource, identified
+    /// as <resource-type>:<id>.
+    ///
+    /// CAPABILITIES:
+    ///   • Get the restriction policy for a resource
+    ///   • Replace a resource's editor/viewer bindings
+    ///
+    /// RESOURCE TYPES (examples):
+    ///   • dashboard:<dashboard-id>
+    ///   • monitor:<monitor-id>
+    ///   • notebook:<notebook-id>
+    ///   • slo:<slo-id>
+    ///   • security-rule:<rule-id>
+    ///   • synthetics-test:<public-id>
+    ///
+    /// PRINCIPALS:
+    ///   role:<uuid>, team:<uuid>, user:<uuid>, org:<uuid>
+    ///
+    /// EXAMPLES:
+    ///   # Show who can edit/view a dashboard
+    ///   pup restriction-policies get dashboard:abc-def-ghi
+    ///
+    ///   # Restrict a monitor to one team, viewable by the whole org
+    ///   pup restriction-policies update monitor:12345 \
+    ///     --editor team:00000000-0000-0000-0000-000000000001 \
+    ///     --viewer org:00000000-0000-0000-0000-000000000002
+    ///
+    ///   # Apply a policy from a JSON file
+    ///   pup restriction-policies update security-rule:abc-123 --file policy.json
+    ///
+    /// NOTES:
+    ///   update replaces all bindings; relations not passed are removed.
+    ///
+    /// AUTHENTICATION:
+    ///   Requires either OAuth2 authentication or API keys.
+    #[command(name = "restriction-policies", verbatim_doc_comment)]
+    RestrictionPolicies {
+        #[command(subcommand)]
+        action: RestrictionPolicyActions,
+    },
     /// Manage Real User Monitoring (RUM)
     ///
     /// Manage Datadog Real User Monitoring (RUM) for frontend application performance.
@@ -1462,6 +2168,13 @@ enum Commands {
     ///   # Get rule details
     ///   pup security rules get rule-id
     ///
+    ///   # Create or update a rule from JSON (detection as code)
+    ///   pup security rules create --body @rule.json
+    ///   pup security rules update rule-id --body @rule.json
+    ///
+    ///   # Disable every rule owned by a team
+    ///   pup security rules disable --tags team:payments
+    ///
     ///   # List security signals
     ///   pup security signals list
     ///
@@ -1508,6 +2221,7 @@ enum Commands {
     ///
     /// CAPABILITIES:
     ///   • List all SLOs with status and error budget
+    ///   • Search SLOs by query with facet counts (team, service, env, type, ...)
     ///   • Get detailed SLO configuration and history
     ///   • Delete SLOs (requires confirmation unless --yes flag is used)
     ///   • View SLO status, error budget burn rate, and target compliance
@@ -1531,12 +2245,19 @@ enum Commands {
     ///   # List all SLOs
     ///   pup slos list
     ///
+    ///   # Search SLOs with facet breakdowns (paged for large orgs)
+    ///   pup slos search --query 'team:payments AND type:metric' --page 1 --page-size 50
+    ///
     ///   # Get detailed SLO information
     ///   pup slos get abc-123-def
     ///
     ///   # Get SLO history and status
     ///   pup slos get abc-123-def | jq '.data'
     ///
+    ///   # Propose SLOs for a service and write them as editable JSON files
+    ///   pup slos suggest --service api --env prod --dir ./slos
+    ///   pup slos create --file ./slos/api-http-request-p95-latency.json
+    ///
     ///   # Delete an SLO with confirmation
     ///   pup slos delete abc-123-def
     ///
@@ -1618,7 +2339,9 @@ enum Commands {
     ///   • List synthetic tests
     ///   • Search synthetic tests by text query
     ///   • Get test details
+    ///   • Create, update, delete, pause, and resume tests from JSON definitions
     ///   • Get test results
+    ///   • Trigger CI test runs and wait for the results
     ///   • List test locations
     ///   • Manage global variables
     ///
@@ -1636,6 +2359,15 @@ enum Commands {
     ///   # List available locations
     ///   pup synthetics locations list
     ///
+    ///   # Manage tests as code
+    ///   pup synthetics tests get abc-def-ghi > checkout.json
+    ///   pup synthetics tests update abc-def-ghi --body @checkout.json
+    ///   pup synthetics tests create --body @new-test.json
+    ///   pup synthetics tests pause abc-def-ghi
+    ///
+    ///   # Gate a pipeline on a test run
+    ///   pup synthetics tests trigger abc-def-ghi --wait --wait-timeout 10m
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication or API keys.
     #[command(verbatim_doc_comment)]
@@ -1656,6 +2388,7 @@ enum Commands {
     ///   • Add tags to a host
     ///   • Update host tags
     ///   • Remove tags from a host
+    ///   • Add or remove tags across many hosts at once
     ///
     /// EXAMPLES:
     ///   # List all host tags
@@ -1667,6 +2400,12 @@ enum Commands {
     ///   # Add tags to a host
     ///   pup tags add my-host env:prod team:backend
     ///
+    ///   # Tag every staging host
+    ///   pup tags bulk-add team:payments --filter "env:staging"
+    ///
+    ///   # Drop every env:* tag from the hosts listed in a file
+    ///   pup tags bulk-remove env --file hosts.txt
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication or API keys.
     #[command(verbatim_doc_comment)]
@@ -1698,6 +2437,9 @@ enum Commands {
     ///   # P99 latency by resource
     ///   pup traces aggregate --query="service:api" --compute="percentile(@duration, 99)" --group-by="resource_name"
     ///
+    ///   # Logs correlated with a trace
+    ///   pup traces logs 1234567890123456789
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication (apm_read scope) or API keys.
     #[command(verbatim_doc_comment)]
@@ -1705,6 +2447,43 @@ enum Commands {
         #[command(subcommand)]
         action: TracesActions,
     },
+    /// Interactive terminal status view
+    ///
+    /// Full-screen browser with panes for alerting monitors, unresolved
+    /// incidents, and the last 15 minutes of logs, refreshed periodically.
+    /// Select a row and press Enter to see its full JSON.
+    ///
+    /// KEYS:
+    ///   ↑/↓ or j/k     move the selection (PgUp/PgDn jump by 10)
+    ///   Enter          open the JSON detail; Esc goes back
+    ///   Tab, 1-3       switch pane
+    ///   r              refresh now
+    ///   q, Ctrl-C      quit
+    ///
+    /// EXAMPLES:
+    ///   # Everything alerting in production
+    ///   pup ui --scope env:prod
+    ///
+    ///   # One service, refreshed every 10 seconds
+    ///   pup ui --scope "env:prod service:checkout" --refresh 10
+    ///
+    /// The scope filters monitors by tag and is used as the logs query;
+    /// incidents are not filtered.
+    #[command(verbatim_doc_comment)]
+    Ui {
+        #[arg(
+            long,
+            default_value = "",
+            help = "Space-separated tags to scope monitors and logs"
+        )]
+        scope: String,
+        #[arg(
+            long,
+            default_value_t = 30,
+            help = "Seconds between automatic refreshes"
+        )]
+        refresh: u64,
+    },
     /// Query usage and billing information
     ///
     /// Query usage metrics and billing information for your organization.
@@ -1713,6 +2492,7 @@ enum Commands {
     ///   • View usage summary
     ///   • Get hourly usage
     ///   • Track usage by product
+    ///   • Report daily trends and day-over-day anomalies per product
     ///   • Monitor cost attribution
     ///
     /// EXAMPLES:
@@ -1722,6 +2502,9 @@ enum Commands {
     ///   # Get hourly usage
     ///   pup usage hourly --start="2024-01-01" --end="2024-01-02"
     ///
+    ///   # Daily trend per product over the last 30 days
+    ///   pup usage report --from 30d --output table
+    ///
     /// AUTHENTICATION:
     ///   Requires either OAuth2 authentication or API keys with billing permissions.
     #[command(verbatim_doc_comment)]
@@ -1759,6 +2542,65 @@ enum Commands {
     },
     /// Print version information
     Version,
+    /// Query Watchdog anomaly alerts
+    ///
+    /// Watchdog detections (latency, error-rate, and traffic anomalies) are
+    /// published as events with source:watchdog. These commands search them
+    /// and flatten each into id, time, title, service, resource, env, status,
+    /// and message, ready for piping into other tooling.
+    ///
+    /// CAPABILITIES:
+    ///   • List Watchdog alerts in a time window
+    ///   • Filter by service, resource, env, or an extra event query
+    ///   • Get the full event for one alert
+    ///
+    /// EXAMPLES:
+    ///   # Alerts for a service in the last day
+    ///   pup watchdog alerts list --service api --env prod --from 24h
+    ///
+    ///   # One endpoint, as JSON lines for another tool
+    ///   pup watchdog alerts list --service api --resource "GET /users" -o jsonl
+    ///
+    ///   # Full event payload
+    ///   pup watchdog alerts get AAAAAYb7...
+    ///
+    /// AUTHENTICATION:
+    ///   Requires API keys (DD_API_KEY + DD_APP_KEY); event search does not
+    ///   accept OAuth2 tokens.
+    #[command(verbatim_doc_comment)]
+    Watchdog {
+        #[command(subcommand)]
+        action: WatchdogActions,
+    },
+    /// Run and inspect Workflow Automation workflows
+    ///
+    /// Find workflows, kick off remediation runs from the terminal, and follow
+    /// or cancel their instances. Triggering requires the workflow to have an
+    /// API trigger; inputs are passed as the trigger payload.
+    ///
+    /// CAPABILITIES:
+    ///   • List and get workflows
+    ///   • Trigger a workflow with inputs from flags or a JSON file
+    ///   • List a workflow's instances and cancel a running one
+    ///
+    /// EXAMPLES:
+    ///   # Find a workflow by name
+    ///   pup workflows list --query restart
+    ///
+    ///   # Trigger it with inputs
+    ///   pup workflows trigger <workflow-id> --input service=api --input dry_run=false
+    ///
+    ///   # Recent runs, then cancel one
+    ///   pup workflows instances list <workflow-id> -o table
+    ///   pup workflows cancel <workflow-id> <instance-id>
+    ///
+    /// AUTHENTICATION:
+    ///   Requires either OAuth2 authentication or API keys.
+    #[command(verbatim_doc_comment)]
+    Workflows {
+        #[command(subcommand)]
+        action: WorkflowActions,
+    },
 }
 
 // ---- Monitors ----
@@ -1779,9 +2621,31 @@ enum MonitorActions {
             help = "Maximum number of monitors to return (default: 200, max: 1000)"
         )]
         limit: i32,
+        #[arg(
+            long,
+            help = "Annotate each monitor with active downtimes and muted scopes"
+        )]
+        include_downtimes: bool,
     },
     /// Get monitor details
-    Get { monitor_id: i64 },
+    Get {
+        #[arg(required_unless_present = "name")]
+        monitor_id: Option<i64>,
+        #[arg(
+            long,
+            conflicts_with = "monitor_id",
+            help = "Monitor name to look up instead of an ID"
+        )]
+        name: Option<String>,
+        #[arg(
+            long,
+            help = "Annotate the monitor with active downtimes and muted scopes"
+        )]
+        include_downtimes: bool,
+    },
+    /// Resolve a composite monitor into a tree of referenced monitors with their states
+    #[command(name = "composite-tree")]
+    CompositeTree { monitor_id: i64 },
     /// Create a monitor from JSON file
     Create {
         #[arg(long)]
@@ -1789,23 +2653,156 @@ enum MonitorActions {
     },
     /// Update a monitor from JSON file
     Update {
-        monitor_id: i64,
+        #[arg(required_unless_present = "name")]
+        monitor_id: Option<i64>,
+        #[arg(
+            long,
+            conflicts_with = "monitor_id",
+            help = "Monitor name to look up instead of an ID"
+        )]
+        name: Option<String>,
         #[arg(long)]
         file: String,
+        #[arg(
+            long,
+            help = "Check permissions and restriction policies before changing anything"
+        )]
+        preflight: bool,
     },
     /// Search monitors
     Search {
         #[arg(long, help = "Search query string")]
         query: Option<String>,
-        #[arg(long, default_value_t = 0, help = "Page number")]
+        #[arg(long, default_value_t = 0, help = "Page number (0-based)")]
         page: i64,
-        #[arg(long, default_value_t = 30, help = "Results per page")]
-        per_page: i64,
+        #[arg(
+            long,
+            alias = "per-page",
+            default_value_t = 30,
+            help = "Results per page"
+        )]
+        limit: i64,
         #[arg(long, help = "Sort order")]
         sort: Option<String>,
     },
     /// Delete a monitor
-    Delete { monitor_id: i64 },
+    Delete {
+        #[arg(required_unless_present = "name")]
+        monitor_id: Option<i64>,
+        #[arg(
+            long,
+            conflicts_with = "monitor_id",
+            help = "Monitor name to look up instead of an ID"
+        )]
+        name: Option<String>,
+        #[arg(
+            long,
+            help = "Check permissions and restriction policies before changing anything"
+        )]
+        preflight: bool,
+        #[arg(
+            long,
+            help = "Warn about composite monitors, SLOs, dashboards, and downtimes that reference the monitor"
+        )]
+        check_dependencies: bool,
+    },
+    /// Delete every monitor matching a search query, with checkpoint/resume
+    ///
+    /// Completed IDs are written to a checkpoint file after each delete. If the
+    /// run is interrupted (Ctrl-C, rate limiting, an expired token), continue it
+    /// with --resume <file>; the search and confirmation are not repeated.
+    /// Rate-limited requests are retried with backoff before the run stops.
+    #[command(name = "bulk-delete", verbatim_doc_comment)]
+    BulkDelete {
+        #[arg(
+            long,
+            required_unless_present = "resume",
+            help = "Monitor search query selecting monitors to delete"
+        )]
+        query: Option<String>,
+        #[arg(
+            long,
+            default_value = commands::monitors::BULK_DELETE_CHECKPOINT,
+            help = "Checkpoint file to record progress in"
+        )]
+        checkpoint: String,
+        #[arg(
+            long,
+            conflicts_with = "query",
+            help = "Resume the run recorded in this checkpoint file"
+        )]
+        resume: Option<String>,
+    },
+    /// Bulk retag monitors matching a search query and rewrite their messages
+    Rewrite {
+        #[arg(long, help = "Monitor search query selecting monitors to rewrite")]
+        query: String,
+        #[arg(long, help = "Tag to add (repeatable)")]
+        add_tag: Vec<String>,
+        #[arg(long, help = "Tag to remove (repeatable)")]
+        remove_tag: Vec<String>,
+        #[arg(long, help = "Message substitution as old=new (repeatable)")]
+        replace_in_message: Vec<String>,
+        #[arg(long, help = "Show the change report without updating monitors")]
+        dry_run: bool,
+        #[arg(long, default_value_t = 8, help = "Maximum concurrent API requests")]
+        concurrency: usize,
+    },
+    /// Export monitors to one JSON file per monitor, named by monitor name slug
+    ///
+    /// Files an earlier export wrote for monitors this export no longer includes
+    /// are removed; files without an id (not yet imported) are kept.
+    Export {
+        #[arg(long, default_value = "monitors", help = "Directory to write files to")]
+        dir: String,
+        #[arg(long, help = "Only export monitors with these tags (comma-separated)")]
+        tags: Option<String>,
+    },
+    /// Create or update monitors from the JSON files in a directory
+    ///
+    /// Files whose monitor id no longer exists are skipped, not re-created, and
+    /// two files with the same id are refused.
+    Import {
+        #[arg(
+            long,
+            default_value = "monitors",
+            help = "Directory to read files from"
+        )]
+        dir: String,
+        #[arg(long, help = "Report what would change without calling the API")]
+        dry_run: bool,
+    },
+    /// Timeline of a monitor's alert, warn, no-data, and recovery transitions
+    ///
+    /// Reads the monitor's notification events in the window and its current
+    /// per-group states. With -o table, prints a readable timeline.
+    ///
+    /// EXAMPLES:
+    ///   pup monitors history 12345 --from=1d -o table
+    ///   pup monitors history 12345 --from="2024-05-01T00:00:00Z" --to="2024-05-02T00:00:00Z"
+    #[command(verbatim_doc_comment)]
+    History {
+        monitor_id: i64,
+        #[arg(long, default_value = "1d", help = "Start time")]
+        from: String,
+        #[arg(long, default_value = "now", help = "End time")]
+        to: String,
+    },
+    /// Suggest alert and warning thresholds from the monitor's metric history, per evaluation window
+    Tune {
+        monitor_id: i64,
+        #[arg(
+            long,
+            default_value = "14d",
+            help = "History to analyze: 1d, 14d, 2w, ..."
+        )]
+        lookback: String,
+        #[arg(
+            long,
+            help = "Print an update body applying the suggestion (for 'monitors update --file')"
+        )]
+        patch: bool,
+    },
 }
 
 // ---- Logs ----
@@ -1831,10 +2828,23 @@ enum LogActions {
         limit: i32,
         #[arg(long, help = "Sort order: asc or desc", default_value = "desc")]
         sort: String,
-        #[arg(long, help = "Comma-separated log indexes")]
-        index: Option<String>,
+        #[arg(
+            long,
+            alias = "index",
+            value_delimiter = ',',
+            help = "Comma-separated log indexes to search (default: all indexes)"
+        )]
+        indexes: Vec<String>,
         #[arg(long, help = "Storage tier: indexes, online-archives, or flex")]
         storage: Option<String>,
+        #[arg(long, help = "Fetch every page (capped by --max-items)")]
+        all: bool,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum items to fetch with --all (0 = no cap)"
+        )]
+        max_items: usize,
     },
     /// List logs (v2 API)
     List {
@@ -1852,8 +2862,22 @@ enum LogActions {
         limit: i32,
         #[arg(long, default_value = "-timestamp", help = "Sort order")]
         sort: String,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "Comma-separated log indexes to search (default: all indexes)"
+        )]
+        indexes: Vec<String>,
         #[arg(long, help = "Storage tier: indexes, online-archives, or flex")]
         storage: Option<String>,
+        #[arg(long, help = "Fetch every page (capped by --max-items)")]
+        all: bool,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum items to fetch with --all (0 = no cap)"
+        )]
+        max_items: usize,
     },
     /// Query logs (v2 API)
     Query {
@@ -1871,6 +2895,12 @@ enum LogActions {
         limit: i32,
         #[arg(long, default_value = "-timestamp", help = "Sort order")]
         sort: String,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "Comma-separated log indexes to search (default: all indexes)"
+        )]
+        indexes: Vec<String>,
         #[arg(long, help = "Storage tier: indexes, online-archives, or flex")]
         storage: Option<String>,
         #[arg(long, help = "Timezone for timestamps")]
@@ -1894,14 +2924,94 @@ enum LogActions {
         group_by: Option<String>,
         #[arg(long, default_value_t = 10, help = "Maximum groups")]
         limit: i32,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "Comma-separated log indexes to search (default: all indexes)"
+        )]
+        indexes: Vec<String>,
         #[arg(long, help = "Storage tier: indexes, online-archives, or flex")]
         storage: Option<String>,
     },
+    /// Cluster sampled log messages into the top recurring patterns
+    Pattern {
+        #[arg(long, default_value = "*", help = "Log query to sample")]
+        query: String,
+        #[arg(
+            long,
+            default_value = "1h",
+            help = "Start time: 1h, 5min, 2hours, '5 minutes', RFC3339, Unix timestamp, or 'now'"
+        )]
+        from: String,
+        #[arg(long, default_value = "now", help = "End time")]
+        to: String,
+        #[arg(long, default_value_t = 1000, help = "Number of logs to sample")]
+        sample: usize,
+        #[arg(long, default_value_t = 10, help = "Number of patterns to show")]
+        top: usize,
+        #[arg(
+            long,
+            default_value_t = 0.5,
+            help = "Minimum token similarity (0-1) for two messages to share a pattern"
+        )]
+        similarity: f64,
+    },
+    /// Print recent logs and, with --follow, stream new ones as they arrive
+    Tail {
+        #[arg(long, default_value = "*", help = "Log query")]
+        query: String,
+        #[arg(
+            long,
+            default_value = "15m",
+            help = "How far back to look for the initial lines: 15m, 1h, RFC3339, Unix timestamp"
+        )]
+        from: String,
+        #[arg(
+            short = 'n',
+            long,
+            default_value_t = 20,
+            help = "Number of recent logs to print before following"
+        )]
+        lines: usize,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "Comma-separated log indexes to search (default: all indexes)"
+        )]
+        indexes: Vec<String>,
+        #[arg(
+            long,
+            help = "Stream format: pretty or json (default: pretty; json in agent mode)"
+        )]
+        format: Option<String>,
+        #[arg(
+            short = 'f',
+            long,
+            help = "Keep polling and print new logs as they are indexed"
+        )]
+        follow: bool,
+        #[arg(
+            long,
+            default_value_t = 2,
+            help = "Polling interval in seconds for --follow"
+        )]
+        interval: u64,
+    },
     /// Manage log archives
     Archives {
         #[command(subcommand)]
         action: LogArchiveActions,
     },
+    /// Manage log indexes and their exclusion filters
+    Indexes {
+        #[command(subcommand)]
+        action: LogIndexActions,
+    },
+    /// Manage log pipelines and their processing order
+    Pipelines {
+        #[command(subcommand)]
+        action: LogPipelineActions,
+    },
     /// Manage custom log destinations
     #[command(name = "custom-destinations")]
     CustomDestinations {
@@ -1935,8 +3045,97 @@ enum LogArchiveActions {
     List,
     /// Get log archive details
     Get { archive_id: String },
+    /// Create a log archive
+    Create {
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Update a log archive (replaces its definition)
+    Update {
+        archive_id: String,
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
     /// Delete a log archive
     Delete { archive_id: String },
+    /// Check an archive's bucket, role, and last reported write state
+    ///
+    /// Read-only. Exits non-zero when any check fails.
+    Validate { archive_id: String },
+}
+
+#[derive(Subcommand)]
+enum LogIndexActions {
+    /// List log indexes
+    List,
+    /// Get log index details, including exclusion filters
+    Get { name: String },
+    /// Edit an index's exclusion filters
+    ///
+    /// Removals apply first, then additions, then sample rates. A sample rate
+    /// is the fraction of matching logs excluded from the index (1 = all).
+    ///
+    /// EXAMPLES:
+    ///   # Stop indexing health checks
+    ///   pup logs indexes update main --add-exclusion "health=path:/health"
+    ///
+    ///   # Keep 10% of debug logs
+    ///   pup logs indexes update main --add-exclusion "debug=status:debug" --sample-rate debug=0.9
+    ///
+    ///   # Drop an exclusion filter
+    ///   pup logs indexes update main --remove-exclusion health
+    #[command(verbatim_doc_comment)]
+    Update {
+        name: String,
+        #[arg(
+            long = "add-exclusion",
+            help = "Add an exclusion filter, or change its query: name=query (repeatable)"
+        )]
+        add_exclusion: Vec<String>,
+        #[arg(
+            long = "remove-exclusion",
+            help = "Remove the exclusion filter with this name (repeatable)"
+        )]
+        remove_exclusion: Vec<String>,
+        #[arg(
+            long = "sample-rate",
+            help = "Fraction of matching logs to exclude: name=0.0-1.0 (repeatable)"
+        )]
+        sample_rate: Vec<String>,
+    },
+}
+
+#[derive(Subcommand)]
+enum LogPipelineActions {
+    /// List log pipelines in processing order
+    List,
+    /// Get log pipeline details
+    Get { pipeline_id: String },
+    /// Create a log pipeline
+    Create {
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Update a log pipeline (replaces its definition)
+    Update {
+        pipeline_id: String,
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Delete a log pipeline
+    Delete { pipeline_id: String },
+    /// Move pipelines to the front of the processing order
+    ///
+    /// The given pipelines run first, in the order listed; the rest keep
+    /// their current relative order.
+    ///
+    /// EXAMPLES:
+    ///   pup logs pipelines reorder abc-123 def-456
+    #[command(verbatim_doc_comment)]
+    Reorder {
+        #[arg(required = true)]
+        pipeline_ids: Vec<String>,
+    },
 }
 
 #[derive(Subcommand)]
@@ -1945,6 +3144,27 @@ enum LogCustomDestinationActions {
     List,
     /// Get custom destination details
     Get { destination_id: String },
+    /// Create a custom destination from a JSON body
+    ///
+    /// Put {{secret}} where a credential belongs (a header value or password)
+    /// and supply it with --secret-from-env or --secret-from-file; it is
+    /// filled in only when the request is sent and redacted from the output.
+    ///
+    /// EXAMPLES:
+    ///   pup logs custom-destinations create --body @splunk.json --secret-from-env SPLUNK_HEC_TOKEN
+    #[command(verbatim_doc_comment)]
+    Create {
+        #[arg(long, help = "JSON body: @file, - for stdin, or a path")]
+        body: String,
+        #[arg(
+            long,
+            conflicts_with = "secret_from_file",
+            help = "Environment variable holding the {{secret}} value"
+        )]
+        secret_from_env: Option<String>,
+        #[arg(long, help = "File holding the {{secret}} value")]
+        secret_from_file: Option<String>,
+    },
 }
 
 #[derive(Subcommand)]
@@ -1960,13 +3180,181 @@ enum LogMetricActions {
 // ---- Incidents ----
 #[derive(Subcommand)]
 enum IncidentActions {
-    /// List all incidents
+    /// List incidents, optionally filtered
+    ///
+    /// With any filter, --sort, --page, or --all the incidents search endpoint is
+    /// used; filters are ANDed together and comma-separated values are ORed.
     List {
-        #[arg(long, default_value_t = 50)]
+        #[arg(
+            long,
+            default_value_t = 50,
+            help = "Results per page (max 100 when filtering)"
+        )]
         limit: i64,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "Comma-separated states to include: active, stable, resolved"
+        )]
+        status: Vec<String>,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "Comma-separated severities to include, e.g. SEV-1,SEV-2"
+        )]
+        severity: Vec<String>,
+        #[arg(
+            long,
+            help = "Only customer-impacting (true) or non-impacting (false) incidents"
+        )]
+        customer_impacted: Option<bool>,
+        #[arg(long, help = "Created at or after (e.g. 7d, 24h, RFC3339)")]
+        from: Option<String>,
+        #[arg(long, help = "Created at or before (e.g. 1d, now, RFC3339)")]
+        to: Option<String>,
+        #[arg(long, help = "Free-text search or extra facets, e.g. \"checkout\"")]
+        query: Option<String>,
+        #[arg(long, help = "Sort order: -created (newest first) or created")]
+        sort: Option<String>,
+        #[arg(long, default_value_t = 0, help = "Page number (0-based)")]
+        page: i64,
+        #[arg(long, help = "Fetch every page (capped by --max-items)")]
+        all: bool,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum items to fetch with --all (0 = no cap)"
+        )]
+        max_items: usize,
     },
     /// Get incident details
     Get { incident_id: String },
+    /// Export incident metadata, timeline, and postmortem as a single document
+    Export {
+        incident_id: String,
+        #[arg(
+            long,
+            default_value = "markdown",
+            help = "Document format: markdown or confluence (storage format)"
+        )]
+        format: String,
+    },
+    /// Declare a new incident
+    Create {
+        #[arg(long, help = "Incident title (required)")]
+        title: String,
+        #[arg(
+            long,
+            help = "Severity: SEV-1 through SEV-5 or UNKNOWN (sev2 and 2 also work)"
+        )]
+        severity: Option<String>,
+        #[arg(long, help = "Incident commander: user UUID, email, or handle")]
+        commander: Option<String>,
+        #[arg(
+            long = "customer-impacted",
+            help = "Mark the incident as customer-impacting"
+        )]
+        customer_impacted: bool,
+        #[arg(
+            long = "customer-impact-scope",
+            help = "Description of the customer impact (implies --customer-impacted)"
+        )]
+        customer_impact_scope: Option<String>,
+    },
+    /// Update an incident's status, severity, title, commander, or impact
+    Update {
+        incident_id: String,
+        #[arg(long, help = "New status: active, stable, or resolved")]
+        status: Option<String>,
+        #[arg(long, help = "New severity: SEV-1 through SEV-5 or UNKNOWN")]
+        severity: Option<String>,
+        #[arg(long, help = "New title")]
+        title: Option<String>,
+        #[arg(long, help = "New incident commander: user UUID, email, or handle")]
+        commander: Option<String>,
+        #[arg(
+            long = "customer-impact-scope",
+            help = "Description of the customer impact (marks the incident customer-impacting)"
+        )]
+        customer_impact_scope: Option<String>,
+    },
+    /// Show an incident's timeline, optionally streaming new entries
+    #[command(args_conflicts_with_subcommands = true)]
+    Timeline {
+        #[command(subcommand)]
+        action: Option<IncidentTimelineActions>,
+        incident_id: Option<String>,
+        #[arg(long, help = "Keep polling and print new entries as they appear")]
+        follow: bool,
+        #[arg(
+            long,
+            default_value_t = 10,
+            help = "Polling interval in seconds for --follow"
+        )]
+        interval: u64,
+    },
+    /// Create a Jira issue linked to an incident and print its URL
+    ///
+    /// Jira only: incident integrations have no ServiceNow ticket type, so
+    /// ServiceNow tickets can't be created from an incident. Declare a case
+    /// for the incident and use 'pup cases servicenow create-ticket' instead.
+    #[command(name = "create-ticket", verbatim_doc_comment)]
+    CreateTicket {
+        incident_id: String,
+        #[arg(long, help = "Jira account (required)")]
+        account: String,
+        #[arg(long, help = "Jira project key (required)")]
+        project: String,
+        #[arg(long = "issue-type", help = "Jira issue type ID")]
+        issue_type: Option<String>,
+    },
+    /// Notify when open incidents stay open past a per-severity age threshold
+    ///
+    /// Polls open (active and stable) incidents and sends one notification per
+    /// incident and severity once it has been open longer than the matching
+    /// --escalate-after threshold. Notifications are Datadog alert events by
+    /// default, or a JSON POST to --webhook. Escalated incidents are remembered
+    /// in a state file so restarts and cron runs don't notify twice.
+    ///
+    /// EXAMPLES:
+    ///   # Long-lived watcher, escalating SEV-2s after 30 minutes
+    ///   pup incidents watch --escalate-after sev2=30m --notify @pagerduty-sre
+    ///
+    ///   # Cron: one pass every 5 minutes, posting to a Slack webhook
+    ///   pup incidents watch --escalate-after sev1=15m,sev2=30m,*=4h --once \
+    ///     --webhook https://hooks.slack.com/services/...
+    #[command(verbatim_doc_comment)]
+    Watch {
+        #[arg(
+            long = "escalate-after",
+            value_delimiter = ',',
+            required = true,
+            help = "Per-severity threshold, e.g. sev1=15m (repeatable; * matches any severity)"
+        )]
+        escalate_after: Vec<String>,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "Handle to mention in notifications, e.g. @slack-incidents (repeatable)"
+        )]
+        notify: Vec<String>,
+        #[arg(
+            long,
+            help = "POST notifications to this URL instead of sending events"
+        )]
+        webhook: Option<String>,
+        #[arg(long, help = "Run a single pass and exit (for cron)")]
+        once: bool,
+        #[arg(long, default_value_t = 60, help = "Polling interval in seconds")]
+        interval: u64,
+        #[arg(
+            long = "state-file",
+            help = "Escalation state file (default: incident-escalations.json in the config dir)"
+        )]
+        state_file: Option<String>,
+        #[arg(long = "dry-run", help = "Report overdue incidents without notifying")]
+        dry_run: bool,
+    },
     /// Manage incident attachments
     Attachments {
         #[command(subcommand)]
@@ -1990,6 +3378,19 @@ enum IncidentActions {
     },
 }
 
+#[derive(Subcommand)]
+enum IncidentTimelineActions {
+    /// Add a markdown note to an incident's timeline
+    Add {
+        incident_id: String,
+        #[arg(
+            long,
+            help = "Note content in markdown, or - to read from stdin (required)"
+        )]
+        content: String,
+    },
+}
+
 #[derive(Subcommand)]
 enum IncidentAttachmentActions {
     /// List incident attachments
@@ -2021,56 +3422,238 @@ enum IncidentHandleActions {
         #[arg(long, help = "JSON file with handle data (required)")]
         file: String,
     },
-    /// Update global incident handle
-    Update {
-        #[arg(long, help = "JSON file with handle data (required)")]
+    /// Update global incident handle
+    Update {
+        #[arg(long, help = "JSON file with handle data (required)")]
+        file: String,
+    },
+    /// Delete global incident handle
+    Delete { handle_id: String },
+}
+
+#[derive(Subcommand)]
+enum IncidentPostmortemActions {
+    /// List postmortem templates
+    List,
+    /// Get postmortem template
+    Get { template_id: String },
+    /// Create postmortem template
+    Create {
+        #[arg(long, help = "JSON file with template (required)")]
+        file: String,
+    },
+    /// Update postmortem template
+    Update {
+        template_id: String,
+        #[arg(long, help = "JSON file with template (required)")]
+        file: String,
+    },
+    /// Delete postmortem template
+    Delete { template_id: String },
+}
+
+// ---- Dashboards ----
+#[derive(Subcommand)]
+enum DashboardActions {
+    /// List all dashboards
+    List,
+    /// Get dashboard details
+    Get {
+        #[arg(required_unless_present = "name")]
+        id: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "id",
+            help = "Dashboard title to look up instead of an ID"
+        )]
+        name: Option<String>,
+    },
+    /// Create a dashboard from JSON file
+    Create {
+        #[arg(long)]
+        file: String,
+    },
+    /// Update a dashboard from JSON file
+    Update {
+        #[arg(required_unless_present = "name")]
+        id: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "id",
+            help = "Dashboard title to look up instead of an ID"
+        )]
+        name: Option<String>,
+        #[arg(long)]
+        file: String,
+        #[arg(
+            long,
+            help = "Check permissions and restriction policies before changing anything"
+        )]
+        preflight: bool,
+    },
+    /// Delete a dashboard
+    Delete {
+        #[arg(required_unless_present = "name")]
+        id: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "id",
+            help = "Dashboard title to look up instead of an ID"
+        )]
+        name: Option<String>,
+        #[arg(
+            long,
+            help = "Check permissions and restriction policies before changing anything"
+        )]
+        preflight: bool,
+    },
+    /// Copy a dashboard, rewriting title, tags, and template variable defaults
+    Clone {
+        id: String,
+        #[arg(long, help = "Title for the copy (default: \"<title> (copy)\")")]
+        title: Option<String>,
+        #[arg(long, help = "Tag to add (repeatable)")]
+        add_tag: Vec<String>,
+        #[arg(long, help = "Tag to remove (repeatable)")]
+        remove_tag: Vec<String>,
+        #[arg(long, help = "Template variable default as name=value (repeatable)")]
+        template_var: Vec<String>,
+        #[arg(long, help = "Create the copy in this named org session")]
+        to_org: Option<String>,
+    },
+    /// Export every dashboard to one JSON file per dashboard, named by title slug
+    ///
+    /// Files an earlier export wrote for dashboards since renamed or deleted are
+    /// removed; files without an id (not yet imported) are kept.
+    Export {
+        #[arg(
+            long,
+            default_value = "dashboards",
+            help = "Directory to write files to"
+        )]
+        dir: String,
+    },
+    /// Create or update dashboards from the JSON files in a directory
+    ///
+    /// Refuses a directory where two files carry the same dashboard id.
+    Import {
+        #[arg(
+            long,
+            default_value = "dashboards",
+            help = "Directory to read files from"
+        )]
+        dir: String,
+        #[arg(long, help = "Report what would change without calling the API")]
+        dry_run: bool,
+    },
+    /// Show added, removed, changed, and moved widgets between a dashboard and a local file
+    Diff {
+        id: String,
+        #[arg(long, help = "Local dashboard JSON to compare against")]
         file: String,
     },
-    /// Delete global incident handle
-    Delete { handle_id: String },
+    /// Manage dashboard lists (curated collections of dashboards)
+    Lists {
+        #[command(subcommand)]
+        action: DashboardListActions,
+    },
+    /// Audit, create, and revoke a dashboard's shared (public) links
+    Shares {
+        #[command(subcommand)]
+        action: DashboardShareActions,
+    },
 }
 
 #[derive(Subcommand)]
-enum IncidentPostmortemActions {
-    /// List postmortem templates
-    List,
-    /// Get postmortem template
-    Get { template_id: String },
-    /// Create postmortem template
+enum DashboardShareActions {
+    /// List a dashboard's shared links with type, expiration, and invitees
+    List { dashboard_id: String },
+    /// Share a dashboard by link
+    ///
+    /// Share types: open (anyone with the link), invite (viewers must sign in
+    /// with an invited email), and embed (for iframes).
+    ///
+    /// EXAMPLES:
+    ///   # Invite-only link that expires in 30 days
+    ///   pup dashboards shares create abc-def-ghi --type invite \
+    ///     --invite partner@example.com --expires 30d
+    ///
+    ///   # Rotate: revoke every existing link, then create a fresh one
+    ///   pup dashboards shares revoke abc-def-ghi --yes
+    ///   pup dashboards shares create abc-def-ghi --type open --expires 7d
+    #[command(verbatim_doc_comment)]
     Create {
-        #[arg(long, help = "JSON file with template (required)")]
-        file: String,
-    },
-    /// Update postmortem template
-    Update {
-        template_id: String,
-        #[arg(long, help = "JSON file with template (required)")]
-        file: String,
+        dashboard_id: String,
+        #[arg(
+            long = "type",
+            default_value = "invite",
+            help = "Share type: open, invite, or embed"
+        )]
+        share_type: String,
+        #[arg(
+            long = "invite",
+            value_delimiter = ',',
+            help = "Email allowed to view an invite share (repeatable)"
+        )]
+        invitees: Vec<String>,
+        #[arg(long, help = "Link expiry: a duration from now (30d) or RFC3339")]
+        expires: Option<String>,
+        #[arg(long, help = "Default time frame for viewers, e.g. 1h or 1d")]
+        live_span: Option<String>,
+        #[arg(long, help = "Let viewers change the time frame")]
+        selectable_time: bool,
+    },
+    /// Revoke one shared link (--token) or all of a dashboard's links
+    Revoke {
+        dashboard_id: String,
+        #[arg(
+            long,
+            help = "Share token to revoke (default: every share of the dashboard)"
+        )]
+        token: Option<String>,
     },
-    /// Delete postmortem template
-    Delete { template_id: String },
 }
 
-// ---- Dashboards ----
 #[derive(Subcommand)]
-enum DashboardActions {
-    /// List all dashboards
+enum DashboardListActions {
+    /// List dashboard lists
     List,
-    /// Get dashboard details
-    Get { id: String },
-    /// Create a dashboard from JSON file
+    /// Get a dashboard list and the dashboards in it
+    Get { list_id: i64 },
+    /// Create an empty dashboard list
     Create {
-        #[arg(long)]
-        file: String,
+        #[arg(long, help = "List name (required)")]
+        name: String,
     },
-    /// Update a dashboard from JSON file
-    Update {
-        id: String,
-        #[arg(long)]
-        file: String,
+    /// Delete a dashboard list (the dashboards themselves are kept)
+    Delete { list_id: i64 },
+    /// Add dashboards to a list
+    ///
+    /// EXAMPLES:
+    ///   pup dashboards lists add-items 4567 --dashboard abc-def-ghi --dashboard jkl-mno-pqr
+    #[command(name = "add-items", verbatim_doc_comment)]
+    AddItems {
+        list_id: i64,
+        #[arg(
+            long = "dashboard",
+            value_delimiter = ',',
+            required = true,
+            help = "Dashboard ID to add (repeatable or comma-separated)"
+        )]
+        dashboards: Vec<String>,
+    },
+    /// Remove dashboards from a list
+    #[command(name = "remove-items")]
+    RemoveItems {
+        list_id: i64,
+        #[arg(
+            long = "dashboard",
+            value_delimiter = ',',
+            required = true,
+            help = "Dashboard ID to remove (repeatable or comma-separated)"
+        )]
+        dashboards: Vec<String>,
     },
-    /// Delete a dashboard
-    Delete { id: String },
 }
 
 // ---- Metrics ----
@@ -2122,6 +3705,40 @@ enum MetricActions {
         )]
         to: String,
     },
+    /// Evaluate a query with anomalies() or forecast() and report flagged intervals
+    Detect {
+        #[arg(long, help = "Metric query to evaluate (required)")]
+        query: String,
+        #[arg(
+            long,
+            default_value = "anomalies",
+            help = "Detection algorithm: anomalies or forecast"
+        )]
+        algorithm: String,
+        #[arg(
+            long,
+            help = "anomalies: basic (default), agile, robust; forecast: linear (default), seasonal"
+        )]
+        method: Option<String>,
+        #[arg(long, default_value_t = 2.0, help = "Band width in deviations")]
+        bounds: f64,
+        #[arg(
+            long,
+            default_value = "4h",
+            help = "History to evaluate (anomalies) or feed the forecast"
+        )]
+        window: String,
+        #[arg(long, default_value = "1d", help = "How far ahead to forecast")]
+        horizon: String,
+        #[arg(
+            long,
+            allow_negative_numbers = true,
+            help = "Forecast: flag intervals where the forecast reaches this value"
+        )]
+        threshold: Option<f64>,
+        #[arg(long, help = "Forecast: flag values at or below --threshold instead")]
+        below: bool,
+    },
     /// Submit custom metrics to Datadog
     Submit {
         #[arg(
@@ -2130,8 +3747,13 @@ enum MetricActions {
             required_unless_present = "file"
         )]
         name: Option<String>,
-        #[arg(long, default_value_t = 0.0, help = "Metric value (required)")]
-        value: f64,
+        #[arg(
+            long,
+            allow_negative_numbers = true,
+            required_unless_present = "file",
+            help = "Metric value (required)"
+        )]
+        value: Option<f64>,
         #[arg(long, help = "Tags (comma-separated)")]
         tags: Option<String>,
         #[arg(
@@ -2148,7 +3770,17 @@ enum MetricActions {
             help = "Interval in seconds for rate/count metrics"
         )]
         interval: i64,
-        #[arg(long, help = "JSON file with metrics data", conflicts_with = "name")]
+        #[arg(
+            long,
+            default_value = "now",
+            help = "Point timestamp (now, Unix timestamp, RFC3339, or relative like 5m for five minutes ago)"
+        )]
+        timestamp: String,
+        #[arg(
+            long,
+            help = "JSON file with a /api/v2/series payload, or an array of series",
+            conflicts_with = "name"
+        )]
         file: Option<String>,
     },
     /// Manage metric metadata
@@ -2203,7 +3835,30 @@ enum SloActions {
     /// List all SLOs
     List,
     /// Get SLO details
-    Get { id: String },
+    Get {
+        #[arg(required_unless_present = "name")]
+        id: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "id",
+            help = "SLO name to look up instead of an ID"
+        )]
+        name: Option<String>,
+    },
+    /// Search SLOs with facet counts
+    Search {
+        #[arg(long, help = "Search query (e.g. 'team:payments AND type:metric')")]
+        query: Option<String>,
+        #[arg(long, default_value_t = 0, help = "Page number (0-based)")]
+        page: i64,
+        #[arg(
+            long,
+            alias = "page-size",
+            default_value_t = 25,
+            help = "Results per page"
+        )]
+        limit: i64,
+    },
     /// Create an SLO from JSON file
     Create {
         #[arg(long)]
@@ -2211,12 +3866,38 @@ enum SloActions {
     },
     /// Update an SLO from JSON file
     Update {
-        id: String,
+        #[arg(required_unless_present = "name")]
+        id: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "id",
+            help = "SLO name to look up instead of an ID"
+        )]
+        name: Option<String>,
         #[arg(long)]
         file: String,
+        #[arg(
+            long,
+            help = "Check permissions and restriction policies before changing anything"
+        )]
+        preflight: bool,
     },
     /// Delete an SLO
-    Delete { id: String },
+    Delete {
+        #[arg(required_unless_present = "name")]
+        id: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "id",
+            help = "SLO name to look up instead of an ID"
+        )]
+        name: Option<String>,
+        #[arg(
+            long,
+            help = "Check permissions and restriction policies before changing anything"
+        )]
+        preflight: bool,
+    },
     /// Get SLO status
     Status {
         id: String,
@@ -2225,6 +3906,108 @@ enum SloActions {
         #[arg(long, help = "End time (now, Unix timestamp, or RFC3339)")]
         to: String,
     },
+    /// Error-budget report: target, observed SLI, budget left, and burn rate
+    ///
+    /// Fetches each matching SLO's history for the window and computes the budget
+    /// client-side. A burn rate of 1.0 spends exactly the window's budget; rows are
+    /// sorted with the least budget left first.
+    ///
+    /// EXAMPLES:
+    ///   # Weekly ops review for one team
+    ///   pup slos report --tag team:payments --from 7d
+    ///
+    ///   # Several tags must all match
+    ///   pup slos report --tag team:payments --tag env:prod --from 30d --output table
+    #[command(verbatim_doc_comment)]
+    Report {
+        #[arg(long, help = "Only SLOs with this tag (repeatable; all must match)")]
+        tag: Vec<String>,
+        #[arg(
+            long,
+            default_value = "30d",
+            help = "Start of the window (7d, 30d, Unix timestamp, or RFC3339)"
+        )]
+        from: String,
+        #[arg(
+            long,
+            default_value = "now",
+            help = "End of the window (now, Unix timestamp, or RFC3339)"
+        )]
+        to: String,
+    },
+    /// Propose SLO definitions for a service from its monitors and APM metrics
+    Suggest {
+        #[arg(long, help = "Service name (matched against service:<name> tags)")]
+        service: String,
+        #[arg(long, help = "Environment (matched against env:<name> tags)")]
+        env: Option<String>,
+        #[arg(
+            long,
+            help = "Trace operation to use, e.g. http.request (repeatable; default: discover from trace.*.hits metrics)"
+        )]
+        operation: Vec<String>,
+        #[arg(long, default_value_t = 99.9, help = "SLO target percentage")]
+        target: f64,
+        #[arg(
+            long,
+            help = "Write one ready-to-edit JSON file per suggestion to this directory"
+        )]
+        dir: Option<String>,
+    },
+    /// Manage SLO status corrections (exclude maintenance windows from SLO calculations)
+    Corrections {
+        #[command(subcommand)]
+        action: SloCorrectionActions,
+    },
+}
+
+#[derive(Subcommand)]
+enum SloCorrectionActions {
+    /// List SLO corrections
+    List {
+        #[arg(long = "slo-id", help = "Only corrections for this SLO")]
+        slo_id: Option<String>,
+    },
+    /// Create an SLO correction
+    ///
+    /// EXAMPLES:
+    ///   # Exclude last night's maintenance window
+    ///   pup slos corrections create --slo-id abc123 --category scheduled-maintenance \
+    ///     --start 2024-06-01T22:00:00Z --end 2024-06-02T01:00:00Z --description "DB upgrade"
+    ///
+    ///   # Exclude a 2-hour window every Sunday
+    ///   pup slos corrections create --slo-id abc123 --category scheduled-maintenance \
+    ///     --start 2024-06-02T02:00:00Z --duration 2h --rrule "FREQ=WEEKLY;BYDAY=SU" --timezone UTC
+    #[command(verbatim_doc_comment)]
+    Create {
+        #[arg(long = "slo-id", help = "SLO ID (required)")]
+        slo_id: String,
+        #[arg(
+            long,
+            help = "Start time (RFC3339, Unix timestamp, or relative like 2h for two hours ago)"
+        )]
+        start: String,
+        #[arg(long, help = "End time (RFC3339, Unix timestamp, relative, or now)")]
+        end: Option<String>,
+        #[arg(
+            long,
+            help = "Length of each correction, e.g. 2h (instead of --end; required with --rrule)"
+        )]
+        duration: Option<String>,
+        #[arg(
+            long,
+            help = "scheduled-maintenance, outside-business-hours, deployment, or other (required)"
+        )]
+        category: String,
+        #[arg(long, help = "Description of the correction")]
+        description: Option<String>,
+        #[arg(long, help = "Timezone for the start time and recurrence, e.g. UTC")]
+        timezone: Option<String>,
+        #[arg(long, help = "iCalendar recurrence rule, e.g. FREQ=WEEKLY;BYDAY=SU")]
+        rrule: Option<String>,
+    },
+    /// Delete an SLO correction
+    Delete { correction_id: String },
 }
 
 // ---- Synthetics ----
@@ -2264,10 +4047,46 @@ enum SyntheticsTestActions {
     Search {
         #[arg(long, help = "Search text query")]
         text: Option<String>,
-        #[arg(long, default_value_t = 50)]
-        count: i64,
-        #[arg(long, default_value_t = 0)]
-        start: i64,
+        #[arg(long, alias = "count", default_value_t = 50, help = "Results per page")]
+        limit: i64,
+        #[arg(long, alias = "start", default_value_t = 0, help = "Results to skip")]
+        offset: i64,
+    },
+    /// Create a test from a JSON definition (type api, browser, or mobile)
+    Create {
+        #[arg(long, help = "JSON body (@filepath or - for stdin) (required)")]
+        body: String,
+    },
+    /// Replace a test's definition
+    Update {
+        public_id: String,
+        #[arg(long, help = "JSON body (@filepath or - for stdin) (required)")]
+        body: String,
+    },
+    /// Delete tests
+    Delete {
+        #[arg(required = true)]
+        public_ids: Vec<String>,
+    },
+    /// Pause a test so it stops running on its schedule
+    Pause { public_id: String },
+    /// Resume a paused test
+    Resume { public_id: String },
+    /// Trigger CI runs of tests, optionally waiting for the results
+    Trigger {
+        #[arg(required = true, help = "Public IDs of the tests to run")]
+        public_ids: Vec<String>,
+        #[arg(
+            long,
+            help = "Poll until the batch finishes; exit nonzero if a blocking result fails"
+        )]
+        wait: bool,
+        #[arg(
+            long,
+            default_value = "10m",
+            help = "Maximum time to wait with --wait (e.g. 30s, 10m, 1h)"
+        )]
+        wait_timeout: String,
     },
 }
 
@@ -2341,6 +4160,50 @@ enum EventActions {
     },
     /// Get event details
     Get { event_id: i64 },
+    /// Send an event through the v2 intake
+    Send {
+        #[arg(long, help = "Event title")]
+        title: String,
+        #[arg(long, default_value = "", help = "Event message body")]
+        text: String,
+        #[arg(
+            long,
+            default_value = "change",
+            help = "Event category: change or alert"
+        )]
+        category: String,
+        #[arg(
+            long,
+            default_value = "warn",
+            help = "Alert status for --category=alert: error, warn, or ok"
+        )]
+        alert_status: String,
+        #[arg(long, help = "Groups related events together in the explorer")]
+        aggregation_key: Option<String>,
+        #[arg(long, help = "Host the event relates to")]
+        host: Option<String>,
+        #[arg(long, help = "Comma-separated tags (e.g., env:prod,team:web)")]
+        tags: Option<String>,
+        #[arg(
+            long,
+            help = "Related monitor ID (repeatable; tagged as monitor_id:<id>)"
+        )]
+        related_monitor: Vec<i64>,
+        #[arg(long, help = "Impacted service for change events (repeatable)")]
+        related_service: Vec<String>,
+        #[arg(long, help = "Event source type (tagged as source:<type>)")]
+        source_type: Option<String>,
+        #[arg(
+            long,
+            help = "Name of the resource a change event changed (default: the title)"
+        )]
+        changed_resource: Option<String>,
+        #[arg(
+            long,
+            help = "Skip sending if an event with the same --aggregation-key, --source-type, and --tags exists within this window (e.g. 10m)"
+        )]
+        dedup_window: Option<String>,
+    },
 }
 
 // ---- Downtime ----
@@ -2357,6 +4220,31 @@ enum DowntimeActions {
     },
     /// Cancel a downtime
     Cancel { id: String },
+    /// Create, update, and cancel managed recurring downtimes to match a YAML file
+    ///
+    /// Each window is matched to its downtime by name, which is recorded in the
+    /// downtime message. Windows removed from the file are cancelled after a
+    /// confirmation prompt (skipped with --yes); other downtimes are left alone.
+    ///
+    /// FILE FORMAT:
+    ///   downtimes:
+    ///     - name: weekly-db-maintenance
+    ///       scope: env:prod AND service:db
+    ///       monitor_tags: [team:db]        # or monitor_id: 123 (default: all monitors)
+    ///       timezone: Europe/Paris         # default: UTC
+    ///       recurrences:
+    ///         - rrule: FREQ=WEEKLY;BYDAY=SU
+    ///           start: "2026-01-04T02:00"  # optional, local to timezone
+    ///           duration: 2h
+    ///       message: Weekly vacuum @slack-db-oncall
+    ///       mute_first_recovery_notification: true
+    #[command(verbatim_doc_comment)]
+    Apply {
+        #[arg(long, help = "YAML file listing the mute windows")]
+        file: String,
+        #[arg(long, help = "Report what would change without calling the API")]
+        dry_run: bool,
+    },
 }
 
 // ---- Tags ----
@@ -2372,6 +4260,116 @@ enum TagActions {
     Update { hostname: String, tags: Vec<String> },
     /// Delete all tags from a host
     Delete { hostname: String },
+    /// Add tags to every host matching a filter or listed in a file
+    ///
+    /// Hosts come from --filter (host map search, e.g. "env:staging"), --file
+    /// (one hostname per line, # comments allowed), or both. Each host gets a
+    /// result row; the command exits non-zero if any host failed.
+    #[command(verbatim_doc_comment)]
+    BulkAdd {
+        #[arg(required = true, help = "Tags to add (key:value)")]
+        tags: Vec<String>,
+        #[arg(long, help = "Host filter, e.g. env:staging")]
+        filter: Option<String>,
+        #[arg(long, help = "File of hostnames, one per line")]
+        file: Option<String>,
+        #[arg(long, default_value_t = 8, help = "Maximum concurrent API requests")]
+        concurrency: usize,
+    },
+    /// Remove tags from every host matching a filter or listed in a file
+    ///
+    /// A bare key (env) removes every value of that key; key:value removes
+    /// only that tag. Only user-set tags are touched.
+    #[command(verbatim_doc_comment)]
+    BulkRemove {
+        #[arg(required = true, help = "Tags (key:value) or keys to remove")]
+        tags: Vec<String>,
+        #[arg(long, help = "Host filter, e.g. env:staging")]
+        filter: Option<String>,
+        #[arg(long, help = "File of hostnames, one per line")]
+        file: Option<String>,
+        #[arg(long, default_value_t = 8, help = "Maximum concurrent API requests")]
+        concurrency: usize,
+    },
+}
+
+// ---- Watchdog ----
+#[derive(Subcommand)]
+enum WatchdogActions {
+    /// Watchdog anomaly alerts
+    Alerts {
+        #[command(subcommand)]
+        action: WatchdogAlertActions,
+    },
+}
+
+#[derive(Subcommand)]
+enum WatchdogAlertActions {
+    /// List Watchdog alerts, newest first
+    List {
+        #[arg(long, default_value = "1h", help = "Start time")]
+        from: String,
+        #[arg(long, default_value = "now", help = "End time")]
+        to: String,
+        #[arg(long, help = "Service name")]
+        service: Option<String>,
+        #[arg(long, help = "Resource name (endpoint)")]
+        resource: Option<String>,
+        #[arg(long, help = "Environment")]
+        env: Option<String>,
+        #[arg(long, help = "Additional event query")]
+        query: Option<String>,
+        #[arg(long, default_value_t = 100, help = "Maximum results (max 1000)")]
+        limit: i32,
+    },
+    /// Get the full event for a Watchdog alert
+    Get { alert_id: String },
+}
+
+// ---- Workflows ----
+#[derive(Subcommand)]
+enum WorkflowActions {
+    /// List workflows
+    List {
+        #[arg(long, help = "Filter workflows by name")]
+        query: Option<String>,
+        #[arg(long, default_value_t = 50, help = "Results per page (max 100)")]
+        limit: i64,
+        #[arg(long, default_value_t = 0, help = "Page number (0-based)")]
+        page: i64,
+    },
+    /// Get a workflow's definition
+    Get { workflow_id: String },
+    /// Start a workflow run through its API trigger
+    Trigger {
+        workflow_id: String,
+        #[arg(long = "input", help = "Workflow input as key=value (repeatable)")]
+        inputs: Vec<String>,
+        #[arg(long, help = "JSON object of inputs: @file, file, or - for stdin")]
+        payload: Option<String>,
+    },
+    /// Cancel a running workflow instance
+    Cancel {
+        workflow_id: String,
+        instance_id: String,
+    },
+    /// Workflow runs
+    Instances {
+        #[command(subcommand)]
+        action: WorkflowInstanceActions,
+    },
+}
+
+#[derive(Subcommand)]
+enum WorkflowInstanceActions {
+    /// List a workflow's instances
+    List {
+        workflow_id: String,
+        #[arg(long, default_value_t = 50, help = "Results per page (max 100)")]
+        limit: i64,
+        #[arg(long, default_value_t = 0, help = "Page number (0-based)")]
+        page: i64,
+    },
 }
 
 // ---- Users ----
@@ -2381,6 +4379,30 @@ enum UserActions {
     List,
     /// Get user details
     Get { user_id: String },
+    /// Create users and email them an invitation
+    ///
+    /// Each email becomes a user with the given roles (names or IDs) and gets
+    /// an invitation. A failed email doesn't stop the rest; every email gets a
+    /// result row and the command exits non-zero if any failed.
+    ///
+    /// EXAMPLES:
+    ///   pup users invite --email ana@example.com --role "Datadog Standard Role"
+    ///
+    ///   # Onboarding wave: one email per line, # comments allowed
+    ///   pup users invite --file wave3.txt --role "Datadog Read Only Role" -o table
+    #[command(verbatim_doc_comment)]
+    Invite {
+        #[arg(long = "email", help = "Email to invite (repeatable)")]
+        emails: Vec<String>,
+        #[arg(long, help = "File of emails, one per line")]
+        file: Option<String>,
+        #[arg(long = "role", help = "Role name or ID to grant (repeatable)")]
+        roles: Vec<String>,
+    },
+    /// Disable a user (DESTRUCTIVE)
+    ///
+    /// The user can no longer log in; what they own is kept.
+    Disable { user_id: String },
     /// Manage roles
     Roles {
         #[command(subcommand)]
@@ -2392,6 +4414,20 @@ enum UserActions {
 enum UserRoleActions {
     /// List roles
     List,
+    /// Add users to a role
+    Assign {
+        #[arg(long, help = "Role name or ID")]
+        role: String,
+        #[arg(required = true, help = "User IDs")]
+        user_ids: Vec<String>,
+    },
+    /// Remove users from a role
+    Remove {
+        #[arg(long, help = "Role name or ID")]
+        role: String,
+        #[arg(required = true, help = "User IDs")]
+        user_ids: Vec<String>,
+    },
 }
 
 // ---- Infrastructure ----
@@ -2412,8 +4448,8 @@ enum InfraHostActions {
         filter: Option<String>,
         #[arg(long, default_value = "status", help = "Sort field")]
         sort: String,
-        #[arg(long, default_value_t = 100, help = "Maximum hosts")]
-        count: i64,
+        #[arg(long, alias = "count", default_value_t = 100, help = "Maximum hosts")]
+        limit: i64,
     },
     /// Get host details
     Get { hostname: String },
@@ -2442,6 +4478,37 @@ enum AuditLogActions {
         #[arg(long, default_value_t = 100, help = "Maximum results")]
         limit: i32,
     },
+    /// Export every audit event in a window to a JSON lines file
+    ///
+    /// Pages through all matching events oldest first and writes one JSON
+    /// object per line. With --follow, keeps polling and appends new events
+    /// until interrupted.
+    ///
+    /// EXAMPLES:
+    ///   # Yesterday's audit trail
+    ///   pup audit-logs export --from=2d --to=1d --output-file=audit.jsonl
+    ///
+    ///   # Dump the last week, then keep appending new events
+    ///   pup audit-logs export --from=7d --output-file=audit.jsonl --follow
+    #[command(verbatim_doc_comment)]
+    Export {
+        #[arg(long, default_value = "*", help = "Search query")]
+        query: String,
+        #[arg(long, default_value = "1h", help = "Start time")]
+        from: String,
+        #[arg(long, default_value = "now", help = "End time")]
+        to: String,
+        #[arg(long, help = "File to write (created or truncated)")]
+        output_file: String,
+        #[arg(long, help = "Keep exporting new events as they arrive")]
+        follow: bool,
+        #[arg(
+            long,
+            default_value_t = 30,
+            help = "Seconds between polls with --follow"
+        )]
+        interval: u64,
+    },
 }
 
 // ---- Security ----
@@ -2491,6 +4558,41 @@ enum SecurityRuleActions {
         /// Rule IDs to export
         rule_ids: Vec<String>,
     },
+    /// Create a rule from JSON
+    Create {
+        #[arg(long, help = "Rule JSON: @file, - for stdin, or a path (required)")]
+        body: String,
+    },
+    /// Replace a rule's settings from JSON
+    Update {
+        rule_id: String,
+        #[arg(long, help = "Rule JSON: @file, - for stdin, or a path (required)")]
+        body: String,
+    },
+    /// Delete a custom rule
+    Delete { rule_id: String },
+    /// Enable rules by ID, or every rule with the given tags
+    Enable {
+        rule_ids: Vec<String>,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            conflicts_with = "rule_ids",
+            help = "Select rules carrying all of these tags (comma-separated)"
+        )]
+        tags: Vec<String>,
+    },
+    /// Disable rules by ID, or every rule with the given tags
+    Disable {
+        rule_ids: Vec<String>,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            conflicts_with = "rule_ids",
+            help = "Select rules carrying all of these tags (comma-separated)"
+        )]
+        tags: Vec<String>,
+    },
 }
 
 #[derive(Subcommand)]
@@ -2518,6 +4620,14 @@ enum SecurityFindingActions {
         query: Option<String>,
         #[arg(long, default_value_t = 100)]
         limit: i64,
+        #[arg(long, help = "Fetch every page (capped by --max-items)")]
+        all: bool,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum items to fetch with --all (0 = no cap)"
+        )]
+        max_items: usize,
     },
 }
 
@@ -2547,6 +4657,38 @@ enum OrgActions {
     List,
     /// Get organization details
     Get,
+    /// Inspect login settings (SAML, strict mode, IdP-initiated login)
+    #[command(name = "login-methods")]
+    LoginMethods {
+        #[command(subcommand)]
+        action: OrgLoginMethodActions,
+    },
+    /// Inspect identity provider (SAML IdP) configuration
+    Idp {
+        #[command(subcommand)]
+        action: OrgIdpActions,
+    },
+}
+
+#[derive(Subcommand)]
+enum OrgLoginMethodActions {
+    /// Get the org's login methods and SAML settings
+    Get,
+}
+
+#[derive(Subcommand)]
+enum OrgIdpActions {
+    /// Inspect SAML IdP metadata
+    Metadata {
+        #[command(subcommand)]
+        action: OrgIdpMetadataActions,
+    },
+}
+
+#[derive(Subcommand)]
+enum OrgIdpMetadataActions {
+    /// Get IdP metadata upload state, IdP endpoint, and SAML login URL
+    Get,
 }
 
 // ---- Cloud ----
@@ -2576,20 +4718,82 @@ enum CloudActions {
 
 #[derive(Subcommand)]
 enum CloudAwsActions {
-    /// List AWS integrations
-    List,
+    /// List integrated AWS accounts
+    List {
+        #[arg(long, help = "Only the account with this 12-digit AWS account ID")]
+        aws_account_id: Option<String>,
+    },
+    /// Get an AWS account by config ID or 12-digit AWS account ID
+    Get { account_id: String },
+    /// Integrate an AWS account
+    Create {
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Update an AWS account by config ID or 12-digit AWS account ID
+    Update {
+        account_id: String,
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Remove an AWS account by config ID or 12-digit AWS account ID
+    Delete { account_id: String },
+    /// Generate an external ID for the IAM role trust policy
+    #[command(name = "generate-external-id")]
+    GenerateExternalId,
 }
 
 #[derive(Subcommand)]
 enum CloudGcpActions {
     /// List GCP integrations
-    List,
+    List {
+        #[arg(long, help = "List STS (keyless) service accounts instead")]
+        sts: bool,
+    },
+    /// Add a GCP STS service account
+    ///
+    /// The file is checked before upload: it must name a service account
+    /// client_email, and key files are refused since STS accounts are keyless.
+    #[command(verbatim_doc_comment)]
+    Create {
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Update a GCP STS service account
+    Update {
+        account_id: String,
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Remove a GCP STS service account
+    Delete { account_id: String },
 }
 
 #[derive(Subcommand)]
 enum CloudAzureActions {
     /// List Azure integrations
     List,
+    /// Add an Azure app registration
+    ///
+    /// The file is checked before upload: tenant_name and client_id must be
+    /// GUIDs and client_secret must be set.
+    #[command(verbatim_doc_comment)]
+    Create {
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Update an Azure app registration (identified by tenant_name and client_id in the file)
+    Update {
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Remove an Azure app registration
+    Delete {
+        #[arg(long, help = "Azure tenant ID (required)")]
+        tenant_id: String,
+        #[arg(long, help = "App registration client ID (required)")]
+        client_id: String,
+    },
 }
 
 #[derive(Subcommand)]
@@ -2636,6 +4840,55 @@ enum CloudOciTenancyActions {
     Delete { tenancy_id: String },
 }
 
+// ---- Cloud Cost ----
+#[derive(Subcommand)]
+enum CloudCostActions {
+    /// AWS Cost and Usage Report configs
+    Aws {
+        #[command(subcommand)]
+        action: CloudCostConfigActions,
+    },
+    /// Azure cost export configs
+    Azure {
+        #[command(subcommand)]
+        action: CloudCostConfigActions,
+    },
+    /// GCP billing export configs
+    Gcp {
+        #[command(subcommand)]
+        action: CloudCostConfigActions,
+    },
+    /// Ingestion status and errors for every config
+    Status {
+        #[arg(long, help = "Only this provider: aws, azure, gcp")]
+        provider: Option<String>,
+    },
+}
+
+#[derive(Subcommand)]
+enum CloudCostConfigActions {
+    /// List configs
+    List,
+    /// Create a config
+    Create {
+        #[arg(
+            long,
+            help = "JSON file with config attributes or full request body (required)"
+        )]
+        file: String,
+    },
+    /// Update a config
+    Update {
+        config_id: String,
+        #[arg(long, help = "JSON file with attributes to change")]
+        file: Option<String>,
+        #[arg(long, help = "Enable or disable ingestion (true/false)")]
+        enabled: Option<bool>,
+    },
+    /// Delete a config
+    Delete { config_id: String },
+}
+
 // ---- Cases ----
 #[derive(Subcommand)]
 enum CaseActions {
@@ -2643,10 +4896,20 @@ enum CaseActions {
     Search {
         #[arg(long, help = "Search query")]
         query: Option<String>,
-        #[arg(long, default_value_t = 10, help = "Results per page")]
-        page_size: i64,
-        #[arg(long, default_value_t = 0, help = "Page number")]
-        page_number: i64,
+        #[arg(
+            long,
+            alias = "page-size",
+            default_value_t = 10,
+            help = "Results per page"
+        )]
+        limit: i64,
+        #[arg(
+            long,
+            alias = "page-number",
+            default_value_t = 0,
+            help = "Page number (0-based)"
+        )]
+        page: i64,
     },
     /// Get case details
     Get { case_id: String },
@@ -2675,18 +4938,52 @@ enum CaseActions {
     /// Assign a case to a user
     Assign {
         case_id: String,
-        #[arg(long, help = "User UUID (required)")]
-        user_id: String,
+        #[arg(
+            long = "user",
+            alias = "user-id",
+            help = "User UUID, email, or handle (required)"
+        )]
+        user: String,
+    },
+    /// Set case status
+    ///
+    /// EXAMPLES:
+    ///   pup cases status <case-id> in_progress
+    ///   pup cases status <case-id> closed
+    #[command(verbatim_doc_comment)]
+    Status {
+        case_id: String,
+        #[arg(value_parser = ["open", "in_progress", "closed"], ignore_case = true)]
+        status: String,
+    },
+    /// Set case priority
+    ///
+    /// EXAMPLES:
+    ///   pup cases priority <case-id> P2
+    #[command(verbatim_doc_comment)]
+    Priority {
+        case_id: String,
+        #[arg(value_parser = ["P1", "P2", "P3", "P4", "P5", "NOT_DEFINED"], ignore_case = true)]
+        priority: String,
+    },
+    /// Add a comment to a case's timeline
+    Comment {
+        case_id: String,
+        #[arg(
+            long,
+            help = "Comment text, or @file to read it from a file (required)"
+        )]
+        message: String,
     },
-    /// Update case priority
-    #[command(name = "update-priority")]
+    /// Update case priority (use `priority`)
+    #[command(name = "update-priority", hide = true)]
     UpdatePriority {
         case_id: String,
         #[arg(long, help = "New priority (required)")]
         priority: String,
     },
-    /// Update case status
-    #[command(name = "update-status")]
+    /// Update case status (use `status`)
+    #[command(name = "update-status", hide = true)]
     UpdateStatus {
         case_id: String,
         #[arg(long, help = "New status (required)")]
@@ -2753,12 +5050,25 @@ enum CaseProjectActions {
 
 #[derive(Subcommand)]
 enum CaseJiraActions {
-    /// Create a Jira issue for a case
+    /// Create a Jira issue for a case and print its URL
+    ///
+    /// Pass the request body with --file, or --account and --project. Waits up
+    /// to 30 seconds for Datadog to create the issue.
     #[command(name = "create-issue")]
     CreateIssue {
         case_id: String,
-        #[arg(long, help = "JSON file with request body (required)")]
-        file: String,
+        #[arg(
+            long,
+            conflicts_with_all = ["account", "project", "issue_type"],
+            help = "JSON file with request body"
+        )]
+        file: Option<String>,
+        #[arg(long, required_unless_present = "file", help = "Jira account ID")]
+        account: Option<String>,
+        #[arg(long, required_unless_present = "file", help = "Jira project ID")]
+        project: Option<String>,
+        #[arg(long = "issue-type", help = "Jira issue type ID")]
+        issue_type: Option<String>,
     },
     /// Link a Jira issue to a case
     Link {
@@ -2772,12 +5082,27 @@ enum CaseJiraActions {
 
 #[derive(Subcommand)]
 enum CaseServicenowActions {
-    /// Create a ServiceNow ticket for a case
+    /// Create a ServiceNow ticket for a case and print its URL
+    ///
+    /// Pass the request body with --file, or --instance. Waits up to 30 seconds
+    /// for Datadog to create the ticket.
     #[command(name = "create-ticket")]
     CreateTicket {
         case_id: String,
-        #[arg(long, help = "JSON file with request body (required)")]
-        file: String,
+        #[arg(
+            long,
+            conflicts_with_all = ["instance", "assignment_group"],
+            help = "JSON file with request body"
+        )]
+        file: Option<String>,
+        #[arg(
+            long,
+            required_unless_present = "file",
+            help = "ServiceNow instance name"
+        )]
+        instance: Option<String>,
+        #[arg(long = "assignment-group", help = "ServiceNow assignment group")]
+        assignment_group: Option<String>,
     },
 }
 
@@ -2815,7 +5140,16 @@ enum ServiceCatalogActions {
 #[derive(Subcommand)]
 enum ApiKeyActions {
     /// List API keys
-    List,
+    List {
+        #[arg(long, help = "Fetch every page (capped by --max-items)")]
+        all: bool,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum items to fetch with --all (0 = no cap)"
+        )]
+        max_items: usize,
+    },
     /// Get API key details
     Get { key_id: String },
     /// Create new API key
@@ -2833,15 +5167,21 @@ enum AppKeyActions {
     /// List application keys
     List {
         /// Results per page
-        #[arg(long, default_value = "10", help = "Number of results per page")]
-        page_size: i64,
+        #[arg(
+            long,
+            alias = "page-size",
+            default_value = "10",
+            help = "Number of results per page"
+        )]
+        limit: i64,
         /// Page number (0-indexed)
         #[arg(
             long,
+            alias = "page-number",
             default_value = "0",
             help = "Page number to retrieve (0-indexed)"
         )]
-        page_number: i64,
+        page: i64,
         /// Filter by key name
         #[arg(long, default_value = "", help = "Filter by key name")]
         filter: String,
@@ -2859,6 +5199,15 @@ enum AppKeyActions {
             help = "List all org keys (requires API keys, not OAuth)"
         )]
         all: bool,
+        /// Fetch every page (--all already means org-wide keys here)
+        #[arg(long, help = "Fetch every page (capped by --max-items)")]
+        all_pages: bool,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum items to fetch with --all-pages (0 = no cap)"
+        )]
+        max_items: usize,
     },
     /// Get application key details
     Get {
@@ -2928,36 +5277,147 @@ enum UsageActions {
         #[arg(long, help = "End time (now, YYYY-MM-DD, or RFC3339)")]
         to: Option<String>,
     },
+    /// Daily usage totals per product with percent changes
+    ///
+    /// Rolls hourly usage up into UTC days for each product family and usage
+    /// type (volumes such as ingested bytes are summed; counts such as hosts
+    /// take the day's peak hour), then reports the change from the first to the last day and flags
+    /// day-over-day swings at or above --threshold. --to is exclusive and
+    /// defaults to today, so the partial current day is left out.
+    #[command(verbatim_doc_comment)]
+    Report {
+        #[arg(
+            long,
+            default_value = "30d",
+            help = "Start day (30d, YYYY-MM-DD, or RFC3339)"
+        )]
+        from: String,
+        #[arg(
+            long,
+            help = "End day, exclusive (YYYY-MM-DD or RFC3339; default: today)"
+        )]
+        to: Option<String>,
+        #[arg(
+            long,
+            default_value = "all",
+            help = "Comma-separated product families (e.g. infra_hosts,logs,apm)"
+        )]
+        product_families: String,
+        #[arg(
+            long,
+            default_value_t = 25.0,
+            help = "Flag day-over-day changes of at least this percent"
+        )]
+        threshold: f64,
+    },
+}
+
+// ---- Notebooks ----
+#[derive(Subcommand)]
+enum NotebookActions {
+    /// List notebooks
+    List,
+    /// Get notebook details
+    Get {
+        #[arg(required_unless_present = "name")]
+        notebook_id: Option<i64>,
+        #[arg(
+            long,
+            conflicts_with = "notebook_id",
+            help = "Notebook name to look up instead of an ID"
+        )]
+        name: Option<String>,
+    },
+    /// Create a new notebook
+    Create {
+        #[arg(
+            long,
+            name = "body",
+            help = "JSON body (@filepath or - for stdin) (required)"
+        )]
+        file: String,
+    },
+    /// Update a notebook
+    Update {
+        #[arg(required_unless_present = "name")]
+        notebook_id: Option<i64>,
+        #[arg(
+            long,
+            conflicts_with = "notebook_id",
+            help = "Notebook name to look up instead of an ID"
+        )]
+        name: Option<String>,
+        #[arg(
+            long,
+            name = "body",
+            help = "JSON body (@filepath or - for stdin) (required)"
+        )]
+        file: String,
+    },
+    /// Delete a notebook
+    Delete {
+        #[arg(required_unless_present = "name")]
+        notebook_id: Option<i64>,
+        #[arg(
+            long,
+            conflicts_with = "notebook_id",
+            help = "Notebook name to look up instead of an ID"
+        )]
+        name: Option<String>,
+    },
+    /// Copy a notebook under a new name, optionally into another org session
+    Clone {
+        notebook_id: i64,
+        #[arg(long, help = "Name for the copy (default: \"<name> (copy)\")")]
+        name: Option<String>,
+        #[arg(long, help = "Create the copy in this named org session")]
+        to_org: Option<String>,
+    },
+    /// List, add, edit, delete, and reorder notebook cells
+    Cells {
+        #[command(subcommand)]
+        action: NotebookCellActions,
+    },
 }
 
-// ---- Notebooks ----
 #[derive(Subcommand)]
-enum NotebookActions {
-    /// List notebooks
-    List,
-    /// Get notebook details
-    Get { notebook_id: i64 },
-    /// Create a new notebook
-    Create {
+enum NotebookCellActions {
+    /// List a notebook's cells with their positions and IDs
+    List { notebook_id: i64 },
+    /// Add a cell at the end of a notebook
+    ///
+    /// The body is a widget definition ({"type": "markdown", "text": "..."}) or
+    /// full cell attributes ({"definition": {...}, "graph_size": "m"}).
+    #[command(verbatim_doc_comment)]
+    Append {
+        notebook_id: i64,
         #[arg(
             long,
             name = "body",
-            help = "JSON body (@filepath or - for stdin) (required)"
+            help = "Cell JSON (@filepath or - for stdin) (required)"
         )]
         file: String,
     },
-    /// Update a notebook
+    /// Replace a cell's content
     Update {
         notebook_id: i64,
+        cell_id: String,
         #[arg(
             long,
             name = "body",
-            help = "JSON body (@filepath or - for stdin) (required)"
+            help = "Cell JSON (@filepath or - for stdin) (required)"
         )]
         file: String,
     },
-    /// Delete a notebook
-    Delete { notebook_id: i64 },
+    /// Delete a cell
+    Delete { notebook_id: i64, cell_id: String },
+    /// Move a cell to a new position
+    Move {
+        notebook_id: i64,
+        cell_id: String,
+        #[arg(long, help = "New 1-based position, as shown by 'cells list'")]
+        position: usize,
+    },
 }
 
 // ---- RUM ----
@@ -3195,6 +5655,14 @@ enum CicdTestActions {
         to: String,
         #[arg(long, default_value_t = 50, help = "Maximum results")]
         limit: i32,
+        #[arg(long, help = "Fetch every page (capped by --max-items)")]
+        all: bool,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum items to fetch with --all (0 = no cap)"
+        )]
+        max_items: usize,
     },
     /// Search CI test events
     Search {
@@ -3297,6 +5765,110 @@ enum OnCallActions {
         #[command(subcommand)]
         action: OnCallTeamActions,
     },
+    /// Manage on-call schedules
+    Schedules {
+        #[command(subcommand)]
+        action: OnCallScheduleActions,
+    },
+    /// Manage escalation policies
+    #[command(name = "escalation-policies")]
+    EscalationPolicies {
+        #[command(subcommand)]
+        action: OnCallEscalationPolicyActions,
+    },
+    /// Manage schedule overrides
+    Overrides {
+        #[command(subcommand)]
+        action: OnCallOverrideActions,
+    },
+    /// Show who is on call now for a team
+    ///
+    /// Looks up the team's schedules and prints each current responder's
+    /// name, handle, and when their shift ends.
+    ///
+    /// EXAMPLES:
+    ///   pup on-call who --team sre-team
+    #[command(verbatim_doc_comment)]
+    Who {
+        #[arg(long, help = "Team handle, name, or ID (required)")]
+        team: String,
+    },
+}
+
+#[derive(Subcommand)]
+enum OnCallScheduleActions {
+    /// List schedules
+    List,
+    /// Get a schedule
+    Get {
+        schedule_id: String,
+        #[arg(
+            long,
+            help = "Related resources to include, e.g. layers,layers.members.user"
+        )]
+        include: Option<String>,
+    },
+    /// Create a schedule
+    Create {
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Update a schedule (data.id is filled in from the argument)
+    Update {
+        schedule_id: String,
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+}
+
+#[derive(Subcommand)]
+enum OnCallEscalationPolicyActions {
+    /// List escalation policies
+    List,
+    /// Get an escalation policy
+    Get {
+        policy_id: String,
+        #[arg(long, help = "Related resources to include, e.g. steps.targets")]
+        include: Option<String>,
+    },
+    /// Create an escalation policy
+    Create {
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+    /// Update an escalation policy (data.id is filled in from the argument)
+    Update {
+        policy_id: String,
+        #[arg(long, help = "JSON file with request body (required)")]
+        file: String,
+    },
+}
+
+#[derive(Subcommand)]
+enum OnCallOverrideActions {
+    /// Put a user on call for part of a schedule
+    ///
+    /// EXAMPLES:
+    ///   # Cover tonight's shift for 12 hours starting now
+    ///   pup on-call overrides create --schedule <schedule-id> --user <user-id> --start now --end 12h
+    ///
+    ///   # Swap a weekend
+    ///   pup on-call overrides create --schedule <schedule-id> --user <user-id> \
+    ///     --start 2026-03-07T09:00:00Z --end 2026-03-09T09:00:00Z
+    #[command(verbatim_doc_comment)]
+    Create {
+        #[arg(long = "schedule", help = "Schedule ID (required)")]
+        schedule_id: String,
+        #[arg(long = "user", help = "User UUID to put on call (required)")]
+        user_id: String,
+        #[arg(long, default_value = "now", help = "Start: now or RFC3339")]
+        start: String,
+        #[arg(
+            long,
+            help = "End: RFC3339, or a duration after --start like 12h (required)"
+        )]
+        end: String,
+    },
 }
 
 #[derive(Subcommand)]
@@ -3304,7 +5876,16 @@ enum OnCallTeamActions {
     /// List all teams
     List,
     /// Get team details
-    Get { team_id: String },
+    Get {
+        #[arg(required_unless_present = "name")]
+        team_id: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "team_id",
+            help = "Team name or handle to look up instead of an ID"
+        )]
+        name: Option<String>,
+    },
     /// Create a new team
     Create {
         #[arg(long, help = "Team display name (required)")]
@@ -3327,7 +5908,16 @@ enum OnCallTeamActions {
         handle: String,
     },
     /// Delete a team
-    Delete { team_id: String },
+    Delete {
+        #[arg(required_unless_present = "name")]
+        team_id: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "team_id",
+            help = "Team name or handle to look up instead of an ID"
+        )]
+        name: Option<String>,
+    },
     /// Manage team memberships
     Memberships {
         #[command(subcommand)]
@@ -3340,10 +5930,20 @@ enum OnCallMembershipActions {
     /// List team members
     List {
         team_id: String,
-        #[arg(long, default_value_t = 100, help = "Results per page")]
-        page_size: i64,
-        #[arg(long, default_value_t = 0, help = "Page number")]
-        page_number: i64,
+        #[arg(
+            long,
+            alias = "page-size",
+            default_value_t = 100,
+            help = "Results per page"
+        )]
+        limit: i64,
+        #[arg(
+            long,
+            alias = "page-number",
+            default_value_t = 0,
+            help = "Page number (0-based)"
+        )]
+        page: i64,
         #[arg(long, default_value = "name", help = "Sort order: name, email")]
         sort: String,
     },
@@ -3390,21 +5990,58 @@ enum FleetActions {
 enum FleetAgentActions {
     /// List fleet agents
     List {
-        #[arg(long)]
-        page_size: Option<i64>,
+        #[arg(long, alias = "page-size", help = "Results per page")]
+        limit: Option<i64>,
+        #[arg(long, help = "Fetch every page (capped by --max-items)")]
+        all: bool,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum items to fetch with --all (0 = no cap)"
+        )]
+        max_items: usize,
     },
     /// Get fleet agent details
     Get { agent_key: String },
     /// List available agent versions
     Versions,
+    /// List agents whose reported configuration breaks a policy rule
+    ///
+    /// Each rule names a field from the agent's fleet info (dotted path, as
+    /// shown by 'pup fleet agents get') and exactly one check. Output has one
+    /// row per rule and non-compliant agent; use --output csv for a tracking sheet.
+    ///
+    /// FILE FORMAT:
+    ///   rules:
+    ///     - name: agent-version
+    ///       field: agent_version
+    ///       min_version: "7.60.0"
+    ///     - name: supported-os
+    ///       field: os
+    ///       one_of: [linux, windows]
+    ///     - name: apm-on-prod
+    ///       scope: [env:prod]               # only agents with all these tags
+    ///       field: enabled_products
+    ///       contains: apm                   # or equals: <value>, pattern: <regex>
+    #[command(verbatim_doc_comment)]
+    Drift {
+        #[arg(long, help = "YAML policy file")]
+        policy: String,
+        #[arg(
+            long,
+            default_value_t = util::DEFAULT_MAX_ITEMS,
+            help = "Maximum agents to check (0 = no cap)"
+        )]
+        max_items: usize,
+    },
 }
 
 #[derive(Subcommand)]
 enum FleetDeploymentActions {
     /// List fleet deployments
     List {
-        #[arg(long)]
-        page_size: Option<i64>,
+        #[arg(long, alias = "page-size", help = "Results per page")]
+        limit: Option<i64>,
     },
     /// Get fleet deployment details
     Get { deployment_id: String },
@@ -3457,17 +6094,75 @@ enum DataGovActions {
 
 #[derive(Subcommand)]
 enum DataGovScannerActions {
+    /// Manage scanning groups
+    Groups {
+        #[command(subcommand)]
+        action: DataGovScannerGroupActions,
+    },
     /// Manage scanning rules
     Rules {
         #[command(subcommand)]
         action: DataGovScannerRuleActions,
     },
+    /// Library patterns rules can be built from
+    #[command(name = "standard-patterns")]
+    StandardPatterns {
+        #[command(subcommand)]
+        action: DataGovStandardPatternActions,
+    },
+}
+
+#[derive(Subcommand)]
+enum DataGovScannerGroupActions {
+    /// List scanning groups in scanning order
+    List,
+    /// Create a scanning group (added last)
+    Create {
+        #[arg(long, help = "JSON file with group attributes (required)")]
+        file: String,
+    },
+    /// Update a scanning group
+    Update {
+        group_id: String,
+        #[arg(long, help = "JSON file with attributes to change (required)")]
+        file: String,
+    },
+    /// Delete a scanning group and its rules
+    Delete { group_id: String },
+    /// Set scanning order: listed groups first, the rest after in their current order
+    Reorder {
+        #[arg(required = true)]
+        group_ids: Vec<String>,
+    },
 }
 
 #[derive(Subcommand)]
 enum DataGovScannerRuleActions {
     /// List scanning rules
     List,
+    /// Create a scanning rule in a group
+    Create {
+        #[arg(long, help = "Scanning group ID (required)")]
+        group: String,
+        #[arg(long, help = "Standard pattern ID to base the rule on")]
+        standard_pattern: Option<String>,
+        #[arg(long, help = "JSON file with rule attributes (required)")]
+        file: String,
+    },
+    /// Update a scanning rule
+    Update {
+        rule_id: String,
+        #[arg(long, help = "JSON file with attributes to change (required)")]
+        file: String,
+    },
+    /// Delete a scanning rule
+    Delete { rule_id: String },
+}
+
+#[derive(Subcommand)]
+enum DataGovStandardPatternActions {
+    /// List standard patterns
+    List,
 }
 
 // ---- Error Tracking ----
@@ -3668,6 +6363,42 @@ enum StatusPageThirdPartyActions {
     },
 }
 
+// ---- IP Allowlist ----
+#[derive(Subcommand)]
+enum IpAllowlistActions {
+    /// Show whether the allowlist is enabled and its entries
+    Get,
+    /// Add or remove CIDR blocks, or replace the list from a file
+    Update {
+        #[arg(
+            long,
+            conflicts_with_all = ["add_cidr", "remove_cidr"],
+            help = "JSON file with the full request body (replaces the list; asks first)"
+        )]
+        file: Option<String>,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "CIDR block or IP to add (repeatable)"
+        )]
+        add_cidr: Vec<String>,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "CIDR block or IP to remove (repeatable)"
+        )]
+        remove_cidr: Vec<String>,
+        #[arg(long, help = "Note stored on added entries, e.g. \"NYC office\"")]
+        note: Option<String>,
+        #[arg(long, help = "Show the changes without applying them")]
+        dry_run: bool,
+    },
+    /// Start enforcing the allowlist
+    Enable,
+    /// Stop enforcing the allowlist (entries are kept)
+    Disable,
+}
+
 // ---- Integrations ----
 #[derive(Subcommand)]
 enum IntegrationActions {
@@ -3691,6 +6422,11 @@ enum IntegrationActions {
         #[command(subcommand)]
         action: PagerdutyActions,
     },
+    /// Manage Opsgenie integration
+    Opsgenie {
+        #[command(subcommand)]
+        action: OpsgenieActions,
+    },
     /// Manage webhooks
     Webhooks {
         #[command(subcommand)]
@@ -3813,26 +6549,221 @@ enum ServiceNowTemplateActions {
         #[arg(long, help = "JSON file with request body (required)")]
         file: String,
     },
-    /// Delete ServiceNow template
-    Delete { template_id: String },
-}
-
-#[derive(Subcommand)]
-enum SlackActions {
-    /// List Slack channels
-    List,
-}
-
-#[derive(Subcommand)]
-enum PagerdutyActions {
-    /// List PagerDuty services
-    List,
-}
-
-#[derive(Subcommand)]
-enum WebhooksActions {
-    /// List webhooks
-    List,
+    /// Delete ServiceNow template
+    Delete { template_id: String },
+}
+
+#[derive(Subcommand)]
+enum SlackActions {
+    /// List Slack channels
+    List,
+}
+
+#[derive(Subcommand)]
+enum PagerdutyActions {
+    /// List PagerDuty services
+    List,
+    /// Get a PagerDuty service
+    Get { service_name: String },
+    /// Add a PagerDuty service
+    ///
+    /// The integration key is read from an environment variable or file so it
+    /// stays out of shell history.
+    ///
+    /// EXAMPLES:
+    ///   pup integrations pagerduty create --name payments --secret-from-env PD_PAYMENTS_KEY
+    #[command(verbatim_doc_comment)]
+    Create {
+        #[arg(long, help = "Service name, as used in @pagerduty-NAME (required)")]
+        name: String,
+        #[arg(
+            long,
+            conflicts_with = "secret_from_file",
+            help = "Environment variable holding the service integration key"
+        )]
+        secret_from_env: Option<String>,
+        #[arg(long, help = "File holding the service integration key")]
+        secret_from_file: Option<String>,
+    },
+    /// Replace a PagerDuty service's integration key
+    Update {
+        service_name: String,
+        #[arg(
+            long,
+            conflicts_with = "secret_from_file",
+            help = "Environment variable holding the service integration key"
+        )]
+        secret_from_env: Option<String>,
+        #[arg(long, help = "File holding the service integration key")]
+        secret_from_file: Option<String>,
+    },
+    /// Delete a PagerDuty service
+    Delete { service_name: String },
+}
+
+#[derive(Subcommand)]
+enum OpsgenieActions {
+    /// Manage Opsgenie services
+    Services {
+        #[command(subcommand)]
+        action: OpsgenieServiceActions,
+    },
+}
+
+#[derive(Subcommand)]
+enum OpsgenieServiceActions {
+    /// List Opsgenie services
+    List,
+    /// Get an Opsgenie service by ID or name
+    Get { service: String },
+    /// Add an Opsgenie service
+    ///
+    /// The Opsgenie API key is read from an environment variable or file so it
+    /// stays out of shell history.
+    ///
+    /// EXAMPLES:
+    ///   pup integrations opsgenie services create --name sre --region eu --secret-from-env OPSGENIE_KEY
+    #[command(verbatim_doc_comment)]
+    Create {
+        #[arg(long, help = "Service name, as used in @opsgenie-NAME (required)")]
+        name: String,
+        #[arg(long, default_value = "us", value_parser = ["us", "eu", "custom"])]
+        region: String,
+        #[arg(long, help = "Opsgenie API URL (with --region custom)")]
+        custom_url: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "secret_from_file",
+            help = "Environment variable holding the Opsgenie API key"
+        )]
+        secret_from_env: Option<String>,
+        #[arg(long, help = "File holding the Opsgenie API key")]
+        secret_from_file: Option<String>,
+    },
+    /// Update an Opsgenie service by ID or name (only the flags given change)
+    Update {
+        service: String,
+        #[arg(long, help = "New service name")]
+        name: Option<String>,
+        #[arg(long, value_parser = ["us", "eu", "custom"])]
+        region: Option<String>,
+        #[arg(long, help = "Opsgenie API URL (with --region custom)")]
+        custom_url: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "secret_from_file",
+            help = "Environment variable holding a new Opsgenie API key"
+        )]
+        secret_from_env: Option<String>,
+        #[arg(long, help = "File holding a new Opsgenie API key")]
+        secret_from_file: Option<String>,
+    },
+    /// Delete an Opsgenie service by ID or name
+    Delete { service: String },
+}
+
+#[derive(Subcommand)]
+enum WebhooksActions {
+    /// List webhooks
+    List,
+    /// Create a webhook
+    ///
+    /// Keep auth tokens out of shell history and process listings: write
+    /// {{secret}} in a --header or the payload and supply the value with
+    /// --secret-from-env or --secret-from-file. It is filled in only when the
+    /// request is sent and redacted from the printed response.
+    ///
+    /// EXAMPLES:
+    ///   pup integrations webhooks create --name deploys --url https://hooks.example.com/in \
+    ///     --header 'Authorization: Bearer {{secret}}' --secret-from-env HOOK_TOKEN
+    ///
+    ///   pup integrations webhooks create --name alerts --url https://hooks.example.com/alerts \
+    ///     --payload @payload.json --header 'X-Api-Key: {{secret}}' --secret-from-file ~/.hook-key
+    #[command(verbatim_doc_comment)]
+    Create {
+        #[arg(long)]
+        name: String,
+        #[arg(long)]
+        url: String,
+        #[arg(long, help = "Payload template (JSON with $VARIABLES), or @file")]
+        payload: Option<String>,
+        #[arg(long = "header", help = "Custom header \"Name: value\" (repeatable)")]
+        headers: Vec<String>,
+        #[arg(long, default_value = "json", value_parser = ["json", "form"])]
+        encode_as: String,
+        #[arg(
+            long,
+            conflicts_with = "secret_from_file",
+            help = "Environment variable holding the {{secret}} value"
+        )]
+        secret_from_env: Option<String>,
+        #[arg(long, help = "File holding the {{secret}} value")]
+        secret_from_file: Option<String>,
+    },
+    /// Update a webhook
+    ///
+    /// Only the flags given change. --header replaces the webhook's whole
+    /// header set, and takes {{secret}} the same way as create.
+    ///
+    /// EXAMPLES:
+    ///   pup integrations webhooks update deploys --url https://hooks.example.com/v2/in
+    ///
+    ///   pup integrations webhooks update deploys \
+    ///     --header 'Authorization: Bearer {{secret}}' --secret-from-env NEW_HOOK_TOKEN
+    #[command(verbatim_doc_comment)]
+    Update {
+        name: String,
+        #[arg(long)]
+        url: Option<String>,
+        #[arg(long, help = "Payload template (JSON with $VARIABLES), or @file")]
+        payload: Option<String>,
+        #[arg(long = "header", help = "Custom header \"Name: value\" (repeatable)")]
+        headers: Vec<String>,
+        #[arg(long, value_parser = ["json", "form"])]
+        encode_as: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "secret_from_file",
+            help = "Environment variable holding the {{secret}} value"
+        )]
+        secret_from_env: Option<String>,
+        #[arg(long, help = "File holding the {{secret}} value")]
+        secret_from_file: Option<String>,
+    },
+    /// Delete a webhook
+    Delete { name: String },
+    /// Send a sample event to a webhook's endpoint
+    ///
+    /// Fills the payload's $VARIABLES with sample values and POSTs it to the
+    /// webhook's URL with its headers, then prints the endpoint's status and
+    /// response. The request is sent from this machine, not from Datadog, so
+    /// IP allowlisting on the receiver is not exercised. Exits non-zero when
+    /// the endpoint does not answer 2xx.
+    ///
+    /// If the webhook's headers hold a {{secret}} placeholder, supply the value
+    /// with --secret-from-env or --secret-from-file.
+    ///
+    /// EXAMPLES:
+    ///   pup integrations webhooks test deploys
+    ///
+    ///   pup integrations webhooks test deploys --payload @sample.json
+    #[command(verbatim_doc_comment)]
+    Test {
+        name: String,
+        #[arg(
+            long,
+            help = "Payload template to send instead of the webhook's own, or @file"
+        )]
+        payload: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "secret_from_file",
+            help = "Environment variable holding the {{secret}} value"
+        )]
+        secret_from_env: Option<String>,
+        #[arg(long, help = "File holding the {{secret}} value")]
+        secret_from_file: Option<String>,
+    },
 }
 
 // ---- Cost ----
@@ -3863,6 +6794,25 @@ enum CostActions {
         #[arg(long, help = "Tag keys for breakdown (required)")]
         fields: Option<String>,
     },
+    /// Report spend missing required cost allocation tags
+    #[command(name = "tag-compliance")]
+    TagCompliance {
+        #[arg(
+            long,
+            help = "Comma-separated tag keys every cost should carry (required)"
+        )]
+        required_tags: String,
+        #[arg(
+            long,
+            default_value = "1M",
+            help = "Start month: YYYY-MM, or NM for N months ago"
+        )]
+        from: String,
+        #[arg(long, help = "End month: YYYY-MM, or NM for N months ago")]
+        to: Option<String>,
+        #[arg(long, help = "Print the per-product breakdown as CSV")]
+        csv: bool,
+    },
 }
 
 // ---- Misc ----
@@ -3893,11 +6843,23 @@ enum ApmActions {
         #[command(subcommand)]
         action: ApmDependencyActions,
     },
+    /// Discover operation (span) names for a service
+    Operations {
+        #[command(subcommand)]
+        action: ApmOperationActions,
+    },
+    /// Discover resources (endpoints) for a service operation
+    Resources {
+        #[command(subcommand)]
+        action: ApmResourceActions,
+    },
     /// View service flow map
-    #[command(name = "flow-map")]
+    #[command(name = "flow-map", args_conflicts_with_subcommands = true)]
     FlowMap {
+        #[command(subcommand)]
+        action: Option<ApmFlowMapActions>,
         #[arg(long, help = "Query filter (required)")]
-        query: String,
+        query: Option<String>,
         #[arg(long, default_value_t = 100, help = "Max nodes")]
         limit: i64,
         #[arg(long, default_value = "1h", help = "Start time")]
@@ -3909,6 +6871,28 @@ enum ApmActions {
     },
 }
 
+#[derive(Subcommand)]
+enum ApmFlowMapActions {
+    /// Get the flow map around a service (normalized nodes and edges)
+    Get {
+        #[arg(long, help = "Service name (required)")]
+        service: String,
+        #[arg(long, help = "Environment (required)")]
+        env: String,
+        #[arg(long, default_value_t = 100, help = "Max nodes")]
+        limit: i64,
+        #[arg(long, default_value = "1h", help = "Start time")]
+        from: String,
+        #[arg(long, default_value = "now", help = "End time")]
+        to: String,
+        #[arg(
+            long,
+            help = "Print the raw API response instead of the normalized shape"
+        )]
+        raw: bool,
+    },
+}
+
 #[derive(Subcommand)]
 enum ApmServiceActions {
     /// List APM services
@@ -3965,6 +6949,26 @@ enum ApmServiceActions {
         #[arg(long, help = "Peer service filter")]
         peer_service: Option<String>,
     },
+    /// Summarize a service's traffic, latency, and errors per resource
+    ///
+    /// Computes requests/sec, p50/p95/p99 latency, and error rate for the
+    /// service's entry spans with the spans aggregate API, for the busiest 25
+    /// resources and for the service as a whole.
+    ///
+    /// EXAMPLES:
+    ///   pup apm services summary --service api --env prod
+    ///   pup apm services summary --service api --env prod --from 24h -o json
+    #[command(verbatim_doc_comment)]
+    Summary {
+        #[arg(long, help = "Service name (required)")]
+        service: String,
+        #[arg(long, help = "Environment (required)")]
+        env: String,
+        #[arg(long, default_value = "1h", help = "Start time")]
+        from: String,
+        #[arg(long, default_value = "now", help = "End time")]
+        to: String,
+    },
 }
 
 #[derive(Subcommand)]
@@ -3987,6 +6991,43 @@ enum ApmEntityActions {
         primary_tag: Option<String>,
         #[arg(long, help = "Entity types (comma-separated)")]
         types: Option<String>,
+        #[arg(
+            long,
+            help = "Print the raw API response instead of the normalized shape"
+        )]
+        raw: bool,
+    },
+}
+
+#[derive(Subcommand)]
+enum ApmOperationActions {
+    /// List operation names for a service
+    List {
+        #[arg(long, help = "Service name (required)")]
+        service: String,
+        #[arg(long, help = "Environment filter")]
+        env: Option<String>,
+        #[arg(long, default_value = "1h", help = "Start time")]
+        from: String,
+        #[arg(long, default_value = "now", help = "End time")]
+        to: String,
+    },
+}
+
+#[derive(Subcommand)]
+enum ApmResourceActions {
+    /// List resources for a service operation
+    List {
+        #[arg(long, help = "Service name (required)")]
+        service: String,
+        #[arg(long, help = "Operation name (required)")]
+        operation: String,
+        #[arg(long, help = "Environment filter")]
+        env: Option<String>,
+        #[arg(long, default_value = "1h", help = "Start time")]
+        from: String,
+        #[arg(long, default_value = "now", help = "End time")]
+        to: String,
     },
 }
 
@@ -4010,10 +7051,20 @@ enum ApmDependencyActions {
 enum InvestigationActions {
     /// List investigations
     List {
-        #[arg(long, default_value_t = 10, help = "Page size")]
-        page_limit: i64,
-        #[arg(long, default_value_t = 0, help = "Pagination offset")]
-        page_offset: i64,
+        #[arg(
+            long,
+            alias = "page-limit",
+            default_value_t = 10,
+            help = "Results per page"
+        )]
+        limit: i64,
+        #[arg(
+            long,
+            alias = "page-offset",
+            default_value_t = 0,
+            help = "Results to skip"
+        )]
+        offset: i64,
         #[arg(long, default_value_t = 0, help = "Filter by monitor ID")]
         monitor_id: i64,
     },
@@ -4113,6 +7164,9 @@ enum TracesActions {
     ///   pup traces search --query="@http.status_code:>=500"
     ///   pup traces search --query="service:api @duration:>1000000000" --from="4h"
     ///   pup traces search --query="env:prod" --sort="timestamp" --limit=20
+    ///
+    ///   # One trace as an indented span tree with durations and a timeline
+    ///   pup traces search --query="trace_id:7215481436352364567" --tree
     #[command(verbatim_doc_comment)]
     Search {
         #[arg(long, default_value = "*", help = "Span search query")]
@@ -4137,6 +7191,11 @@ enum TracesActions {
             help = "Sort order: timestamp or -timestamp"
         )]
         sort: String,
+        #[arg(
+            long,
+            help = "Render one trace as a span tree (fetches up to 1000 spans; ignores --limit and --sort)"
+        )]
+        tree: bool,
     },
     /// Compute aggregated stats over spans
     ///
@@ -4182,6 +7241,43 @@ enum TracesActions {
         )]
         group_by: Option<String>,
     },
+    /// Show the logs correlated with a trace
+    ///
+    /// Finds the trace's spans to get its time window, services, and env, then
+    /// searches logs carrying the trace id within that window. Decimal, hex, and
+    /// 128-bit trace ids are all matched.
+    ///
+    /// EXAMPLES:
+    ///   pup traces logs 1234567890123456789
+    ///   pup traces logs 6553f5b1000000000123456789abcdef --lookback 7d
+    ///   pup traces logs 1234567890123456789 --query="status:error"
+    ///   # Services that don't inject trace ids: all their logs during the trace
+    ///   pup traces logs 1234567890123456789 --by-service --padding 30s
+    #[command(verbatim_doc_comment)]
+    Logs {
+        trace_id: String,
+        #[arg(
+            long,
+            default_value = "1d",
+            help = "How far back to look for the trace's spans (e.g. 1h, 1d, 7d)"
+        )]
+        lookback: String,
+        #[arg(
+            long,
+            default_value = "1m",
+            help = "Time added before and after the trace for the logs search"
+        )]
+        padding: String,
+        #[arg(long, default_value_t = 100, help = "Maximum number of logs (1-1000)")]
+        limit: usize,
+        #[arg(
+            long,
+            help = "Match logs by the trace's services and env instead of trace id"
+        )]
+        by_service: bool,
+        #[arg(long, help = "Additional log query to narrow the results")]
+        query: Option<String>,
+    },
 }
 
 // ---- Agent (placeholder) ----
@@ -4216,6 +7312,50 @@ enum AliasActions {
     },
 }
 
+// ---- Config ----
+#[derive(Subcommand)]
+enum ConfigActions {
+    /// Check config.yaml and aliases.yaml for unknown keys and invalid values
+    Lint,
+    /// Show the effective value of each setting (secrets masked)
+    Show {
+        #[arg(
+            long,
+            help = "Also show where each value came from: flag, env var, config file, or default"
+        )]
+        sources: bool,
+    },
+    /// Manage named profiles
+    Profiles {
+        #[command(subcommand)]
+        action: ConfigProfileActions,
+    },
+}
+
+#[derive(Subcommand)]
+enum ConfigProfileActions {
+    /// List configured profiles
+    List,
+    /// Create a profile or update the given fields of an existing one
+    ///
+    /// The global --site, --api-key, and --app-key flags set the profile's
+    /// site and keys. Keys given that way are stored in plaintext; prefer
+    /// --api-key-file and --app-key-file.
+    Set {
+        name: String,
+        #[arg(long, help = "File containing the API key")]
+        api_key_file: Option<String>,
+        #[arg(long, help = "File containing the application key")]
+        app_key_file: Option<String>,
+        #[arg(long, help = "Default output format: json, table, yaml, csv")]
+        default_output: Option<String>,
+        #[arg(long, help = "Named org session (see 'pup auth login --org')")]
+        default_org: Option<String>,
+    },
+    /// Delete a profile
+    Delete { name: String },
+}
+
 // ---- Product Analytics ----
 #[derive(Subcommand)]
 enum ProductAnalyticsActions {
@@ -4243,6 +7383,118 @@ enum ProductAnalyticsEventActions {
     },
 }
 
+// ---- Reference Tables ----
+#[derive(Subcommand)]
+enum ReferenceTableActions {
+    /// List reference tables
+    List {
+        #[arg(long, help = "Only tables whose name contains this text")]
+        name: Option<String>,
+        #[arg(long, default_value_t = 100, help = "Maximum number of tables")]
+        limit: i64,
+    },
+    /// Get a reference table by ID or name
+    Get { table: String },
+    /// Create a reference table from a local CSV
+    Create {
+        #[arg(
+            long,
+            required_unless_present = "file",
+            help = "Table name used by pipeline lookup processors"
+        )]
+        name: Option<String>,
+        #[arg(
+            long,
+            conflicts_with = "file",
+            help = "CSV file with a header row (uploaded in parts)"
+        )]
+        csv: Option<String>,
+        #[arg(
+            long = "primary-key",
+            value_delimiter = ',',
+            help = "Primary key column (repeatable)"
+        )]
+        primary_key: Vec<String>,
+        #[arg(
+            long = "int-column",
+            value_delimiter = ',',
+            help = "Column stored as INT32 instead of STRING (repeatable)"
+        )]
+        int_column: Vec<String>,
+        #[arg(long, help = "Table description")]
+        description: Option<String>,
+        #[arg(long, value_delimiter = ',', help = "Table tags (repeatable)")]
+        tags: Vec<String>,
+        #[arg(
+            long,
+            conflicts_with_all = ["name", "primary_key", "int_column", "description", "tags"],
+            help = "JSON file with the full request body"
+        )]
+        file: Option<String>,
+        #[arg(
+            long,
+            help = "Poll until the table finishes processing; exit nonzero if it errors"
+        )]
+        wait: bool,
+        #[arg(
+            long,
+            default_value = "10m",
+            help = "Maximum time to wait with --wait (e.g. 30s, 10m, 1h)"
+        )]
+        wait_timeout: String,
+    },
+    /// Replace a table's rows from a CSV, or change its description or tags
+    Update {
+        table: String,
+        #[arg(long, help = "CSV file whose rows replace the table's contents")]
+        csv: Option<String>,
+        #[arg(long, help = "New description")]
+        description: Option<String>,
+        #[arg(
+            long,
+            value_delimiter = ',',
+            help = "Replace the table's tags (repeatable)"
+        )]
+        tags: Vec<String>,
+        #[arg(
+            long,
+            conflicts_with_all = ["csv", "description", "tags"],
+            help = "JSON file with the full request body"
+        )]
+        file: Option<String>,
+        #[arg(
+            long,
+            help = "Poll until the table finishes processing; exit nonzero if it errors"
+        )]
+        wait: bool,
+        #[arg(
+            long,
+            default_value = "10m",
+            help = "Maximum time to wait with --wait (e.g. 30s, 10m, 1h)"
+        )]
+        wait_timeout: String,
+    },
+    /// Delete a reference table
+    Delete { table: String },
+}
+
+// ---- Restriction Policies ----
+#[derive(Subcommand)]
+enum RestrictionPolicyActions {
+    /// Get the restriction policy for a resource (<resource-type>:<id>)
+    Get { resource_id: String },
+    /// Replace the restriction policy bindings for a resource
+    Update {
+        resource_id: String,
+        #[arg(long, help = "JSON file with the full policy body")]
+        file: Option<String>,
+        #[arg(long, help = "Editor principal, e.g. team:<uuid> (repeatable)")]
+        editor: Vec<String>,
+        #[arg(long, help = "Viewer principal, e.g. org:<uuid> (repeatable)")]
+        viewer: Vec<String>,
+    },
+}
+
 // ---- Static Analysis ----
 #[derive(Subcommand)]
 enum StaticAnalysisActions {
@@ -4365,7 +7617,13 @@ enum StaticAnalysisCoverageActions {
 #[derive(Subcommand)]
 enum AuthActions {
     /// Login via OAuth2
-    Login,
+    Login {
+        #[arg(
+            long,
+            help = "Don't open a browser or listen for the callback; print the URL and read the redirect URL back (for SSH and headless hosts)"
+        )]
+        no_browser: bool,
+    },
     /// Logout and clear tokens
     Logout,
     /// Check authentication status
@@ -4376,11 +7634,61 @@ enum AuthActions {
     Refresh,
     /// List all stored org sessions
     List,
+    /// Show granted OAuth scopes against the scopes login requests
+    Scopes,
+    /// Move file-stored tokens into the OS keychain
+    ///
+    /// Tokens saved while the keychain was unavailable stay in ~/.config/pup/ and
+    /// are not read once the keychain is in use. Each file is deleted after its
+    /// entry is stored in the keychain.
+    Migrate {
+        /// List the files that would move without changing anything
+        #[arg(long)]
+        dry_run: bool,
+    },
+    /// Show which auth methods the configured site supports
+    ///
+    /// The US1-FED government site (ddog-gov.com) has no OAuth dynamic client
+    /// registration, so 'pup auth login' is unavailable there; use DD_API_KEY +
+    /// DD_APP_KEY instead.
+    Capabilities,
+    /// Run a command with credentials injected into its environment only
+    ///
+    /// Injects DD_ACCESS_TOKEN (or DD_API_KEY + DD_APP_KEY with --keys) and DD_SITE
+    /// into the child process. Inherited credential variables are removed first, and
+    /// nothing is exported to the calling shell. Exits with the child's exit code.
+    Exec {
+        #[arg(
+            long,
+            help = "Inject DD_API_KEY + DD_APP_KEY instead of the OAuth token"
+        )]
+        keys: bool,
+        /// Command and arguments to run (after --)
+        #[arg(trailing_var_arg = true, allow_hyphen_values = true, required = true)]
+        command: Vec<String>,
+    },
 }
 
 // ---- Agent-mode JSON schema for --help ----
 
 /// Walk the clap command tree to find the subcommand matching the given path.
+/// Subcommand path named on the command line (e.g. `["monitors", "list"]`),
+/// found by descending through positional args that name a subcommand.
+fn invoked_command_path(cmd: &clap::Command, args: &[String]) -> Vec<String> {
+    let mut current = cmd;
+    let mut path = Vec::new();
+    for arg in args.iter().skip(1).filter(|a| !a.starts_with('-')) {
+        if let Some(sub) = current
+            .get_subcommands()
+            .find(|s| s.get_name() == arg || s.get_all_aliases().any(|a| a == arg))
+        {
+            path.push(sub.get_name().to_string());
+            current = sub;
+        }
+    }
+    path
+}
+
 fn find_subcommand<'a>(cmd: &'a clap::Command, path: &[&str]) -> Option<&'a clap::Command> {
     let mut current = cmd;
     for name in path {
@@ -4422,10 +7730,58 @@ fn build_agent_schema_scoped(
         "global_flags".into(),
         serde_json::json!([
             {
-                "name": "--agent",
-                "type": "bool",
-                "default": "false",
-                "description": "Enable agent mode (auto-detected for AI coding assistants)"
+                "name": "--agent",
+                "type": "bool",
+                "default": "false",
+                "description": "Enable agent mode (auto-detected for AI coding assistants)"
+            },
+            {
+                "name": "--api-key",
+                "type": "string",
+                "default": null,
+                "description": "Datadog API key for this invocation (overrides DD_API_KEY and config; visible in process listings)"
+            },
+            {
+                "name": "--app-key",
+                "type": "string",
+                "default": null,
+                "description": "Datadog application key for this invocation (overrides DD_APP_KEY and config; visible in process listings)"
+            },
+            {
+                "name": "--connect-timeout",
+                "type": "string",
+                "default": "10s",
+                "description": "Give up connecting to the API after this long (also PUP_CONNECT_TIMEOUT or config connect_timeout)"
+            },
+            {
+                "name": "--credential-store",
+                "type": "string",
+                "default": null,
+                "description": "OAuth token storage backend: keyring (OS keychain) or file (default: auto-detect)"
+            },
+            {
+                "name": "--debug-http",
+                "type": "bool",
+                "default": "false",
+                "description": "Log every API call (method, URL, status, latency, request ID) to stderr with credentials redacted (also PUP_DEBUG=http)"
+            },
+            {
+                "name": "--debug-http-bodies",
+                "type": "bool",
+                "default": "false",
+                "description": "Like --debug-http, and also log request and response bodies (also PUP_DEBUG=http,bodies)"
+            },
+            {
+                "name": "--jq",
+                "type": "string",
+                "default": null,
+                "description": "Filter output with a jq expression before formatting (e.g. '.[] | {id, name}')"
+            },
+            {
+                "name": "--max-output-bytes",
+                "type": "int",
+                "default": "10485760",
+                "description": "Truncate JSON/agent output larger than this many bytes, with a warning and continuation offset/cursor (0 disables)"
             },
             {
                 "name": "--org",
@@ -4437,7 +7793,43 @@ fn build_agent_schema_scoped(
                 "name": "--output",
                 "type": "string",
                 "default": "json",
-                "description": "Output format (json, table, yaml)"
+                "description": "Output format (json, table, yaml, csv)"
+            },
+            {
+                "name": "--profile",
+                "type": "string",
+                "default": null,
+                "description": "Named profile from config.yaml (site, keys, output, org); overrides PUP_PROFILE"
+            },
+            {
+                "name": "--schema-out",
+                "type": "bool",
+                "default": "false",
+                "description": "Print the JSON Schema of the command's agent-mode output envelope and exit without running it; data is only constrained for core list/get commands"
+            },
+            {
+                "name": "--site",
+                "type": "string",
+                "default": null,
+                "description": "Datadog site for this invocation, e.g. datadoghq.eu (overrides DD_SITE and config)"
+            },
+            {
+                "name": "--stats",
+                "type": "bool",
+                "default": "false",
+                "description": "Print API call count, bytes transferred, total time, and rate-limit remaining to stderr"
+            },
+            {
+                "name": "--time-format",
+                "type": "string",
+                "default": null,
+                "description": "Render timestamp columns in table output as relative, iso, epoch, or local time"
+            },
+            {
+                "name": "--timeout",
+                "type": "string",
+                "default": null,
+                "description": "Give up on each API request after this long, e.g. 30s or 2m; 0 disables (also PUP_TIMEOUT or config timeout)"
             },
             {
                 "name": "--yes",
@@ -4547,6 +7939,54 @@ fn build_agent_schema(cmd: &clap::Command) -> serde_json::Value {
                 "default": "false",
                 "description": "Enable agent mode (auto-detected for AI coding assistants)"
             },
+            {
+                "name": "--api-key",
+                "type": "string",
+                "default": null,
+                "description": "Datadog API key for this invocation (overrides DD_API_KEY and config; visible in process listings)"
+            },
+            {
+                "name": "--app-key",
+                "type": "string",
+                "default": null,
+                "description": "Datadog application key for this invocation (overrides DD_APP_KEY and config; visible in process listings)"
+            },
+            {
+                "name": "--connect-timeout",
+                "type": "string",
+                "default": "10s",
+                "description": "Give up connecting to the API after this long (also PUP_CONNECT_TIMEOUT or config connect_timeout)"
+            },
+            {
+                "name": "--credential-store",
+                "type": "string",
+                "default": null,
+                "description": "OAuth token storage backend: keyring (OS keychain) or file (default: auto-detect)"
+            },
+            {
+                "name": "--debug-http",
+                "type": "bool",
+                "default": "false",
+                "description": "Log every API call (method, URL, status, latency, request ID) to stderr with credentials redacted (also PUP_DEBUG=http)"
+            },
+            {
+                "name": "--debug-http-bodies",
+                "type": "bool",
+                "default": "false",
+                "description": "Like --debug-http, and also log request and response bodies (also PUP_DEBUG=http,bodies)"
+            },
+            {
+                "name": "--jq",
+                "type": "string",
+                "default": null,
+                "description": "Filter output with a jq expression before formatting (e.g. '.[] | {id, name}')"
+            },
+            {
+                "name": "--max-output-bytes",
+                "type": "int",
+                "default": "10485760",
+                "description": "Truncate JSON/agent output larger than this many bytes, with a warning and continuation offset/cursor (0 disables)"
+            },
             {
                 "name": "--org",
                 "type": "string",
@@ -4557,7 +7997,43 @@ fn build_agent_schema(cmd: &clap::Command) -> serde_json::Value {
                 "name": "--output",
                 "type": "string",
                 "default": "json",
-                "description": "Output format (json, table, yaml)"
+                "description": "Output format (json, table, yaml, csv)"
+            },
+            {
+                "name": "--profile",
+                "type": "string",
+                "default": null,
+                "description": "Named profile from config.yaml (site, keys, output, org); overrides PUP_PROFILE"
+            },
+            {
+                "name": "--schema-out",
+                "type": "bool",
+                "default": "false",
+                "description": "Print the JSON Schema of the command's agent-mode output envelope and exit without running it; data is only constrained for core list/get commands"
+            },
+            {
+                "name": "--site",
+                "type": "string",
+                "default": null,
+                "description": "Datadog site for this invocation, e.g. datadoghq.eu (overrides DD_SITE and config)"
+            },
+            {
+                "name": "--stats",
+                "type": "bool",
+                "default": "false",
+                "description": "Print API call count, bytes transferred, total time, and rate-limit remaining to stderr"
+            },
+            {
+                "name": "--time-format",
+                "type": "string",
+                "default": null,
+                "description": "Render timestamp columns in table output as relative, iso, epoch, or local time"
+            },
+            {
+                "name": "--timeout",
+                "type": "string",
+                "default": null,
+                "description": "Give up on each API request after this long, e.g. 30s or 2m; 0 disables (also PUP_TIMEOUT or config timeout)"
             },
             {
                 "name": "--yes",
@@ -4756,28 +8232,8 @@ fn build_command_schema(cmd: &clap::Command, parent_path: &str) -> serde_json::V
 
     // Determine read_only based on command name — but only emit for leaf commands
     // (commands with no subcommands), matching Go behavior
-    let is_write = name == "delete"
-        || name == "create"
-        || name == "update"
-        || name == "cancel"
-        || name == "trigger"
-        || name == "set"
-        || name == "add"
-        || name == "remove"
-        || name == "assign"
-        || name == "archive"
-        || name == "unarchive"
-        || name == "activate"
-        || name == "deactivate"
-        || name.starts_with("update-")
-        || name.starts_with("create-")
-        || name == "submit"
-        || name == "send"
-        || name == "import"
-        || name == "register"
-        || name == "unregister"
-        || name.contains("delete")
-        || name.contains("patch");
+    let path: Vec<&str> = full_path.split(' ').collect();
+    let is_write = commands::capabilities::access(&path) != commands::capabilities::Access::Read;
 
     // Flags (named --flags only, excluding positional args and globals)
     let flags: Vec<serde_json::Value> = cmd
@@ -4858,13 +8314,50 @@ fn build_command_schema(cmd: &clap::Command, parent_path: &str) -> serde_json::V
 #[cfg(not(target_arch = "wasm32"))]
 #[tokio::main]
 async fn main() -> anyhow::Result<()> {
-    main_inner().await
+    let started = std::time::Instant::now();
+    let result = main_inner().await;
+    stats::print_footer(started.elapsed());
+    record_run(started.elapsed(), result.as_ref().err());
+    exit_on_error(result)
 }
 
 #[cfg(target_arch = "wasm32")]
 #[tokio::main(flavor = "current_thread")]
 async fn main() -> anyhow::Result<()> {
-    main_inner().await
+    let started = std::time::Instant::now();
+    let result = main_inner().await;
+    stats::print_footer(started.elapsed());
+    record_run(started.elapsed(), result.as_ref().err());
+    exit_on_error(result)
+}
+
+/// Print a failed command's error and exit with the code for its failure class.
+fn exit_on_error(result: anyhow::Result<()>) -> anyhow::Result<()> {
+    if let Err(e) = result {
+        match e.downcast_ref::<clap::Error>() {
+            Some(usage) => {
+                let _ = usage.print();
+            }
+            // The wrapped command already reported its own failure.
+            None if e.is::<exit_code::ChildExit>() => {}
+            None => eprintln!("Error: {e:?}"),
+        }
+        if let Some(hint) = auth::scopes::forbidden_hint() {
+            eprintln!("Hint: {hint}");
+        }
+        std::process::exit(exit_code::classify(&e));
+    }
+    Ok(())
+}
+
+/// Append this invocation to the `DD_PUP_LOG_FILE` execution log, if configured.
+fn record_run(elapsed: std::time::Duration, error: Option<&anyhow::Error>) {
+    if runlog::path().is_none() {
+        return;
+    }
+    let args: Vec<String> = std::env::args().collect();
+    let command = invoked_command_path(&Cli::command(), &args).join(" ");
+    runlog::record(&command, &args[1..], elapsed, error);
 }
 
 async fn main_inner() -> anyhow::Result<()> {
@@ -4894,23 +8387,118 @@ async fn main_inner() -> anyhow::Result<()> {
         return Ok(());
     }
 
-    let cli = Cli::parse();
-    let mut cfg = config::Config::from_env()?;
+    // --schema-out describes the output without running the command, so it is
+    // handled before parsing and required arguments may be left out.
+    if args.iter().any(|a| a == "--schema-out") {
+        let path = invoked_command_path(&Cli::command(), &args).join(" ");
+        let schema = core_contract::envelope_schema(&path);
+        println!("{}", serde_json::to_string_pretty(&schema)?);
+        return Ok(());
+    }
+
+    // Usage errors are returned rather than exiting here, so they reach the
+    // run log and exit with the validation code instead of clap's default of
+    // 2, which is reserved for authentication failures.
+    let cli = match Cli::try_parse() {
+        Ok(cli) => cli,
+        Err(e) if e.use_stderr() => return Err(e.into()),
+        Err(e) => {
+            let _ = e.print();
+            std::process::exit(exit_code::SUCCESS);
+        }
+    };
+    let command_path = invoked_command_path(&Cli::command(), &args).join(" ");
+    for warning in util::renamed_flag_warnings(&command_path, &args) {
+        eprintln!("{warning}");
+    }
+    if let Some(profile) = &cli.profile {
+        config::set_profile(profile);
+    }
+    if matches!(
+        cli.command,
+        Commands::Config {
+            action: ConfigActions::Lint
+        }
+    ) {
+        config::suppress_config_warnings();
+    }
+    // Must precede config loading, which reads stored tokens.
+    if let Some(store) = &cli.credential_store {
+        auth::storage::set_preferred_backend(auth::storage::parse_backend(store)?);
+    }
+    let (mut cfg, mut settings) = match config::Config::load() {
+        Ok(loaded) => loaded,
+        // Doctor explains a broken config instead of failing on it like other commands.
+        Err(e) if matches!(cli.command, Commands::Doctor) => {
+            return commands::doctor::config_load_error(e);
+        }
+        Err(e) => return Err(e),
+    };
 
-    // Apply flag overrides
-    if let Ok(fmt) = cli.output.parse() {
+    // Apply flag overrides, noting each for 'pup config show --sources'.
+    let mut overrides: Vec<(&str, String)> = Vec::new();
+    if let Some(fmt) = cli.output.as_deref().and_then(|o| o.parse().ok()) {
         cfg.output_format = fmt;
+        overrides.push(("output", "flag --output".into()));
     }
     if cli.yes {
         cfg.auto_approve = true;
+        overrides.push(("auto_approve", "flag --yes".into()));
+    }
+    if let Some(max) = cli.max_output_bytes {
+        cfg.max_output_bytes = max;
+        overrides.push(("max_output_bytes", "flag --max-output-bytes".into()));
+    }
+    if let Some(tf) = &cli.time_format {
+        cfg.time_format = Some(tf.parse()?);
+        overrides.push(("time_format", "flag --time-format".into()));
+    }
+    if cli.credential_store.is_some() {
+        overrides.push(("credential_store", "flag --credential-store".into()));
+    }
+    if let Some(filter) = cli.jq {
+        jq::parse(&filter)?;
+        cfg.jq = Some(filter);
+    }
+    if cli.stats {
+        stats::enable();
     }
     cfg.agent_mode = cli.agent || useragent::is_agent_mode();
     if cfg.agent_mode {
+        if !cli.yes {
+            overrides.push(("auto_approve", "agent mode".into()));
+        }
         cfg.auto_approve = true;
+        core_contract::set_command(&invoked_command_path(&Cli::command(), &args).join(" "));
+    }
+    // Apply --site: per-invocation override, e.g. for scripts looping over orgs.
+    if let Some(site) = &cli.site {
+        cfg.site = config::normalize_site(site)?;
+        overrides.push(("site", "flag --site".into()));
+        // Stored OAuth tokens are per site
+        #[cfg(all(not(feature = "browser"), not(target_arch = "wasm32")))]
+        if std::env::var("DD_ACCESS_TOKEN")
+            .ok()
+            .filter(|s| !s.is_empty())
+            .is_none()
+        {
+            cfg.access_token = config::load_token_from_storage(&cfg.site, cfg.org.as_deref());
+            overrides.push(("access_token", "token storage (--site)".into()));
+        }
+    }
+    let key_flags = cli.api_key.is_some() || cli.app_key.is_some();
+    if let Some(key) = &cli.api_key {
+        cfg.api_key = Some(key.clone());
+        overrides.push(("api_key", "flag --api-key".into()));
+    }
+    if let Some(key) = &cli.app_key {
+        cfg.app_key = Some(key.clone());
+        overrides.push(("app_key", "flag --app-key".into()));
     }
     // Apply --org flag (higher priority than DD_ORG env var / config file)
     if let Some(org) = cli.org {
         cfg.org = Some(org);
+        overrides.push(("org", "flag --org".into()));
         // Reload token from storage for this org, unless DD_ACCESS_TOKEN was explicitly set
         #[cfg(all(not(feature = "browser"), not(target_arch = "wasm32")))]
         if std::env::var("DD_ACCESS_TOKEN")
@@ -4919,32 +8507,222 @@ async fn main_inner() -> anyhow::Result<()> {
             .is_none()
         {
             cfg.access_token = config::load_token_from_storage(&cfg.site, cfg.org.as_deref());
+            overrides.push(("access_token", "token storage (--org)".into()));
+        }
+    }
+    // Keys passed as flags mean key auth for this run, even with a stored OAuth
+    // session (which would otherwise take precedence).
+    if key_flags && cfg.has_api_keys() {
+        cfg.access_token = None;
+    }
+    // Every client reads PUP_MOCK_SERVER, so redirecting it routes all
+    // traffic through the mock or cassette server.
+    if let Some(url) = &cli.mock_server {
+        std::env::set_var("PUP_MOCK_SERVER", url.trim_end_matches('/'));
+    }
+    #[cfg(not(target_arch = "wasm32"))]
+    {
+        if let Some(path) = &cli.record {
+            cfg.resolve_credential_process()?;
+            let secrets = [&cfg.api_key, &cfg.app_key, &cfg.access_token]
+                .into_iter()
+                .flatten()
+                .filter(|s| !s.is_empty())
+                .cloned()
+                .collect();
+            let base = cassette::start_record(path, cfg.api_base_url(), secrets).await?;
+            std::env::set_var("PUP_MOCK_SERVER", base);
+        }
+        if let Some(path) = &cli.replay {
+            let base = cassette::start_replay(path).await?;
+            std::env::set_var("PUP_MOCK_SERVER", base);
+            // Replays need no credentials; placeholders satisfy validate_auth.
+            if !cfg.has_api_keys() && !cfg.has_bearer_token() {
+                cfg.api_key = Some("replay".into());
+                cfg.app_key = Some("replay".into());
+                overrides.push(("api_key", "flag --replay".into()));
+                overrides.push(("app_key", "flag --replay".into()));
+            }
+        }
+    }
+    #[cfg(target_arch = "wasm32")]
+    if cli.record.is_some() || cli.replay.is_some() {
+        anyhow::bail!("--record and --replay are not supported in WASM builds");
+    }
+    #[cfg(not(target_arch = "wasm32"))]
+    {
+        let (timeout, connect) =
+            config::request_timeouts(cli.timeout.as_deref(), cli.connect_timeout.as_deref())?;
+        client::set_timeouts(timeout, connect);
+    }
+    #[cfg(target_arch = "wasm32")]
+    if cli.timeout.is_some() || cli.connect_timeout.is_some() {
+        anyhow::bail!("--timeout and --connect-timeout are not supported in WASM builds");
+    }
+    if cli.timeout.is_some() {
+        overrides.push(("timeout", "flag --timeout".into()));
+    }
+    if cli.connect_timeout.is_some() {
+        overrides.push(("connect_timeout", "flag --connect-timeout".into()));
+    }
+    // After every override, so the keys in effect are the ones redacted.
+    #[cfg(not(target_arch = "wasm32"))]
+    {
+        let (env_http, env_bodies) = std::env::var("PUP_DEBUG")
+            .map(|v| http_debug::parse_env(&v))
+            .unwrap_or_default();
+        if cli.debug_http || cli.debug_http_bodies || env_http {
+            cfg.resolve_credential_process()?;
+            let secrets = [&cfg.api_key, &cfg.app_key, &cfg.access_token]
+                .into_iter()
+                .flatten()
+                .cloned()
+                .collect();
+            http_debug::enable(cli.debug_http_bodies || env_bodies, secrets);
         }
     }
+    #[cfg(target_arch = "wasm32")]
+    if cli.debug_http || cli.debug_http_bodies {
+        anyhow::bail!("--debug-http is not supported in WASM builds");
+    }
+    // Keys from credential_process are registered when it runs; see
+    // Config::resolve_credential_process.
+    runlog::add_secrets(&cfg);
 
     match cli.command {
         // --- Monitors ---
         Commands::Monitors { action } => {
             cfg.validate_auth()?;
             match action {
-                MonitorActions::List { name, tags, limit } => {
-                    commands::monitors::list(&cfg, name, tags, limit).await?;
+                MonitorActions::List {
+                    name,
+                    tags,
+                    limit,
+                    include_downtimes,
+                } => {
+                    commands::monitors::list(&cfg, name, tags, limit, include_downtimes).await?;
+                }
+                MonitorActions::Get {
+                    monitor_id,
+                    name,
+                    include_downtimes,
+                } => {
+                    let monitor_id =
+                        resolve::numeric_id_or_name(&cfg, resolve::Kind::Monitor, monitor_id, name)
+                            .await?;
+                    commands::monitors::get(&cfg, monitor_id, include_downtimes).await?;
                 }
-                MonitorActions::Get { monitor_id } => {
-                    commands::monitors::get(&cfg, monitor_id).await?;
+                MonitorActions::CompositeTree { monitor_id } => {
+                    commands::monitors::composite_tree(&cfg, monitor_id).await?;
                 }
                 MonitorActions::Create { file } => {
                     commands::monitors::create(&cfg, &file).await?;
                 }
-                MonitorActions::Update { monitor_id, file } => {
+                MonitorActions::Update {
+                    monitor_id,
+                    name,
+                    file,
+                    preflight,
+                } => {
+                    let monitor_id =
+                        resolve::numeric_id_or_name(&cfg, resolve::Kind::Monitor, monitor_id, name)
+                            .await?;
+                    if preflight {
+                        commands::restriction_policies::preflight(
+                            &cfg,
+                            "monitor",
+                            &monitor_id.to_string(),
+                        )
+                        .await?;
+                    }
                     commands::monitors::update(&cfg, monitor_id, &file).await?;
                 }
-                MonitorActions::Search { query, .. } => {
-                    commands::monitors::search(&cfg, query).await?;
+                MonitorActions::Search {
+                    query, page, limit, ..
+                } => {
+                    commands::monitors::search(&cfg, query, page, limit).await?;
                 }
-                MonitorActions::Delete { monitor_id } => {
+                MonitorActions::Delete {
+                    monitor_id,
+                    name,
+                    preflight,
+                    check_dependencies,
+                } => {
+                    let monitor_id =
+                        resolve::numeric_id_or_name(&cfg, resolve::Kind::Monitor, monitor_id, name)
+                            .await?;
+                    if preflight {
+                        commands::restriction_policies::preflight(
+                            &cfg,
+                            "monitor",
+                            &monitor_id.to_string(),
+                        )
+                        .await?;
+                    }
+                    if check_dependencies {
+                        let dependents =
+                            commands::monitors::find_dependents(&cfg, monitor_id).await;
+                        for line in commands::monitors::dependents_warning(monitor_id, &dependents)
+                        {
+                            eprintln!("{line}");
+                        }
+                    }
+                    if !confirm::Destructive::new("delete", "monitor", monitor_id).confirm(&cfg)? {
+                        return Ok(());
+                    }
                     commands::monitors::delete(&cfg, monitor_id).await?;
                 }
+                MonitorActions::BulkDelete {
+                    query,
+                    checkpoint,
+                    resume,
+                } => {
+                    commands::monitors::bulk_delete(
+                        &cfg,
+                        query.as_deref(),
+                        &checkpoint,
+                        resume.as_deref(),
+                    )
+                    .await?;
+                }
+                MonitorActions::Rewrite {
+                    query,
+                    add_tag,
+                    remove_tag,
+                    replace_in_message,
+                    dry_run,
+                    concurrency,
+                } => {
+                    let opts = commands::monitors::RewriteOptions {
+                        query,
+                        add_tags: add_tag,
+                        remove_tags: remove_tag,
+                        replacements: replace_in_message,
+                        dry_run,
+                        concurrency,
+                    };
+                    commands::monitors::rewrite(&cfg, opts).await?;
+                }
+                MonitorActions::Export { dir, tags } => {
+                    commands::monitors::export(&cfg, &dir, tags.as_deref()).await?;
+                }
+                MonitorActions::Import { dir, dry_run } => {
+                    commands::monitors::import(&cfg, &dir, dry_run).await?;
+                }
+                MonitorActions::History {
+                    monitor_id,
+                    from,
+                    to,
+                } => {
+                    commands::monitors::history(&cfg, monitor_id, &from, &to).await?;
+                }
+                MonitorActions::Tune {
+                    monitor_id,
+                    lookback,
+                    patch,
+                } => {
+                    commands::monitors::tune(&cfg, monitor_id, &lookback, patch).await?;
+                }
             }
         }
         // --- Logs ---
@@ -4957,10 +8735,20 @@ async fn main_inner() -> anyhow::Result<()> {
                     to,
                     limit,
                     sort: _,
-                    index: _,
+                    indexes,
                     storage,
+                    all,
+                    max_items,
                 } => {
-                    commands::logs::search(&cfg, query, from, to, limit, storage).await?;
+                    if all {
+                        commands::logs::search_all_pages(
+                            &cfg, query, from, to, indexes, storage, max_items,
+                        )
+                        .await?;
+                    } else {
+                        commands::logs::search(&cfg, query, from, to, limit, indexes, storage)
+                            .await?;
+                    }
                 }
                 LogActions::List {
                     query,
@@ -4968,9 +8756,20 @@ async fn main_inner() -> anyhow::Result<()> {
                     to,
                     limit,
                     sort: _,
+                    indexes,
                     storage,
+                    all,
+                    max_items,
                 } => {
-                    commands::logs::list(&cfg, query, from, to, limit, storage).await?;
+                    if all {
+                        commands::logs::search_all_pages(
+                            &cfg, query, from, to, indexes, storage, max_items,
+                        )
+                        .await?;
+                    } else {
+                        commands::logs::list(&cfg, query, from, to, limit, indexes, storage)
+                            .await?;
+                    }
                 }
                 LogActions::Query {
                     query,
@@ -4978,10 +8777,11 @@ async fn main_inner() -> anyhow::Result<()> {
                     to,
                     limit,
                     sort: _,
+                    indexes,
                     storage,
                     timezone: _,
                 } => {
-                    commands::logs::query(&cfg, query, from, to, limit, storage).await?;
+                    commands::logs::query(&cfg, query, from, to, limit, indexes, storage).await?;
                 }
                 LogActions::Aggregate {
                     query,
@@ -4990,19 +8790,108 @@ async fn main_inner() -> anyhow::Result<()> {
                     compute: _,
                     group_by: _,
                     limit: _,
+                    indexes,
                     storage,
                 } => {
-                    commands::logs::aggregate(&cfg, query.unwrap_or_default(), from, to, storage)
-                        .await?;
+                    commands::logs::aggregate(
+                        &cfg,
+                        query.unwrap_or_default(),
+                        from,
+                        to,
+                        indexes,
+                        storage,
+                    )
+                    .await?;
+                }
+                LogActions::Tail {
+                    query,
+                    from,
+                    lines,
+                    indexes,
+                    format,
+                    follow,
+                    interval,
+                } => {
+                    commands::logs::tail(
+                        &cfg, query, from, lines, indexes, format, follow, interval,
+                    )
+                    .await?;
+                }
+                LogActions::Pattern {
+                    query,
+                    from,
+                    to,
+                    sample,
+                    top,
+                    similarity,
+                } => {
+                    commands::logs::pattern(&cfg, query, from, to, sample, top, similarity).await?;
                 }
                 LogActions::Archives { action } => match action {
                     LogArchiveActions::List => commands::logs::archives_list(&cfg).await?,
                     LogArchiveActions::Get { archive_id } => {
                         commands::logs::archives_get(&cfg, &archive_id).await?;
                     }
+                    LogArchiveActions::Create { file } => {
+                        commands::logs::archives_create(&cfg, &file).await?;
+                    }
+                    LogArchiveActions::Update { archive_id, file } => {
+                        commands::logs::archives_update(&cfg, &archive_id, &file).await?;
+                    }
                     LogArchiveActions::Delete { archive_id } => {
+                        if !confirm::Destructive::new("delete", "logs archive", &archive_id)
+                            .typed()
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::logs::archives_delete(&cfg, &archive_id).await?;
                     }
+                    LogArchiveActions::Validate { archive_id } => {
+                        commands::logs::archives_validate(&cfg, &archive_id).await?;
+                    }
+                },
+                LogActions::Indexes { action } => match action {
+                    LogIndexActions::List => commands::logs::indexes_list(&cfg).await?,
+                    LogIndexActions::Get { name } => {
+                        commands::logs::indexes_get(&cfg, &name).await?;
+                    }
+                    LogIndexActions::Update {
+                        name,
+                        add_exclusion,
+                        remove_exclusion,
+                        sample_rate,
+                    } => {
+                        let edits = commands::logs::ExclusionEdits {
+                            add: add_exclusion,
+                            remove: remove_exclusion,
+                            sample_rates: sample_rate,
+                        };
+                        commands::logs::indexes_update(&cfg, &name, &edits).await?;
+                    }
+                },
+                LogActions::Pipelines { action } => match action {
+                    LogPipelineActions::List => commands::logs::pipelines_list(&cfg).await?,
+                    LogPipelineActions::Get { pipeline_id } => {
+                        commands::logs::pipelines_get(&cfg, &pipeline_id).await?;
+                    }
+                    LogPipelineActions::Create { file } => {
+                        commands::logs::pipelines_create(&cfg, &file).await?;
+                    }
+                    LogPipelineActions::Update { pipeline_id, file } => {
+                        commands::logs::pipelines_update(&cfg, &pipeline_id, &file).await?;
+                    }
+                    LogPipelineActions::Delete { pipeline_id } => {
+                        if !confirm::Destructive::new("delete", "logs pipeline", &pipeline_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::logs::pipelines_delete(&cfg, &pipeline_id).await?;
+                    }
+                    LogPipelineActions::Reorder { pipeline_ids } => {
+                        commands::logs::pipelines_reorder(&cfg, &pipeline_ids).await?;
+                    }
                 },
                 LogActions::CustomDestinations { action } => match action {
                     LogCustomDestinationActions::List => {
@@ -5011,6 +8900,17 @@ async fn main_inner() -> anyhow::Result<()> {
                     LogCustomDestinationActions::Get { destination_id } => {
                         commands::logs::custom_destinations_get(&cfg, &destination_id).await?;
                     }
+                    LogCustomDestinationActions::Create {
+                        body,
+                        secret_from_env,
+                        secret_from_file,
+                    } => {
+                        let secret = util::read_secret(
+                            secret_from_env.as_deref(),
+                            secret_from_file.as_deref(),
+                        )?;
+                        commands::logs::custom_destinations_create(&cfg, &body, secret).await?;
+                    }
                 },
                 LogActions::Metrics { action } => match action {
                     LogMetricActions::List => commands::logs::metrics_list(&cfg).await?,
@@ -5018,6 +8918,11 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::logs::metrics_get(&cfg, &metric_id).await?;
                     }
                     LogMetricActions::Delete { metric_id } => {
+                        if !confirm::Destructive::new("delete", "log-based metric", &metric_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::logs::metrics_delete(&cfg, &metric_id).await?;
                     }
                 },
@@ -5029,17 +8934,145 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::logs::restriction_queries_get(&cfg, &query_id).await?;
                     }
                 },
-            }
-        }
-        // --- Incidents ---
-        Commands::Incidents { action } => {
-            cfg.validate_auth()?;
-            match action {
-                IncidentActions::List { limit } => {
-                    commands::incidents::list(&cfg, limit).await?;
+            }
+        }
+        // --- Incidents ---
+        Commands::Incidents { action } => {
+            cfg.validate_auth()?;
+            match action {
+                IncidentActions::List {
+                    limit,
+                    status,
+                    severity,
+                    customer_impacted,
+                    from,
+                    to,
+                    query,
+                    sort,
+                    page,
+                    all,
+                    max_items,
+                } => {
+                    let filters = commands::incidents::IncidentFilters {
+                        states: status,
+                        severities: severity,
+                        customer_impacted,
+                        from,
+                        to,
+                        query,
+                    };
+                    if filters.is_empty() && sort.is_none() && page == 0 && !all {
+                        commands::incidents::list(&cfg, limit).await?;
+                    } else {
+                        let page = commands::incidents::SearchPage {
+                            sort: sort.unwrap_or_else(|| "-created".into()),
+                            limit,
+                            page,
+                            all,
+                            max_items,
+                        };
+                        commands::incidents::search(&cfg, &filters, page).await?;
+                    }
+                }
+                IncidentActions::Get { incident_id } => {
+                    commands::incidents::get(&cfg, &incident_id).await?;
+                }
+                IncidentActions::Export {
+                    incident_id,
+                    format,
+                } => {
+                    commands::incidents::export(&cfg, &incident_id, &format).await?;
+                }
+                IncidentActions::Create {
+                    title,
+                    severity,
+                    commander,
+                    customer_impacted,
+                    customer_impact_scope,
+                } => {
+                    let changes = commands::incidents::IncidentChanges {
+                        title: Some(title),
+                        severity,
+                        commander,
+                        customer_impacted: customer_impacted.then_some(true),
+                        customer_impact_scope,
+                        ..Default::default()
+                    };
+                    commands::incidents::create(&cfg, changes).await?;
+                }
+                IncidentActions::Update {
+                    incident_id,
+                    status,
+                    severity,
+                    title,
+                    commander,
+                    customer_impact_scope,
+                } => {
+                    let changes = commands::incidents::IncidentChanges {
+                        title,
+                        severity,
+                        state: status,
+                        commander,
+                        customer_impact_scope,
+                        ..Default::default()
+                    };
+                    commands::incidents::update(&cfg, &incident_id, changes).await?;
+                }
+                IncidentActions::Timeline {
+                    action,
+                    incident_id,
+                    follow,
+                    interval,
+                } => match action {
+                    Some(IncidentTimelineActions::Add {
+                        incident_id,
+                        content,
+                    }) => {
+                        commands::incidents::timeline_add(&cfg, &incident_id, &content).await?;
+                    }
+                    None => {
+                        let Some(incident_id) = incident_id else {
+                            anyhow::bail!(
+                                "an incident ID is required (or use 'pup incidents timeline add')"
+                            );
+                        };
+                        commands::incidents::timeline(&cfg, &incident_id, follow, interval).await?;
+                    }
+                },
+                IncidentActions::CreateTicket {
+                    incident_id,
+                    account,
+                    project,
+                    issue_type,
+                } => {
+                    commands::incidents::create_ticket(
+                        &cfg,
+                        &incident_id,
+                        &account,
+                        &project,
+                        issue_type.as_deref(),
+                    )
+                    .await?;
                 }
-                IncidentActions::Get { incident_id } => {
-                    commands::incidents::get(&cfg, &incident_id).await?;
+                IncidentActions::Watch {
+                    escalate_after,
+                    notify,
+                    webhook,
+                    once,
+                    interval,
+                    state_file,
+                    dry_run,
+                } => {
+                    let opts = commands::incidents::WatchOptions {
+                        rules: commands::incidents::parse_escalation_rules(&escalate_after)?,
+                        notify,
+                        webhook,
+                        once,
+                        interval_secs: interval,
+                        state_file,
+                        dry_run,
+                    };
+                    commands::incidents::watch(&cfg, opts).await?;
                 }
                 IncidentActions::Attachments { action } => match action {
                     IncidentAttachmentActions::List { incident_id } => {
@@ -5049,6 +9082,15 @@ async fn main_inner() -> anyhow::Result<()> {
                         incident_id,
                         attachment_id,
                     } => {
+                        if !confirm::Destructive::new(
+                            "delete",
+                            "incident attachment",
+                            &attachment_id,
+                        )
+                        .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::incidents::attachments_delete(&cfg, &incident_id, &attachment_id)
                             .await?;
                     }
@@ -5072,6 +9114,11 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::incidents::handles_update(&cfg, &file).await?;
                     }
                     IncidentHandleActions::Delete { handle_id } => {
+                        if !confirm::Destructive::new("delete", "incident handle", &handle_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::incidents::handles_delete(&cfg, &handle_id).await?;
                     }
                 },
@@ -5090,6 +9137,11 @@ async fn main_inner() -> anyhow::Result<()> {
                             .await?;
                     }
                     IncidentPostmortemActions::Delete { template_id } => {
+                        if !confirm::Destructive::new("delete", "postmortem template", &template_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::incidents::postmortem_templates_delete(&cfg, &template_id)
                             .await?;
                     }
@@ -5101,14 +9153,144 @@ async fn main_inner() -> anyhow::Result<()> {
             cfg.validate_auth()?;
             match action {
                 DashboardActions::List => commands::dashboards::list(&cfg).await?,
-                DashboardActions::Get { id } => commands::dashboards::get(&cfg, &id).await?,
+                DashboardActions::Get { id, name } => {
+                    let id = resolve::id_or_name(&cfg, resolve::Kind::Dashboard, id, name).await?;
+                    commands::dashboards::get(&cfg, &id).await?;
+                }
                 DashboardActions::Create { file } => {
                     commands::dashboards::create(&cfg, &file).await?;
                 }
-                DashboardActions::Update { id, file } => {
+                DashboardActions::Update {
+                    id,
+                    name,
+                    file,
+                    preflight,
+                } => {
+                    let id = resolve::id_or_name(&cfg, resolve::Kind::Dashboard, id, name).await?;
+                    if preflight {
+                        commands::restriction_policies::preflight(&cfg, "dashboard", &id).await?;
+                    }
                     commands::dashboards::update(&cfg, &id, &file).await?;
                 }
-                DashboardActions::Delete { id } => commands::dashboards::delete(&cfg, &id).await?,
+                DashboardActions::Delete {
+                    id,
+                    name,
+                    preflight,
+                } => {
+                    let id = resolve::id_or_name(&cfg, resolve::Kind::Dashboard, id, name).await?;
+                    if preflight {
+                        commands::restriction_policies::preflight(&cfg, "dashboard", &id).await?;
+                    }
+                    if !confirm::Destructive::new("delete", "dashboard", &id).confirm(&cfg)? {
+                        return Ok(());
+                    }
+                    commands::dashboards::delete(&cfg, &id).await?;
+                }
+                DashboardActions::Clone {
+                    id,
+                    title,
+                    add_tag,
+                    remove_tag,
+                    template_var,
+                    to_org,
+                } => {
+                    let opts = commands::dashboards::CloneOptions {
+                        title,
+                        add_tags: add_tag,
+                        remove_tags: remove_tag,
+                        template_defaults: template_var,
+                        to_org,
+                    };
+                    commands::dashboards::clone(&cfg, &id, opts).await?;
+                }
+                DashboardActions::Export { dir } => {
+                    commands::dashboards::export(&cfg, &dir).await?;
+                }
+                DashboardActions::Import { dir, dry_run } => {
+                    commands::dashboards::import(&cfg, &dir, dry_run).await?;
+                }
+                DashboardActions::Diff { id, file } => {
+                    commands::dashboards::diff(&cfg, &id, &file).await?;
+                }
+                DashboardActions::Shares { action } => match action {
+                    DashboardShareActions::List { dashboard_id } => {
+                        commands::dashboards::shares_list(&cfg, &dashboard_id).await?;
+                    }
+                    DashboardShareActions::Create {
+                        dashboard_id,
+                        share_type,
+                        invitees,
+                        expires,
+                        live_span,
+                        selectable_time,
+                    } => {
+                        let opts = commands::dashboards::ShareOptions {
+                            share_type,
+                            invitees,
+                            expires,
+                            live_span,
+                            selectable_time,
+                        };
+                        commands::dashboards::shares_create(&cfg, &dashboard_id, &opts).await?;
+                    }
+                    DashboardShareActions::Revoke {
+                        dashboard_id,
+                        token,
+                    } => {
+                        let tokens = commands::dashboards::shares_to_revoke(
+                            &cfg,
+                            &dashboard_id,
+                            token.as_deref(),
+                        )
+                        .await?;
+                        if tokens.is_empty() {
+                            eprintln!("Dashboard {dashboard_id} has no shared links.");
+                            return Ok(());
+                        }
+                        let mut prompt = confirm::Destructive::new(
+                            "revoke",
+                            "shared links of dashboard",
+                            &dashboard_id,
+                        );
+                        for token in &tokens {
+                            prompt = prompt.detail(format!("  {token}"));
+                        }
+                        if !prompt.confirm(&cfg)? {
+                            return Ok(());
+                        }
+                        commands::dashboards::shares_revoke(&cfg, &tokens).await?;
+                    }
+                },
+                DashboardActions::Lists { action } => match action {
+                    DashboardListActions::List => commands::dashboards::lists_list(&cfg).await?,
+                    DashboardListActions::Get { list_id } => {
+                        commands::dashboards::lists_get(&cfg, list_id).await?;
+                    }
+                    DashboardListActions::Create { name } => {
+                        commands::dashboards::lists_create(&cfg, &name).await?;
+                    }
+                    DashboardListActions::Delete { list_id } => {
+                        if !confirm::Destructive::new("delete", "dashboard list", list_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::dashboards::lists_delete(&cfg, list_id).await?;
+                    }
+                    DashboardListActions::AddItems {
+                        list_id,
+                        dashboards,
+                    } => {
+                        commands::dashboards::lists_add_items(&cfg, list_id, &dashboards).await?;
+                    }
+                    DashboardListActions::RemoveItems {
+                        list_id,
+                        dashboards,
+                    } => {
+                        commands::dashboards::lists_remove_items(&cfg, list_id, &dashboards)
+                            .await?;
+                    }
+                },
             }
         }
         // --- Metrics ---
@@ -5124,12 +9306,59 @@ async fn main_inner() -> anyhow::Result<()> {
                 MetricActions::Query { query, from, to } => {
                     commands::metrics::query(&cfg, query, from, to).await?;
                 }
-                MetricActions::Submit { file, .. } => {
-                    if let Some(f) = file {
-                        commands::metrics::submit(&cfg, &f).await?;
-                    } else {
-                        anyhow::bail!("flag-based submit not yet implemented; use --file");
-                    }
+                MetricActions::Detect {
+                    query,
+                    algorithm,
+                    method,
+                    bounds,
+                    window,
+                    horizon,
+                    threshold,
+                    below,
+                } => {
+                    let opts = commands::metrics::DetectOptions {
+                        query,
+                        algorithm: algorithm.parse()?,
+                        method,
+                        bounds,
+                        window,
+                        horizon,
+                        threshold,
+                        below,
+                    };
+                    commands::metrics::detect(&cfg, opts).await?;
+                }
+                MetricActions::Submit {
+                    name,
+                    value,
+                    tags,
+                    r#type,
+                    host,
+                    interval,
+                    timestamp,
+                    file,
+                } => {
+                    let body = match (file, name, value) {
+                        (Some(f), _, _) => commands::metrics::read_series_file(&f)?,
+                        (None, Some(name), Some(value)) => {
+                            commands::metrics::series_payload(&commands::metrics::SubmitOptions {
+                                name,
+                                value,
+                                metric_type: r#type,
+                                tags: tags
+                                    .iter()
+                                    .flat_map(|t| t.split(','))
+                                    .map(|t| t.trim().to_string())
+                                    .filter(|t| !t.is_empty())
+                                    .collect(),
+                                host,
+                                interval,
+                                timestamp: util::parse_time_to_unix(&timestamp)?,
+                            })?
+                        }
+                        _ => anyhow::bail!("--name and --value are required (or use --file)"),
+                    };
+                    commands::metrics::submit(&cfg, body).await?;
                 }
                 MetricActions::Metadata { action } => match action {
                     MetricMetadataActions::Get { metric_name } => {
@@ -5159,17 +9388,105 @@ async fn main_inner() -> anyhow::Result<()> {
             cfg.validate_auth()?;
             match action {
                 SloActions::List => commands::slos::list(&cfg).await?,
-                SloActions::Get { id } => commands::slos::get(&cfg, &id).await?,
+                SloActions::Get { id, name } => {
+                    let id = resolve::id_or_name(&cfg, resolve::Kind::Slo, id, name).await?;
+                    commands::slos::get(&cfg, &id).await?;
+                }
+                SloActions::Search { query, page, limit } => {
+                    commands::slos::search(&cfg, query, page, limit).await?
+                }
                 SloActions::Create { file } => commands::slos::create(&cfg, &file).await?,
-                SloActions::Update { id, file } => {
+                SloActions::Update {
+                    id,
+                    name,
+                    file,
+                    preflight,
+                } => {
+                    let id = resolve::id_or_name(&cfg, resolve::Kind::Slo, id, name).await?;
+                    if preflight {
+                        commands::restriction_policies::preflight(&cfg, "slo", &id).await?;
+                    }
                     commands::slos::update(&cfg, &id, &file).await?;
                 }
-                SloActions::Delete { id } => commands::slos::delete(&cfg, &id).await?,
+                SloActions::Delete {
+                    id,
+                    name,
+                    preflight,
+                } => {
+                    let id = resolve::id_or_name(&cfg, resolve::Kind::Slo, id, name).await?;
+                    if preflight {
+                        commands::restriction_policies::preflight(&cfg, "slo", &id).await?;
+                    }
+                    if !confirm::Destructive::new("delete", "SLO", &id).confirm(&cfg)? {
+                        return Ok(());
+                    }
+                    commands::slos::delete(&cfg, &id).await?;
+                }
                 SloActions::Status { id, from, to } => {
                     let from_ts = util::parse_time_to_unix_millis(&from)? / 1000;
                     let to_ts = util::parse_time_to_unix_millis(&to)? / 1000;
                     commands::slos::status(&cfg, &id, from_ts, to_ts).await?;
                 }
+                SloActions::Report { tag, from, to } => {
+                    let from_ts = util::parse_time_to_unix(&from)?;
+                    let to_ts = util::parse_time_to_unix(&to)?;
+                    commands::slos::report(&cfg, &tag, from_ts, to_ts).await?;
+                }
+                SloActions::Suggest {
+                    service,
+                    env,
+                    operation,
+                    target,
+                    dir,
+                } => {
+                    commands::slos::suggest(
+                        &cfg,
+                        &service,
+                        env.as_deref(),
+                        operation,
+                        target,
+                        dir.as_deref(),
+                    )
+                    .await?;
+                }
+                SloActions::Corrections { action } => match action {
+                    SloCorrectionActions::List { slo_id } => {
+                        commands::slos::corrections_list(&cfg, slo_id.as_deref()).await?;
+                    }
+                    SloCorrectionActions::Create {
+                        slo_id,
+                        start,
+                        end,
+                        duration,
+                        category,
+                        description,
+                        timezone,
+                        rrule,
+                    } => {
+                        let opts = commands::slos::CorrectionOptions {
+                            slo_id,
+                            start: util::parse_time_to_unix(&start)?,
+                            end: end.as_deref().map(util::parse_time_to_unix).transpose()?,
+                            duration: duration
+                                .as_deref()
+                                .map(util::parse_duration_secs)
+                                .transpose()?,
+                            category,
+                            description,
+                            timezone,
+                            rrule,
+                        };
+                        commands::slos::corrections_create(&cfg, &opts).await?;
+                    }
+                    SloCorrectionActions::Delete { correction_id } => {
+                        if !confirm::Destructive::new("delete", "SLO correction", &correction_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::slos::corrections_delete(&cfg, &correction_id).await?;
+                    }
+                },
             }
         }
         // --- Synthetics ---
@@ -5183,8 +9500,44 @@ async fn main_inner() -> anyhow::Result<()> {
                     SyntheticsTestActions::Get { public_id } => {
                         commands::synthetics::tests_get(&cfg, &public_id).await?;
                     }
-                    SyntheticsTestActions::Search { text, count, start } => {
-                        commands::synthetics::tests_search(&cfg, text, count, start).await?;
+                    SyntheticsTestActions::Search {
+                        text,
+                        limit,
+                        offset,
+                    } => {
+                        commands::synthetics::tests_search(&cfg, text, limit, offset).await?;
+                    }
+                    SyntheticsTestActions::Create { body } => {
+                        commands::synthetics::tests_create(&cfg, &body).await?;
+                    }
+                    SyntheticsTestActions::Update { public_id, body } => {
+                        commands::synthetics::tests_update(&cfg, &public_id, &body).await?;
+                    }
+                    SyntheticsTestActions::Delete { public_ids } => {
+                        if !confirm::Destructive::new(
+                            "delete",
+                            "synthetic test(s)",
+                            public_ids.join(", "),
+                        )
+                        .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::synthetics::tests_delete(&cfg, &public_ids).await?;
+                    }
+                    SyntheticsTestActions::Pause { public_id } => {
+                        commands::synthetics::tests_set_status(&cfg, &public_id, "paused").await?;
+                    }
+                    SyntheticsTestActions::Resume { public_id } => {
+                        commands::synthetics::tests_set_status(&cfg, &public_id, "live").await?;
+                    }
+                    SyntheticsTestActions::Trigger {
+                        public_ids,
+                        wait,
+                        wait_timeout,
+                    } => {
+                        commands::synthetics::tests_trigger(&cfg, &public_ids, wait, &wait_timeout)
+                            .await?;
                     }
                 },
                 SyntheticsActions::Locations { action } => match action {
@@ -5206,6 +9559,15 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::synthetics::suites_update(&cfg, &suite_id, &file).await?;
                     }
                     SyntheticsSuiteActions::Delete { suite_ids, .. } => {
+                        if !confirm::Destructive::new(
+                            "delete",
+                            "synthetic suite(s)",
+                            suite_ids.join(", "),
+                        )
+                        .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::synthetics::suites_delete(&cfg, suite_ids).await?;
                     }
                 },
@@ -5231,8 +9593,47 @@ async fn main_inner() -> anyhow::Result<()> {
                 EventActions::Get { event_id } => {
                     commands::events::get(&cfg, event_id).await?;
                 }
+                EventActions::Send {
+                    title,
+                    text,
+                    category,
+                    alert_status,
+                    aggregation_key,
+                    host,
+                    tags,
+                    related_monitor,
+                    related_service,
+                    source_type,
+                    changed_resource,
+                    dedup_window,
+                } => {
+                    let opts = commands::events::EventSendOptions {
+                        title,
+                        message: text,
+                        category,
+                        alert_status,
+                        aggregation_key,
+                        host,
+                        tags: tags
+                            .map(|t| t.split(',').map(|s| s.trim().to_string()).collect())
+                            .unwrap_or_default(),
+                        related_monitors: related_monitor,
+                        related_services: related_service,
+                        source_type,
+                        changed_resource,
+                        dedup_window,
+                    };
+                    commands::events::send(&cfg, opts).await?;
+                }
             }
         }
+        // --- Doctor ---
+        Commands::Doctor => {
+            if let Err(e) = cfg.resolve_credential_process() {
+                return commands::doctor::config_load_error(e);
+            }
+            commands::doctor::run(&cfg).await?
+        }
         // --- Downtime ---
         Commands::Downtime { action } => {
             cfg.validate_auth()?;
@@ -5242,7 +9643,15 @@ async fn main_inner() -> anyhow::Result<()> {
                 DowntimeActions::Create { file } => {
                     commands::downtime::create(&cfg, &file).await?;
                 }
-                DowntimeActions::Cancel { id } => commands::downtime::cancel(&cfg, &id).await?,
+                DowntimeActions::Cancel { id } => {
+                    if !confirm::Destructive::new("cancel", "downtime", &id).confirm(&cfg)? {
+                        return Ok(());
+                    }
+                    commands::downtime::cancel(&cfg, &id).await?;
+                }
+                DowntimeActions::Apply { file, dry_run } => {
+                    commands::downtime::apply(&cfg, &file, dry_run).await?
+                }
             }
         }
         // --- Tags ---
@@ -5258,8 +9667,45 @@ async fn main_inner() -> anyhow::Result<()> {
                     commands::tags::update(&cfg, &hostname, tags).await?;
                 }
                 TagActions::Delete { hostname } => {
+                    if !confirm::Destructive::new("delete", "all user tags on host", &hostname)
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
+                    }
                     commands::tags::delete(&cfg, &hostname).await?;
                 }
+                TagActions::BulkAdd {
+                    tags,
+                    filter,
+                    file,
+                    concurrency,
+                } => {
+                    commands::tags::bulk(
+                        &cfg,
+                        commands::tags::BulkOp::Add,
+                        &tags,
+                        filter.as_deref(),
+                        file.as_deref(),
+                        concurrency,
+                    )
+                    .await?;
+                }
+                TagActions::BulkRemove {
+                    tags,
+                    filter,
+                    file,
+                    concurrency,
+                } => {
+                    commands::tags::bulk(
+                        &cfg,
+                        commands::tags::BulkOp::Remove,
+                        &tags,
+                        filter.as_deref(),
+                        file.as_deref(),
+                        concurrency,
+                    )
+                    .await?;
+                }
             }
         }
         // --- Users ---
@@ -5268,8 +9714,103 @@ async fn main_inner() -> anyhow::Result<()> {
             match action {
                 UserActions::List => commands::users::list(&cfg).await?,
                 UserActions::Get { user_id } => commands::users::get(&cfg, &user_id).await?,
+                UserActions::Invite {
+                    emails,
+                    file,
+                    roles,
+                } => commands::users::invite(&cfg, &emails, file.as_deref(), &roles).await?,
+                UserActions::Disable { user_id } => {
+                    if !confirm::Destructive::new("disable", "user", &user_id).confirm(&cfg)? {
+                        return Ok(());
+                    }
+                    commands::users::disable(&cfg, &user_id).await?
+                }
                 UserActions::Roles { action } => match action {
                     UserRoleActions::List => commands::users::roles_list(&cfg).await?,
+                    UserRoleActions::Assign { role, user_ids } => {
+                        commands::users::roles_assign(&cfg, &role, &user_ids).await?
+                    }
+                    UserRoleActions::Remove { role, user_ids } => {
+                        if !confirm::Destructive::new("remove", "role", &role)
+                            .detail(format!("From {} user(s).", user_ids.len()))
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::users::roles_remove(&cfg, &role, &user_ids).await?
+                    }
+                },
+            }
+        }
+        // --- Watchdog ---
+        Commands::Watchdog { action } => {
+            cfg.validate_auth()?;
+            match action {
+                WatchdogActions::Alerts { action } => match action {
+                    WatchdogAlertActions::List {
+                        from,
+                        to,
+                        service,
+                        resource,
+                        env,
+                        query,
+                        limit,
+                    } => {
+                        let opts = commands::watchdog::AlertsListOptions {
+                            from,
+                            to,
+                            service,
+                            resource,
+                            env,
+                            query,
+                            limit,
+                        };
+                        commands::watchdog::alerts_list(&cfg, opts).await?;
+                    }
+                    WatchdogAlertActions::Get { alert_id } => {
+                        commands::watchdog::alerts_get(&cfg, &alert_id).await?;
+                    }
+                },
+            }
+        }
+        // --- Workflows ---
+        Commands::Workflows { action } => {
+            cfg.validate_auth()?;
+            match action {
+                WorkflowActions::List { query, limit, page } => {
+                    commands::workflows::list(&cfg, query, limit, page).await?;
+                }
+                WorkflowActions::Get { workflow_id } => {
+                    commands::workflows::get(&cfg, &workflow_id).await?;
+                }
+                WorkflowActions::Trigger {
+                    workflow_id,
+                    inputs,
+                    payload,
+                } => {
+                    commands::workflows::trigger(&cfg, &workflow_id, payload, &inputs).await?;
+                }
+                WorkflowActions::Cancel {
+                    workflow_id,
+                    instance_id,
+                } => {
+                    if !confirm::Destructive::new("cancel", "workflow instance", &instance_id)
+                        .detail(format!("Workflow: {workflow_id}"))
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
+                    }
+                    commands::workflows::cancel(&cfg, &workflow_id, &instance_id).await?;
+                }
+                WorkflowActions::Instances { action } => match action {
+                    WorkflowInstanceActions::List {
+                        workflow_id,
+                        limit,
+                        page,
+                    } => {
+                        commands::workflows::instances_list(&cfg, &workflow_id, limit, page)
+                            .await?;
+                    }
                 },
             }
         }
@@ -5281,9 +9822,9 @@ async fn main_inner() -> anyhow::Result<()> {
                     InfraHostActions::List {
                         filter,
                         sort,
-                        count,
+                        limit,
                     } => {
-                        commands::infrastructure::hosts_list(&cfg, filter, sort, count).await?;
+                        commands::infrastructure::hosts_list(&cfg, filter, sort, limit).await?;
                     }
                     InfraHostActions::Get { hostname } => {
                         commands::infrastructure::hosts_get(&cfg, &hostname).await?;
@@ -5306,6 +9847,25 @@ async fn main_inner() -> anyhow::Result<()> {
                 } => {
                     commands::audit_logs::search(&cfg, query, from, to, limit).await?;
                 }
+                AuditLogActions::Export {
+                    query,
+                    from,
+                    to,
+                    output_file,
+                    follow,
+                    interval,
+                } => {
+                    commands::audit_logs::export(
+                        &cfg,
+                        query,
+                        from,
+                        to,
+                        output_file,
+                        follow,
+                        interval,
+                    )
+                    .await?;
+                }
             }
         }
         // --- Security ---
@@ -5322,6 +9882,48 @@ async fn main_inner() -> anyhow::Result<()> {
                     SecurityRuleActions::BulkExport { rule_ids } => {
                         commands::security::rules_bulk_export(&cfg, rule_ids).await?;
                     }
+                    SecurityRuleActions::Create { body } => {
+                        commands::security::rules_create(&cfg, &body).await?;
+                    }
+                    SecurityRuleActions::Update { rule_id, body } => {
+                        commands::security::rules_update(&cfg, &rule_id, &body).await?;
+                    }
+                    SecurityRuleActions::Delete { rule_id } => {
+                        if !confirm::Destructive::new("delete", "security rule", &rule_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::security::rules_delete(&cfg, &rule_id).await?;
+                    }
+                    SecurityRuleActions::Enable { rule_ids, tags } => {
+                        let rules =
+                            commands::security::rules_matching(&cfg, &rule_ids, &tags).await?;
+                        commands::security::rules_set_enabled(&cfg, rules, true).await?;
+                    }
+                    SecurityRuleActions::Disable { rule_ids, tags } => {
+                        let rules =
+                            commands::security::rules_matching(&cfg, &rule_ids, &tags).await?;
+                        let mut prompt = confirm::Destructive::new(
+                            "disable",
+                            &format!("{} security rules", rules.len()),
+                            "",
+                        );
+                        for rule in rules.iter().take(10) {
+                            prompt = prompt.detail(format!(
+                                "  {} ({})",
+                                rule["name"].as_str().unwrap_or_default(),
+                                rule["id"].as_str().unwrap_or_default()
+                            ));
+                        }
+                        if rules.len() > 10 {
+                            prompt = prompt.detail(format!("  ... and {} more", rules.len() - 10));
+                        }
+                        if !prompt.confirm(&cfg)? {
+                            return Ok(());
+                        }
+                        commands::security::rules_set_enabled(&cfg, rules, false).await?;
+                    }
                 },
                 SecurityActions::Signals { action } => match action {
                     SecuritySignalActions::List {
@@ -5335,8 +9937,18 @@ async fn main_inner() -> anyhow::Result<()> {
                     }
                 },
                 SecurityActions::Findings { action } => match action {
-                    SecurityFindingActions::Search { query, limit } => {
-                        commands::security::findings_search(&cfg, query, limit).await?;
+                    SecurityFindingActions::Search {
+                        query,
+                        limit,
+                        all,
+                        max_items,
+                    } => {
+                        if all {
+                            commands::security::findings_search_all_pages(&cfg, query, max_items)
+                                .await?;
+                        } else {
+                            commands::security::findings_search(&cfg, query, limit).await?;
+                        }
                     }
                 },
                 SecurityActions::ContentPacks { action } => match action {
@@ -5363,6 +9975,64 @@ async fn main_inner() -> anyhow::Result<()> {
             match action {
                 OrgActions::List => commands::organizations::list(&cfg).await?,
                 OrgActions::Get => commands::organizations::get(&cfg).await?,
+                OrgActions::LoginMethods { action } => match action {
+                    OrgLoginMethodActions::Get => {
+                        commands::organizations::login_methods_get(&cfg).await?;
+                    }
+                },
+                OrgActions::Idp { action } => match action {
+                    OrgIdpActions::Metadata { action } => match action {
+                        OrgIdpMetadataActions::Get => {
+                            commands::organizations::idp_metadata_get(&cfg).await?;
+                        }
+                    },
+                },
+            }
+        }
+        // --- Cloud Cost ---
+        Commands::CloudCost { action } => {
+            cfg.validate_auth()?;
+            let (provider, action) = match action {
+                CloudCostActions::Status { provider } => {
+                    let provider = provider.map(|p| p.parse()).transpose()?;
+                    commands::cloud_cost::status(&cfg, provider).await?;
+                    return Ok(());
+                }
+                CloudCostActions::Aws { action } => (commands::cloud_cost::Provider::Aws, action),
+                CloudCostActions::Azure { action } => {
+                    (commands::cloud_cost::Provider::Azure, action)
+                }
+                CloudCostActions::Gcp { action } => (commands::cloud_cost::Provider::Gcp, action),
+            };
+            match action {
+                CloudCostConfigActions::List => commands::cloud_cost::list(&cfg, provider).await?,
+                CloudCostConfigActions::Create { file } => {
+                    commands::cloud_cost::create(&cfg, provider, &file).await?;
+                }
+                CloudCostConfigActions::Update {
+                    config_id,
+                    file,
+                    enabled,
+                } => {
+                    commands::cloud_cost::update(
+                        &cfg,
+                        provider,
+                        &config_id,
+                        file.as_deref(),
+                        enabled,
+                    )
+                    .await?;
+                }
+                CloudCostConfigActions::Delete { config_id } => {
+                    let what = format!("{} cost config", provider.name());
+                    if !confirm::Destructive::new("delete", &what, &config_id)
+                        .detail("Cost data stops ingesting for this account.")
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
+                    }
+                    commands::cloud_cost::delete(&cfg, provider, &config_id).await?;
+                }
             }
         }
         // --- Cloud ---
@@ -5370,13 +10040,78 @@ async fn main_inner() -> anyhow::Result<()> {
             cfg.validate_auth()?;
             match action {
                 CloudActions::Aws { action } => match action {
-                    CloudAwsActions::List => commands::cloud::aws_list(&cfg).await?,
+                    CloudAwsActions::List { aws_account_id } => {
+                        commands::cloud::aws_list(&cfg, aws_account_id.as_deref()).await?;
+                    }
+                    CloudAwsActions::Get { account_id } => {
+                        commands::cloud::aws_get(&cfg, &account_id).await?;
+                    }
+                    CloudAwsActions::Create { file } => {
+                        commands::cloud::aws_create(&cfg, &file).await?;
+                    }
+                    CloudAwsActions::Update { account_id, file } => {
+                        commands::cloud::aws_update(&cfg, &account_id, &file).await?;
+                    }
+                    CloudAwsActions::Delete { account_id } => {
+                        if !confirm::Destructive::new("delete", "AWS account", &account_id)
+                            .detail("Datadog will stop collecting metrics, logs, and resources from this account.")
+                            .typed()
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::cloud::aws_delete(&cfg, &account_id).await?;
+                    }
+                    CloudAwsActions::GenerateExternalId => {
+                        commands::cloud::aws_generate_external_id(&cfg).await?;
+                    }
                 },
                 CloudActions::Gcp { action } => match action {
-                    CloudGcpActions::List => commands::cloud::gcp_list(&cfg).await?,
+                    CloudGcpActions::List { sts: false } => commands::cloud::gcp_list(&cfg).await?,
+                    CloudGcpActions::List { sts: true } => {
+                        commands::cloud::gcp_sts_list(&cfg).await?;
+                    }
+                    CloudGcpActions::Create { file } => {
+                        commands::cloud::gcp_create(&cfg, &file).await?;
+                    }
+                    CloudGcpActions::Update { account_id, file } => {
+                        commands::cloud::gcp_update(&cfg, &account_id, &file).await?;
+                    }
+                    CloudGcpActions::Delete { account_id } => {
+                        if !confirm::Destructive::new("delete", "GCP STS account", &account_id)
+                            .typed()
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::cloud::gcp_delete(&cfg, &account_id).await?;
+                    }
                 },
                 CloudActions::Azure { action } => match action {
                     CloudAzureActions::List => commands::cloud::azure_list(&cfg).await?,
+                    CloudAzureActions::Create { file } => {
+                        commands::cloud::azure_create(&cfg, &file).await?;
+                    }
+                    CloudAzureActions::Update { file } => {
+                        commands::cloud::azure_update(&cfg, &file).await?;
+                    }
+                    CloudAzureActions::Delete {
+                        tenant_id,
+                        client_id,
+                    } => {
+                        if !confirm::Destructive::new(
+                            "delete",
+                            "Azure app registration",
+                            &client_id,
+                        )
+                        .detail(format!("Tenant: {tenant_id}"))
+                        .typed()
+                        .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::cloud::azure_delete(&cfg, &tenant_id, &client_id).await?;
+                    }
                 },
                 CloudActions::Oci { action } => match action {
                     CloudOciActions::Tenancies { action } => match action {
@@ -5393,6 +10128,12 @@ async fn main_inner() -> anyhow::Result<()> {
                             commands::cloud::oci_tenancies_update(&cfg, &tenancy_id, &file).await?;
                         }
                         CloudOciTenancyActions::Delete { tenancy_id } => {
+                            if !confirm::Destructive::new("delete", "OCI tenancy", &tenancy_id)
+                                .typed()
+                                .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
                             commands::cloud::oci_tenancies_delete(&cfg, &tenancy_id).await?;
                         }
                     },
@@ -5408,10 +10149,8 @@ async fn main_inner() -> anyhow::Result<()> {
         Commands::Cases { action } => {
             cfg.validate_auth()?;
             match action {
-                CaseActions::Search {
-                    query, page_size, ..
-                } => {
-                    commands::cases::search(&cfg, query, page_size).await?;
+                CaseActions::Search { query, limit, page } => {
+                    commands::cases::search(&cfg, query, limit, page).await?;
                 }
                 CaseActions::Get { case_id } => commands::cases::get(&cfg, &case_id).await?,
                 CaseActions::Create {
@@ -5440,9 +10179,29 @@ async fn main_inner() -> anyhow::Result<()> {
                 CaseActions::Unarchive { case_id } => {
                     commands::cases::unarchive(&cfg, &case_id).await?;
                 }
-                CaseActions::Assign { case_id, user_id } => {
+                CaseActions::Assign { case_id, user } => {
+                    let user_id = if util::parse_uuid(&user, "user").is_ok() {
+                        user
+                    } else {
+                        resolve::resolve(&cfg, resolve::Kind::User, &user).await?
+                    };
                     commands::cases::assign(&cfg, &case_id, &user_id).await?;
                 }
+                CaseActions::Status { case_id, status } => {
+                    commands::cases::update_status(&cfg, &case_id, &status).await?;
+                }
+                CaseActions::Priority { case_id, priority } => {
+                    commands::cases::update_priority(&cfg, &case_id, &priority).await?;
+                }
+                CaseActions::Comment { case_id, message } => {
+                    let message = match message.strip_prefix('@') {
+                        Some(path) => std::fs::read_to_string(path).map_err(|e| {
+                            anyhow::anyhow!("failed to read message file {path:?}: {e}")
+                        })?,
+                        None => message,
+                    };
+                    commands::cases::comment(&cfg, &case_id, &message).await?;
+                }
                 CaseActions::UpdatePriority { case_id, priority } => {
                     commands::cases::update_priority(&cfg, &case_id, &priority).await?;
                 }
@@ -5467,6 +10226,12 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::cases::projects_create(&cfg, &name, &key).await?;
                     }
                     CaseProjectActions::Delete { project_id } => {
+                        if !confirm::Destructive::new("delete", "case project", &project_id)
+                            .typed()
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::cases::projects_delete(&cfg, &project_id).await?;
                     }
                     CaseProjectActions::Update { project_id, file } => {
@@ -5502,6 +10267,15 @@ async fn main_inner() -> anyhow::Result<()> {
                             project_id,
                             rule_id,
                         } => {
+                            if !confirm::Destructive::new(
+                                "delete",
+                                "case notification rule",
+                                &rule_id,
+                            )
+                            .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
                             commands::cases::projects_notification_rules_delete(
                                 &cfg,
                                 &project_id,
@@ -5512,8 +10286,21 @@ async fn main_inner() -> anyhow::Result<()> {
                     },
                 },
                 CaseActions::Jira { action } => match action {
-                    CaseJiraActions::CreateIssue { case_id, file } => {
-                        commands::cases::jira_create_issue(&cfg, &case_id, &file).await?;
+                    CaseJiraActions::CreateIssue {
+                        case_id,
+                        file,
+                        account,
+                        project,
+                        issue_type,
+                    } => {
+                        let body = commands::cases::request_body(file.as_deref(), || {
+                            commands::cases::jira_issue_body(
+                                account.as_deref().unwrap_or_default(),
+                                project.as_deref().unwrap_or_default(),
+                                issue_type.as_deref(),
+                            )
+                        })?;
+                        commands::cases::jira_create_issue(&cfg, &case_id, body).await?;
                     }
                     CaseJiraActions::Link { case_id, file } => {
                         commands::cases::jira_link(&cfg, &case_id, &file).await?;
@@ -5523,8 +10310,19 @@ async fn main_inner() -> anyhow::Result<()> {
                     }
                 },
                 CaseActions::Servicenow { action } => match action {
-                    CaseServicenowActions::CreateTicket { case_id, file } => {
-                        commands::cases::servicenow_create_ticket(&cfg, &case_id, &file).await?;
+                    CaseServicenowActions::CreateTicket {
+                        case_id,
+                        file,
+                        instance,
+                        assignment_group,
+                    } => {
+                        let body = commands::cases::request_body(file.as_deref(), || {
+                            commands::cases::servicenow_ticket_body(
+                                instance.as_deref().unwrap_or_default(),
+                                assignment_group.as_deref(),
+                            )
+                        })?;
+                        commands::cases::servicenow_create_ticket(&cfg, &case_id, body).await?;
                     }
                 },
             }
@@ -5543,12 +10341,24 @@ async fn main_inner() -> anyhow::Result<()> {
         Commands::ApiKeys { action } => {
             cfg.validate_auth()?;
             match action {
-                ApiKeyActions::List => commands::api_keys::list(&cfg).await?,
+                ApiKeyActions::List { all, max_items } => {
+                    if all {
+                        commands::api_keys::list_all_pages(&cfg, max_items).await?;
+                    } else {
+                        commands::api_keys::list(&cfg).await?;
+                    }
+                }
                 ApiKeyActions::Get { key_id } => commands::api_keys::get(&cfg, &key_id).await?,
                 ApiKeyActions::Create { name } => {
                     commands::api_keys::create(&cfg, &name).await?;
                 }
                 ApiKeyActions::Delete { key_id } => {
+                    if !confirm::Destructive::new("delete", "API key", &key_id)
+                        .typed()
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
+                    }
                     commands::api_keys::delete(&cfg, &key_id).await?;
                 }
             }
@@ -5558,19 +10368,24 @@ async fn main_inner() -> anyhow::Result<()> {
             cfg.validate_auth()?;
             match action {
                 AppKeyActions::List {
-                    page_size,
-                    page_number,
+                    limit,
+                    page,
                     filter,
                     sort,
                     all,
+                    all_pages,
+                    max_items,
                 } => {
                     if all {
                         cfg.validate_api_and_app_keys()?;
-                        commands::app_keys::list_all(&cfg, page_size, page_number, &filter, &sort)
+                    }
+                    if all_pages {
+                        commands::app_keys::list_all_pages(&cfg, all, &filter, &sort, max_items)
                             .await?
+                    } else if all {
+                        commands::app_keys::list_all(&cfg, limit, page, &filter, &sort).await?
                     } else {
-                        commands::app_keys::list(&cfg, page_size, page_number, &filter, &sort)
-                            .await?
+                        commands::app_keys::list(&cfg, limit, page, &filter, &sort).await?
                     }
                 }
                 AppKeyActions::Get { key_id } => commands::app_keys::get(&cfg, &key_id).await?,
@@ -5588,16 +10403,11 @@ async fn main_inner() -> anyhow::Result<()> {
                     commands::app_keys::update(&cfg, &key_id, &name, &scopes).await?
                 }
                 AppKeyActions::Delete { key_id } => {
-                    if !cfg.auto_approve {
-                        eprint!(
-                            "Permanently delete application key {key_id}? Type 'yes' to confirm: "
-                        );
-                        let mut input = String::new();
-                        std::io::stdin().read_line(&mut input)?;
-                        if input.trim() != "yes" {
-                            println!("Operation cancelled.");
-                            return Ok(());
-                        }
+                    if !confirm::Destructive::new("delete", "application key", &key_id)
+                        .typed()
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
                     }
                     commands::app_keys::delete(&cfg, &key_id).await?
                 }
@@ -5613,6 +10423,14 @@ async fn main_inner() -> anyhow::Result<()> {
                 UsageActions::Hourly { from, to } => {
                     commands::usage::hourly(&cfg, from, to).await?;
                 }
+                UsageActions::Report {
+                    from,
+                    to,
+                    product_families,
+                    threshold,
+                } => {
+                    commands::usage::report(&cfg, from, to, &product_families, threshold).await?;
+                }
             }
         }
         // --- Notebooks ---
@@ -5620,18 +10438,99 @@ async fn main_inner() -> anyhow::Result<()> {
             cfg.validate_auth()?;
             match action {
                 NotebookActions::List => commands::notebooks::list(&cfg).await?,
-                NotebookActions::Get { notebook_id } => {
+                NotebookActions::Get { notebook_id, name } => {
+                    let notebook_id = resolve::numeric_id_or_name(
+                        &cfg,
+                        resolve::Kind::Notebook,
+                        notebook_id,
+                        name,
+                    )
+                    .await?;
                     commands::notebooks::get(&cfg, notebook_id).await?;
                 }
                 NotebookActions::Create { file } => {
                     commands::notebooks::create(&cfg, &file).await?;
                 }
-                NotebookActions::Update { notebook_id, file } => {
+                NotebookActions::Update {
+                    notebook_id,
+                    name,
+                    file,
+                } => {
+                    let notebook_id = resolve::numeric_id_or_name(
+                        &cfg,
+                        resolve::Kind::Notebook,
+                        notebook_id,
+                        name,
+                    )
+                    .await?;
                     commands::notebooks::update(&cfg, notebook_id, &file).await?;
                 }
-                NotebookActions::Delete { notebook_id } => {
+                NotebookActions::Delete { notebook_id, name } => {
+                    let notebook_id = resolve::numeric_id_or_name(
+                        &cfg,
+                        resolve::Kind::Notebook,
+                        notebook_id,
+                        name,
+                    )
+                    .await?;
+                    if !confirm::Destructive::new("delete", "notebook", notebook_id)
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
+                    }
                     commands::notebooks::delete(&cfg, notebook_id).await?;
                 }
+                NotebookActions::Clone {
+                    notebook_id,
+                    name,
+                    to_org,
+                } => {
+                    commands::notebooks::clone(&cfg, notebook_id, name, to_org.as_deref()).await?;
+                }
+                NotebookActions::Cells { action } => {
+                    use commands::notebooks::{cell_attributes, cells_edit, CellEdit};
+                    match action {
+                        NotebookCellActions::List { notebook_id } => {
+                            commands::notebooks::cells_list(&cfg, notebook_id).await?;
+                        }
+                        NotebookCellActions::Append { notebook_id, file } => {
+                            let attributes = cell_attributes(util::read_json_body(&file)?)?;
+                            cells_edit(&cfg, notebook_id, CellEdit::Append(attributes)).await?;
+                        }
+                        NotebookCellActions::Update {
+                            notebook_id,
+                            cell_id,
+                            file,
+                        } => {
+                            let attributes = cell_attributes(util::read_json_body(&file)?)?;
+                            let edit = CellEdit::Update {
+                                cell_id,
+                                attributes,
+                            };
+                            cells_edit(&cfg, notebook_id, edit).await?;
+                        }
+                        NotebookCellActions::Delete {
+                            notebook_id,
+                            cell_id,
+                        } => {
+                            if !confirm::Destructive::new("delete", "notebook cell", &cell_id)
+                                .detail(format!("From notebook {notebook_id}."))
+                                .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
+                            cells_edit(&cfg, notebook_id, CellEdit::Delete { cell_id }).await?;
+                        }
+                        NotebookCellActions::Move {
+                            notebook_id,
+                            cell_id,
+                            position,
+                        } => {
+                            let edit = CellEdit::Move { cell_id, position };
+                            cells_edit(&cfg, notebook_id, edit).await?;
+                        }
+                    }
+                }
             }
         }
         // --- RUM ---
@@ -5649,6 +10548,12 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::rum::apps_update(&cfg, &app_id, &f).await?;
                     }
                     RumAppActions::Delete { app_id } => {
+                        if !confirm::Destructive::new("delete", "RUM application", &app_id)
+                            .typed()
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::rum::apps_delete(&cfg, &app_id).await?;
                     }
                 },
@@ -5680,6 +10585,11 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::rum::metrics_update(&cfg, &metric_id, &file).await?;
                     }
                     RumMetricActions::Delete { metric_id } => {
+                        if !confirm::Destructive::new("delete", "RUM metric", &metric_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::rum::metrics_delete(&cfg, &metric_id).await?;
                     }
                 },
@@ -5702,6 +10612,11 @@ async fn main_inner() -> anyhow::Result<()> {
                             .await?;
                     }
                     RumRetentionFilterActions::Delete { app_id, filter_id } => {
+                        if !confirm::Destructive::new("delete", "RUM retention filter", &filter_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::rum::retention_filters_delete(&cfg, &app_id, &filter_id).await?;
                     }
                 },
@@ -5742,8 +10657,15 @@ async fn main_inner() -> anyhow::Result<()> {
                         from,
                         to,
                         limit,
+                        all,
+                        max_items,
                     } => {
-                        commands::cicd::tests_list(&cfg, query, from, to, limit).await?;
+                        if all {
+                            commands::cicd::tests_list_all_pages(&cfg, query, from, to, max_items)
+                                .await?;
+                        } else {
+                            commands::cicd::tests_list(&cfg, query, from, to, limit).await?;
+                        }
                     }
                     CicdTestActions::Search {
                         query,
@@ -5799,7 +10721,9 @@ async fn main_inner() -> anyhow::Result<()> {
             match action {
                 OnCallActions::Teams { action } => match action {
                     OnCallTeamActions::List => commands::on_call::teams_list(&cfg).await?,
-                    OnCallTeamActions::Get { team_id } => {
+                    OnCallTeamActions::Get { team_id, name } => {
+                        let team_id =
+                            resolve::id_or_name(&cfg, resolve::Kind::Team, team_id, name).await?;
                         commands::on_call::teams_get(&cfg, &team_id).await?;
                     }
                     OnCallTeamActions::Create { name, handle, .. } => {
@@ -5812,14 +10736,26 @@ async fn main_inner() -> anyhow::Result<()> {
                     } => {
                         commands::on_call::teams_update(&cfg, &team_id, &name, &handle).await?;
                     }
-                    OnCallTeamActions::Delete { team_id } => {
+                    OnCallTeamActions::Delete { team_id, name } => {
+                        let team_id =
+                            resolve::id_or_name(&cfg, resolve::Kind::Team, team_id, name).await?;
+                        if !confirm::Destructive::new("delete", "team", &team_id)
+                            .typed()
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::on_call::teams_delete(&cfg, &team_id).await?;
                     }
                     OnCallTeamActions::Memberships { action } => match action {
                         OnCallMembershipActions::List {
-                            team_id, page_size, ..
+                            team_id,
+                            limit,
+                            page,
+                            ..
                         } => {
-                            commands::on_call::memberships_list(&cfg, &team_id, page_size).await?;
+                            commands::on_call::memberships_list(&cfg, &team_id, limit, page)
+                                .await?;
                         }
                         OnCallMembershipActions::Add {
                             team_id,
@@ -5838,33 +10774,145 @@ async fn main_inner() -> anyhow::Result<()> {
                                 .await?;
                         }
                         OnCallMembershipActions::Remove { team_id, user_id } => {
+                            if !confirm::Destructive::new("remove", "team member", &user_id)
+                                .detail(format!("From team {team_id}."))
+                                .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
                             commands::on_call::memberships_remove(&cfg, &team_id, &user_id).await?;
                         }
                     },
                 },
+                OnCallActions::Schedules { action } => {
+                    let r = commands::on_call::SCHEDULES;
+                    match action {
+                        OnCallScheduleActions::List => commands::on_call::list(&cfg, r).await?,
+                        OnCallScheduleActions::Get {
+                            schedule_id,
+                            include,
+                        } => {
+                            commands::on_call::get(&cfg, r, &schedule_id, include.as_deref())
+                                .await?;
+                        }
+                        OnCallScheduleActions::Create { file } => {
+                            commands::on_call::create(&cfg, r, &file).await?;
+                        }
+                        OnCallScheduleActions::Update { schedule_id, file } => {
+                            commands::on_call::update(&cfg, r, &schedule_id, &file).await?;
+                        }
+                    }
+                }
+                OnCallActions::EscalationPolicies { action } => {
+                    let r = commands::on_call::ESCALATION_POLICIES;
+                    match action {
+                        OnCallEscalationPolicyActions::List => {
+                            commands::on_call::list(&cfg, r).await?;
+                        }
+                        OnCallEscalationPolicyActions::Get { policy_id, include } => {
+                            commands::on_call::get(&cfg, r, &policy_id, include.as_deref()).await?;
+                        }
+                        OnCallEscalationPolicyActions::Create { file } => {
+                            commands::on_call::create(&cfg, r, &file).await?;
+                        }
+                        OnCallEscalationPolicyActions::Update { policy_id, file } => {
+                            commands::on_call::update(&cfg, r, &policy_id, &file).await?;
+                        }
+                    }
+                }
+                OnCallActions::Who { team } => commands::on_call::who(&cfg, &team).await?,
+                OnCallActions::Overrides { action } => match action {
+                    OnCallOverrideActions::Create {
+                        schedule_id,
+                        user_id,
+                        start,
+                        end,
+                    } => {
+                        commands::on_call::overrides_create(
+                            &cfg,
+                            &schedule_id,
+                            &user_id,
+                            &start,
+                            &end,
+                        )
+                        .await?;
+                    }
+                },
             }
         }
+        // --- Examples ---
+        Commands::Examples {
+            command,
+            vars,
+            index,
+            run,
+        } => {
+            commands::examples::run(&cfg, &Cli::command(), &command, &vars, index, run)?;
+        }
         // --- Fleet ---
+        Commands::Fanout {
+            orgs,
+            profiles,
+            concurrency,
+            command,
+        } => {
+            let (target, names) = match (orgs, profiles) {
+                (Some(orgs), _) => (commands::fanout::Target::Org, orgs),
+                (None, Some(profiles)) => (commands::fanout::Target::Profile, profiles),
+                (None, None) => unreachable!("clap requires --orgs or --profiles"),
+            };
+            let inherited = commands::fanout::Inherited {
+                profile: cli.profile.clone(),
+                timeout: cli.timeout.clone(),
+                connect_timeout: cli.connect_timeout.clone(),
+            };
+            commands::fanout::run(&cfg, target, &names, concurrency, &inherited, &command).await?;
+        }
+        // --- Find ---
+        Commands::Find {
+            query,
+            types,
+            limit,
+        } => {
+            cfg.validate_auth()?;
+            commands::find::run(&cfg, &query, &types, limit).await?;
+        }
         Commands::Fleet { action } => {
             cfg.validate_auth()?;
             match action {
                 FleetActions::Agents { action } => match action {
-                    FleetAgentActions::List { page_size } => {
-                        commands::fleet::agents_list(&cfg, page_size).await?;
+                    FleetAgentActions::List {
+                        limit,
+                        all,
+                        max_items,
+                    } => {
+                        if all {
+                            commands::fleet::agents_list_all_pages(&cfg, max_items).await?;
+                        } else {
+                            commands::fleet::agents_list(&cfg, limit).await?;
+                        }
                     }
                     FleetAgentActions::Get { agent_key } => {
                         commands::fleet::agents_get(&cfg, &agent_key).await?;
                     }
                     FleetAgentActions::Versions => commands::fleet::agents_versions(&cfg).await?,
+                    FleetAgentActions::Drift { policy, max_items } => {
+                        commands::fleet::agents_drift(&cfg, &policy, max_items).await?;
+                    }
                 },
                 FleetActions::Deployments { action } => match action {
-                    FleetDeploymentActions::List { page_size } => {
-                        commands::fleet::deployments_list(&cfg, page_size).await?;
+                    FleetDeploymentActions::List { limit } => {
+                        commands::fleet::deployments_list(&cfg, limit).await?;
                     }
                     FleetDeploymentActions::Get { deployment_id } => {
                         commands::fleet::deployments_get(&cfg, &deployment_id).await?;
                     }
                     FleetDeploymentActions::Cancel { deployment_id } => {
+                        if !confirm::Destructive::new("cancel", "fleet deployment", &deployment_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::fleet::deployments_cancel(&cfg, &deployment_id).await?;
                     }
                     FleetDeploymentActions::Configure { file } => {
@@ -5886,6 +10934,11 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::fleet::schedules_update(&cfg, &schedule_id, &file).await?;
                     }
                     FleetScheduleActions::Delete { schedule_id } => {
+                        if !confirm::Destructive::new("delete", "fleet schedule", &schedule_id)
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::fleet::schedules_delete(&cfg, &schedule_id).await?;
                     }
                     FleetScheduleActions::Trigger { schedule_id } => {
@@ -5899,10 +10952,63 @@ async fn main_inner() -> anyhow::Result<()> {
             cfg.validate_auth()?;
             match action {
                 DataGovActions::Scanner { action } => match action {
+                    DataGovScannerActions::Groups { action } => match action {
+                        DataGovScannerGroupActions::List => {
+                            commands::data_governance::groups_list(&cfg).await?;
+                        }
+                        DataGovScannerGroupActions::Create { file } => {
+                            commands::data_governance::groups_create(&cfg, &file).await?;
+                        }
+                        DataGovScannerGroupActions::Update { group_id, file } => {
+                            commands::data_governance::groups_update(&cfg, &group_id, &file)
+                                .await?;
+                        }
+                        DataGovScannerGroupActions::Delete { group_id } => {
+                            if !confirm::Destructive::new("delete", "scanning group", &group_id)
+                                .detail("Its rules are deleted with it.")
+                                .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
+                            commands::data_governance::groups_delete(&cfg, &group_id).await?;
+                        }
+                        DataGovScannerGroupActions::Reorder { group_ids } => {
+                            commands::data_governance::groups_reorder(&cfg, &group_ids).await?;
+                        }
+                    },
                     DataGovScannerActions::Rules { action } => match action {
                         DataGovScannerRuleActions::List => {
                             commands::data_governance::scanner_rules_list(&cfg).await?;
                         }
+                        DataGovScannerRuleActions::Create {
+                            group,
+                            standard_pattern,
+                            file,
+                        } => {
+                            commands::data_governance::rules_create(
+                                &cfg,
+                                &group,
+                                standard_pattern.as_deref(),
+                                &file,
+                            )
+                            .await?;
+                        }
+                        DataGovScannerRuleActions::Update { rule_id, file } => {
+                            commands::data_governance::rules_update(&cfg, &rule_id, &file).await?;
+                        }
+                        DataGovScannerRuleActions::Delete { rule_id } => {
+                            if !confirm::Destructive::new("delete", "scanning rule", &rule_id)
+                                .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
+                            commands::data_governance::rules_delete(&cfg, &rule_id).await?;
+                        }
+                    },
+                    DataGovScannerActions::StandardPatterns { action } => match action {
+                        DataGovStandardPatternActions::List => {
+                            commands::data_governance::standard_patterns_list(&cfg).await?;
+                        }
                     },
                 },
             }
@@ -5922,6 +11028,10 @@ async fn main_inner() -> anyhow::Result<()> {
             }
         }
         // --- Code Coverage ---
+        Commands::Codegen { resource, id, lang } => {
+            cfg.validate_auth()?;
+            commands::codegen::run(&cfg, &resource, &id, &lang).await?;
+        }
         Commands::CodeCoverage { action } => {
             cfg.validate_auth()?;
             match action {
@@ -5961,6 +11071,12 @@ async fn main_inner() -> anyhow::Result<()> {
                         commands::status_pages::pages_update(&cfg, &page_id, &file).await?;
                     }
                     StatusPagePageActions::Delete { page_id } => {
+                        if !confirm::Destructive::new("delete", "status page", &page_id)
+                            .typed()
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::status_pages::pages_delete(&cfg, &page_id).await?;
                     }
                 }
@@ -5998,6 +11114,15 @@ async fn main_inner() -> anyhow::Result<()> {
                         page_id,
                         component_id,
                     } => {
+                        if !confirm::Destructive::new(
+                            "delete",
+                            "status page component",
+                            &component_id,
+                        )
+                        .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::status_pages::components_delete(&cfg, &page_id, &component_id)
                             .await?;
                     }
@@ -6036,6 +11161,15 @@ async fn main_inner() -> anyhow::Result<()> {
                         page_id,
                         degradation_id,
                     } => {
+                        if !confirm::Destructive::new(
+                            "delete",
+                            "status page degradation",
+                            &degradation_id,
+                        )
+                        .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
                         commands::status_pages::degradations_delete(
                             &cfg,
                             &page_id,
@@ -6052,6 +11186,66 @@ async fn main_inner() -> anyhow::Result<()> {
                 }
             },
         },
+        // --- IP Allowlist ---
+        Commands::IpAllowlist { action } => {
+            cfg.validate_auth()?;
+            match action {
+                IpAllowlistActions::Get => commands::ip_allowlist::get(&cfg).await?,
+                IpAllowlistActions::Update {
+                    file: Some(file),
+                    dry_run,
+                    ..
+                } => {
+                    commands::ip_allowlist::update_from_file(&cfg, &file, dry_run).await?;
+                }
+                IpAllowlistActions::Update {
+                    file: None,
+                    add_cidr,
+                    remove_cidr,
+                    note,
+                    dry_run,
+                } => {
+                    if add_cidr.is_empty() && remove_cidr.is_empty() {
+                        anyhow::bail!(
+                            "nothing to update: pass --add-cidr, --remove-cidr, or --file"
+                        );
+                    }
+                    let changes = commands::ip_allowlist::AllowlistChanges {
+                        add: add_cidr,
+                        remove: remove_cidr,
+                        note,
+                        enabled: None,
+                    };
+                    commands::ip_allowlist::update(&cfg, &changes, dry_run).await?;
+                }
+                IpAllowlistActions::Enable => {
+                    if !confirm::Destructive::new("enable", "IP allowlist", "")
+                        .detail("Requests from IPs outside the list, including yours, will be rejected.")
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
+                    }
+                    let changes = commands::ip_allowlist::AllowlistChanges {
+                        enabled: Some(true),
+                        ..Default::default()
+                    };
+                    commands::ip_allowlist::update(&cfg, &changes, false).await?;
+                }
+                IpAllowlistActions::Disable => {
+                    if !confirm::Destructive::new("disable", "IP allowlist", "")
+                        .detail("API and application keys will work from any IP.")
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
+                    }
+                    let changes = commands::ip_allowlist::AllowlistChanges {
+                        enabled: Some(false),
+                        ..Default::default()
+                    };
+                    commands::ip_allowlist::update(&cfg, &changes, false).await?;
+                }
+            }
+        }
         // --- Integrations ---
         Commands::Integrations { action } => {
             cfg.validate_auth()?;
@@ -6062,6 +11256,12 @@ async fn main_inner() -> anyhow::Result<()> {
                             commands::integrations::jira_accounts_list(&cfg).await?
                         }
                         JiraAccountActions::Delete { account_id } => {
+                            if !confirm::Destructive::new("delete", "Jira account", &account_id)
+                                .typed()
+                                .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
                             commands::integrations::jira_accounts_delete(&cfg, &account_id).await?;
                         }
                     },
@@ -6084,6 +11284,11 @@ async fn main_inner() -> anyhow::Result<()> {
                             .await?;
                         }
                         JiraTemplateActions::Delete { template_id } => {
+                            if !confirm::Destructive::new("delete", "Jira template", &template_id)
+                                .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
                             commands::integrations::jira_templates_delete(&cfg, &template_id)
                                 .await?;
                         }
@@ -6116,6 +11321,15 @@ async fn main_inner() -> anyhow::Result<()> {
                             .await?;
                         }
                         ServiceNowTemplateActions::Delete { template_id } => {
+                            if !confirm::Destructive::new(
+                                "delete",
+                                "ServiceNow template",
+                                &template_id,
+                            )
+                            .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
                             commands::integrations::servicenow_templates_delete(&cfg, &template_id)
                                 .await?;
                         }
@@ -6152,9 +11366,186 @@ async fn main_inner() -> anyhow::Result<()> {
                     PagerdutyActions::List => {
                         commands::integrations::pagerduty_list(&cfg).await?;
                     }
+                    PagerdutyActions::Get { service_name } => {
+                        commands::integrations::pagerduty_get(&cfg, &service_name).await?;
+                    }
+                    PagerdutyActions::Create {
+                        name,
+                        secret_from_env,
+                        secret_from_file,
+                    } => {
+                        let Some(key) = util::read_secret(
+                            secret_from_env.as_deref(),
+                            secret_from_file.as_deref(),
+                        )?
+                        else {
+                            anyhow::bail!(
+                                "pass the integration key with --secret-from-env or --secret-from-file"
+                            );
+                        };
+                        commands::integrations::pagerduty_create(&cfg, &name, &key).await?;
+                    }
+                    PagerdutyActions::Update {
+                        service_name,
+                        secret_from_env,
+                        secret_from_file,
+                    } => {
+                        let Some(key) = util::read_secret(
+                            secret_from_env.as_deref(),
+                            secret_from_file.as_deref(),
+                        )?
+                        else {
+                            anyhow::bail!(
+                                "pass the new integration key with --secret-from-env or --secret-from-file"
+                            );
+                        };
+                        commands::integrations::pagerduty_update(&cfg, &service_name, &key).await?;
+                    }
+                    PagerdutyActions::Delete { service_name } => {
+                        if !confirm::Destructive::new("delete", "PagerDuty service", &service_name)
+                            .detail(format!(
+                                "Monitors that notify @pagerduty-{service_name} will stop paging."
+                            ))
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::integrations::pagerduty_delete(&cfg, &service_name).await?;
+                    }
+                },
+                IntegrationActions::Opsgenie { action } => match action {
+                    OpsgenieActions::Services { action } => match action {
+                        OpsgenieServiceActions::List => {
+                            commands::integrations::opsgenie_services_list(&cfg).await?;
+                        }
+                        OpsgenieServiceActions::Get { service } => {
+                            commands::integrations::opsgenie_services_get(&cfg, &service).await?;
+                        }
+                        OpsgenieServiceActions::Create {
+                            name,
+                            region,
+                            custom_url,
+                            secret_from_env,
+                            secret_from_file,
+                        } => {
+                            let Some(key) = util::read_secret(
+                                secret_from_env.as_deref(),
+                                secret_from_file.as_deref(),
+                            )?
+                            else {
+                                anyhow::bail!(
+                                    "pass the Opsgenie API key with --secret-from-env or --secret-from-file"
+                                );
+                            };
+                            let service = commands::integrations::OpsgenieService {
+                                name: Some(name),
+                                api_key: Some(key),
+                                region: Some(region),
+                                custom_url,
+                            };
+                            commands::integrations::opsgenie_services_create(&cfg, &service)
+                                .await?;
+                        }
+                        OpsgenieServiceActions::Update {
+                            service,
+                            name,
+                            region,
+                            custom_url,
+                            secret_from_env,
+                            secret_from_file,
+                        } => {
+                            let changes = commands::integrations::OpsgenieService {
+                                name,
+                                api_key: util::read_secret(
+                                    secret_from_env.as_deref(),
+                                    secret_from_file.as_deref(),
+                                )?,
+                                region,
+                                custom_url,
+                            };
+                            commands::integrations::opsgenie_services_update(
+                                &cfg, &service, &changes,
+                            )
+                            .await?;
+                        }
+                        OpsgenieServiceActions::Delete { service } => {
+                            if !confirm::Destructive::new("delete", "Opsgenie service", &service)
+                                .confirm(&cfg)?
+                            {
+                                return Ok(());
+                            }
+                            commands::integrations::opsgenie_services_delete(&cfg, &service)
+                                .await?;
+                        }
+                    },
                 },
                 IntegrationActions::Webhooks { action } => match action {
                     WebhooksActions::List => commands::integrations::webhooks_list(&cfg).await?,
+                    WebhooksActions::Create {
+                        name,
+                        url,
+                        payload,
+                        headers,
+                        encode_as,
+                        secret_from_env,
+                        secret_from_file,
+                    } => {
+                        let secret = util::read_secret(
+                            secret_from_env.as_deref(),
+                            secret_from_file.as_deref(),
+                        )?;
+                        commands::integrations::webhooks_create(
+                            &cfg, &name, &url, payload, &headers, &encode_as, secret,
+                        )
+                        .await?;
+                    }
+                    WebhooksActions::Update {
+                        name,
+                        url,
+                        payload,
+                        headers,
+                        encode_as,
+                        secret_from_env,
+                        secret_from_file,
+                    } => {
+                        let secret = util::read_secret(
+                            secret_from_env.as_deref(),
+                            secret_from_file.as_deref(),
+                        )?;
+                        commands::integrations::webhooks_update(
+                            &cfg,
+                            &name,
+                            url.as_deref(),
+                            payload,
+                            &headers,
+                            encode_as.as_deref(),
+                            secret,
+                        )
+                        .await?;
+                    }
+                    WebhooksActions::Delete { name } => {
+                        if !confirm::Destructive::new("delete", "webhook", &name)
+                            .detail(format!(
+                                "Monitors that notify @webhook-{name} will stop delivering to it."
+                            ))
+                            .confirm(&cfg)?
+                        {
+                            return Ok(());
+                        }
+                        commands::integrations::webhooks_delete(&cfg, &name).await?;
+                    }
+                    WebhooksActions::Test {
+                        name,
+                        payload,
+                        secret_from_env,
+                        secret_from_file,
+                    } => {
+                        let secret = util::read_secret(
+                            secret_from_env.as_deref(),
+                            secret_from_file.as_deref(),
+                        )?;
+                        commands::integrations::webhooks_test(&cfg, &name, payload, secret).await?;
+                    }
                 },
             }
         }
@@ -6173,6 +11564,14 @@ async fn main_inner() -> anyhow::Result<()> {
                 CostActions::Attribution { start, fields, .. } => {
                     commands::cost::attribution(&cfg, start, fields).await?;
                 }
+                CostActions::TagCompliance {
+                    required_tags,
+                    from,
+                    to,
+                    csv,
+                } => {
+                    commands::cost::tag_compliance(&cfg, &required_tags, from, to, csv).await?;
+                }
             }
         }
         // --- Misc ---
@@ -6201,7 +11600,8 @@ async fn main_inner() -> anyhow::Result<()> {
                         to,
                         ..
                     } => {
-                        commands::apm::services_operations(&cfg, service, env, from, to).await?;
+                        commands::apm::services_operations(&cfg, service, Some(env), from, to)
+                            .await?;
                     }
                     ApmServiceActions::Resources {
                         service,
@@ -6211,13 +11611,57 @@ async fn main_inner() -> anyhow::Result<()> {
                         to,
                         ..
                     } => {
-                        commands::apm::services_resources(&cfg, service, operation, env, from, to)
-                            .await?;
+                        commands::apm::services_resources(
+                            &cfg,
+                            service,
+                            operation,
+                            Some(env),
+                            from,
+                            to,
+                        )
+                        .await?;
+                    }
+                    ApmServiceActions::Summary {
+                        service,
+                        env,
+                        from,
+                        to,
+                    } => {
+                        commands::apm::services_summary(&cfg, service, env, from, to).await?;
                     }
                 },
                 ApmActions::Entities { action } => match action {
-                    ApmEntityActions::List { from, to, .. } => {
-                        commands::apm::entities_list(&cfg, from, to).await?;
+                    ApmEntityActions::List {
+                        from,
+                        to,
+                        env,
+                        types,
+                        raw,
+                        ..
+                    } => {
+                        commands::apm::entities_list(&cfg, from, to, env, types, raw).await?;
+                    }
+                },
+                ApmActions::Operations { action } => match action {
+                    ApmOperationActions::List {
+                        service,
+                        env,
+                        from,
+                        to,
+                    } => {
+                        commands::apm::services_operations(&cfg, service, env, from, to).await?;
+                    }
+                },
+                ApmActions::Resources { action } => match action {
+                    ApmResourceActions::List {
+                        service,
+                        operation,
+                        env,
+                        from,
+                        to,
+                    } => {
+                        commands::apm::services_resources(&cfg, service, operation, env, from, to)
+                            .await?;
                     }
                 },
                 ApmActions::Dependencies { action } => match action {
@@ -6226,26 +11670,39 @@ async fn main_inner() -> anyhow::Result<()> {
                     }
                 },
                 ApmActions::FlowMap {
+                    action,
                     query,
                     limit,
                     from,
                     to,
                     ..
-                } => {
-                    commands::apm::flow_map(&cfg, query, limit, from, to).await?;
-                }
+                } => match action {
+                    Some(ApmFlowMapActions::Get {
+                        service,
+                        env,
+                        limit,
+                        from,
+                        to,
+                        raw,
+                    }) => {
+                        commands::apm::flow_map_get(&cfg, service, env, limit, from, to, raw)
+                            .await?;
+                    }
+                    None => {
+                        let Some(query) = query else {
+                            anyhow::bail!("--query is required (or use 'pup apm flow-map get')");
+                        };
+                        commands::apm::flow_map(&cfg, query, limit, from, to).await?;
+                    }
+                },
             }
         }
         // --- Investigations ---
         Commands::Investigations { action } => {
             cfg.validate_auth()?;
             match action {
-                InvestigationActions::List {
-                    page_limit,
-                    page_offset,
-                    ..
-                } => {
-                    commands::investigations::list(&cfg, page_limit, page_offset).await?;
+                InvestigationActions::List { limit, offset, .. } => {
+                    commands::investigations::list(&cfg, limit, offset).await?;
                 }
                 InvestigationActions::Get { investigation_id } => {
                     commands::investigations::get(&cfg, &investigation_id).await?;
@@ -6289,6 +11746,11 @@ async fn main_inner() -> anyhow::Result<()> {
                 commands::scorecards::get(&scorecard_id)?;
             }
         },
+        // --- UI ---
+        Commands::Ui { scope, refresh } => {
+            cfg.validate_auth()?;
+            commands::ui::run(&cfg, &scope, refresh).await?;
+        }
         // --- Traces ---
         Commands::Traces { action } => {
             cfg.validate_auth()?;
@@ -6299,8 +11761,13 @@ async fn main_inner() -> anyhow::Result<()> {
                     to,
                     limit,
                     sort,
+                    tree,
                 } => {
-                    commands::traces::search(&cfg, query, from, to, limit, sort).await?;
+                    if tree {
+                        commands::traces::tree(&cfg, query, from, to).await?;
+                    } else {
+                        commands::traces::search(&cfg, query, from, to, limit, sort).await?;
+                    }
                 }
                 TracesActions::Aggregate {
                     query,
@@ -6311,6 +11778,24 @@ async fn main_inner() -> anyhow::Result<()> {
                 } => {
                     commands::traces::aggregate(&cfg, query, from, to, compute, group_by).await?;
                 }
+                TracesActions::Logs {
+                    trace_id,
+                    lookback,
+                    padding,
+                    limit,
+                    by_service,
+                    query,
+                } => {
+                    let opts = commands::traces::TraceLogsOptions {
+                        trace_id,
+                        lookback,
+                        padding,
+                        limit,
+                        by_service,
+                        query,
+                    };
+                    commands::traces::logs(&cfg, opts).await?;
+                }
             }
         }
         // --- Agent ---
@@ -6333,6 +11818,46 @@ async fn main_inner() -> anyhow::Result<()> {
             AliasActions::Delete { names } => commands::alias::delete(names)?,
             AliasActions::Import { file } => commands::alias::import(&file)?,
         },
+        // --- Config ---
+        Commands::Config { action } => match action {
+            ConfigActions::Lint => {
+                let commands: Vec<String> = Cli::command()
+                    .get_subcommands()
+                    .flat_map(|c| std::iter::once(c.get_name()).chain(c.get_all_aliases()))
+                    .map(String::from)
+                    .collect();
+                commands::config::lint(&cfg, &commands)?;
+            }
+            ConfigActions::Show { sources } => {
+                config::apply_overrides(&mut settings, &overrides);
+                commands::config::show(&cfg, settings, cli.credential_store.as_deref(), sources)?
+            }
+            ConfigActions::Profiles { action } => match action {
+                ConfigProfileActions::List => commands::config::profiles_list(&cfg)?,
+                ConfigProfileActions::Set {
+                    name,
+                    api_key_file,
+                    app_key_file,
+                    default_output,
+                    default_org,
+                } => commands::config::profiles_set(
+                    &name,
+                    config::Profile {
+                        // cfg.site holds --site already normalized.
+                        site: cli.site.as_ref().map(|_| cfg.site.clone()),
+                        api_key: cli.api_key.clone(),
+                        app_key: cli.app_key.clone(),
+                        api_key_file,
+                        app_key_file,
+                        output: default_output,
+                        org: default_org,
+                    },
+                )?,
+                ConfigProfileActions::Delete { name } => {
+                    commands::config::profiles_delete(&name)?;
+                }
+            },
+        },
         // --- Product Analytics ---
         Commands::ProductAnalytics { action } => {
             cfg.validate_auth()?;
@@ -6345,6 +11870,120 @@ async fn main_inner() -> anyhow::Result<()> {
                 },
             }
         }
+        // --- Reference Tables ---
+        Commands::ReferenceTables { action } => {
+            cfg.validate_auth()?;
+            match action {
+                ReferenceTableActions::List { name, limit } => {
+                    commands::reference_tables::list(&cfg, name.as_deref(), limit).await?;
+                }
+                ReferenceTableActions::Get { table } => {
+                    commands::reference_tables::get(&cfg, &table).await?;
+                }
+                ReferenceTableActions::Create {
+                    file: Some(file),
+                    wait,
+                    wait_timeout,
+                    ..
+                } => {
+                    let wait = wait
+                        .then(|| util::parse_duration_secs(&wait_timeout))
+                        .transpose()?;
+                    commands::reference_tables::create_from_file(&cfg, &file, wait).await?;
+                }
+                ReferenceTableActions::Create {
+                    name,
+                    csv,
+                    primary_key,
+                    int_column,
+                    description,
+                    tags,
+                    file: None,
+                    wait,
+                    wait_timeout,
+                } => {
+                    let wait = wait
+                        .then(|| util::parse_duration_secs(&wait_timeout))
+                        .transpose()?;
+                    let changes = commands::reference_tables::TableChanges {
+                        csv,
+                        primary_keys: primary_key,
+                        int_columns: int_column,
+                        description,
+                        tags,
+                    };
+                    let name = name.unwrap_or_default();
+                    commands::reference_tables::create(&cfg, &name, &changes, wait).await?;
+                }
+                ReferenceTableActions::Update {
+                    table,
+                    file: Some(file),
+                    wait,
+                    wait_timeout,
+                    ..
+                } => {
+                    let wait = wait
+                        .then(|| util::parse_duration_secs(&wait_timeout))
+                        .transpose()?;
+                    commands::reference_tables::update_from_file(&cfg, &table, &file, wait).await?;
+                }
+                ReferenceTableActions::Update {
+                    table,
+                    csv,
+                    description,
+                    tags,
+                    file: None,
+                    wait,
+                    wait_timeout,
+                } => {
+                    let wait = wait
+                        .then(|| util::parse_duration_secs(&wait_timeout))
+                        .transpose()?;
+                    let changes = commands::reference_tables::TableChanges {
+                        csv,
+                        description,
+                        tags,
+                        ..Default::default()
+                    };
+                    commands::reference_tables::update(&cfg, &table, &changes, wait).await?;
+                }
+                ReferenceTableActions::Delete { table } => {
+                    if !confirm::Destructive::new("delete", "reference table", &table)
+                        .detail(
+                            "Log pipeline lookups that use this table will stop enriching logs.",
+                        )
+                        .confirm(&cfg)?
+                    {
+                        return Ok(());
+                    }
+                    commands::reference_tables::delete(&cfg, &table).await?;
+                }
+            }
+        }
+        // --- Restriction Policies ---
+        Commands::RestrictionPolicies { action } => {
+            cfg.validate_auth()?;
+            match action {
+                RestrictionPolicyActions::Get { resource_id } => {
+                    commands::restriction_policies::get(&cfg, &resource_id).await?;
+                }
+                RestrictionPolicyActions::Update {
+                    resource_id,
+                    file,
+                    editor,
+                    viewer,
+                } => {
+                    commands::restriction_policies::update(
+                        &cfg,
+                        &resource_id,
+                        file,
+                        editor,
+                        viewer,
+                    )
+                    .await?;
+                }
+            }
+        }
         // --- Static Analysis ---
         Commands::StaticAnalysis { action } => {
             cfg.validate_auth()?;
@@ -6385,19 +12024,34 @@ async fn main_inner() -> anyhow::Result<()> {
         }
         // --- Auth ---
         Commands::Auth { action } => match action {
-            AuthActions::Login => commands::auth::login(&cfg).await?,
+            AuthActions::Login { no_browser } => commands::auth::login(&cfg, no_browser).await?,
             AuthActions::Logout => commands::auth::logout(&cfg).await?,
             AuthActions::Status => commands::auth::status(&cfg)?,
             AuthActions::Token => commands::auth::token(&cfg)?,
             AuthActions::Refresh => commands::auth::refresh(&cfg).await?,
             AuthActions::List => commands::auth::list(&cfg)?,
+            AuthActions::Scopes => commands::auth::scopes(&cfg)?,
+            AuthActions::Migrate { dry_run } => commands::auth::migrate(dry_run)?,
+            AuthActions::Capabilities => commands::auth::capabilities(&cfg)?,
+            AuthActions::Exec { keys, command } => {
+                let code = commands::auth::exec(&mut cfg, keys, &command)?;
+                if code != exit_code::SUCCESS {
+                    return Err(exit_code::ChildExit(code).into());
+                }
+            }
         },
         // --- Utility ---
+        Commands::Capabilities { domain } => {
+            commands::capabilities::run(&cfg, &Cli::command(), domain)?;
+        }
         Commands::Completions { shell } => {
             clap_complete::generate(shell, &mut Cli::command(), "pup", &mut std::io::stdout());
         }
         Commands::Version => println!("{}", version::build_info()),
-        Commands::Test => commands::test::run(&cfg)?,
+        Commands::Test => {
+            cfg.resolve_credential_process()?;
+            commands::test::run(&cfg)?
+        }
     }
 
     Ok(())
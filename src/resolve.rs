@@ -0,0 +1,258 @@
+//! Name-to-ID resolution for `--name` on get/update/delete commands.
+//!
+//! Each resource kind searches its list endpoint for the name and accepts a
+//! single match: exact names win over case-insensitive ones, and several
+//! matches fail with the candidates so the caller can pass an ID instead.
+
+use anyhow::{bail, Result};
+
+use crate::config::Config;
+
+/// Most candidates listed in an ambiguity or "did you mean" error.
+const MAX_LISTED: usize = 10;
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+pub enum Kind {
+    Monitor,
+    Dashboard,
+    Slo,
+    Notebook,
+    Team,
+    User,
+}
+
+impl Kind {
+    fn label(self) -> &'static str {
+        match self {
+            Kind::Monitor => "monitor",
+            Kind::Dashboard => "dashboard",
+            Kind::Slo => "SLO",
+            Kind::Notebook => "notebook",
+            Kind::Team => "team",
+            Kind::User => "user",
+        }
+    }
+}
+
+/// A search hit: its ID and the names it answers to (teams match name or
+/// handle, users email or handle).
+#[derive(Debug, Clone, PartialEq)]
+pub struct Candidate {
+    pub id: String,
+    pub names: Vec<String>,
+}
+
+fn id_text(v: &serde_json::Value) -> Option<String> {
+    match v {
+        serde_json::Value::String(s) => Some(s.clone()),
+        serde_json::Value::Number(n) => Some(n.to_string()),
+        _ => None,
+    }
+}
+
+/// Extract candidates from a kind's search response.
+pub fn candidates(kind: Kind, resp: &serde_json::Value) -> Vec<Candidate> {
+    let (items, names): (&serde_json::Value, &[&str]) = match kind {
+        Kind::Monitor => (resp, &["/name"]),
+        Kind::Dashboard => (&resp["dashboards"], &["/title"]),
+        Kind::Slo => (&resp["data"], &["/name"]),
+        Kind::Notebook => (&resp["data"], &["/attributes/name"]),
+        Kind::Team => (&resp["data"], &["/attributes/name", "/attributes/handle"]),
+        Kind::User => (&resp["data"], &["/attributes/email", "/attributes/handle"]),
+    };
+    let mut out: Vec<Candidate> = Vec::new();
+    for item in items.as_array().into_iter().flatten() {
+        let Some(id) = id_text(&item["id"]) else {
+            continue;
+        };
+        if out.iter().any(|c| c.id == id) {
+            continue;
+        }
+        let names = names
+            .iter()
+            .filter_map(|p| item.pointer(p).and_then(|n| n.as_str()))
+            .map(str::to_string)
+            .collect();
+        out.push(Candidate { id, names });
+    }
+    out
+}
+
+fn listing(cands: &[&Candidate]) -> String {
+    let mut lines: Vec<String> = cands
+        .iter()
+        .take(MAX_LISTED)
+        .map(|c| format!("  {}  {}", c.id, c.names.join(" / ")))
+        .collect();
+    if cands.len() > MAX_LISTED {
+        lines.push(format!("  ... and {} more", cands.len() - MAX_LISTED));
+    }
+    lines.join("\n")
+}
+
+/// Choose the single candidate named `name`, or explain why there isn't one.
+pub fn pick(kind: Kind, name: &str, cands: &[Candidate]) -> Result<String> {
+    let exact: Vec<&Candidate> = cands
+        .iter()
+        .filter(|c| c.names.iter().any(|n| n == name))
+        .collect();
+    let matches = if exact.is_empty() {
+        cands
+            .iter()
+            .filter(|c| c.names.iter().any(|n| n.eq_ignore_ascii_case(name)))
+            .collect()
+    } else {
+        exact
+    };
+    match matches.len() {
+        1 => Ok(matches[0].id.clone()),
+        0 => {
+            let needle = name.to_lowercase();
+            let partial: Vec<&Candidate> = cands
+                .iter()
+                .filter(|c| c.names.iter().any(|n| n.to_lowercase().contains(&needle)))
+                .collect();
+            if partial.is_empty() {
+                bail!("no {} named {name:?}", kind.label());
+            }
+            bail!(
+                "no {} named {name:?}; did you mean:\n{}",
+                kind.label(),
+                listing(&partial)
+            )
+        }
+        n => bail!(
+            "{n} {}s are named {name:?}; pass one of these IDs instead:\n{}",
+            kind.label(),
+            listing(&matches)
+        ),
+    }
+}
+
+async fn search(cfg: &Config, kind: Kind, name: &str) -> Result<serde_json::Value> {
+    let (path, query): (&str, Vec<(&str, String)>) = match kind {
+        Kind::Monitor => ("/api/v1/monitor", vec![("name", name.to_string())]),
+        // The dashboard list has no title filter, so every summary is fetched.
+        Kind::Dashboard => ("/api/v1/dashboard", vec![]),
+        Kind::Slo => ("/api/v1/slo", vec![("query", name.to_string())]),
+        Kind::Notebook => ("/api/v1/notebooks", vec![("query", name.to_string())]),
+        Kind::Team => ("/api/v2/team", vec![("filter[keyword]", name.to_string())]),
+        Kind::User => ("/api/v2/users", vec![("filter", name.to_string())]),
+    };
+    crate::api::get(cfg, path, &query)
+        .await
+        .map_err(|e| crate::api::failed(format!("failed to search {}s", kind.label()), e))
+}
+
+/// Resolve `name` to the ID of the one resource of `kind` that carries it.
+pub async fn resolve(cfg: &Config, kind: Kind, name: &str) -> Result<String> {
+    let resp = search(cfg, kind, name).await?;
+    pick(kind, name, &candidates(kind, &resp))
+}
+
+/// The ID given positionally, or the one `--name` resolves to.
+pub async fn id_or_name(
+    cfg: &Config,
+    kind: Kind,
+    id: Option<String>,
+    name: Option<String>,
+) -> Result<String> {
+    match (id, name) {
+        (Some(id), _) => Ok(id),
+        (None, Some(name)) => resolve(cfg, kind, &name).await,
+        (None, None) => bail!("pass a {} ID or --name", kind.label()),
+    }
+}
+
+/// `id_or_name` for kinds with numeric IDs (monitors, notebooks).
+pub async fn numeric_id_or_name(
+    cfg: &Config,
+    kind: Kind,
+    id: Option<i64>,
+    name: Option<String>,
+) -> Result<i64> {
+    let id = id_or_name(cfg, kind, id.map(|i| i.to_string()), name).await?;
+    id.parse()
+        .map_err(|_| anyhow::anyhow!("{} ID {id:?} is not numeric", kind.label()))
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn cand(id: &str, names: &[&str]) -> Candidate {
+        Candidate {
+            id: id.into(),
+            names: names.iter().map(|n| n.to_string()).collect(),
+        }
+    }
+
+    #[test]
+    fn test_candidates_per_kind() {
+        let monitors = serde_json::json!([
+            {"id": 1, "name": "Checkout latency"},
+            {"id": 1, "name": "Checkout latency"},
+            {"id": 2, "name": "Checkout errors"}
+        ]);
+        assert_eq!(
+            candidates(Kind::Monitor, &monitors),
+            vec![
+                cand("1", &["Checkout latency"]),
+                cand("2", &["Checkout errors"])
+            ]
+        );
+        let dashboards = serde_json::json!({"dashboards": [{"id": "abc-123", "title": "Ops"}]});
+        assert_eq!(
+            candidates(Kind::Dashboard, &dashboards),
+            vec![cand("abc-123", &["Ops"])]
+        );
+        let teams = serde_json::json!({"data": [
+            {"id": "t1", "attributes": {"name": "Payments", "handle": "payments"}}
+        ]});
+        assert_eq!(
+            candidates(Kind::Team, &teams),
+            vec![cand("t1", &["Payments", "payments"])]
+        );
+        let users = serde_json::json!({"data": [
+            {"id": "u1", "attributes": {"email": "ada@example.com", "handle": "ada@example.com"}}
+        ]});
+        assert_eq!(
+            candidates(Kind::User, &users),
+            vec![cand("u1", &["ada@example.com", "ada@example.com"])]
+        );
+        let notebooks = serde_json::json!({"data": [{"id": 7, "attributes": {"name": "RCA"}}]});
+        assert_eq!(
+            candidates(Kind::Notebook, &notebooks),
+            vec![cand("7", &["RCA"])]
+        );
+    }
+
+    #[test]
+    fn test_pick_prefers_exact_then_case_insensitive() {
+        let cands = vec![
+            cand("1", &["Checkout latency"]),
+            cand("2", &["checkout latency"]),
+            cand("3", &["Checkout latency (canary)"]),
+        ];
+        assert_eq!(
+            pick(Kind::Monitor, "Checkout latency", &cands).unwrap(),
+            "1"
+        );
+        let err = pick(Kind::Monitor, "CHECKOUT LATENCY", &cands)
+            .unwrap_err()
+            .to_string();
+        assert!(err.starts_with("2 monitors are named"), "{err}");
+        assert!(err.contains("  1  Checkout latency") && err.contains("  2  checkout latency"));
+        assert!(!err.contains("canary"));
+    }
+
+    #[test]
+    fn test_pick_not_found_suggests_partial_matches() {
+        let cands = vec![cand("t1", &["Payments", "payments"])];
+        assert_eq!(pick(Kind::Team, "payments", &cands).unwrap(), "t1");
+        let err = pick(Kind::Team, "pay", &cands).unwrap_err().to_string();
+        assert!(err.contains("did you mean") && err.contains("t1  Payments / payments"));
+        let err = pick(Kind::Slo, "nothing", &[]).unwrap_err().to_string();
+        assert_eq!(err, "no SLO named \"nothing\"");
+    }
+}
@@ -0,0 +1,345 @@
+//! Structured execution log for shared machines.
+//!
+//! When `DD_PUP_LOG_FILE` is set, every invocation appends one JSON line
+//! (redacted arguments, duration, outcome) to that file, rotating it once it
+//! grows past `DD_PUP_LOG_MAX_BYTES`. Logging is best-effort and silent: a
+//! log that can't be written never changes the command's output or exit status.
+
+use std::io::Write;
+use std::path::{Path, PathBuf};
+use std::sync::Mutex;
+use std::time::Duration;
+
+pub const LOG_FILE_ENV: &str = "DD_PUP_LOG_FILE";
+pub const LOG_MAX_BYTES_ENV: &str = "DD_PUP_LOG_MAX_BYTES";
+pub const DEFAULT_MAX_BYTES: u64 = 5 * 1024 * 1024;
+/// Rotated files kept next to the live log: `<file>.1` (newest) .. `<file>.3`.
+const KEEP_ROTATED: usize = 3;
+
+const REDACTED: &str = "<redacted>";
+
+/// Environment variables whose values never appear in the log, even when
+/// passed as a bare argument.
+const SECRET_ENV_VARS: &[&str] = &["DD_API_KEY", "DD_APP_KEY", "DD_ACCESS_TOKEN"];
+
+/// Key and token values from the resolved config (config file, profile,
+/// key files, credential_process, token storage), registered by `add_secrets`.
+static SECRETS: Mutex<Vec<String>> = Mutex::new(Vec::new());
+
+pub fn path() -> Option<PathBuf> {
+    std::env::var(LOG_FILE_ENV)
+        .ok()
+        .filter(|p| !p.trim().is_empty())
+        .map(PathBuf::from)
+}
+
+fn max_bytes() -> u64 {
+    std::env::var(LOG_MAX_BYTES_ENV)
+        .ok()
+        .and_then(|v| v.parse().ok())
+        .unwrap_or(DEFAULT_MAX_BYTES)
+}
+
+/// Flags whose value is a credential: `--api-key`, `--token`, `--client-secret`, ...
+/// `*-file` flags name a path, not a secret, and are kept.
+fn is_secret_flag(flag: &str) -> bool {
+    let name = flag.trim_start_matches('-').to_lowercase();
+    if name.ends_with("-file") {
+        return false;
+    }
+    ["key", "token", "secret", "password"]
+        .iter()
+        .any(|s| name.split(['-', '_']).any(|part| part == *s))
+}
+
+/// Redact the credentials `cfg` resolved to from every entry logged after
+/// this call, wherever they came from. Never fails, so registering secrets
+/// can't change a command's outcome.
+pub fn add_secrets(cfg: &crate::config::Config) {
+    let Ok(mut known) = SECRETS.lock() else {
+        return;
+    };
+    for secret in [&cfg.api_key, &cfg.app_key, &cfg.access_token]
+        .into_iter()
+        .flatten()
+    {
+        if !secret.is_empty() && !known.contains(secret) {
+            known.push(secret.clone());
+        }
+    }
+}
+
+fn secrets() -> Vec<String> {
+    let mut secrets: Vec<String> = SECRET_ENV_VARS
+        .iter()
+        .filter_map(|v| std::env::var(v).ok())
+        .filter(|s| !s.is_empty())
+        .collect();
+    if let Ok(known) = SECRETS.lock() {
+        secrets.extend(known.iter().cloned());
+    }
+    secrets
+}
+
+/// Arguments (without the binary) with credential values replaced.
+pub fn redact_args(args: &[String], secrets: &[String]) -> Vec<String> {
+    let mut out = Vec::with_capacity(args.len());
+    let mut redact_next = false;
+    for arg in args {
+        if redact_next && !arg.starts_with('-') {
+            out.push(REDACTED.to_string());
+            redact_next = false;
+            continue;
+        }
+        redact_next = false;
+        if arg.starts_with("--") {
+            if let Some((flag, _)) = arg.split_once('=') {
+                if is_secret_flag(flag) {
+                    out.push(format!("{flag}={REDACTED}"));
+                    continue;
+                }
+            } else if is_secret_flag(arg) {
+                redact_next = true;
+            }
+        }
+        if secrets
+            .iter()
+            .any(|s| !s.is_empty() && arg.contains(s.as_str()))
+        {
+            out.push(REDACTED.to_string());
+        } else {
+            out.push(arg.clone());
+        }
+    }
+    out
+}
+
+fn rotated(path: &Path, n: usize) -> PathBuf {
+    let mut name = path.as_os_str().to_owned();
+    name.push(format!(".{n}"));
+    PathBuf::from(name)
+}
+
+/// Shift `<file>` to `<file>.1`, `<file>.1` to `<file>.2`, ..., dropping the oldest.
+fn rotate(path: &Path) -> std::io::Result<()> {
+    let _ = std::fs::remove_file(rotated(path, KEEP_ROTATED));
+    for n in (1..KEEP_ROTATED).rev() {
+        let from = rotated(path, n);
+        if from.exists() {
+            std::fs::rename(&from, rotated(path, n + 1))?;
+        }
+    }
+    std::fs::rename(path, rotated(path, 1))
+}
+
+/// Append one line, rotating first if it would push the file past `max_bytes`.
+fn append(path: &Path, line: &str, max_bytes: u64) -> std::io::Result<()> {
+    if let Ok(meta) = std::fs::metadata(path) {
+        if meta.len() > 0 && meta.len() + line.len() as u64 + 1 > max_bytes {
+            rotate(path)?;
+        }
+    }
+    if let Some(parent) = path.parent().filter(|p| !p.as_os_str().is_empty()) {
+        std::fs::create_dir_all(parent)?;
+    }
+    let mut file = std::fs::OpenOptions::new()
+        .create(true)
+        .append(true)
+        .open(path)?;
+    // One write per entry so concurrent invocations don't interleave lines.
+    file.write_all(format!("{line}\n").as_bytes())
+}
+
+pub fn entry(
+    command: &str,
+    args: &[String],
+    elapsed: Duration,
+    error: Option<&anyhow::Error>,
+) -> serde_json::Value {
+    let secrets = secrets();
+    let mut entry = serde_json::json!({
+        "timestamp": chrono::Utc::now().to_rfc3339_opts(chrono::SecondsFormat::Millis, true),
+        "version": crate::version::VERSION,
+        "pid": std::process::id(),
+        "command": command,
+        "args": redact_args(args, &secrets),
+        "duration_ms": elapsed.as_millis() as u64,
+        "status": if error.is_some() { "error" } else { "ok" },
+        "exit_code": error.map_or(crate::exit_code::SUCCESS, crate::exit_code::classify),
+    });
+    if let Some(profile) = crate::config::active_profile() {
+        entry["profile"] = profile.into();
+    }
+    if crate::useragent::is_agent_mode() {
+        entry["agent_mode"] = true.into();
+    }
+    if let Some(e) = error {
+        let message = secrets
+            .iter()
+            .fold(format!("{e:#}"), |m, s| m.replace(s.as_str(), REDACTED));
+        entry["error"] = message.into();
+    }
+    entry
+}
+
+/// Record one invocation when `DD_PUP_LOG_FILE` is set. Never fails.
+pub fn record(command: &str, args: &[String], elapsed: Duration, error: Option<&anyhow::Error>) {
+    let Some(path) = path() else {
+        return;
+    };
+    let line = entry(command, args, elapsed, error).to_string();
+    let _ = append(&path, &line, max_bytes());
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn args(list: &[&str]) -> Vec<String> {
+        list.iter().map(|s| s.to_string()).collect()
+    }
+
+    #[test]
+    fn test_redact_args() {
+        let out = redact_args(
+            &args(&[
+                "config",
+                "profiles",
+                "set",
+                "prod",
+                "--api-key",
+                "abc123",
+                "--app-key=def456",
+                "--api-key-file",
+                "/run/secrets/api",
+                "--site",
+                "datadoghq.eu",
+            ]),
+            &[],
+        );
+        assert_eq!(
+            out,
+            args(&[
+                "config",
+                "profiles",
+                "set",
+                "prod",
+                "--api-key",
+                "<redacted>",
+                "--app-key=<redacted>",
+                "--api-key-file",
+                "/run/secrets/api",
+                "--site",
+                "datadoghq.eu",
+            ])
+        );
+    }
+
+    #[test]
+    fn test_redact_args_known_secret_values() {
+        let out = redact_args(
+            &args(&[
+                "api",
+                "get",
+                "/api/v1/validate?api_key=s3cr3t",
+                "--sort-by",
+                "name",
+            ]),
+            &["s3cr3t".to_string(), String::new()],
+        );
+        assert_eq!(
+            out,
+            args(&["api", "get", "<redacted>", "--sort-by", "name"])
+        );
+    }
+
+    #[test]
+    fn test_is_secret_flag() {
+        assert!(is_secret_flag("--token"));
+        assert!(is_secret_flag("--client-secret"));
+        assert!(!is_secret_flag("--app-key-file"));
+        assert!(!is_secret_flag("--keyword"));
+        assert!(!is_secret_flag("--query"));
+    }
+
+    #[test]
+    fn test_append_rotates() {
+        let dir = std::env::temp_dir().join(format!("pup_test_runlog_{}", std::process::id()));
+        let _ = std::fs::remove_dir_all(&dir);
+        let log = dir.join("pup.log");
+        for i in 0..6 {
+            append(&log, &format!("{{\"n\":{i}}}"), 12).unwrap();
+        }
+        assert_eq!(std::fs::read_to_string(&log).unwrap(), "{\"n\":5}\n");
+        assert_eq!(
+            std::fs::read_to_string(rotated(&log, 1)).unwrap(),
+            "{\"n\":4}\n"
+        );
+        assert_eq!(
+            std::fs::read_to_string(rotated(&log, 3)).unwrap(),
+            "{\"n\":2}\n"
+        );
+        assert!(!rotated(&log, 4).exists());
+        let _ = std::fs::remove_dir_all(&dir);
+    }
+
+    #[test]
+    fn test_entry_records_exit_code() {
+        let err = crate::api::failed(
+            "failed to list monitors",
+            crate::api::ApiError {
+                status: 429,
+                reason: "Too Many Requests".into(),
+                body: "{}".into(),
+                reset: None,
+            },
+        );
+        let e = entry("monitors list", &[], Duration::from_millis(5), Some(&err));
+        assert_eq!(e["status"], "error");
+        assert_eq!(e["exit_code"], crate::exit_code::RATE_LIMITED);
+        let usage = clap::Command::new("pup")
+            .try_get_matches_from(["pup", "--no-such-flag"])
+            .unwrap_err();
+        let e = entry("", &[], Duration::from_millis(1), Some(&usage.into()));
+        assert_eq!(e["exit_code"], crate::exit_code::VALIDATION);
+        assert!(e["error"].as_str().unwrap().contains("--no-such-flag"));
+        let ok = entry("monitors list", &[], Duration::from_millis(5), None);
+        assert_eq!(ok["exit_code"], 0);
+    }
+
+    #[test]
+    fn test_entry_redacts_config_secrets() {
+        let cfg = crate::config::Config {
+            api_key: Some("profile-api-key".into()),
+            app_key: Some("process-app-key".into()),
+            access_token: Some("stored-token".into()),
+            ..crate::test_utils::config()
+        };
+        add_secrets(&cfg);
+        let err = anyhow::anyhow!("request with key stored-token was rejected");
+        let e = entry(
+            "api get",
+            &args(&["api", "get", "/api/v1/validate?api_key=profile-api-key"]),
+            Duration::from_millis(1),
+            Some(&err),
+        );
+        assert_eq!(e["args"], serde_json::json!(["api", "get", "<redacted>"]));
+        assert_eq!(e["error"], "request with key <redacted> was rejected");
+        let e = entry("", &args(&["process-app-key"]), Duration::ZERO, None);
+        assert_eq!(e["args"], serde_json::json!(["<redacted>"]));
+    }
+
+    #[cfg(unix)]
+    #[test]
+    fn test_credential_process_keys_are_redacted_once_resolved() {
+        let mut cfg = crate::config::Config {
+            credential_process: Some(
+                r#"printf '{"api_key":"cp-api-key","app_key":"cp-app-key"}'"#.into(),
+            ),
+            ..crate::test_utils::config()
+        };
+        cfg.resolve_credential_process().unwrap();
+        let e = entry("", &args(&["cp-app-key"]), Duration::ZERO, None);
+        assert_eq!(e["args"], serde_json::json!(["<redacted>"]));
+    }
+}
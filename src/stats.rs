@@ -0,0 +1,199 @@
+//! Per-invocation API call accounting for the `--stats` footer.
+//!
+//! Counters are process-global: every HTTP call made through the native client
+//! helpers is recorded once stats are enabled, and `print_footer` reports the
+//! totals on stderr when the command finishes.
+
+use std::sync::atomic::{AtomicBool, AtomicU64, Ordering};
+use std::sync::Mutex;
+use std::time::Duration;
+
+static ENABLED: AtomicBool = AtomicBool::new(false);
+static CALLS: AtomicU64 = AtomicU64::new(0);
+static BYTES_SENT: AtomicU64 = AtomicU64::new(0);
+static BYTES_RECEIVED: AtomicU64 = AtomicU64::new(0);
+static RATE_LIMIT: Mutex<Option<RateLimit>> = Mutex::new(None);
+
+/// Rate-limit state from the most recent response's `X-RateLimit-*` headers.
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct RateLimit {
+    pub name: Option<String>,
+    pub limit: Option<String>,
+    pub remaining: String,
+    /// Seconds until the window resets.
+    pub reset: Option<String>,
+}
+
+impl RateLimit {
+    /// Returns None when the response carries no `X-RateLimit-Remaining` header.
+    pub fn from_headers(headers: &reqwest::header::HeaderMap) -> Option<RateLimit> {
+        let get = |name: &str| {
+            headers
+                .get(name)
+                .and_then(|v| v.to_str().ok())
+                .map(String::from)
+        };
+        Some(RateLimit {
+            name: get("x-ratelimit-name"),
+            limit: get("x-ratelimit-limit"),
+            remaining: get("x-ratelimit-remaining")?,
+            reset: get("x-ratelimit-reset"),
+        })
+    }
+}
+
+/// Totals collected so far.
+#[derive(Debug, Clone, Default)]
+pub struct Snapshot {
+    pub calls: u64,
+    pub bytes_sent: u64,
+    pub bytes_received: u64,
+    pub rate_limit: Option<RateLimit>,
+}
+
+pub fn enable() {
+    ENABLED.store(true, Ordering::Relaxed);
+}
+
+pub fn is_enabled() -> bool {
+    ENABLED.load(Ordering::Relaxed)
+}
+
+/// Record one completed API call. `received` is the response's Content-Length,
+/// when the server sent one.
+pub fn record(sent: u64, received: Option<u64>, headers: &reqwest::header::HeaderMap) {
+    if !is_enabled() {
+        return;
+    }
+    CALLS.fetch_add(1, Ordering::Relaxed);
+    BYTES_SENT.fetch_add(sent, Ordering::Relaxed);
+    BYTES_RECEIVED.fetch_add(received.unwrap_or(0), Ordering::Relaxed);
+    if let Some(rl) = RateLimit::from_headers(headers) {
+        if let Ok(mut last) = RATE_LIMIT.lock() {
+            *last = Some(rl);
+        }
+    }
+}
+
+/// Record a response from the raw reqwest helpers.
+pub fn record_response(sent: u64, resp: &reqwest::Response) {
+    record(sent, resp.content_length(), resp.headers());
+}
+
+pub fn snapshot() -> Snapshot {
+    Snapshot {
+        calls: CALLS.load(Ordering::Relaxed),
+        bytes_sent: BYTES_SENT.load(Ordering::Relaxed),
+        bytes_received: BYTES_RECEIVED.load(Ordering::Relaxed),
+        rate_limit: RATE_LIMIT.lock().ok().and_then(|rl| rl.clone()),
+    }
+}
+
+fn format_bytes(n: u64) -> String {
+    const UNITS: [&str; 4] = ["B", "KB", "MB", "GB"];
+    let mut value = n as f64;
+    let mut unit = 0;
+    while value >= 1024.0 && unit < UNITS.len() - 1 {
+        value /= 1024.0;
+        unit += 1;
+    }
+    if unit == 0 {
+        format!("{n} B")
+    } else {
+        format!("{value:.1} {}", UNITS[unit])
+    }
+}
+
+/// Render the one-line footer, e.g.
+/// `stats: 3 API calls, 1.2 KB sent, 45.6 KB received, 0.84s total, rate limit 97/100 remaining (resets in 12s)`.
+pub fn format_footer(s: &Snapshot, elapsed: Duration) -> String {
+    let calls = if s.calls == 1 { "call" } else { "calls" };
+    let mut out = format!(
+        "stats: {} API {calls}, {} sent, {} received, {:.2}s total",
+        s.calls,
+        format_bytes(s.bytes_sent),
+        format_bytes(s.bytes_received),
+        elapsed.as_secs_f64()
+    );
+    match &s.rate_limit {
+        Some(rl) => {
+            let name = rl
+                .name
+                .as_deref()
+                .map(|n| format!(" [{n}]"))
+                .unwrap_or_default();
+            let limit = rl
+                .limit
+                .as_deref()
+                .map(|l| format!("/{l}"))
+                .unwrap_or_default();
+            out.push_str(&format!(
+                ", rate limit{name} {}{limit} remaining",
+                rl.remaining
+            ));
+            if let Some(reset) = &rl.reset {
+                out.push_str(&format!(" (resets in {reset}s)"));
+            }
+        }
+        None if s.calls > 0 => out.push_str(", rate limit unknown"),
+        None => {}
+    }
+    out
+}
+
+/// Print the footer to stderr if `--stats` was given.
+pub fn print_footer(elapsed: Duration) {
+    if is_enabled() {
+        eprintln!("{}", format_footer(&snapshot(), elapsed));
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_format_bytes() {
+        assert_eq!(format_bytes(0), "0 B");
+        assert_eq!(format_bytes(1023), "1023 B");
+        assert_eq!(format_bytes(1536), "1.5 KB");
+        assert_eq!(format_bytes(5 * 1024 * 1024), "5.0 MB");
+    }
+
+    #[test]
+    fn test_format_footer() {
+        let s = Snapshot {
+            calls: 3,
+            bytes_sent: 1229,
+            bytes_received: 46694,
+            rate_limit: Some(RateLimit {
+                name: Some("monitors".into()),
+                limit: Some("100".into()),
+                remaining: "97".into(),
+                reset: Some("12".into()),
+            }),
+        };
+        assert_eq!(
+            format_footer(&s, Duration::from_millis(840)),
+            "stats: 3 API calls, 1.2 KB sent, 45.6 KB received, 0.84s total, \
+             rate limit [monitors] 97/100 remaining (resets in 12s)"
+        );
+        let empty = Snapshot::default();
+        assert_eq!(
+            format_footer(&empty, Duration::from_secs(1)),
+            "stats: 0 API calls, 0 B sent, 0 B received, 1.00s total"
+        );
+    }
+
+    #[test]
+    fn test_rate_limit_from_headers() {
+        let mut headers = reqwest::header::HeaderMap::new();
+        assert!(RateLimit::from_headers(&headers).is_none());
+        headers.insert("x-ratelimit-remaining", "42".parse().unwrap());
+        headers.insert("x-ratelimit-limit", "60".parse().unwrap());
+        let rl = RateLimit::from_headers(&headers).unwrap();
+        assert_eq!(rl.remaining, "42");
+        assert_eq!(rl.limit.as_deref(), Some("60"));
+        assert!(rl.name.is_none());
+    }
+}
@@ -26,12 +26,7 @@ fn test_config(mock_url: &str) -> Config {
     Config {
         api_key: Some("test-api-key".into()),
         app_key: Some("test-app-key".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
+        ..crate::test_utils::config()
     }
 }
 
@@ -70,7 +65,7 @@ async fn test_monitors_list_empty() {
     let cfg = test_config(&server.url());
     let _mock = mock_any(&mut server, "GET", "[]").await;
 
-    let result = crate::commands::monitors::list(&cfg, None, None, 10).await;
+    let result = crate::commands::monitors::list(&cfg, None, None, 10, false).await;
     assert!(result.is_ok(), "monitors list failed: {:?}", result.err());
     cleanup_env();
 }
@@ -84,7 +79,7 @@ async fn test_monitors_list_with_results() {
     let body = r#"[{"id": 1, "name": "Test Monitor", "type": "metric alert", "query": "avg(last_5m):avg:system.cpu.user{*} > 90", "message": "CPU high", "tags": [], "options": {}}]"#;
     let _mock = mock_any(&mut server, "GET", body).await;
 
-    let result = crate::commands::monitors::list(&cfg, Some("Test".into()), None, 10).await;
+    let result = crate::commands::monitors::list(&cfg, Some("Test".into()), None, 10, false).await;
     assert!(
         result.is_ok(),
         "monitors list with results failed: {:?}",
@@ -93,6 +88,25 @@ async fn test_monitors_list_with_results() {
     cleanup_env();
 }
 
+#[tokio::test]
+async fn test_monitors_list_csv() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let mut cfg = test_config(&server.url());
+    cfg.output_format = OutputFormat::Csv;
+
+    let body = r#"[{"id": 1, "name": "CPU", "type": "metric alert", "tags": ["env:prod"], "options": {"thresholds": {"critical": 90}}}]"#;
+    let _mock = mock_any(&mut server, "GET", body).await;
+
+    let result = crate::commands::monitors::list(&cfg, None, None, 10, false).await;
+    assert!(
+        result.is_ok(),
+        "monitors list -o csv failed: {:?}",
+        result.err()
+    );
+    cleanup_env();
+}
+
 #[tokio::test]
 async fn test_monitors_get() {
     let _lock = lock_env();
@@ -102,1070 +116,2394 @@ async fn test_monitors_get() {
     let body = r#"{"id": 12345, "name": "Test Monitor", "type": "metric alert", "query": "avg(last_5m):avg:system.cpu.user{*} > 90", "message": "CPU high", "tags": [], "options": {}}"#;
     let _mock = mock_any(&mut server, "GET", body).await;
 
-    let result = crate::commands::monitors::get(&cfg, 12345).await;
+    let result = crate::commands::monitors::get(&cfg, 12345, false).await;
     assert!(result.is_ok(), "monitors get failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_monitors_search() {
+async fn test_resolve_monitor_by_name() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
+    let _search = server
+        .mock("GET", "/api/v1/monitor")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "name".into(),
+            "Checkout latency".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"[{"id": 1, "name": "Checkout latency"}, {"id": 2, "name": "Checkout latency (canary)"}]"#,
+        )
+        .create_async()
+        .await;
+    let id = crate::resolve::numeric_id_or_name(
+        &cfg,
+        crate::resolve::Kind::Monitor,
+        None,
+        Some("Checkout latency".into()),
+    )
+    .await;
+    assert_eq!(id.unwrap(), 1);
+    cleanup_env();
+}
 
-    let body = r#"{"monitors": [], "metadata": {"page": 0, "page_count": 0, "per_page": 30, "total_count": 0}}"#;
-    let _mock = mock_any(&mut server, "GET", body).await;
-
-    let result = crate::commands::monitors::search(&cfg, Some("cpu".into())).await;
-    assert!(result.is_ok(), "monitors search failed: {:?}", result.err());
+#[tokio::test]
+async fn test_resolve_dashboard_by_name_ambiguous() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _list = server
+        .mock("GET", "/api/v1/dashboard")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"dashboards": [{"id": "abc-1", "title": "Ops"}, {"id": "abc-2", "title": "Ops"}]}"#,
+        )
+        .create_async()
+        .await;
+    let err = crate::resolve::resolve(&cfg, crate::resolve::Kind::Dashboard, "Ops")
+        .await
+        .unwrap_err()
+        .to_string();
+    assert!(err.contains("2 dashboards are named"), "{err}");
+    assert!(err.contains("abc-1") && err.contains("abc-2"));
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_monitors_delete() {
+async fn test_monitors_get_include_downtimes() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "DELETE", r#"{"deleted_monitor_id": 12345}"#).await;
+    let mock = server
+        .mock("GET", "/api/v1/monitor/12345")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "with_downtimes".into(),
+            "true".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": 12345, "name": "Test Monitor", "type": "metric alert",
+                "query": "avg(last_5m):avg:system.cpu.user{*} > 90",
+                "matching_downtimes": [{"id": 7, "active": true, "scope": ["env:prod"]}]}"#,
+        )
+        .create_async()
+        .await;
 
-    let result = crate::commands::monitors::delete(&cfg, 12345).await;
-    assert!(result.is_ok(), "monitors delete failed: {:?}", result.err());
+    let result = crate::commands::monitors::get(&cfg, 12345, true).await;
+    assert!(
+        result.is_ok(),
+        "monitors get --include-downtimes failed: {:?}",
+        result.err()
+    );
+    mock.assert_async().await;
     cleanup_env();
 }
 
-// -------------------------------------------------------------------------
-// Dashboards
-// -------------------------------------------------------------------------
+#[tokio::test]
+async fn test_monitors_tune() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _monitor = server
+        .mock("GET", "/api/v1/monitor/12345")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": 12345, "name": "CPU high", "type": "metric alert",
+                "query": "avg(last_5m):avg:system.cpu.user{*} > 90",
+                "options": {"thresholds": {"critical": 90}}}"#,
+        )
+        .create_async()
+        .await;
+    let query = server
+        .mock("GET", "/api/v1/query")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "query".into(),
+            "avg:system.cpu.user{*}.rollup(avg, 300)".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"series": [{"pointlist": [[1000, 40.0], [2000, 55.5], [3000, 61.0], [4000, 93.0]]}]}"#,
+        )
+        .create_async()
+        .await;
+
+    let result = crate::commands::monitors::tune(&cfg, 12345, "14d", true).await;
+    assert!(result.is_ok(), "monitors tune failed: {:?}", result.err());
+    query.assert_async().await;
+    cleanup_env();
+}
 
 #[tokio::test]
-async fn test_dashboards_list() {
+async fn test_monitors_composite_tree() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "GET", r#"{"dashboards": []}"#).await;
 
-    let result = crate::commands::dashboards::list(&cfg).await;
-    assert!(result.is_ok(), "dashboards list failed: {:?}", result.err());
+    let monitors = [
+        (
+            "/api/v1/monitor/1",
+            r#"{"id": 1, "name": "Checkout", "type": "composite", "query": "2 && 3", "overall_state": "Alert"}"#,
+        ),
+        (
+            "/api/v1/monitor/2",
+            r#"{"id": 2, "name": "Latency", "type": "metric alert", "query": "avg:x{*} > 1", "overall_state": "Alert"}"#,
+        ),
+        (
+            "/api/v1/monitor/3",
+            r#"{"id": 3, "name": "Errors", "type": "metric alert", "query": "avg:y{*} > 1", "overall_state": "OK"}"#,
+        ),
+    ];
+    let mut mocks = Vec::new();
+    for (path, body) in monitors {
+        mocks.push(
+            server
+                .mock("GET", path)
+                .with_status(200)
+                .with_header("content-type", "application/json")
+                .with_body(body)
+                .expect(1)
+                .create_async()
+                .await,
+        );
+    }
+
+    let result = crate::commands::monitors::composite_tree(&cfg, 1).await;
+    assert!(
+        result.is_ok(),
+        "monitors composite-tree failed: {:?}",
+        result.err()
+    );
+    for m in mocks {
+        m.assert_async().await;
+    }
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_dashboards_get() {
+async fn test_monitors_create_retry_finds_existing() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(
-        &mut server,
-        "GET",
-        r#"{"id": "abc-123", "title": "Test Dashboard", "layout_type": "ordered", "widgets": []}"#,
+    let file = std::env::temp_dir().join("pup_test_monitor_create_retry.json");
+    std::fs::write(
+        &file,
+        r#"{"name": "CPU high", "type": "metric alert", "query": "avg(last_5m):avg:cpu{*} > 90"}"#,
     )
-    .await;
+    .unwrap();
+    // The first POST fails after the server may have committed it; the retry
+    // must find the monitor instead of creating a duplicate.
+    let create = server
+        .mock("POST", "/api/v1/monitor")
+        .with_status(503)
+        .with_body("upstream timeout")
+        .expect(1)
+        .create_async()
+        .await;
+    let body = format!(
+        r#"[{{"id": 9, "name": "CPU high", "type": "metric alert",
+            "query": "avg(last_5m):avg:cpu{{*}} > 90", "created": "{}"}}]"#,
+        chrono::Utc::now().to_rfc3339()
+    );
+    let _list = server
+        .mock("GET", "/api/v1/monitor")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(body)
+        .create_async()
+        .await;
 
-    let result = crate::commands::dashboards::get(&cfg, "abc-123").await;
-    assert!(result.is_ok(), "dashboards get failed: {:?}", result.err());
+    let result = crate::commands::monitors::create(&cfg, file.to_str().unwrap()).await;
+    let _ = std::fs::remove_file(&file);
+    assert!(result.is_ok(), "monitors create failed: {:?}", result.err());
+    create.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_dashboards_delete() {
+async fn test_monitors_export_import() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(
-        &mut server,
-        "DELETE",
-        r#"{"deleted_dashboard_id": "abc-123"}"#,
+    let dir = std::env::temp_dir().join("pup_test_monitors_export");
+    let _ = std::fs::remove_dir_all(&dir);
+    let _list = server
+        .mock("GET", "/api/v1/monitor")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"[{"id": 1, "name": "CPU high", "type": "metric alert",
+                "query": "avg(last_5m):avg:cpu{*} > 90", "overall_state": "OK"}]"#,
+        )
+        .create_async()
+        .await;
+    let update = server
+        .mock("PUT", "/api/v1/monitor/1")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"id": 1}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let create = server
+        .mock("POST", "/api/v1/monitor")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"id": 5, "name": "Disk full", "type": "metric alert", "query": "q"}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    // Written before the monitor was renamed.
+    std::fs::create_dir_all(&dir).unwrap();
+    std::fs::write(
+        dir.join("cpu.json"),
+        r#"{"id": 1, "name": "CPU", "query": "q"}"#,
     )
-    .await;
+    .unwrap();
+
+    let dir_str = dir.to_str().unwrap();
+    let result = crate::commands::monitors::export(&cfg, dir_str, Some("env:prod")).await;
+    assert!(result.is_ok(), "monitors export failed: {:?}", result.err());
+    assert!(!dir.join("cpu.json").exists(), "stale file kept");
+    let path = dir.join("cpu-high.json");
+    let exported = std::fs::read_to_string(&path).unwrap();
+    assert!(!exported.contains("overall_state"));
+    std::fs::write(&path, exported.replace("> 90", "> 95")).unwrap();
+    let new_file = dir.join("disk-full.json");
+    std::fs::write(
+        &new_file,
+        r#"{"name": "Disk full", "type": "metric alert", "query": "q"}"#,
+    )
+    .unwrap();
+    // Deleted upstream since the export: must not come back.
+    std::fs::write(
+        dir.join("gone.json"),
+        r#"{"id": 9, "name": "Gone", "type": "metric alert", "query": "q"}"#,
+    )
+    .unwrap();
 
-    let result = crate::commands::dashboards::delete(&cfg, "abc-123").await;
+    let result = crate::commands::monitors::import(&cfg, dir_str, true).await;
     assert!(
         result.is_ok(),
-        "dashboards delete failed: {:?}",
+        "monitors import --dry-run failed: {:?}",
         result.err()
     );
+    let result = crate::commands::monitors::import(&cfg, dir_str, false).await;
+    assert!(result.is_ok(), "monitors import failed: {:?}", result.err());
+    let written = std::fs::read_to_string(&new_file).unwrap();
+    assert!(written.contains("\"id\": 5"));
+    update.assert_async().await;
+    create.assert_async().await;
+
+    std::fs::copy(&path, dir.join("cpu-copy.json")).unwrap();
+    let result = crate::commands::monitors::import(&cfg, dir_str, false).await;
+    let _ = std::fs::remove_dir_all(&dir);
+    let err = result.unwrap_err().to_string();
+    assert!(err.contains("both have id 1"), "{err}");
     cleanup_env();
 }
 
-// -------------------------------------------------------------------------
-// SLOs
-// -------------------------------------------------------------------------
+#[tokio::test]
+async fn test_monitors_bulk_delete_resume() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let mut cfg = test_config(&server.url());
+    cfg.auto_approve = true;
+    let checkpoint = std::env::temp_dir().join("pup_test_bulk_delete.json");
+    let _ = std::fs::remove_file(&checkpoint);
+    let checkpoint = checkpoint.to_str().unwrap().to_string();
+    let _search = server
+        .mock("GET", "/api/v1/monitor/search")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"monitors": [{"id": 1}, {"id": 2}], "metadata": {"page": 0, "page_count": 1}}"#,
+        )
+        .create_async()
+        .await;
+    let first = server
+        .mock("DELETE", "/api/v1/monitor/1")
+        .with_status(200)
+        .with_body(r#"{"deleted_monitor_id": 1}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let expired = server
+        .mock("DELETE", "/api/v1/monitor/2")
+        .with_status(403)
+        .with_body(r#"{"errors": ["Forbidden"]}"#)
+        .create_async()
+        .await;
+
+    let err = crate::commands::monitors::bulk_delete(&cfg, Some("tag:legacy"), &checkpoint, None)
+        .await
+        .unwrap_err()
+        .to_string();
+    assert!(err.contains("--resume"), "{err}");
+    expired.remove_async().await;
+
+    let second = server
+        .mock("DELETE", "/api/v1/monitor/2")
+        .with_status(200)
+        .with_body(r#"{"deleted_monitor_id": 2}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let result =
+        crate::commands::monitors::bulk_delete(&cfg, None, "unused.json", Some(&checkpoint)).await;
+    assert!(
+        result.is_ok(),
+        "bulk-delete --resume failed: {:?}",
+        result.err()
+    );
+    first.assert_async().await;
+    second.assert_async().await;
+    assert!(!std::path::Path::new(&checkpoint).exists());
+    cleanup_env();
+}
 
 #[tokio::test]
-async fn test_slos_list() {
+async fn test_monitors_rewrite() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "GET", r#"{"data": [], "errors": []}"#).await;
+    let _search = server
+        .mock("GET", "/api/v1/monitor/search")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"monitors": [{"id": 5, "name": "CPU"}], "metadata": {"page": 0, "page_count": 1}}"#,
+        )
+        .create_async()
+        .await;
+    let _get = server
+        .mock("GET", "/api/v1/monitor/5")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": 5, "name": "CPU", "type": "metric alert", "query": "avg:cpu{*} > 90",
+                "tags": ["legacy-team", "env:prod"], "message": "CPU high @old-handle"}"#,
+        )
+        .create_async()
+        .await;
+    let update = server
+        .mock("PUT", "/api/v1/monitor/5")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "tags": ["env:prod", "team:payments"],
+            "message": "CPU high @new-handle"
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": 5, "name": "CPU", "type": "metric alert", "query": "avg:cpu{*} > 90"}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
 
-    let result = crate::commands::slos::list(&cfg).await;
-    assert!(result.is_ok(), "slos list failed: {:?}", result.err());
+    let opts = |dry_run| crate::commands::monitors::RewriteOptions {
+        query: "tag:legacy-team".into(),
+        add_tags: vec!["team:payments".into()],
+        remove_tags: vec!["legacy-team".into()],
+        replacements: vec!["@old-handle=@new-handle".into()],
+        dry_run,
+        concurrency: 4,
+    };
+    let result = crate::commands::monitors::rewrite(&cfg, opts(true)).await;
+    assert!(
+        result.is_ok(),
+        "monitors rewrite --dry-run failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::monitors::rewrite(&cfg, opts(false)).await;
+    assert!(
+        result.is_ok(),
+        "monitors rewrite failed: {:?}",
+        result.err()
+    );
+    update.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_slos_get() {
+async fn test_monitors_search() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(
-        &mut server,
-        "GET",
-        r#"{"data": {"id": "abc123", "name": "Test SLO", "type": "metric", "thresholds": [{"timeframe": "7d", "target": 99.9}]}, "errors": []}"#,
-    )
-    .await;
 
-    let result = crate::commands::slos::get(&cfg, "abc123").await;
-    assert!(result.is_ok(), "slos get failed: {:?}", result.err());
+    let body = r#"{"monitors": [], "metadata": {"page": 0, "page_count": 0, "per_page": 30, "total_count": 0}}"#;
+    let _mock = mock_any(&mut server, "GET", body).await;
+
+    let result = crate::commands::monitors::search(&cfg, Some("cpu".into()), 0, 30).await;
+    assert!(result.is_ok(), "monitors search failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_slos_delete() {
+async fn test_monitors_delete() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "DELETE", r#"{"data": []}"#).await;
+    let _mock = mock_any(&mut server, "DELETE", r#"{"deleted_monitor_id": 12345}"#).await;
 
-    let result = crate::commands::slos::delete(&cfg, "abc123").await;
-    assert!(result.is_ok(), "slos delete failed: {:?}", result.err());
+    let result = crate::commands::monitors::delete(&cfg, 12345).await;
+    assert!(result.is_ok(), "monitors delete failed: {:?}", result.err());
     cleanup_env();
 }
 
 // -------------------------------------------------------------------------
-// Tags
+// Dashboards
 // -------------------------------------------------------------------------
 
 #[tokio::test]
-async fn test_tags_list() {
+async fn test_dashboard_lists_add_items() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "GET", r#"{"tags": {}}"#).await;
+    let _dashboard = server
+        .mock("GET", "/api/v1/dashboard/abc-def-ghi")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"id": "abc-def-ghi", "layout_type": "free"}"#)
+        .create_async()
+        .await;
+    let add = server
+        .mock("POST", "/api/v2/dashboard/lists/manual/4567/dashboards")
+        .match_body(mockito::Matcher::Json(serde_json::json!({
+            "dashboards": [{"id": "abc-def-ghi", "type": "custom_screenboard"}]
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"added_dashboards_to_list": [{"id": "abc-def-ghi", "type": "custom_screenboard"}]}"#)
+        .expect(1)
+        .create_async()
+        .await;
 
-    let result = crate::commands::tags::list(&cfg).await;
-    assert!(result.is_ok(), "tags list failed: {:?}", result.err());
+    let result =
+        crate::commands::dashboards::lists_add_items(&cfg, 4567, &["abc-def-ghi".into()]).await;
+    assert!(
+        result.is_ok(),
+        "dashboard lists add-items failed: {:?}",
+        result.err()
+    );
+    add.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_tags_get() {
+async fn test_dashboards_list() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(
-        &mut server,
-        "GET",
-        r#"{"host": "myhost", "tags": ["env:prod", "service:web"]}"#,
-    )
-    .await;
+    let _mock = mock_any(&mut server, "GET", r#"{"dashboards": []}"#).await;
 
-    let result = crate::commands::tags::get(&cfg, "myhost").await;
-    assert!(result.is_ok(), "tags get failed: {:?}", result.err());
+    let result = crate::commands::dashboards::list(&cfg).await;
+    assert!(result.is_ok(), "dashboards list failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_tags_add() {
+async fn test_dashboards_get() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
     let _mock = mock_any(
         &mut server,
-        "POST",
-        r#"{"host": "myhost", "tags": ["env:prod"]}"#,
+        "GET",
+        r#"{"id": "abc-123", "title": "Test Dashboard", "layout_type": "ordered", "widgets": []}"#,
     )
     .await;
 
-    let result = crate::commands::tags::add(&cfg, "myhost", vec!["env:prod".into()]).await;
-    assert!(result.is_ok(), "tags add failed: {:?}", result.err());
+    let result = crate::commands::dashboards::get(&cfg, "abc-123").await;
+    assert!(result.is_ok(), "dashboards get failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_tags_update() {
+async fn test_dashboards_delete() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
     let _mock = mock_any(
         &mut server,
-        "PUT",
-        r#"{"host": "myhost", "tags": ["env:staging"]}"#,
+        "DELETE",
+        r#"{"deleted_dashboard_id": "abc-123"}"#,
     )
     .await;
 
-    let result = crate::commands::tags::update(&cfg, "myhost", vec!["env:staging".into()]).await;
-    assert!(result.is_ok(), "tags update failed: {:?}", result.err());
+    let result = crate::commands::dashboards::delete(&cfg, "abc-123").await;
+    assert!(
+        result.is_ok(),
+        "dashboards delete failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_tags_delete() {
+async fn test_dashboards_diff() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-
-    // Delete returns 204 No Content
-    let _mock = server
-        .mock("DELETE", mockito::Matcher::Any)
-        .match_query(mockito::Matcher::Any)
-        .with_status(204)
+    let file = std::env::temp_dir().join("pup_test_dashboard_diff.json");
+    std::fs::write(
+        &file,
+        r#"{"id": "abc-def-ghi", "title": "Golden", "layout_type": "ordered", "widgets": [
+            {"id": 1, "definition": {"type": "note", "title": "Readme", "content": "new"}}
+        ]}"#,
+    )
+    .unwrap();
+    let mock = server
+        .mock("GET", "/api/v1/dashboard/abc-def-ghi")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": "abc-def-ghi", "title": "Golden", "layout_type": "ordered",
+                "url": "/dashboard/abc-def-ghi", "widgets": [
+                {"id": 1, "definition": {"type": "note", "title": "Readme", "content": "old"}}
+            ]}"#,
+        )
         .create_async()
         .await;
 
-    let result = crate::commands::tags::delete(&cfg, "myhost").await;
-    assert!(result.is_ok(), "tags delete failed: {:?}", result.err());
+    let result =
+        crate::commands::dashboards::diff(&cfg, "abc-def-ghi", file.to_str().unwrap()).await;
+    assert!(result.is_ok(), "dashboards diff failed: {:?}", result.err());
+    mock.assert_async().await;
+    let _ = std::fs::remove_file(&file);
     cleanup_env();
 }
 
-// -------------------------------------------------------------------------
-// Events
-// -------------------------------------------------------------------------
-
 #[tokio::test]
-async fn test_events_list() {
+async fn test_dashboards_export_import_round_trip() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "GET", r#"{"events": []}"#).await;
+    let dir = std::env::temp_dir().join("pup_test_dashboards_export");
+    let _ = std::fs::remove_dir_all(&dir);
+    let _list = server
+        .mock("GET", "/api/v1/dashboard")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"dashboards": [{"id": "abc-123", "title": "Golden Service"}]}"#)
+        .create_async()
+        .await;
+    let _get = server
+        .mock("GET", "/api/v1/dashboard/abc-123")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": "abc-123", "title": "Golden Service", "layout_type": "ordered",
+                "widgets": [], "url": "/dashboard/abc-123", "modified_at": "2024-01-01T00:00:00Z"}"#,
+        )
+        .create_async()
+        .await;
+    // Unchanged files must not be pushed back.
+    let put = server
+        .mock("PUT", "/api/v1/dashboard/abc-123")
+        .expect(0)
+        .create_async()
+        .await;
 
-    let now = chrono::Utc::now().timestamp();
-    let result = crate::commands::events::list(&cfg, now - 3600, now, None).await;
-    assert!(result.is_ok(), "events list failed: {:?}", result.err());
+    // A file from before the dashboard was renamed, and a draft never imported.
+    std::fs::create_dir_all(&dir).unwrap();
+    std::fs::write(
+        dir.join("golden.json"),
+        r#"{"id": "abc-123", "title": "Golden"}"#,
+    )
+    .unwrap();
+    std::fs::write(dir.join("draft.json"), r#"{"title": "Draft"}"#).unwrap();
+
+    let dir_str = dir.to_str().unwrap();
+    let result = crate::commands::dashboards::export(&cfg, dir_str).await;
+    assert!(
+        result.is_ok(),
+        "dashboards export failed: {:?}",
+        result.err()
+    );
+    let exported = std::fs::read_to_string(dir.join("golden-service.json")).unwrap();
+    assert!(exported.contains("\"id\": \"abc-123\""));
+    assert!(!exported.contains("modified_at"));
+    assert!(!dir.join("golden.json").exists(), "stale file kept");
+    assert!(dir.join("draft.json").exists(), "draft removed");
+    std::fs::remove_file(dir.join("draft.json")).unwrap();
+
+    let result = crate::commands::dashboards::import(&cfg, dir_str, false).await;
+    assert!(
+        result.is_ok(),
+        "dashboards import failed: {:?}",
+        result.err()
+    );
+    put.assert_async().await;
+
+    // Two files for one dashboard are refused before anything is sent.
+    std::fs::copy(dir.join("golden-service.json"), dir.join("copy.json")).unwrap();
+    let result = crate::commands::dashboards::import(&cfg, dir_str, false).await;
+    let _ = std::fs::remove_dir_all(&dir);
+    let err = result.unwrap_err().to_string();
+    assert!(err.contains("both have id abc-123"), "{err}");
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_events_get() {
+async fn test_dashboards_clone() {
     let _lock = lock_env();
-    let mut server = mockito::Server::new_async().await;
-    let cfg = test_config(&server.url());
-    let _mock = mock_any(
-        &mut server,
-        "GET",
-        r#"{"event": {"id": 12345, "title": "Test Event", "text": "Something happened"}}"#,
-    )
-    .await;
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    let _get = s
+        .mock("GET", "/api/v1/dashboard/abc-123")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": "abc-123", "title": "Golden", "layout_type": "ordered", "widgets": [],
+                "tags": ["team:platform"], "url": "/dashboard/abc-123", "author_handle": "a@b.c",
+                "template_variables": [{"name": "service", "prefix": "service", "default": "*"}]}"#,
+        )
+        .create_async()
+        .await;
+    let create = s
+        .mock("POST", "/api/v1/dashboard")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "title": "Payments",
+            "tags": ["team:payments"],
+            "template_variables": [{"name": "service", "defaults": ["payments-api"]}]
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"id": "xyz-789", "title": "Payments"}"#)
+        .create_async()
+        .await;
 
-    let result = crate::commands::events::get(&cfg, 12345).await;
-    assert!(result.is_ok(), "events get failed: {:?}", result.err());
+    let opts = crate::commands::dashboards::CloneOptions {
+        title: Some("Payments".into()),
+        add_tags: vec!["team:payments".into()],
+        remove_tags: vec!["team:platform".into()],
+        template_defaults: vec!["service=payments-api".into()],
+        to_org: None,
+    };
+    let result = crate::commands::dashboards::clone(&cfg, "abc-123", opts).await;
+    assert!(
+        result.is_ok(),
+        "dashboards clone failed: {:?}",
+        result.err()
+    );
+    create.assert_async().await;
     cleanup_env();
 }
 
-// -------------------------------------------------------------------------
-// Logs (requires API keys)
-// -------------------------------------------------------------------------
-
 #[tokio::test]
-async fn test_logs_search() {
+async fn test_dashboards_shares_to_revoke() {
     let _lock = lock_env();
-    let mut server = mockito::Server::new_async().await;
-    let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "POST", r#"{"data": [], "meta": {"page": {}}}"#).await;
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    let _list = s
+        .mock("GET", "/api/v1/dashboard/public")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"[
+                {"token": "tok-1", "dashboard_id": "abc-123", "dashboard_type": "custom_timeboard",
+                 "share_type": "open", "status": "active", "public_url": "https://p.datadoghq.com/sb/tok-1",
+                 "author": {"handle": "a@b.c", "name": null}, "created": "2026-01-01T00:00:00Z"},
+                {"token": "tok-2", "dashboard_id": "xyz-789", "dashboard_type": "custom_screenboard",
+                 "share_type": "invite", "status": "active", "share_list": ["c@d.e"]}
+            ]"#,
+        )
+        .expect(2)
+        .create_async()
+        .await;
 
-    let result = crate::commands::logs::search(
-        &cfg,
-        "status:error".into(),
-        "1h".into(),
-        "now".into(),
-        10,
-        None,
-    )
-    .await;
-    assert!(result.is_ok(), "logs search failed: {:?}", result.err());
+    let tokens = crate::commands::dashboards::shares_to_revoke(&cfg, "abc-123", None)
+        .await
+        .unwrap();
+    assert_eq!(tokens, vec!["tok-1".to_string()]);
+    let result =
+        crate::commands::dashboards::shares_to_revoke(&cfg, "abc-123", Some("tok-2")).await;
+    assert!(
+        result.is_err(),
+        "another dashboard's share must not be revocable"
+    );
     cleanup_env();
 }
 
+// -------------------------------------------------------------------------
+// SLOs
+// -------------------------------------------------------------------------
+
 #[tokio::test]
-async fn test_logs_search_with_oauth() {
+async fn test_slos_search() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
-
-    let cfg = Config {
-        api_key: None,
-        app_key: None,
-        access_token: Some("token".into()),
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
-
-    let _mock = mock_any(&mut server, "POST", r#"{"data": []}"#).await;
+    let cfg = test_config(&server.url());
+    let mock = server
+        .mock("GET", "/api/v1/slo/search")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "include_facets".into(),
+            "true".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"type": "search_slo", "attributes": {
+                "slos": [{"data": {"id": "abc", "type": "slo", "attributes": {"name": "Checkout"}}}],
+                "facets": {"team": [{"name": "payments", "count": 1}]}}},
+                "meta": {"pagination": {"number": 0, "size": 25, "total": 1}}}"#,
+        )
+        .create_async()
+        .await;
 
-    let result = crate::commands::logs::search(
-        &cfg,
-        "status:error".into(),
-        "1h".into(),
-        "now".into(),
-        10,
-        None,
-    )
-    .await;
-    assert!(result.is_ok(), "logs search should work with OAuth");
+    let result = crate::commands::slos::search(&cfg, Some("team:payments".into()), 0, 25).await;
+    assert!(result.is_ok(), "slos search failed: {:?}", result.err());
+    mock.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_logs_aggregate() {
+async fn test_slos_list() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "POST", r#"{"data": {"buckets": []}}"#).await;
+    let _mock = mock_any(&mut server, "GET", r#"{"data": [], "errors": []}"#).await;
 
-    let result =
-        crate::commands::logs::aggregate(&cfg, "*".into(), "1h".into(), "now".into(), None).await;
-    assert!(result.is_ok(), "logs aggregate failed: {:?}", result.err());
+    let result = crate::commands::slos::list(&cfg).await;
+    assert!(result.is_ok(), "slos list failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_logs_search_with_flex_storage() {
+async fn test_slos_get() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "POST", r#"{"data": [], "meta": {"page": {}}}"#).await;
-
-    let result = crate::commands::logs::search(
-        &cfg,
-        "*".into(),
-        "1h".into(),
-        "now".into(),
-        10,
-        Some("flex".into()),
+    let _mock = mock_any(
+        &mut server,
+        "GET",
+        r#"{"data": {"id": "abc123", "name": "Test SLO", "type": "metric", "thresholds": [{"timeframe": "7d", "target": 99.9}]}, "errors": []}"#,
     )
     .await;
-    assert!(
-        result.is_ok(),
-        "logs search with flex failed: {:?}",
-        result.err()
-    );
+
+    let result = crate::commands::slos::get(&cfg, "abc123").await;
+    assert!(result.is_ok(), "slos get failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_logs_search_with_online_archives_storage() {
+async fn test_slos_delete() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "POST", r#"{"data": [], "meta": {"page": {}}}"#).await;
+    let _mock = mock_any(&mut server, "DELETE", r#"{"data": []}"#).await;
 
-    let result = crate::commands::logs::search(
-        &cfg,
-        "*".into(),
-        "1h".into(),
-        "now".into(),
-        10,
-        Some("online-archives".into()),
-    )
-    .await;
-    assert!(
-        result.is_ok(),
-        "logs search with online-archives failed: {:?}",
-        result.err()
-    );
+    let result = crate::commands::slos::delete(&cfg, "abc123").await;
+    assert!(result.is_ok(), "slos delete failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_logs_search_with_invalid_storage_tier() {
+async fn test_slos_report() {
     let _lock = lock_env();
-    let server = mockito::Server::new_async().await;
+    let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
+    let list = server
+        .mock("GET", "/api/v1/slo")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "tags_query".into(),
+            "team:payments".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [
+                {"id": "abc123", "name": "Checkout", "type": "monitor", "tags": ["team:payments"],
+                 "thresholds": [{"timeframe": "30d", "target": 99.9}]}
+            ]}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    let history = server
+        .mock("GET", "/api/v1/slo/abc123/history")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"overall": {"sli_value": 99.95}}}"#)
+        .expect(1)
+        .create_async()
+        .await;
 
-    let result = crate::commands::logs::search(
-        &cfg,
-        "*".into(),
-        "1h".into(),
-        "now".into(),
-        10,
-        Some("invalid-tier".into()),
-    )
-    .await;
-    assert!(
-        result.is_err(),
-        "logs search with invalid storage tier should fail"
-    );
-    assert!(
-        result
-            .unwrap_err()
-            .to_string()
-            .contains("unknown storage tier"),
-        "error should mention unknown storage tier"
-    );
+    let to_ts = 1_700_000_000;
+    let result =
+        crate::commands::slos::report(&cfg, &["team:payments".into()], to_ts - 30 * 86400, to_ts)
+            .await;
+    assert!(result.is_ok(), "slos report failed: {:?}", result.err());
+    list.assert_async().await;
+    history.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_logs_aggregate_with_flex_storage() {
+async fn test_slos_corrections() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "POST", r#"{"data": {"buckets": []}}"#).await;
+    let list = server
+        .mock("GET", "/api/v1/slo/abc123/corrections")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": []}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let create = server
+        .mock("POST", "/api/v1/slo/correction")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "data": {"type": "correction", "attributes": {
+                "slo_id": "abc123", "category": "Scheduled Maintenance",
+                "start": 1_717_279_200, "end": 1_717_290_000
+            }}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"id": "c1", "type": "correction", "attributes": {
+                "slo_id": "abc123", "category": "Scheduled Maintenance",
+                "start": 1717279200, "end": 1717290000}}}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    let delete = server
+        .mock("DELETE", "/api/v1/slo/correction/c1")
+        .with_status(204)
+        .expect(1)
+        .create_async()
+        .await;
 
-    let result = crate::commands::logs::aggregate(
-        &cfg,
-        "*".into(),
-        "1h".into(),
-        "now".into(),
-        Some("flex".into()),
-    )
-    .await;
+    let result = crate::commands::slos::corrections_list(&cfg, Some("abc123")).await;
     assert!(
         result.is_ok(),
-        "logs aggregate with flex failed: {:?}",
+        "corrections list failed: {:?}",
         result.err()
     );
-    cleanup_env();
-}
-
-#[tokio::test]
-async fn test_logs_archives_list() {
-    let _lock = lock_env();
-    let mut server = mockito::Server::new_async().await;
-    let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "GET", r#"{"data": []}"#).await;
-
-    let result = crate::commands::logs::archives_list(&cfg).await;
+    let opts = crate::commands::slos::CorrectionOptions {
+        slo_id: "abc123".into(),
+        start: 1_717_279_200,
+        end: Some(1_717_290_000),
+        category: "scheduled-maintenance".into(),
+        ..Default::default()
+    };
+    let result = crate::commands::slos::corrections_create(&cfg, &opts).await;
     assert!(
         result.is_ok(),
-        "logs archives list failed: {:?}",
+        "corrections create failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::slos::corrections_delete(&cfg, "c1").await;
+    assert!(
+        result.is_ok(),
+        "corrections delete failed: {:?}",
         result.err()
     );
+    list.assert_async().await;
+    create.assert_async().await;
+    delete.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_logs_custom_destinations_list() {
+async fn test_slos_suggest_writes_files() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "GET", r#"{"data": []}"#).await;
+    let dir = std::env::temp_dir().join("pup_test_slos_suggest");
+    let _ = std::fs::remove_dir_all(&dir);
+    let _monitors = server
+        .mock("GET", "/api/v1/monitor")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"[{"id": 7, "type": "metric alert", "name": "api error rate"}]"#)
+        .create_async()
+        .await;
+    let _metrics = server
+        .mock("GET", "/api/v1/search")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"results": {"metrics": ["trace.http.request.hits"]}}"#)
+        .create_async()
+        .await;
+    let _query = server
+        .mock("GET", "/api/v1/query")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"series": [{"pointlist": [[1700000000000, 0.25]]}]}"#)
+        .create_async()
+        .await;
 
-    let result = crate::commands::logs::custom_destinations_list(&cfg).await;
-    assert!(
-        result.is_ok(),
-        "logs custom destinations list failed: {:?}",
-        result.err()
-    );
+    let result =
+        crate::commands::slos::suggest(&cfg, "api", Some("prod"), vec![], 99.9, dir.to_str()).await;
+    assert!(result.is_ok(), "slos suggest failed: {:?}", result.err());
+    let latency = std::fs::read_to_string(dir.join("api-http-request-p95-latency.json")).unwrap();
+    assert!(latency.contains("p95:trace.http.request{service:api,env:prod}"));
+    assert!(dir.join("api-availability-monitor-uptime.json").exists());
+    assert!(dir.join("api-http-request-availability.json").exists());
+    let _ = std::fs::remove_dir_all(&dir);
     cleanup_env();
 }
 
+// -------------------------------------------------------------------------
+// Tags
+// -------------------------------------------------------------------------
+
 #[tokio::test]
-async fn test_logs_metrics_list() {
+async fn test_tags_list() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "GET", r#"{"data": []}"#).await;
+    let _mock = mock_any(&mut server, "GET", r#"{"tags": {}}"#).await;
 
-    let result = crate::commands::logs::metrics_list(&cfg).await;
-    assert!(
-        result.is_ok(),
-        "logs metrics list failed: {:?}",
-        result.err()
-    );
+    let result = crate::commands::tags::list(&cfg).await;
+    assert!(result.is_ok(), "tags list failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_logs_restriction_queries_list() {
+async fn test_tags_get() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
+    let _mock = mock_any(
+        &mut server,
+        "GET",
+        r#"{"host": "myhost", "tags": ["env:prod", "service:web"]}"#,
+    )
+    .await;
 
-    // restriction_queries_list uses raw HTTP (not DD client), so mock specific path
-    let _mock = server
-        .mock("GET", "/api/v2/logs/config/restriction_queries")
-        .match_query(mockito::Matcher::Any)
-        .with_status(200)
-        .with_header("content-type", "application/json")
-        .with_body(r#"{"data": []}"#)
-        .create_async()
-        .await;
-
-    let result = crate::commands::logs::restriction_queries_list(&cfg).await;
-    assert!(
-        result.is_ok(),
-        "logs restriction queries list failed: {:?}",
-        result.err()
-    );
+    let result = crate::commands::tags::get(&cfg, "myhost").await;
+    assert!(result.is_ok(), "tags get failed: {:?}", result.err());
     cleanup_env();
 }
 
-// -------------------------------------------------------------------------
-// Metrics
-// -------------------------------------------------------------------------
-
 #[tokio::test]
-async fn test_metrics_list() {
+async fn test_tags_add() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
     let _mock = mock_any(
         &mut server,
-        "GET",
-        r#"{"metrics": [], "from": "2024-01-01T00:00:00Z"}"#,
+        "POST",
+        r#"{"host": "myhost", "tags": ["env:prod"]}"#,
     )
     .await;
 
-    let result = crate::commands::metrics::list(&cfg, None, "1h".into()).await;
-    assert!(result.is_ok(), "metrics list failed: {:?}", result.err());
+    let result = crate::commands::tags::add(&cfg, "myhost", vec!["env:prod".into()]).await;
+    assert!(result.is_ok(), "tags add failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_metrics_query() {
+async fn test_tags_update() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
     let _mock = mock_any(
         &mut server,
-        "GET",
-        r#"{"status": "ok", "res_type": "time_series", "series": [], "from_date": 0, "to_date": 0, "query": "avg:system.cpu.user{*}"}"#,
+        "PUT",
+        r#"{"host": "myhost", "tags": ["env:staging"]}"#,
     )
     .await;
 
-    let result = crate::commands::metrics::query(
-        &cfg,
-        "avg:system.cpu.user{*}".into(),
-        "1h".into(),
-        "now".into(),
-    )
-    .await;
-    assert!(result.is_ok(), "metrics query failed: {:?}", result.err());
+    let result = crate::commands::tags::update(&cfg, "myhost", vec!["env:staging".into()]).await;
+    assert!(result.is_ok(), "tags update failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_metrics_metadata_get() {
+async fn test_tags_delete() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(
-        &mut server,
-        "GET",
-        r#"{"type": "gauge", "description": "CPU usage", "unit": "percent"}"#,
-    )
-    .await;
 
-    let result = crate::commands::metrics::metadata_get(&cfg, "system.cpu.user").await;
-    assert!(
-        result.is_ok(),
-        "metrics metadata get failed: {:?}",
-        result.err()
-    );
+    // Delete returns 204 No Content
+    let _mock = server
+        .mock("DELETE", mockito::Matcher::Any)
+        .match_query(mockito::Matcher::Any)
+        .with_status(204)
+        .create_async()
+        .await;
+
+    let result = crate::commands::tags::delete(&cfg, "myhost").await;
+    assert!(result.is_ok(), "tags delete failed: {:?}", result.err());
     cleanup_env();
 }
 
 // -------------------------------------------------------------------------
-// Events search (requires API keys)
+// Events
 // -------------------------------------------------------------------------
 
 #[tokio::test]
-async fn test_events_search() {
+async fn test_events_list() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     let cfg = test_config(&server.url());
-    let _mock = mock_any(&mut server, "POST", r#"{"data": [], "meta": {"page": {}}}"#).await;
+    let _mock = mock_any(&mut server, "GET", r#"{"events": []}"#).await;
 
-    let result =
-        crate::commands::events::search(&cfg, "source:nginx".into(), "1h".into(), "now".into(), 10)
-            .await;
-    assert!(result.is_ok(), "events search failed: {:?}", result.err());
+    let now = chrono::Utc::now().timestamp();
+    let result = crate::commands::events::list(&cfg, now - 3600, now, None).await;
+    assert!(result.is_ok(), "events list failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_events_search_requires_api_keys() {
+async fn test_events_get() {
     let _lock = lock_env();
-    let server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
-
-    let cfg = Config {
-        api_key: None,
-        app_key: None,
-        access_token: Some("token".into()),
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(
+        &mut server,
+        "GET",
+        r#"{"event": {"id": 12345, "title": "Test Event", "text": "Something happened"}}"#,
+    )
+    .await;
 
-    let result =
-        crate::commands::events::search(&cfg, "source:nginx".into(), "1h".into(), "now".into(), 10)
-            .await;
-    assert!(result.is_err(), "events search should require API keys");
+    let result = crate::commands::events::get(&cfg, 12345).await;
+    assert!(result.is_ok(), "events get failed: {:?}", result.err());
     cleanup_env();
 }
 
-// =========================================================================
-// Raw HTTP api module tests — these use the api.rs module directly
-// (not the DD client library), so we can mock specific paths precisely.
-// =========================================================================
+// -------------------------------------------------------------------------
+// Logs (requires API keys)
+// -------------------------------------------------------------------------
 
 #[tokio::test]
-async fn test_api_get() {
+async fn test_logs_search() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
-
-    let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
-
-    let mock = server
-        .mock("GET", "/api/v1/test")
-        .with_status(200)
-        .with_header("content-type", "application/json")
-        .with_body(r#"{"status": "ok"}"#)
-        .create_async()
-        .await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "POST", r#"{"data": [], "meta": {"page": {}}}"#).await;
 
-    let result = crate::api::get(&cfg, "/api/v1/test", &[]).await;
-    assert!(result.is_ok(), "api get failed: {:?}", result.err());
-    let val = result.unwrap();
-    assert_eq!(val["status"], "ok");
-    mock.assert_async().await;
+    let result = crate::commands::logs::search(
+        &cfg,
+        "status:error".into(),
+        "1h".into(),
+        "now".into(),
+        10,
+        vec![],
+        None,
+    )
+    .await;
+    assert!(result.is_ok(), "logs search failed: {:?}", result.err());
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_get_with_query() {
+async fn test_logs_search_all_pages_follows_cursor() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
-
-    let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
-
-    let mock = server
-        .mock("GET", "/api/v1/search")
-        .match_query(mockito::Matcher::Any)
+    let cfg = test_config(&server.url());
+    let first = server
+        .mock("POST", "/api/v2/logs/events/search")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "page": {"limit": 1000}
+        })))
         .with_status(200)
         .with_header("content-type", "application/json")
-        .with_body(r#"{"results": []}"#)
+        .with_body(r#"{"data": [{"id": "1"}, {"id": "2"}], "meta": {"page": {"after": "c1"}}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    // The first mock is satisfied after one hit, so the cursor request lands here.
+    let second = server
+        .mock("POST", "/api/v2/logs/events/search")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "page": {"cursor": "c1"}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": [{"id": "3"}], "meta": {"page": {}}}"#)
+        .expect(1)
         .create_async()
         .await;
 
-    let query = vec![("q", "test".to_string())];
-    let result = crate::api::get(&cfg, "/api/v1/search", &query).await;
+    let result = crate::commands::logs::search_all_pages(
+        &cfg,
+        "status:error".into(),
+        "1h".into(),
+        "now".into(),
+        vec![],
+        None,
+        0,
+    )
+    .await;
     assert!(
         result.is_ok(),
-        "api get with query failed: {:?}",
+        "logs search --all failed: {:?}",
         result.err()
     );
-    mock.assert_async().await;
+    first.assert_async().await;
+    second.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_post() {
+async fn test_logs_search_with_oauth() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
     std::env::set_var("PUP_MOCK_SERVER", &server.url());
 
     let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
+        access_token: Some("token".into()),
+        ..crate::test_utils::config()
     };
 
-    let mock = server
-        .mock("POST", "/api/v2/test")
-        .with_status(200)
-        .with_header("content-type", "application/json")
-        .with_body(r#"{"created": true}"#)
-        .create_async()
-        .await;
+    let _mock = mock_any(&mut server, "POST", r#"{"data": []}"#).await;
 
-    let body = serde_json::json!({"name": "test"});
-    let result = crate::api::post(&cfg, "/api/v2/test", &body).await;
-    assert!(result.is_ok(), "api post failed: {:?}", result.err());
-    mock.assert_async().await;
+    let result = crate::commands::logs::search(
+        &cfg,
+        "status:error".into(),
+        "1h".into(),
+        "now".into(),
+        10,
+        vec![],
+        None,
+    )
+    .await;
+    assert!(result.is_ok(), "logs search should work with OAuth");
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_put() {
+async fn test_logs_search_and_aggregate_with_indexes() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
-
-    let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
-
-    let mock = server
-        .mock("PUT", "/api/v1/test/123")
+    let cfg = test_config(&server.url());
+    let indexes = serde_json::json!({"filter": {"indexes": ["main", "audit"]}});
+    let search = server
+        .mock("POST", "/api/v2/logs/events/search")
+        .match_body(mockito::Matcher::PartialJson(indexes.clone()))
         .with_status(200)
         .with_header("content-type", "application/json")
-        .with_body(r#"{"updated": true}"#)
+        .with_body(r#"{"data": [], "meta": {"page": {}}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let aggregate = server
+        .mock("POST", "/api/v2/logs/analytics/aggregate")
+        .match_body(mockito::Matcher::PartialJson(indexes))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"buckets": []}}"#)
+        .expect(1)
         .create_async()
         .await;
 
-    let body = serde_json::json!({"name": "updated"});
-    let result = crate::api::put(&cfg, "/api/v1/test/123", &body).await;
-    assert!(result.is_ok(), "api put failed: {:?}", result.err());
-    mock.assert_async().await;
+    let result = crate::commands::logs::search(
+        &cfg,
+        "*".into(),
+        "1h".into(),
+        "now".into(),
+        10,
+        vec!["main".into(), "audit".into()],
+        None,
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "logs search --indexes failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::logs::aggregate(
+        &cfg,
+        "*".into(),
+        "1h".into(),
+        "now".into(),
+        vec!["main".into(), "audit".into()],
+        None,
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "logs aggregate --indexes failed: {:?}",
+        result.err()
+    );
+    search.assert_async().await;
+    aggregate.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_patch() {
+async fn test_logs_tail() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
-
-    let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
-
+    let cfg = test_config(&server.url());
     let mock = server
-        .mock("PATCH", "/api/v1/test/123")
+        .mock("POST", "/api/v2/logs/events/search")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "filter": {"query": "status:error", "indexes": ["main"]},
+            "page": {"limit": 2},
+            "sort": "-timestamp"
+        })))
         .with_status(200)
         .with_header("content-type", "application/json")
-        .with_body(r#"{"patched": true}"#)
+        .with_body(
+            r#"{"data": [
+                {"id": "2", "attributes": {"timestamp": "2024-05-01T12:00:02Z", "status": "error", "message": "b"}},
+                {"id": "1", "attributes": {"timestamp": "2024-05-01T12:00:01Z", "status": "error", "message": "a"}}
+            ], "meta": {"page": {}}}"#,
+        )
+        .expect(1)
         .create_async()
         .await;
 
-    let body = serde_json::json!({"name": "patched"});
-    let result = crate::api::patch(&cfg, "/api/v1/test/123", &body).await;
-    assert!(result.is_ok(), "api patch failed: {:?}", result.err());
+    let result = crate::commands::logs::tail(
+        &cfg,
+        "status:error".into(),
+        "15m".into(),
+        2,
+        vec!["main".into()],
+        Some("json".into()),
+        false,
+        2,
+    )
+    .await;
+    assert!(result.is_ok(), "logs tail failed: {:?}", result.err());
     mock.assert_async().await;
+
+    let result = crate::commands::logs::tail(
+        &cfg,
+        "*".into(),
+        "15m".into(),
+        2,
+        vec![],
+        Some("xml".into()),
+        false,
+        2,
+    )
+    .await;
+    assert!(result.is_err(), "invalid --format should fail");
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_delete() {
+async fn test_logs_aggregate() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "POST", r#"{"data": {"buckets": []}}"#).await;
 
-    let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
+    let result =
+        crate::commands::logs::aggregate(&cfg, "*".into(), "1h".into(), "now".into(), vec![], None)
+            .await;
+    assert!(result.is_ok(), "logs aggregate failed: {:?}", result.err());
+    cleanup_env();
+}
 
-    let mock = server
-        .mock("DELETE", "/api/v1/test/123")
-        .with_status(200)
-        .with_header("content-type", "application/json")
-        .with_body(r#"{"deleted": true}"#)
-        .create_async()
-        .await;
+#[tokio::test]
+async fn test_logs_search_with_flex_storage() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "POST", r#"{"data": [], "meta": {"page": {}}}"#).await;
 
-    let result = crate::api::delete(&cfg, "/api/v1/test/123").await;
-    assert!(result.is_ok(), "api delete failed: {:?}", result.err());
-    mock.assert_async().await;
+    let result = crate::commands::logs::search(
+        &cfg,
+        "*".into(),
+        "1h".into(),
+        "now".into(),
+        10,
+        vec![],
+        Some("flex".into()),
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "logs search with flex failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_error_response() {
+async fn test_logs_search_with_online_archives_storage() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "POST", r#"{"data": [], "meta": {"page": {}}}"#).await;
 
-    let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
+    let result = crate::commands::logs::search(
+        &cfg,
+        "*".into(),
+        "1h".into(),
+        "now".into(),
+        10,
+        vec![],
+        Some("online-archives".into()),
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "logs search with online-archives failed: {:?}",
+        result.err()
+    );
+    cleanup_env();
+}
 
-    let mock = server
-        .mock("GET", "/api/v1/test/missing")
-        .with_status(404)
-        .with_header("content-type", "application/json")
-        .with_body(r#"{"errors": ["not found"]}"#)
-        .create_async()
-        .await;
+#[tokio::test]
+async fn test_logs_search_with_invalid_storage_tier() {
+    let _lock = lock_env();
+    let server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
 
-    let result = crate::api::get(&cfg, "/api/v1/test/missing", &[]).await;
-    assert!(result.is_err(), "should return error for 404");
-    assert!(result.unwrap_err().to_string().contains("404"));
-    mock.assert_async().await;
+    let result = crate::commands::logs::search(
+        &cfg,
+        "*".into(),
+        "1h".into(),
+        "now".into(),
+        10,
+        vec![],
+        Some("invalid-tier".into()),
+    )
+    .await;
+    assert!(
+        result.is_err(),
+        "logs search with invalid storage tier should fail"
+    );
+    assert!(
+        result
+            .unwrap_err()
+            .to_string()
+            .contains("unknown storage tier"),
+        "error should mention unknown storage tier"
+    );
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_bearer_auth() {
+async fn test_logs_aggregate_with_flex_storage() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
-
-    let cfg = Config {
-        api_key: None,
-        app_key: None,
-        access_token: Some("test-bearer-token".into()),
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
-
-    let mock = server
-        .mock("GET", "/api/v1/test")
-        .match_header("Authorization", "Bearer test-bearer-token")
-        .with_status(200)
-        .with_header("content-type", "application/json")
-        .with_body(r#"{"auth": "bearer"}"#)
-        .create_async()
-        .await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "POST", r#"{"data": {"buckets": []}}"#).await;
 
-    let result = crate::api::get(&cfg, "/api/v1/test", &[]).await;
-    assert!(result.is_ok(), "bearer auth failed: {:?}", result.err());
-    mock.assert_async().await;
+    let result = crate::commands::logs::aggregate(
+        &cfg,
+        "*".into(),
+        "1h".into(),
+        "now".into(),
+        vec![],
+        Some("flex".into()),
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "logs aggregate with flex failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_no_auth() {
+async fn test_logs_archives_list() {
     let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "GET", r#"{"data": []}"#).await;
 
-    let cfg = Config {
-        api_key: None,
-        app_key: None,
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
-
-    let result = crate::api::get(&cfg, "/api/v1/test", &[]).await;
-    assert!(result.is_err(), "should fail without auth");
+    let result = crate::commands::logs::archives_list(&cfg).await;
     assert!(
-        result.unwrap_err().to_string().contains("authentication"),
-        "error should mention authentication"
+        result.is_ok(),
+        "logs archives list failed: {:?}",
+        result.err()
     );
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_empty_response() {
+async fn test_logs_archives_validate() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
-
-    let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
+    let cfg = test_config(&server.url());
+    let archive = |state: &str| {
+        format!(
+            r#"{{"data": {{"type": "archives", "id": "a1", "attributes": {{
+                "name": "main", "query": "*", "state": "{state}",
+                "destination": {{"type": "s3", "bucket": "logs",
+                    "integration": {{"account_id": "123456789012", "role_name": "dd"}}}}}}}}}}"#
+        )
     };
-
-    let mock = server
-        .mock("DELETE", "/api/v1/test/empty")
-        .with_status(204)
-        .with_body("")
+    let working = server
+        .mock("GET", "/api/v2/logs/config/archives/a1")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(archive("WORKING"))
+        .create_async()
+        .await;
+    // Validation only reads; it must never write the archive back.
+    let put = server
+        .mock("PUT", "/api/v2/logs/config/archives/a1")
+        .expect(0)
         .create_async()
         .await;
 
-    let result = crate::api::delete(&cfg, "/api/v1/test/empty").await;
-    assert!(result.is_ok(), "empty response failed: {:?}", result.err());
-    let val = result.unwrap();
-    assert_eq!(val, serde_json::json!({}));
-    mock.assert_async().await;
+    let result = crate::commands::logs::archives_validate(&cfg, "a1").await;
+    assert!(
+        result.is_ok(),
+        "logs archives validate failed: {:?}",
+        result.err()
+    );
+    working.remove_async().await;
+
+    let _failing = server
+        .mock("GET", "/api/v2/logs/config/archives/a1")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(archive("FAILING"))
+        .create_async()
+        .await;
+    let err = crate::commands::logs::archives_validate(&cfg, "a1")
+        .await
+        .unwrap_err()
+        .to_string();
+    assert!(err.contains("failed 1 validation check"), "{err}");
+    put.assert_async().await;
     cleanup_env();
 }
 
 #[tokio::test]
-async fn test_api_server_error() {
+async fn test_logs_custom_destinations_list() {
     let _lock = lock_env();
     let mut server = mockito::Server::new_async().await;
-    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "GET", r#"{"data": []}"#).await;
 
-    let cfg = Config {
-        api_key: Some("test-key".into()),
-        app_key: Some("test-app".into()),
-        access_token: None,
-        site: "datadoghq.com".into(),
-        org: None,
-        output_format: OutputFormat::Json,
-        auto_approve: false,
-        agent_mode: false,
-    };
-
-    let mock = server
-        .mock("GET", "/api/v1/test")
-        .with_status(500)
-        .with_body(r#"{"errors": ["internal server error"]}"#)
-        .create_async()
-        .await;
-
-    let result = crate::api::get(&cfg, "/api/v1/test", &[]).await;
-    assert!(result.is_err());
-    assert!(result.unwrap_err().to_string().contains("500"));
-    mock.assert_async().await;
+    let result = crate::commands::logs::custom_destinations_list(&cfg).await;
+    assert!(
+        result.is_ok(),
+        "logs custom destinations list failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
 
-// =========================================================================
-// Bulk command module tests — exercise list/get operations for all remaining
-// command modules to maximize coverage. The mock_any helper catches all
-// requests. We use `let _ =` instead of asserting success because some DD
-// client types may not deserialize our minimal responses — the important
-// thing is that the command code paths are exercised.
-// =========================================================================
+#[tokio::test]
+async fn test_logs_metrics_list() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "GET", r#"{"data": []}"#).await;
 
-/// Mock all HTTP methods with the same response body.
-async fn mock_all(s: &mut mockito::Server, body: &str) {
-    for method in &["GET", "POST", "PUT", "PATCH", "DELETE"] {
-        s.mock(method, mockito::Matcher::Any)
-            .match_query(mockito::Matcher::Any)
-            .with_status(200)
-            .with_header("content-type", "application/json")
-            .with_body(body)
-            .create_async()
-            .await;
-    }
+    let result = crate::commands::logs::metrics_list(&cfg).await;
+    assert!(
+        result.is_ok(),
+        "logs metrics list failed: {:?}",
+        result.err()
+    );
+    cleanup_env();
 }
 
-// --- RUM ---
 #[tokio::test]
-async fn test_rum_apps_list() {
+async fn test_logs_pipelines_reorder_keeps_unlisted_order() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::rum::apps_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _current = server
+        .mock("GET", "/api/v1/logs/config/pipeline-order")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"pipeline_ids": ["a", "b", "c"]}"#)
+        .create_async()
+        .await;
+    let update = server
+        .mock("PUT", "/api/v1/logs/config/pipeline-order")
+        .match_body(mockito::Matcher::Json(
+            serde_json::json!({"pipeline_ids": ["c", "a", "b"]}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"pipeline_ids": ["c", "a", "b"]}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::logs::pipelines_reorder(&cfg, &["c".into()]).await;
+    assert!(
+        result.is_ok(),
+        "pipelines reorder failed: {:?}",
+        result.err()
+    );
+    update.assert_async().await;
+
+    let result = crate::commands::logs::pipelines_reorder(&cfg, &["z".into()]).await;
+    assert!(result.is_err());
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_rum_apps_get() {
+async fn test_logs_indexes_update_resubmits_with_exclusion() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {"id": "abc", "type": "rum_browser"}}"#).await;
-    let _ = crate::commands::rum::apps_get(&cfg, "abc").await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _current = server
+        .mock("GET", "/api/v1/logs/config/indexes/main")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"name": "main", "filter": {"query": "*"}, "num_retention_days": 15,
+                "is_rate_limited": false, "exclusion_filters": []}"#,
+        )
+        .create_async()
+        .await;
+    let update = server
+        .mock("PUT", "/api/v1/logs/config/indexes/main")
+        .match_body(mockito::Matcher::Json(serde_json::json!({
+            "filter": {"query": "*"},
+            "exclusion_filters": [{
+                "name": "health", "is_enabled": true,
+                "filter": {"query": "path:/health", "sample_rate": 0.5}
+            }],
+            "num_retention_days": 15
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"name": "main"}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let edits = crate::commands::logs::ExclusionEdits {
+        add: vec!["health=path:/health".into()],
+        sample_rates: vec!["health=0.5".into()],
+        ..Default::default()
+    };
+    let result = crate::commands::logs::indexes_update(&cfg, "main", &edits).await;
+    assert!(result.is_ok(), "indexes update failed: {:?}", result.err());
+    update.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_rum_apps_delete() {
+async fn test_logs_restriction_queries_list() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::rum::apps_delete(&cfg, "abc").await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+
+    // restriction_queries_list uses raw HTTP (not DD client), so mock specific path
+    let _mock = server
+        .mock("GET", "/api/v2/logs/config/restriction_queries")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": []}"#)
+        .create_async()
+        .await;
+
+    let result = crate::commands::logs::restriction_queries_list(&cfg).await;
+    assert!(
+        result.is_ok(),
+        "logs restriction queries list failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
+
+// -------------------------------------------------------------------------
+// Metrics
+// -------------------------------------------------------------------------
+
 #[tokio::test]
-async fn test_rum_metrics_list() {
+async fn test_metrics_list() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::rum::metrics_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(
+        &mut server,
+        "GET",
+        r#"{"metrics": [], "from": "2024-01-01T00:00:00Z"}"#,
+    )
+    .await;
+
+    let result = crate::commands::metrics::list(&cfg, None, "1h".into()).await;
+    assert!(result.is_ok(), "metrics list failed: {:?}", result.err());
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_rum_metrics_get() {
+async fn test_metrics_query() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::rum::metrics_get(&cfg, "m1").await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(
+        &mut server,
+        "GET",
+        r#"{"status": "ok", "res_type": "time_series", "series": [], "from_date": 0, "to_date": 0, "query": "avg:system.cpu.user{*}"}"#,
+    )
+    .await;
+
+    let result = crate::commands::metrics::query(
+        &cfg,
+        "avg:system.cpu.user{*}".into(),
+        "1h".into(),
+        "now".into(),
+    )
+    .await;
+    assert!(result.is_ok(), "metrics query failed: {:?}", result.err());
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_rum_metrics_delete() {
+async fn test_metrics_metadata_get() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::rum::metrics_delete(&cfg, "m1").await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(
+        &mut server,
+        "GET",
+        r#"{"type": "gauge", "description": "CPU usage", "unit": "percent"}"#,
+    )
+    .await;
+
+    let result = crate::commands::metrics::metadata_get(&cfg, "system.cpu.user").await;
+    assert!(
+        result.is_ok(),
+        "metrics metadata get failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_rum_retention_filters_list() {
+async fn test_metrics_detect_anomalies() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::rum::retention_filters_list(&cfg, "app1").await;
+    let mock = s
+        .mock("GET", "/api/v1/query")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "query".into(),
+            "anomalies(avg:checkout.latency{env:prod}, 'agile', 3)".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"series": [{"scope": "env:prod",
+                "pointlist": [[1000.0, 5.0, 1.0, 10.0], [2000.0, 25.0, 1.0, 10.0]]}]}"#,
+        )
+        .create_async()
+        .await;
+
+    let opts = crate::commands::metrics::DetectOptions {
+        query: "avg:checkout.latency{env:prod}".into(),
+        algorithm: crate::commands::metrics::DetectAlgorithm::Anomalies,
+        method: Some("agile".into()),
+        bounds: 3.0,
+        window: "4h".into(),
+        horizon: "1d".into(),
+        threshold: None,
+        below: false,
+    };
+    let result = crate::commands::metrics::detect(&cfg, opts).await;
+    assert!(result.is_ok(), "metrics detect failed: {:?}", result.err());
+    mock.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_rum_events_list() {
+async fn test_metrics_submit() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::rum::events_list(&cfg, "1h".into(), "now".into(), 10).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let mock = server
+        .mock("POST", "/api/v2/series")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "series": [{"metric": "custom.metric", "type": 3,
+                "points": [{"timestamp": 1_700_000_000, "value": 3.2}], "tags": ["env:dev"]}]
+        })))
+        .with_status(202)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"errors": []}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let body = crate::commands::metrics::series_payload(&crate::commands::metrics::SubmitOptions {
+        name: "custom.metric".into(),
+        value: 3.2,
+        metric_type: "gauge".into(),
+        tags: vec!["env:dev".into()],
+        timestamp: 1_700_000_000,
+        ..Default::default()
+    })
+    .unwrap();
+    let result = crate::commands::metrics::submit(&cfg, body).await;
+    assert!(result.is_ok(), "metrics submit failed: {:?}", result.err());
+    mock.assert_async().await;
     cleanup_env();
 }
+
+// -------------------------------------------------------------------------
+// Events search (requires API keys)
+// -------------------------------------------------------------------------
+
 #[tokio::test]
-async fn test_rum_playlists_list() {
+async fn test_events_search() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::rum::playlists_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = mock_any(&mut server, "POST", r#"{"data": [], "meta": {"page": {}}}"#).await;
+
+    let result =
+        crate::commands::events::search(&cfg, "source:nginx".into(), "1h".into(), "now".into(), 10)
+            .await;
+    assert!(result.is_ok(), "events search failed: {:?}", result.err());
     cleanup_env();
 }
 
-// --- Status Pages ---
+// -------------------------------------------------------------------------
+// Watchdog
+// -------------------------------------------------------------------------
+
 #[tokio::test]
-async fn test_status_pages_list() {
+async fn test_watchdog_alerts_list_filters_by_service() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::status_pages::pages_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let search = server
+        .mock("POST", "/api/v2/events/search")
+        .match_body(mockito::Matcher::Regex(
+            "source:watchdog service:api env:prod".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"id": "w1", "type": "event", "attributes": {"tags": ["service:api"]}}]}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+
+    let opts = crate::commands::watchdog::AlertsListOptions {
+        from: "1d".into(),
+        to: "now".into(),
+        service: Some("api".into()),
+        resource: None,
+        env: Some("prod".into()),
+        query: None,
+        limit: 50,
+    };
+    let result = crate::commands::watchdog::alerts_list(&cfg, opts).await;
+    assert!(
+        result.is_ok(),
+        "watchdog alerts list failed: {:?}",
+        result.err()
+    );
+    search.assert_async().await;
+    cleanup_env();
+}
+
+// -------------------------------------------------------------------------
+// Workflows
+// -------------------------------------------------------------------------
+
+#[tokio::test]
+async fn test_workflows_trigger_sends_inputs_as_payload() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let run = server
+        .mock("POST", "/api/v2/workflows/wf-1/instances")
+        .match_body(mockito::Matcher::Json(serde_json::json!({
+            "meta": {"payload": {"service": "api", "dry_run": false}}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "inst-1"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let inputs = vec!["service=api".to_string(), "dry_run=false".to_string()];
+    let result = crate::commands::workflows::trigger(&cfg, "wf-1", None, &inputs).await;
+    assert!(
+        result.is_ok(),
+        "workflows trigger failed: {:?}",
+        result.err()
+    );
+    run.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_events_send_dedup_skips_duplicate() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _search = server
+        .mock("POST", "/api/v2/events/search")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"id": "e1", "type": "event", "attributes": {"attributes": {"aggregation_key": "deploy-1"}}}]}"#,
+        )
+        .create_async()
+        .await;
+    let intake = server
+        .mock("POST", "/api/v2/events")
+        .with_status(202)
+        .with_body("{}")
+        .expect(0)
+        .create_async()
+        .await;
+
+    let opts = crate::commands::events::EventSendOptions {
+        title: "Deploy".into(),
+        category: "change".into(),
+        alert_status: "warn".into(),
+        aggregation_key: Some("deploy-1".into()),
+        dedup_window: Some("10m".into()),
+        ..Default::default()
+    };
+    let result = crate::commands::events::send(&cfg, opts).await;
+    assert!(result.is_ok(), "events send failed: {:?}", result.err());
+    intake.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_events_send_dedup_pages_past_first_page() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let first = server
+        .mock("POST", "/api/v2/events/search")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"id": "e1", "type": "event", "attributes": {"attributes": {"aggregation_key": "other"}}}],
+                "meta": {"page": {"after": "c2"}}}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    let second = server
+        .mock("POST", "/api/v2/events/search")
+        .match_body(mockito::Matcher::PartialJson(
+            serde_json::json!({"page": {"cursor": "c2"}}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"id": "e2", "type": "event", "attributes": {"attributes": {"aggregation_key": "deploy-1"}}}]}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    let intake = server
+        .mock("POST", "/api/v2/events")
+        .with_status(202)
+        .with_body("{}")
+        .expect(0)
+        .create_async()
+        .await;
+
+    let opts = crate::commands::events::EventSendOptions {
+        title: "Deploy".into(),
+        category: "change".into(),
+        alert_status: "warn".into(),
+        aggregation_key: Some("deploy-1".into()),
+        dedup_window: Some("10m".into()),
+        ..Default::default()
+    };
+    let result = crate::commands::events::send(&cfg, opts).await;
+    assert!(result.is_ok(), "events send failed: {:?}", result.err());
+    first.assert_async().await;
+    second.assert_async().await;
+    intake.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_events_send_dedup_stops_at_page_cap() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let search = server
+        .mock("POST", "/api/v2/events/search")
+        .match_body(mockito::Matcher::PartialJson(
+            serde_json::json!({"filter": {"query": "source:github"}}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"id": "e1", "type": "event", "attributes": {"attributes": {"aggregation_key": "other"}}}],
+                "meta": {"page": {"after": "next"}}}"#,
+        )
+        .expect(5)
+        .create_async()
+        .await;
+    let intake = server
+        .mock("POST", "/api/v2/events")
+        .with_status(202)
+        .with_body("{}")
+        .expect(1)
+        .create_async()
+        .await;
+
+    let opts = crate::commands::events::EventSendOptions {
+        title: "Deploy".into(),
+        category: "change".into(),
+        alert_status: "warn".into(),
+        source_type: Some("github".into()),
+        aggregation_key: Some("deploy-1".into()),
+        dedup_window: Some("10m".into()),
+        ..Default::default()
+    };
+    let result = crate::commands::events::send(&cfg, opts).await;
+    assert!(result.is_ok(), "events send failed: {:?}", result.err());
+    search.assert_async().await;
+    intake.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_events_search_requires_api_keys() {
+    let _lock = lock_env();
+    let server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        access_token: Some("token".into()),
+        ..crate::test_utils::config()
+    };
+
+    let result =
+        crate::commands::events::search(&cfg, "source:nginx".into(), "1h".into(), "now".into(), 10)
+            .await;
+    assert!(result.is_err(), "events search should require API keys");
+    cleanup_env();
+}
+
+// =========================================================================
+// Raw HTTP api module tests — these use the api.rs module directly
+// (not the DD client library), so we can mock specific paths precisely.
+// =========================================================================
+
+#[tokio::test]
+async fn test_api_get() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("GET", "/api/v1/test")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"status": "ok"}"#)
+        .create_async()
+        .await;
+
+    let result = crate::api::get(&cfg, "/api/v1/test", &[]).await;
+    assert!(result.is_ok(), "api get failed: {:?}", result.err());
+    let val = result.unwrap();
+    assert_eq!(val["status"], "ok");
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_get_with_query() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("GET", "/api/v1/search")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"results": []}"#)
+        .create_async()
+        .await;
+
+    let query = vec![("q", "test".to_string())];
+    let result = crate::api::get(&cfg, "/api/v1/search", &query).await;
+    assert!(
+        result.is_ok(),
+        "api get with query failed: {:?}",
+        result.err()
+    );
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_post() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("POST", "/api/v2/test")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"created": true}"#)
+        .create_async()
+        .await;
+
+    let body = serde_json::json!({"name": "test"});
+    let result = crate::api::post(&cfg, "/api/v2/test", &body).await;
+    assert!(result.is_ok(), "api post failed: {:?}", result.err());
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_put() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("PUT", "/api/v1/test/123")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"updated": true}"#)
+        .create_async()
+        .await;
+
+    let body = serde_json::json!({"name": "updated"});
+    let result = crate::api::put(&cfg, "/api/v1/test/123", &body).await;
+    assert!(result.is_ok(), "api put failed: {:?}", result.err());
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_patch() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("PATCH", "/api/v1/test/123")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"patched": true}"#)
+        .create_async()
+        .await;
+
+    let body = serde_json::json!({"name": "patched"});
+    let result = crate::api::patch(&cfg, "/api/v1/test/123", &body).await;
+    assert!(result.is_ok(), "api patch failed: {:?}", result.err());
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_delete() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("DELETE", "/api/v1/test/123")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"deleted": true}"#)
+        .create_async()
+        .await;
+
+    let result = crate::api::delete(&cfg, "/api/v1/test/123").await;
+    assert!(result.is_ok(), "api delete failed: {:?}", result.err());
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_error_response() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("GET", "/api/v1/test/missing")
+        .with_status(404)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"errors": ["not found"]}"#)
+        .create_async()
+        .await;
+
+    let result = crate::api::get(&cfg, "/api/v1/test/missing", &[]).await;
+    assert!(result.is_err(), "should return error for 404");
+    assert!(result.unwrap_err().to_string().contains("404"));
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_bearer_auth() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        access_token: Some("test-bearer-token".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("GET", "/api/v1/test")
+        .match_header("Authorization", "Bearer test-bearer-token")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"auth": "bearer"}"#)
+        .create_async()
+        .await;
+
+    let result = crate::api::get(&cfg, "/api/v1/test", &[]).await;
+    assert!(result.is_ok(), "bearer auth failed: {:?}", result.err());
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_no_auth() {
+    let _lock = lock_env();
+
+    let cfg = crate::test_utils::config();
+
+    let result = crate::api::get(&cfg, "/api/v1/test", &[]).await;
+    assert!(result.is_err(), "should fail without auth");
+    assert!(
+        result.unwrap_err().to_string().contains("authentication"),
+        "error should mention authentication"
+    );
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_empty_response() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("DELETE", "/api/v1/test/empty")
+        .with_status(204)
+        .with_body("")
+        .create_async()
+        .await;
+
+    let result = crate::api::delete(&cfg, "/api/v1/test/empty").await;
+    assert!(result.is_ok(), "empty response failed: {:?}", result.err());
+    let val = result.unwrap();
+    assert_eq!(val, serde_json::json!({}));
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_api_server_error() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    std::env::set_var("PUP_MOCK_SERVER", &server.url());
+
+    let cfg = Config {
+        api_key: Some("test-key".into()),
+        app_key: Some("test-app".into()),
+        ..crate::test_utils::config()
+    };
+
+    let mock = server
+        .mock("GET", "/api/v1/test")
+        .with_status(500)
+        .with_body(r#"{"errors": ["internal server error"]}"#)
+        .create_async()
+        .await;
+
+    let result = crate::api::get(&cfg, "/api/v1/test", &[]).await;
+    assert!(result.is_err());
+    assert!(result.unwrap_err().to_string().contains("500"));
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+// =========================================================================
+// Bulk command module tests — exercise list/get operations for all remaining
+// command modules to maximize coverage. The mock_any helper catches all
+// requests. We use `let _ =` instead of asserting success because some DD
+// client types may not deserialize our minimal responses — the important
+// thing is that the command code paths are exercised.
+// =========================================================================
+
+/// Mock all HTTP methods with the same response body.
+async fn mock_all(s: &mut mockito::Server, body: &str) {
+    for method in &["GET", "POST", "PUT", "PATCH", "DELETE"] {
+        s.mock(method, mockito::Matcher::Any)
+            .match_query(mockito::Matcher::Any)
+            .with_status(200)
+            .with_header("content-type", "application/json")
+            .with_body(body)
+            .create_async()
+            .await;
+    }
+}
+
+// --- RUM ---
+#[tokio::test]
+async fn test_rum_apps_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::rum::apps_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_rum_apps_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": {"id": "abc", "type": "rum_browser"}}"#).await;
+    let _ = crate::commands::rum::apps_get(&cfg, "abc").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_rum_apps_delete() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{}"#).await;
+    let _ = crate::commands::rum::apps_delete(&cfg, "abc").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_rum_metrics_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::rum::metrics_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_rum_metrics_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::rum::metrics_get(&cfg, "m1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_rum_metrics_delete() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{}"#).await;
+    let _ = crate::commands::rum::metrics_delete(&cfg, "m1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_rum_retention_filters_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::rum::retention_filters_list(&cfg, "app1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_rum_events_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::rum::events_list(&cfg, "1h".into(), "now".into(), 10).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_rum_playlists_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::rum::playlists_list(&cfg).await;
+    cleanup_env();
+}
+
+// --- Status Pages ---
+#[tokio::test]
+async fn test_status_pages_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::status_pages::pages_list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
@@ -1173,742 +2511,2970 @@ async fn test_status_pages_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::status_pages::pages_get(&cfg, "p1").await;
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::status_pages::pages_get(&cfg, "p1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_status_pages_delete() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{}"#).await;
+    let _ = crate::commands::status_pages::pages_delete(&cfg, "p1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_status_pages_components_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::status_pages::components_list(&cfg, "p1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_status_pages_degradations_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::status_pages::degradations_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_status_pages_third_party_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::status_pages::third_party_list(&cfg, None, false).await;
+    cleanup_env();
+}
+
+// --- Cases ---
+#[tokio::test]
+async fn test_cases_search() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::cases::search(&cfg, None, 10, 0).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_cases_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::cases::get(&cfg, "case1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_cases_comment() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    let commented = s
+        .mock("POST", "/api/v2/cases/case1/comment")
+        .match_body(mockito::Matcher::Json(serde_json::json!({
+            "data": {"attributes": {"comment": "Rolled back"}, "type": "case"}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "c1", "type": "timeline_cell"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let result = crate::commands::cases::comment(&cfg, "case1", "Rolled back").await;
+    assert!(result.is_ok(), "cases comment failed: {:?}", result.err());
+    commented.assert_async().await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_cases_jira_create_issue_waits_for_new_url() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    let created = s
+        .mock("POST", "/api/v2/cases/case1/jira_issue")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "data": {"type": "issues", "attributes": {"jira_account_id": "acct-1", "project_id": "10001"}}
+        })))
+        .with_status(202)
+        .with_header("content-type", "application/json")
+        .with_body("{}")
+        .expect(1)
+        .create_async()
+        .await;
+    // The case already links OPS-11; the new issue appears on the second read.
+    let reads = std::sync::Arc::new(std::sync::atomic::AtomicUsize::new(0));
+    let counter = reads.clone();
+    let _fetched = s
+        .mock("GET", "/api/v2/cases/case1")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body_from_request(move |_| {
+            let n = counter.fetch_add(1, std::sync::atomic::Ordering::SeqCst);
+            let key = if n == 0 { "OPS-11" } else { "OPS-12" };
+            serde_json::json!({"data": {"id": "case1", "attributes": {"jira_issue": {
+                "status": "COMPLETED",
+                "result": {"issue_url": format!("https://acme.atlassian.net/browse/{key}")}
+            }}}})
+            .to_string()
+            .into()
+        })
+        .create_async()
+        .await;
+    let body = crate::commands::cases::jira_issue_body("acct-1", "10001", None);
+    let result = crate::commands::cases::jira_create_issue(&cfg, "case1", body).await;
+    assert!(
+        result.is_ok(),
+        "cases jira create-issue failed: {:?}",
+        result.err()
+    );
+    created.assert_async().await;
+    assert_eq!(reads.load(std::sync::atomic::Ordering::SeqCst), 2);
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_cases_projects_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::cases::projects_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_cases_projects_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::cases::projects_get(&cfg, "proj1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_cases_projects_delete() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{}"#).await;
+    let _ = crate::commands::cases::projects_delete(&cfg, "proj1").await;
+    cleanup_env();
+}
+
+// --- Integrations ---
+#[tokio::test]
+async fn test_integrations_jira_accounts_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::integrations::jira_accounts_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_integrations_jira_templates_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::integrations::jira_templates_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_integrations_servicenow_instances_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::integrations::servicenow_instances_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_integrations_servicenow_templates_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::integrations::servicenow_templates_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_integrations_slack_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::integrations::slack_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_integrations_webhooks_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::integrations::webhooks_list(&cfg).await;
+    cleanup_env();
+}
+
+// --- CI/CD ---
+#[tokio::test]
+async fn test_cicd_pipelines_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::cicd::pipelines_list(&cfg, None, "1h".into(), "now".into(), 10).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_cicd_tests_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::cicd::tests_list(&cfg, None, "1h".into(), "now".into(), 10).await;
+    cleanup_env();
+}
+
+// --- Fleet ---
+#[tokio::test]
+async fn test_fleet_agents_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::fleet::agents_list(&cfg, None).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_fleet_agents_list_all_pages() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    let page0 = s
+        .mock("GET", "/api/v2/fleet/agents")
+        .match_query(mockito::Matcher::AllOf(vec![
+            mockito::Matcher::UrlEncoded("page[size]".into(), "2".into()),
+            mockito::Matcher::UrlEncoded("page[number]".into(), "0".into()),
+        ]))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"attributes": {"agents": [{"agent_key": "a1"}, {"agent_key": "a2"}]}}}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    let page1 = s
+        .mock("GET", "/api/v2/fleet/agents")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "page[number]".into(),
+            "1".into(),
+        ))
+        .expect(0)
+        .create_async()
+        .await;
+    // --max-items=2 is satisfied by the first page, so the walk stops there.
+    let result = crate::commands::fleet::agents_list_all_pages(&cfg, 2).await;
+    assert!(
+        result.is_ok(),
+        "agents list --all failed: {:?}",
+        result.err()
+    );
+    page0.assert_async().await;
+    page1.assert_async().await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_fleet_agents_drift() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let mut cfg = test_config(&s.url());
+    cfg.output_format = OutputFormat::Csv;
+    let file = std::env::temp_dir().join("pup_test_fleet_policy.yaml");
+    std::fs::write(
+        &file,
+        "rules:\n  - name: agent-version\n    field: agent_version\n    min_version: \"7.60.0\"\n",
+    )
+    .unwrap();
+    let _list = s
+        .mock("GET", "/api/v2/fleet/agents")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"attributes": {"agents": [{"agent_key": "a1", "hostname": "web-1"}]}}}"#,
+        )
+        .create_async()
+        .await;
+    let info = s
+        .mock("GET", "/api/v2/fleet/agents/a1")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"attributes": {"agent_infos": {"agent_version": "7.50.0"}}}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let result = crate::commands::fleet::agents_drift(
+        &cfg,
+        file.to_str().unwrap(),
+        crate::util::DEFAULT_MAX_ITEMS,
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "fleet agents drift failed: {:?}",
+        result.err()
+    );
+    info.assert_async().await;
+    let _ = std::fs::remove_file(&file);
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_fleet_agents_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::fleet::agents_get(&cfg, "a1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_fleet_agents_versions() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::fleet::agents_versions(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_fleet_deployments_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::fleet::deployments_list(&cfg, None).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_fleet_schedules_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::fleet::schedules_list(&cfg).await;
+    cleanup_env();
+}
+
+// --- Incidents ---
+#[tokio::test]
+async fn test_incidents_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::incidents::list(&cfg, 10).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_incidents_list_filtered() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let search = server
+        .mock("GET", "/api/v2/incidents/search")
+        .match_query(mockito::Matcher::AllOf(vec![
+            mockito::Matcher::UrlEncoded(
+                "query".into(),
+                "state:active AND severity:(SEV-1 OR SEV-2)".into(),
+            ),
+            mockito::Matcher::UrlEncoded("sort".into(), "-created".into()),
+            mockito::Matcher::UrlEncoded("page[size]".into(), "25".into()),
+            mockito::Matcher::UrlEncoded("page[offset]".into(), "25".into()),
+        ]))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"type": "incidents_search_results", "attributes": {"total": 60,
+                "incidents": [{"data": {"id": "a", "type": "incidents"}}]}}}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    let filters = crate::commands::incidents::IncidentFilters {
+        states: vec!["active".into()],
+        severities: vec!["sev1".into(), "2".into()],
+        ..Default::default()
+    };
+    let page = crate::commands::incidents::SearchPage {
+        sort: "-created".into(),
+        limit: 25,
+        page: 1,
+        all: false,
+        max_items: 0,
+    };
+    let result = crate::commands::incidents::search(&cfg, &filters, page).await;
+    assert!(
+        result.is_ok(),
+        "incidents search failed: {:?}",
+        result.err()
+    );
+    search.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_incidents_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::incidents::get(&cfg, "inc1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_incidents_settings_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::incidents::settings_get(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_incidents_handles_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::incidents::handles_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_incidents_postmortem_templates_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::incidents::postmortem_templates_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_incidents_export() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(
+        &mut s,
+        r#"{"data": {"id": "inc1", "attributes": {"title": "Outage"}}}"#,
+    )
+    .await;
+    let result = crate::commands::incidents::export(&cfg, "inc1", "markdown").await;
+    assert!(
+        result.is_ok(),
+        "incidents export failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::incidents::export(&cfg, "inc1", "pdf").await;
+    assert!(result.is_err());
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_incidents_timeline() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let mock = server
+        .mock("GET", "/api/v2/incidents/abc-123/timeline")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"id": "c1", "type": "incident_timeline_cells",
+                "attributes": {"created": "2024-01-02T03:00:00Z", "cell_type": "markdown",
+                "content": {"content": "paged"}}}]}"#,
+        )
+        .create_async()
+        .await;
+
+    let result = crate::commands::incidents::timeline(&cfg, "abc-123", false, 10).await;
+    assert!(
+        result.is_ok(),
+        "incidents timeline failed: {:?}",
+        result.err()
+    );
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_incidents_create_update_and_timeline_add() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _users = server
+        .mock("GET", "/api/v2/users")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "filter".into(),
+            "jane@example.com".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": [{"id": "u1", "attributes": {"email": "jane@example.com"}}]}"#)
+        .create_async()
+        .await;
+    let create = server
+        .mock("POST", "/api/v2/incidents")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({"data": {
+            "attributes": {"title": "Checkout errors", "customer_impacted": false,
+                "fields": {"severity": {"value": "SEV-2"}}},
+            "relationships": {"commander_user": {"data": {"id": "u1"}}}
+        }})))
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "abc-123", "type": "incidents"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let update = server
+        .mock("PATCH", "/api/v2/incidents/abc-123")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({"data": {
+            "id": "abc-123", "attributes": {"fields": {"state": {"value": "resolved"}}}
+        }})))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "abc-123", "type": "incidents"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let note = server
+        .mock("POST", "/api/v2/incidents/abc-123/timeline")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({"data": {
+            "attributes": {"cell_type": "markdown", "content": {"content": "Rolled back"}}
+        }})))
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "c1", "type": "incident_timeline_cells"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::incidents::create(
+        &cfg,
+        crate::commands::incidents::IncidentChanges {
+            title: Some("Checkout errors".into()),
+            severity: Some("sev2".into()),
+            commander: Some("jane@example.com".into()),
+            ..Default::default()
+        },
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "incidents create failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::incidents::timeline_add(&cfg, "abc-123", "Rolled back").await;
+    assert!(result.is_ok(), "timeline add failed: {:?}", result.err());
+    let result = crate::commands::incidents::update(
+        &cfg,
+        "abc-123",
+        crate::commands::incidents::IncidentChanges {
+            state: Some("resolved".into()),
+            ..Default::default()
+        },
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "incidents update failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::incidents::update(
+        &cfg,
+        "abc-123",
+        crate::commands::incidents::IncidentChanges::default(),
+    )
+    .await;
+    assert!(result.is_err());
+    create.assert_async().await;
+    update.assert_async().await;
+    note.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_incidents_create_with_impact_scope_is_customer_impacted() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let create = server
+        .mock("POST", "/api/v2/incidents")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({"data": {
+            "attributes": {"customer_impacted": true, "customer_impact_scope": "EU checkout"}
+        }})))
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "abc-123", "type": "incidents"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::incidents::create(
+        &cfg,
+        crate::commands::incidents::IncidentChanges {
+            title: Some("Checkout errors".into()),
+            customer_impact_scope: Some("EU checkout".into()),
+            ..Default::default()
+        },
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "incidents create failed: {:?}",
+        result.err()
+    );
+    create.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_incidents_watch_once() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _list = server
+        .mock("GET", "/api/v2/incidents")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [
+                {"id": "a", "type": "incidents", "attributes": {"public_id": 7,
+                    "title": "Checkout errors", "severity": "SEV-2", "state": "active",
+                    "created": "2024-01-02T03:00:00Z"}},
+                {"id": "b", "type": "incidents", "attributes": {"public_id": 8,
+                    "title": "Old news", "severity": "SEV-2", "state": "resolved",
+                    "created": "2024-01-02T03:00:00Z"}}
+            ]}"#,
+        )
+        .create_async()
+        .await;
+    let event = server
+        .mock("POST", "/api/v2/events")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "data": {"attributes": {"aggregation_key": "incident-escalation-a"}}
+        })))
+        .with_status(202)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "e1"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let state = std::env::temp_dir().join("pup_test_incidents_watch.json");
+    let _ = std::fs::remove_file(&state);
+    let opts = || crate::commands::incidents::WatchOptions {
+        rules: crate::commands::incidents::parse_escalation_rules(&["sev2=30m".into()]).unwrap(),
+        notify: vec!["@pagerduty-sre".into()],
+        once: true,
+        state_file: Some(state.to_string_lossy().into_owned()),
+        ..Default::default()
+    };
+    let result = crate::commands::incidents::watch(&cfg, opts()).await;
+    assert!(result.is_ok(), "incidents watch failed: {:?}", result.err());
+    // The second pass reads the state file and doesn't notify again.
+    let result = crate::commands::incidents::watch(&cfg, opts()).await;
+    assert!(result.is_ok(), "incidents watch failed: {:?}", result.err());
+    event.assert_async().await;
+    assert!(std::fs::read_to_string(&state).unwrap().contains("a:sev2"));
+    let _ = std::fs::remove_file(&state);
+    cleanup_env();
+}
+
+// --- On-Call ---
+#[tokio::test]
+async fn test_on_call_teams_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::on_call::teams_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_on_call_teams_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::on_call::teams_get(&cfg, "t1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_on_call_teams_delete() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{}"#).await;
+    let _ = crate::commands::on_call::teams_delete(&cfg, "t1").await;
+    cleanup_env();
+}
+
+// --- Security ---
+#[tokio::test]
+async fn test_security_rules_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::security::rules_list(&cfg).await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_status_pages_delete() {
+async fn test_security_rules_disable_by_tags_skips_disabled_and_other_teams() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::status_pages::pages_delete(&cfg, "p1").await;
+    let _list = s
+        .mock("GET", "/api/v2/security_monitoring/rules")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [
+                {"id": "r1", "name": "A", "isEnabled": true, "tags": ["team:pay"]},
+                {"id": "r2", "name": "B", "isEnabled": false, "tags": ["team:pay"]},
+                {"id": "r3", "name": "C", "isEnabled": true, "tags": ["team:web"]}
+            ]}"#,
+        )
+        .create_async()
+        .await;
+    let update = s
+        .mock("PUT", "/api/v2/security_monitoring/rules/r1")
+        .match_body(mockito::Matcher::Json(
+            serde_json::json!({"isEnabled": false}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"id": "r1"}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let untouched = s
+        .mock("PUT", mockito::Matcher::Regex("/rules/r[23]$".into()))
+        .expect(0)
+        .create_async()
+        .await;
+
+    let rules = crate::commands::security::rules_matching(&cfg, &[], &["team:pay".into()])
+        .await
+        .unwrap();
+    assert_eq!(rules.len(), 2);
+    let result = crate::commands::security::rules_set_enabled(&cfg, rules, false).await;
+    assert!(result.is_ok(), "rules disable failed: {:?}", result.err());
+    update.assert_async().await;
+    untouched.assert_async().await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_status_pages_components_list() {
+async fn test_security_rules_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::status_pages::components_list(&cfg, "p1").await;
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::security::rules_get(&cfg, "r1").await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_status_pages_degradations_list() {
+async fn test_security_content_packs_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::status_pages::degradations_list(&cfg).await;
+    let _ = crate::commands::security::content_packs_list(&cfg).await;
     cleanup_env();
 }
+
+// --- Synthetics ---
 #[tokio::test]
-async fn test_status_pages_third_party_list() {
+async fn test_synthetics_tests_trigger_wait_fails_on_blocking_failure() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::status_pages::third_party_list(&cfg, None, false).await;
+    let trigger = s
+        .mock("POST", "/api/v1/synthetics/tests/trigger/ci")
+        .match_body(mockito::Matcher::Json(
+            serde_json::json!({"tests": [{"public_id": "abc-def-ghi"}]}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"batch_id": "b-1", "results": [{"result_id": "r-1", "public_id": "abc-def-ghi"}]}"#,
+        )
+        .create_async()
+        .await;
+    let _batch = s
+        .mock("GET", "/api/v1/synthetics/ci/batch/b-1")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"status": "failed", "results": [
+                {"test_public_id": "abc-def-ghi", "test_name": "Checkout", "status": "failed",
+                 "execution_rule": "blocking", "location": "aws:eu-west-1", "result_id": "r-1"}
+            ]}}"#,
+        )
+        .create_async()
+        .await;
+
+    let result =
+        crate::commands::synthetics::tests_trigger(&cfg, &["abc-def-ghi".to_string()], true, "1m")
+            .await;
+    let err = result.expect_err("a failed blocking result must fail the command");
+    assert!(err.to_string().contains("1 of 1"), "{err}");
+    trigger.assert_async().await;
     cleanup_env();
 }
 
-// --- Cases ---
 #[tokio::test]
-async fn test_cases_search() {
+async fn test_synthetics_tests_create_and_pause() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    let file = std::env::temp_dir().join("pup_test_synthetics_test.json");
+    std::fs::write(
+        &file,
+        r#"{"public_id": "old-id", "monitor_id": 1, "name": "Checkout", "type": "browser", "config": {}}"#,
+    )
+    .unwrap();
+    let create = s
+        .mock("POST", "/api/v1/synthetics/tests/browser")
+        .match_body(mockito::Matcher::Json(
+            serde_json::json!({"name": "Checkout", "type": "browser", "config": {}}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"public_id": "new-id", "name": "Checkout", "type": "browser"}"#)
+        .create_async()
+        .await;
+    let pause = s
+        .mock("PUT", "/api/v1/synthetics/tests/new-id/status")
+        .match_body(mockito::Matcher::Json(
+            serde_json::json!({"new_status": "paused"}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body("true")
+        .create_async()
+        .await;
+
+    let body = format!("@{}", file.display());
+    let result = crate::commands::synthetics::tests_create(&cfg, &body).await;
+    assert!(
+        result.is_ok(),
+        "synthetics tests create failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::synthetics::tests_set_status(&cfg, "new-id", "paused").await;
+    assert!(
+        result.is_ok(),
+        "synthetics tests pause failed: {:?}",
+        result.err()
+    );
+    create.assert_async().await;
+    pause.assert_async().await;
+    let _ = std::fs::remove_file(&file);
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_synthetics_tests_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"tests": []}"#).await;
+    let _ = crate::commands::synthetics::tests_list(&cfg).await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_synthetics_tests_get() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{}"#).await;
+    let _ = crate::commands::synthetics::tests_get(&cfg, "pub1").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_synthetics_locations_list() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"locations": []}"#).await;
+    let _ = crate::commands::synthetics::locations_list(&cfg).await;
+    cleanup_env();
+}
+
+// --- App Keys ---
+#[tokio::test]
+async fn test_app_keys_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::cases::search(&cfg, None, 10).await;
+    let _ = crate::commands::app_keys::list(&cfg, 10, 0, "", "").await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_cases_get() {
+async fn test_app_keys_list_all() {
+    let _lock = lock_env();
+    let mut s = mockito::Server::new_async().await;
+    let cfg = test_config(&s.url());
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::app_keys::list_all(&cfg, 10, 0, "", "").await;
+    cleanup_env();
+}
+#[tokio::test]
+async fn test_app_keys_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::cases::get(&cfg, "case1").await;
+    let _ = crate::commands::app_keys::get(&cfg, "k1").await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_cases_projects_list() {
+async fn test_app_keys_create() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::cases::projects_list(&cfg).await;
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::app_keys::create(&cfg, "test-key", "").await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_cases_projects_get() {
+async fn test_app_keys_update() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::cases::projects_get(&cfg, "proj1").await;
+    let _ = crate::commands::app_keys::update(&cfg, "k1", "new-name", "").await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_cases_projects_delete() {
+async fn test_app_keys_delete() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::cases::projects_delete(&cfg, "proj1").await;
+    let _ = crate::commands::app_keys::delete(&cfg, "k1").await;
     cleanup_env();
 }
 
-// --- Integrations ---
+// --- API Keys ---
 #[tokio::test]
-async fn test_integrations_jira_accounts_list() {
+async fn test_api_keys_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::integrations::jira_accounts_list(&cfg).await;
+    let _ = crate::commands::api_keys::list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_integrations_jira_templates_list() {
+async fn test_api_keys_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::integrations::jira_templates_list(&cfg).await;
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::api_keys::get(&cfg, "k1").await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_integrations_servicenow_instances_list() {
+async fn test_api_keys_delete() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::integrations::servicenow_instances_list(&cfg).await;
+    mock_all(&mut s, r#"{}"#).await;
+    let _ = crate::commands::api_keys::delete(&cfg, "k1").await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_integrations_servicenow_templates_list() {
+async fn test_monitors_history_filters_to_monitor() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::integrations::servicenow_templates_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _monitor = server
+        .mock("GET", "/api/v1/monitor/7")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "group_states".into(),
+            "all".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": 7, "name": "High CPU", "overall_state": "OK", "state": {"groups": {}}}"#,
+        )
+        .create_async()
+        .await;
+    let _first = server
+        .mock("GET", "/api/v1/events")
+        .match_query(mockito::Matcher::AllOf(vec![
+            mockito::Matcher::UrlEncoded("tags".into(), "monitor_id:7".into()),
+            mockito::Matcher::UrlEncoded("page".into(), "0".into()),
+        ]))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"events": [
+                {"monitor_id": 7, "date_happened": 1714564800, "title": "[Triggered on {host:a}] High CPU", "alert_type": "error"},
+                {"monitor_id": 8, "date_happened": 1714564900, "title": "[Triggered] Other", "alert_type": "error"},
+                {"monitor_id": 7, "date_happened": 1714565400, "title": "[Recovered on {host:a}] High CPU", "alert_type": "success"}
+            ]}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    let last = server
+        .mock("GET", "/api/v1/events")
+        .match_query(mockito::Matcher::UrlEncoded("page".into(), "1".into()))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"events": []}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::monitors::history(&cfg, 7, "1d", "now").await;
+    assert!(
+        result.is_ok(),
+        "monitors history failed: {:?}",
+        result.err()
+    );
+    last.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_integrations_slack_list() {
+async fn test_oauth_forbidden_records_scope_hint() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let mut cfg = test_config(&server.url());
+    cfg.access_token = Some("test-token".into());
+    let _mock = server
+        .mock("GET", "/api/v2/logs/config/archives")
+        .with_status(403)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"errors": ["Forbidden"]}"#)
+        .create_async()
+        .await;
+
+    let result = crate::api::get(&cfg, "/api/v2/logs/config/archives", &[]).await;
+    assert!(result.is_err());
+    let hint = crate::auth::scopes::forbidden_hint().expect("403 should record a scope hint");
+    assert!(hint.contains("logs_read_archives"), "{hint}");
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_doctor_validates_api_key() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let mock = server
+        .mock("GET", "/api/v1/validate")
+        .match_header("DD-API-KEY", "test-api-key")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"valid": true}"#)
+        .create_async()
+        .await;
+
+    let result = crate::commands::doctor::run(&cfg).await;
+    assert!(result.is_ok(), "doctor failed: {:?}", result.err());
+    mock.assert_async().await;
+    cleanup_env();
+}
+
+#[tokio::test]
+async fn test_doctor_fails_on_rejected_api_key() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _mock = server
+        .mock("GET", "/api/v1/validate")
+        .with_status(403)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"errors": ["Forbidden"]}"#)
+        .create_async()
+        .await;
+
+    let err = crate::commands::doctor::run(&cfg).await.unwrap_err();
+    assert!(err.to_string().contains("doctor check(s) failed"), "{err}");
+    cleanup_env();
+}
+
+// --- Audit Logs ---
+#[tokio::test]
+async fn test_audit_logs_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::integrations::slack_list(&cfg).await;
+    let _ = crate::commands::audit_logs::list(&cfg, "1h".into(), "now".into(), 10).await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_integrations_webhooks_list() {
+async fn test_audit_logs_export_pages_to_jsonl() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::integrations::webhooks_list(&cfg).await;
+    let event = |id: &str, ts: &str| serde_json::json!({"id": id, "attributes": {"timestamp": ts}});
+    let first = s
+        .mock("POST", "/api/v2/audit/events/search")
+        .match_body(mockito::Matcher::PartialJson(
+            serde_json::json!({"sort": "timestamp", "filter": {"query": "*", "to": "now"}}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            serde_json::json!({
+                "data": [event("a", "2024-05-01T12:00:01Z"), event("b", "2024-05-01T12:00:02Z")],
+                "meta": {"page": {"after": "next"}}
+            })
+            .to_string(),
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    // The first mock is satisfied after one hit, so the cursor request lands here.
+    let second = s
+        .mock("POST", "/api/v2/audit/events/search")
+        .match_body(mockito::Matcher::PartialJson(
+            serde_json::json!({"page": {"cursor": "next"}}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(serde_json::json!({"data": [event("c", "2024-05-01T12:00:03Z")]}).to_string())
+        .expect(1)
+        .create_async()
+        .await;
+
+    let file = std::env::temp_dir().join("pup_test_audit_export.jsonl");
+    let result = crate::commands::audit_logs::export(
+        &cfg,
+        "*".into(),
+        "2024-05-01T00:00:00Z".into(),
+        "now".into(),
+        file.display().to_string(),
+        false,
+        30,
+    )
+    .await;
+    assert!(result.is_ok(), "audit export failed: {:?}", result.err());
+    let written = std::fs::read_to_string(&file).unwrap();
+    let ids: Vec<String> = written
+        .lines()
+        .map(|l| {
+            serde_json::from_str::<serde_json::Value>(l).unwrap()["id"]
+                .as_str()
+                .unwrap()
+                .to_string()
+        })
+        .collect();
+    assert_eq!(ids, vec!["a", "b", "c"]);
+    first.assert_async().await;
+    second.assert_async().await;
+    let _ = std::fs::remove_file(&file);
     cleanup_env();
 }
 
-// --- CI/CD ---
 #[tokio::test]
-async fn test_cicd_pipelines_list() {
+async fn test_audit_logs_export_bounded_window() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::cicd::pipelines_list(&cfg, None, "1h".into(), "now".into(), 10).await;
+    let search = s
+        .mock("POST", "/api/v2/audit/events/search")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "filter": {"from": "2024-05-01T00:00:00+00:00", "to": "2024-05-02T00:00:00+00:00"}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": []}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let file = std::env::temp_dir().join("pup_test_audit_export_bounded.jsonl");
+    let result = crate::commands::audit_logs::export(
+        &cfg,
+        "*".into(),
+        "2024-05-01T00:00:00Z".into(),
+        "2024-05-02T00:00:00Z".into(),
+        file.display().to_string(),
+        false,
+        30,
+    )
+    .await;
+    assert!(result.is_ok(), "audit export failed: {:?}", result.err());
+    assert_eq!(std::fs::read_to_string(&file).unwrap(), "");
+    search.assert_async().await;
+    let _ = std::fs::remove_file(&file);
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_cicd_tests_list() {
+async fn test_audit_logs_export_rejects_bad_bounds() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
+    let s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::cicd::tests_list(&cfg, None, "1h".into(), "now".into(), 10).await;
+    let file = std::env::temp_dir().join("pup_test_audit_export_rejected.jsonl");
+    let _ = std::fs::remove_file(&file);
+    let export = |from: &str, to: &str, follow: bool| {
+        crate::commands::audit_logs::export(
+            &cfg,
+            "*".into(),
+            from.into(),
+            to.into(),
+            file.display().to_string(),
+            follow,
+            30,
+        )
+    };
+
+    let err = export("1h", "30m", true).await.unwrap_err();
+    assert!(err.to_string().contains("--follow"), "{err}");
+    // A timestamp past the representable range is an error, not a panic.
+    let err = export("99999999999999999", "now", false).await.unwrap_err();
+    assert!(err.to_string().contains("out of range"), "{err}");
+    let err = export("1h", "99999999999999999", false).await.unwrap_err();
+    assert!(err.to_string().contains("out of range"), "{err}");
+    assert!(!file.exists(), "no output file should be created");
     cleanup_env();
 }
 
-// --- Fleet ---
+// --- Users ---
 #[tokio::test]
-async fn test_fleet_agents_list() {
+async fn test_users_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::fleet::agents_list(&cfg, None).await;
+    let _ = crate::commands::users::list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_fleet_agents_get() {
+async fn test_users_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::fleet::agents_get(&cfg, "a1").await;
+    let _ = crate::commands::users::get(&cfg, "u1").await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_fleet_agents_versions() {
+async fn test_users_roles_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::fleet::agents_versions(&cfg).await;
+    let _ = crate::commands::users::roles_list(&cfg).await;
     cleanup_env();
 }
+
+// --- Usage ---
 #[tokio::test]
-async fn test_fleet_deployments_list() {
+async fn test_usage_summary() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::fleet::deployments_list(&cfg, None).await;
+    mock_all(&mut s, r#"{"usage": []}"#).await;
+    let _ = crate::commands::usage::summary(&cfg, "2024-01".into(), None).await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_fleet_schedules_list() {
+async fn test_usage_report() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::fleet::schedules_list(&cfg).await;
+    let mock = s
+        .mock("GET", "/api/v2/usage/hourly_usage")
+        .match_query(mockito::Matcher::AllOf(vec![
+            mockito::Matcher::UrlEncoded(
+                "filter[timestamp][start]".into(),
+                "2026-03-01T00:00:00Z".into(),
+            ),
+            mockito::Matcher::UrlEncoded(
+                "filter[timestamp][end]".into(),
+                "2026-03-03T00:00:00Z".into(),
+            ),
+            mockito::Matcher::UrlEncoded("filter[product_families]".into(), "logs".into()),
+        ]))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [
+                {"attributes": {"product_family": "logs", "timestamp": "2026-03-01T05:00:00Z",
+                 "measurements": [{"usage_type": "ingested_bytes", "value": 100}]}},
+                {"attributes": {"product_family": "logs", "timestamp": "2026-03-02T05:00:00Z",
+                 "measurements": [{"usage_type": "ingested_bytes", "value": 150}]}}
+            ], "meta": {"pagination": {}}}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+    let result = crate::commands::usage::report(
+        &cfg,
+        "2026-03-01".into(),
+        Some("2026-03-03".into()),
+        "logs",
+        25.0,
+    )
+    .await;
+    assert!(result.is_ok(), "usage report failed: {:?}", result.err());
+    mock.assert_async().await;
     cleanup_env();
 }
 
-// --- Incidents ---
+// --- Infrastructure ---
 #[tokio::test]
-async fn test_incidents_list() {
+async fn test_infrastructure_hosts_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::incidents::list(&cfg, 10).await;
+    mock_all(&mut s, r#"{"host_list": [], "total_returned": 0}"#).await;
+    let _ = crate::commands::infrastructure::hosts_list(&cfg, None, "name".into(), 10).await;
     cleanup_env();
 }
+
+// --- Notebooks ---
 #[tokio::test]
-async fn test_incidents_get() {
+async fn test_notebooks_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::incidents::get(&cfg, "inc1").await;
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::notebooks::list(&cfg).await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_incidents_settings_get() {
+async fn test_notebooks_clone() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::incidents::settings_get(&cfg).await;
+    let _get = s
+        .mock("GET", "/api/v1/notebooks/42")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"id": 42, "type": "notebooks", "attributes": {
+                "name": "Runbook", "status": "published", "time": {"live_span": "1h"},
+                "cells": [{"id": "c1", "type": "notebook_cells", "attributes": {}}]}}}"#,
+        )
+        .create_async()
+        .await;
+    let create = s
+        .mock("POST", "/api/v1/notebooks")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "data": {"attributes": {"name": "Runbook (copy)"}}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": 43, "type": "notebooks"}}"#)
+        .create_async()
+        .await;
+
+    let result = crate::commands::notebooks::clone(&cfg, 42, None, None).await;
+    assert!(result.is_ok(), "notebooks clone failed: {:?}", result.err());
+    create.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_incidents_handles_list() {
+async fn test_notebooks_cells_move_rewrites_cell_order() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::incidents::handles_list(&cfg).await;
+    let cell = |id: &str| {
+        serde_json::json!({"id": id, "type": "notebook_cells", "attributes": {
+            "definition": {"type": "markdown", "text": id}
+        }})
+    };
+    let notebook = serde_json::json!({"data": {"id": 42, "type": "notebooks", "attributes": {
+        "name": "Runbook",
+        "time": {"live_span": "1h"},
+        "cells": [cell("a"), cell("b")]
+    }}});
+    let _get = s
+        .mock("GET", "/api/v1/notebooks/42")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(notebook.to_string())
+        .create_async()
+        .await;
+    let put = s
+        .mock("PUT", "/api/v1/notebooks/42")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "data": {"attributes": {"name": "Runbook", "cells": [cell("b"), cell("a")]}}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(notebook.to_string())
+        .expect(1)
+        .create_async()
+        .await;
+    let edit = crate::commands::notebooks::CellEdit::Move {
+        cell_id: "b".into(),
+        position: 1,
+    };
+    let result = crate::commands::notebooks::cells_edit(&cfg, 42, edit).await;
+    assert!(result.is_ok(), "cells move failed: {:?}", result.err());
+    put.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_incidents_postmortem_templates_list() {
+async fn test_notebooks_cells_list_uses_api_keys_over_oauth() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::incidents::postmortem_templates_list(&cfg).await;
+    let mut cfg = test_config(&s.url());
+    cfg.access_token = Some("token".into());
+    let get = s
+        .mock("GET", "/api/v1/notebooks/42")
+        .match_header("DD-API-KEY", "test-api-key")
+        .match_header("authorization", mockito::Matcher::Missing)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": 42, "attributes": {"cells": []}}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let result = crate::commands::notebooks::cells_list(&cfg, 42).await;
+    assert!(result.is_ok(), "cells list failed: {:?}", result.err());
+    get.assert_async().await;
+
+    cfg.api_key = None;
+    cfg.app_key = None;
+    let err = crate::commands::notebooks::cells_list(&cfg, 42)
+        .await
+        .unwrap_err();
+    assert!(
+        err.to_string().contains("does not accept OAuth tokens"),
+        "{err}"
+    );
     cleanup_env();
 }
 
-// --- On-Call ---
+// --- Downtime ---
 #[tokio::test]
-async fn test_on_call_teams_list() {
+async fn test_downtime_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::on_call::teams_list(&cfg).await;
+    let _ = crate::commands::downtime::list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_on_call_teams_get() {
+async fn test_downtime_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::on_call::teams_get(&cfg, "t1").await;
+    let _ = crate::commands::downtime::get(&cfg, "d1").await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_on_call_teams_delete() {
+async fn test_downtime_apply() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::on_call::teams_delete(&cfg, "t1").await;
+    let mut cfg = test_config(&s.url());
+    cfg.auto_approve = true;
+    let file = std::env::temp_dir().join("pup_test_mute_windows.yaml");
+    std::fs::write(
+        &file,
+        "downtimes:\n  - name: nightly\n    scope: service:batch\n    recurrences:\n      - rrule: FREQ=DAILY\n        duration: 1h\n",
+    )
+    .unwrap();
+    let _list = s
+        .mock("GET", "/api/v2/downtime")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [
+                {"id": "old-1", "type": "downtime", "attributes": {"status": "active", "scope": "env:prod",
+                 "message": "[pup:mute-window=retired]"}},
+                {"id": "manual", "type": "downtime", "attributes": {"status": "active", "scope": "env:dev",
+                 "message": "hands off"}}
+            ]}"#,
+        )
+        .create_async()
+        .await;
+    let create = s
+        .mock("POST", "/api/v2/downtime")
+        .match_body(mockito::Matcher::PartialJsonString(
+            r#"{"data": {"attributes": {"scope": "service:batch", "message": "[pup:mute-window=nightly]"}}}"#
+                .into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "new-1", "type": "downtime"}}"#)
+        .create_async()
+        .await;
+    let cancel = s
+        .mock("DELETE", "/api/v2/downtime/old-1")
+        .with_status(204)
+        .create_async()
+        .await;
+
+    let result = crate::commands::downtime::apply(&cfg, file.to_str().unwrap(), false).await;
+    assert!(result.is_ok(), "downtime apply failed: {:?}", result.err());
+    create.assert_async().await;
+    cancel.assert_async().await;
+    let _ = std::fs::remove_file(&file);
     cleanup_env();
 }
 
-// --- Security ---
+// --- Cost ---
 #[tokio::test]
-async fn test_security_rules_list() {
+async fn test_cost_projected() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::security::rules_list(&cfg).await;
+    let _ = crate::commands::cost::projected(&cfg).await;
     cleanup_env();
 }
+
+// --- Cloud Cost ---
 #[tokio::test]
-async fn test_security_rules_get() {
+async fn test_cloud_cost_status_reports_other_providers_when_one_fails() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::security::rules_get(&cfg, "r1").await;
+    let aws = s
+        .mock("GET", "/api/v2/cost/aws_cur_config")
+        .with_status(403)
+        .with_body(r#"{"errors": ["Forbidden"]}"#)
+        .create_async()
+        .await;
+    let azure = s
+        .mock("GET", "/api/v2/cost/azure_uc_config")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": []}"#)
+        .create_async()
+        .await;
+    let gcp = s
+        .mock("GET", "/api/v2/cost/gcp_uc_config")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"id": "7", "type": "gcp_uc_config", "attributes": {"billing_account_id": "B-1", "status": "active"}}]}"#,
+        )
+        .create_async()
+        .await;
+
+    let result = crate::commands::cloud_cost::status(&cfg, None).await;
+    assert!(
+        result.is_ok(),
+        "cloud-cost status failed: {:?}",
+        result.err()
+    );
+    aws.assert_async().await;
+    azure.assert_async().await;
+    gcp.assert_async().await;
     cleanup_env();
 }
+
+// --- Error Tracking ---
 #[tokio::test]
-async fn test_security_content_packs_list() {
+async fn test_error_tracking_issues_search() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::security::content_packs_list(&cfg).await;
+    let _ = crate::commands::error_tracking::issues_search(&cfg, None, 10).await;
     cleanup_env();
 }
 
-// --- Synthetics ---
+// --- Cloud ---
 #[tokio::test]
-async fn test_synthetics_tests_list() {
+async fn test_cloud_aws_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"tests": []}"#).await;
-    let _ = crate::commands::synthetics::tests_list(&cfg).await;
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::cloud::aws_list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_synthetics_tests_get() {
+async fn test_cloud_gcp_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::synthetics::tests_get(&cfg, "pub1").await;
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::cloud::gcp_list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_synthetics_locations_list() {
+async fn test_cloud_azure_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"locations": []}"#).await;
-    let _ = crate::commands::synthetics::locations_list(&cfg).await;
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::cloud::azure_list(&cfg).await;
     cleanup_env();
 }
 
-// --- App Keys ---
+// --- Organizations ---
 #[tokio::test]
-async fn test_app_keys_list() {
+async fn test_organizations_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::app_keys::list(&cfg, 10, 0, "", "").await;
+    mock_all(&mut s, r#"{"orgs": []}"#).await;
+    let _ = crate::commands::organizations::list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_app_keys_list_all() {
+async fn test_organizations_login_methods_and_idp_metadata() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::app_keys::list_all(&cfg, 10, 0, "", "").await;
+    mock_all(
+        &mut s,
+        r#"{"org": {"public_id": "abc123", "name": "Acme", "settings": {"saml": {"enabled": true}, "saml_idp_metadata_uploaded": true}}}"#,
+    )
+    .await;
+    let result = crate::commands::organizations::login_methods_get(&cfg).await;
+    assert!(
+        result.is_ok(),
+        "login-methods get failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::organizations::idp_metadata_get(&cfg).await;
+    assert!(
+        result.is_ok(),
+        "idp metadata get failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
+
+// --- Restriction Policies ---
 #[tokio::test]
-async fn test_app_keys_get() {
+async fn test_restriction_policies_get_and_update() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::app_keys::get(&cfg, "k1").await;
+    let _get = s
+        .mock("GET", "/api/v2/restriction_policy/dashboard:abc-def")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "dashboard:abc-def", "type": "restriction_policy", "attributes": {"bindings": []}}}"#)
+        .create_async()
+        .await;
+    let update = s
+        .mock("POST", "/api/v2/restriction_policy/dashboard:abc-def")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "data": {"attributes": {"bindings": [{"relation": "editor", "principals": ["team:t1"]}]}}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "dashboard:abc-def", "type": "restriction_policy"}}"#)
+        .create_async()
+        .await;
+
+    let result = crate::commands::restriction_policies::get(&cfg, "dashboard:abc-def").await;
+    assert!(
+        result.is_ok(),
+        "restriction-policies get failed: {:?}",
+        result.err()
+    );
+    let result = crate::commands::restriction_policies::update(
+        &cfg,
+        "dashboard:abc-def",
+        None,
+        vec!["team:t1".into()],
+        vec![],
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "restriction-policies update failed: {:?}",
+        result.err()
+    );
+    update.assert_async().await;
+    let result = crate::commands::restriction_policies::get(&cfg, "abc-def").await;
+    assert!(result.is_err());
     cleanup_env();
 }
+
+// --- Service Catalog ---
 #[tokio::test]
-async fn test_app_keys_create() {
+async fn test_service_catalog_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::app_keys::create(&cfg, "test-key", "").await;
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::service_catalog::list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_app_keys_update() {
+async fn test_service_catalog_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::app_keys::update(&cfg, "k1", "new-name", "").await;
+    let _ = crate::commands::service_catalog::get(&cfg, "svc1").await;
     cleanup_env();
 }
+
+// --- Misc ---
 #[tokio::test]
-async fn test_app_keys_delete() {
+async fn test_misc_ip_ranges() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::app_keys::delete(&cfg, "k1").await;
+    let _ = crate::commands::misc::ip_ranges(&cfg).await;
     cleanup_env();
 }
 
-// --- API Keys ---
-#[tokio::test]
-async fn test_api_keys_list() {
-    let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::api_keys::list(&cfg).await;
-    cleanup_env();
-}
+// --- Data Governance ---
 #[tokio::test]
-async fn test_api_keys_get() {
+async fn test_data_governance_scanner_rules_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::api_keys::get(&cfg, "k1").await;
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::data_governance::scanner_rules_list(&cfg).await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_api_keys_delete() {
+async fn test_data_governance_groups_reorder_sends_version_and_full_order() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::api_keys::delete(&cfg, "k1").await;
+    let config = r#"{"data": {"id": "cfg-1", "type": "sensitive_data_scanner_configuration",
+        "relationships": {"groups": {"data": [{"id": "g1"}, {"id": "g2"}, {"id": "g3"}]}}},
+        "meta": {"version": 7}}"#;
+    let _get = s
+        .mock("GET", "/api/v2/sensitive-data-scanner/config")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(config)
+        .create_async()
+        .await;
+    let patch = s
+        .mock("PATCH", "/api/v2/sensitive-data-scanner/config")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "data": {"id": "cfg-1", "relationships": {"groups": {"data": [
+                {"type": "sensitive_data_scanner_group", "id": "g3"},
+                {"type": "sensitive_data_scanner_group", "id": "g1"},
+                {"type": "sensitive_data_scanner_group", "id": "g2"}
+            ]}}},
+            "meta": {"version": 7}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body("{}")
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::data_governance::groups_reorder(&cfg, &["g3".into()]).await;
+    assert!(result.is_ok(), "groups reorder failed: {:?}", result.err());
+    patch.assert_async().await;
     cleanup_env();
 }
 
-// --- Audit Logs ---
+// --- Investigations ---
 #[tokio::test]
-async fn test_audit_logs_list() {
+async fn test_investigations_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::audit_logs::list(&cfg, "1h".into(), "now".into(), 10).await;
+    let _ = crate::commands::investigations::list(&cfg, 10, 0).await;
     cleanup_env();
 }
-
-// --- Users ---
 #[tokio::test]
-async fn test_users_list() {
+async fn test_investigations_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::users::list(&cfg).await;
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::investigations::get(&cfg, "inv1").await;
     cleanup_env();
 }
+
+// --- Network ---
 #[tokio::test]
-async fn test_users_get() {
+async fn test_network_flows_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::users::get(&cfg, "u1").await;
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::network::flows_list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_users_roles_list() {
+async fn test_network_devices_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::users::roles_list(&cfg).await;
+    let _ = crate::commands::network::devices_list(&cfg).await;
     cleanup_env();
 }
 
-// --- Usage ---
+// --- Code Coverage ---
 #[tokio::test]
-async fn test_usage_summary() {
+async fn test_code_coverage_branch_summary() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"usage": []}"#).await;
-    let _ = crate::commands::usage::summary(&cfg, "2024-01".into(), None).await;
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ =
+        crate::commands::code_coverage::branch_summary(&cfg, "repo".into(), "main".into()).await;
     cleanup_env();
 }
 
-// --- Infrastructure ---
+// --- Codegen ---
 #[tokio::test]
-async fn test_infrastructure_hosts_list() {
+async fn test_codegen_monitor() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"host_list": [], "total_returned": 0}"#).await;
-    let _ = crate::commands::infrastructure::hosts_list(&cfg, None, "name".into(), 10).await;
+    let mut server = mockito::Server::new_async().await;
+    let mut cfg = test_config(&server.url());
+    let get = server
+        .mock("GET", "/api/v1/monitor/123")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"id": 123, "name": "CPU high", "type": "metric alert",
+                "query": "avg(last_5m):avg:system.cpu.user{*} > 90",
+                "overall_state": "OK", "options": {"thresholds": {"critical": 90}}}"#,
+        )
+        .expect(2)
+        .create_async()
+        .await;
+
+    let result = crate::commands::codegen::run(&cfg, "monitor", "123", "terraform").await;
+    assert!(result.is_ok(), "codegen failed: {:?}", result.err());
+    cfg.agent_mode = true;
+    let result = crate::commands::codegen::run(&cfg, "monitor", "123", "python").await;
+    assert!(result.is_ok(), "codegen (agent) failed: {:?}", result.err());
+    get.assert_async().await;
+
+    let result = crate::commands::codegen::run(&cfg, "monitor", "123", "rust").await;
+    assert!(result.is_err());
     cleanup_env();
 }
 
-// --- Notebooks ---
+// --- HAMR ---
 #[tokio::test]
-async fn test_notebooks_list() {
+async fn test_hamr_connections_get() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::notebooks::list(&cfg).await;
+    mock_all(&mut s, r#"{"data": {}}"#).await;
+    let _ = crate::commands::hamr::connections_get(&cfg).await;
     cleanup_env();
 }
 
-// --- Downtime ---
+// --- Static Analysis ---
 #[tokio::test]
-async fn test_downtime_list() {
+async fn test_static_analysis_ast_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::downtime::list(&cfg).await;
+    let _ = crate::commands::static_analysis::ast_list(&cfg).await;
     cleanup_env();
 }
 #[tokio::test]
-async fn test_downtime_get() {
+async fn test_static_analysis_sca_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::downtime::get(&cfg, "d1").await;
+    mock_all(&mut s, r#"{"data": []}"#).await;
+    let _ = crate::commands::static_analysis::sca_list(&cfg).await;
     cleanup_env();
 }
-
-// --- Cost ---
 #[tokio::test]
-async fn test_cost_projected() {
+async fn test_static_analysis_custom_rulesets_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::cost::projected(&cfg).await;
+    let _ = crate::commands::static_analysis::custom_rulesets_list(&cfg).await;
     cleanup_env();
 }
-
-// --- Error Tracking ---
 #[tokio::test]
-async fn test_error_tracking_issues_search() {
+async fn test_static_analysis_coverage_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::error_tracking::issues_search(&cfg, None, 10).await;
+    let _ = crate::commands::static_analysis::coverage_list(&cfg).await;
     cleanup_env();
 }
 
-// --- Cloud ---
+// --- APM ---
 #[tokio::test]
-async fn test_cloud_aws_list() {
+async fn test_apm_services_list() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
     mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::cloud::aws_list(&cfg).await;
+    let _ =
+        crate::commands::apm::services_list(&cfg, "prod".into(), "1h".into(), "now".into()).await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_cloud_gcp_list() {
+async fn test_apm_services_summary_aggregates_by_resource() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::cloud::gcp_list(&cfg).await;
+    let aggregates = s
+        .mock("POST", "/api/v2/spans/analytics/aggregate")
+        .match_body(mockito::Matcher::Regex(
+            r"service:api env:prod @_top_level:1".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": [{"attributes": {"by": {"resource_name": "GET /"}, "compute": {"c0": 10}}}]}"#)
+        .expect(4)
+        .create_async()
+        .await;
+    let result = crate::commands::apm::services_summary(
+        &cfg,
+        "api".into(),
+        "prod".into(),
+        "1h".into(),
+        "now".into(),
+    )
+    .await;
+    assert!(result.is_ok(), "summary failed: {:?}", result.err());
+    aggregates.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_cloud_azure_list() {
+async fn test_apm_entities_list_normalized() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::cloud::azure_list(&cfg).await;
+    mock_all(
+        &mut s,
+        r#"{"data": [{"id": "svc-1", "type": "service", "attributes": {"name": "web"}}]}"#,
+    )
+    .await;
+    let result = crate::commands::apm::entities_list(
+        &cfg,
+        "1h".into(),
+        "now".into(),
+        Some("prod".into()),
+        None,
+        false,
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "apm entities list failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
 
-// --- Organizations ---
 #[tokio::test]
-async fn test_organizations_list() {
+async fn test_apm_flow_map_get_unexpected_shape_falls_back() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"orgs": []}"#).await;
-    let _ = crate::commands::organizations::list(&cfg).await;
+    mock_all(&mut s, r#"{"graph": {"vertices": []}}"#).await;
+    let result = crate::commands::apm::flow_map_get(
+        &cfg,
+        "web".into(),
+        "prod".into(),
+        100,
+        "1h".into(),
+        "now".into(),
+        false,
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "apm flow-map get failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
 
-// --- Service Catalog ---
 #[tokio::test]
-async fn test_service_catalog_list() {
+async fn test_apm_operations_list_without_env() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::service_catalog::list(&cfg).await;
+    mock_all(&mut s, r#"["web.request", "db.query"]"#).await;
+    let result = crate::commands::apm::services_operations(
+        &cfg,
+        "api".into(),
+        None,
+        "1h".into(),
+        "now".into(),
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "apm operations list failed: {:?}",
+        result.err()
+    );
     cleanup_env();
 }
+
+// --- Traces ---
 #[tokio::test]
-async fn test_service_catalog_get() {
+async fn test_traces_logs() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::service_catalog::get(&cfg, "svc1").await;
+    let spans = s
+        .mock("POST", "/api/v2/spans/events/search")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"attributes": {"service": "web", "env": "prod",
+                "start_timestamp": "2026-01-01T00:00:01.000Z",
+                "end_timestamp": "2026-01-01T00:00:02.000Z"}}]}"#,
+        )
+        .create_async()
+        .await;
+    let logs = s
+        .mock("POST", "/api/v2/logs/events/search")
+        .match_body(mockito::Matcher::PartialJsonString(
+            r#"{"filter": {"query": "trace_id:(42 OR 000000000000002a)",
+                "from": "1767225600000", "to": "1767225603000"}}"#
+                .into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": [{"id": "l1", "attributes": {"timestamp": "2026-01-01T00:00:01.500Z", "message": "hi"}}]}"#)
+        .create_async()
+        .await;
+
+    let opts = crate::commands::traces::TraceLogsOptions {
+        trace_id: "42".into(),
+        lookback: "1d".into(),
+        padding: "1s".into(),
+        limit: 100,
+        by_service: false,
+        query: None,
+    };
+    let result = crate::commands::traces::logs(&cfg, opts).await;
+    assert!(result.is_ok(), "traces logs failed: {:?}", result.err());
+    spans.assert_async().await;
+    logs.assert_async().await;
     cleanup_env();
 }
 
-// --- Misc ---
+// --- Logs patterns ---
 #[tokio::test]
-async fn test_misc_ip_ranges() {
+async fn test_logs_pattern() {
     let _lock = lock_env();
     let mut s = mockito::Server::new_async().await;
     let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{}"#).await;
-    let _ = crate::commands::misc::ip_ranges(&cfg).await;
+    mock_all(
+        &mut s,
+        r#"{"data": [
+            {"id": "1", "attributes": {"message": "Connection to db-1 refused"}},
+            {"id": "2", "attributes": {"message": "Connection to db-2 refused"}}
+        ], "meta": {"page": {}}}"#,
+    )
+    .await;
+    let result = crate::commands::logs::pattern(
+        &cfg,
+        "service:api".into(),
+        "1h".into(),
+        "now".into(),
+        100,
+        10,
+        0.5,
+    )
+    .await;
+    assert!(result.is_ok(), "logs pattern failed: {:?}", result.err());
     cleanup_env();
 }
 
-// --- Data Governance ---
+#[test]
+fn test_capabilities_registry_covers_all_commands() {
+    use clap::CommandFactory;
+    let cmd = crate::Cli::command();
+    let missing = crate::commands::capabilities::unregistered(&cmd);
+    assert!(
+        missing.is_empty(),
+        "top-level commands missing from the capability registry: {missing:?}"
+    );
+}
+
+#[test]
+fn test_cli_flags_do_not_collide_with_globals() {
+    use clap::{CommandFactory, Parser};
+    crate::Cli::command().debug_assert();
+    let cli = crate::Cli::try_parse_from([
+        "pup",
+        "config",
+        "profiles",
+        "set",
+        "staging",
+        "--site",
+        "datadoghq.eu",
+        "--api-key",
+        "k",
+    ])
+    .unwrap();
+    assert_eq!(cli.site.as_deref(), Some("datadoghq.eu"));
+    assert_eq!(cli.api_key.as_deref(), Some("k"));
+}
+
+#[test]
+fn test_capabilities_declare_every_leaf() {
+    use crate::commands::capabilities::{access, declared_access, lookup, Access};
+    use clap::CommandFactory;
+
+    fn leaves(cmd: &clap::Command, path: &mut Vec<String>, out: &mut Vec<Vec<String>>) {
+        let subs: Vec<&clap::Command> = cmd
+            .get_subcommands()
+            .filter(|s| s.get_name() != "help")
+            .collect();
+        if subs.is_empty() {
+            out.push(path.clone());
+        }
+        for sub in subs {
+            path.push(sub.get_name().to_string());
+            leaves(sub, path, out);
+            path.pop();
+        }
+    }
+    let mut all = Vec::new();
+    leaves(&crate::Cli::command(), &mut Vec::new(), &mut all);
+
+    // Every leaf that calls the API must be declared, so a new mutating
+    // command can't fall through to read-only.
+    let undeclared: Vec<String> = all
+        .iter()
+        .map(|p| p.iter().map(String::as_str).collect::<Vec<_>>())
+        .filter(|p| lookup(p[0]).is_some_and(|d| !d.endpoints.is_empty()))
+        .filter(|p| declared_access(p).is_none())
+        .map(|p| p.join(" "))
+        .collect();
+    assert!(
+        undeclared.is_empty(),
+        "leaf commands without declared access (add them to the verb lists or LEAF_ACCESS): {undeclared:?}"
+    );
+
+    for path in [
+        "cases comment",
+        "cases jira create-issue",
+        "cases jira link",
+        "cases jira unlink",
+        "cases move",
+        "cases priority",
+        "cases servicenow create-ticket",
+        "cases status",
+        "dashboards lists add-items",
+        "dashboards lists remove-items",
+        "dashboards shares revoke",
+        "incidents create-ticket",
+        "integrations webhooks test",
+        "notebooks cells append",
+        "notebooks cells move",
+        "security rules enable",
+        "tags bulk-add",
+        "tags bulk-remove",
+    ] {
+        let words: Vec<&str> = path.split(' ').collect();
+        assert!(
+            all.iter().any(|p| p.join(" ") == path),
+            "{path} is not a command"
+        );
+        assert_ne!(access(&words), Access::Read, "{path} modifies state");
+    }
+}
+
+#[test]
+fn test_core_contract_covers_real_commands() {
+    use clap::CommandFactory;
+    fn leaves(cmd: &clap::Command, path: &mut Vec<String>, out: &mut Vec<String>) {
+        let subs: Vec<&clap::Command> = cmd
+            .get_subcommands()
+            .filter(|s| s.get_name() != "help")
+            .collect();
+        if subs.is_empty() {
+            out.push(path.join(" "));
+        }
+        for sub in subs {
+            path.push(sub.get_name().to_string());
+            leaves(sub, path, out);
+            path.pop();
+        }
+    }
+    let mut all = Vec::new();
+    leaves(&crate::Cli::command(), &mut Vec::new(), &mut all);
+    let covered = crate::core_contract::COVERED_COMMANDS;
+    for command in covered {
+        assert!(
+            all.contains(&command.to_string()),
+            "{command} is not a command"
+        );
+    }
+    let with_schema: Vec<&String> = all
+        .iter()
+        .filter(|c| crate::core_contract::data_schema(c).is_some())
+        .collect();
+    assert_eq!(with_schema.len(), covered.len(), "{with_schema:?}");
+}
+
+#[test]
+fn test_examples_templates_name_real_commands() {
+    use clap::CommandFactory;
+    let cmd = crate::Cli::command();
+    for t in crate::commands::examples::TEMPLATES {
+        let path = crate::commands::examples::command_path(&cmd, t.template);
+        assert_eq!(path.join(" "), t.command, "template {:?}", t.template);
+    }
+    let all = crate::commands::examples::all_examples(&cmd);
+    assert!(all.len() > crate::commands::examples::TEMPLATES.len());
+}
+
 #[tokio::test]
-async fn test_data_governance_scanner_rules_list() {
+async fn test_integrations_webhooks_create_injects_secret() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::data_governance::scanner_rules_list(&cfg).await;
-    cleanup_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let mock = server
+        .mock(
+            "POST",
+            "/api/v1/integration/webhooks/configuration/webhooks",
+        )
+        .match_body(mockito::Matcher::Regex(
+            r#"Authorization\\":\\"Bearer s3cr3t"#.into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"name": "deploys", "custom_headers": "{\"Authorization\":\"Bearer s3cr3t\"}"}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::integrations::webhooks_create(
+        &cfg,
+        "deploys",
+        "https://hooks.example.com/in",
+        None,
+        &["Authorization: Bearer {{secret}}".to_string()],
+        "json",
+        Some("s3cr3t".to_string()),
+    )
+    .await;
+    assert!(result.is_ok(), "webhooks create failed: {:?}", result.err());
+    mock.assert_async().await;
+}
+
+#[tokio::test]
+async fn test_restriction_policy_preflight_names_editors() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let mocks = [
+        (
+            "/api/v2/current_user",
+            r#"{"data": {"id": "u1", "relationships": {
+                    "org": {"data": {"id": "o1"}},
+                    "roles": {"data": [{"id": "r1", "type": "roles"}]}}}}"#,
+        ),
+        (
+            "/api/v2/users/u1/permissions",
+            r#"{"data": [{"attributes": {"name": "monitors_write"}}]}"#,
+        ),
+        (
+            "/api/v2/users/u1/memberships",
+            r#"{"data": [{"relationships": {"team": {"data": {"id": "t1"}}}}]}"#,
+        ),
+        (
+            "/api/v2/restriction_policy/monitor:123",
+            r#"{"data": {"attributes": {"bindings": [
+                    {"relation": "editor", "principals": ["team:t2"]}]}}}"#,
+        ),
+        (
+            "/api/v1/monitor/123",
+            r#"{"id": 123, "restricted_roles": null}"#,
+        ),
+        (
+            "/api/v2/team/t2",
+            r#"{"data": {"attributes": {"name": "Payments"}}}"#,
+        ),
+    ];
+    let mut handles = Vec::new();
+    for (path, body) in mocks {
+        handles.push(
+            server
+                .mock("GET", path)
+                .with_status(200)
+                .with_header("content-type", "application/json")
+                .with_body(body)
+                .create_async()
+                .await,
+        );
+    }
+
+    let err = crate::commands::restriction_policies::preflight(&cfg, "monitor", "123")
+        .await
+        .unwrap_err()
+        .to_string();
+    assert!(
+        err.starts_with("preflight: you cannot modify monitor 123"),
+        "{err}"
+    );
+    assert!(err.contains("Payments (team:t2)"), "{err}");
+}
+
+#[tokio::test]
+async fn test_find_tolerates_failed_type() {
+    let _lock = lock_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _monitors = server
+        .mock("GET", "/api/v1/monitor")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "name".into(),
+            "checkout".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"[{"id": 1, "name": "Checkout latency"}]"#)
+        .create_async()
+        .await;
+    let _dashboards = server
+        .mock("GET", "/api/v1/dashboard")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"dashboards": [{"id": "abc", "title": "Checkout"}, {"id": "def", "title": "Cart"}]}"#)
+        .create_async()
+        .await;
+    let _synthetics = server
+        .mock("GET", "/api/v1/synthetics/tests")
+        .with_status(403)
+        .with_body(r#"{"errors": ["Forbidden"]}"#)
+        .create_async()
+        .await;
+
+    let types = vec!["monitor,dashboard".to_string(), "synthetic".to_string()];
+    let result = crate::commands::find::run(&cfg, "checkout", &types, 50).await;
+    assert!(result.is_ok(), "find failed: {:?}", result.err());
+
+    let only_synthetics = vec!["synthetic".to_string()];
+    let err = crate::commands::find::run(&cfg, "checkout", &only_synthetics, 50)
+        .await
+        .unwrap_err();
+    assert!(
+        err.to_string().contains("failed to search synthetics"),
+        "{err}"
+    );
 }
 
-// --- Investigations ---
 #[tokio::test]
-async fn test_investigations_list() {
+async fn test_users_invite_batch_reports_each_email() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::investigations::list(&cfg, 10, 0).await;
-    cleanup_env();
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _roles = server
+        .mock("GET", "/api/v2/roles")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": [{"id": "r-std", "attributes": {"name": "Datadog Standard Role"}}]}"#,
+        )
+        .create_async()
+        .await;
+    let _created = server
+        .mock("POST", "/api/v2/users")
+        .match_body(mockito::Matcher::Regex("ana@example.com".into()))
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "u-ana", "type": "users"}}"#)
+        .create_async()
+        .await;
+    let _conflict = server
+        .mock("POST", "/api/v2/users")
+        .match_body(mockito::Matcher::Regex("bo@example.com".into()))
+        .with_status(409)
+        .with_body(r#"{"errors": ["User already exists"]}"#)
+        .create_async()
+        .await;
+    let invitations = server
+        .mock("POST", "/api/v2/user_invitations")
+        .match_body(mockito::Matcher::Regex("u-ana".into()))
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": []}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let emails = vec!["ana@example.com".to_string(), "bo@example.com".to_string()];
+    let err =
+        crate::commands::users::invite(&cfg, &emails, None, &["Datadog Standard Role".into()])
+            .await
+            .unwrap_err();
+    assert_eq!(err.to_string(), "1 of 2 invitations failed");
+    invitations.assert_async().await;
 }
+
 #[tokio::test]
-async fn test_investigations_get() {
+async fn test_tags_bulk_remove_resolves_filter_and_updates_each_host() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::investigations::get(&cfg, "inv1").await;
-    cleanup_env();
+    let mut server = mockito::Server::new_async().await;
+    let mut cfg = test_config(&server.url());
+    cfg.auto_approve = true;
+    let _hosts = server
+        .mock("GET", "/api/v1/hosts")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "filter".into(),
+            "env:staging".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"host_list": [{"host_name": "web-1"}, {"host_name": "web-2"}]}"#)
+        .create_async()
+        .await;
+    let _web1 = server
+        .mock("GET", "/api/v1/tags/hosts/web-1")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"host": "web-1", "tags": ["env:staging", "team:web"]}"#)
+        .create_async()
+        .await;
+    let _web2 = server
+        .mock("GET", "/api/v1/tags/hosts/web-2")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"host": "web-2", "tags": ["env:staging"]}"#)
+        .create_async()
+        .await;
+    let updated = server
+        .mock("PUT", "/api/v1/tags/hosts/web-1")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "source".into(),
+            "users".into(),
+        ))
+        .match_body(mockito::Matcher::Json(
+            serde_json::json!({"host": "web-1", "tags": ["team:web"]}),
+        ))
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"host": "web-1", "tags": ["team:web"]}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let deleted = server
+        .mock("DELETE", "/api/v1/tags/hosts/web-2")
+        .match_query(mockito::Matcher::Any)
+        .with_status(204)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::tags::bulk(
+        &cfg,
+        crate::commands::tags::BulkOp::Remove,
+        &["env".into()],
+        Some("env:staging"),
+        None,
+        4,
+    )
+    .await;
+    assert!(result.is_ok(), "bulk remove failed: {:?}", result.err());
+    updated.assert_async().await;
+    deleted.assert_async().await;
 }
 
-// --- Network ---
 #[tokio::test]
-async fn test_network_flows_list() {
+async fn test_ip_allowlist_update_rotates_cidr() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::network::flows_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _current = server
+        .mock("GET", "/api/v2/ip_allowlist")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"type": "ip_allowlist", "attributes": {"enabled": true, "entries": [
+                {"data": {"type": "ip_allowlist_entry", "attributes": {"cidr_block": "198.51.100.7/32", "note": "old office"}}}
+            ]}}}"#,
+        )
+        .create_async()
+        .await;
+    let patched = server
+        .mock("PATCH", "/api/v2/ip_allowlist")
+        .match_body(mockito::Matcher::Json(serde_json::json!({
+            "data": {"type": "ip_allowlist", "attributes": {"enabled": true, "entries": [
+                {"data": {"type": "ip_allowlist_entry", "attributes": {"cidr_block": "203.0.113.9/32", "note": "new office"}}}
+            ]}}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"type": "ip_allowlist", "attributes": {"enabled": true, "entries": []}}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let changes = crate::commands::ip_allowlist::AllowlistChanges {
+        add: vec!["203.0.113.9".into()],
+        remove: vec!["198.51.100.7".into()],
+        note: Some("new office".into()),
+        enabled: None,
+    };
+    let result = crate::commands::ip_allowlist::update(&cfg, &changes, false).await;
+    assert!(
+        result.is_ok(),
+        "ip-allowlist update failed: {:?}",
+        result.err()
+    );
+    patched.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_network_devices_list() {
+async fn test_cloud_aws_get_by_account_id() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::network::devices_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _lookup = server
+        .mock("GET", "/api/v2/integration/aws/accounts")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "aws_account_id".into(),
+            "123456789012".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": [{"id": "cfg-b", "type": "account", "attributes": {"aws_account_id": "123456789012"}}]}"#)
+        .create_async()
+        .await;
+    let get = server
+        .mock("GET", "/api/v2/integration/aws/accounts/cfg-b")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "cfg-b", "type": "account", "attributes": {"aws_account_id": "123456789012"}}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::cloud::aws_get(&cfg, "123456789012").await;
+    assert!(result.is_ok(), "aws get failed: {:?}", result.err());
+    get.assert_async().await;
     cleanup_env();
 }
 
-// --- Code Coverage ---
 #[tokio::test]
-async fn test_code_coverage_branch_summary() {
+async fn test_cloud_azure_delete_sends_body() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ =
-        crate::commands::code_coverage::branch_summary(&cfg, "repo".into(), "main".into()).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let tenant = "00000000-0000-0000-0000-000000000001";
+    let client = "00000000-0000-0000-0000-000000000002";
+    let deleted = server
+        .mock("DELETE", "/api/v1/integration/azure")
+        .match_body(mockito::Matcher::Json(
+            serde_json::json!({"tenant_name": tenant, "client_id": client}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body("{}")
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::cloud::azure_delete(&cfg, tenant, client).await;
+    assert!(result.is_ok(), "azure delete failed: {:?}", result.err());
+    deleted.assert_async().await;
     cleanup_env();
 }
 
-// --- HAMR ---
 #[tokio::test]
-async fn test_hamr_connections_get() {
+async fn test_integrations_webhooks_test_fires_sample_event() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": {}}"#).await;
-    let _ = crate::commands::hamr::connections_get(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let hook_url = format!("{}/hook", server.url());
+    let _webhook = server
+        .mock(
+            "GET",
+            "/api/v1/integration/webhooks/configuration/webhooks/deploys",
+        )
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            serde_json::json!({
+                "name": "deploys",
+                "url": hook_url,
+                "payload": r#"{"title": "$EVENT_TITLE"}"#,
+                "custom_headers": r#"{"X-Source": "datadog"}"#,
+                "encode_as": "json"
+            })
+            .to_string(),
+        )
+        .create_async()
+        .await;
+    let fired = server
+        .mock("POST", "/hook")
+        .match_header("x-source", "datadog")
+        .match_body(mockito::Matcher::Json(
+            serde_json::json!({"title": "[Triggered] Test alert from pup"}),
+        ))
+        .with_status(200)
+        .with_body("ok")
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::integrations::webhooks_test(&cfg, "deploys", None, None).await;
+    assert!(result.is_ok(), "webhooks test failed: {:?}", result.err());
+    fired.assert_async().await;
     cleanup_env();
 }
 
-// --- Static Analysis ---
 #[tokio::test]
-async fn test_static_analysis_ast_list() {
+async fn test_integrations_opsgenie_update_by_name() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::static_analysis::ast_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let _list = server
+        .mock("GET", "/api/v2/integration/opsgenie/services")
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": [{"id": "svc-1", "type": "opsgenie_service", "attributes": {"name": "sre", "region": "us"}}]}"#)
+        .create_async()
+        .await;
+    let updated = server
+        .mock("PATCH", "/api/v2/integration/opsgenie/services/svc-1")
+        .match_body(mockito::Matcher::Json(serde_json::json!({
+            "data": {"id": "svc-1", "type": "opsgenie_service", "attributes": {"region": "eu"}}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "svc-1", "type": "opsgenie_service", "attributes": {"name": "sre", "region": "eu"}}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let changes = crate::commands::integrations::OpsgenieService {
+        region: Some("eu".into()),
+        ..Default::default()
+    };
+    let result =
+        crate::commands::integrations::opsgenie_services_update(&cfg, "sre", &changes).await;
+    assert!(
+        result.is_ok(),
+        "opsgenie services update failed: {:?}",
+        result.err()
+    );
+    updated.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_static_analysis_sca_list() {
+async fn test_on_call_schedules_update_fills_id() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::static_analysis::sca_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let file = std::env::temp_dir().join("pup_test_on_call_schedule_update.json");
+    std::fs::write(
+        &file,
+        r#"{"data": {"type": "schedules", "attributes": {"name": "SRE primary", "time_zone": "UTC"}}}"#,
+    )
+    .unwrap();
+    let updated = server
+        .mock("PUT", "/api/v2/on-call/schedules/sch-1")
+        .match_body(mockito::Matcher::PartialJson(
+            serde_json::json!({"data": {"id": "sch-1", "type": "schedules"}}),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "sch-1", "type": "schedules"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::on_call::update(
+        &cfg,
+        crate::commands::on_call::SCHEDULES,
+        "sch-1",
+        file.to_str().unwrap(),
+    )
+    .await;
+    assert!(
+        result.is_ok(),
+        "schedules update failed: {:?}",
+        result.err()
+    );
+    updated.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_static_analysis_custom_rulesets_list() {
+async fn test_on_call_who() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::static_analysis::custom_rulesets_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let team_id = "00000000-0000-0000-0000-0000000000aa";
+    let _schedules = server
+        .mock("GET", "/api/v2/on-call/schedules")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            serde_json::json!({"data": [{
+                "id": "sch-1",
+                "type": "schedules",
+                "attributes": {"name": "SRE primary"},
+                "relationships": {"teams": {"data": [{"id": team_id, "type": "teams"}]}}
+            }, {
+                "id": "sch-2",
+                "type": "schedules",
+                "attributes": {"name": "SRE secondary"},
+                "relationships": {"teams": {"data": [{"id": team_id, "type": "teams"}]}}
+            }]})
+            .to_string(),
+        )
+        .create_async()
+        .await;
+    // One failing schedule is reported in its row, not fatal.
+    let missing = server
+        .mock("GET", "/api/v2/on-call/schedules/sch-2/on-call")
+        .match_query(mockito::Matcher::Any)
+        .with_status(404)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"errors": ["Not found"]}"#)
+        .expect(1)
+        .create_async()
+        .await;
+    let on_call = server
+        .mock("GET", "/api/v2/on-call/schedules/sch-1/on-call")
+        .match_query(mockito::Matcher::Any)
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"type": "shifts", "attributes": {"end": "2026-03-09T09:00:00Z"}, "relationships": {"user": {"data": {"id": "u-1", "type": "users"}}}}, "included": [{"type": "users", "id": "u-1", "attributes": {"name": "Ada", "email": "ada@example.com"}}]}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let result = crate::commands::on_call::who(&cfg, team_id).await;
+    assert!(result.is_ok(), "on-call who failed: {:?}", result.err());
+    on_call.assert_async().await;
+    missing.assert_async().await;
     cleanup_env();
 }
+
 #[tokio::test]
-async fn test_static_analysis_coverage_list() {
+async fn test_reference_tables_update_uploads_csv() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ = crate::commands::static_analysis::coverage_list(&cfg).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let csv = std::env::temp_dir().join("pup_test_reference_table.csv");
+    std::fs::write(&csv, "host,team,cost\nweb-1,sre,12\nweb-2,payments,7\n").unwrap();
+    let id = "00000000-0000-0000-0000-0000000000aa";
+    let _list = server
+        .mock("GET", "/api/v2/reference-tables/tables")
+        .match_query(mockito::Matcher::UrlEncoded(
+            "filter[table_name][exact]".into(),
+            "host_owners".into(),
+        ))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(format!(
+            r#"{{"data": [{{"id": "{id}", "type": "reference_table"}}]}}"#
+        ))
+        .create_async()
+        .await;
+    let _get = server
+        .mock(
+            "GET",
+            format!("/api/v2/reference-tables/tables/{id}").as_str(),
+        )
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"attributes": {"table_name": "host_owners", "schema": {
+                "primary_keys": ["host"],
+                "fields": [{"name": "host", "type": "STRING"}, {"name": "cost", "type": "INT32"}]
+            }}}}"#,
+        )
+        .create_async()
+        .await;
+    let upload = server
+        .mock("POST", "/api/v2/reference-tables/uploads")
+        .match_body(mockito::Matcher::PartialJson(serde_json::json!({
+            "data": {"type": "upload", "attributes": {
+                "table_name": "host_owners",
+                "headers": ["host", "team", "cost"],
+                "part_count": 1
+            }}
+        })))
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(format!(
+            r#"{{"data": {{"id": "up-1", "type": "upload", "attributes": {{"part_urls": ["{}/presigned/part-1"]}}}}}}"#,
+            server.url()
+        ))
+        .expect(1)
+        .create_async()
+        .await;
+    let part = server
+        .mock("PUT", "/presigned/part-1")
+        .match_header("authorization", mockito::Matcher::Missing)
+        .match_header("dd-api-key", mockito::Matcher::Missing)
+        .match_body("host,team,cost\nweb-1,sre,12\nweb-2,payments,7\n")
+        .with_status(200)
+        .expect(1)
+        .create_async()
+        .await;
+    let updated = server
+        .mock(
+            "PATCH",
+            format!("/api/v2/reference-tables/tables/{id}").as_str(),
+        )
+        .match_body(mockito::Matcher::Json(serde_json::json!({
+            "data": {"type": "reference_table", "attributes": {
+                "schema": {
+                    "primary_keys": ["host"],
+                    "fields": [
+                        {"name": "host", "type": "STRING"},
+                        {"name": "team", "type": "STRING"},
+                        {"name": "cost", "type": "INT32"}
+                    ]
+                },
+                "file_metadata": {"upload_id": "up-1"}
+            }}
+        })))
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(r#"{"data": {"id": "t1", "type": "reference_table"}}"#)
+        .expect(1)
+        .create_async()
+        .await;
+
+    let changes = crate::commands::reference_tables::TableChanges {
+        csv: Some(csv.to_string_lossy().into_owned()),
+        ..Default::default()
+    };
+    let result =
+        crate::commands::reference_tables::update(&cfg, "host_owners", &changes, None).await;
+    let _ = std::fs::remove_file(&csv);
+    assert!(
+        result.is_ok(),
+        "reference-tables update failed: {:?}",
+        result.err()
+    );
+    upload.assert_async().await;
+    part.assert_async().await;
+    updated.assert_async().await;
     cleanup_env();
 }
 
-// --- APM ---
 #[tokio::test]
-async fn test_apm_services_list() {
+async fn test_reference_tables_create_retries_parts_and_waits() {
     let _lock = lock_env();
-    let mut s = mockito::Server::new_async().await;
-    let cfg = test_config(&s.url());
-    mock_all(&mut s, r#"{"data": []}"#).await;
-    let _ =
-        crate::commands::apm::services_list(&cfg, "prod".into(), "1h".into(), "now".into()).await;
+    let mut server = mockito::Server::new_async().await;
+    let cfg = test_config(&server.url());
+    let csv = std::env::temp_dir().join("pup_test_reference_table_create.csv");
+    std::fs::write(&csv, "host,team\nweb-1,sre\n").unwrap();
+    let id = "00000000-0000-0000-0000-0000000000bb";
+    let _upload = server
+        .mock("POST", "/api/v2/reference-tables/uploads")
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(format!(
+            r#"{{"data": {{"id": "up-1", "type": "upload", "attributes": {{"part_urls": ["{}/presigned/part-1"]}}}}}}"#,
+            server.url()
+        ))
+        .create_async()
+        .await;
+    let unavailable = server
+        .mock("PUT", "/presigned/part-1")
+        .with_status(503)
+        .expect(1)
+        .create_async()
+        .await;
+    let part = server
+        .mock("PUT", "/presigned/part-1")
+        .with_status(200)
+        .expect(1)
+        .create_async()
+        .await;
+    let _create = server
+        .mock("POST", "/api/v2/reference-tables/tables")
+        .with_status(201)
+        .with_header("content-type", "application/json")
+        .with_body(format!(
+            r#"{{"data": {{"id": "{id}", "type": "reference_table", "attributes": {{"status": "PROCESSING"}}}}}}"#
+        ))
+        .create_async()
+        .await;
+    let status = server
+        .mock(
+            "GET",
+            format!("/api/v2/reference-tables/tables/{id}").as_str(),
+        )
+        .with_status(200)
+        .with_header("content-type", "application/json")
+        .with_body(
+            r#"{"data": {"attributes": {"status": "ERROR",
+                "file_metadata": {"error_message": "row 2: duplicate primary key"}}}}"#,
+        )
+        .expect(1)
+        .create_async()
+        .await;
+
+    let changes = crate::commands::reference_tables::TableChanges {
+        csv: Some(csv.to_string_lossy().into_owned()),
+        primary_keys: vec!["host".into()],
+        ..Default::default()
+    };
+    let result =
+        crate::commands::reference_tables::create(&cfg, "host_owners", &changes, Some(60)).await;
+    let _ = std::fs::remove_file(&csv);
+    let err = result.unwrap_err();
+    assert!(
+        err.to_string().contains("duplicate primary key"),
+        "unexpected error: {err}"
+    );
+    unavailable.assert_async().await;
+    part.assert_async().await;
+    status.assert_async().await;
     cleanup_env();
 }
@@ -2,6 +2,8 @@ use anyhow::{bail, Result};
 use chrono::Utc;
 use regex::Regex;
 
+use crate::exit_code::Failure;
+
 /// Parses a time string into Unix milliseconds.
 ///
 /// Supported formats:
@@ -25,42 +27,63 @@ pub fn parse_time_to_unix_millis(input: &str) -> Result<i64> {
 
     // Unix timestamp (all digits)
     if !input.is_empty() && input.chars().all(|c| c.is_ascii_digit()) {
-        return Ok(input.parse()?);
+        return input
+            .parse()
+            .map_err(|e| Failure::validation(format!("invalid timestamp {input:?}: {e}")));
     }
 
     // RFC3339 timestamp
     if input.contains('T') {
-        let dt = chrono::DateTime::parse_from_rfc3339(input)?;
+        let dt = chrono::DateTime::parse_from_rfc3339(input)
+            .map_err(|e| Failure::validation(format!("invalid RFC3339 time {input:?}: {e}")))?;
         return Ok(dt.timestamp() * 1000);
     }
 
     // Relative time — strip leading minus
     let stripped = input.trim_start_matches('-').trim();
-
-    let re = Regex::new(
-        r"(?i)^(\d+)\s*(s|sec|secs|second|seconds|m|min|mins|minute|minutes|h|hr|hrs|hour|hours|d|day|days|w|week|weeks)$",
-    )
-    .unwrap();
-
-    if let Some(caps) = re.captures(stripped) {
-        let num: i64 = caps[1].parse()?;
-        let unit = caps[2].to_lowercase();
-        let seconds = match unit.as_str() {
-            "s" | "sec" | "secs" | "second" | "seconds" => num,
-            "m" | "min" | "mins" | "minute" | "minutes" => num * 60,
-            "h" | "hr" | "hrs" | "hour" | "hours" => num * 3600,
-            "d" | "day" | "days" => num * 86400,
-            "w" | "week" | "weeks" => num * 7 * 86400,
-            _ => bail!("unknown time unit: {}", unit),
-        };
+    if let Some(seconds) = relative_seconds(stripped)? {
         // Second-aligned: Unix seconds * 1000 (matches Go behavior)
         return Ok((Utc::now().timestamp() - seconds) * 1000);
     }
 
-    bail!(
+    Err(Failure::validation(format!(
         "unable to parse time: {input:?}\n\
          Expected: now, 1h, 30m, 7d, 5minutes, RFC3339, or Unix timestamp"
+    )))
+}
+
+/// Parses a relative duration ("30s", "15m", "2 hours", "1w") into seconds.
+pub fn parse_duration_secs(input: &str) -> Result<i64> {
+    match relative_seconds(input.trim())? {
+        Some(seconds) => Ok(seconds),
+        None => Err(Failure::validation(format!(
+            "unable to parse duration: {input:?}\n\
+             Expected: 30s, 15m, 2h, 1d, or 1w"
+        ))),
+    }
+}
+
+/// Seconds in a relative time like "5m" or "2 hours"; None if `input` isn't one.
+fn relative_seconds(input: &str) -> Result<Option<i64>> {
+    let re = Regex::new(
+        r"(?i)^(\d+)\s*(s|sec|secs|second|seconds|m|min|mins|minute|minutes|h|hr|hrs|hour|hours|d|day|days|w|week|weeks)$",
     )
+    .unwrap();
+
+    let Some(caps) = re.captures(input) else {
+        return Ok(None);
+    };
+    let num: i64 = caps[1].parse()?;
+    let unit = caps[2].to_lowercase();
+    let seconds = match unit.as_str() {
+        "s" | "sec" | "secs" | "second" | "seconds" => num,
+        "m" | "min" | "mins" | "minute" | "minutes" => num * 60,
+        "h" | "hr" | "hrs" | "hour" | "hours" => num * 3600,
+        "d" | "day" | "days" => num * 86400,
+        "w" | "week" | "weeks" => num * 7 * 86400,
+        _ => bail!("unknown time unit: {}", unit),
+    };
+    Ok(Some(seconds))
 }
 
 /// Convenience: parse to Unix seconds.
@@ -81,15 +104,554 @@ pub fn read_json_file<T: serde::de::DeserializeOwned>(path: &str) -> Result<T> {
         .map_err(|e| anyhow::anyhow!("failed to parse JSON from {path:?}: {e}"))
 }
 
+/// Read a JSON request body given as `--body`: `@path`, `-` for stdin, or a
+/// bare path.
+pub fn read_json_body<T: serde::de::DeserializeOwned>(arg: &str) -> Result<T> {
+    if arg == "-" {
+        let contents = std::io::read_to_string(std::io::stdin())
+            .map_err(|e| anyhow::anyhow!("failed to read stdin: {e}"))?;
+        return serde_json::from_str(&contents)
+            .map_err(|e| anyhow::anyhow!("failed to parse JSON from stdin: {e}"));
+    }
+    read_json_file(arg.strip_prefix('@').unwrap_or(arg))
+}
+
+/// Pagination flags renamed to the standard set: `--limit` (results per
+/// request), `--page` (0-based page number), `--offset` (results to skip),
+/// `--cursor` (continuation token), and `--all`/`--max-items` to follow every
+/// page. The old spellings still parse as clap aliases; this table maps each
+/// one, by command path, to its replacement for the deprecation warning.
+pub const RENAMED_PAGE_FLAGS: &[(&str, &str, &str)] = &[
+    ("app-keys list", "--page-size", "--limit"),
+    ("app-keys list", "--page-number", "--page"),
+    ("cases search", "--page-size", "--limit"),
+    ("cases search", "--page-number", "--page"),
+    ("fleet agents list", "--page-size", "--limit"),
+    ("fleet deployments list", "--page-size", "--limit"),
+    ("infrastructure hosts list", "--count", "--limit"),
+    ("investigations list", "--page-limit", "--limit"),
+    ("investigations list", "--page-offset", "--offset"),
+    ("monitors search", "--per-page", "--limit"),
+    ("on-call teams memberships list", "--page-size", "--limit"),
+    ("on-call teams memberships list", "--page-number", "--page"),
+    ("slos search", "--page-size", "--limit"),
+    ("synthetics tests search", "--count", "--limit"),
+    ("synthetics tests search", "--start", "--offset"),
+];
+
+/// Deprecation warnings for renamed pagination flags in `args`, given the
+/// invoked command path (e.g. `"cases search"`).
+pub fn renamed_flag_warnings(command: &str, args: &[String]) -> Vec<String> {
+    RENAMED_PAGE_FLAGS
+        .iter()
+        .filter(|(cmd, old, _)| {
+            *cmd == command
+                && args
+                    .iter()
+                    .any(|a| a == old || a.strip_prefix(old).is_some_and(|r| r.starts_with('=')))
+        })
+        .map(|(_, old, new)| format!("warning: {old} is deprecated; use {new}"))
+        .collect()
+}
+
+/// Placeholder in a request body, header, or payload that is replaced by the
+/// `--secret-from-env`/`--secret-from-file` value just before sending.
+pub const SECRET_PLACEHOLDER: &str = "{{secret}}";
+
+const REDACTED: &str = "<redacted>";
+
+/// Read the secret named by `--secret-from-env VAR` or `--secret-from-file PATH`.
+/// The value never appears on the command line, so it stays out of shell
+/// history and process listings. A trailing newline in the file is dropped, and
+/// the value is scrubbed from `--debug-http` output.
+pub fn read_secret(from_env: Option<&str>, from_file: Option<&str>) -> Result<Option<String>> {
+    let secret = match (from_env, from_file) {
+        (Some(_), Some(_)) => bail!("pass only one of --secret-from-env and --secret-from-file"),
+        (Some(var), None) => std::env::var(var)
+            .map_err(|_| anyhow::anyhow!("environment variable {var} is not set"))?,
+        (None, Some(path)) => std::fs::read_to_string(path)
+            .map_err(|e| anyhow::anyhow!("failed to read secret file {path:?}: {e}"))?
+            .trim_end_matches(['\n', '\r'])
+            .to_string(),
+        (None, None) => return Ok(None),
+    };
+    if secret.is_empty() {
+        bail!("secret is empty");
+    }
+    #[cfg(not(target_arch = "wasm32"))]
+    crate::http_debug::add_secret(&secret);
+    Ok(Some(secret))
+}
+
+/// Replace every `{{secret}}` in the string values of `value`; returns how
+/// many strings contained it.
+pub fn inject_secret(value: &mut serde_json::Value, secret: &str) -> usize {
+    match value {
+        serde_json::Value::String(s) if s.contains(SECRET_PLACEHOLDER) => {
+            *s = s.replace(SECRET_PLACEHOLDER, secret);
+            1
+        }
+        serde_json::Value::Array(items) => items.iter_mut().map(|v| inject_secret(v, secret)).sum(),
+        serde_json::Value::Object(map) => map.values_mut().map(|v| inject_secret(v, secret)).sum(),
+        _ => 0,
+    }
+}
+
+/// Inject the secret (if any) into a request body, failing when a secret and
+/// the placeholder don't come together.
+pub fn apply_secret(body: &mut serde_json::Value, secret: Option<&str>) -> Result<()> {
+    match secret {
+        Some(secret) => {
+            if inject_secret(body, secret) == 0 {
+                bail!(
+                    "a secret was given but nothing in the request contains {SECRET_PLACEHOLDER}"
+                );
+            }
+        }
+        None => {
+            if body.to_string().contains(SECRET_PLACEHOLDER) {
+                bail!(
+                    "the request contains {SECRET_PLACEHOLDER}; pass --secret-from-env or --secret-from-file"
+                );
+            }
+        }
+    }
+    Ok(())
+}
+
+/// Mask the secret wherever an API response (or error) echoes it back.
+pub fn redact_secret(value: &mut serde_json::Value, secret: &str) {
+    match value {
+        serde_json::Value::String(s) if s.contains(secret) => *s = s.replace(secret, REDACTED),
+        serde_json::Value::Array(items) => items.iter_mut().for_each(|v| redact_secret(v, secret)),
+        serde_json::Value::Object(map) => map.values_mut().for_each(|v| redact_secret(v, secret)),
+        _ => {}
+    }
+}
+
+/// `redact_secret` for error text.
+pub fn redact_secret_text(text: &str, secret: Option<&str>) -> String {
+    match secret {
+        Some(secret) => text.replace(secret, REDACTED),
+        None => text.to_string(),
+    }
+}
+
 /// Parses a UUID string, returning a descriptive error if invalid.
 pub fn parse_uuid(id: &str, label: &str) -> anyhow::Result<uuid::Uuid> {
-    uuid::Uuid::parse_str(id).map_err(|e| anyhow::anyhow!("invalid {label} UUID '{id}': {e}"))
+    uuid::Uuid::parse_str(id)
+        .map_err(|e| Failure::validation(format!("invalid {label} UUID '{id}': {e}")))
+}
+
+/// File-name slug for a resource name: lowercase ASCII alphanumerics joined by
+/// `-`, or `fallback` when nothing usable is left.
+pub fn slug(name: &str, fallback: &str) -> String {
+    let mut out = String::new();
+    for c in name.chars() {
+        if c.is_ascii_alphanumeric() {
+            out.push(c.to_ascii_lowercase());
+        } else if !out.is_empty() && !out.ends_with('-') {
+            out.push('-');
+        }
+    }
+    let out = out.trim_end_matches('-');
+    if out.is_empty() {
+        fallback.into()
+    } else {
+        out.to_string()
+    }
+}
+
+/// One export file name per `(id, name)`: `<slug>.json`, or `<slug>-<id>.json`
+/// when several resources share a slug so none overwrites another.
+pub fn export_file_names(items: &[(String, String)], fallback: &str) -> Vec<String> {
+    let slugs: Vec<String> = items.iter().map(|(_, n)| slug(n, fallback)).collect();
+    slugs
+        .iter()
+        .zip(items)
+        .map(|(s, (id, _))| {
+            if slugs.iter().filter(|o| *o == s).count() > 1 {
+                format!("{s}-{id}.json")
+            } else {
+                format!("{s}.json")
+            }
+        })
+        .collect()
+}
+
+/// `*.json` files directly inside `dir`, sorted by name.
+pub fn json_files(dir: &str) -> Result<Vec<std::path::PathBuf>> {
+    let entries = std::fs::read_dir(dir)
+        .map_err(|e| anyhow::anyhow!("failed to read directory {dir:?}: {e}"))?;
+    let mut files: Vec<std::path::PathBuf> = entries
+        .filter_map(|e| e.ok().map(|e| e.path()))
+        .filter(|p| p.is_file() && p.extension().is_some_and(|x| x == "json"))
+        .collect();
+    files.sort();
+    Ok(files)
+}
+
+fn has_id(value: &serde_json::Value) -> bool {
+    value.get("id").is_some_and(|id| !id.is_null())
+}
+
+/// Files an export left behind earlier: `*.json` files in `dir` with an `id`
+/// that this export did not write, because the resource was renamed (so its
+/// slug changed) or no longer exists. Files without an `id` have never been
+/// imported and are kept.
+pub fn stale_export_files(
+    dir: &str,
+    written: &[std::path::PathBuf],
+) -> Result<Vec<std::path::PathBuf>> {
+    Ok(json_files(dir)?
+        .into_iter()
+        .filter(|path| !written.contains(path))
+        .filter(|path| {
+            std::fs::read_to_string(path)
+                .ok()
+                .and_then(|c| serde_json::from_str::<serde_json::Value>(&c).ok())
+                .is_some_and(|v| has_id(&v))
+        })
+        .collect())
+}
+
+/// Refuse an import where two files carry the same `id`: both would be pushed
+/// to one resource, the last silently winning.
+pub fn check_unique_ids(files: &[(String, serde_json::Value)]) -> Result<()> {
+    let mut seen: std::collections::HashMap<String, &str> = std::collections::HashMap::new();
+    for (file, value) in files.iter().filter(|(_, v)| has_id(v)) {
+        let id = match &value["id"] {
+            serde_json::Value::String(s) => s.clone(),
+            other => other.to_string(),
+        };
+        if let Some(first) = seen.insert(id.clone(), file) {
+            bail!("{first} and {file} both have id {id}; remove one of them");
+        }
+    }
+    Ok(())
+}
+
+// ---------------------------------------------------------------------------
+// Automatic pagination (--all / --max-items)
+// ---------------------------------------------------------------------------
+
+/// Default `--max-items` cap for `--all` so a runaway listing can't exhaust memory.
+pub const DEFAULT_MAX_ITEMS: usize = 10_000;
+
+/// How a list endpoint advances to its next page.
+#[derive(Debug, Clone, Copy)]
+pub enum Paging {
+    /// `page[number]`-style pagination; a page shorter than `size` is the last one.
+    Number { size: usize },
+    /// Cursor pagination; the next cursor is read from the JSON pointer `next`
+    /// (e.g. `/meta/page/after`) and the walk ends when it is absent.
+    Cursor { next: &'static str },
+}
+
+/// The page to request next: 0-based page number and, for cursor APIs, the cursor.
+#[derive(Debug, Clone, Default, PartialEq)]
+pub struct PageRequest {
+    pub number: usize,
+    pub cursor: Option<String>,
+}
+
+/// Items gathered across pages by `collect_pages`.
+#[derive(Debug, Default)]
+pub struct Collected {
+    pub items: Vec<serde_json::Value>,
+    pub pages: usize,
+    /// True when `max_items` stopped the walk before the last page.
+    pub truncated: bool,
+}
+
+/// Page size to request: the endpoint's maximum, but no more than the cap needs.
+pub fn page_size(max_page: usize, max_items: usize) -> usize {
+    if max_items == 0 {
+        max_page
+    } else {
+        max_page.min(max_items)
+    }
+}
+
+/// Follow pages until the API runs out or `max_items` (0 = no cap) is reached.
+/// `fetch` performs one request for the given page; items are read from the
+/// JSON pointer `items` in each response (usually `/data`).
+pub async fn collect_pages<F, Fut>(
+    paging: Paging,
+    items: &str,
+    max_items: usize,
+    mut fetch: F,
+) -> Result<Collected>
+where
+    F: FnMut(PageRequest) -> Fut,
+    Fut: std::future::Future<Output = Result<serde_json::Value>>,
+{
+    let mut out = Collected::default();
+    let mut req = PageRequest::default();
+    loop {
+        let resp = fetch(req.clone()).await?;
+        out.pages += 1;
+        let page = resp
+            .pointer(items)
+            .and_then(|v| v.as_array())
+            .cloned()
+            .unwrap_or_default();
+        let n = page.len();
+        out.items.extend(page);
+
+        let next = match paging {
+            _ if n == 0 => None,
+            Paging::Number { size } => (n >= size).then(|| PageRequest {
+                number: req.number + 1,
+                cursor: None,
+            }),
+            Paging::Cursor { next } => resp
+                .pointer(next)
+                .and_then(|c| c.as_str())
+                .filter(|c| !c.is_empty() && Some(*c) != req.cursor.as_deref())
+                .map(|c| PageRequest {
+                    number: req.number + 1,
+                    cursor: Some(c.to_string()),
+                }),
+        };
+
+        if max_items > 0 && out.items.len() >= max_items {
+            out.truncated = out.items.len() > max_items || next.is_some();
+            out.items.truncate(max_items);
+            return Ok(out);
+        }
+        match next {
+            Some(r) => req = r,
+            None => return Ok(out),
+        }
+    }
+}
+
+/// Print items gathered with `--all` as `{"data": [...]}`. When `--max-items`
+/// cut the walk short, agents get it in metadata and humans get a stderr warning.
+pub fn print_collected(
+    cfg: &crate::config::Config,
+    command: &str,
+    collected: Collected,
+) -> Result<()> {
+    let count = collected.items.len();
+    let next_action = collected.truncated.then(|| {
+        format!(
+            "Stopped at --max-items={count}; raise --max-items (0 removes the cap) or narrow the query"
+        )
+    });
+    let data = serde_json::json!({ "data": collected.items });
+    if cfg.agent_mode {
+        let meta = crate::formatter::Metadata {
+            count: Some(count),
+            truncated: collected.truncated,
+            command: Some(command.to_string()),
+            next_action,
+        };
+        return crate::formatter::format_and_print(&data, cfg, Some(&meta));
+    }
+    if let Some(msg) = &next_action {
+        eprintln!("warning: {command}: {msg}");
+    }
+    crate::formatter::output(cfg, &data)
+}
+
+/// Run `f` over `items` with at most `limit` in flight, returning results in input order.
+#[cfg(not(target_arch = "wasm32"))]
+pub async fn run_bounded<T, R, F, Fut>(items: Vec<T>, limit: usize, f: F) -> Vec<R>
+where
+    F: Fn(T) -> Fut,
+    Fut: std::future::Future<Output = R> + Send + 'static,
+    R: Send + 'static,
+{
+    let semaphore = std::sync::Arc::new(tokio::sync::Semaphore::new(limit.max(1)));
+    let mut set = tokio::task::JoinSet::new();
+    for (i, item) in items.into_iter().enumerate() {
+        let permit = semaphore
+            .clone()
+            .acquire_owned()
+            .await
+            .expect("semaphore is never closed");
+        let fut = f(item);
+        set.spawn(async move {
+            let out = fut.await;
+            drop(permit);
+            (i, out)
+        });
+    }
+    let mut results = Vec::new();
+    while let Some(joined) = set.join_next().await {
+        results.push(joined.expect("bounded task panicked"));
+    }
+    results.sort_by_key(|(i, _)| *i);
+    results.into_iter().map(|(_, r)| r).collect()
+}
+
+/// The browser runtime is single-threaded; run sequentially.
+#[cfg(target_arch = "wasm32")]
+pub async fn run_bounded<T, R, F, Fut>(items: Vec<T>, _limit: usize, f: F) -> Vec<R>
+where
+    F: Fn(T) -> Fut,
+    Fut: std::future::Future<Output = R>,
+{
+    let mut results = Vec::new();
+    for item in items {
+        results.push(f(item).await);
+    }
+    results
 }
 
 #[cfg(test)]
 mod tests {
     use super::*;
 
+    #[test]
+    fn test_renamed_flag_warnings() {
+        let args: Vec<String> = ["pup", "cases", "search", "--page-size=5", "--page", "2"]
+            .iter()
+            .map(|s| s.to_string())
+            .collect();
+        assert_eq!(
+            renamed_flag_warnings("cases search", &args),
+            vec!["warning: --page-size is deprecated; use --limit"]
+        );
+        // --start is only renamed on synthetics tests search.
+        let args = vec!["--start".to_string(), "1h".to_string()];
+        assert!(renamed_flag_warnings("events list", &args).is_empty());
+    }
+
+    #[test]
+    fn test_slug() {
+        assert_eq!(slug("Golden Service", "x"), "golden-service");
+        assert_eq!(slug("  API / Latency (p95)!", "x"), "api-latency-p95");
+        assert_eq!(slug("???", "dashboard"), "dashboard");
+    }
+
+    #[test]
+    fn test_export_file_names_disambiguates_duplicates() {
+        let items = vec![
+            ("abc".to_string(), "Overview".to_string()),
+            ("def".to_string(), "overview".to_string()),
+            ("ghi".to_string(), "Payments".to_string()),
+        ];
+        assert_eq!(
+            export_file_names(&items, "dashboard"),
+            vec!["overview-abc.json", "overview-def.json", "payments.json"]
+        );
+    }
+
+    #[test]
+    fn test_stale_export_files_and_unique_ids() {
+        let dir = std::env::temp_dir().join("pup_test_stale_export_files");
+        let _ = std::fs::remove_dir_all(&dir);
+        std::fs::create_dir_all(&dir).unwrap();
+        let write = |name: &str, body: &str| {
+            let path = dir.join(name);
+            std::fs::write(&path, body).unwrap();
+            path
+        };
+        let current = write("overview.json", r#"{"id": "abc"}"#);
+        let renamed = write("old-overview.json", r#"{"id": "abc"}"#);
+        write("draft.json", r#"{"title": "Not imported yet"}"#);
+        write("notes.txt", "id: xyz");
+        let stale = stale_export_files(dir.to_str().unwrap(), &[current]);
+        let _ = std::fs::remove_dir_all(&dir);
+        assert_eq!(stale.unwrap(), vec![renamed]);
+
+        let files = vec![
+            ("a.json".to_string(), serde_json::json!({"id": 1})),
+            ("b.json".to_string(), serde_json::json!({"title": "new"})),
+            ("c.json".to_string(), serde_json::json!({"title": "new"})),
+        ];
+        assert!(check_unique_ids(&files).is_ok());
+        let mut dup = files;
+        dup.push(("d.json".to_string(), serde_json::json!({"id": 1})));
+        let err = check_unique_ids(&dup).unwrap_err().to_string();
+        assert_eq!(err, "a.json and d.json both have id 1; remove one of them");
+    }
+
+    #[tokio::test]
+    async fn test_collect_pages_number_stops_on_short_page() {
+        let mut seen = Vec::new();
+        let out = collect_pages(Paging::Number { size: 2 }, "/data", 0, |req| {
+            seen.push(req.number);
+            let data = match req.number {
+                0 => serde_json::json!([1, 2]),
+                1 => serde_json::json!([3, 4]),
+                _ => serde_json::json!([5]),
+            };
+            async move { Ok(serde_json::json!({ "data": data })) }
+        })
+        .await
+        .unwrap();
+        assert_eq!(seen, vec![0, 1, 2]);
+        assert_eq!(out.items.len(), 5);
+        assert_eq!(out.pages, 3);
+        assert!(!out.truncated);
+    }
+
+    #[tokio::test]
+    async fn test_collect_pages_cursor_and_cap() {
+        let out = collect_pages(
+            Paging::Cursor {
+                next: "/meta/page/after",
+            },
+            "/data",
+            3,
+            |req| async move {
+                let n = req
+                    .cursor
+                    .as_deref()
+                    .map_or(0, |c| c.parse::<i64>().unwrap());
+                Ok(serde_json::json!({
+                    "data": [n, n + 1],
+                    "meta": {"page": {"after": (n + 2).to_string()}}
+                }))
+            },
+        )
+        .await
+        .unwrap();
+        assert_eq!(out.items, vec![serde_json::json!(0), 1.into(), 2.into()]);
+        assert_eq!(out.pages, 2);
+        assert!(out.truncated);
+    }
+
+    #[tokio::test]
+    async fn test_collect_pages_cursor_ends_without_next() {
+        let out = collect_pages(
+            Paging::Cursor {
+                next: "/meta/page/after",
+            },
+            "/data/attributes/agents",
+            2,
+            |req| async move {
+                Ok(match req.cursor {
+                    None => serde_json::json!({
+                        "data": {"attributes": {"agents": [{"id": 1}]}},
+                        "meta": {"page": {"after": "x"}}
+                    }),
+                    // Repeating the same cursor must not loop forever.
+                    Some(_) => serde_json::json!({
+                        "data": {"attributes": {"agents": [{"id": 2}]}},
+                        "meta": {"page": {"after": "x"}}
+                    }),
+                })
+            },
+        )
+        .await
+        .unwrap();
+        assert_eq!(out.items.len(), 2);
+        assert_eq!(out.pages, 2);
+        assert!(!out.truncated);
+    }
+
+    #[test]
+    fn test_page_size() {
+        assert_eq!(page_size(1000, 0), 1000);
+        assert_eq!(page_size(1000, 50), 50);
+        assert_eq!(page_size(100, 10_000), 100);
+    }
+
     #[test]
     fn test_now() {
         let ms = parse_time_to_unix_millis("now").unwrap();
@@ -149,6 +711,15 @@ mod tests {
         assert!(parse_time_to_unix_millis("").is_err());
     }
 
+    #[test]
+    fn test_parse_duration_secs() {
+        assert_eq!(parse_duration_secs("30m").unwrap(), 1800);
+        assert_eq!(parse_duration_secs(" 2 hours ").unwrap(), 7200);
+        assert_eq!(parse_duration_secs("1w").unwrap(), 604800);
+        assert!(parse_duration_secs("now").is_err());
+        assert!(parse_duration_secs("-5m").is_err());
+    }
+
     #[test]
     fn test_parse_time_to_unix_returns_seconds() {
         let secs = parse_time_to_unix("1700000000000").unwrap();
@@ -221,6 +792,17 @@ mod tests {
         std::fs::remove_file(path).ok();
     }
 
+    #[test]
+    fn test_read_json_body_at_path() {
+        let path = "/tmp/__pup_test_body__.json";
+        std::fs::write(path, r#"{"name": "body"}"#).unwrap();
+        let at: serde_json::Value = read_json_body(&format!("@{path}")).unwrap();
+        let bare: serde_json::Value = read_json_body(path).unwrap();
+        assert_eq!(at, bare);
+        assert_eq!(at["name"], "body");
+        std::fs::remove_file(path).ok();
+    }
+
     #[test]
     fn test_read_json_file_valid() {
         let path = "/tmp/__pup_test_valid__.json";